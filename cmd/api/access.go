@@ -0,0 +1,55 @@
+package main
+
+import (
+	"TSVProcessingService/internal/config"
+	"TSVProcessingService/internal/dto"
+	"encoding/json"
+	"net/http"
+)
+
+// accessRoles - роли API-ключей из access.keys
+type accessRoles struct {
+	defaultRole string
+	keys        map[string]string
+	masked      dto.Fields
+}
+
+// newAccessRoles строит таблицу ролей по конфигурации (проверена при загрузке)
+func newAccessRoles(cfg *config.AccessConfig) *accessRoles {
+	keys := make(map[string]string, len(cfg.Keys))
+	for _, k := range cfg.Keys {
+		keys[k.Key] = k.Role
+	}
+	return &accessRoles{defaultRole: cfg.DefaultRole, keys: keys, masked: dto.Fields(cfg.MaskedFields)}
+}
+
+// requestRole - роль клиента по заголовку X-API-Key; запросы без ключа
+// или с неизвестным ключом получают access.default_role
+func (a *App) requestRole(r *http.Request) string {
+	if role, ok := a.access.keys[r.Header.Get("X-API-Key")]; ok {
+		return role
+	}
+	return a.access.defaultRole
+}
+
+// maskedFields - поля, скрываемые в ответе на запрос (только для viewer)
+func (a *App) maskedFields(r *http.Request) dto.Fields {
+	if a.requestRole(r) != dto.RoleViewer {
+		return nil
+	}
+	return a.access.masked
+}
+
+// withChangeAccess - изменяющие административные запросы (дренаж, флаги,
+// инциденты, replay) недоступны роли viewer
+func (a *App) withChangeAccess(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if a.requestRole(r) == dto.RoleViewer {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusForbidden)
+			json.NewEncoder(w).Encode(map[string]string{"error": "Viewer role cannot change service state"})
+			return
+		}
+		next(w, r)
+	}
+}
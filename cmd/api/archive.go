@@ -0,0 +1,164 @@
+package main
+
+import (
+	"TSVProcessingService/db/sqlc"
+	"TSVProcessingService/internal/archive"
+	"TSVProcessingService/internal/config"
+	"TSVProcessingService/internal/database"
+	"TSVProcessingService/internal/dto"
+	"TSVProcessingService/internal/sorting"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// newArchiveStore - объектное хранилище архива из конфигурации
+func newArchiveStore(cfg *config.ArchiveConfig) archive.ObjectStore {
+	if cfg.Storage == "s3" {
+		return archive.NewS3Store(archive.S3Options{
+			Endpoint:  cfg.S3.Endpoint,
+			Region:    cfg.S3.Region,
+			Bucket:    cfg.S3.Bucket,
+			AccessKey: cfg.S3.AccessKey,
+			SecretKey: cfg.S3.SecretKey,
+			UseSSL:    cfg.S3.UseSSL,
+			Timeout:   cfg.S3.Timeout,
+		})
+	}
+	return archive.NewDirStore(cfg.Dir)
+}
+
+// newArchiver - архивация старых данных; после удаления строк устройства
+// сбрасываются его кэш и статистика
+func (a *App) newArchiver(db *sql.DB, queries *sqlc.Queries, store archive.ObjectStore) *archive.Archiver {
+	cfg := &a.config.Archive
+	return archive.NewArchiver(db, queries, store, archive.Options{
+		OlderThanMonths: cfg.OlderThanMonths,
+		Interval:        cfg.Interval,
+		BatchRows:       cfg.BatchRows,
+		Prefix:          cfg.Prefix,
+		OnArchived: func(unitGuid uuid.UUID) {
+			a.cache.DeletePrefix(unitCachePrefix(unitGuid))
+			a.cache.Delete(statisticsCacheKey)
+		},
+		Jobs: a.jobs,
+		Wait: a.waitForMaintenance,
+	})
+}
+
+// getDeviceDataRange - данные устройства за период (from/to: RFC3339 или
+// YYYY-MM-DD, to - не включительно). С include_archived=true к записям
+// из БД добавляются записи архива Parquet (после них, т.к. архив старше);
+// такие записи помечены "archived": true. Ответ не кэшируется.
+func (a *App) getDeviceDataRange(w http.ResponseWriter, r *http.Request, unitGuid uuid.UUID, pageReq pageRequest, fields dto.Fields, sortFields []sorting.Field) {
+	query := r.URL.Query()
+	var from, to time.Time
+	if raw := query.Get("from"); raw != "" {
+		t, _, err := parseReportTime(raw)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(map[string]string{"error": "from must be RFC3339 or YYYY-MM-DD"})
+			return
+		}
+		from = t
+	}
+	if raw := query.Get("to"); raw != "" {
+		t, dateOnly, err := parseReportTime(raw)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(map[string]string{"error": "to must be RFC3339 or YYYY-MM-DD"})
+			return
+		}
+		if dateOnly {
+			t = t.AddDate(0, 0, 1)
+		}
+		to = t
+	}
+	if !from.IsZero() && !to.IsZero() && !from.Before(to) {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": "from must be before to"})
+		return
+	}
+
+	includeArchived := false
+	switch query.Get("include_archived") {
+	case "", "false":
+	case "true":
+		includeArchived = true
+	default:
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": "include_archived must be true or false"})
+		return
+	}
+	if includeArchived {
+		var msg string
+		switch {
+		case a.archiveReader == nil:
+			msg = "Archive is not enabled"
+		case from.IsZero():
+			msg = "include_archived requires from"
+		case len(sortFields) > 0:
+			msg = "include_archived cannot be combined with sort"
+		}
+		if msg != "" {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(map[string]string{"error": msg})
+			return
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 30*time.Second)
+	defer cancel()
+
+	orderBy := sorting.OrderBy(sortFields, database.DeviceDataSortFields, "created_at DESC")
+	data, total, err := a.store.ListDeviceDataByUnitRange(ctx, unitGuid, from, to, orderBy, int32(pageReq.Limit), int32(pageReq.Offset))
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Failed to fetch device data"})
+		return
+	}
+	items := dto.FromDeviceData(data)
+
+	var archivedTotal int64
+	if includeArchived {
+		archiveTo := to
+		if archiveTo.IsZero() {
+			archiveTo = time.Now()
+		}
+		archived, err := a.archiveReader.Query(ctx, unitGuid, from, archiveTo)
+		if err != nil {
+			a.logger.Error("❌ Failed to read archive of unit", "unit", unitGuid, "error", err)
+			w.WriteHeader(http.StatusBadGateway)
+			json.NewEncoder(w).Encode(map[string]string{"error": "Failed to read archived data"})
+			return
+		}
+		archivedTotal = int64(len(archived))
+
+		// Страница продолжается архивными записями после записей из БД
+		start := int64(pageReq.Offset) - total
+		if start < 0 {
+			start = 0
+		}
+		for i := start; i < archivedTotal && len(items) < pageReq.Limit; i++ {
+			item := dto.FromDeviceDatum(archived[i])
+			item.Archived = true
+			items = append(items, item)
+		}
+		total += archivedTotal
+	}
+	dto.Mask(items, a.maskedFields(r))
+
+	response := struct {
+		pageResponse
+		ArchivedRecords int64 `json:"archived_records"`
+	}{
+		pageResponse:    newPageResponse(dto.Project(items, fields), pageReq, total),
+		ArchivedRecords: archivedTotal,
+	}
+
+	json.NewEncoder(w).Encode(response)
+}
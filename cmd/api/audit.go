@@ -0,0 +1,93 @@
+package main
+
+import (
+	"TSVProcessingService/internal/audit"
+	"context"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// requestActor - инициатор действия через API: "api" или "api:<префикс
+// хеша X-API-Key>" (как источник файлов, поставленных этим клиентом)
+func requestActor(r *http.Request) string {
+	if apiKey := r.Header.Get("X-API-Key"); apiKey != "" {
+		return apiKeySource(apiKey)
+	}
+	return "api"
+}
+
+// recordAudit - запись действия в журнал аудита (если журнал включён).
+// Ошибка записи логируется: действие уже выполнено.
+func (a *App) recordAudit(ctx context.Context, event, subject, actor string, payload interface{}) {
+	if a.audit == nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), 5*time.Second)
+	defer cancel()
+	if _, err := a.audit.Append(ctx, event, subject, actor, payload); err != nil {
+		a.logger.Warn("⚠️ Failed to record audit event", "event", event, "subject", subject, "error", err)
+	}
+}
+
+// exportAudit - выгрузка журнала аудита в NDJSON (запись на строку, по
+// порядку цепочки) для независимой проверки; after_id - продолжение
+// предыдущей выгрузки
+// GET /admin/audit/export?after_id=
+func (a *App) exportAudit(w http.ResponseWriter, r *http.Request) {
+	if a.audit == nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Audit log is not enabled"})
+		return
+	}
+	var afterID int64
+	if v := r.URL.Query().Get("after_id"); v != "" {
+		parsed, err := strconv.ParseInt(v, 10, 64)
+		if err != nil || parsed < 0 {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(map[string]string{"error": "Invalid after_id"})
+			return
+		}
+		afterID = parsed
+	}
+
+	w.Header().Set("Content-Type", "application/x-ndjson")
+	w.Header().Set("Content-Disposition", `attachment; filename="audit-log.ndjson"`)
+	enc := json.NewEncoder(w)
+	enc.SetEscapeHTML(false)
+	if err := a.audit.Export(r.Context(), afterID, func(e audit.Entry) error {
+		return enc.Encode(e)
+	}); err != nil {
+		// Заголовки уже отправлены: обрыв выгрузки виден получателю по неполной цепочке
+		a.logger.Warn("Audit export interrupted", "error", err)
+	}
+}
+
+// verifyAudit - проверка цепочки хешей журнала аудита
+// GET /admin/audit/verify
+func (a *App) verifyAudit(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	if a.audit == nil {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Audit log is not enabled"})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Minute)
+	defer cancel()
+
+	result, err := a.audit.Verify(ctx)
+	if err != nil {
+		a.logger.Error("Audit verification failed", "error", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Failed to read audit log"})
+		return
+	}
+	if !result.Valid {
+		a.logger.Error("❌ Audit chain broken", "entry", *result.BrokenAt, "error", result.Error)
+	}
+	json.NewEncoder(w).Encode(result)
+}
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"TSVProcessingService/internal/dispatch"
+	"TSVProcessingService/internal/watcher"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// backlogResponse - ответ GET /admin/backlog
+type backlogResponse struct {
+	Files      int                    `json:"files"`
+	TotalBytes int64                  `json:"total_bytes"`
+	OldestAge  string                 `json:"oldest_age"`
+	ByReason   map[string]int         `json:"by_reason"`
+	Restored   int                    `json:"restored"`
+	Alarms     []string               `json:"alarms"`
+	Oldest     []watcher.BacklogEntry `json:"oldest"`
+}
+
+// getBacklog - необработанные файлы watch-директории (самые старые первыми)
+// с причиной, по которой каждый из них ещё не обработан
+func (a *App) getBacklog(w http.ResponseWriter, r *http.Request) {
+	limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+	if limit < 1 || limit > 1000 {
+		limit = 50
+	}
+
+	entries := a.watcher.Backlog()
+	if len(entries) > limit {
+		entries = entries[:limit]
+	}
+
+	stats := a.watcher.GetBacklogStats()
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(backlogResponse{
+		Files:      stats.Files,
+		TotalBytes: stats.TotalBytes,
+		OldestAge:  stats.OldestAge.Round(time.Second).String(),
+		ByReason:   stats.ByReason,
+		Restored:   stats.Restored,
+		Alarms:     a.backlogAlarms(),
+		Oldest:     entries,
+	})
+}
+
+// getQueueStats - очередь обработки: глубина, счётчики постановки/выборки
+// и распределение времени ожидания воркера (для планирования мощности)
+func (a *App) getQueueStats(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	response := struct {
+		watcher.QueueStats
+		Assignment string `json:"assignment"`
+		WorkerLoad []int  `json:"worker_load,omitempty"` // файлов в очереди и в работе по воркерам
+	}{
+		QueueStats: a.watcher.QueueStats(),
+		Assignment: dispatch.StrategyShared,
+	}
+	if a.dispatcher != nil {
+		response.Assignment = a.config.Worker.Assignment
+		response.WorkerLoad = a.dispatcher.Load()
+	}
+	json.NewEncoder(w).Encode(response)
+}
+
+// backlogAlarms - список превышенных порогов backlog
+func (a *App) backlogAlarms() []string {
+	cfg := a.config.Backlog
+	stats := a.watcher.GetBacklogStats()
+
+	var alarms []string
+	if cfg.AlertMaxFiles > 0 && stats.Files > cfg.AlertMaxFiles {
+		alarms = append(alarms, fmt.Sprintf("backlog files %d exceed %d",
+			stats.Files, cfg.AlertMaxFiles))
+	}
+	if cfg.AlertMaxBytes > 0 && stats.TotalBytes > cfg.AlertMaxBytes {
+		alarms = append(alarms, fmt.Sprintf("backlog size %d bytes exceeds %d",
+			stats.TotalBytes, cfg.AlertMaxBytes))
+	}
+	if cfg.AlertMaxAge > 0 && stats.OldestAge > cfg.AlertMaxAge {
+		alarms = append(alarms, fmt.Sprintf("oldest backlog file age %v exceeds %v",
+			stats.OldestAge.Round(time.Second), cfg.AlertMaxAge))
+	}
+	return alarms
+}
+
+// checkBacklogAlarms - периодическая проверка порогов backlog
+func (a *App) checkBacklogAlarms() {
+	for _, alarm := range a.backlogAlarms() {
+		a.logger.Warn("🚨 Backlog alarm", "alarm", alarm)
+	}
+}
@@ -0,0 +1,405 @@
+package main
+
+import (
+	"TSVProcessingService/internal/audit"
+	"TSVProcessingService/internal/ingest"
+	"TSVProcessingService/internal/processor"
+	"TSVProcessingService/internal/safepath"
+	"TSVProcessingService/internal/tracing"
+	"TSVProcessingService/internal/watcher"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+)
+
+const (
+	maxBatchFiles      = 100  // файлов в одном запросе
+	maxStoredBatches   = 1000 // хранимых в памяти пакетов (старые вытесняются)
+	minBatchHashPrefix = 8    // минимальная длина префикса хеша
+)
+
+// Состояния файла в пакете
+const (
+	batchFileRejected   = "rejected"   // не прошёл проверку, в очередь не поставлен
+	batchFileQueued     = "queued"     // ждёт воркера
+	batchFileProcessing = "processing" // обрабатывается
+	batchFileDone       = "done"       // обработан, итог - в поле status
+	batchFileFailed     = "failed"     // ошибка обработки
+)
+
+// batchRequest - тело POST /files/process-batch
+type batchRequest struct {
+	Filenames []string `json:"filenames"`
+	Hashes    []string `json:"hashes"` // полный SHA256 или префикс от 8 символов
+
+	ConflictPolicy string  `json:"conflict_policy"` // политика конфликтов для всех файлов пакета
+	SampleEvery    int     `json:"sample_every"`    // выборка строк для всех файлов пакета
+	SamplePercent  float64 `json:"sample_percent"`
+}
+
+// batchFile - итог по одному файлу пакета
+type batchFile struct {
+	Filename string     `json:"filename,omitempty"`
+	Hash     string     `json:"hash,omitempty"`
+	State    string     `json:"state"`
+	Status   string     `json:"status,omitempty"` // статус записи о файле после обработки
+	Error    string     `json:"error,omitempty"`
+	Finished *time.Time `json:"finished_at,omitempty"`
+}
+
+// batch - пакет файлов, поставленных в очередь одним запросом
+type batch struct {
+	ID        string       `json:"batch_id"`
+	CreatedAt time.Time    `json:"created_at"`
+	Files     []*batchFile `json:"files"`
+}
+
+// batchStatus - ответ с состоянием пакета
+type batchStatus struct {
+	ID        string         `json:"batch_id"`
+	CreatedAt time.Time      `json:"created_at"`
+	Total     int            `json:"total"`
+	Pending   int            `json:"pending"` // queued + processing
+	ByState   map[string]int `json:"by_state"`
+	Files     []batchFile    `json:"files"`
+}
+
+// batchRegistry - пакеты обработки в памяти процесса.
+// После перезапуска сервиса состояние пакетов теряется,
+// итоги обработки остаются в записях о файлах.
+type batchRegistry struct {
+	mu      sync.Mutex
+	batches map[string]*batch
+	order   []string
+}
+
+func newBatchRegistry() *batchRegistry {
+	return &batchRegistry{batches: make(map[string]*batch)}
+}
+
+// add сохраняет пакет, вытесняя самый старый при переполнении
+func (r *batchRegistry) add(b *batch) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if len(r.order) >= maxStoredBatches {
+		delete(r.batches, r.order[0])
+		r.order = r.order[1:]
+	}
+	r.batches[b.ID] = b
+	r.order = append(r.order, b.ID)
+}
+
+// update изменяет состояние файла пакета
+func (r *batchRegistry) update(batchID, filename string, fn func(f *batchFile)) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	b, ok := r.batches[batchID]
+	if !ok {
+		return
+	}
+	for _, f := range b.Files {
+		if f.Filename == filename && f.State != batchFileRejected {
+			fn(f)
+			return
+		}
+	}
+}
+
+// status возвращает копию состояния пакета
+func (r *batchRegistry) status(batchID string) (batchStatus, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	b, ok := r.batches[batchID]
+	if !ok {
+		return batchStatus{}, false
+	}
+
+	status := batchStatus{
+		ID:        b.ID,
+		CreatedAt: b.CreatedAt,
+		Total:     len(b.Files),
+		ByState:   make(map[string]int),
+		Files:     make([]batchFile, 0, len(b.Files)),
+	}
+	for _, f := range b.Files {
+		status.ByState[f.State]++
+		if f.State == batchFileQueued || f.State == batchFileProcessing {
+			status.Pending++
+		}
+		status.Files = append(status.Files, *f)
+	}
+	return status, true
+}
+
+// processBatch - постановка в очередь списка файлов по именам или хешам.
+// Каждый файл проверяется отдельно; отклонённые файлы не мешают остальным.
+func (a *App) processBatch(w http.ResponseWriter, r *http.Request) {
+	var req batchRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<20)).Decode(&req); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Invalid request body"})
+		return
+	}
+	if !processor.ValidConflictPolicy(req.ConflictPolicy) {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": "conflict_policy must be one of: append, skip, overwrite, version"})
+		return
+	}
+	sampling := ingest.Sampling{Every: req.SampleEvery, Percent: req.SamplePercent}
+	if err := sampling.Validate(); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+		return
+	}
+	total := len(req.Filenames) + len(req.Hashes)
+	if total == 0 {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": "filenames or hashes are required"})
+		return
+	}
+	if total > maxBatchFiles {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": fmt.Sprintf("Too many files in batch (max %d)", maxBatchFiles),
+		})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 30*time.Second)
+	defer cancel()
+
+	source := "api"
+	if apiKey := r.Header.Get("X-API-Key"); apiKey != "" {
+		source = apiKeySource(apiKey)
+	}
+
+	b := &batch{ID: uuid.New().String(), CreatedAt: time.Now()}
+	files := a.resolveBatchFiles(req)
+	seen := make(map[string]bool, len(files))
+	var queue []watcher.FileInfo
+
+	for _, f := range files {
+		if f.State == batchFileRejected {
+			b.Files = append(b.Files, f)
+			continue
+		}
+		if seen[f.Filename] {
+			f.State = batchFileRejected
+			f.Error = "duplicate file in batch"
+			b.Files = append(b.Files, f)
+			continue
+		}
+		seen[f.Filename] = true
+
+		fileInfo, err := a.validateBatchFile(ctx, f.Filename)
+		if err != nil {
+			f.State = batchFileRejected
+			f.Error = err.Error()
+			b.Files = append(b.Files, f)
+			continue
+		}
+		fileInfo.Source = source
+		fileInfo.BatchID = b.ID
+		fileInfo.ConflictPolicy = req.ConflictPolicy
+		fileInfo.Sampling = sampling
+		f.Hash = fileInfo.Hash
+		f.State = batchFileQueued
+		b.Files = append(b.Files, f)
+		queue = append(queue, fileInfo)
+	}
+
+	// Пакет регистрируется до постановки в очередь, чтобы воркер нашёл его
+	a.batches.add(b)
+	for _, fileInfo := range queue {
+		if err := a.watcher.SendToQueue(fileInfo); err != nil {
+			reason := "processing queue is full"
+			if errors.Is(err, watcher.ErrStopped) {
+				reason = "service is draining"
+			}
+			a.batches.update(b.ID, fileInfo.Name, func(f *batchFile) {
+				f.State = batchFileRejected
+				f.Error = reason
+			})
+		}
+	}
+
+	status, _ := a.batches.status(b.ID)
+	a.logger.Info("Batch queued", "batch", b.ID, "queued", status.ByState[batchFileQueued], "total", status.Total)
+	for _, f := range status.Files {
+		if f.State == batchFileQueued {
+			a.recordAudit(ctx, audit.EventFileReprocess, f.Filename, requestActor(r), map[string]interface{}{
+				"file_hash": f.Hash,
+				"batch_id":  b.ID,
+			})
+		}
+	}
+
+	w.WriteHeader(http.StatusAccepted)
+	json.NewEncoder(w).Encode(status)
+}
+
+// getBatchStatus - состояние пакета и итоги по каждому файлу
+func (a *App) getBatchStatus(w http.ResponseWriter, r *http.Request) {
+	status, ok := a.batches.status(mux.Vars(r)["batch_id"])
+	if !ok {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Batch not found"})
+		return
+	}
+	json.NewEncoder(w).Encode(status)
+}
+
+// resolveBatchFiles сопоставляет хеши файлам watch-директории
+func (a *App) resolveBatchFiles(req batchRequest) []*batchFile {
+	files := make([]*batchFile, 0, len(req.Filenames)+len(req.Hashes))
+	for _, name := range req.Filenames {
+		files = append(files, &batchFile{Filename: name})
+	}
+	if len(req.Hashes) == 0 {
+		return files
+	}
+
+	hashes, err := a.watchDirHashes()
+	for _, prefix := range req.Hashes {
+		f := &batchFile{Hash: prefix, State: batchFileRejected}
+		files = append(files, f)
+
+		prefix = strings.ToLower(prefix)
+		if err != nil {
+			f.Error = "failed to read watch directory"
+			continue
+		}
+		if len(prefix) < minBatchHashPrefix {
+			f.Error = fmt.Sprintf("hash prefix must be at least %d characters", minBatchHashPrefix)
+			continue
+		}
+
+		var matches []string
+		for name, hash := range hashes {
+			if strings.HasPrefix(hash, prefix) {
+				matches = append(matches, name)
+			}
+		}
+		switch len(matches) {
+		case 0:
+			f.Error = "no file with this hash in watch directory"
+		case 1:
+			f.Filename = matches[0]
+			f.State = ""
+		default:
+			f.Error = "hash prefix matches several files"
+		}
+	}
+	return files
+}
+
+// watchDirHashes вычисляет хеши .tsv файлов watch-директории
+func (a *App) watchDirHashes() (map[string]string, error) {
+	entries, err := os.ReadDir(a.config.Directory.WatchPath)
+	if err != nil {
+		return nil, err
+	}
+	hashes := make(map[string]string)
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(strings.ToLower(entry.Name()), ".tsv") {
+			continue
+		}
+		hash, err := ingest.HashFile(filepath.Join(a.config.Directory.WatchPath, entry.Name()))
+		if err != nil {
+			continue
+		}
+		hashes[entry.Name()] = hash
+	}
+	return hashes, nil
+}
+
+// validateBatchFile проверяет, что файл можно поставить в очередь
+func (a *App) validateBatchFile(ctx context.Context, filename string) (watcher.FileInfo, error) {
+	filePath, err := safepath.Join(a.config.Directory.WatchPath, filename)
+	if err != nil {
+		return watcher.FileInfo{}, errors.New("invalid filename")
+	}
+
+	stat, err := os.Stat(filePath)
+	if os.IsNotExist(err) {
+		return watcher.FileInfo{}, errors.New("file not found in watch directory")
+	}
+	if err != nil {
+		return watcher.FileInfo{}, errors.New("failed to access file")
+	}
+	if stat.IsDir() {
+		return watcher.FileInfo{}, errors.New("not a regular file")
+	}
+
+	existing, err := a.queries.GetFileByFilename(ctx, filename)
+	if err == nil {
+		return watcher.FileInfo{}, fmt.Errorf("file already processed (status: %s)", existing.Status.String)
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return watcher.FileInfo{}, errors.New("failed to check file status")
+	}
+
+	hash, err := ingest.HashFile(filePath)
+	if err != nil {
+		return watcher.FileInfo{}, errors.New("failed to calculate file hash")
+	}
+
+	return watcher.FileInfo{
+		Name:      filename,
+		Path:      filePath,
+		Hash:      hash,
+		Size:      stat.Size(),
+		ModTime:   stat.ModTime(),
+		ArrivedAt: time.Now(),
+		Trace:     tracing.FromContext(ctx),
+	}, nil
+}
+
+// batchFileStarted отмечает начало обработки файла пакета
+func (a *App) batchFileStarted(fileInfo watcher.FileInfo) {
+	if fileInfo.BatchID == "" {
+		return
+	}
+	a.batches.update(fileInfo.BatchID, fileInfo.Name, func(f *batchFile) {
+		f.State = batchFileProcessing
+	})
+}
+
+// batchFileFinished сохраняет итог обработки файла пакета
+func (a *App) batchFileFinished(fileInfo watcher.FileInfo, processErr error) {
+	if fileInfo.BatchID == "" {
+		return
+	}
+
+	var status string
+	if processErr == nil {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		if file, err := a.queries.GetFileByFilename(ctx, fileInfo.Name); err == nil {
+			status = file.Status.String
+		}
+		cancel()
+	}
+
+	now := time.Now()
+	a.batches.update(fileInfo.BatchID, fileInfo.Name, func(f *batchFile) {
+		f.Finished = &now
+		if processErr != nil {
+			f.State = batchFileFailed
+			f.Error = processErr.Error()
+			return
+		}
+		f.State = batchFileDone
+		f.Status = status
+	})
+}
@@ -0,0 +1,162 @@
+package main
+
+import (
+	"TSVProcessingService/internal/audit"
+	"TSVProcessingService/internal/clock"
+	"TSVProcessingService/internal/watcher"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// setupBatchApp - приложение с watch-директорией, очередью на queueSize
+// файлов и журналом аудита
+func setupBatchApp(t *testing.T, queueSize int, files ...string) (*App, *sql.DB, *mux.Router) {
+	a, db := setupFilesApp(t)
+	_, err := db.Exec(auditLogSchema)
+	require.NoError(t, err)
+
+	a.config.Directory.WatchPath = t.TempDir()
+	for _, name := range files {
+		require.NoError(t, os.WriteFile(filepath.Join(a.config.Directory.WatchPath, name), []byte("n\tmqtt\n"+name+"\n"), 0644))
+	}
+	a.watcher = watcher.NewWatcher(a.config.Directory.WatchPath, time.Hour, queueSize)
+	a.batches = newBatchRegistry()
+	a.audit = audit.NewLog(a.queries)
+
+	router := mux.NewRouter()
+	router.HandleFunc("/api/v1/files/process-batch", a.processBatch).Methods("POST")
+	router.HandleFunc("/api/v1/files/batches/{batch_id}", a.getBatchStatus).Methods("GET")
+	return a, db, router
+}
+
+func postBatch(t *testing.T, router http.Handler, body string) batchStatus {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/files/process-batch", strings.NewReader(body))
+	req.Header.Set("X-API-Key", "batch-key")
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	require.Equal(t, http.StatusAccepted, rec.Code, rec.Body.String())
+
+	var status batchStatus
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &status))
+	return status
+}
+
+// batchErrors - ошибки по именам файлов пакета
+func batchErrors(status batchStatus) map[string]string {
+	result := make(map[string]string)
+	for _, f := range status.Files {
+		if f.State == batchFileRejected {
+			result[f.Filename] = f.Error
+		}
+	}
+	return result
+}
+
+func TestProcessBatch_PartialFailure(t *testing.T) {
+	a, db, router := setupBatchApp(t, 1, "a.tsv", "b.tsv", "done.tsv")
+	_, err := db.Exec(`INSERT INTO files (filename, file_hash, status) VALUES ('done.tsv', 'h', 'success')`)
+	require.NoError(t, err)
+
+	// В очереди место для одного файла: b.tsv не помещается
+	// и отклоняется по таймауту постановки (таймеры a.tsv и b.tsv)
+	fake := clock.NewFake(time.Now())
+	a.watcher.SetClock(fake)
+	go func() {
+		fake.BlockUntil(2)
+		fake.Advance(5 * time.Second)
+	}()
+	status := postBatch(t, router, `{"filenames":["a.tsv","missing.tsv","../etc/passwd","done.tsv","b.tsv"]}`)
+	assert.Equal(t, 5, status.Total)
+	assert.Equal(t, 1, status.Pending)
+	assert.Equal(t, map[string]int{batchFileQueued: 1, batchFileRejected: 4}, status.ByState)
+	assert.Equal(t, map[string]string{
+		"missing.tsv":   "file not found in watch directory",
+		"../etc/passwd": "invalid filename",
+		"done.tsv":      "file already processed (status: success)",
+		"b.tsv":         "processing queue is full",
+	}, batchErrors(status))
+
+	queued := <-a.watcher.GetFileQueue()
+	assert.Equal(t, "a.tsv", queued.Name)
+	assert.Equal(t, status.ID, queued.BatchID)
+	assert.Equal(t, apiKeySource("batch-key"), queued.Source)
+
+	// Ошибка обработки одного файла отражается только в его итоге
+	a.batchFileStarted(queued)
+	a.batchFileFinished(queued, errors.New("database is unavailable"))
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/v1/files/batches/"+status.ID, nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+	var final batchStatus
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &final))
+	assert.Equal(t, 0, final.Pending)
+	assert.Equal(t, map[string]int{batchFileFailed: 1, batchFileRejected: 4}, final.ByState)
+	assert.Equal(t, "database is unavailable", final.Files[0].Error)
+	assert.NotNil(t, final.Files[0].Finished)
+}
+
+func TestProcessBatch_DuplicateNames(t *testing.T) {
+	a, _, router := setupBatchApp(t, 10, "a.tsv", "b.tsv")
+	hashes, err := a.watchDirHashes()
+	require.NoError(t, err)
+
+	// a.tsv указан дважды по имени и ещё раз по префиксу хеша
+	status := postBatch(t, router, `{"filenames":["a.tsv","b.tsv","a.tsv"],"hashes":["`+hashes["a.tsv"][:8]+`"]}`)
+	assert.Equal(t, 4, status.Total)
+	assert.Equal(t, map[string]int{batchFileQueued: 2, batchFileRejected: 2}, status.ByState)
+	assert.Equal(t, batchFileQueued, status.Files[0].State)
+	assert.Equal(t, batchFileQueued, status.Files[1].State)
+	for _, f := range status.Files[2:] {
+		assert.Equal(t, "a.tsv", f.Filename)
+		assert.Equal(t, "duplicate file in batch", f.Error)
+	}
+	assert.Len(t, a.watcher.GetFileQueue(), 2)
+
+	// Итог обработки пишется в поставленный в очередь файл, а не в дубликат
+	a.batchFileFinished(watcher.FileInfo{Name: "a.tsv", BatchID: status.ID}, nil)
+	final, ok := a.batches.status(status.ID)
+	require.True(t, ok)
+	assert.Equal(t, batchFileDone, final.Files[0].State)
+	assert.Equal(t, batchFileRejected, final.Files[2].State)
+	assert.Equal(t, batchFileRejected, final.Files[3].State)
+}
+
+func TestProcessBatch_AuditsQueuedFiles(t *testing.T) {
+	_, db, router := setupBatchApp(t, 10, "a.tsv", "b.tsv")
+	status := postBatch(t, router, `{"filenames":["a.tsv","missing.tsv","b.tsv","b.tsv"]}`)
+	require.Equal(t, 2, status.ByState[batchFileQueued])
+
+	// По одной записи на каждый поставленный в очередь файл
+	rows, err := db.Query(`SELECT event, subject, actor, payload FROM audit_log ORDER BY id`)
+	require.NoError(t, err)
+	defer rows.Close()
+	var subjects []string
+	for rows.Next() {
+		var event, subject, actor, payload string
+		require.NoError(t, rows.Scan(&event, &subject, &actor, &payload))
+		assert.Equal(t, audit.EventFileReprocess, event)
+		assert.Equal(t, apiKeySource("batch-key"), actor)
+
+		var p map[string]string
+		require.NoError(t, json.Unmarshal([]byte(payload), &p))
+		assert.Equal(t, status.ID, p["batch_id"])
+		assert.Len(t, p["file_hash"], 64)
+		subjects = append(subjects, subject)
+	}
+	require.NoError(t, rows.Err())
+	assert.Equal(t, []string{"a.tsv", "b.tsv"}, subjects)
+}
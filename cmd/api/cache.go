@@ -0,0 +1,195 @@
+package main
+
+import (
+	"TSVProcessingService/db/sqlc"
+	"TSVProcessingService/internal/cache"
+	"TSVProcessingService/internal/config"
+	"TSVProcessingService/internal/database"
+	"TSVProcessingService/internal/processor"
+	"TSVProcessingService/internal/sorting"
+	"context"
+	"fmt"
+	"log/slog"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Ключи кэша, общие для всех устройств
+const (
+	statisticsCacheKey = "stats"
+	filesCachePrefix   = "files:"
+)
+
+// newCache - создание кэша по конфигурации. Если Redis недоступен,
+// кэш отключается: локальный кэш разошёлся бы между экземплярами.
+func newCache(cfg *config.CacheConfig) cache.Cache {
+	switch cfg.Backend {
+	case "memory":
+		return cache.NewMemoryCache(cfg.MaxEntries)
+	case "redis":
+		redisCache, err := cache.NewRedisCache(cache.RedisOptions{
+			Address:     cfg.Redis.Address,
+			Password:    cfg.Redis.Password,
+			DB:          cfg.Redis.DB,
+			KeyPrefix:   cfg.Redis.KeyPrefix,
+			PoolSize:    cfg.Redis.PoolSize,
+			DialTimeout: cfg.Redis.DialTimeout,
+		})
+		if err != nil {
+			slog.Warn("⚠️ Redis cache unavailable, caching disabled", "error", err)
+			return cache.NopCache{}
+		}
+		slog.Info("🗄️ Redis cache enabled", "address", cfg.Redis.Address)
+		return redisCache
+	default:
+		return cache.NopCache{}
+	}
+}
+
+// unitCachePrefix - префикс всех ключей кэша, относящихся к устройству
+func unitCachePrefix(unitGuid uuid.UUID) string {
+	return "unit:" + unitGuid.String() + ":"
+}
+
+// unitMetaCacheKey - ключ кэша сводных данных устройства
+func unitMetaCacheKey(unitGuid uuid.UUID) string {
+	return unitCachePrefix(unitGuid) + "meta"
+}
+
+// unitMetadata - количество записей и последняя активность устройства (через кэш)
+func (a *App) unitMetadata(ctx context.Context, unitGuid uuid.UUID) (database.UnitMetadata, error) {
+	key := unitMetaCacheKey(unitGuid)
+
+	var meta database.UnitMetadata
+	if cache.GetJSON(a.cache, key, &meta) {
+		return meta, nil
+	}
+
+	meta, err := a.store.GetUnitMetadata(ctx, unitGuid)
+	if err != nil {
+		return meta, err
+	}
+	cache.SetJSON(a.cache, key, meta, a.config.Cache.TTL)
+	return meta, nil
+}
+
+// deviceDataPage - страница данных устройства (через кэш)
+func (a *App) deviceDataPage(ctx context.Context, unitGuid uuid.UUID, sortFields []sorting.Field, limit, offset int32) ([]sqlc.DeviceDatum, error) {
+	key := fmt.Sprintf("%sdata:%d:%d:%s", unitCachePrefix(unitGuid), limit, offset, sorting.Key(sortFields))
+
+	var data []sqlc.DeviceDatum
+	if cache.GetJSON(a.cache, key, &data) {
+		return data, nil
+	}
+
+	orderBy := sorting.OrderBy(sortFields, database.DeviceDataSortFields, "created_at DESC")
+	data, err := a.store.ListDeviceDataByUnitSorted(ctx, unitGuid, orderBy, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	cache.SetJSON(a.cache, key, data, a.config.Cache.ListingTTL)
+	return data, nil
+}
+
+// filesPage - страница списка файлов (через кэш); meta - фильтр по полям
+// имени файла
+func (a *App) filesPage(ctx context.Context, sortFields []sorting.Field, meta map[string]string, limit, offset int32) ([]sqlc.File, error) {
+	key := fmt.Sprintf("%slist:%d:%d:%s:%s", filesCachePrefix, limit, offset, sorting.Key(sortFields), metadataKey(meta))
+
+	var files []sqlc.File
+	if cache.GetJSON(a.cache, key, &files) {
+		return files, nil
+	}
+
+	orderBy := sorting.OrderBy(sortFields, database.FileSortFields, "created_at DESC")
+	files, err := a.store.ListFilesSorted(ctx, orderBy, meta, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	cache.SetJSON(a.cache, key, files, a.config.Cache.ListingTTL)
+	return files, nil
+}
+
+// filesCount - общее количество файлов (через кэш); meta - фильтр по
+// полям имени файла
+func (a *App) filesCount(ctx context.Context, meta map[string]string) (int64, error) {
+	key := filesCachePrefix + "count:" + metadataKey(meta)
+
+	var total int64
+	if cache.GetJSON(a.cache, key, &total) {
+		return total, nil
+	}
+
+	var err error
+	if len(meta) == 0 {
+		total, err = a.queries.CountFiles(ctx)
+	} else {
+		total, err = a.store.CountFilesByMetadata(ctx, meta)
+	}
+	if err != nil {
+		return 0, err
+	}
+	cache.SetJSON(a.cache, key, total, a.config.Cache.ListingTTL)
+	return total, nil
+}
+
+// metadataKey - часть ключа кэша для фильтра по метаданным (ключи по порядку)
+func metadataKey(meta map[string]string) string {
+	keys := make([]string, 0, len(meta))
+	for k := range meta {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	var b strings.Builder
+	for _, k := range keys {
+		fmt.Fprintf(&b, "%q=%q;", k, meta[k])
+	}
+	return b.String()
+}
+
+// cachedStatistics - статистика из БД, которая хранится в кэше
+type cachedStatistics struct {
+	database.Statistics
+	IngestLatency database.IngestLatencyStatistics `json:"ingest_latency"`
+}
+
+// statistics - агрегированная статистика из БД (через кэш)
+func (a *App) statistics(ctx context.Context) (cachedStatistics, error) {
+	var stats cachedStatistics
+	if cache.GetJSON(a.cache, statisticsCacheKey, &stats) {
+		return stats, nil
+	}
+
+	var err error
+	stats.Statistics, err = a.store.GetStatistics(ctx)
+	if err != nil {
+		return stats, err
+	}
+	now := time.Now()
+	stats.IngestLatency, err = a.store.GetIngestLatency(ctx, now.Add(-a.config.SLA.Window), now, a.config.SLA.IngestLatency)
+	if err != nil {
+		return stats, err
+	}
+	cache.SetJSON(a.cache, statisticsCacheKey, stats, a.config.Cache.StatisticsTTL)
+	return stats, nil
+}
+
+// cacheInvalidationHook - сброс кэша после фиксации данных файла.
+// При Redis-кэше сброс виден всем экземплярам сервиса.
+type cacheInvalidationHook struct {
+	cache cache.Cache
+}
+
+func (h *cacheInvalidationHook) Name() string { return "cache-invalidation" }
+
+func (h *cacheInvalidationHook) AfterProcess(ctx context.Context, result processor.ProcessResult) error {
+	for _, guid := range result.UnitGuids {
+		h.cache.DeletePrefix(unitCachePrefix(guid))
+	}
+	h.cache.Delete(statisticsCacheKey)
+	h.cache.DeletePrefix(filesCachePrefix)
+	return nil
+}
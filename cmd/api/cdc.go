@@ -0,0 +1,37 @@
+package main
+
+import (
+	"TSVProcessingService/internal/database"
+	"context"
+	"encoding/json"
+	"net/http"
+	"time"
+)
+
+// cdcSchemaResponse - ответ GET /admin/cdc/schema
+type cdcSchemaResponse struct {
+	Publication    string                      `json:"publication"`
+	ChangeSequence string                      `json:"change_sequence"`
+	Tables         []database.PublicationTable `json:"tables"`
+}
+
+// getCDCSchema - таблицы и столбцы публикации для логического декодирования
+// GET /admin/cdc/schema
+func (a *App) getCDCSchema(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	tables, err := a.store.GetPublicationSchema(ctx, database.CDCPublication)
+	if err != nil {
+		a.logger.Error("Failed to read CDC schema", "error", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Failed to read publication schema"})
+		return
+	}
+
+	json.NewEncoder(w).Encode(cdcSchemaResponse{
+		Publication:    database.CDCPublication,
+		ChangeSequence: "cdc_change_seq",
+		Tables:         tables,
+	})
+}
@@ -0,0 +1,130 @@
+package main
+
+import (
+	"TSVProcessingService/db/sqlc"
+	"TSVProcessingService/internal/audit"
+	"TSVProcessingService/internal/clickhouse"
+	"TSVProcessingService/internal/config"
+	"TSVProcessingService/internal/jobs"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"time"
+)
+
+// newClickHouseSink - выгрузка в ClickHouse; таблица создаётся при запуске
+// (create_table), недоступность ClickHouse запуск сервиса не блокирует
+func newClickHouseSink(ctx context.Context, queries *sqlc.Queries, cfg *config.ClickHouseConfig) *clickhouse.Sink {
+	client := clickhouse.NewClient(clickhouse.Options{
+		URL:      cfg.URL,
+		Database: cfg.Database,
+		Table:    cfg.Table,
+		User:     cfg.User,
+		Password: cfg.Password,
+		Timeout:  cfg.Timeout,
+	})
+	if cfg.CreateTable {
+		if err := client.CreateTable(ctx); err != nil {
+			slog.Warn("⚠️ Failed to create ClickHouse table", "table", cfg.Table, "error", err)
+		}
+	}
+	return clickhouse.NewSink(queries, client, clickhouse.SinkOptions{
+		BatchRows:  cfg.BatchRows,
+		Interval:   cfg.FlushInterval,
+		LagWarning: cfg.LagWarning,
+	})
+}
+
+// getClickHouseStatus - отставание выгрузки в ClickHouse
+// GET /admin/clickhouse
+func (a *App) getClickHouseStatus(w http.ResponseWriter, r *http.Request) {
+	if a.clickhouse == nil {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(map[string]string{"error": "ClickHouse sink is not enabled"})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	lag, err := a.clickhouse.Lag(ctx)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Failed to get ClickHouse lag"})
+		return
+	}
+	json.NewEncoder(w).Encode(lag)
+}
+
+// clickHouseReplayResponse - ответ POST /admin/clickhouse/replay
+type clickHouseReplayResponse struct {
+	QueuedFiles int64     `json:"queued_files"`
+	From        time.Time `json:"from"`
+	To          time.Time `json:"to"`
+}
+
+// replayClickHouse - повторная выгрузка в ClickHouse файлов, созданных
+// в [from, to) (RFC3339 или YYYY-MM-DD, to - не включительно)
+// POST /admin/clickhouse/replay?from=...&to=...
+func (a *App) replayClickHouse(w http.ResponseWriter, r *http.Request) {
+	if a.clickhouse == nil {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(map[string]string{"error": "ClickHouse sink is not enabled"})
+		return
+	}
+
+	query := r.URL.Query()
+	from, _, err := parseReportTime(query.Get("from"))
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": "from must be RFC3339 or YYYY-MM-DD"})
+		return
+	}
+	to := time.Now()
+	if raw := query.Get("to"); raw != "" {
+		t, dateOnly, err := parseReportTime(raw)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(map[string]string{"error": "to must be RFC3339 or YYYY-MM-DD"})
+			return
+		}
+		if dateOnly {
+			t = t.AddDate(0, 0, 1)
+		}
+		to = t
+	}
+	if !from.Before(to) {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": "from must be before to"})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 30*time.Second)
+	defer cancel()
+
+	var queued int64
+	spec := jobs.Spec{
+		Kind:    jobs.KindBackfill,
+		Subject: "clickhouse:" + from.Format(time.RFC3339) + "/" + to.Format(time.RFC3339),
+		Owner:   "api",
+	}
+	err = a.jobs.Run(ctx, spec, func(ctx context.Context) (err error) {
+		queued, err = a.clickhouse.Replay(ctx, from, to)
+		return err
+	})
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Failed to queue files for replay"})
+		return
+	}
+	a.logger.Info("📤 ClickHouse replay queued", "queued", queued, "from", from.Format(time.RFC3339), "to", to.Format(time.RFC3339))
+	a.recordAudit(r.Context(), audit.EventReplayRequested, spec.Subject, requestActor(r), map[string]interface{}{
+		"from":   from,
+		"to":     to,
+		"queued": queued,
+	})
+
+	w.WriteHeader(http.StatusAccepted)
+	json.NewEncoder(w).Encode(clickHouseReplayResponse{QueuedFiles: queued, From: from, To: to})
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"TSVProcessingService/internal/breaker"
+	"TSVProcessingService/internal/clock"
+	"TSVProcessingService/internal/config"
+	"TSVProcessingService/internal/database"
+	"context"
+	"encoding/json"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// newReadBreaker - автомат защиты запросов API к БД
+func newReadBreaker(cfg *config.ReadBreakerConfig) *breaker.Breaker {
+	return breaker.New("database-reads", breaker.Config{
+		FailureThreshold: cfg.FailureThreshold,
+		OpenTimeout:      cfg.OpenTimeout,
+		HalfOpenProbes:   cfg.HalfOpenProbes,
+		IsFailure:        database.IsOverload,
+	}, clock.Real)
+}
+
+// readBreakerMiddleware - при разомкнутом автомате чтения GET-запросы
+// сразу получают 503 с Retry-After, не занимая соединения пула и не
+// дожидаясь таймаута. В half_open пропускаются пробные запросы.
+func (a *App) readBreakerMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		done, err := a.readBreaker.Allow()
+		if err != nil {
+			retryAfter := int(math.Ceil(a.readBreaker.RetryAfter().Seconds()))
+			w.Header().Set("Retry-After", strconv.Itoa(retryAfter))
+			w.WriteHeader(http.StatusServiceUnavailable)
+			json.NewEncoder(w).Encode(map[string]string{
+				"error": "Database is overloaded, retry later",
+			})
+			return
+		}
+		defer done()
+		next.ServeHTTP(w, r)
+	})
+}
+
+// readinessResponse - ответ GET /health/ready
+type readinessResponse struct {
+	Status      string         `json:"status"` // ready, degraded, draining, stopping, not_ready
+	Degraded    bool           `json:"degraded"`
+	ReadBreaker *breaker.Stats `json:"read_breaker,omitempty"`
+	Error       string         `json:"error,omitempty"`
+}
+
+// readinessCheck - готовность принимать запросы. degraded - автомат
+// чтения разомкнут: файлы обрабатываются, но чтение API отклоняется.
+func (a *App) readinessCheck(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	response := readinessResponse{Status: "ready"}
+	if a.readBreaker != nil {
+		stats := a.readBreaker.Stats()
+		response.ReadBreaker = &stats
+		if stats.State != breaker.StateClosed {
+			response.Status = "degraded"
+			response.Degraded = true
+		}
+	}
+
+	// Дренаж: файлы не принимаются, балансировщик переключает запросы.
+	// stopping - порт API закрывается (server.shutdown_delay)
+	if a.draining.Load() || a.stopping.Load() {
+		response.Status = "draining"
+		if a.stopping.Load() {
+			response.Status = "stopping"
+		}
+		w.WriteHeader(http.StatusServiceUnavailable)
+		json.NewEncoder(w).Encode(response)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+	if err := a.store.HealthCheck(ctx); err != nil {
+		response.Status = "not_ready"
+		response.Error = "Database connection failed"
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+	json.NewEncoder(w).Encode(response)
+}
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"TSVProcessingService/internal/dto"
+	"context"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+)
+
+// deviceError - ошибка разбора строки устройства с файлом, из которого она пришла
+type deviceError struct {
+	dto.ProcessingError
+	Filename string `json:"filename"`
+}
+
+// getDeviceErrors - ошибки разбора строк устройства по всем файлам:
+// GET /devices/{unit_guid}/errors с пагинацией. Учитываются строки,
+// в которых unit_guid удалось разобрать.
+func (a *App) getDeviceErrors(w http.ResponseWriter, r *http.Request) {
+	unitGuid, err := uuid.Parse(mux.Vars(r)["unit_guid"])
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Invalid unit_guid format"})
+		return
+	}
+	pageReq, err := parsePageRequest(r, 50, 100)
+	if err != nil {
+		writeInvalidCursor(w)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
+	defer cancel()
+
+	errs, total, err := a.store.ListProcessingErrorsByUnit(ctx, unitGuid, int32(pageReq.Limit), int32(pageReq.Offset))
+	if err != nil {
+		a.logger.Error("Failed to list errors of unit", "unit", unitGuid, "error", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Failed to fetch device errors"})
+		return
+	}
+
+	items := make([]deviceError, 0, len(errs))
+	for _, e := range errs {
+		items = append(items, deviceError{
+			ProcessingError: dto.FromProcessingError(e.ProcessingError),
+			Filename:        e.Filename,
+		})
+	}
+	dto.Mask(items, a.maskedFields(r))
+	json.NewEncoder(w).Encode(newPageResponse(items, pageReq, total))
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"TSVProcessingService/internal/config"
+	"TSVProcessingService/internal/digest"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// newDigestScheduler - планировщик дайджестов из конфигурации
+func newDigestScheduler(store digest.Store, cfg *config.AppConfig) *digest.Scheduler {
+	var sender digest.Sender
+	if smtpCfg := cfg.Digest.SMTP; smtpCfg.Host != "" {
+		sender = &digest.SMTPSender{
+			Addr:     fmt.Sprintf("%s:%d", smtpCfg.Host, smtpCfg.Port),
+			Username: smtpCfg.Username,
+			Password: smtpCfg.Password,
+			From:     smtpCfg.From,
+			To:       smtpCfg.To,
+		}
+	}
+	return digest.NewScheduler(store, digest.Options{
+		Schedules: cfg.Digest.Schedules,
+		Hour:      cfg.Digest.Hour,
+		Formats:   cfg.Digest.Formats,
+		OutputDir: cfg.Digest.OutputDir,
+		TopUnits:  int32(cfg.Digest.TopUnits),
+		SLA:       cfg.SLA.IngestLatency,
+		Branding:  cfg.Report.Branding,
+
+		PDFPasswords: cfg.Digest.PDFPasswords,
+	}, sender)
+}
+
+// getDigest - дайджест за последний завершённый период по запросу
+// GET /admin/digest?period=weekly|monthly&format=json|html|pdf
+// (для pdf - необязательный заголовок X-Report-Password)
+func (a *App) getDigest(w http.ResponseWriter, r *http.Request) {
+	kind := r.URL.Query().Get("period")
+	if kind == "" {
+		kind = digest.Weekly
+	}
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		format = "json"
+	}
+	if format != "json" && format != digest.FormatHTML && format != digest.FormatPDF {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": "format must be one of: json, html, pdf"})
+		return
+	}
+
+	period, err := digest.PreviousPeriod(kind, time.Now())
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": "period must be one of: weekly, monthly"})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), time.Minute)
+	defer cancel()
+
+	topUnits := int32(a.config.Digest.TopUnits)
+	if topUnits <= 0 {
+		topUnits = 10
+	}
+	d, err := digest.Build(ctx, a.store, period, topUnits, a.config.SLA.IngestLatency)
+	if err != nil {
+		a.logger.Error("Failed to build digest", "kind", kind, "error", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Failed to build digest"})
+		return
+	}
+
+	var data []byte
+	switch format {
+	case digest.FormatHTML:
+		data, err = digest.RenderHTML(d, a.config.Report.Branding)
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	case digest.FormatPDF:
+		// Пароль из запроса, иначе пароль расписания
+		password := r.Header.Get("X-Report-Password")
+		if password == "" {
+			password = a.config.Digest.PDFPasswords[kind]
+		}
+		data, err = digest.RenderPDF(d, a.config.Report.Branding, password)
+		w.Header().Set("Content-Type", "application/pdf")
+		w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="digest_%s_%s.pdf"`,
+			kind, period.From.Format("20060102")))
+	default:
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(d)
+		return
+	}
+	if err != nil {
+		a.logger.Error("Failed to render digest", "kind", kind, "error", err)
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Del("Content-Disposition")
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Failed to render digest"})
+		return
+	}
+	w.Write(data)
+}
@@ -0,0 +1,110 @@
+package main
+
+import (
+	"TSVProcessingService/internal/config"
+	"TSVProcessingService/internal/signedurl"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+)
+
+// downloadURL - подписанная ссылка на скачивание
+type downloadURL struct {
+	URL       string    `json:"url"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// newDownloadSigner - подпись ссылок на скачивание. Без downloads.signing_key
+// ключ генерируется при запуске: выданные ссылки не переживают перезапуск
+// и не действуют на других экземплярах.
+func newDownloadSigner(cfg *config.DownloadsConfig) (*signedurl.Signer, error) {
+	key := []byte(cfg.SigningKey)
+	if len(key) == 0 {
+		generated, err := signedurl.GenerateKey()
+		if err != nil {
+			return nil, err
+		}
+		key = generated
+		slog.Warn("⚠️ downloads.signing_key is not set, signed download links are valid only until restart")
+	}
+	return signedurl.NewSigner(key, cfg.URLTTL), nil
+}
+
+// withSignedDownload - проверка подписи ссылки на скачивание. Запрос
+// без подписи пропускается, если не включён downloads.require_signature;
+// неверная или просроченная подпись отклоняется всегда.
+func (a *App) withSignedDownload(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		err := a.downloads.Verify(r.URL.Path, r.URL.Query())
+		switch {
+		case err == nil:
+			next(w, r)
+		case errors.Is(err, signedurl.ErrMissing) && !a.config.Downloads.RequireSignature:
+			next(w, r)
+		case errors.Is(err, signedurl.ErrMissing):
+			w.WriteHeader(http.StatusUnauthorized)
+			json.NewEncoder(w).Encode(map[string]string{"error": "Signed download link required"})
+		case errors.Is(err, signedurl.ErrExpired):
+			w.WriteHeader(http.StatusForbidden)
+			json.NewEncoder(w).Encode(map[string]string{"error": "Download link has expired"})
+		default:
+			w.WriteHeader(http.StatusForbidden)
+			json.NewEncoder(w).Encode(map[string]string{"error": "Invalid download signature"})
+		}
+	}
+}
+
+// getReportDownloadURL - подписанная ссылка на файл отчёта для браузера
+// GET /reports/{unit_guid}/{id}/download-url
+func (a *App) getReportDownloadURL(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	unitGuid, err := uuid.Parse(vars["unit_guid"])
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Invalid unit_guid format"})
+		return
+	}
+	id, err := strconv.ParseInt(vars["id"], 10, 64)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Invalid report id"})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	report, err := a.queries.GetReportByID(ctx, id)
+	if errors.Is(err, sql.ErrNoRows) || (err == nil && report.UnitGuid != unitGuid) {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Report not found"})
+		return
+	}
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Failed to fetch report"})
+		return
+	}
+
+	url, expires := a.downloads.Sign("/api/v1/reports/" + unitGuid.String() + "/" + strconv.FormatInt(id, 10) + "/download")
+	json.NewEncoder(w).Encode(downloadURL{URL: url, ExpiresAt: expires})
+}
+
+// getReportGroupDownloadURL - подписанная ссылка на zip-архив группы отчётов
+// GET /reports/groups/{group_id}/download-url
+func (a *App) getReportGroupDownloadURL(w http.ResponseWriter, r *http.Request) {
+	group, _, ok := a.fetchReportGroup(w, r)
+	if !ok {
+		return
+	}
+	url, expires := a.downloads.Sign("/api/v1/reports/groups/" + group.String() + "/download")
+	json.NewEncoder(w).Encode(downloadURL{URL: url, ExpiresAt: expires})
+}
@@ -0,0 +1,111 @@
+package main
+
+import (
+	"TSVProcessingService/internal/audit"
+	"encoding/json"
+	"net/http"
+	"os"
+	"path/filepath"
+)
+
+// watcherLockFile - блокировка сканирования watch-директории (в temp_path)
+const watcherLockFile = "watcher.lock"
+
+// drainResponse - ответ POST /admin/drain
+type drainResponse struct {
+	Status      string `json:"status"` // draining
+	Queued      int    `json:"queued"` // файлов в очереди, которые будут обработаны до завершения
+	Prioritized int    `json:"prioritized"`
+	InFlight    int    `json:"in_flight"`
+}
+
+// startDrain - мягкая остановка (SIGUSR1 или POST /admin/drain): watcher
+// перестаёт ставить файлы в очередь, API и повторы получают отказ, а
+// воркеры обрабатывают всю накопленную очередь, не только файлы в работе.
+// Когда воркеры завершатся, закрывается a.drained и приложение
+// останавливается как обычно (waitForShutdown). Файлы, не попавшие
+// в очередь, остаются в watch-директории. С server.reuse_port порт API
+// закрывается сразу (stopAPIServer): запросы принимает новый экземпляр,
+// запущенный на том же порту, а watch-директорию он начинает сканировать,
+// когда очередь дообработана (lockWatcher). false - дренаж уже идёт.
+func (a *App) startDrain(reason string) bool {
+	if !a.draining.CompareAndSwap(false, true) {
+		return false
+	}
+	stats := a.watcher.QueueStats()
+	a.logger.Info("🚰 Draining: no new files are accepted, finishing queued and in-flight files", "reason", reason, "queued", stats.Depth+stats.Prioritized, "inflight", a.inflight.count())
+
+	a.watcher.Stop()
+	if a.config.Server.ReusePort {
+		// Порт слушает и новый экземпляр: передаём ему запросы сразу,
+		// не дожидаясь конца очереди
+		a.logger.Info("🔌 Handing the API port over to the new instance")
+		go a.stopAPIServer()
+	}
+	go func() {
+		a.workerWg.Wait()
+		a.logger.Info("✓ Queue drained")
+		a.unlockWatcher()
+		close(a.drained)
+	}()
+	return true
+}
+
+// lockWatcher - блокировка watch-директории при server.reuse_port. Новый
+// экземпляр запускается, пока старый ещё дообрабатывает очередь: её
+// файлы лежат в той же директории до конца обработки, а записи о них не
+// зафиксированы, поэтому второй watcher поставил бы их в очередь
+// повторно. Директорию сканирует только экземпляр, удерживающий flock на
+// temp_path/watcher.lock; старый отпускает её, дообработав очередь после
+// SIGUSR1 (или при завершении процесса). Ждёт, пока блокировка занята.
+func (a *App) lockWatcher() error {
+	path := filepath.Join(a.config.Directory.TempPath, watcherLockFile)
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_RDWR, 0644)
+	if err != nil {
+		return err
+	}
+	locked, err := tryLockFile(f)
+	if err == nil && !locked {
+		a.logger.Info("⏳ Previous instance is draining its queue, waiting before scanning the watch directory", "lock", path)
+		if err = lockFile(f); err == nil {
+			a.logger.Info("🔓 Watch directory released by the previous instance")
+		}
+	}
+	if err != nil {
+		f.Close()
+		return err
+	}
+	a.watchLock.Store(f)
+	return nil
+}
+
+// unlockWatcher отпускает блокировку watch-директории (см. lockWatcher)
+func (a *App) unlockWatcher() {
+	if f := a.watchLock.Swap(nil); f != nil {
+		f.Close()
+	}
+}
+
+// drainQueue - перевод в режим дренажа (см. startDrain)
+// POST /admin/drain
+func (a *App) drainQueue(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	if !a.startDrain("requested by " + requestActor(r)) {
+		w.WriteHeader(http.StatusConflict)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Already draining"})
+		return
+	}
+
+	stats := a.watcher.QueueStats()
+	a.recordAudit(r.Context(), audit.EventDrainRequested, "service", requestActor(r), map[string]interface{}{
+		"queued":    stats.Depth + stats.Prioritized,
+		"in_flight": a.inflight.count(),
+	})
+	w.WriteHeader(http.StatusAccepted)
+	json.NewEncoder(w).Encode(drainResponse{
+		Status:      "draining",
+		Queued:      stats.Depth,
+		Prioritized: stats.Prioritized,
+		InFlight:    a.inflight.count(),
+	})
+}
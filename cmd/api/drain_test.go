@@ -0,0 +1,129 @@
+package main
+
+import (
+	"TSVProcessingService/db/sqlc"
+	"TSVProcessingService/internal/audit"
+	"TSVProcessingService/internal/config"
+	"TSVProcessingService/internal/throttle"
+	"TSVProcessingService/internal/watcher"
+	"database/sql"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// auditLogSchema - таблица журнала аудита (колонки в порядке модели sqlc)
+const auditLogSchema = `CREATE TABLE audit_log (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	event TEXT NOT NULL,
+	subject TEXT NOT NULL,
+	actor TEXT NOT NULL,
+	payload TEXT NOT NULL,
+	prev_hash TEXT NOT NULL UNIQUE,
+	hash TEXT NOT NULL UNIQUE,
+	created_at DATETIME NOT NULL,
+	updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+	change_seq INTEGER NOT NULL DEFAULT 0
+)`
+
+func TestStartDrain_ProcessesSpillover(t *testing.T) {
+	cfg := &config.AppConfig{}
+	cfg.Throttle = config.ThrottleConfig{Enabled: true, DefaultFilesPerMinute: 1, Burst: 1, MaxSpillover: 10}
+
+	w := watcher.NewWatcher(t.TempDir(), time.Hour, 10)
+	a := &App{
+		config:   cfg,
+		watcher:  w,
+		limiter:  throttle.NewLimiter(cfg.Throttle, 1),
+		inflight: newInflightFiles(),
+		drained:  make(chan struct{}),
+		logger:   slog.Default(),
+	}
+	go a.limiter.Run(w.GetFileQueue())
+
+	// Воркер забирает файлы из очереди после ограничения скорости
+	var processed []string
+	a.workerWg.Add(1)
+	go func() {
+		defer a.workerWg.Done()
+		for fileInfo := range a.limiter.Output() {
+			processed = append(processed, fileInfo.Name)
+		}
+	}()
+
+	names := []string{"a.tsv", "b.tsv", "c.tsv", "d.tsv"}
+	for _, name := range names {
+		require.NoError(t, w.SendToQueue(watcher.FileInfo{Name: name, Path: "/in/" + name, Hash: "0123456789", Source: "api"}))
+	}
+	require.Eventually(t, func() bool {
+		return a.limiter.SpilloverSizes()["api"] == len(names)-1
+	}, 5*time.Second, 10*time.Millisecond)
+
+	require.True(t, a.startDrain("test"))
+	select {
+	case <-a.drained:
+	case <-time.After(5 * time.Second):
+		t.Fatal("queue was not drained")
+	}
+	assert.Equal(t, names, processed)
+	assert.ErrorIs(t, w.SendToQueue(watcher.FileInfo{Name: "late.tsv"}), watcher.ErrStopped)
+}
+
+func TestDrainQueue_RequiresChangeAccessAndIsAudited(t *testing.T) {
+	db, err := sql.Open("sqlite", ":memory:")
+	require.NoError(t, err)
+	db.SetMaxOpenConns(1)
+	t.Cleanup(func() { db.Close() })
+	_, err = db.Exec(auditLogSchema)
+	require.NoError(t, err)
+
+	cfg := &config.AppConfig{}
+	cfg.Access = config.AccessConfig{
+		DefaultRole: "viewer",
+		Keys:        []config.AccessKeyConfig{{Key: "ops-key", Role: "operator"}},
+	}
+	queries := sqlc.New(db)
+	a := &App{
+		config:   cfg,
+		queries:  queries,
+		access:   newAccessRoles(&cfg.Access),
+		audit:    audit.NewLog(queries),
+		watcher:  watcher.NewWatcher(t.TempDir(), time.Hour, 10),
+		inflight: newInflightFiles(),
+		drained:  make(chan struct{}),
+		logger:   slog.Default(),
+	}
+	handler := a.withChangeAccess(a.drainQueue)
+	drain := func(apiKey string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/admin/drain", nil)
+		if apiKey != "" {
+			req.Header.Set("X-API-Key", apiKey)
+		}
+		rec := httptest.NewRecorder()
+		handler(rec, req)
+		return rec
+	}
+
+	// Без ключа и с неизвестным ключом - роль viewer
+	for _, key := range []string{"", "unknown"} {
+		rec := drain(key)
+		assert.Equal(t, http.StatusForbidden, rec.Code)
+		assert.JSONEq(t, `{"error":"Viewer role cannot change service state"}`, rec.Body.String())
+	}
+	assert.False(t, a.draining.Load())
+
+	rec := drain("ops-key")
+	require.Equal(t, http.StatusAccepted, rec.Code, rec.Body.String())
+	assert.True(t, a.draining.Load())
+
+	var event, subject, actor string
+	require.NoError(t, db.QueryRow(`SELECT event, subject, actor FROM audit_log`).Scan(&event, &subject, &actor))
+	assert.Equal(t, audit.EventDrainRequested, event)
+	assert.Equal(t, "service", subject)
+	assert.Equal(t, apiKeySource("ops-key"), actor)
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"TSVProcessingService/db/sqlc"
+	"TSVProcessingService/internal/dto"
+	"TSVProcessingService/internal/processor"
+	"TSVProcessingService/internal/safepath"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+// getFileDuplicates - дубликаты строк файла, найденные при обработке с
+// worker.duplicate_report: строки, уже загруженные другими файлами
+// (kind=existing), и повторы внутри файла (kind=in_file). Счётчики -
+// rows_duplicate_* в GET /files/{filename}.
+// GET /files/{filename}/duplicates?kind=existing
+func (a *App) getFileDuplicates(w http.ResponseWriter, r *http.Request) {
+	filename := mux.Vars(r)["filename"]
+	if err := safepath.Filename(filename); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Invalid filename"})
+		return
+	}
+	kind := r.URL.Query().Get("kind")
+	if !processor.ValidDuplicateKind(kind) {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": "kind must be one of: existing, in_file"})
+		return
+	}
+	pageReq, err := parsePageRequest(r, 100, 1000)
+	if err != nil {
+		writeInvalidCursor(w)
+		return
+	}
+	fields, ok := parseFieldsParam(w, r, dto.FileDuplicate{})
+	if !ok {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	file, err := a.queries.GetFileByFilename(ctx, filename)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			w.WriteHeader(http.StatusNotFound)
+		} else {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+		json.NewEncoder(w).Encode(map[string]string{"error": "File not found"})
+		return
+	}
+	if !file.RowsDuplicateExisting.Valid {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "No duplicate report for this file (processed with worker.duplicate_report disabled)",
+		})
+		return
+	}
+
+	duplicates, err := a.queries.ListFileDuplicatesByFilePaged(ctx, sqlc.ListFileDuplicatesByFilePagedParams{
+		FileID: file.ID,
+		Kind:   kind,
+		Limit:  int32(pageReq.Limit),
+		Offset: int32(pageReq.Offset),
+	})
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Failed to fetch duplicates"})
+		return
+	}
+	total, err := a.queries.CountFileDuplicatesByFile(ctx, sqlc.CountFileDuplicatesByFileParams{
+		FileID: file.ID,
+		Kind:   kind,
+	})
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Failed to count duplicates"})
+		return
+	}
+
+	json.NewEncoder(w).Encode(newPageResponse(dto.Project(dto.FromFileDuplicates(duplicates), fields), pageReq, total))
+}
@@ -0,0 +1,174 @@
+package main
+
+import (
+	"TSVProcessingService/db/sqlc"
+	"TSVProcessingService/internal/audit"
+	"TSVProcessingService/internal/features"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+// featureOverride - переопределение флага в БД (feature_flags)
+type featureOverride struct {
+	Name      string     `json:"name"`
+	Tenant    string     `json:"tenant,omitempty"` // пусто - для всех
+	Enabled   bool       `json:"enabled"`
+	UpdatedBy string     `json:"updated_by,omitempty"`
+	UpdatedAt *time.Time `json:"updated_at,omitempty"`
+}
+
+func newFeatureOverride(row sqlc.FeatureFlag) featureOverride {
+	override := featureOverride{
+		Name:      row.Name,
+		Tenant:    row.Tenant,
+		Enabled:   row.Enabled,
+		UpdatedBy: row.UpdatedBy.String,
+	}
+	if row.UpdatedAt.Valid {
+		override.UpdatedAt = &row.UpdatedAt.Time
+	}
+	return override
+}
+
+// featuresResponse - ответ GET /admin/features
+type featuresResponse struct {
+	Tenant    string            `json:"tenant,omitempty"`
+	Flags     []features.State  `json:"flags"`     // действующие значения для tenant (пусто - без tenant)
+	Tenants   []string          `json:"tenants"`   // tenant с отдельными значениями
+	Overrides []featureOverride `json:"overrides"` // переопределения в БД
+}
+
+// featureRequest - тело PUT /admin/features/{name}
+type featureRequest struct {
+	Enabled *bool  `json:"enabled"`
+	Tenant  string `json:"tenant"` // пусто - для всех
+}
+
+// refreshFeatures - периодическое перечитывание переопределений флагов:
+// изменения через API другого экземпляра применяются без перезапуска
+func (a *App) refreshFeatures() {
+	ticker := time.NewTicker(a.config.Features.RefreshInterval)
+	defer ticker.Stop()
+	for range ticker.C {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		if err := a.features.Refresh(ctx, a.queries); err != nil {
+			a.logger.Warn("⚠️ Feature flag overrides not loaded", "error", err)
+		}
+		cancel()
+	}
+}
+
+// getFeatures - действующие значения флагов и переопределения
+// GET /admin/features?tenant=
+func (a *App) getFeatures(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	rows, err := a.queries.ListFeatureFlags(r.Context())
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Failed to fetch feature flags"})
+		return
+	}
+	overrides := make([]featureOverride, 0, len(rows))
+	for _, row := range rows {
+		overrides = append(overrides, newFeatureOverride(row))
+	}
+
+	tenant := strings.ToLower(r.URL.Query().Get("tenant"))
+	json.NewEncoder(w).Encode(featuresResponse{
+		Tenant:    tenant,
+		Flags:     a.features.States(tenant),
+		Tenants:   a.features.Tenants(),
+		Overrides: overrides,
+	})
+}
+
+// setFeature - переопределение флага для всех или для tenant
+// PUT /admin/features/{name}
+func (a *App) setFeature(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	flag, ok := a.featureFlag(w, r)
+	if !ok {
+		return
+	}
+
+	var req featureRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Enabled == nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": `Body must be {"enabled": true|false, "tenant": "<optional>"}`})
+		return
+	}
+
+	actor := requestActor(r)
+	row, err := a.queries.UpsertFeatureFlag(r.Context(), sqlc.UpsertFeatureFlagParams{
+		Name:      string(flag),
+		Tenant:    strings.ToLower(req.Tenant),
+		Enabled:   *req.Enabled,
+		UpdatedBy: sql.NullString{String: actor, Valid: true},
+	})
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Failed to save feature flag"})
+		return
+	}
+	a.logger.Info("🚩 Feature flag set", "flag", flag, "enabled", row.Enabled, "tenant", row.Tenant, "actor", actor)
+	a.afterFeatureChange(r, flag, map[string]interface{}{"tenant": row.Tenant, "enabled": row.Enabled})
+
+	json.NewEncoder(w).Encode(newFeatureOverride(row))
+}
+
+// deleteFeature - удаление переопределения: действует значение из конфигурации
+// DELETE /admin/features/{name}?tenant=
+func (a *App) deleteFeature(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	flag, ok := a.featureFlag(w, r)
+	if !ok {
+		return
+	}
+
+	tenant := strings.ToLower(r.URL.Query().Get("tenant"))
+	deleted, err := a.queries.DeleteFeatureFlag(r.Context(), sqlc.DeleteFeatureFlagParams{
+		Name:   string(flag),
+		Tenant: tenant,
+	})
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Failed to delete feature flag"})
+		return
+	}
+	if deleted == 0 {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Feature flag is not overridden"})
+		return
+	}
+	a.logger.Info("🚩 Feature flag override removed", "flag", flag, "tenant", tenant, "actor", requestActor(r))
+	a.afterFeatureChange(r, flag, map[string]interface{}{"tenant": tenant, "removed": true})
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// featureFlag - флаг из пути запроса изменения (viewer отклоняет withChangeAccess)
+func (a *App) featureFlag(w http.ResponseWriter, r *http.Request) (features.Flag, bool) {
+	flag, err := features.Parse(mux.Vars(r)["name"])
+	if err != nil {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+		return "", false
+	}
+	return flag, true
+}
+
+// afterFeatureChange применяет изменение сразу (другие экземпляры - через
+// features.refresh_interval) и записывает его в журнал аудита
+func (a *App) afterFeatureChange(r *http.Request, flag features.Flag, payload map[string]interface{}) {
+	if err := a.features.Refresh(r.Context(), a.queries); err != nil {
+		a.logger.Warn("⚠️ Feature flag overrides not loaded", "error", err)
+	}
+	a.recordAudit(r.Context(), audit.EventFeatureFlagChanged, string(flag), requestActor(r), payload)
+}
@@ -0,0 +1,168 @@
+package main
+
+import (
+	"TSVProcessingService/internal/audit"
+	"TSVProcessingService/internal/filestatus"
+	"TSVProcessingService/internal/processor"
+	"TSVProcessingService/internal/watcher"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+// prioritizeResponse - ответ POST /files/{filename}/prioritize
+type prioritizeResponse struct {
+	Filename    string    `json:"filename"`
+	Hash        string    `json:"hash"`
+	FirstSeen   time.Time `json:"first_seen"`
+	Prioritized int       `json:"prioritized"` // файлов в приоритетной очереди
+}
+
+// prioritizeFile - поставить файл из backlog в начало порядка обработки
+// (следующий свободный воркер возьмёт его раньше основной очереди)
+// POST /files/{filename}/prioritize
+func (a *App) prioritizeFile(w http.ResponseWriter, r *http.Request) {
+	filename := mux.Vars(r)["filename"]
+	w.Header().Set("Content-Type", "application/json")
+
+	entry, ok := a.watcher.LookupBacklog(filename)
+	if !ok {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(map[string]string{"error": "file is not in the watch directory backlog"})
+		return
+	}
+	if entry.Reason == watcher.ReasonIgnored {
+		w.WriteHeader(http.StatusConflict)
+		json.NewEncoder(w).Encode(map[string]string{"error": "file is ignored by the watcher"})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
+	defer cancel()
+
+	fileInfo, err := a.validateBatchFile(ctx, filename)
+	if err != nil {
+		w.WriteHeader(http.StatusConflict)
+		json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+		return
+	}
+
+	if err := a.watcher.Prioritize(fileInfo); err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, watcher.ErrPriorityQueueFull) || errors.Is(err, watcher.ErrStopped) {
+			status = http.StatusServiceUnavailable
+		}
+		w.WriteHeader(status)
+		json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+		return
+	}
+
+	a.recordAudit(ctx, audit.EventFilePrioritized, filename, requestActor(r), map[string]interface{}{
+		"file_hash": fileInfo.Hash,
+	})
+
+	w.WriteHeader(http.StatusAccepted)
+	json.NewEncoder(w).Encode(prioritizeResponse{
+		Filename:    filename,
+		Hash:        fileInfo.Hash,
+		FirstSeen:   entry.FirstSeen,
+		Prioritized: a.watcher.QueueStats().Prioritized,
+	})
+}
+
+// inflightFile - файл, который обрабатывает воркер
+type inflightFile struct {
+	worker  int
+	started time.Time
+	cancel  context.CancelCauseFunc
+}
+
+// inflightFiles - контексты обработки файлов по имени (для отмены)
+type inflightFiles struct {
+	mu    sync.Mutex
+	files map[string]*inflightFile
+}
+
+func newInflightFiles() *inflightFiles {
+	return &inflightFiles{files: make(map[string]*inflightFile)}
+}
+
+// add регистрирует файл, взятый воркером в обработку
+func (f *inflightFiles) add(name string, worker int, cancel context.CancelCauseFunc) *inflightFile {
+	entry := &inflightFile{worker: worker, started: time.Now(), cancel: cancel}
+	f.mu.Lock()
+	f.files[name] = entry
+	f.mu.Unlock()
+	return entry
+}
+
+// remove снимает регистрацию (если файл не перерегистрирован другим воркером)
+func (f *inflightFiles) remove(name string, entry *inflightFile) {
+	f.mu.Lock()
+	if f.files[name] == entry {
+		delete(f.files, name)
+	}
+	f.mu.Unlock()
+}
+
+// count - число файлов в обработке
+func (f *inflightFiles) count() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return len(f.files)
+}
+
+// cancel отменяет контекст обработки файла с причиной processor.ErrCancelled
+func (f *inflightFiles) cancel(name string) (inflightFile, bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	entry, ok := f.files[name]
+	if !ok {
+		return inflightFile{}, false
+	}
+	entry.cancel(processor.ErrCancelled)
+	return *entry, true
+}
+
+// cancelResponse - ответ POST /files/{filename}/cancel
+type cancelResponse struct {
+	Filename string            `json:"filename"`
+	Status   filestatus.Status `json:"status"`
+	Worker   int               `json:"worker"`
+	Running  string            `json:"running"` // сколько обрабатывался файл
+	HoldPath string            `json:"hold_path"`
+}
+
+// cancelFile - отменить обработку файла: транзакция откатывается, файл
+// помечается cancelled и перемещается в hold_path (для повторной обработки
+// его достаточно вернуть в watch-директорию)
+// POST /files/{filename}/cancel
+func (a *App) cancelFile(w http.ResponseWriter, r *http.Request) {
+	filename := mux.Vars(r)["filename"]
+	w.Header().Set("Content-Type", "application/json")
+
+	entry, ok := a.inflight.cancel(filename)
+	if !ok {
+		w.WriteHeader(http.StatusConflict)
+		json.NewEncoder(w).Encode(map[string]string{"error": "file is not being processed"})
+		return
+	}
+	a.logger.Info("Cancelled processing of file", "file", filename, "worker", entry.worker)
+	a.recordAudit(r.Context(), audit.EventFileCancelled, filename, requestActor(r), map[string]interface{}{
+		"worker": entry.worker,
+	})
+
+	w.WriteHeader(http.StatusAccepted)
+	json.NewEncoder(w).Encode(cancelResponse{
+		Filename: filename,
+		Status:   filestatus.Cancelled,
+		Worker:   entry.worker,
+		Running:  time.Since(entry.started).Round(time.Millisecond).String(),
+		HoldPath: a.config.Directory.HoldPath,
+	})
+}
@@ -0,0 +1,30 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// metadataFilterPrefix - префикс параметров фильтра по полям имени файла
+const metadataFilterPrefix = "meta."
+
+// parseMetadataFilter - фильтр по files.metadata из параметров
+// meta.<поле>=<значение> (например ?meta.site=SITE42&meta.type=full)
+func parseMetadataFilter(r *http.Request) (map[string]string, error) {
+	var meta map[string]string
+	for param, values := range r.URL.Query() {
+		field, ok := strings.CutPrefix(param, metadataFilterPrefix)
+		if !ok {
+			continue
+		}
+		if field == "" || len(values) != 1 {
+			return nil, fmt.Errorf("invalid metadata filter %s", param)
+		}
+		if meta == nil {
+			meta = make(map[string]string)
+		}
+		meta[field] = values[0]
+	}
+	return meta, nil
+}
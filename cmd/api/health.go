@@ -0,0 +1,109 @@
+package main
+
+import (
+	"TSVProcessingService/internal/alert"
+	"TSVProcessingService/internal/config"
+	"TSVProcessingService/internal/health"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// newAlertDispatcher - каналы оповещений из конфигурации
+func newAlertDispatcher(cfg *config.AlertsConfig) *alert.Dispatcher {
+	dispatcher := alert.NewDispatcher(alert.LogNotifier{})
+	if cfg.WebhookURL != "" {
+		dispatcher.Add(alert.NewWebhookNotifier(cfg.WebhookURL, cfg.WebhookTimeout))
+		slog.Info("🔔 Alert channel: webhook", "url", cfg.WebhookURL)
+	}
+	return dispatcher
+}
+
+// checkDatabaseHealth - одна проверка БД с записью в историю.
+// Оповещения отправляются только при смене состояния или флаппинге.
+func (a *App) checkDatabaseHealth() {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	start := time.Now()
+	err := a.store.HealthCheck(ctx)
+	cancel()
+
+	sample := health.Sample{
+		Time:      start,
+		Healthy:   err == nil,
+		LatencyMs: time.Since(start).Milliseconds(),
+	}
+	if err != nil {
+		sample.Error = err.Error()
+	}
+
+	event := a.healthHistory.Record(sample)
+	if event == health.EventNone {
+		return
+	}
+
+	dbAlert := alert.Alert{Source: "database", Time: start}
+	switch event {
+	case health.EventDown:
+		dbAlert.Severity = alert.SeverityCritical
+		dbAlert.Title = "Database health check failing"
+		dbAlert.Message = sample.Error
+	case health.EventRecovered:
+		dbAlert.Severity = alert.SeverityInfo
+		dbAlert.Title = "Database health check recovered"
+		dbAlert.Message = fmt.Sprintf("latency %dms", sample.LatencyMs)
+	case health.EventFlapping:
+		dbAlert.Severity = alert.SeverityWarning
+		dbAlert.Title = "Database health is flapping"
+		dbAlert.Message = fmt.Sprintf("%d state changes in the last %d checks",
+			a.healthHistory.Transitions(), a.config.Health.FlapWindow)
+	case health.EventFlapResolved:
+		dbAlert.Severity = alert.SeverityInfo
+		dbAlert.Title = "Database health stabilized"
+		if sample.Healthy {
+			dbAlert.Message = "healthy"
+		} else {
+			dbAlert.Message = "unhealthy: " + sample.Error
+		}
+	}
+
+	ctx, cancel = context.WithTimeout(context.Background(), 10*time.Second)
+	a.alerts.Send(ctx, dbAlert)
+	cancel()
+}
+
+// checkStalledComponents - оповещение о компонентах, переставших присылать heartbeat
+func (a *App) checkStalledComponents() {
+	for _, name := range a.supervisor.CheckStalls() {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		a.alerts.Send(ctx, alert.Alert{
+			Source:   "supervisor",
+			Severity: alert.SeverityWarning,
+			Title:    "Component stalled",
+			Message:  fmt.Sprintf("%s has not sent a heartbeat in time", name),
+		})
+		cancel()
+	}
+}
+
+// healthHistoryResponse - ответ GET /admin/health/history
+type healthHistoryResponse struct {
+	Flapping    bool            `json:"flapping"`
+	Transitions int             `json:"transitions"`
+	Samples     []health.Sample `json:"samples"`
+}
+
+// getHealthHistory - последние проверки здоровья (самые новые первыми)
+func (a *App) getHealthHistory(w http.ResponseWriter, r *http.Request) {
+	limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(healthHistoryResponse{
+		Flapping:    a.healthHistory.Flapping(),
+		Transitions: a.healthHistory.Transitions(),
+		Samples:     a.healthHistory.Samples(limit),
+	})
+}
@@ -0,0 +1,149 @@
+package main
+
+import (
+	"TSVProcessingService/db/sqlc"
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+)
+
+// idempotencyKeyHeader - заголовок, по которому клиент передаёт ключ идемпотентности
+const idempotencyKeyHeader = "Idempotency-Key"
+
+// maxIdempotencyKeyLength - ограничение длины ключа, чтобы не хранить произвольные строки
+const maxIdempotencyKeyLength = 255
+
+// recordingResponseWriter - запоминает статус и тело ответа для повторной выдачи
+type recordingResponseWriter struct {
+	http.ResponseWriter
+	statusCode int
+	body       bytes.Buffer
+}
+
+func (rw *recordingResponseWriter) WriteHeader(code int) {
+	rw.statusCode = code
+	rw.ResponseWriter.WriteHeader(code)
+}
+
+func (rw *recordingResponseWriter) Write(b []byte) (int, error) {
+	rw.body.Write(b)
+	return rw.ResponseWriter.Write(b)
+}
+
+// idempotencyLockTTL - срок резервирования ключа на время обработки:
+// если экземпляр остановился, не завершив запрос, ключ освобождается
+// по истечении срока
+const idempotencyLockTTL = 10 * time.Minute
+
+// withIdempotency - обёртка для POST-обработчиков с поддержкой Idempotency-Key.
+// Ключ резервируется (pending) до вызова обработчика: повтор во время
+// обработки первого запроса получает 409, повтор после неё - сохранённый
+// ответ, а сам обработчик повторно не вызывается. Ответы 5xx не
+// сохраняются, резерв снимается, чтобы клиент мог повторить запрос после
+// временного сбоя.
+func (a *App) withIdempotency(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		key := r.Header.Get(idempotencyKeyHeader)
+		if key == "" {
+			next(w, r)
+			return
+		}
+		if len(key) > maxIdempotencyKeyLength {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(map[string]string{"error": "Idempotency-Key is too long"})
+			return
+		}
+
+		endpoint := r.URL.Path
+
+		ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+		reserved, err := a.queries.ReserveIdempotencyKey(ctx, sqlc.ReserveIdempotencyKeyParams{
+			Key:       key,
+			Endpoint:  endpoint,
+			ExpiresAt: time.Now().Add(idempotencyLockTTL),
+		})
+		cancel()
+		if err != nil {
+			// Без БД ключей запрос выполняется без защиты от повтора
+			a.logger.Warn("⚠️ Failed to reserve Idempotency-Key", "key", key, "error", err)
+			next(w, r)
+			return
+		}
+		if reserved == 0 {
+			a.replayIdempotent(w, r, key, endpoint)
+			return
+		}
+
+		rec := &recordingResponseWriter{ResponseWriter: w, statusCode: http.StatusOK}
+		completed := false
+		defer func() {
+			// Паника или 5xx: резерв снимается, запрос можно повторить
+			if !completed {
+				a.releaseIdempotencyKey(key, endpoint)
+			}
+		}()
+		next(rec, r)
+
+		if rec.statusCode >= http.StatusInternalServerError {
+			return
+		}
+		completed = true
+
+		ctx, cancel = context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		err = a.queries.CompleteIdempotencyKey(ctx, sqlc.CompleteIdempotencyKeyParams{
+			Key:          key,
+			Endpoint:     endpoint,
+			StatusCode:   int32(rec.statusCode),
+			ResponseBody: rec.body.String(),
+			ExpiresAt:    time.Now().Add(a.config.Server.IdempotencyTTL),
+		})
+		if err != nil {
+			a.logger.Warn("⚠️ Failed to store Idempotency-Key", "key", key, "error", err)
+		}
+	}
+}
+
+// replayIdempotent - ответ на повтор с занятым ключом: сохранённый ответ
+// или 409, если первый запрос ещё обрабатывается
+func (a *App) replayIdempotent(w http.ResponseWriter, r *http.Request, key, endpoint string) {
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+	stored, err := a.queries.GetIdempotencyKey(ctx, sqlc.GetIdempotencyKeyParams{
+		Key:      key,
+		Endpoint: endpoint,
+	})
+	w.Header().Set("Content-Type", "application/json")
+	switch {
+	case err == nil && !stored.Pending:
+		a.logger.Info("Replaying response for Idempotency-Key", "key", key, "endpoint", endpoint)
+		w.Header().Set("Idempotent-Replayed", "true")
+		w.WriteHeader(int(stored.StatusCode))
+		w.Write([]byte(stored.ResponseBody))
+	case err == nil || errors.Is(err, sql.ErrNoRows):
+		// В обработке (или резерв только что снят после 5xx)
+		w.Header().Set("Retry-After", "1")
+		w.WriteHeader(http.StatusConflict)
+		json.NewEncoder(w).Encode(map[string]string{"error": "A request with this Idempotency-Key is in progress"})
+	default:
+		a.logger.Error("❌ Failed to look up Idempotency-Key", "key", key, "error", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Failed to look up Idempotency-Key"})
+	}
+}
+
+// releaseIdempotencyKey снимает резерв ключа без сохранённого ответа
+func (a *App) releaseIdempotencyKey(key, endpoint string) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := a.queries.ReleaseIdempotencyKey(ctx, sqlc.ReleaseIdempotencyKeyParams{
+		Key:      key,
+		Endpoint: endpoint,
+	}); err != nil {
+		a.logger.Warn("⚠️ Failed to release Idempotency-Key", "key", key, "error", err)
+	}
+}
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"TSVProcessingService/db/sqlc"
+	"TSVProcessingService/internal/config"
+	"database/sql"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	_ "modernc.org/sqlite"
+)
+
+func setupIdempotencyApp(t *testing.T) *App {
+	db, err := sql.Open("sqlite", ":memory:")
+	require.NoError(t, err)
+	db.SetMaxOpenConns(1) // одна in-memory база на все запросы
+	t.Cleanup(func() { db.Close() })
+
+	_, err = db.Exec(`CREATE TABLE idempotency_keys (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		key TEXT NOT NULL,
+		endpoint TEXT NOT NULL,
+		status_code INTEGER NOT NULL,
+		response_body TEXT NOT NULL,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		expires_at DATETIME NOT NULL,
+		updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		change_seq INTEGER NOT NULL DEFAULT 0,
+		pending BOOLEAN NOT NULL DEFAULT false,
+		UNIQUE (key, endpoint)
+	)`)
+	require.NoError(t, err)
+
+	cfg := &config.AppConfig{}
+	cfg.Server.IdempotencyTTL = time.Hour
+	return &App{config: cfg, queries: sqlc.New(db), logger: slog.Default()}
+}
+
+func idempotentRequest(handler http.HandlerFunc, key string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/files/a.tsv/process", nil)
+	req.Header.Set(idempotencyKeyHeader, key)
+	rec := httptest.NewRecorder()
+	handler(rec, req)
+	return rec
+}
+
+func TestWithIdempotency_ConcurrentRetryAndReplay(t *testing.T) {
+	a := setupIdempotencyApp(t)
+
+	var calls atomic.Int32
+	entered := make(chan struct{})
+	release := make(chan struct{})
+	handler := a.withIdempotency(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		close(entered)
+		<-release
+		w.WriteHeader(http.StatusAccepted)
+		w.Write([]byte(`{"status":"queued"}`))
+	})
+
+	first := make(chan *httptest.ResponseRecorder)
+	go func() { first <- idempotentRequest(handler, "k1") }()
+	<-entered
+
+	// Повтор во время обработки первого запроса обработчик не вызывает
+	retry := idempotentRequest(handler, "k1")
+	assert.Equal(t, http.StatusConflict, retry.Code)
+	assert.Equal(t, "1", retry.Header().Get("Retry-After"))
+
+	close(release)
+	rec := <-first
+	assert.Equal(t, http.StatusAccepted, rec.Code)
+
+	// Повтор после завершения получает сохранённый ответ
+	replay := idempotentRequest(handler, "k1")
+	assert.Equal(t, http.StatusAccepted, replay.Code)
+	assert.Equal(t, "true", replay.Header().Get("Idempotent-Replayed"))
+	assert.JSONEq(t, `{"status":"queued"}`, replay.Body.String())
+	assert.Equal(t, int32(1), calls.Load())
+}
+
+func TestWithIdempotency_ServerErrorReleasesKey(t *testing.T) {
+	a := setupIdempotencyApp(t)
+
+	var calls atomic.Int32
+	handler := a.withIdempotency(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) == 1 {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	})
+
+	assert.Equal(t, http.StatusInternalServerError, idempotentRequest(handler, "k2").Code)
+	// 5xx не сохраняется: повтор выполняет обработчик снова
+	assert.Equal(t, http.StatusOK, idempotentRequest(handler, "k2").Code)
+	assert.Equal(t, int32(2), calls.Load())
+
+	// Другой ключ - отдельный запрос
+	assert.Equal(t, http.StatusOK, idempotentRequest(handler, "k3").Code)
+	assert.Equal(t, int32(3), calls.Load())
+}
@@ -0,0 +1,156 @@
+package main
+
+import (
+	"TSVProcessingService/internal/alert"
+	"TSVProcessingService/internal/audit"
+	"TSVProcessingService/internal/config"
+	"TSVProcessingService/internal/filestatus"
+	"TSVProcessingService/internal/processor"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+// newIncidentManager - система дежурств из конфигурации (nil - не настроена)
+func newIncidentManager(cfg *config.IncidentsConfig) *alert.IncidentManager {
+	var notifier alert.IncidentNotifier
+	switch cfg.Provider {
+	case "pagerduty":
+		notifier = alert.NewPagerDutyNotifier(cfg.RoutingKey, cfg.URL, cfg.Timeout)
+	case "opsgenie":
+		notifier = alert.NewOpsgenieNotifier(cfg.APIKey, cfg.URL, cfg.Timeout)
+	default:
+		return nil
+	}
+	slog.Info("📟 Incident channel", "provider", cfg.Provider, "dedup_prefix", cfg.DedupPrefix)
+	return alert.NewIncidentManager(cfg.DedupPrefix, notifier)
+}
+
+// failedFilesHook - учёт файлов, ушедших в папку ошибок, за скользящее окно
+type failedFilesHook struct {
+	window time.Duration
+
+	mu    sync.Mutex
+	times []time.Time
+}
+
+func (h *failedFilesHook) Name() string { return "incident-failures" }
+
+func (h *failedFilesHook) AfterProcess(ctx context.Context, result processor.ProcessResult) error {
+	if result.Status != filestatus.Failed {
+		return nil
+	}
+	h.mu.Lock()
+	h.times = append(h.times, time.Now())
+	h.mu.Unlock()
+	return nil
+}
+
+// count - число отказов за окно до now (старые отметки удаляются)
+func (h *failedFilesHook) count(now time.Time) int {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	keep := h.times[:0]
+	for _, t := range h.times {
+		if now.Sub(t) <= h.window {
+			keep = append(keep, t)
+		}
+	}
+	h.times = keep
+	return len(h.times)
+}
+
+// checkIncidents - проверка устойчивых условий для системы дежурств
+func (a *App) checkIncidents() {
+	// В окне обслуживания с подавлением оповещений условия не проверяются
+	if a.incidents == nil || a.alertsSuppressed() {
+		return
+	}
+	cfg := a.config.Incidents
+	now := time.Now()
+	ctx, cancel := context.WithTimeout(context.Background(), 2*cfg.Timeout)
+	defer cancel()
+
+	dbDown, dbError := false, ""
+	if samples := a.healthHistory.Samples(1); len(samples) > 0 && !samples[0].Healthy {
+		dbDown, dbError = true, samples[0].Error
+	}
+	a.incidents.Observe(ctx, alert.Condition{
+		Key: "database-down", Source: "database", Severity: alert.SeverityCritical,
+		Title: "Database unavailable", For: cfg.DatabaseDownFor, ResolveAfter: cfg.ResolveAfter,
+	}, dbDown, dbError, now)
+
+	if cfg.BacklogFiles > 0 {
+		stats := a.watcher.GetBacklogStats()
+		a.incidents.Observe(ctx, alert.Condition{
+			Key: "backlog", Source: "backlog", Severity: alert.SeverityCritical,
+			Title: "Ingestion backlog not draining", For: cfg.BacklogFor, ResolveAfter: cfg.ResolveAfter,
+		}, stats.Files > cfg.BacklogFiles,
+			fmt.Sprintf("%d files waiting (limit %d), oldest %v", stats.Files, cfg.BacklogFiles,
+				stats.OldestAge.Round(time.Second)), now)
+	}
+
+	if a.failedFiles != nil {
+		failed := a.failedFiles.count(now)
+		a.incidents.Observe(ctx, alert.Condition{
+			Key: "failed-files", Source: "processor", Severity: alert.SeverityWarning,
+			Title: "Repeated file failures", ResolveAfter: cfg.ResolveAfter,
+		}, failed >= cfg.FailedFiles,
+			fmt.Sprintf("%d files moved to the error folder in the last %v", failed, cfg.FailedWindow), now)
+	}
+}
+
+// incidentsResponse - ответ GET /admin/incidents
+type incidentsResponse struct {
+	Provider  string           `json:"provider"`
+	Incidents []alert.Incident `json:"incidents"`
+}
+
+// acknowledgeResponse - ответ POST /admin/incidents/{key}/acknowledge
+type acknowledgeResponse struct {
+	DedupKey     string `json:"dedup_key"`
+	Acknowledged bool   `json:"acknowledged"`
+}
+
+// getIncidents - открытые инциденты системы дежурств
+func (a *App) getIncidents(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	if a.incidents == nil {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Incident channel is not configured"})
+		return
+	}
+	json.NewEncoder(w).Encode(incidentsResponse{
+		Provider:  a.config.Incidents.Provider,
+		Incidents: a.incidents.Open(),
+	})
+}
+
+// acknowledgeIncident - подтверждение инцидента (дежурный взял в работу)
+func (a *App) acknowledgeIncident(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	if a.incidents == nil {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Incident channel is not configured"})
+		return
+	}
+
+	key := mux.Vars(r)["key"]
+	now := time.Now()
+	if !a.incidents.Acknowledge(r.Context(), key, now) {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(map[string]string{"error": "No open incident " + key})
+		return
+	}
+	a.recordAudit(r.Context(), audit.EventIncidentAcknowledged, key, requestActor(r), map[string]interface{}{
+		"acknowledged_at": now,
+	})
+	json.NewEncoder(w).Encode(acknowledgeResponse{DedupKey: key, Acknowledged: true})
+}
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"TSVProcessingService/internal/database"
+	"TSVProcessingService/internal/dto"
+	"TSVProcessingService/internal/jobs"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+// listJobs - журнал заданий: GET /jobs?kind=&state=&owner=&subject=
+// с пагинацией (новые первыми)
+func (a *App) listJobs(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	filter := database.JobFilter{
+		Kind:    query.Get("kind"),
+		State:   query.Get("state"),
+		Owner:   query.Get("owner"),
+		Subject: query.Get("subject"),
+	}
+	if filter.Kind != "" && !slices.Contains(jobs.Kinds, filter.Kind) {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": fmt.Sprintf("kind must be one of: %s", strings.Join(jobs.Kinds, ", ")),
+		})
+		return
+	}
+	if filter.State != "" && !slices.Contains(jobs.States, filter.State) {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": fmt.Sprintf("state must be one of: %s", strings.Join(jobs.States, ", ")),
+		})
+		return
+	}
+
+	pageReq, err := parsePageRequest(r, 50, 500)
+	if err != nil {
+		writeInvalidCursor(w)
+		return
+	}
+	fields, ok := parseFieldsParam(w, r, dto.Job{})
+	if !ok {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	items, total, err := a.store.ListJobs(ctx, filter, int32(pageReq.Limit), int32(pageReq.Offset))
+	if err != nil {
+		a.logger.Error("Failed to list jobs", "error", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Failed to fetch jobs"})
+		return
+	}
+
+	json.NewEncoder(w).Encode(newPageResponse(dto.Project(dto.FromJobs(items), fields), pageReq, total))
+}
+
+// getJob - задание журнала по ID
+// GET /jobs/{id}
+func (a *App) getJob(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Invalid job id"})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	job, err := a.queries.GetJob(ctx, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Job not found"})
+		return
+	}
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Failed to fetch job"})
+		return
+	}
+
+	json.NewEncoder(w).Encode(dto.FromJob(job))
+}
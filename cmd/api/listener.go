@@ -0,0 +1,53 @@
+package main
+
+import (
+	"context"
+	"net"
+	"time"
+)
+
+// listenFdsStart - первый дескриптор, переданный systemd (sd_listen_fds)
+const listenFdsStart = 3
+
+// listen - сокет API. При запуске через systemd socket activation
+// (LISTEN_PID/LISTEN_FDS) используется сокет systemd: он остаётся
+// открытым между перезапусками, и соединения ждут в его очереди, а не
+// отклоняются. Иначе сокет создаётся на addr, с SO_REUSEPORT при
+// server.reuse_port - тогда новый экземпляр слушает порт одновременно
+// со старым.
+func (a *App) listen(addr string) (net.Listener, error) {
+	if l, err := systemdListener(a.logger, listenFdsStart); l != nil || err != nil {
+		return l, err
+	}
+	lc := net.ListenConfig{}
+	if a.config.Server.ReusePort {
+		lc.Control = reusePort
+	}
+	return lc.Listen(context.Background(), "tcp", addr)
+}
+
+// stopAPIServer - закрытие порта API без разрыва соединений: сначала
+// /health/ready отвечает 503 в течение server.shutdown_delay, чтобы
+// балансировщик снял экземпляр, затем сервер перестаёт принимать
+// соединения и дожидается начатых запросов. Вызывается при остановке
+// и при дренаже с server.reuse_port (порт передаётся новому экземпляру).
+func (a *App) stopAPIServer() {
+	a.stopServer.Do(func() {
+		if a.server == nil {
+			return
+		}
+		a.stopping.Store(true)
+		if delay := a.config.Server.ShutdownDelay; delay > 0 {
+			a.logger.Info("⏳ Waiting for load balancers to deregister the instance", "delay", delay)
+			time.Sleep(delay)
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+		if err := a.server.Shutdown(ctx); err != nil {
+			a.logger.Error("Error shutting down API server", "error", err)
+		} else {
+			a.logger.Info("✓ API server stopped")
+		}
+	})
+}
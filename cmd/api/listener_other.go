@@ -0,0 +1,32 @@
+//go:build !unix
+
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"net"
+	"os"
+	"runtime"
+	"syscall"
+)
+
+// errUnsupported - server.reuse_port требует SO_REUSEPORT и flock
+var errUnsupported = fmt.Errorf("server.reuse_port is not supported on %s", runtime.GOOS)
+
+// systemdListener - socket activation есть только у systemd
+func systemdListener(logger *slog.Logger, fd int) (net.Listener, error) {
+	return nil, nil
+}
+
+func reusePort(network, address string, c syscall.RawConn) error {
+	return errUnsupported
+}
+
+func tryLockFile(f *os.File) (bool, error) {
+	return false, errUnsupported
+}
+
+func lockFile(f *os.File) error {
+	return errUnsupported
+}
@@ -0,0 +1,153 @@
+//go:build unix
+
+package main
+
+import (
+	"TSVProcessingService/internal/config"
+	"errors"
+	"log/slog"
+	"net"
+	"os"
+	"strconv"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// passedSocket - дескриптор слушающего сокета, как его передаёт systemd
+// (владеет им systemdListener)
+func passedSocket(t *testing.T) (fd int, addr string) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer l.Close()
+	f, err := l.(*net.TCPListener).File()
+	require.NoError(t, err)
+	defer f.Close()
+	fd, err = syscall.Dup(int(f.Fd()))
+	require.NoError(t, err)
+	return fd, l.Addr().String()
+}
+
+func TestSystemdListener(t *testing.T) {
+	pid := strconv.Itoa(os.Getpid())
+
+	t.Run("passed socket", func(t *testing.T) {
+		fd, addr := passedSocket(t)
+		t.Setenv("LISTEN_PID", pid)
+		t.Setenv("LISTEN_FDS", "1")
+		t.Setenv("LISTEN_FDNAMES", "api")
+
+		l, err := systemdListener(slog.Default(), fd)
+		require.NoError(t, err)
+		require.NotNil(t, l)
+		defer l.Close()
+		assert.Equal(t, addr, l.Addr().String())
+
+		// Дочерние процессы сокет не получают
+		for _, name := range []string{"LISTEN_PID", "LISTEN_FDS", "LISTEN_FDNAMES"} {
+			_, ok := os.LookupEnv(name)
+			assert.False(t, ok, name)
+		}
+
+		accepted := make(chan error, 1)
+		go func() {
+			conn, err := l.Accept()
+			if err == nil {
+				conn.Close()
+			}
+			accepted <- err
+		}()
+		conn, err := net.Dial("tcp", addr)
+		require.NoError(t, err)
+		conn.Close()
+		select {
+		case err := <-accepted:
+			assert.NoError(t, err)
+		case <-time.After(5 * time.Second):
+			t.Fatal("connection was not accepted")
+		}
+	})
+
+	tests := []struct {
+		name string
+		pid  string
+		fds  string
+	}{
+		{"no variables", "", ""},
+		{"other process", strconv.Itoa(os.Getpid() + 1), "1"},
+		{"invalid pid", "systemd", "1"},
+		{"no sockets", pid, "0"},
+		{"invalid count", pid, "one"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("LISTEN_PID", tt.pid)
+			t.Setenv("LISTEN_FDS", tt.fds)
+
+			// Дескриптор не трогается: он может принадлежать другому коду
+			l, err := systemdListener(slog.Default(), -1)
+			assert.NoError(t, err)
+			assert.Nil(t, l)
+			assert.Equal(t, tt.fds, os.Getenv("LISTEN_FDS"))
+		})
+	}
+}
+
+func TestListen_ReusePort(t *testing.T) {
+	// Сокет systemd передан другому процессу: свой сокет на addr
+	t.Setenv("LISTEN_PID", strconv.Itoa(os.Getpid()+1))
+	t.Setenv("LISTEN_FDS", "1")
+
+	newApp := func(reusePort bool) *App {
+		cfg := &config.AppConfig{}
+		cfg.Server.ReusePort = reusePort
+		return &App{config: cfg, logger: slog.Default()}
+	}
+
+	first, err := newApp(true).listen("127.0.0.1:0")
+	require.NoError(t, err)
+	defer first.Close()
+	addr := first.Addr().String()
+
+	// Новый экземпляр слушает порт одновременно со старым
+	second, err := newApp(true).listen(addr)
+	require.NoError(t, err)
+	second.Close()
+
+	// Без reuse_port порт занят
+	_, err = newApp(false).listen(addr)
+	assert.True(t, errors.Is(err, syscall.EADDRINUSE), "%v", err)
+}
+
+func TestLockWatcher_WaitsForDrainingInstance(t *testing.T) {
+	newApp := func() *App {
+		cfg := &config.AppConfig{}
+		cfg.Directory.TempPath = t.TempDir()
+		return &App{config: cfg, logger: slog.Default()}
+	}
+	old, next := newApp(), newApp()
+	next.config.Directory.TempPath = old.config.Directory.TempPath
+
+	require.NoError(t, old.lockWatcher())
+	locked := make(chan error, 1)
+	go func() { locked <- next.lockWatcher() }()
+
+	// Пока старый экземпляр дообрабатывает очередь, новый не сканирует директорию
+	select {
+	case <-locked:
+		t.Fatal("watch directory locked twice")
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	old.unlockWatcher()
+	select {
+	case err := <-locked:
+		require.NoError(t, err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("lock was not released")
+	}
+	next.unlockWatcher()
+}
@@ -0,0 +1,69 @@
+//go:build unix
+
+package main
+
+import (
+	"errors"
+	"log/slog"
+	"net"
+	"os"
+	"strconv"
+	"syscall"
+
+	"golang.org/x/sys/unix"
+)
+
+// systemdListener - сокет, переданный systemd процессу в дескрипторе fd
+// (nil - не передан или передан другому процессу)
+func systemdListener(logger *slog.Logger, fd int) (net.Listener, error) {
+	pid, err := strconv.Atoi(os.Getenv("LISTEN_PID"))
+	if err != nil || pid != os.Getpid() {
+		return nil, nil
+	}
+	n, err := strconv.Atoi(os.Getenv("LISTEN_FDS"))
+	if err != nil || n < 1 {
+		return nil, nil
+	}
+	// Дочерние процессы не должны получить сокет повторно
+	os.Unsetenv("LISTEN_PID")
+	os.Unsetenv("LISTEN_FDS")
+	os.Unsetenv("LISTEN_FDNAMES")
+	if n > 1 {
+		logger.Warn("⚠️ systemd passed several sockets, using the first one", "count", n)
+	}
+
+	syscall.CloseOnExec(fd)
+	f := os.NewFile(uintptr(fd), "systemd-socket")
+	defer f.Close() // FileListener работает с копией дескриптора
+	l, err := net.FileListener(f)
+	if err != nil {
+		return nil, err
+	}
+	logger.Info("🔌 Using socket from systemd", "addr", l.Addr())
+	return l, nil
+}
+
+// reusePort включает SO_REUSEPORT на сокете перед bind
+func reusePort(network, address string, c syscall.RawConn) error {
+	var opErr error
+	if err := c.Control(func(fd uintptr) {
+		opErr = unix.SetsockoptInt(int(fd), unix.SOL_SOCKET, unix.SO_REUSEPORT, 1)
+	}); err != nil {
+		return err
+	}
+	return opErr
+}
+
+// tryLockFile - flock без ожидания; false - блокировку держит другой процесс
+func tryLockFile(f *os.File) (bool, error) {
+	err := unix.Flock(int(f.Fd()), unix.LOCK_EX|unix.LOCK_NB)
+	if errors.Is(err, unix.EWOULDBLOCK) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// lockFile - flock с ожиданием освобождения
+func lockFile(f *os.File) error {
+	return unix.Flock(int(f.Fd()), unix.LOCK_EX)
+}
@@ -3,24 +3,55 @@ package main
 
 import (
 	"TSVProcessingService/db/sqlc"
+	"TSVProcessingService/internal/alert"
+	"TSVProcessingService/internal/anomaly"
+	"TSVProcessingService/internal/apilog"
+	"TSVProcessingService/internal/archive"
+	"TSVProcessingService/internal/audit"
+	"TSVProcessingService/internal/breaker"
+	"TSVProcessingService/internal/cache"
+	"TSVProcessingService/internal/clickhouse"
+	"TSVProcessingService/internal/clock"
 	"TSVProcessingService/internal/config"
 	"TSVProcessingService/internal/database"
+	"TSVProcessingService/internal/digest"
+	"TSVProcessingService/internal/dispatch"
+	"TSVProcessingService/internal/dto"
+	"TSVProcessingService/internal/features"
+	"TSVProcessingService/internal/filemeta"
+	"TSVProcessingService/internal/filestatus"
+	"TSVProcessingService/internal/health"
+	"TSVProcessingService/internal/ingest"
+	"TSVProcessingService/internal/jobs"
+	"TSVProcessingService/internal/locale"
+	"TSVProcessingService/internal/logger"
+	"TSVProcessingService/internal/maintenance"
+	"TSVProcessingService/internal/metrics"
 	"TSVProcessingService/internal/processor"
+	"TSVProcessingService/internal/safepath"
+	"TSVProcessingService/internal/sequence"
+	"TSVProcessingService/internal/signedurl"
+	"TSVProcessingService/internal/sorting"
+	"TSVProcessingService/internal/subscription"
+	"TSVProcessingService/internal/supervisor"
+	"TSVProcessingService/internal/throttle"
+	"TSVProcessingService/internal/tracing"
+	"TSVProcessingService/internal/usage"
 	"TSVProcessingService/internal/watcher"
 	"context"
-	"crypto/sha256"
 	"database/sql"
-	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
-	"io"
-	"log"
+	"log/slog"
 	"net/http"
 	"os"
 	"os/signal"
-	"path/filepath"
+	"runtime/debug"
+	"slices"
 	"strconv"
 	"sync"
+	"sync/atomic"
 	"syscall"
 	"time"
 
@@ -30,32 +61,67 @@ import (
 
 // App - основная структура приложения
 type App struct {
-	config    *config.AppConfig
-	store     *database.Store
-	queries   *sqlc.Queries
-	watcher   *watcher.Watcher
-	processor *processor.Processor
-	router    *mux.Router
-	server    *http.Server
-	workerWg  sync.WaitGroup
+	config        *config.AppConfig
+	store         *database.Store
+	queries       *sqlc.Queries
+	watcher       *watcher.Watcher
+	processor     *processor.Processor
+	limiter       *throttle.Limiter
+	dispatcher    *dispatch.Dispatcher // nil - общая очередь воркеров
+	cache         cache.Cache
+	alerts        *alert.Dispatcher
+	incidents     *alert.IncidentManager // nil - система дежурств не настроена
+	maintenance   *maintenance.Calendar  // nil - окна обслуживания не настроены
+	failedFiles   *failedFilesHook
+	healthHistory *health.History
+	supervisor    *supervisor.Supervisor
+	apiLogs       *apilog.Writer
+	batches       *batchRegistry
+	inflight      *inflightFiles
+	digests       *digest.Scheduler
+	archiver      *archive.Archiver
+	archiveReader *archive.Reader // nil - архив не подключён
+	clickhouse    *clickhouse.Sink
+	subscriptions *subscription.Deliverer // nil - подписки на отчёты выключены
+	audit         *audit.Log              // nil - журнал аудита выключен
+	access        *accessRoles
+	downloads     *signedurl.Signer
+	usage         *usage.Collector  // nil - подсчёт хранилища отключён
+	sequence      *sequence.Checker // nil - проверка последовательностей n отключена
+	jobs          *jobs.Manager
+	readBreaker   *breaker.Breaker // nil - чтение без автомата защиты
+	features      *features.Flags
+	clock         clock.Clock // расписание фоновых задач
+	router        *mux.Router
+	server        *http.Server
+	workerWg      sync.WaitGroup
+	draining      atomic.Bool             // приём файлов закрыт, очередь дообрабатывается (drain.go)
+	drained       chan struct{}           // закрывается, когда воркеры дообработали очередь
+	stopping      atomic.Bool             // порт API закрывается (listener.go)
+	watchLock     atomic.Pointer[os.File] // блокировка watch-директории при server.reuse_port (drain.go)
+	stopServer    sync.Once
+	logger        *slog.Logger
+	closeLog      func() error // закрытие файла журнала (logging.output: file)
 }
 
 func main() {
 	// Инициализация приложения
 	app, err := initializeApp()
 	if err != nil {
-		log.Fatalf("Failed to initialize application: %v", err)
+		slog.Error("Failed to initialize application", "error", err)
+		os.Exit(1)
 	}
 
 	// Запуск приложения
 	if err := app.Run(); err != nil {
-		log.Fatalf("Application error: %v", err)
+		slog.Error("Application error", "error", err)
+		os.Exit(1)
 	}
 }
 
 // initializeApp - инициализация всех компонентов приложения
 func initializeApp() (*App, error) {
-	log.Println("🚀 Initializing TSV Processing Service...")
+	slog.Info("🚀 Initializing TSV Processing Service...")
 
 	// 1. Загрузка конфигурации
 	cfg, err := config.LoadConfig("")
@@ -63,6 +129,13 @@ func initializeApp() (*App, error) {
 		return nil, fmt.Errorf("failed to load config: %w", err)
 	}
 
+	// Журнал по logging: JSON или text, уровень, файл с ротацией. App,
+	// Processor и Watcher получают его с атрибутом component
+	appLog, closeLog, err := logger.Setup(cfg.Logging)
+	if err != nil {
+		return nil, fmt.Errorf("failed to set up logging: %w", err)
+	}
+
 	if cfg.IsDebugMode() {
 		cfg.PrintConfig()
 	}
@@ -78,17 +151,55 @@ func initializeApp() (*App, error) {
 		return nil, fmt.Errorf("failed to connect to database: %w", err)
 	}
 
-	// Создаем Store
+	// Создаем Store. Длительность запросов учитывается по имени запроса
+	// sqlc (tsv_db_query_duration_seconds), медленные пишутся в лог
+	slowQuery := cfg.Database.SlowQueryThreshold
+	timedDB := database.InstrumentDB(db, slowQuery)
 	store := database.NewStore(db)
-	queries := sqlc.New(db)
+	store.Instrument(slowQuery)
+	queries := sqlc.New(timedDB)
+
+	// Запросы API к БД учитываются автоматом защиты чтения;
+	// processor и журнал заданий работают с БД напрямую
+	var readBreaker *breaker.Breaker
+	apiQueries := queries
+	if cfg.ReadBreaker.Enabled {
+		readBreaker = newReadBreaker(&cfg.ReadBreaker)
+		store.Guard(readBreaker)
+		apiQueries = sqlc.New(database.GuardDB(timedDB, readBreaker))
+		metrics.Default.NewGaugeFunc("tsv_read_breaker_open", "1 if reads are rejected or probed (degraded mode)",
+			func() float64 {
+				if readBreaker.State() == breaker.StateClosed {
+					return 0
+				}
+				return 1
+			})
+	}
 
 	// 4. Проверка существования таблиц
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
 	if err := store.CheckTablesExist(ctx); err != nil {
-		log.Printf("Warning: %v", err)
-		log.Println("Please run database migrations first")
+		slog.Warn("⚠️ Database tables are missing, please run database migrations first", "error", err)
+	}
+	if missing, err := store.CheckIndexes(ctx); err != nil {
+		slog.Warn("⚠️ Failed to check indexes", "error", err)
+	} else if len(missing) > 0 {
+		slog.Warn("⚠️ Required indexes are missing, run database migrations (000029_add_query_indexes)", "missing", len(missing))
+	}
+
+	// Журнал заданий: обработка файлов, отчёты, очистка, архивация, backfill.
+	// Задания, прерванные прошлой остановкой, помечаются failed.
+	jobManager := jobs.NewManager(queries)
+	// Фоновые подсистемы ограничены долями пула соединений
+	poolShares := jobs.NewPoolShares(cfg.Database.MaxOpenConns, cfg.Database.PoolShares)
+	poolShares.LogLimits()
+	jobManager.SetPoolShares(poolShares)
+	if n, err := jobManager.Recover(ctx); err != nil {
+		slog.Warn("⚠️ Failed to recover interrupted jobs", "error", err)
+	} else if n > 0 {
+		slog.Info("🗂️ Marked interrupted jobs as failed", "count", n)
 	}
 
 	// 5. Создание watcher
@@ -97,27 +208,210 @@ func initializeApp() (*App, error) {
 		cfg.Worker.ScanInterval,
 		cfg.Worker.MaxQueueSize,
 	)
+	watcher.SetLogger(logger.Component(appLog, "watcher"))
+	watcher.SetIgnorePatterns(cfg.Directory.IgnorePatterns)
+	watcher.SetMinFileAge(cfg.Worker.MinFileAge)
+	watcher.SetHashWorkers(cfg.Worker.HashWorkers)
+	watcher.SetMode(cfg.Watcher.Mode, cfg.Watcher.Debounce)
+	watcher.SetSandbox(cfg.Sandbox.Enabled)
+	if cfg.Directory.StateFile != "" {
+		watcher.SetStateFile(cfg.Directory.StateFile)
+		restored, err := watcher.LoadState()
+		if err != nil {
+			slog.Warn("⚠️ Watcher state not restored", "error", err)
+		} else {
+			slog.Info("📂 Restored backlog files", "count", restored, "state_file", cfg.Directory.StateFile)
+		}
+	}
+	metrics.Default.NewGaugeFunc("tsv_queue_depth", "Files waiting in the processing queue",
+		func() float64 { return float64(watcher.QueueStats().Depth) })
 
 	// 6. Создание processor
 	processor := processor.NewProcessor(db, queries, &cfg.Directory)
+	processor.SetLogger(logger.Component(appLog, "processor"))
+	processor.SetStageBudgets(processorStageBudgets(cfg))
+	processor.SetProgressInterval(cfg.Worker.ProgressEvery, cfg.Worker.ProgressInterval)
+	processor.SetReportConfig(cfg.Report)
+	processor.SetSerializePerUnit(cfg.Worker.SerializePerUnit)
+	processor.SetConflictPolicy(cfg.Worker.ConflictPolicy)
+	processor.SetDuplicateReport(cfg.Worker.DuplicateReport)
+	processor.SetAllowDuplicateContent(cfg.Worker.AllowDuplicateContent)
+	processor.SetSandbox(cfg.Sandbox.Enabled)
+	if cfg.Sandbox.Enabled {
+		slog.Info("🧪 Sandbox mode: source files are copied and never moved or deleted", "schema", cfg.Sandbox.Schema)
+	}
+	featureFlags := features.New(cfg.Features.Flags, cfg.Features.Tenants)
+	if err := featureFlags.Refresh(context.Background(), queries); err != nil {
+		slog.Warn("⚠️ Feature flag overrides not loaded", "error", err)
+	}
+	processor.SetFeatures(featureFlags)
+	processor.SetProfiling(cfg.Worker.ProfileFields)
+	processor.SetDeltaSources(cfg.Worker.DeltaSources)
+	processor.SetSequenceRuns(cfg.Sequence.Enabled)
+	processor.SetBatchSize(cfg.Worker.BatchSize)
+	processor.SetReportMemoryBudget(int64(cfg.Worker.ReportMemoryMB) << 20)
+	processor.SetStreaming(int64(cfg.Worker.StreamMinSizeMB)<<20, cfg.Worker.StreamChunkRows)
+	processor.SetStageConcurrency(cfg.Worker.ParseWorkers, cfg.Worker.InsertWorkers)
+	processor.SetTxWrapper(func(tx sqlc.DBTX) sqlc.DBTX {
+		return database.InstrumentDB(tx, slowQuery)
+	})
+	processor.SetRetry(cfg.Worker.RetryAttempts, cfg.Worker.RetryDelay, watcher.Requeue)
+	if meta := cfg.FileMetadata; meta.Pattern != "" {
+		pattern, err := filemeta.Compile(meta.Pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid filename_metadata.pattern: %w", err)
+		}
+		processor.SetFilenameMetadata(pattern, meta.ArchiveDir, meta.ReportName)
+		slog.Info("🏷️ Filename metadata enabled", "fields", pattern.Fields())
+	}
+	processor.SetJobs(jobManager)
+
+	// Кэш сбрасывается hook'ом после фиксации данных каждого файла
+	appCache := newCache(&cfg.Cache)
+	processor.RegisterHook(&cacheInvalidationHook{cache: appCache})
+	processor.RegisterHook(&ingestLatencyHook{sla: cfg.SLA.IngestLatency})
+	registerPostProcessHooks(processor, cfg)
 
 	// 7. Инициализация структуры приложения
 	app := &App{
 		config:    cfg,
 		store:     store,
-		queries:   queries,
+		queries:   apiQueries,
 		watcher:   watcher,
 		processor: processor,
+		features:  featureFlags,
+		cache:     appCache,
+		alerts:    newAlertDispatcher(&cfg.Alerts),
+		access:    newAccessRoles(&cfg.Access),
 		router:    mux.NewRouter(),
+		healthHistory: health.NewHistory(cfg.Health.HistorySize,
+			cfg.Health.FlapWindow, cfg.Health.FlapThreshold),
+		supervisor:  supervisor.New(cfg.Supervisor.MinBackoff, cfg.Supervisor.MaxBackoff),
+		batches:     newBatchRegistry(),
+		inflight:    newInflightFiles(),
+		drained:     make(chan struct{}),
+		jobs:        jobManager,
+		readBreaker: readBreaker,
+		clock:       clock.Real,
+		logger:      logger.Component(appLog, "api"),
+		closeLog:    closeLog,
+	}
+
+	// Подпись ссылок на скачивание отчётов
+	if app.downloads, err = newDownloadSigner(&cfg.Downloads); err != nil {
+		return nil, fmt.Errorf("failed to create download signer: %w", err)
+	}
+
+	// Окна обслуживания: пауза приёма и подавление оповещений
+	app.maintenance = newMaintenanceCalendar(&cfg.Maintenance)
+	if app.maintenance != nil {
+		watcher.SetPaused(app.maintenance.IngestionPaused)
+		app.alerts.SetSuppressed(app.maintenance.AlertsSuppressed)
+	}
+
+	// 8. Асинхронный журнал API-запросов (опционально)
+	if cfg.APILog.Enabled {
+		app.apiLogs = apilog.NewWriter(store, cfg.APILog.QueueSize,
+			cfg.APILog.BatchSize, cfg.APILog.FlushInterval)
+	}
+
+	// 9. Периодический дайджест (опционально)
+	if cfg.Digest.Enabled {
+		app.digests = newDigestScheduler(store, cfg)
+		slog.Info("📰 Digest enabled", "schedules", cfg.Digest.Schedules, "hour", cfg.Digest.Hour)
+	}
+
+	// Архивация старых данных в Parquet (опционально)
+	if cfg.Archive.Enabled {
+		archiveStore := newArchiveStore(&cfg.Archive)
+		app.archiver = app.newArchiver(db, queries, archiveStore)
+		app.archiveReader = archive.NewReader(queries, archiveStore)
+		slog.Info("🧊 Archive enabled", "older_than_months", cfg.Archive.OlderThanMonths, "storage", cfg.Archive.Storage)
+	}
+
+	// Выгрузка device_data в ClickHouse (опционально)
+	if cfg.ClickHouse.Enabled {
+		app.clickhouse = newClickHouseSink(ctx, queries, &cfg.ClickHouse)
+		processor.RegisterHook(app.clickhouse)
+		slog.Info("📤 ClickHouse sink enabled", "url", cfg.ClickHouse.URL, "database", cfg.ClickHouse.Database, "table", cfg.ClickHouse.Table)
+	}
+
+	// Подписки внешних систем на новые отчёты (опционально)
+	if cfg.Subscriptions.Enabled {
+		app.subscriptions = newReportDeliverer(queries, &cfg.Subscriptions)
+		processor.OnReportCreated(app.subscriptions.ReportCreated)
+		slog.Info("📨 Report subscriptions enabled", "public_url", cfg.Subscriptions.PublicURL, "max_attempts", cfg.Subscriptions.MaxAttempts)
+	}
+
+	// Журнал аудита загрузки (опционально)
+	if cfg.Audit.Enabled {
+		app.audit = audit.NewLog(queries)
+		processor.RegisterHook(audit.NewHook(app.audit))
+		slog.Info("🔏 Audit log enabled (append-only, hash-chained)")
 	}
 
-	log.Println("✅ Application initialized successfully")
+	// Поиск аномалий загрузки (опционально)
+	if cfg.Anomaly.Enabled {
+		processor.RegisterHook(anomaly.NewDetector(queries, app.alerts, anomaly.Options{
+			MinHistory: cfg.Anomaly.MinHistory,
+			Window:     cfg.Anomaly.Window,
+			Threshold:  cfg.Anomaly.Threshold,
+		}))
+		slog.Info("🔍 Anomaly detection enabled", "window", cfg.Anomaly.Window, "threshold", cfg.Anomaly.Threshold)
+	}
+
+	// Инциденты в системе дежурств (опционально)
+	app.incidents = newIncidentManager(&cfg.Incidents)
+	if app.incidents != nil && cfg.Incidents.FailedFiles > 0 {
+		app.failedFiles = &failedFilesHook{window: cfg.Incidents.FailedWindow}
+		processor.RegisterHook(app.failedFiles)
+	}
+
+	// Подсчёт использования хранилища
+	if cfg.Storage.Enabled {
+		app.usage = newUsageCollector(store, poolShares, cfg)
+	}
+
+	// Поиск пропусков в последовательностях n устройств (опционально)
+	if cfg.Sequence.Enabled {
+		app.sequence = newSequenceChecker(queries, app.alerts, &cfg.Sequence)
+		slog.Info("🔢 Sequence check enabled", "interval", cfg.Sequence.Interval, "lookback", cfg.Sequence.Lookback)
+	}
+
+	// 10. Ограничение скорости приёма по источникам (опционально)
+	if cfg.Throttle.Enabled {
+		app.limiter = throttle.NewLimiter(cfg.Throttle, cfg.Worker.MaxQueueSize)
+		slog.Info("🚦 Ingestion throttling enabled", "files_per_minute", cfg.Throttle.DefaultFilesPerMinute)
+	}
+
+	slog.Info("✅ Application initialized successfully")
 	return app, nil
 }
 
+// processorStageBudgets - доли общего таймаута для этапов обработки файла
+func processorStageBudgets(cfg *config.AppConfig) processor.StageBudgets {
+	return processor.StageBudgets{
+		Parse:  cfg.Worker.ParseBudget,
+		Insert: cfg.Worker.InsertBudget,
+		Report: cfg.Worker.ReportBudget,
+	}
+}
+
+// registerPostProcessHooks - регистрация post-processing hooks из конфигурации
+func registerPostProcessHooks(p *processor.Processor, cfg *config.AppConfig) {
+	if dir := cfg.PostProcess.CopyToDir; dir != "" {
+		p.RegisterHook(processor.NewCopyHook(dir))
+		slog.Info("🔗 Post-process hook: copy", "dir", dir)
+	}
+	if command := cfg.PostProcess.Command; command != "" {
+		p.RegisterHook(processor.NewCommandHook(command, cfg.PostProcess.CommandTimeout))
+		slog.Info("🔗 Post-process hook: command", "command", command)
+	}
+}
+
 // createDirectories - создание необходимых директорий
 func createDirectories(cfg *config.AppConfig) error {
-	log.Println("📁 Creating directories...")
+	slog.Info("📁 Creating directories...")
 
 	dirs := []string{
 		cfg.Directory.WatchPath,
@@ -125,6 +419,7 @@ func createDirectories(cfg *config.AppConfig) error {
 		cfg.Directory.ArchivePath,
 		cfg.Directory.ErrorPath,
 		cfg.Directory.TempPath,
+		cfg.Directory.HoldPath,
 		"logs",
 	}
 
@@ -132,7 +427,7 @@ func createDirectories(cfg *config.AppConfig) error {
 		if err := os.MkdirAll(dir, 0755); err != nil {
 			return fmt.Errorf("failed to create directory %s: %w", dir, err)
 		}
-		log.Printf("  ✓ Created: %s", dir)
+		slog.Debug("✓ Created directory", "dir", dir)
 	}
 
 	return nil
@@ -140,7 +435,7 @@ func createDirectories(cfg *config.AppConfig) error {
 
 // Run - запуск основного цикла приложения
 func (a *App) Run() error {
-	log.Println("🚀 Starting application...")
+	a.logger.Info("🚀 Starting application...")
 
 	// Запуск компонентов приложения
 	// 1. Запуск мониторинга директории
@@ -149,7 +444,10 @@ func (a *App) Run() error {
 	// 2. Запуск воркеров
 	go a.startWorkers()
 
-	// 3. Запуск API сервера
+	// 3. Запуск API сервера (и записи журнала запросов)
+	if a.apiLogs != nil {
+		go a.apiLogs.Run()
+	}
 	go a.startAPIServer()
 
 	// 4. Запуск health checks
@@ -158,59 +456,225 @@ func (a *App) Run() error {
 	// 5. Запуск очистки старых данных
 	go a.startCleanupTasks()
 
+	// 6. Запуск планировщика дайджестов
+	if a.digests != nil {
+		go a.digests.Run()
+	}
+
+	// 7. Запуск архивации старых данных
+	if a.archiver != nil {
+		go a.archiver.Run()
+	}
+
+	// 8. Запуск выгрузки в ClickHouse
+	if a.clickhouse != nil {
+		go a.clickhouse.Run()
+	}
+
+	// 9. Запуск доставки уведомлений подписчикам
+	if a.subscriptions != nil {
+		go a.subscriptions.Run()
+	}
+
+	// 10. Запуск подсчёта использования хранилища
+	if a.usage != nil {
+		go a.usage.Run()
+	}
+
+	// 11. Запуск проверки последовательностей n устройств
+	if a.sequence != nil {
+		go a.sequence.Run()
+	}
+
+	// 12. Перечитывание переопределений флагов возможностей
+	go a.refreshFeatures()
+
 	// Ожидание сигнала завершения
 	return a.waitForShutdown()
 }
 
 // startDirectoryWatcher - запуск мониторинга директории
 func (a *App) startDirectoryWatcher() {
-	log.Printf("👀 Starting directory watcher for: %s", a.config.Directory.WatchPath)
-	// Запускаем watcher (он сам наполняет очередь)
-	go a.watcher.Start()
+	if a.config.Server.ReusePort {
+		if err := a.lockWatcher(); err != nil {
+			a.logger.Error("Failed to lock the watch directory, scanning without the lock", "error", err)
+		}
+		// Дренаж начался, пока предыдущий экземпляр держал директорию
+		if a.draining.Load() {
+			a.unlockWatcher()
+			return
+		}
+	}
+	a.logger.Info("👀 Starting directory watcher", "dir", a.config.Directory.WatchPath)
+	// Запускаем watcher (он сам наполняет очередь) под наблюдением супервизора
+	stallTimeout := a.config.Worker.ScanInterval + a.config.Supervisor.StallTimeout
+	a.supervisor.Go("watcher", stallTimeout, func(beat func()) error {
+		a.watcher.SetHeartbeat(beat)
+		a.watcher.Start()
+		return nil
+	})
 }
 
 // startWorkers - запуск пула воркеров для параллельной обработки файлов
 func (a *App) startWorkers() {
-	log.Printf("👷 Starting %d workers", a.config.Worker.MaxWorkers)
+	a.logger.Info("👷 Starting workers", "workers", a.config.Worker.MaxWorkers)
+
+	// Отдельная очередь отчётов, чтобы PDF не задерживал обработку файлов
+	if a.config.Worker.AsyncReports {
+		a.processor.StartReportWorkers(
+			a.config.Worker.ReportWorkers,
+			a.config.Worker.ReportQueueSize,
+			a.config.Worker.ReportTimeout,
+		)
+	}
+
+	// Прогресс файлов виден до commit их транзакций, в том числе другим экземплярам
+	a.processor.StartProgressPublisher()
 
 	fileQueue := a.watcher.GetFileQueue()
 
-	// Запускаем указанное количество воркеров
-	for i := 0; i < a.config.Worker.MaxWorkers; i++ {
+	// При включённом throttling воркеры читают очередь после ограничителя
+	if a.limiter != nil {
+		go a.limiter.Run(fileQueue)
+		fileQueue = a.limiter.Output()
+	}
+
+	// Собственные очереди воркеров при стратегии, отличной от shared
+	workers := a.config.Worker.MaxWorkers
+	if strategy := a.config.Worker.Assignment; strategy != "" && strategy != dispatch.StrategyShared {
+		d, err := dispatch.NewDispatcher(strategy, workers, a.config.Worker.MaxQueueSize/workers, a.dispatchKey)
+		if err != nil {
+			a.logger.Warn("⚠️ Worker assignment unavailable, using shared queue", "strategy", strategy, "error", err)
+		} else {
+			a.logger.Info("🔀 Worker assignment strategy", "strategy", strategy)
+			a.dispatcher = d
+			go d.Run(fileQueue)
+		}
+	}
+
+	// Запускаем указанное количество воркеров; упавший воркер перезапускается супервизором
+	stallTimeout := a.config.Worker.ProcessTimeout + a.config.Supervisor.StallTimeout
+	for i := 0; i < workers; i++ {
+		id := i + 1
+		queue := fileQueue
+		if a.dispatcher != nil {
+			queue = a.dispatcher.Queue(i)
+		}
 		a.workerWg.Add(1)
-		go a.worker(i+1, fileQueue)
+		done := a.supervisor.Go(fmt.Sprintf("worker-%d", id), stallTimeout, func(beat func()) error {
+			return a.worker(id, queue, beat)
+		})
+		go func() {
+			<-done
+			a.workerWg.Done()
+		}()
 	}
 }
 
 // worker - отдельный воркер, обрабатывающий файлы из очереди
-func (a *App) worker(id int, fileQueue <-chan watcher.FileInfo) {
-	defer a.workerWg.Done()
-	log.Printf("  👤 Worker %d started", id)
+func (a *App) worker(id int, fileQueue <-chan watcher.FileInfo, beat func()) error {
+	a.logger.Debug("👤 Worker started", "worker", id)
+
+	// Heartbeat в простое, чтобы супервизор отличал ожидание от зависания
+	idle := time.NewTicker(a.config.Supervisor.HeartbeatInterval)
+	defer idle.Stop()
+
+	// Файлы из POST /files/{filename}/prioritize берутся раньше основной очереди
+	priority := a.watcher.GetPriorityQueue()
+
+	for {
+		var fileInfo watcher.FileInfo
+		select {
+		case info, ok := <-priority:
+			if !ok {
+				priority = nil
+				continue
+			}
+			fileInfo = info
+		default:
+			select {
+			case <-idle.C:
+				beat()
+				continue
+			case info, ok := <-priority:
+				if !ok {
+					priority = nil
+					continue
+				}
+				fileInfo = info
+			case info, ok := <-fileQueue:
+				if !ok {
+					a.logger.Debug("👤 Worker stopped (queue closed)", "worker", id)
+					return nil
+				}
+				fileInfo = info
+			}
+		}
+		beat()
+		watcher.Dequeued(fileInfo)
+		owner := fmt.Sprintf("worker-%d", id)
+		a.processor.RecordEvent(fileInfo.Name, processor.EventClaimed, owner)
 
-	for fileInfo := range fileQueue {
-		log.Printf("Worker %d: processing file: %s (hash: %s)",
-			id, fileInfo.Name, fileInfo.Hash[:8])
+		a.logger.Info("Worker processing file", "worker", id, "file", fileInfo.Name, "hash", fileInfo.Hash[:8])
 
 		// Обработка файла через processor
-		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Minute)
-		err := a.processor.ProcessFile(ctx, fileInfo)
+		fileInfo.Source = a.fileSource(fileInfo)
+		a.batchFileStarted(fileInfo)
+		if a.dispatcher != nil {
+			a.dispatcher.Started(id - 1)
+		}
+		// Отмена через POST /files/{filename}/cancel - с причиной processor.ErrCancelled
+		parent, cancelCause := context.WithCancelCause(context.Background())
+		ctx, cancel := context.WithTimeout(parent, a.config.Worker.ProcessTimeout)
+		inflight := a.inflight.add(fileInfo.Name, id, cancelCause)
+		spec := jobs.Spec{
+			Kind:     jobs.KindFile,
+			Subject:  fileInfo.Name,
+			Owner:    owner,
+			Priority: fileInfo.Priority,
+		}
+		err := a.jobs.Run(ctx, spec, func(ctx context.Context) error {
+			return a.processSafely(ctx, fileInfo)
+		})
+		a.inflight.remove(fileInfo.Name, inflight)
 		cancel()
-
-		if err != nil {
-			log.Printf("Worker %d: error processing file %s: %v",
-				id, fileInfo.Name, err)
+		cancelCause(nil)
+		if a.dispatcher != nil {
+			a.dispatcher.Finished(id - 1)
+		}
+		a.batchFileFinished(fileInfo, err)
+
+		var stageErr *processor.StageTimeoutError
+		if errors.Is(err, processor.ErrCancelled) {
+			a.logger.Info("⏹️ File cancelled", "worker", id, "file", fileInfo.Name)
+		} else if errors.Is(err, processor.ErrNotReady) {
+			a.logger.Info("File is not ready yet", "worker", id, "file", fileInfo.Name)
+		} else if errors.As(err, &stageErr) {
+			a.logger.Warn("⏱️ File timed out", "worker", id, "file", fileInfo.Name, "stage", stageErr.Stage, "error", err)
+		} else if err != nil {
+			a.logger.Error("Error processing file", "worker", id, "file", fileInfo.Name, "error", err)
 		} else {
-			log.Printf("Worker %d: completed file %s", id, fileInfo.Name)
+			a.logger.Info("Worker completed file", "worker", id, "file", fileInfo.Name)
 		}
+		beat()
 	}
+}
 
-	log.Printf("  👤 Worker %d stopped (queue closed)", id)
+// processSafely - обработка файла; panic превращается в ошибку,
+// и воркер продолжает со следующим файлом
+func (a *App) processSafely(ctx context.Context, fileInfo watcher.FileInfo) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = &processor.PanicError{Value: r, Stack: debug.Stack()}
+		}
+	}()
+	return a.processor.ProcessFile(ctx, fileInfo)
 }
 
 // startAPIServer - запуск API сервера
 func (a *App) startAPIServer() {
 	addr := a.config.Server.GetListenAddr()
-	log.Printf("🌐 Starting API server on %s", addr)
+	a.logger.Info("🌐 Starting API server", "addr", addr)
 
 	// Настраиваем маршруты
 	a.setupRoutes()
@@ -224,10 +688,16 @@ func (a *App) startAPIServer() {
 		IdleTimeout:  120 * time.Second,
 	}
 
-	// Запускаем сервер в горутине
+	// Сокет открываем сразу, чтобы ошибка bind не терялась в горутине
+	listener, err := a.listen(addr)
+	if err != nil {
+		a.logger.Error("❌ Failed to start API server", "error", err)
+		os.Exit(1)
+	}
 	go func() {
-		if err := a.server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
-			log.Fatalf("❌ Failed to start API server: %v", err)
+		if err := a.server.Serve(listener); err != nil && err != http.ErrServerClosed {
+			a.logger.Error("❌ API server failed", "error", err)
+			os.Exit(1)
 		}
 	}()
 }
@@ -236,25 +706,99 @@ func (a *App) startAPIServer() {
 func (a *App) setupRoutes() {
 	// Health check
 	a.router.HandleFunc("/health", a.healthCheck).Methods("GET")
+	a.router.HandleFunc("/health/ready", a.readinessCheck).Methods("GET")
+	a.router.Handle("/metrics", metrics.Default.Handler()).Methods("GET")
 
 	// API v1
 	v1 := a.router.PathPrefix("/api/v1").Subrouter()
+	v1.Use(tracingMiddleware)
+	if a.apiLogs != nil {
+		v1.Use(a.loggingMiddleware)
+	}
+	if a.readBreaker != nil {
+		v1.Use(a.readBreakerMiddleware)
+	}
 
 	// Device data endpoints
 	v1.HandleFunc("/devices/{unit_guid}/data", a.getDeviceData).Methods("GET")
+	v1.HandleFunc("/devices/{unit_guid}/errors", a.getDeviceErrors).Methods("GET")
+	v1.HandleFunc("/devices/{unit_guid}/summary", a.getDeviceSummary).Methods("GET")
+	v1.HandleFunc("/devices/{unit_guid}/sequence", a.getDeviceSequence).Methods("GET")
 
 	// File endpoints
 	v1.HandleFunc("/files", a.getFiles).Methods("GET")
+	v1.HandleFunc("/files/process-batch", a.withIdempotency(a.processBatch)).Methods("POST")
+	v1.HandleFunc("/files/batches/{batch_id}", a.getBatchStatus).Methods("GET")
 	v1.HandleFunc("/files/{filename}", a.getFileStatus).Methods("GET")
 	v1.HandleFunc("/files/{filename}/errors", a.getFileErrors).Methods("GET")
-	v1.HandleFunc("/files/{filename}/process", a.processFile).Methods("POST")
+	v1.HandleFunc("/files/{filename}/timeline", a.getFileTimeline).Methods("GET")
+	v1.HandleFunc("/files/{filename}/duplicates", a.getFileDuplicates).Methods("GET")
+	v1.HandleFunc("/files/{filename}/profile", a.getFileProfile).Methods("GET")
+	v1.HandleFunc("/files/{filename}/process", a.withIdempotency(a.processFile)).Methods("POST")
+	v1.HandleFunc("/files/{filename}/supersede", a.supersedeFile).Methods("POST")
+	v1.HandleFunc("/files/{filename}/prioritize", a.prioritizeFile).Methods("POST")
+	v1.HandleFunc("/files/{filename}/cancel", a.cancelFile).Methods("POST")
 
 	// Report endpoints
+	v1.HandleFunc("/reports", a.listReports).Methods("GET")
+	v1.HandleFunc("/reports/verify", a.verifyReport).Methods("GET", "POST")
+	v1.HandleFunc("/reports/jobs/{job_id}", a.getReportJob).Methods("GET")
+	v1.HandleFunc("/reports/groups/{group_id}", a.getReportGroup).Methods("GET")
+	v1.HandleFunc("/reports/groups/{group_id}/download", a.withSignedDownload(a.downloadReportGroup)).Methods("GET")
+	v1.HandleFunc("/reports/groups/{group_id}/download-url", a.getReportGroupDownloadURL).Methods("GET")
 	v1.HandleFunc("/reports/{unit_guid}", a.getReports).Methods("GET")
-	v1.HandleFunc("/reports/{unit_guid}/generate", a.generateReport).Methods("POST")
+	v1.HandleFunc("/reports/{unit_guid}/generate", a.withIdempotency(a.generateReport)).Methods("POST")
+	v1.HandleFunc("/reports/{id:[0-9]+}/download", a.withSignedDownload(a.downloadReportByID)).Methods("GET", "HEAD")
+	v1.HandleFunc("/reports/{unit_guid}/{id:[0-9]+}/download", a.withSignedDownload(a.downloadReport)).Methods("GET", "HEAD")
+	v1.HandleFunc("/reports/{unit_guid}/{id:[0-9]+}/download-url", a.getReportDownloadURL).Methods("GET")
+
+	// Subscription endpoints
+	v1.HandleFunc("/subscriptions", a.createSubscription).Methods("POST")
+	v1.HandleFunc("/subscriptions", a.listSubscriptions).Methods("GET")
+	v1.HandleFunc("/subscriptions/{id:[0-9]+}", a.deleteSubscription).Methods("DELETE")
+	v1.HandleFunc("/subscriptions/{id:[0-9]+}/deliveries", a.getSubscriptionDeliveries).Methods("GET")
 
 	// Statistics endpoints
 	v1.HandleFunc("/statistics", a.getStatistics).Methods("GET")
+	v1.HandleFunc("/statistics/sources", a.getSourceStatistics).Methods("GET")
+	v1.HandleFunc("/statistics/storage", a.getStorageUsage).Methods("GET")
+
+	// Job endpoints
+	v1.HandleFunc("/jobs", a.listJobs).Methods("GET")
+	v1.HandleFunc("/jobs/{id:[0-9]+}", a.getJob).Methods("GET")
+
+	// Throttling endpoints
+	v1.HandleFunc("/throttle", a.getThrottleStatus).Methods("GET")
+
+	// Admin endpoints
+	v1.HandleFunc("/admin/backlog", a.getBacklog).Methods("GET")
+	v1.HandleFunc("/admin/queue", a.getQueueStats).Methods("GET")
+	v1.HandleFunc("/admin/drain", a.withChangeAccess(a.drainQueue)).Methods("POST")
+	v1.HandleFunc("/admin/features", a.getFeatures).Methods("GET")
+	v1.HandleFunc("/admin/features/{name}", a.withChangeAccess(a.setFeature)).Methods("PUT")
+	v1.HandleFunc("/admin/features/{name}", a.withChangeAccess(a.deleteFeature)).Methods("DELETE")
+	v1.HandleFunc("/admin/health/history", a.getHealthHistory).Methods("GET")
+	v1.HandleFunc("/admin/maintenance", a.getMaintenance).Methods("GET")
+	v1.HandleFunc("/admin/incidents", a.getIncidents).Methods("GET")
+	v1.HandleFunc("/admin/incidents/{key}/acknowledge", a.withChangeAccess(a.acknowledgeIncident)).Methods("POST")
+	v1.HandleFunc("/admin/digest", a.getDigest).Methods("GET")
+	v1.HandleFunc("/admin/sequence", a.getSequenceReport).Methods("GET")
+	v1.HandleFunc("/admin/sequence/reexports", a.getReexportRequests).Methods("GET")
+	v1.HandleFunc("/admin/cdc/schema", a.getCDCSchema).Methods("GET")
+	v1.HandleFunc("/admin/audit/export", a.exportAudit).Methods("GET")
+	v1.HandleFunc("/admin/audit/verify", a.verifyAudit).Methods("GET")
+	v1.HandleFunc("/admin/clickhouse", a.getClickHouseStatus).Methods("GET")
+	v1.HandleFunc("/admin/clickhouse/replay", a.withChangeAccess(a.replayClickHouse)).Methods("POST")
+
+	// Admin UI
+	a.setupUIRoutes()
+}
+
+// healthResponse - ответ GET /health
+type healthResponse struct {
+	Status      string              `json:"status"`
+	Message     string              `json:"message"`
+	Maintenance *maintenance.Status `json:"maintenance,omitempty"` // только при настроенных окнах обслуживания
 }
 
 // healthCheck - обработчик health check
@@ -265,17 +809,16 @@ func (a *App) healthCheck(w http.ResponseWriter, r *http.Request) {
 	// Проверяем соединение с БД
 	if err := a.store.HealthCheck(ctx); err != nil {
 		w.WriteHeader(http.StatusServiceUnavailable)
-		json.NewEncoder(w).Encode(map[string]string{
-			"status":  "unhealthy",
-			"message": "Database connection failed",
-		})
+		json.NewEncoder(w).Encode(healthResponse{Status: "unhealthy", Message: "Database connection failed"})
 		return
 	}
 
-	json.NewEncoder(w).Encode(map[string]string{
-		"status":  "healthy",
-		"message": "Service is running",
-	})
+	response := healthResponse{Status: "healthy", Message: "Service is running"}
+	if a.maintenance != nil {
+		status := a.maintenance.Status()
+		response.Maintenance = &status
+	}
+	json.NewEncoder(w).Encode(response)
 }
 
 // getDeviceData - получение данных устройства
@@ -294,29 +837,36 @@ func (a *App) getDeviceData(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Парсим параметры пагинации
-	page, _ := strconv.Atoi(r.URL.Query().Get("page"))
-	if page < 1 {
-		page = 1
+	pageReq, err := parsePageRequest(r, 50, 100)
+	if err != nil {
+		writeInvalidCursor(w)
+		return
+	}
+	fields, ok := parseFieldsParam(w, r, dto.DeviceData{})
+	if !ok {
+		return
 	}
 
-	limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
-	if limit < 1 || limit > 100 {
-		limit = 50
+	// Сортировка: sort=level,-created_at (только поля из allow-list)
+	sortFields, err := sorting.Parse(r.URL.Query().Get("sort"), database.DeviceDataSortFields)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+		return
 	}
 
-	offset := (page - 1) * limit
+	// Период и архив: from/to, include_archived=true
+	query := r.URL.Query()
+	if query.Get("from") != "" || query.Get("to") != "" || query.Get("include_archived") != "" {
+		a.getDeviceDataRange(w, r, unitGuid, pageReq, fields, sortFields)
+		return
+	}
 
 	ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
 	defer cancel()
 
 	// Получаем данные из БД
-	params := sqlc.ListDeviceDataByUnitParams{
-		UnitGuid: unitGuid,
-		Limit:    int32(limit),
-		Offset:   int32(offset),
-	}
-
-	data, err := a.queries.ListDeviceDataByUnit(ctx, params)
+	data, err := a.deviceDataPage(ctx, unitGuid, sortFields, int32(pageReq.Limit), int32(pageReq.Offset))
 	if err != nil {
 		w.WriteHeader(http.StatusInternalServerError)
 		json.NewEncoder(w).Encode(map[string]string{
@@ -325,8 +875,8 @@ func (a *App) getDeviceData(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Получаем общее количество с помощью нового метода
-	total, err := a.store.CountDeviceDataByUnit(ctx, unitGuid)
+	// Общее количество и последняя активность (кэшируются между опросами)
+	meta, err := a.unitMetadata(ctx, unitGuid)
 	if err != nil {
 		w.WriteHeader(http.StatusInternalServerError)
 		json.NewEncoder(w).Encode(map[string]string{
@@ -335,13 +885,15 @@ func (a *App) getDeviceData(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	response := map[string]interface{}{
-		"data": data,
-		"pagination": map[string]interface{}{
-			"page":  page,
-			"limit": limit,
-			"total": total,
-		},
+	items := dto.FromDeviceData(data)
+	dto.Mask(items, a.maskedFields(r))
+
+	response := struct {
+		pageResponse
+		LastActivity *time.Time `json:"last_activity"`
+	}{
+		pageResponse: newPageResponse(dto.Project(items, fields), pageReq, meta.TotalRecords),
+		LastActivity: meta.LastActivity,
 	}
 
 	json.NewEncoder(w).Encode(response)
@@ -349,47 +901,85 @@ func (a *App) getDeviceData(w http.ResponseWriter, r *http.Request) {
 
 // getFiles - получение списка файлов
 func (a *App) getFiles(w http.ResponseWriter, r *http.Request) {
-	page, _ := strconv.Atoi(r.URL.Query().Get("page"))
-	if page < 1 {
-		page = 1
+	pageReq, err := parsePageRequest(r, 20, 100)
+	if err != nil {
+		writeInvalidCursor(w)
+		return
+	}
+	fields, ok := parseFieldsParam(w, r, dto.File{})
+	if !ok {
+		return
 	}
 
-	limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
-	if limit < 1 || limit > 100 {
-		limit = 20
+	sortFields, err := sorting.Parse(r.URL.Query().Get("sort"), database.FileSortFields)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+		return
 	}
 
-	offset := (page - 1) * limit
+	meta, err := parseMetadataFilter(r)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+		return
+	}
 
 	ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
 	defer cancel()
 
-	params := sqlc.ListFilesParams{
-		Limit:  int32(limit),
-		Offset: int32(offset),
+	files, err := a.filesPage(ctx, sortFields, meta, int32(pageReq.Limit), int32(pageReq.Offset))
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "Failed to fetch files",
+		})
+		return
 	}
 
-	files, err := a.queries.ListFiles(ctx, params)
+	total, err := a.filesCount(ctx, meta)
 	if err != nil {
 		w.WriteHeader(http.StatusInternalServerError)
 		json.NewEncoder(w).Encode(map[string]string{
-			"error": "Failed to fetch files",
+			"error": "Failed to count files",
 		})
 		return
 	}
 
-	json.NewEncoder(w).Encode(files)
+	json.NewEncoder(w).Encode(newPageResponse(dto.Project(dto.FromFiles(files), fields), pageReq, total))
 }
 
 // getFileStatus - получение статуса файла
 func (a *App) getFileStatus(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	filename := vars["filename"]
+	if err := safepath.Filename(filename); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Invalid filename"})
+		return
+	}
 
 	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
 	defer cancel()
 
+	// Пока файл обрабатывается, запись о нём ещё не зафиксирована
+	// в БД - отдаём текущий прогресс из процессора или, если файл
+	// обрабатывает другой экземпляр, из file_progress
+	progress, processing := a.processor.Progress(filename)
+
 	file, err := a.queries.GetFileByFilename(ctx, filename)
+	if err == sql.ErrNoRows && !processing {
+		if stored, progressErr := a.queries.GetFileProgress(ctx, filename); progressErr == nil {
+			progress, processing = processor.ProgressFromRecord(stored), true
+		}
+	}
+	if err == sql.ErrNoRows && processing {
+		json.NewEncoder(w).Encode(newFileStatusResponse(dto.File{
+			ID:       progress.FileID,
+			Filename: filename,
+		}, progress))
+		return
+	}
 	if err != nil {
 		if err == sql.ErrNoRows {
 			w.WriteHeader(http.StatusNotFound)
@@ -402,13 +992,32 @@ func (a *App) getFileStatus(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	json.NewEncoder(w).Encode(file)
+	if !processing {
+		json.NewEncoder(w).Encode(dto.FromFile(file))
+		return
+	}
+	json.NewEncoder(w).Encode(newFileStatusResponse(dto.FromFile(file), progress))
 }
 
 // getFileErrors - получение ошибок обработки файла
 func (a *App) getFileErrors(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	filename := vars["filename"]
+	if err := safepath.Filename(filename); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Invalid filename"})
+		return
+	}
+
+	pageReq, err := parsePageRequest(r, 100, 1000)
+	if err != nil {
+		writeInvalidCursor(w)
+		return
+	}
+	fields, ok := parseFieldsParam(w, r, dto.ProcessingError{})
+	if !ok {
+		return
+	}
 
 	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
 	defer cancel()
@@ -422,7 +1031,11 @@ func (a *App) getFileErrors(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	errors, err := a.queries.ListProcessingErrorsByFile(ctx, file.ID)
+	errors, err := a.queries.ListProcessingErrorsByFilePaged(ctx, sqlc.ListProcessingErrorsByFilePagedParams{
+		FileID: file.ID,
+		Limit:  int32(pageReq.Limit),
+		Offset: int32(pageReq.Offset),
+	})
 	if err != nil {
 		w.WriteHeader(http.StatusInternalServerError)
 		json.NewEncoder(w).Encode(map[string]string{
@@ -431,7 +1044,27 @@ func (a *App) getFileErrors(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	json.NewEncoder(w).Encode(errors)
+	total, err := a.queries.CountProcessingErrorsByFile(ctx, file.ID)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "Failed to count errors",
+		})
+		return
+	}
+
+	items := dto.FromProcessingErrors(errors)
+	dto.Mask(items, a.maskedFields(r))
+	json.NewEncoder(w).Encode(newPageResponse(dto.Project(items, fields), pageReq, total))
+}
+
+// processFileResponse - ответ POST /files/{filename}/process
+type processFileResponse struct {
+	Message  string `json:"message"`
+	Filename string `json:"filename"`
+	Hash     string `json:"hash"` // первые 8 символов SHA256
+	Size     string `json:"size"`
+	Sampling string `json:"sampling"`
 }
 
 // processFile - обработка файла по запросу API (исправленная версия)
@@ -439,7 +1072,13 @@ func (a *App) processFile(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	filename := vars["filename"]
 
-	filePath := filepath.Join(a.config.Directory.WatchPath, filename)
+	// Имя из URL не должно выводить за пределы watch-директории
+	filePath, err := safepath.Join(a.config.Directory.WatchPath, filename)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Invalid filename"})
+		return
+	}
 
 	// 1. Проверяем существование файла и получаем размер
 	stat, err := os.Stat(filePath)
@@ -455,36 +1094,71 @@ func (a *App) processFile(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// 2. Вычисляем хеш файла
-	hash, err := calculateFileHash(filePath)
+	hash, err := ingest.HashFile(filePath)
 	if err != nil {
 		w.WriteHeader(http.StatusInternalServerError)
 		json.NewEncoder(w).Encode(map[string]string{"error": "Failed to calculate file hash"})
 		return
 	}
 
-	// 3. Создаём FileInfo
+	conflictPolicy := r.URL.Query().Get("conflict")
+	if !processor.ValidConflictPolicy(conflictPolicy) {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": "conflict must be one of: append, skip, overwrite, version"})
+		return
+	}
+
+	// Выборка строк для предварительной загрузки огромных файлов
+	sampling, err := ingest.ParseSampling(r.URL.Query().Get("sample_every"), r.URL.Query().Get("sample_percent"))
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+		return
+	}
+
+	// 3. Создаём FileInfo (источник - API, с разделением по ключу клиента)
+	source := "api"
+	if apiKey := r.Header.Get("X-API-Key"); apiKey != "" {
+		source = apiKeySource(apiKey)
+	}
 	fileInfo := watcher.FileInfo{
-		Name: filename,
-		Path: filePath,
-		Hash: hash,
-		Size: stat.Size(),
+		Name:      filename,
+		Path:      filePath,
+		Hash:      hash,
+		Size:      stat.Size(),
+		ModTime:   stat.ModTime(),
+		ArrivedAt: time.Now(),
+		Source:    source,
+		Trace:     tracing.FromContext(r.Context()),
+
+		ConflictPolicy: conflictPolicy,
+		Sampling:       sampling,
 	}
 
 	// 4. Отправляем в очередь воркеров
 	if err := a.watcher.SendToQueue(fileInfo); err != nil {
+		message := "Processing queue is full"
+		if errors.Is(err, watcher.ErrStopped) {
+			message = "Service is draining, files are not accepted"
+		}
 		w.WriteHeader(http.StatusServiceUnavailable)
-		json.NewEncoder(w).Encode(map[string]string{"error": "Processing queue is full"})
+		json.NewEncoder(w).Encode(map[string]string{"error": message})
 		return
 	}
 
-	log.Printf("API: queued file %s (hash: %s, size: %d bytes)",
-		filename, hash[:8], stat.Size())
+	a.logger.Info("Queued file", "file", filename, "hash", hash[:8], "size", stat.Size(), "sampling", sampling)
+	a.recordAudit(r.Context(), audit.EventFileReprocess, filename, requestActor(r), map[string]interface{}{
+		"file_hash": hash,
+		"size":      stat.Size(),
+		"sampling":  sampling.String(),
+	})
 
-	json.NewEncoder(w).Encode(map[string]string{
-		"message":  "File processing started",
-		"filename": filename,
-		"hash":     hash[:8],
-		"size":     fmt.Sprintf("%d bytes", stat.Size()),
+	json.NewEncoder(w).Encode(processFileResponse{
+		Message:  "File processing started",
+		Filename: filename,
+		Hash:     hash[:8],
+		Size:     fmt.Sprintf("%d bytes", stat.Size()),
+		Sampling: sampling.String(),
 	})
 }
 
@@ -502,10 +1176,24 @@ func (a *App) getReports(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	pageReq, err := parsePageRequest(r, 20, 100)
+	if err != nil {
+		writeInvalidCursor(w)
+		return
+	}
+	fields, ok := parseFieldsParam(w, r, dto.Report{})
+	if !ok {
+		return
+	}
+
 	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
 	defer cancel()
 
-	reports, err := a.queries.GetReportsByUnit(ctx, unitGuid)
+	reports, err := a.queries.ListReportsByUnit(ctx, sqlc.ListReportsByUnitParams{
+		UnitGuid: unitGuid,
+		Limit:    int32(pageReq.Limit),
+		Offset:   int32(pageReq.Offset),
+	})
 	if err != nil {
 		w.WriteHeader(http.StatusInternalServerError)
 		json.NewEncoder(w).Encode(map[string]string{
@@ -514,7 +1202,25 @@ func (a *App) getReports(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	json.NewEncoder(w).Encode(reports)
+	total, err := a.queries.CountReportsByUnit(ctx, unitGuid)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "Failed to count reports",
+		})
+		return
+	}
+
+	json.NewEncoder(w).Encode(newPageResponse(dto.Project(dto.FromReports(reports), fields), pageReq, total))
+}
+
+// generateReportResponse - ответ POST /reports/{unit_guid}/generate
+type generateReportResponse struct {
+	Message     string `json:"message"`
+	UnitGuid    string `json:"unit_guid"`
+	JobID       string `json:"job_id"`
+	ReportGroup string `json:"report_group"` // группа частей отчёта, совпадает с job_id
+	Format      string `json:"format"`
 }
 
 // generateReport - генерация отчета для устройства (исправленная версия)
@@ -529,141 +1235,293 @@ func (a *App) generateReport(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Запускаем генерацию в горутине, чтобы не блокировать HTTP-ответ
-	go func() {
-		ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
-		defer cancel()
-		if err := a.processor.GenerateReportForUnit(ctx, unitGuid); err != nil {
-			log.Printf("❌ Error generating report for %s: %v", unitGuid, err)
+	// Большие отчёты делятся на части: part_size записей и/или split=day.
+	// Accept-Language выбирает формат дат и чисел (иначе report.language).
+	opts := processor.ReportOptions{
+		Password:   r.Header.Get("X-Report-Password"),
+		SplitByDay: r.URL.Query().Get("split") == "day",
+		Class:      r.URL.Query().Get("class"),
+		Language:   locale.Match(r.Header.Get("Accept-Language")),
+	}
+	// ?format= - pdf (по умолчанию), csv, xlsx или json; пароль - только для PDF
+	format, err := processor.ParseReportFormat(r.URL.Query().Get("format"))
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+		return
+	}
+	if opts.Password != "" && format != processor.ReportPDF {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": "X-Report-Password is only supported for PDF reports"})
+		return
+	}
+	opts.Format = format
+	if v := r.URL.Query().Get("part_size"); v != "" {
+		partSize, err := strconv.Atoi(v)
+		if err != nil || partSize <= 0 {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(map[string]string{"error": "part_size must be a positive integer"})
+			return
 		}
-	}()
+		opts.PartSize = partSize
+	}
+	// Необязательный период данных (как в /reports: RFC3339 или YYYY-MM-DD)
+	if v := r.URL.Query().Get("from"); v != "" {
+		from, _, err := parseReportTime(v)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(map[string]string{"error": "from must be RFC3339 or YYYY-MM-DD"})
+			return
+		}
+		opts.From = from
+	}
+	if v := r.URL.Query().Get("to"); v != "" {
+		to, dateOnly, err := parseReportTime(v)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(map[string]string{"error": "to must be RFC3339 or YYYY-MM-DD"})
+			return
+		}
+		if dateOnly {
+			to = to.AddDate(0, 0, 1)
+		}
+		opts.To = to
+	}
 
-	json.NewEncoder(w).Encode(map[string]string{
-		"message":   "Report generation started",
-		"unit_guid": unitGuid.String(),
+	// Ставим генерацию в очередь отчётов, чтобы не блокировать HTTP-ответ.
+	// X-Report-Password шифрует отчёт; пароль не сохраняется и не возвращается.
+	opts.Trace = tracing.FromContext(r.Context())
+	jobID, err := a.processor.EnqueueUnitReport(unitGuid, opts)
+	if err != nil {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Report queue is full"})
+		return
+	}
+
+	w.Header().Set("Location", "/api/v1/reports/jobs/"+jobID.String())
+	w.WriteHeader(http.StatusAccepted)
+	json.NewEncoder(w).Encode(generateReportResponse{
+		Message:     "Report generation started",
+		UnitGuid:    unitGuid.String(),
+		JobID:       jobID.String(),
+		ReportGroup: jobID.String(),
+		Format:      format,
 	})
 }
 
+// statisticsResponse - ответ GET /statistics
+type statisticsResponse struct {
+	cachedStatistics
+	WatchBacklog      watcher.BacklogStats        `json:"watch_backlog"`
+	Components        []supervisor.ComponentStats `json:"components"`
+	ComponentRestarts int                         `json:"component_restarts"`
+	APILog            *apilog.Stats               `json:"api_log,omitempty"` // только при включённом журнале запросов
+}
+
 // getStatistics - получение статистики
 func (a *App) getStatistics(w http.ResponseWriter, r *http.Request) {
 	ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
 	defer cancel()
 
-	stats, err := a.store.GetStatistics(ctx)
+	stats, err := a.statistics(ctx)
 	if err != nil {
-		log.Printf("❌ Error fetching statistics: %v", err)
+		a.logger.Error("❌ Error fetching statistics", "error", err)
 		w.WriteHeader(http.StatusInternalServerError)
 		json.NewEncoder(w).Encode(map[string]string{
 			"error": "Failed to fetch statistics",
 		})
 		return
 	}
+	response := statisticsResponse{
+		cachedStatistics:  stats,
+		WatchBacklog:      a.watcher.GetBacklogStats(),
+		Components:        a.supervisor.Stats(),
+		ComponentRestarts: a.supervisor.TotalRestarts(),
+	}
+	if a.apiLogs != nil {
+		apiLog := a.apiLogs.Stats()
+		response.APILog = &apiLog
+	}
 
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(stats)
+	json.NewEncoder(w).Encode(response)
 }
 
 // startHealthChecks - запуск health checks
 func (a *App) startHealthChecks() {
-	log.Println("🏥 Starting health checks...")
+	a.logger.Info("🏥 Starting health checks...")
 
-	ticker := time.NewTicker(30 * time.Second)
+	ticker := time.NewTicker(a.config.Health.CheckInterval)
 	defer ticker.Stop()
 
 	for range ticker.C {
-		// Проверка базы данных
-		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-		err := a.store.HealthCheck(ctx)
-		cancel()
+		// Проверка базы данных (в лог и каналы оповещений - только изменения состояния)
+		a.checkDatabaseHealth()
 
-		if err != nil {
-			log.Printf("⚠️  Database health check failed: %v", err)
-		} else {
-			log.Printf("✅ Database health check passed")
+		// Вывод статистики базы данных
+		if a.config.IsDebugMode() {
+			stats := a.store.GetStats()
+			a.logger.Debug("📊 DB Stats", "open_connections", stats.OpenConnections, "in_use", stats.InUse, "idle", stats.Idle)
 		}
 
-		// Вывод статистики базы данных
-		stats := a.store.GetStats()
-		log.Printf("📊 DB Stats: OpenConnections=%d, InUse=%d, Idle=%d",
-			stats.OpenConnections, stats.InUse, stats.Idle)
+		// Проверка backlog watch-директории
+		a.checkBacklogAlarms()
+
+		// Проверка зависших watcher'а и воркеров
+		a.checkStalledComponents()
+
+		// Устойчивые проблемы - инциденты в системе дежурств
+		a.checkIncidents()
 	}
 }
 
 // startCleanupTasks - запуск задач очистки
 func (a *App) startCleanupTasks() {
-	log.Println("🧹 Starting cleanup tasks...")
-
-	ticker := time.NewTicker(24 * time.Hour) // Ежедневно
-	defer ticker.Stop()
-
-	// Запускаем сразу при старте
-	go a.runCleanup()
+	a.logger.Info("🧹 Starting cleanup tasks...")
+
+	// Сразу при старте и затем ежедневно (в ближайшем окне обслуживания
+	// heavy_tasks, если оно начнётся не позже maintenance.heavy_task_max_delay)
+	clock.Every(context.Background(), a.clock, 24*time.Hour, func() {
+		go func() {
+			a.waitForMaintenance(context.Background())
+			a.runCleanup()
+		}()
+	})
+}
 
-	for range ticker.C {
-		go a.runCleanup()
+// runCleanup - выполнение задач очистки (задание cleanup в журнале заданий)
+func (a *App) runCleanup() {
+	spec := jobs.Spec{Kind: jobs.KindCleanup, Owner: "scheduler"}
+	if err := a.jobs.Run(context.Background(), spec, a.cleanupOnce); err != nil {
+		a.logger.Warn("⚠️ Cleanup tasks completed with errors", "error", err)
+		return
 	}
+	a.logger.Info("✅ Cleanup tasks completed")
 }
 
-// runCleanup - выполнение задач очистки
-func (a *App) runCleanup() {
-	ctx := context.Background()
+// cleanupOnce - один проход очистки; ошибка одной задачи не отменяет остальные
+func (a *App) cleanupOnce(ctx context.Context) error {
+	var errs []error
 
 	// Очистка старых API логов (30 дней)
 	err := a.queries.CleanupOldApiLogs(ctx)
 	if err != nil {
-		log.Printf("Error cleaning old API logs: %v", err)
+		a.logger.Error("Error cleaning old API logs", "error", err)
+		errs = append(errs, err)
 	}
 
 	// Очистка старых файлов
-	err = a.queries.DeleteOldFiles(ctx, sql.NullString{String: "completed", Valid: true})
+	err = a.queries.DeleteOldFiles(ctx, filestatus.Completed.NullString())
 	if err != nil {
-		log.Printf("Error cleaning old files: %v", err)
+		a.logger.Error("Error cleaning old files", "error", err)
+		errs = append(errs, err)
 	}
 
 	// Очистка старых отчетов (1 год)
 	err = a.queries.DeleteOldReports(ctx)
 	if err != nil {
-		log.Printf("Error cleaning old reports: %v", err)
+		a.logger.Error("Error cleaning old reports", "error", err)
+		errs = append(errs, err)
 	}
 
-	log.Println("✅ Cleanup tasks completed")
+	// Очистка просроченных ключей идемпотентности
+	err = a.queries.DeleteExpiredIdempotencyKeys(ctx)
+	if err != nil {
+		a.logger.Error("Error cleaning expired idempotency keys", "error", err)
+		errs = append(errs, err)
+	}
+
+	// Очистка журнала заданий (30 дней)
+	err = a.queries.DeleteOldJobs(ctx)
+	if err != nil {
+		a.logger.Error("Error cleaning old jobs", "error", err)
+		errs = append(errs, err)
+	}
+
+	// Очистка хронологии обработки файлов (30 дней)
+	err = a.queries.DeleteOldFileEvents(ctx)
+	if err != nil {
+		a.logger.Error("Error cleaning old file events", "error", err)
+		errs = append(errs, err)
+	}
+
+	// Удаление данных по сроку хранения фиксируется в журнале аудита
+	a.recordAudit(ctx, audit.EventRetentionCleanup, "retention", "scheduler", map[string]interface{}{
+		"tables": []string{"api_logs", "files", "reports", "idempotency_keys", "jobs", "file_events"},
+		"errors": len(errs),
+	})
+
+	return errors.Join(errs...)
 }
 
-// waitForShutdown - ожидание сигнала завершения
+// waitForShutdown - ожидание сигнала завершения. SIGUSR1 - дренаж
+// (startDrain): завершение после обработки всей очереди; SIGINT/SIGTERM
+// во время дренажа останавливают приложение сразу.
 func (a *App) waitForShutdown() error {
 	sigChan := make(chan os.Signal, 1)
-	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
-
-	sig := <-sigChan
-	log.Printf("🛑 Received signal %v, shutting down...", sig)
-
-	return a.shutdown()
+	signal.Notify(sigChan, append([]os.Signal{syscall.SIGINT, syscall.SIGTERM}, drainSignals...)...)
+
+	for {
+		select {
+		case sig := <-sigChan:
+			if slices.Contains(drainSignals, sig) {
+				a.startDrain("signal " + sig.String())
+				continue
+			}
+			a.logger.Info("🛑 Received signal, shutting down...", "signal", sig)
+		case <-a.drained:
+			a.logger.Info("🛑 Queue drained, shutting down...")
+		}
+		return a.shutdown()
+	}
 }
 
 // shutdown - graceful shutdown приложения
 func (a *App) shutdown() error {
-	log.Println("🔒 Shutting down application...")
+	a.logger.Info("🔒 Shutting down application...")
 
-	// 1. Остановка API сервера
-	if a.server != nil {
-		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
-		defer cancel()
+	// 1. Остановка API сервера (если порт не передан раньше при дренаже)
+	a.stopAPIServer()
 
-		if err := a.server.Shutdown(ctx); err != nil {
-			log.Printf("Error shutting down API server: %v", err)
+	// Сохраняем накопленный журнал запросов (новых запросов уже нет)
+	if a.apiLogs != nil {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		if err := a.apiLogs.Close(ctx); err != nil {
+			a.logger.Error("Error flushing API logs", "error", err)
 		} else {
-			log.Println("  ✓ API server stopped")
+			a.logger.Info("✓ API logs flushed")
 		}
+		cancel()
 	}
 
-	// 2. Остановка watcher
+	if a.digests != nil {
+		a.digests.Stop()
+	}
+	if a.archiver != nil {
+		a.archiver.Stop()
+	}
+	if a.clickhouse != nil {
+		a.clickhouse.Stop()
+	}
+	if a.subscriptions != nil {
+		a.subscriptions.Stop()
+	}
+	if a.usage != nil {
+		a.usage.Stop()
+	}
+	if a.sequence != nil {
+		a.sequence.Stop()
+	}
+
+	// 2. Остановка watcher (без перезапусков супервизором)
+	a.supervisor.Stop()
 	if a.watcher != nil {
 		a.watcher.Stop()
-		log.Println("  ✓ Directory watcher stopped")
+		a.logger.Info("✓ Directory watcher stopped")
 	}
 
 	// 3. Ожидаем завершения всех воркеров (с таймаутом)
-	log.Println("  ⏳ Waiting for workers to finish current tasks...")
+	a.logger.Info("⏳ Waiting for workers to finish current tasks...")
 	waitChan := make(chan struct{})
 	go func() {
 		a.workerWg.Wait()
@@ -671,35 +1529,31 @@ func (a *App) shutdown() error {
 	}()
 	select {
 	case <-waitChan:
-		log.Println("  ✓ All workers stopped")
+		a.logger.Info("✓ All workers stopped")
 	case <-time.After(30 * time.Second):
-		log.Println("  ⚠️ Worker shutdown timeout (some tasks may be incomplete)")
+		a.logger.Warn("⚠️ Worker shutdown timeout (some tasks may be incomplete)")
 	}
 
-	// 4. Закрытие соединения с базой данных
+	// 4. Дожидаемся генерации уже поставленных в очередь отчётов
+	a.processor.StopReportWorkers()
+	a.logger.Info("✓ Report workers stopped")
+	a.processor.StopProgressPublisher()
+
+	// 5. Закрытие соединений кэша
+	if redisCache, ok := a.cache.(*cache.RedisCache); ok {
+		redisCache.Close()
+		a.logger.Info("✓ Redis cache connections closed")
+	}
+
+	// 6. Закрытие соединения с базой данных
 	if a.store != nil {
 		if err := a.store.Close(); err != nil {
-			log.Printf("  Error closing database: %v", err)
+			a.logger.Error("Error closing database", "error", err)
 		} else {
-			log.Println("  ✓ Database connection closed")
+			a.logger.Info("✓ Database connection closed")
 		}
 	}
 
-	log.Println("👋 Application shutdown complete")
-	return nil
-}
-
-// calculateFileHash вычисляет SHA256 хеш файла
-func calculateFileHash(filePath string) (string, error) {
-	file, err := os.Open(filePath)
-	if err != nil {
-		return "", err
-	}
-	defer file.Close()
-
-	hash := sha256.New()
-	if _, err := io.Copy(hash, file); err != nil {
-		return "", err
-	}
-	return hex.EncodeToString(hash.Sum(nil)), nil
+	a.logger.Info("👋 Application shutdown complete")
+	return a.closeLog()
 }
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"TSVProcessingService/internal/config"
+	"TSVProcessingService/internal/maintenance"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"time"
+)
+
+// newMaintenanceCalendar - окна обслуживания из конфигурации (nil - окон нет).
+// Расписания и часовой пояс уже проверены validateConfig.
+func newMaintenanceCalendar(cfg *config.MaintenanceConfig) *maintenance.Calendar {
+	if len(cfg.Windows) == 0 {
+		return nil
+	}
+	loc, err := time.LoadLocation(cfg.Timezone)
+	if err != nil {
+		loc = time.UTC
+	}
+
+	windows := make([]maintenance.Window, 0, len(cfg.Windows))
+	for _, w := range cfg.Windows {
+		schedule, err := maintenance.ParseSchedule(w.Schedule)
+		if err != nil {
+			slog.Warn("⚠️ Maintenance window skipped", "window", w.Name, "error", err)
+			continue
+		}
+		windows = append(windows, maintenance.Window{
+			Name:           w.Name,
+			Schedule:       schedule,
+			Duration:       w.Duration,
+			PauseIngestion: w.PauseIngestion,
+			SuppressAlerts: w.SuppressAlerts,
+			HeavyTasks:     w.HeavyTasks,
+		})
+		slog.Info("🛠️ Maintenance window", "window", w.Name, "schedule", w.Schedule, "duration", w.Duration, "location", loc)
+	}
+	return maintenance.NewCalendar(windows, loc)
+}
+
+// waitForMaintenance - ожидание окна для тяжёлых задач (очистка, архивация)
+func (a *App) waitForMaintenance(ctx context.Context) error {
+	if a.maintenance == nil {
+		return nil
+	}
+	return a.maintenance.WaitForHeavyTasks(ctx, a.config.Maintenance.HeavyTaskMaxDelay)
+}
+
+// alertsSuppressed - оповещения подавлены окном обслуживания
+func (a *App) alertsSuppressed() bool {
+	return a.maintenance != nil && a.maintenance.AlertsSuppressed()
+}
+
+// getMaintenance - состояние окон обслуживания
+func (a *App) getMaintenance(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	if a.maintenance == nil {
+		json.NewEncoder(w).Encode(maintenance.Status{Windows: []maintenance.WindowStatus{}})
+		return
+	}
+	json.NewEncoder(w).Encode(a.maintenance.Status())
+}
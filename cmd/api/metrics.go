@@ -0,0 +1,46 @@
+package main
+
+import (
+	"TSVProcessingService/internal/filestatus"
+	"TSVProcessingService/internal/metrics"
+	"TSVProcessingService/internal/processor"
+	"context"
+	"time"
+)
+
+// Бакеты задержки загрузки: от 1 секунды до ~4.5 часов
+var ingestLatencyBuckets = metrics.ExponentialBuckets(1, 2, 15)
+
+var (
+	ingestLatency = metrics.Default.NewHistogram("tsv_ingest_latency_seconds",
+		"Time from file arrival to data commit", ingestLatencyBuckets, "status")
+	exportLatency = metrics.Default.NewHistogram("tsv_export_latency_seconds",
+		"Time from file modification (partner export) to data commit", ingestLatencyBuckets, "status")
+	ingestSLABreaches = metrics.Default.NewCounter("tsv_ingest_sla_breaches_total",
+		"Files committed later than the ingest latency SLA")
+)
+
+// ingestLatencyHook - учёт задержки загрузки после фиксации данных файла
+type ingestLatencyHook struct {
+	sla time.Duration
+}
+
+func (h *ingestLatencyHook) Name() string { return "ingest-latency" }
+
+func (h *ingestLatencyHook) AfterProcess(ctx context.Context, result processor.ProcessResult) error {
+	file := result.File
+	if !file.CompletedAt.Valid || result.Status == filestatus.Failed {
+		return nil
+	}
+	if file.ArrivedAt.Valid {
+		latency := file.CompletedAt.Time.Sub(file.ArrivedAt.Time)
+		ingestLatency.Observe(latency.Seconds(), string(result.Status))
+		if latency > h.sla {
+			ingestSLABreaches.Inc()
+		}
+	}
+	if file.FileMtime.Valid {
+		exportLatency.Observe(file.CompletedAt.Time.Sub(file.FileMtime.Time).Seconds(), string(result.Status))
+	}
+	return nil
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"TSVProcessingService/internal/apilog"
+	"TSVProcessingService/internal/tracing"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+)
+
+// statusResponseWriter - запоминает код ответа для журнала API
+type statusResponseWriter struct {
+	http.ResponseWriter
+	statusCode int
+}
+
+func (rw *statusResponseWriter) WriteHeader(code int) {
+	rw.statusCode = code
+	rw.ResponseWriter.WriteHeader(code)
+}
+
+// loggingMiddleware - журнал запросов в api_logs. Запись идёт через
+// асинхронную очередь и не задерживает ответ; при перегрузке записи
+// отбрасываются (см. счётчики в /statistics).
+func (a *App) loggingMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		rw := &statusResponseWriter{ResponseWriter: w, statusCode: http.StatusOK}
+
+		next.ServeHTTP(rw, r)
+
+		entry := apilog.Entry{
+			Endpoint:       r.URL.Path,
+			ResponseTimeMs: int32(time.Since(start).Milliseconds()),
+			StatusCode:     int32(rw.statusCode),
+			CreatedAt:      start,
+		}
+		if guid, err := uuid.Parse(mux.Vars(r)["unit_guid"]); err == nil {
+			entry.UnitGuid = uuid.NullUUID{UUID: guid, Valid: true}
+		}
+		a.apiLogs.Log(entry)
+	})
+}
+
+// tracingMiddleware - span запроса в трассе из заголовка traceparent
+// (или в новой трассе). Span передаётся в контексте запроса: в нём
+// обрабатываются поставленные запросом файлы и отчёты. Заголовок
+// traceparent ответа позволяет найти трассу в Jaeger/Tempo.
+func tracingMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		parent, _ := tracing.Parse(r.Header.Get(tracing.Header))
+		span := tracing.NewSpan(parent)
+		w.Header().Set(tracing.Header, span.Traceparent())
+		next.ServeHTTP(w, r.WithContext(tracing.ContextWithSpan(r.Context(), span)))
+	})
+}
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"TSVProcessingService/internal/dto"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+)
+
+// pageRequest - параметры запрашиваемой страницы списка
+type pageRequest struct {
+	Page   int
+	Limit  int
+	Offset int
+}
+
+// pageResponse - единый формат ответа всех списковых endpoint'ов
+type pageResponse struct {
+	Items      interface{} `json:"items"`
+	Page       int         `json:"page"`
+	Limit      int         `json:"limit"`
+	Total      int64       `json:"total"`
+	NextCursor *string     `json:"next_cursor"`
+}
+
+// parsePageRequest - разбор page/limit или cursor из query.
+// Cursor имеет приоритет над page и ссылается на начало следующей страницы.
+func parsePageRequest(r *http.Request, defaultLimit, maxLimit int) (pageRequest, error) {
+	query := r.URL.Query()
+
+	limit, _ := strconv.Atoi(query.Get("limit"))
+	if limit < 1 || limit > maxLimit {
+		limit = defaultLimit
+	}
+
+	if cursor := query.Get("cursor"); cursor != "" {
+		offset, err := decodeCursor(cursor)
+		if err != nil {
+			return pageRequest{}, err
+		}
+		return pageRequest{Page: offset/limit + 1, Limit: limit, Offset: offset}, nil
+	}
+
+	page, _ := strconv.Atoi(query.Get("page"))
+	if page < 1 {
+		page = 1
+	}
+	return pageRequest{Page: page, Limit: limit, Offset: (page - 1) * limit}, nil
+}
+
+// newPageResponse - формирование ответа; next_cursor равен null на последней странице
+func newPageResponse(items interface{}, req pageRequest, total int64) pageResponse {
+	resp := pageResponse{
+		Items: items,
+		Page:  req.Page,
+		Limit: req.Limit,
+		Total: total,
+	}
+	if next := req.Offset + req.Limit; int64(next) < total {
+		cursor := encodeCursor(next)
+		resp.NextCursor = &cursor
+	}
+	return resp
+}
+
+// encodeCursor - непрозрачный курсор следующей страницы
+func encodeCursor(offset int) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(strconv.Itoa(offset)))
+}
+
+// decodeCursor - смещение, закодированное в курсоре
+func decodeCursor(cursor string) (int, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return 0, errors.New("invalid cursor")
+	}
+	offset, err := strconv.Atoi(string(raw))
+	if err != nil || offset < 0 {
+		return 0, errors.New("invalid cursor")
+	}
+	return offset, nil
+}
+
+// parseFieldsParam - разбор параметра fields (выбор полей элементов списка).
+// При неизвестном поле пишет ответ 400 и возвращает false.
+func parseFieldsParam(w http.ResponseWriter, r *http.Request, model interface{}) (dto.Fields, bool) {
+	fields, err := dto.ParseFields(r.URL.Query().Get("fields"), model)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+		return nil, false
+	}
+	return fields, true
+}
+
+// writeInvalidCursor - ответ на некорректный cursor
+func writeInvalidCursor(w http.ResponseWriter) {
+	w.WriteHeader(http.StatusBadRequest)
+	json.NewEncoder(w).Encode(map[string]string{
+		"error": "Invalid cursor",
+	})
+}
@@ -0,0 +1,175 @@
+package main
+
+import (
+	"TSVProcessingService/db/sqlc"
+	"TSVProcessingService/internal/config"
+	"database/sql"
+	"encoding/base64"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	_ "modernc.org/sqlite"
+)
+
+// filesSchema - таблицы files и processing_errors (колонки в порядке моделей sqlc)
+const filesSchema = `
+	CREATE TABLE files (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		filename TEXT UNIQUE NOT NULL,
+		file_hash TEXT NOT NULL,
+		status TEXT DEFAULT 'pending',
+		rows_processed INTEGER DEFAULT 0,
+		rows_failed INTEGER DEFAULT 0,
+		error_message TEXT,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		source TEXT NOT NULL DEFAULT 'unknown',
+		file_mtime DATETIME,
+		arrived_at DATETIME,
+		completed_at DATETIME,
+		conflict_policy TEXT,
+		rows_skipped INTEGER DEFAULT 0,
+		rows_overwritten INTEGER DEFAULT 0,
+		rows_versioned INTEGER DEFAULT 0,
+		superseded_by INTEGER,
+		superseded_at DATETIME,
+		change_seq INTEGER NOT NULL DEFAULT 0,
+		trace_id TEXT,
+		span_id TEXT,
+		sample_rate REAL,
+		corrects_file_id INTEGER,
+		rows_corrected INTEGER DEFAULT 0,
+		correction_note TEXT,
+		rows_duplicate_existing INTEGER,
+		rows_duplicate_in_file INTEGER,
+		profile BLOB NOT NULL DEFAULT X'7B7D',
+		anomalies BLOB NOT NULL DEFAULT X'5B5D',
+		seq_first INTEGER,
+		seq_last INTEGER,
+		seq_gaps INTEGER,
+		metadata BLOB NOT NULL DEFAULT X'7B7D'
+	);
+	CREATE TABLE processing_errors (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		file_id INTEGER NOT NULL,
+		line_number INTEGER,
+		raw_line TEXT,
+		error_message TEXT NOT NULL,
+		field_name TEXT,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		unit_guid TEXT,
+		partial BLOB NOT NULL DEFAULT X'7B7D',
+		updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		change_seq INTEGER NOT NULL DEFAULT 0
+	);`
+
+// setupFilesApp - приложение с таблицами files и processing_errors
+func setupFilesApp(t *testing.T) (*App, *sql.DB) {
+	db, err := sql.Open("sqlite", ":memory:")
+	require.NoError(t, err)
+	db.SetMaxOpenConns(1)
+	t.Cleanup(func() { db.Close() })
+	_, err = db.Exec(filesSchema)
+	require.NoError(t, err)
+
+	cfg := &config.AppConfig{}
+	return &App{config: cfg, queries: sqlc.New(db), access: newAccessRoles(&cfg.Access), logger: slog.Default()}, db
+}
+
+// errorsPage - ответ GET /files/{filename}/errors
+type errorsPage struct {
+	Items []struct {
+		LineNumber int32 `json:"line_number"`
+	} `json:"items"`
+	Page       int     `json:"page"`
+	Limit      int     `json:"limit"`
+	Total      int64   `json:"total"`
+	NextCursor *string `json:"next_cursor"`
+}
+
+func TestFileErrors_Pagination(t *testing.T) {
+	a, db := setupFilesApp(t)
+	res, err := db.Exec(`INSERT INTO files (filename, file_hash, status) VALUES ('bad.tsv', 'h', 'partial')`)
+	require.NoError(t, err)
+	fileID, _ := res.LastInsertId()
+	for line := 1; line <= 5; line++ {
+		_, err := db.Exec(`INSERT INTO processing_errors (file_id, line_number, error_message) VALUES (?, ?, 'invalid level')`, fileID, line)
+		require.NoError(t, err)
+	}
+
+	router := mux.NewRouter()
+	router.HandleFunc("/api/v1/files/{filename}/errors", a.getFileErrors).Methods("GET")
+	get := func(query string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/v1/files/bad.tsv/errors?"+query, nil))
+		return rec
+	}
+	page := func(query string) errorsPage {
+		t.Helper()
+		rec := get(query)
+		require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+		var p errorsPage
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &p))
+		return p
+	}
+	lines := func(p errorsPage) []int32 {
+		var result []int32
+		for _, item := range p.Items {
+			result = append(result, item.LineNumber)
+		}
+		return result
+	}
+
+	// Первая страница
+	first := page("limit=2")
+	assert.Equal(t, []int32{1, 2}, lines(first))
+	assert.Equal(t, 1, first.Page)
+	assert.Equal(t, 2, first.Limit)
+	assert.Equal(t, int64(5), first.Total)
+	require.NotNil(t, first.NextCursor)
+
+	// Следующая страница по курсору
+	second := page("limit=2&cursor=" + *first.NextCursor)
+	assert.Equal(t, []int32{3, 4}, lines(second))
+	assert.Equal(t, 2, second.Page)
+	require.NotNil(t, second.NextCursor)
+
+	// Последняя страница: next_cursor - null
+	rec := get("limit=2&cursor=" + *second.NextCursor)
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Body.String(), `"next_cursor":null`)
+	var last errorsPage
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &last))
+	assert.Equal(t, []int32{5}, lines(last))
+	assert.Equal(t, 3, last.Page)
+
+	// Номер страницы без курсора; cursor имеет приоритет над page
+	assert.Equal(t, []int32{3, 4}, lines(page("limit=2&page=2")))
+	assert.Equal(t, []int32{5}, lines(page("limit=2&page=1&cursor="+*second.NextCursor)))
+
+	// Некорректный limit - значение по умолчанию (100, максимум 1000)
+	for _, limit := range []string{"0", "-1", "abc", "1001"} {
+		p := page("limit=" + limit)
+		assert.Equal(t, 100, p.Limit, limit)
+		assert.Len(t, p.Items, 5)
+		assert.Nil(t, p.NextCursor)
+	}
+
+	// Некорректный курсор: не base64, не число, отрицательное смещение
+	for _, cursor := range []string{"!!!", encodeCursorText("abc"), encodeCursorText("-2")} {
+		rec := get("cursor=" + cursor)
+		assert.Equal(t, http.StatusBadRequest, rec.Code, cursor)
+		assert.JSONEq(t, `{"error":"Invalid cursor"}`, rec.Body.String())
+	}
+}
+
+// encodeCursorText - курсор с произвольным содержимым
+func encodeCursorText(s string) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(s))
+}
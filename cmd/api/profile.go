@@ -0,0 +1,56 @@
+package main
+
+import (
+	"TSVProcessingService/internal/safepath"
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+// fileProfile - ответ GET /files/{filename}/profile
+type fileProfile struct {
+	Filename string          `json:"filename"`
+	FileID   int64           `json:"file_id"`
+	Profile  json.RawMessage `json:"profile"`
+}
+
+// getFileProfile - профиль значений полей файла, посчитанный при
+// обработке с worker.profile_fields: число различных значений и доля
+// пустых по колонкам, диапазон level, самые частые классы.
+// GET /files/{filename}/profile
+func (a *App) getFileProfile(w http.ResponseWriter, r *http.Request) {
+	filename := mux.Vars(r)["filename"]
+	if err := safepath.Filename(filename); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Invalid filename"})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	file, err := a.queries.GetFileByFilename(ctx, filename)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			w.WriteHeader(http.StatusNotFound)
+		} else {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+		json.NewEncoder(w).Encode(map[string]string{"error": "File not found"})
+		return
+	}
+	if len(file.Profile) == 0 || bytes.Equal(bytes.TrimSpace(file.Profile), []byte("{}")) {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "No profile for this file (processed with worker.profile_fields disabled)",
+		})
+		return
+	}
+
+	json.NewEncoder(w).Encode(fileProfile{Filename: file.Filename, FileID: file.ID, Profile: file.Profile})
+}
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"TSVProcessingService/internal/dto"
+	"TSVProcessingService/internal/filestatus"
+	"TSVProcessingService/internal/processor"
+	"time"
+)
+
+// fileProgress - ход обработки файла в ответе GET /files/{filename}
+type fileProgress struct {
+	Stage         string     `json:"stage"`
+	RowsTotal     int32      `json:"rows_total"`
+	RowsProcessed int32      `json:"rows_processed"`
+	RowsFailed    int32      `json:"rows_failed"`
+	RowsPerSecond float64    `json:"rows_per_second"`
+	ETASeconds    *float64   `json:"eta_seconds"`
+	ETA           *time.Time `json:"eta"`
+	UpdatedAt     time.Time  `json:"updated_at"`
+}
+
+// fileStatusResponse - запись о файле с текущим прогрессом обработки
+type fileStatusResponse struct {
+	dto.File
+	Progress *fileProgress `json:"progress,omitempty"`
+}
+
+// newFileStatusResponse дополняет запись о файле живыми счётчиками
+func newFileStatusResponse(file dto.File, progress processor.Progress) fileStatusResponse {
+	status := string(filestatus.Processing)
+	file.Status = &status
+	file.RowsProcessed = &progress.RowsProcessed
+	file.RowsFailed = &progress.RowsFailed
+	response := fileStatusResponse{File: file}
+	response.Progress = &fileProgress{
+		Stage:         progress.Stage,
+		RowsTotal:     progress.RowsTotal,
+		RowsProcessed: progress.RowsProcessed,
+		RowsFailed:    progress.RowsFailed,
+		RowsPerSecond: progress.Rate(),
+		UpdatedAt:     progress.UpdatedAt,
+	}
+	if eta, ok := progress.ETA(); ok {
+		seconds := eta.Seconds()
+		finish := progress.UpdatedAt.Add(eta)
+		response.Progress.ETASeconds = &seconds
+		response.Progress.ETA = &finish
+	}
+	return response
+}
@@ -0,0 +1,110 @@
+package main
+
+import (
+	"TSVProcessingService/db/sqlc"
+	"TSVProcessingService/internal/dto"
+	"TSVProcessingService/internal/safepath"
+	"archive/zip"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+)
+
+// reportGroup - части одного отчёта
+type reportGroup struct {
+	ReportGroup uuid.UUID    `json:"report_group"`
+	Parts       []dto.Report `json:"parts"`
+}
+
+// getReportGroup - список частей отчёта (report_group из ответа generate).
+// Части появляются по мере генерации.
+func (a *App) getReportGroup(w http.ResponseWriter, r *http.Request) {
+	group, reports, ok := a.fetchReportGroup(w, r)
+	if !ok {
+		return
+	}
+	json.NewEncoder(w).Encode(reportGroup{ReportGroup: group, Parts: dto.FromReports(reports)})
+}
+
+// downloadReportGroup - все части отчёта одним zip-архивом
+func (a *App) downloadReportGroup(w http.ResponseWriter, r *http.Request) {
+	group, reports, ok := a.fetchReportGroup(w, r)
+	if !ok {
+		return
+	}
+
+	paths := make([]string, 0, len(reports))
+	for _, report := range reports {
+		path, err := safepath.Within(report.FilePath, a.config.Directory.OutputPath)
+		if err != nil {
+			w.WriteHeader(http.StatusForbidden)
+			json.NewEncoder(w).Encode(map[string]string{"error": "Report file is outside the reports directory"})
+			return
+		}
+		paths = append(paths, path)
+	}
+
+	w.Header().Set("Content-Type", "application/zip")
+	w.Header().Set("Content-Disposition", `attachment; filename="report_`+group.String()+`.zip"`)
+
+	// Заголовки уже отправлены - ошибки только логируются
+	zw := zip.NewWriter(w)
+	for _, path := range paths {
+		if err := addZipFile(zw, path); err != nil {
+			a.logger.Error("❌ Failed to add report to report group archive", "path", path, "group", group, "error", err)
+			return
+		}
+	}
+	if err := zw.Close(); err != nil {
+		a.logger.Error("❌ Failed to write report group archive", "group", group, "error", err)
+	}
+}
+
+// fetchReportGroup читает части отчёта; при ошибке ответ уже записан
+func (a *App) fetchReportGroup(w http.ResponseWriter, r *http.Request) (uuid.UUID, []sqlc.Report, bool) {
+	group, err := uuid.Parse(mux.Vars(r)["group_id"])
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Invalid group_id format"})
+		return uuid.Nil, nil, false
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	reports, err := a.queries.ListReportsByGroup(ctx, uuid.NullUUID{UUID: group, Valid: true})
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Failed to fetch report group"})
+		return uuid.Nil, nil, false
+	}
+	if len(reports) == 0 {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Report group not found"})
+		return uuid.Nil, nil, false
+	}
+	return group, reports, true
+}
+
+// addZipFile копирует файл в архив под его именем
+func addZipFile(zw *zip.Writer, path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	dst, err := zw.Create(filepath.Base(path))
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(dst, f)
+	return err
+}
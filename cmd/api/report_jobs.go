@@ -0,0 +1,58 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+)
+
+// reportJobResponse - статус задания на отчёт
+type reportJobResponse struct {
+	JobID       uuid.UUID  `json:"job_id"`
+	ReportGroup uuid.UUID  `json:"report_group"`
+	UnitGuid    uuid.UUID  `json:"unit_guid"`
+	Status      string     `json:"status"`
+	Parts       int        `json:"parts"`
+	Records     int64      `json:"records"`
+	Error       string     `json:"error,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+	FinishedAt  *time.Time `json:"finished_at"`
+}
+
+// getReportJob - статус задания из ответа generate. Готовые части
+// доступны через /reports/groups/{report_group}.
+func (a *App) getReportJob(w http.ResponseWriter, r *http.Request) {
+	jobID, err := uuid.Parse(mux.Vars(r)["job_id"])
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Invalid job_id format"})
+		return
+	}
+
+	job, ok := a.processor.ReportJobStatus(jobID)
+	if !ok {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Report job not found"})
+		return
+	}
+
+	resp := reportJobResponse{
+		JobID:       job.ID,
+		ReportGroup: job.ID,
+		UnitGuid:    job.UnitGuid,
+		Status:      job.Status,
+		Parts:       job.Parts,
+		Records:     job.Records,
+		Error:       job.Error,
+		CreatedAt:   job.CreatedAt,
+		UpdatedAt:   job.UpdatedAt,
+	}
+	if !job.FinishedAt.IsZero() {
+		resp.FinishedAt = &job.FinishedAt
+	}
+	json.NewEncoder(w).Encode(resp)
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"TSVProcessingService/internal/database"
+	"TSVProcessingService/internal/dto"
+	"context"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// listReports - поиск отчётов: GET /reports?from=&to=&unit_guid=&type=
+// с пагинацией. from/to - RFC3339 или дата YYYY-MM-DD (to включает
+// весь указанный день).
+func (a *App) listReports(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	var filter database.ReportFilter
+
+	if raw := query.Get("from"); raw != "" {
+		from, _, err := parseReportTime(raw)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(map[string]string{"error": "from must be RFC3339 or YYYY-MM-DD"})
+			return
+		}
+		filter.From = from
+	}
+	if raw := query.Get("to"); raw != "" {
+		to, dateOnly, err := parseReportTime(raw)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(map[string]string{"error": "to must be RFC3339 or YYYY-MM-DD"})
+			return
+		}
+		if dateOnly {
+			to = to.AddDate(0, 0, 1)
+		}
+		filter.To = to
+	}
+	if !filter.From.IsZero() && !filter.To.IsZero() && !filter.From.Before(filter.To) {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": "from must be before to"})
+		return
+	}
+	if raw := query.Get("unit_guid"); raw != "" {
+		unitGuid, err := uuid.Parse(raw)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(map[string]string{"error": "Invalid unit_guid format"})
+			return
+		}
+		filter.UnitGuid = unitGuid
+	}
+	filter.ReportType = query.Get("type")
+
+	pageReq, err := parsePageRequest(r, 20, 100)
+	if err != nil {
+		writeInvalidCursor(w)
+		return
+	}
+	fields, ok := parseFieldsParam(w, r, dto.Report{})
+	if !ok {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	reports, total, err := a.store.ListReports(ctx, filter, int32(pageReq.Limit), int32(pageReq.Offset))
+	if err != nil {
+		a.logger.Error("Failed to list reports", "error", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Failed to fetch reports"})
+		return
+	}
+
+	json.NewEncoder(w).Encode(newPageResponse(dto.Project(dto.FromReports(reports), fields), pageReq, total))
+}
+
+// parseReportTime - RFC3339 или дата (dateOnly = true)
+func parseReportTime(raw string) (t time.Time, dateOnly bool, err error) {
+	if t, err = time.Parse(time.RFC3339, raw); err == nil {
+		return t, false, nil
+	}
+	t, err = time.Parse(time.DateOnly, raw)
+	return t, err == nil, err
+}
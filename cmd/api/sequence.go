@@ -0,0 +1,164 @@
+package main
+
+import (
+	"TSVProcessingService/db/sqlc"
+	"TSVProcessingService/internal/alert"
+	"TSVProcessingService/internal/config"
+	"TSVProcessingService/internal/dto"
+	"TSVProcessingService/internal/sequence"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+)
+
+// newSequenceChecker - фоновый поиск пропусков в последовательностях n устройств
+func newSequenceChecker(queries *sqlc.Queries, alerts *alert.Dispatcher, cfg *config.SequenceConfig) *sequence.Checker {
+	if !cfg.Alert {
+		alerts = nil
+	}
+	checker := sequence.NewChecker(queries, alerts, sequence.Options{
+		Interval: cfg.Interval,
+		Lookback: cfg.Lookback,
+	})
+	if cfg.Reexport.URL != "" {
+		checker.SetReexporter(sequence.NewReexporter(queries, sequence.ReexportOptions{
+			URL:         cfg.Reexport.URL,
+			Secret:      cfg.Reexport.Secret,
+			Timeout:     cfg.Reexport.Timeout,
+			MaxAttempts: cfg.Reexport.MaxAttempts,
+			RetryBase:   cfg.Reexport.RetryBase,
+			MaxBackoff:  cfg.Reexport.MaxBackoff,
+		}))
+		slog.Info("🔁 Missing data re-export requests enabled", "url", cfg.Reexport.URL)
+	}
+	return checker
+}
+
+// unitSequenceResponse - ответ GET /devices/{unit_guid}/sequence
+type unitSequenceResponse struct {
+	Since  time.Time `json:"since"`
+	Window string    `json:"window"`
+	sequence.UnitReport
+}
+
+// getDeviceSequence - пропуски и нарушения порядка номеров n устройства
+// в файлах за окно since (по умолчанию sequence_check.lookback)
+// GET /devices/{unit_guid}/sequence?since=
+func (a *App) getDeviceSequence(w http.ResponseWriter, r *http.Request) {
+	if a.sequence == nil {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Sequence check is not enabled"})
+		return
+	}
+	unitGuid, err := uuid.Parse(mux.Vars(r)["unit_guid"])
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Invalid unit_guid format"})
+		return
+	}
+	window := a.sequence.Lookback()
+	if raw := r.URL.Query().Get("since"); raw != "" {
+		parsed, err := time.ParseDuration(raw)
+		if err != nil || parsed <= 0 {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(map[string]string{"error": "since must be a positive duration (e.g. 720h)"})
+			return
+		}
+		window = parsed
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
+	defer cancel()
+
+	since := time.Now().Add(-window)
+	report, err := a.sequence.Unit(ctx, unitGuid, since)
+	if err != nil {
+		a.logger.Error("Failed to check sequence of unit", "unit", unitGuid, "error", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Failed to check sequence"})
+		return
+	}
+
+	json.NewEncoder(w).Encode(unitSequenceResponse{
+		Since:      since,
+		Window:     window.String(),
+		UnitReport: report,
+	})
+}
+
+// getSequenceReport - итог последней фоновой проверки последовательностей
+// GET /admin/sequence
+func (a *App) getSequenceReport(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if a.sequence == nil {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Sequence check is not enabled"})
+		return
+	}
+	report, ok := a.sequence.Report()
+	if !ok {
+		w.Header().Set("Retry-After", "60")
+		w.WriteHeader(http.StatusServiceUnavailable)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Sequence check has not run yet"})
+		return
+	}
+
+	json.NewEncoder(w).Encode(report)
+}
+
+// getReexportRequests - запросы повторной выгрузки пропусков у партнёра
+// GET /admin/sequence/reexports?status=
+func (a *App) getReexportRequests(w http.ResponseWriter, r *http.Request) {
+	if a.sequence == nil {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Sequence check is not enabled"})
+		return
+	}
+	status := r.URL.Query().Get("status")
+	if status != "" && !slices.Contains(sequence.ReexportStatuses, status) {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error": "status must be one of: " + strings.Join(sequence.ReexportStatuses, ", "),
+		})
+		return
+	}
+	pageReq, err := parsePageRequest(r, 50, 500)
+	if err != nil {
+		writeInvalidCursor(w)
+		return
+	}
+	fields, ok := parseFieldsParam(w, r, dto.ReexportRequest{})
+	if !ok {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	requests, err := a.queries.ListReexportRequests(ctx, sqlc.ListReexportRequestsParams{
+		Status: status,
+		Limit:  int32(pageReq.Limit),
+		Offset: int32(pageReq.Offset),
+	})
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Failed to fetch re-export requests"})
+		return
+	}
+	total, err := a.queries.CountReexportRequests(ctx, status)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Failed to count re-export requests"})
+		return
+	}
+
+	json.NewEncoder(w).Encode(newPageResponse(dto.Project(dto.FromReexportRequests(requests), fields), pageReq, total))
+}
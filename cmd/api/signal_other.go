@@ -0,0 +1,8 @@
+//go:build !unix
+
+package main
+
+import "os"
+
+// drainSignals - сигналов для дренажа нет, только POST /admin/drain
+var drainSignals []os.Signal
@@ -0,0 +1,11 @@
+//go:build unix
+
+package main
+
+import (
+	"os"
+	"syscall"
+)
+
+// drainSignals - сигналы мягкой остановки (startDrain)
+var drainSignals = []os.Signal{syscall.SIGUSR1}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"TSVProcessingService/internal/database"
+	"TSVProcessingService/internal/ingest"
+	"TSVProcessingService/internal/throttle"
+	"TSVProcessingService/internal/watcher"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	defaultSourceStatsWindow = 24 * time.Hour
+	maxSourceStatsWindow     = 90 * 24 * time.Hour
+)
+
+// fileSource - источник файла для записи в files.source: API-ключ,
+// tenant по префиксу имени или watch-директория (как в throttle)
+func (a *App) fileSource(fileInfo watcher.FileInfo) string {
+	return throttle.ResolveSource(fileInfo, a.config.Throttle.TenantSeparator)
+}
+
+// apiKeySource - источник "api:<префикс хеша ключа>" (ключ не раскрывается).
+// Задаётся при постановке в очередь: по источнику файл виден в spillover
+// (GET /throttle) и логах throttle задолго до обработки.
+func apiKeySource(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return "api:" + hex.EncodeToString(sum[:])[:12]
+}
+
+// dispatchKey - ключ файла для стратегии worker.assignment=hash: tenant
+// по префиксу имени, иначе unit_guid первой строки, иначе имя файла.
+func (a *App) dispatchKey(fileInfo watcher.FileInfo) string {
+	if source := throttle.ResolveSource(fileInfo, a.config.Throttle.TenantSeparator); strings.HasPrefix(source, "tenant:") {
+		return source
+	}
+	if guid, err := ingest.FirstUnitGuid(fileInfo.Path); err == nil && guid != uuid.Nil {
+		return guid.String()
+	}
+	return fileInfo.Name
+}
+
+// sourceStatisticsResponse - ответ GET /statistics/sources
+type sourceStatisticsResponse struct {
+	Since   time.Time                   `json:"since"`
+	Window  string                      `json:"window"`
+	Sources []database.SourceStatistics `json:"sources"`
+}
+
+// getSourceStatistics - статистика файлов по источникам за окно since
+// (доля ошибок, задержка обработки) для контроля SLA партнёров
+func (a *App) getSourceStatistics(w http.ResponseWriter, r *http.Request) {
+	window := defaultSourceStatsWindow
+	if raw := r.URL.Query().Get("since"); raw != "" {
+		parsed, err := time.ParseDuration(raw)
+		if err != nil || parsed <= 0 || parsed > maxSourceStatsWindow {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(map[string]string{
+				"error": "since must be a positive duration up to 2160h (e.g. 24h)",
+			})
+			return
+		}
+		window = parsed
+	}
+	source := r.URL.Query().Get("source")
+
+	ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
+	defer cancel()
+
+	since := time.Now().Add(-window)
+	sources, err := a.store.GetSourceStatistics(ctx, since, source)
+	if err != nil {
+		a.logger.Error("Failed to get source statistics", "error", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Failed to get statistics"})
+		return
+	}
+
+	json.NewEncoder(w).Encode(sourceStatisticsResponse{
+		Since:   since,
+		Window:  window.String(),
+		Sources: sources,
+	})
+}
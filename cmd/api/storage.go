@@ -0,0 +1,86 @@
+package main
+
+import (
+	"TSVProcessingService/internal/config"
+	"TSVProcessingService/internal/database"
+	"TSVProcessingService/internal/jobs"
+	"TSVProcessingService/internal/usage"
+	"context"
+	"encoding/json"
+	"net/http"
+)
+
+// newUsageCollector - подсчёт места в БД, директориях отчётов и архивов
+func newUsageCollector(store *database.Store, shares *jobs.PoolShares, cfg *config.AppConfig) *usage.Collector {
+	dirs := []usage.Dir{
+		{Name: "reports", Path: cfg.Directory.OutputPath},
+		{Name: "archive", Path: cfg.Directory.ArchivePath},
+		{Name: "errors", Path: cfg.Directory.ErrorPath},
+	}
+	if cfg.Digest.Enabled {
+		dirs = append(dirs, usage.Dir{Name: "digests", Path: cfg.Digest.OutputDir})
+	}
+	// Parquet в S3 учитывается по archived_partitions (archived_bytes устройств)
+	if cfg.Archive.Enabled && cfg.Archive.Storage == "dir" {
+		dirs = append(dirs, usage.Dir{Name: "parquet_archive", Path: cfg.Archive.Dir})
+	}
+
+	return usage.NewCollector(&sharedUsageSource{store: store, shares: shares}, usage.Options{
+		Interval: cfg.Storage.Interval,
+		TopUnits: cfg.Storage.TopUnits,
+		Dirs:     dirs,
+	})
+}
+
+// sharedUsageSource - запросы подсчёта в пределах доли пула statistics
+type sharedUsageSource struct {
+	store  *database.Store
+	shares *jobs.PoolShares
+}
+
+func (s *sharedUsageSource) GetTableSizes(ctx context.Context) ([]database.TableSize, error) {
+	release, err := s.shares.Acquire(ctx, jobs.SubsystemStatistics)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	return s.store.GetTableSizes(ctx)
+}
+
+func (s *sharedUsageSource) GetUnitStorage(ctx context.Context) ([]database.UnitStorage, error) {
+	release, err := s.shares.Acquire(ctx, jobs.SubsystemStatistics)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	return s.store.GetUnitStorage(ctx)
+}
+
+func (s *sharedUsageSource) GetSourceStorage(ctx context.Context) ([]database.SourceStorage, error) {
+	release, err := s.shares.Acquire(ctx, jobs.SubsystemStatistics)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	return s.store.GetSourceStorage(ctx)
+}
+
+// getStorageUsage - последний подсчёт использования хранилища
+func (a *App) getStorageUsage(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if a.usage == nil {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Storage usage reporting is disabled"})
+		return
+	}
+	report, ok := a.usage.Report()
+	if !ok {
+		w.Header().Set("Retry-After", "60")
+		w.WriteHeader(http.StatusServiceUnavailable)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Storage usage is not computed yet"})
+		return
+	}
+
+	json.NewEncoder(w).Encode(report)
+}
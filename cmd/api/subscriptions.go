@@ -0,0 +1,229 @@
+package main
+
+import (
+	"TSVProcessingService/db/sqlc"
+	"TSVProcessingService/internal/config"
+	"TSVProcessingService/internal/dto"
+	"TSVProcessingService/internal/subscription"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+)
+
+// newReportDeliverer - рассылка уведомлений подписчикам из конфигурации
+func newReportDeliverer(queries *sqlc.Queries, cfg *config.SubscriptionsConfig) *subscription.Deliverer {
+	return subscription.NewDeliverer(queries, subscription.Options{
+		PublicURL:   cfg.PublicURL,
+		Interval:    cfg.Interval,
+		Timeout:     cfg.Timeout,
+		MaxAttempts: cfg.MaxAttempts,
+		RetryBase:   cfg.RetryBase,
+		MaxBackoff:  cfg.MaxBackoff,
+	})
+}
+
+// subscriptionRequest - тело POST /subscriptions
+type subscriptionRequest struct {
+	UnitGuid    string `json:"unit_guid"` // пусто - все устройства
+	CallbackURL string `json:"callback_url"`
+	Secret      string `json:"secret"` // пусто - генерируется сервисом
+}
+
+// subscriptionsEnabled - 404, если подписки выключены (subscriptions.enabled)
+func (a *App) subscriptionsEnabled(w http.ResponseWriter) bool {
+	w.Header().Set("Content-Type", "application/json")
+	if a.subscriptions == nil {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Report subscriptions are not enabled"})
+		return false
+	}
+	return true
+}
+
+// createSubscription - подписка внешней системы на новые отчёты устройства
+// (или всех устройств). Секрет подписи возвращается только в этом ответе.
+// POST /subscriptions
+func (a *App) createSubscription(w http.ResponseWriter, r *http.Request) {
+	if !a.subscriptionsEnabled(w) {
+		return
+	}
+	var req subscriptionRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<20)).Decode(&req); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Invalid request body"})
+		return
+	}
+	callback, err := url.Parse(req.CallbackURL)
+	if err != nil || (callback.Scheme != "http" && callback.Scheme != "https") || callback.Host == "" {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": "callback_url must be an absolute http(s) URL"})
+		return
+	}
+	var unitGuid uuid.NullUUID
+	if req.UnitGuid != "" {
+		parsed, err := uuid.Parse(req.UnitGuid)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(map[string]string{"error": "Invalid unit_guid format"})
+			return
+		}
+		unitGuid = uuid.NullUUID{UUID: parsed, Valid: true}
+	}
+	secret := req.Secret
+	if secret == "" {
+		if secret, err = subscription.GenerateSecret(); err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			json.NewEncoder(w).Encode(map[string]string{"error": "Failed to generate secret"})
+			return
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	sub, err := a.queries.CreateReportSubscription(ctx, sqlc.CreateReportSubscriptionParams{
+		UnitGuid:    unitGuid,
+		CallbackUrl: callback.String(),
+		Secret:      secret,
+	})
+	if err != nil {
+		a.logger.Error("Failed to create subscription", "error", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Failed to create subscription"})
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(struct {
+		dto.ReportSubscription
+		Secret string `json:"secret"`
+	}{dto.FromReportSubscription(sub), sub.Secret})
+}
+
+// subscriptionsResponse - ответ GET /subscriptions
+type subscriptionsResponse struct {
+	Subscriptions []dto.ReportSubscription `json:"subscriptions"`
+}
+
+// listSubscriptions - все подписки на отчёты
+// GET /subscriptions
+func (a *App) listSubscriptions(w http.ResponseWriter, r *http.Request) {
+	if !a.subscriptionsEnabled(w) {
+		return
+	}
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	subs, err := a.queries.ListReportSubscriptions(ctx)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Failed to fetch subscriptions"})
+		return
+	}
+	json.NewEncoder(w).Encode(subscriptionsResponse{Subscriptions: dto.FromReportSubscriptions(subs)})
+}
+
+// deleteSubscription - отмена подписки (вместе с историей доставок)
+// DELETE /subscriptions/{id}
+func (a *App) deleteSubscription(w http.ResponseWriter, r *http.Request) {
+	if !a.subscriptionsEnabled(w) {
+		return
+	}
+	id, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Invalid subscription id"})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	deleted, err := a.queries.DeleteReportSubscription(ctx, id)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Failed to delete subscription"})
+		return
+	}
+	if deleted == 0 {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Subscription not found"})
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// getSubscriptionDeliveries - статус доставки уведомлений подписчику
+// (новые первыми), с фильтром по статусу
+// GET /subscriptions/{id}/deliveries?status=failed
+func (a *App) getSubscriptionDeliveries(w http.ResponseWriter, r *http.Request) {
+	if !a.subscriptionsEnabled(w) {
+		return
+	}
+	id, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Invalid subscription id"})
+		return
+	}
+	status := r.URL.Query().Get("status")
+	switch status {
+	case "", subscription.StatusPending, subscription.StatusDelivered, subscription.StatusFailed:
+	default:
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": "status must be one of: pending, delivered, failed"})
+		return
+	}
+	pageReq, err := parsePageRequest(r, 50, 500)
+	if err != nil {
+		writeInvalidCursor(w)
+		return
+	}
+	fields, ok := parseFieldsParam(w, r, dto.ReportDelivery{})
+	if !ok {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	if _, err := a.queries.GetReportSubscription(ctx, id); err != nil {
+		if err == sql.ErrNoRows {
+			w.WriteHeader(http.StatusNotFound)
+		} else {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+		json.NewEncoder(w).Encode(map[string]string{"error": "Subscription not found"})
+		return
+	}
+
+	deliveries, err := a.queries.ListReportDeliveriesBySubscription(ctx, sqlc.ListReportDeliveriesBySubscriptionParams{
+		SubscriptionID: id,
+		Status:         status,
+		Limit:          int32(pageReq.Limit),
+		Offset:         int32(pageReq.Offset),
+	})
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Failed to fetch deliveries"})
+		return
+	}
+	total, err := a.queries.CountReportDeliveriesBySubscription(ctx, sqlc.CountReportDeliveriesBySubscriptionParams{
+		SubscriptionID: id,
+		Status:         status,
+	})
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Failed to count deliveries"})
+		return
+	}
+
+	json.NewEncoder(w).Encode(newPageResponse(dto.Project(dto.FromReportDeliveries(deliveries), fields), pageReq, total))
+}
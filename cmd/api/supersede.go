@@ -0,0 +1,135 @@
+package main
+
+import (
+	"TSVProcessingService/db/sqlc"
+	"TSVProcessingService/internal/audit"
+	"TSVProcessingService/internal/dto"
+	"TSVProcessingService/internal/filestatus"
+	"TSVProcessingService/internal/safepath"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+// supersedeRequest - тело POST /files/{filename}/supersede
+type supersedeRequest struct {
+	OldFilename string `json:"old_filename"`
+}
+
+// supersedeResponse - ответ POST /files/{filename}/supersede
+type supersedeResponse struct {
+	Filename   string   `json:"filename"`
+	Superseded dto.File `json:"superseded"`
+}
+
+// supersedeFile - отметка файла {filename} как замены old_filename.
+// Данные старого файла остаются в device_data для аудита, но исключаются
+// из выборок по устройствам, отчётов и статистики.
+func (a *App) supersedeFile(w http.ResponseWriter, r *http.Request) {
+	filename := mux.Vars(r)["filename"]
+	if err := safepath.Filename(filename); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Invalid filename"})
+		return
+	}
+	var req supersedeRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<20)).Decode(&req); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Invalid request body"})
+		return
+	}
+	if err := safepath.Filename(req.OldFilename); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Invalid old_filename"})
+		return
+	}
+	if req.OldFilename == filename {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": "A file cannot supersede itself"})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
+	defer cancel()
+
+	newFile, ok := a.fetchFile(ctx, w, filename)
+	if !ok {
+		return
+	}
+	oldFile, ok := a.fetchFile(ctx, w, req.OldFilename)
+	if !ok {
+		return
+	}
+	if !filestatus.Of(newFile.Status).Processed() {
+		w.WriteHeader(http.StatusConflict)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Replacement file is not processed yet"})
+		return
+	}
+	if newFile.SupersededBy.Valid {
+		w.WriteHeader(http.StatusConflict)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Replacement file is itself superseded"})
+		return
+	}
+	if oldFile.SupersededBy.Valid {
+		w.WriteHeader(http.StatusConflict)
+		json.NewEncoder(w).Encode(map[string]string{"error": "File is already superseded"})
+		return
+	}
+
+	updated, err := a.queries.SupersedeFile(ctx, sqlc.SupersedeFileParams{
+		ID:           oldFile.ID,
+		SupersededBy: sql.NullInt64{Int64: newFile.ID, Valid: true},
+	})
+	if err != nil {
+		a.logger.Error("Failed to supersede file", "file", req.OldFilename, "error", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Failed to supersede file"})
+		return
+	}
+	a.invalidateFileData(ctx, oldFile.ID)
+	a.logger.Info("File superseded", "file", req.OldFilename, "superseded_by", filename)
+	a.recordAudit(ctx, audit.EventFileSuperseded, req.OldFilename, requestActor(r), map[string]interface{}{
+		"file_id":       oldFile.ID,
+		"file_hash":     oldFile.FileHash,
+		"superseded_by": filename,
+		"new_file_id":   newFile.ID,
+		"new_file_hash": newFile.FileHash,
+	})
+
+	json.NewEncoder(w).Encode(supersedeResponse{Filename: filename, Superseded: dto.FromFile(updated)})
+}
+
+// fetchFile - запись о файле или ответ 404/500
+func (a *App) fetchFile(ctx context.Context, w http.ResponseWriter, filename string) (sqlc.File, bool) {
+	file, err := a.queries.GetFileByFilename(ctx, filename)
+	if errors.Is(err, sql.ErrNoRows) {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(map[string]string{"error": "File not found: " + filename})
+		return file, false
+	}
+	if err != nil {
+		a.logger.Error("Failed to get file", "file", filename, "error", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Failed to fetch file"})
+		return file, false
+	}
+	return file, true
+}
+
+// invalidateFileData сбрасывает кэш устройств, данные которых были в файле
+func (a *App) invalidateFileData(ctx context.Context, fileID int64) {
+	guids, err := a.store.FileUnitGuids(ctx, fileID)
+	if err != nil {
+		a.logger.Error("Failed to list units of file", "file_id", fileID, "error", err)
+	}
+	for _, guid := range guids {
+		a.cache.DeletePrefix(unitCachePrefix(guid))
+	}
+	a.cache.Delete(statisticsCacheKey)
+	a.cache.DeletePrefix(filesCachePrefix)
+}
@@ -0,0 +1,32 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+// throttleResponse - ответ GET /throttle; при выключенном ограничении
+// заполнено только enabled
+type throttleResponse struct {
+	Enabled               bool           `json:"enabled"`
+	DefaultFilesPerMinute int            `json:"default_files_per_minute"`
+	SourceLimits          map[string]int `json:"source_limits"`
+	Spillover             map[string]int `json:"spillover"`
+}
+
+// getThrottleStatus - состояние ограничения скорости приёма по источникам
+func (a *App) getThrottleStatus(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if a.limiter == nil {
+		json.NewEncoder(w).Encode(throttleResponse{Enabled: false})
+		return
+	}
+
+	json.NewEncoder(w).Encode(throttleResponse{
+		Enabled:               true,
+		DefaultFilesPerMinute: a.config.Throttle.DefaultFilesPerMinute,
+		SourceLimits:          a.config.Throttle.SourceLimits,
+		Spillover:             a.limiter.SpilloverSizes(),
+	})
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"TSVProcessingService/internal/config"
+	"TSVProcessingService/internal/throttle"
+	"TSVProcessingService/internal/watcher"
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// syncBuffer - буфер лога, в который пишут горутины лимитера
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+func TestThrottleStatus_HidesAPIKey(t *testing.T) {
+	const apiKey = "partner-secret-key-0001"
+
+	// throttle пишет в стандартный логгер
+	var logs syncBuffer
+	defaultLogger := slog.Default()
+	slog.SetDefault(slog.New(slog.NewTextHandler(&logs, nil)))
+	t.Cleanup(func() { slog.SetDefault(defaultLogger) })
+
+	cfg := &config.AppConfig{}
+	cfg.Directory.WatchPath = t.TempDir()
+	cfg.Throttle = config.ThrottleConfig{Enabled: true, DefaultFilesPerMinute: 1, Burst: 1, MaxSpillover: 1}
+
+	w := watcher.NewWatcher(cfg.Directory.WatchPath, time.Hour, 10)
+	a := &App{config: cfg, watcher: w, limiter: throttle.NewLimiter(cfg.Throttle, 10), logger: slog.Default()}
+	go a.limiter.Run(w.GetFileQueue())
+
+	router := mux.NewRouter()
+	router.HandleFunc("/api/v1/files/{filename}/process", a.processFile).Methods("POST")
+	router.HandleFunc("/api/v1/throttle", a.getThrottleStatus).Methods("GET")
+
+	// Первый файл проходит, второй ждёт в spillover, третий отбрасывается
+	for _, name := range []string{"a.tsv", "b.tsv", "c.tsv"} {
+		require.NoError(t, os.WriteFile(filepath.Join(cfg.Directory.WatchPath, name), []byte("n\n"), 0644))
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/files/"+name+"/process", nil)
+		req.Header.Set("X-API-Key", apiKey)
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+		var resp processFileResponse
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+		assert.Equal(t, name, resp.Filename)
+		assert.Equal(t, "2 bytes", resp.Size)
+	}
+	require.Eventually(t, func() bool {
+		return len(a.limiter.SpilloverSizes()) == 1
+	}, 5*time.Second, 10*time.Millisecond)
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/v1/throttle", nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.NotContains(t, rec.Body.String(), apiKey)
+
+	var status throttleResponse
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &status))
+	assert.Equal(t, map[string]int{apiKeySource(apiKey): 1}, status.Spillover)
+
+	w.Stop()
+	for range a.limiter.Output() {
+	}
+	assert.Contains(t, logs.String(), "Spillover is full")
+	assert.NotContains(t, logs.String(), apiKey)
+	assert.Contains(t, logs.String(), apiKeySource(apiKey))
+}
@@ -0,0 +1,135 @@
+package main
+
+import (
+	"TSVProcessingService/internal/database"
+	"TSVProcessingService/internal/dto"
+	"TSVProcessingService/internal/jobs"
+	"TSVProcessingService/internal/processor"
+	"TSVProcessingService/internal/safepath"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+// timelineEvent - событие хронологии с временем от предыдущего события
+type timelineEvent struct {
+	processor.TimelineEvent
+	SincePrevious string `json:"since_previous,omitempty"`
+}
+
+// fileTimeline - ответ GET /files/{filename}/timeline
+type fileTimeline struct {
+	Filename string          `json:"filename"`
+	FileID   *int64          `json:"file_id"`
+	Status   *string         `json:"status"`
+	TraceID  *string         `json:"trace_id"`
+	Total    string          `json:"total"` // от первого до последнего события
+	Events   []timelineEvent `json:"events"`
+}
+
+// getFileTimeline - хронология обработки файла: обнаружение, очередь,
+// воркер, разбор, вставка, фиксация, отчёты, архивация. События из
+// file_events дополняются заданиями отчётов из журнала заданий и ещё
+// не сохранёнными событиями файла, который сейчас обрабатывается.
+// GET /files/{filename}/timeline
+func (a *App) getFileTimeline(w http.ResponseWriter, r *http.Request) {
+	filename := mux.Vars(r)["filename"]
+	if err := safepath.Filename(filename); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Invalid filename"})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	stored, err := a.queries.ListFileEvents(ctx, filename)
+	if err != nil {
+		a.logger.Error("Failed to list file events", "file", filename, "error", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Failed to fetch file timeline"})
+		return
+	}
+	events := make([]processor.TimelineEvent, 0, len(stored))
+	for _, e := range stored {
+		events = append(events, processor.TimelineEvent{Event: e.Event, At: e.OccurredAt, Detail: e.Detail})
+	}
+	events = append(events, a.processor.LiveTimeline(filename)...)
+
+	timeline := fileTimeline{Filename: filename}
+	file, err := a.queries.GetFileByFilename(ctx, filename)
+	switch {
+	case err == nil:
+		record := dto.FromFile(file)
+		timeline.FileID = &record.ID
+		timeline.Status = record.Status
+		timeline.TraceID = record.TraceID
+		reportEvents, err := a.reportJobEvents(ctx, file.ID)
+		if err != nil {
+			a.logger.Error("Failed to list report jobs of file", "file", filename, "error", err)
+		}
+		events = append(events, reportEvents...)
+	case !errors.Is(err, sql.ErrNoRows):
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Failed to fetch file"})
+		return
+	case len(events) == 0:
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(map[string]string{"error": "File not found"})
+		return
+	}
+
+	sort.SliceStable(events, func(i, j int) bool { return events[i].At.Before(events[j].At) })
+	timeline.Events = make([]timelineEvent, 0, len(events))
+	for i, e := range events {
+		event := timelineEvent{TimelineEvent: e}
+		if i > 0 {
+			event.SincePrevious = e.At.Sub(events[i-1].At).Round(time.Millisecond).String()
+		}
+		timeline.Events = append(timeline.Events, event)
+	}
+	if len(events) > 0 {
+		timeline.Total = events[len(events)-1].At.Sub(events[0].At).Round(time.Millisecond).String()
+	}
+
+	json.NewEncoder(w).Encode(timeline)
+}
+
+// reportJobEvents - начало и завершение заданий генерации отчётов файла
+func (a *App) reportJobEvents(ctx context.Context, fileID int64) ([]processor.TimelineEvent, error) {
+	filter := database.JobFilter{Kind: jobs.KindReport, Subject: fmt.Sprintf("file:%d", fileID)}
+	items, _, err := a.store.ListJobs(ctx, filter, 100, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	var events []processor.TimelineEvent
+	for _, job := range items {
+		if job.StartedAt.Valid {
+			events = append(events, processor.TimelineEvent{
+				Event:  processor.EventReportJobStarted,
+				At:     job.StartedAt.Time,
+				Detail: job.Owner,
+			})
+		}
+		if job.FinishedAt.Valid {
+			detail := job.State
+			if job.LastError.Valid {
+				detail += ": " + job.LastError.String
+			}
+			events = append(events, processor.TimelineEvent{
+				Event:  processor.EventReportJobFinished,
+				At:     job.FinishedAt.Time,
+				Detail: detail,
+			})
+		}
+	}
+	return events, nil
+}
@@ -0,0 +1,162 @@
+package main
+
+import (
+	"TSVProcessingService/db/sqlc"
+	"TSVProcessingService/internal/safepath"
+	"context"
+	"database/sql"
+	"embed"
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"mime"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+)
+
+// uiFiles - статическая админ-панель (одна страница поверх /api/v1)
+//
+//go:embed ui
+var uiFiles embed.FS
+
+// setupUIRoutes - админ-панель на /ui/
+func (a *App) setupUIRoutes() {
+	static, err := fs.Sub(uiFiles, "ui")
+	if err != nil {
+		panic(err) // каталог ui встроен при сборке
+	}
+	a.router.Handle("/ui", http.RedirectHandler("/ui/", http.StatusMovedPermanently)).Methods("GET")
+	a.router.PathPrefix("/ui/").Handler(http.StripPrefix("/ui/", http.FileServer(http.FS(static)))).Methods("GET")
+}
+
+// downloadReport - скачивание файла отчёта. Отдаются только файлы
+// из директории отчётов (directory.output_path).
+func (a *App) downloadReport(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	unitGuid, err := uuid.Parse(vars["unit_guid"])
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Invalid unit_guid format"})
+		return
+	}
+	id, err := strconv.ParseInt(vars["id"], 10, 64)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Invalid report id"})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	report, err := a.queries.GetReportByID(ctx, id)
+	if errors.Is(err, sql.ErrNoRows) || (err == nil && report.UnitGuid != unitGuid) {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Report not found"})
+		return
+	}
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Failed to fetch report"})
+		return
+	}
+	a.serveReport(w, r, report)
+}
+
+// downloadReportByID - скачивание файла отчёта по id без unit_guid
+// (id приходит из /reports, report_jobs и событий подписок)
+// GET /reports/{id}/download
+func (a *App) downloadReportByID(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Invalid report id"})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	report, err := a.queries.GetReportByID(ctx, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Report not found"})
+		return
+	}
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Failed to fetch report"})
+		return
+	}
+	a.serveReport(w, r, report)
+}
+
+// serveReport отдаёт файл отчёта, если он в директории отчётов;
+// файла нет на диске - 404
+func (a *App) serveReport(w http.ResponseWriter, r *http.Request, report sqlc.Report) {
+	path, err := safepath.Within(report.FilePath, a.config.Directory.OutputPath)
+	if err != nil {
+		w.WriteHeader(http.StatusForbidden)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Report file is outside the reports directory"})
+		return
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Report file not found"})
+		return
+	}
+	defer f.Close()
+	stat, err := f.Stat()
+	if err != nil || stat.IsDir() {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Report file not found"})
+		return
+	}
+
+	w.Header().Set("Content-Type", reportContentType(report.ReportType.String, path))
+	w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": filepath.Base(path)}))
+	if report.Checksum.Valid {
+		w.Header().Set("X-Report-Checksum", report.Checksum.String)
+		w.Header().Set("ETag", `"`+report.Checksum.String+`"`)
+	}
+	// ServeContent выставляет Content-Length, обрабатывает HEAD,
+	// Range/If-Range (докачка больших отчётов) и If-Modified-Since
+	http.ServeContent(w, r, filepath.Base(path), stat.ModTime(), f)
+}
+
+// reportContentTypes - MIME-типы по report_type
+var reportContentTypes = map[string]string{
+	"pdf":  "application/pdf",
+	"txt":  "text/plain; charset=utf-8",
+	"text": "text/plain; charset=utf-8",
+	"html": "text/html; charset=utf-8",
+	"csv":  "text/csv; charset=utf-8",
+	"xlsx": "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet",
+	"json": "application/json",
+	"zip":  "application/zip",
+}
+
+// reportContentType - Content-Type отчёта: по report_type из БД,
+// иначе по расширению файла
+func reportContentType(reportType, path string) string {
+	if ct, ok := reportContentTypes[strings.ToLower(reportType)]; ok {
+		return ct
+	}
+	ext := strings.ToLower(filepath.Ext(path))
+	if ct, ok := reportContentTypes[strings.TrimPrefix(ext, ".")]; ok {
+		return ct
+	}
+	if ct := mime.TypeByExtension(ext); ct != "" {
+		return ct
+	}
+	return "application/octet-stream"
+}
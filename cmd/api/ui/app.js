@@ -0,0 +1,255 @@
+// Админ-панель TSV Processing Service: только вызовы существующего /api/v1
+"use strict";
+
+const api = "/api/v1";
+const $ = (sel) => document.querySelector(sel);
+
+async function getJSON(url, options) {
+  const resp = await fetch(url, options);
+  const body = await resp.json().catch(() => ({}));
+  if (!resp.ok) {
+    throw new Error(body.error || resp.statusText);
+  }
+  return body;
+}
+
+function cell(text, className) {
+  const td = document.createElement("td");
+  td.textContent = text === null || text === undefined ? "" : text;
+  if (className) td.className = className;
+  return td;
+}
+
+function row(...cells) {
+  const tr = document.createElement("tr");
+  cells.forEach((c) => tr.appendChild(c));
+  return tr;
+}
+
+function formatTime(value) {
+  return value ? new Date(value).toLocaleString() : "";
+}
+
+function formatBytes(n) {
+  if (n < 1024) return n + " B";
+  if (n < 1024 * 1024) return (n / 1024).toFixed(1) + " KB";
+  return (n / 1024 / 1024).toFixed(1) + " MB";
+}
+
+function card(label, value) {
+  const div = document.createElement("div");
+  div.className = "card";
+  const b = document.createElement("b");
+  b.textContent = value;
+  div.append(b, label);
+  return div;
+}
+
+// Состояние сервиса и очереди
+async function loadHealth() {
+  const badge = $("#health");
+  try {
+    const health = await getJSON("/health");
+    badge.textContent = health.status;
+    badge.className = "badge " + health.status;
+    showMaintenance(health.maintenance);
+  } catch (err) {
+    badge.textContent = "unhealthy";
+    badge.className = "badge unhealthy";
+  }
+}
+
+// Окно обслуживания: активное окно или ближайшее начало
+function showMaintenance(status) {
+  const badge = $("#maintenance");
+  const windows = (status && status.windows) || [];
+  const active = windows.filter((w) => w.active);
+  if (active.length > 0) {
+    const flags = [];
+    if (status.ingestion_paused) flags.push("ingestion paused");
+    if (status.alerts_suppressed) flags.push("alerts suppressed");
+    badge.textContent = "maintenance: " + active.map((w) => w.name).join(", ") +
+      " until " + formatTime(active[0].until) + (flags.length ? " (" + flags.join(", ") + ")" : "");
+    badge.hidden = false;
+    return;
+  }
+  const next = windows.map((w) => w.next_start).filter(Boolean).sort()[0];
+  badge.textContent = next ? "next maintenance " + formatTime(next) : "";
+  badge.hidden = !next;
+}
+
+async function loadQueue() {
+  const queue = $("#queue");
+  const tbody = $("#backlog tbody");
+  try {
+    const [backlog, stats, queueStats] = await Promise.all([
+      getJSON(api + "/admin/backlog?limit=20"),
+      getJSON(api + "/statistics"),
+      getJSON(api + "/admin/queue"),
+    ]);
+    queue.replaceChildren(
+      card("files waiting", backlog.files),
+      card("oldest", backlog.oldest_age),
+      card("queue depth", queueStats.depth + " / " + queueStats.capacity),
+      card("avg queue wait", queueStats.avg_wait_seconds.toFixed(2) + " s"),
+      card("total files", stats.total_files),
+      card("device records", stats.total_device_records),
+      card("row errors", stats.total_errors),
+      card("reports", stats.total_reports),
+    );
+    (backlog.alarms || []).forEach((alarm) => queue.appendChild(card("alarm", alarm)));
+
+    tbody.replaceChildren(...(backlog.oldest || []).map((e) =>
+      row(cell(e.name), cell(formatBytes(e.size)), cell(formatTime(e.first_seen)), cell(e.reason))));
+  } catch (err) {
+    queue.replaceChildren(card("error", err.message));
+  }
+}
+
+// Последние файлы (новые первыми) с переходом к ошибкам
+let filesCursor = null;
+
+async function loadFiles(append) {
+  const tbody = $("#files tbody");
+  const url = api + "/files?limit=20&sort=-created_at" + (append && filesCursor ? "&cursor=" + filesCursor : "");
+  try {
+    const page = await getJSON(url);
+    const rows = page.items.map((f) => {
+      const tr = row(
+        cell(f.filename),
+        cell(f.status, "status-" + f.status),
+        cell(f.rows_processed),
+        cell(f.rows_failed),
+        cell(f.source),
+        cell(formatTime(f.created_at)),
+        cell(f.error_message, "error"),
+      );
+      tr.className = "clickable";
+      tr.title = "Show errors";
+      tr.addEventListener("click", () => loadErrors(f.filename, false));
+      return tr;
+    });
+    if (append) tbody.append(...rows);
+    else tbody.replaceChildren(...rows);
+    filesCursor = page.next_cursor;
+    $("#files-more").hidden = !filesCursor;
+  } catch (err) {
+    tbody.replaceChildren(row(cell(err.message, "error")));
+  }
+}
+
+// Ошибки разбора строк выбранного файла
+let errorsFile = null;
+let errorsCursor = null;
+
+async function loadErrors(filename, append) {
+  errorsFile = filename;
+  $("#errors-section").hidden = false;
+  $("#errors-file").textContent = filename;
+  $("#errors-message").textContent = "";
+  const tbody = $("#errors tbody");
+  const name = encodeURIComponent(filename);
+  const url = api + "/files/" + name + "/errors?limit=50" + (append && errorsCursor ? "&cursor=" + errorsCursor : "");
+  try {
+    const [file, page] = await Promise.all([getJSON(api + "/files/" + name), getJSON(url)]);
+    if (file.error_message) $("#errors-message").textContent = file.error_message;
+    const rows = page.items.map((e) =>
+      row(cell(e.line_number), cell(e.field_name), cell(e.error_message), cell(e.raw_line, "raw")));
+    if (append) tbody.append(...rows);
+    else tbody.replaceChildren(...rows);
+    errorsCursor = page.next_cursor;
+    $("#errors-more").hidden = !errorsCursor;
+    if (!append) $("#errors-section").scrollIntoView({ behavior: "smooth" });
+  } catch (err) {
+    $("#errors-message").textContent = err.message;
+    tbody.replaceChildren();
+  }
+}
+
+// Отчёты устройства: список, скачивание и генерация
+async function loadReports(unitGuid) {
+  const tbody = $("#reports tbody");
+  const message = $("#reports-message");
+  message.textContent = "";
+  try {
+    const page = await getJSON(api + "/reports?unit_guid=" + encodeURIComponent(unitGuid) + "&limit=50");
+    tbody.replaceChildren(...page.items.map((r) => {
+      const link = document.createElement("a");
+      link.href = api + "/reports/" + r.unit_guid + "/" + r.id + "/download";
+      link.textContent = r.file_path.split(/[\\/]/).pop();
+      link.addEventListener("click", (e) => {
+        e.preventDefault();
+        downloadSigned(api + "/reports/" + r.unit_guid + "/" + r.id + "/download-url");
+      });
+      const td = cell("");
+      td.appendChild(link);
+      return row(cell(formatTime(r.generated_at)), cell(r.report_type), td);
+    }));
+    if (page.items.length === 0) message.textContent = "No reports yet";
+  } catch (err) {
+    message.textContent = err.message;
+    tbody.replaceChildren();
+  }
+}
+
+// Скачивание по подписанной ссылке: браузер получает файл без ключа API
+async function downloadSigned(urlEndpoint) {
+  const message = $("#reports-message");
+  try {
+    const resp = await getJSON(urlEndpoint);
+    window.location.assign(resp.url);
+  } catch (err) {
+    message.textContent = err.message;
+  }
+}
+
+async function generateReport(unitGuid) {
+  const message = $("#reports-message");
+  try {
+    const resp = await getJSON(api + "/reports/" + encodeURIComponent(unitGuid) + "/generate", { method: "POST" });
+    message.textContent = resp.message + " (job " + resp.job_id + ")";
+    pollReportJob(resp.job_id, unitGuid);
+  } catch (err) {
+    message.textContent = err.message;
+  }
+}
+
+async function pollReportJob(jobId, unitGuid) {
+  const message = $("#reports-message");
+  try {
+    const job = await getJSON(api + "/reports/jobs/" + jobId);
+    if (job.status === "queued" || job.status === "running") {
+      message.textContent = "Report job " + job.status + ", parts ready: " + job.parts;
+      setTimeout(() => pollReportJob(jobId, unitGuid), 2000);
+      return;
+    }
+    message.textContent = job.status === "completed"
+      ? "Report ready: " + job.parts + " part(s), " + job.records + " records"
+      : "Report failed: " + job.error;
+    loadReports(unitGuid);
+  } catch (err) {
+    message.textContent = err.message;
+  }
+}
+
+function refresh() {
+  loadHealth();
+  loadQueue();
+  loadFiles(false);
+  if (errorsFile) loadErrors(errorsFile, false);
+}
+
+$("#refresh").addEventListener("click", refresh);
+$("#files-more").addEventListener("click", () => loadFiles(true));
+$("#errors-more").addEventListener("click", () => loadErrors(errorsFile, true));
+$("#reports-form").addEventListener("submit", (e) => {
+  e.preventDefault();
+  loadReports(e.target.unit_guid.value.trim());
+});
+$("#reports-generate").addEventListener("click", () => {
+  const unitGuid = $("#reports-form").unit_guid.value.trim();
+  if (unitGuid) generateReport(unitGuid);
+});
+
+refresh();
+setInterval(() => { loadHealth(); loadQueue(); }, 10000);
@@ -0,0 +1,231 @@
+package main
+
+import (
+	"TSVProcessingService/db/sqlc"
+	"TSVProcessingService/internal/config"
+	"TSVProcessingService/internal/signedurl"
+	"database/sql"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	_ "modernc.org/sqlite"
+)
+
+// reportDownloadKey - ключ подписи ссылок на скачивание в тестах
+const reportDownloadKey = "test-signing-key"
+
+// setupReportDownload - приложение с одним отчётом (id 1) в директории
+// отчётов и маршрутами скачивания, как в setupRoutes
+func setupReportDownload(t *testing.T, content string) (*mux.Router, *sql.DB, string) {
+	db, err := sql.Open("sqlite", ":memory:")
+	require.NoError(t, err)
+	db.SetMaxOpenConns(1)
+	t.Cleanup(func() { db.Close() })
+
+	_, err = db.Exec(`CREATE TABLE reports (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		unit_guid TEXT NOT NULL,
+		report_type TEXT,
+		file_path TEXT NOT NULL,
+		generated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		checksum TEXT,
+		report_group TEXT,
+		part INTEGER,
+		updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		change_seq INTEGER NOT NULL DEFAULT 0,
+		trace_id TEXT,
+		span_id TEXT
+	)`)
+	require.NoError(t, err)
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "01749246-95f6-57db-b7c3-2ae0e8be671f_20250314_103000.csv")
+	require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+	_, err = db.Exec(`INSERT INTO reports (unit_guid, report_type, file_path, checksum) VALUES (?, ?, ?, ?)`,
+		"01749246-95f6-57db-b7c3-2ae0e8be671f", "csv", path, "abc123")
+	require.NoError(t, err)
+
+	cfg := &config.AppConfig{}
+	cfg.Directory.OutputPath = dir
+	cfg.Downloads.RequireSignature = true
+	a := &App{
+		config:    cfg,
+		queries:   sqlc.New(db),
+		downloads: signedurl.NewSigner([]byte(reportDownloadKey), time.Hour),
+		logger:    slog.Default(),
+	}
+	router := mux.NewRouter()
+	router.HandleFunc("/api/v1/reports/{id:[0-9]+}/download", a.downloadReportByID).Methods("GET", "HEAD")
+	router.HandleFunc("/api/v1/reports/{unit_guid}/{id:[0-9]+}/download", a.withSignedDownload(a.downloadReport)).Methods("GET", "HEAD")
+	return router, db, path
+}
+
+func serveDownload(router http.Handler, method, url string, header map[string]string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, url, nil)
+	for k, v := range header {
+		req.Header.Set(k, v)
+	}
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestDownloadReportByID(t *testing.T) {
+	content := "line_number,unit_guid\n1,01749246-95f6-57db-b7c3-2ae0e8be671f\n"
+	router, db, path := setupReportDownload(t, content)
+
+	t.Run("full file", func(t *testing.T) {
+		rec := serveDownload(router, http.MethodGet, "/api/v1/reports/1/download", nil)
+		require.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, content, rec.Body.String())
+		assert.Equal(t, "text/csv; charset=utf-8", rec.Header().Get("Content-Type"))
+		assert.Equal(t, `attachment; filename=01749246-95f6-57db-b7c3-2ae0e8be671f_20250314_103000.csv`,
+			rec.Header().Get("Content-Disposition"))
+		assert.Equal(t, `"abc123"`, rec.Header().Get("ETag"))
+		assert.Equal(t, "abc123", rec.Header().Get("X-Report-Checksum"))
+		assert.Equal(t, "bytes", rec.Header().Get("Accept-Ranges"))
+	})
+
+	t.Run("range", func(t *testing.T) {
+		rec := serveDownload(router, http.MethodGet, "/api/v1/reports/1/download", map[string]string{"Range": "bytes=5-10"})
+		require.Equal(t, http.StatusPartialContent, rec.Code)
+		assert.Equal(t, content[5:11], rec.Body.String())
+		assert.Equal(t, "bytes 5-10/"+strconv.Itoa(len(content)), rec.Header().Get("Content-Range"))
+
+		// Продолжение закачки того же файла (If-Range по ETag)
+		rec = serveDownload(router, http.MethodGet, "/api/v1/reports/1/download", map[string]string{
+			"Range": "bytes=20-", "If-Range": `"abc123"`,
+		})
+		require.Equal(t, http.StatusPartialContent, rec.Code)
+		assert.Equal(t, content[20:], rec.Body.String())
+
+		// Файл изменился: If-Range не совпадает - файл целиком
+		rec = serveDownload(router, http.MethodGet, "/api/v1/reports/1/download", map[string]string{
+			"Range": "bytes=20-", "If-Range": `"other"`,
+		})
+		require.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, content, rec.Body.String())
+
+		rec = serveDownload(router, http.MethodGet, "/api/v1/reports/1/download", map[string]string{"Range": "bytes=1000-"})
+		assert.Equal(t, http.StatusRequestedRangeNotSatisfiable, rec.Code)
+	})
+
+	t.Run("head", func(t *testing.T) {
+		rec := serveDownload(router, http.MethodHead, "/api/v1/reports/1/download", nil)
+		require.Equal(t, http.StatusOK, rec.Code)
+		assert.Empty(t, rec.Body.String())
+		assert.Equal(t, strconv.Itoa(len(content)), rec.Header().Get("Content-Length"))
+		assert.NotEmpty(t, rec.Header().Get("Content-Disposition"))
+	})
+
+	t.Run("not modified", func(t *testing.T) {
+		rec := serveDownload(router, http.MethodGet, "/api/v1/reports/1/download", map[string]string{"If-None-Match": `"abc123"`})
+		assert.Equal(t, http.StatusNotModified, rec.Code)
+		assert.Empty(t, rec.Body.String())
+	})
+
+	t.Run("unknown id", func(t *testing.T) {
+		rec := serveDownload(router, http.MethodGet, "/api/v1/reports/999/download", nil)
+		assert.Equal(t, http.StatusNotFound, rec.Code)
+		assert.JSONEq(t, `{"error":"Report not found"}`, rec.Body.String())
+	})
+
+	t.Run("file outside reports directory", func(t *testing.T) {
+		_, err := db.Exec(`INSERT INTO reports (id, unit_guid, file_path) VALUES (2, ?, ?)`,
+			"01749246-95f6-57db-b7c3-2ae0e8be671f", "/etc/passwd")
+		require.NoError(t, err)
+		rec := serveDownload(router, http.MethodGet, "/api/v1/reports/2/download", nil)
+		assert.Equal(t, http.StatusForbidden, rec.Code)
+	})
+
+	t.Run("file removed", func(t *testing.T) {
+		require.NoError(t, os.Remove(path))
+		rec := serveDownload(router, http.MethodGet, "/api/v1/reports/1/download", nil)
+		assert.Equal(t, http.StatusNotFound, rec.Code)
+		assert.JSONEq(t, `{"error":"Report file not found"}`, rec.Body.String())
+	})
+}
+
+func TestReportContentDisposition_QuotesFileName(t *testing.T) {
+	router, db, path := setupReportDownload(t, "data")
+	quoted := filepath.Join(filepath.Dir(path), `отчёт "A".csv`)
+	require.NoError(t, os.Rename(path, quoted))
+	_, err := db.Exec(`UPDATE reports SET file_path = ? WHERE id = 1`, quoted)
+	require.NoError(t, err)
+
+	rec := serveDownload(router, http.MethodGet, "/api/v1/reports/1/download", nil)
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, `attachment; filename*=utf-8''%D0%BE%D1%82%D1%87%D1%91%D1%82%20%22A%22.csv`,
+		rec.Header().Get("Content-Disposition"))
+}
+
+func TestDownloadReport_SignedHeadAndRange(t *testing.T) {
+	content := "line_number,unit_guid\n1,01749246-95f6-57db-b7c3-2ae0e8be671f\n"
+	router, _, _ := setupReportDownload(t, content)
+	signer := signedurl.NewSigner([]byte(reportDownloadKey), time.Hour)
+	signed := func(unitGuid string) string {
+		url, _ := signer.Sign("/api/v1/reports/" + unitGuid + "/1/download")
+		return url
+	}
+	url := signed("01749246-95f6-57db-b7c3-2ae0e8be671f")
+	size := strconv.Itoa(len(content))
+
+	t.Run("head", func(t *testing.T) {
+		rec := serveDownload(router, http.MethodHead, url, nil)
+		require.Equal(t, http.StatusOK, rec.Code)
+		assert.Empty(t, rec.Body.String())
+		assert.Equal(t, size, rec.Header().Get("Content-Length"))
+		assert.Equal(t, "text/csv; charset=utf-8", rec.Header().Get("Content-Type"))
+		assert.Equal(t, "bytes", rec.Header().Get("Accept-Ranges"))
+		assert.Equal(t, `"abc123"`, rec.Header().Get("ETag"))
+		assert.Equal(t, `attachment; filename=01749246-95f6-57db-b7c3-2ae0e8be671f_20250314_103000.csv`,
+			rec.Header().Get("Content-Disposition"))
+		assert.NotEmpty(t, rec.Header().Get("Last-Modified"))
+	})
+
+	t.Run("head with range", func(t *testing.T) {
+		rec := serveDownload(router, http.MethodHead, url, map[string]string{"Range": "bytes=0-9"})
+		require.Equal(t, http.StatusPartialContent, rec.Code)
+		assert.Empty(t, rec.Body.String())
+		assert.Equal(t, "10", rec.Header().Get("Content-Length"))
+		assert.Equal(t, "bytes 0-9/"+size, rec.Header().Get("Content-Range"))
+	})
+
+	t.Run("single range", func(t *testing.T) {
+		rec := serveDownload(router, http.MethodGet, url, map[string]string{"Range": "bytes=12-20"})
+		require.Equal(t, http.StatusPartialContent, rec.Code)
+		assert.Equal(t, content[12:21], rec.Body.String())
+		assert.Equal(t, "9", rec.Header().Get("Content-Length"))
+		assert.Equal(t, "bytes 12-20/"+size, rec.Header().Get("Content-Range"))
+
+		// Суффиксный диапазон - последние байты файла
+		rec = serveDownload(router, http.MethodGet, url, map[string]string{"Range": "bytes=-5"})
+		require.Equal(t, http.StatusPartialContent, rec.Code)
+		assert.Equal(t, content[len(content)-5:], rec.Body.String())
+	})
+
+	t.Run("unsatisfiable range", func(t *testing.T) {
+		rec := serveDownload(router, http.MethodGet, url, map[string]string{"Range": "bytes=" + size + "-"})
+		require.Equal(t, http.StatusRequestedRangeNotSatisfiable, rec.Code)
+		assert.Equal(t, "bytes */"+size, rec.Header().Get("Content-Range"))
+	})
+
+	t.Run("other unit", func(t *testing.T) {
+		rec := serveDownload(router, http.MethodHead, signed("9a0f0f2c-2a3e-4f5b-8c6d-7e8f90a1b2c3"), nil)
+		assert.Equal(t, http.StatusNotFound, rec.Code)
+	})
+
+	t.Run("unsigned", func(t *testing.T) {
+		rec := serveDownload(router, http.MethodHead, "/api/v1/reports/01749246-95f6-57db-b7c3-2ae0e8be671f/1/download", nil)
+		assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	})
+}
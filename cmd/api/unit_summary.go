@@ -0,0 +1,85 @@
+package main
+
+import (
+	"TSVProcessingService/db/sqlc"
+	"TSVProcessingService/internal/dto"
+	"context"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+)
+
+// maxSummaryDays - наибольший период одного запроса суточной сводки
+const maxSummaryDays = 366
+
+// unitSummaryResponse - ответ GET /devices/{unit_guid}/summary
+type unitSummaryResponse struct {
+	UnitGuid uuid.UUID              `json:"unit_guid"`
+	From     string                 `json:"from"`
+	To       string                 `json:"to"`
+	Days     []dto.UnitDailySummary `json:"days"`
+}
+
+// getDeviceSummary - суточная сводка устройства (записи, аварии, максимальный
+// уровень): GET /devices/{unit_guid}/summary?from=&to= (YYYY-MM-DD, UTC,
+// включительно). По умолчанию - последние 30 дней. Дни без данных не включаются.
+func (a *App) getDeviceSummary(w http.ResponseWriter, r *http.Request) {
+	unitGuid, err := uuid.Parse(mux.Vars(r)["unit_guid"])
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Invalid unit_guid format"})
+		return
+	}
+
+	to := time.Now().UTC().Truncate(24 * time.Hour)
+	if raw := r.URL.Query().Get("to"); raw != "" {
+		if to, err = time.Parse(time.DateOnly, raw); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(map[string]string{"error": "to must be YYYY-MM-DD"})
+			return
+		}
+	}
+	from := to.AddDate(0, 0, -29)
+	if raw := r.URL.Query().Get("from"); raw != "" {
+		if from, err = time.Parse(time.DateOnly, raw); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(map[string]string{"error": "from must be YYYY-MM-DD"})
+			return
+		}
+	}
+	if from.After(to) {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": "from must not be after to"})
+		return
+	}
+	if to.Sub(from) >= maxSummaryDays*24*time.Hour {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Period must not exceed 366 days"})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
+	defer cancel()
+
+	days, err := a.queries.ListUnitDailySummary(ctx, sqlc.ListUnitDailySummaryParams{
+		UnitGuid: unitGuid,
+		Day:      from,
+		Day_2:    to,
+	})
+	if err != nil {
+		a.logger.Error("Failed to get daily summary of unit", "unit", unitGuid, "error", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Failed to fetch device summary"})
+		return
+	}
+
+	json.NewEncoder(w).Encode(unitSummaryResponse{
+		UnitGuid: unitGuid,
+		From:     from.Format(time.DateOnly),
+		To:       to.Format(time.DateOnly),
+		Days:     dto.FromUnitDailySummaries(days),
+	})
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"TSVProcessingService/internal/dto"
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// maxVerifyReportSize - максимальный размер проверяемого файла отчёта
+const maxVerifyReportSize = 100 << 20
+
+// reportVerification - результат проверки отчёта
+type reportVerification struct {
+	Valid    bool        `json:"valid"`
+	Checksum string      `json:"checksum"`
+	Report   *dto.Report `json:"report,omitempty"`
+}
+
+// verifyReport - проверка, что отчёт сгенерирован сервисом и не изменён.
+// POST /reports/verify с файлом отчёта в теле запроса или
+// GET /reports/verify?checksum=<sha256>.
+func (a *App) verifyReport(w http.ResponseWriter, r *http.Request) {
+	var checksum string
+	if r.Method == http.MethodPost {
+		hash := sha256.New()
+		if _, err := io.Copy(hash, http.MaxBytesReader(w, r.Body, maxVerifyReportSize)); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(map[string]string{"error": "Failed to read report file"})
+			return
+		}
+		checksum = hex.EncodeToString(hash.Sum(nil))
+	} else {
+		checksum = strings.ToLower(r.URL.Query().Get("checksum"))
+		if _, err := hex.DecodeString(checksum); err != nil || len(checksum) != sha256.Size*2 {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(map[string]string{"error": "checksum must be a SHA256 hex string"})
+			return
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	report, err := a.queries.GetReportByChecksum(ctx, sql.NullString{String: checksum, Valid: true})
+	if errors.Is(err, sql.ErrNoRows) {
+		json.NewEncoder(w).Encode(reportVerification{Valid: false, Checksum: checksum})
+		return
+	}
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Failed to verify report"})
+		return
+	}
+
+	result := dto.FromReport(report)
+	json.NewEncoder(w).Encode(reportVerification{Valid: true, Checksum: checksum, Report: &result})
+}
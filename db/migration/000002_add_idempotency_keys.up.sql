@@ -0,0 +1,16 @@
+CREATE TABLE "idempotency_keys" (
+  "id" bigserial PRIMARY KEY,
+  "key" varchar NOT NULL,
+  "endpoint" varchar NOT NULL,
+  "status_code" integer NOT NULL,
+  "response_body" text NOT NULL,
+  -- Ключ резервируется до вызова обработчика: повтор, пришедший во время
+  -- обработки первого запроса, получает 409, а не выполняет его второй раз
+  "pending" boolean NOT NULL DEFAULT false,
+  "created_at" timestamptz DEFAULT (now()),
+  "expires_at" timestamptz NOT NULL
+);
+
+CREATE UNIQUE INDEX ON "idempotency_keys" ("key", "endpoint");
+
+CREATE INDEX ON "idempotency_keys" ("expires_at");
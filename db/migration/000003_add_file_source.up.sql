@@ -0,0 +1,3 @@
+ALTER TABLE "files" ADD COLUMN "source" varchar NOT NULL DEFAULT 'unknown';
+
+CREATE INDEX ON "files" ("source", "created_at");
\ No newline at end of file
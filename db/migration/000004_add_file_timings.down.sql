@@ -0,0 +1,5 @@
+ALTER TABLE "files" DROP COLUMN IF EXISTS "completed_at";
+
+ALTER TABLE "files" DROP COLUMN IF EXISTS "arrived_at";
+
+ALTER TABLE "files" DROP COLUMN IF EXISTS "file_mtime";
\ No newline at end of file
@@ -0,0 +1,7 @@
+ALTER TABLE "files" ADD COLUMN "file_mtime" timestamptz;
+
+ALTER TABLE "files" ADD COLUMN "arrived_at" timestamptz;
+
+ALTER TABLE "files" ADD COLUMN "completed_at" timestamptz;
+
+CREATE INDEX ON "files" ("completed_at");
\ No newline at end of file
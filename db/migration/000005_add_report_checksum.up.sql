@@ -0,0 +1,3 @@
+ALTER TABLE "reports" ADD COLUMN "checksum" varchar(64);
+
+CREATE INDEX ON "reports" ("checksum");
\ No newline at end of file
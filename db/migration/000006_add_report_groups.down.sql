@@ -0,0 +1,3 @@
+ALTER TABLE "reports" DROP COLUMN IF EXISTS "part";
+
+ALTER TABLE "reports" DROP COLUMN IF EXISTS "report_group";
\ No newline at end of file
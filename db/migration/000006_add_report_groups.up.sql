@@ -0,0 +1,5 @@
+ALTER TABLE "reports" ADD COLUMN "report_group" uuid;
+
+ALTER TABLE "reports" ADD COLUMN "part" integer;
+
+CREATE INDEX ON "reports" ("report_group");
\ No newline at end of file
@@ -0,0 +1,11 @@
+ALTER TABLE "files" DROP COLUMN IF EXISTS "rows_versioned";
+
+ALTER TABLE "files" DROP COLUMN IF EXISTS "rows_overwritten";
+
+ALTER TABLE "files" DROP COLUMN IF EXISTS "rows_skipped";
+
+ALTER TABLE "files" DROP COLUMN IF EXISTS "conflict_policy";
+
+DROP INDEX IF EXISTS "device_data_unit_guid_msg_id_idx";
+
+ALTER TABLE "device_data" DROP COLUMN IF EXISTS "version";
\ No newline at end of file
@@ -0,0 +1,11 @@
+ALTER TABLE "device_data" ADD COLUMN "version" integer NOT NULL DEFAULT 1;
+
+CREATE INDEX ON "device_data" ("unit_guid", "msg_id");
+
+ALTER TABLE "files" ADD COLUMN "conflict_policy" varchar;
+
+ALTER TABLE "files" ADD COLUMN "rows_skipped" integer DEFAULT 0;
+
+ALTER TABLE "files" ADD COLUMN "rows_overwritten" integer DEFAULT 0;
+
+ALTER TABLE "files" ADD COLUMN "rows_versioned" integer DEFAULT 0;
\ No newline at end of file
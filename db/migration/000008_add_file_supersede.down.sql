@@ -0,0 +1,3 @@
+ALTER TABLE "files" DROP COLUMN IF EXISTS "superseded_at";
+
+ALTER TABLE "files" DROP COLUMN IF EXISTS "superseded_by";
\ No newline at end of file
@@ -0,0 +1,7 @@
+ALTER TABLE "files" ADD COLUMN "superseded_by" bigint;
+
+ALTER TABLE "files" ADD COLUMN "superseded_at" timestamptz;
+
+ALTER TABLE "files" ADD FOREIGN KEY ("superseded_by") REFERENCES "files" ("id");
+
+CREATE INDEX ON "files" ("superseded_by");
\ No newline at end of file
@@ -0,0 +1 @@
+ALTER TABLE "processing_errors" DROP COLUMN IF EXISTS "unit_guid";
\ No newline at end of file
@@ -0,0 +1,3 @@
+ALTER TABLE "processing_errors" ADD COLUMN "unit_guid" uuid;
+
+CREATE INDEX ON "processing_errors" ("unit_guid", "created_at");
\ No newline at end of file
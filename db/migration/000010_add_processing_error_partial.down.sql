@@ -0,0 +1 @@
+ALTER TABLE "processing_errors" DROP COLUMN IF EXISTS "partial";
\ No newline at end of file
@@ -0,0 +1 @@
+ALTER TABLE "processing_errors" ADD COLUMN "partial" jsonb NOT NULL DEFAULT '{}';
\ No newline at end of file
@@ -0,0 +1 @@
+DROP TABLE IF EXISTS "unit_daily_summary";
\ No newline at end of file
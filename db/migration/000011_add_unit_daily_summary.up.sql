@@ -0,0 +1,17 @@
+CREATE TABLE "unit_daily_summary" (
+  "unit_guid" uuid NOT NULL,
+  "day" date NOT NULL,
+  "records" integer NOT NULL DEFAULT 0,
+  "alarms" integer NOT NULL DEFAULT 0,
+  "max_level" integer,
+  "updated_at" timestamptz DEFAULT (now()),
+  PRIMARY KEY ("unit_guid", "day")
+);
+
+INSERT INTO "unit_daily_summary" ("unit_guid", "day", "records", "alarms", "max_level")
+SELECT "unit_guid", ("created_at" AT TIME ZONE 'UTC')::date, COUNT(*),
+  SUM(CASE WHEN "class" = 'alarm' THEN 1 ELSE 0 END), MAX("level")
+FROM "device_data"
+WHERE "created_at" IS NOT NULL
+  AND "file_id" NOT IN (SELECT "id" FROM "files" WHERE "superseded_by" IS NOT NULL)
+GROUP BY 1, 2;
\ No newline at end of file
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS "device_data_created_at_idx";
+
+DROP TABLE IF EXISTS "archived_partitions";
\ No newline at end of file
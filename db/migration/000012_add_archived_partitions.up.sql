@@ -0,0 +1,16 @@
+CREATE TABLE "archived_partitions" (
+  "id" bigserial PRIMARY KEY,
+  "unit_guid" uuid NOT NULL,
+  "month" date NOT NULL,
+  "object_key" varchar UNIQUE NOT NULL,
+  "row_count" integer NOT NULL,
+  "size_bytes" bigint NOT NULL,
+  "checksum" varchar(64) NOT NULL,
+  "min_id" bigint NOT NULL,
+  "max_id" bigint NOT NULL,
+  "archived_at" timestamptz DEFAULT (now())
+);
+
+CREATE INDEX ON "archived_partitions" ("unit_guid", "month");
+
+CREATE INDEX ON "device_data" ("created_at");
\ No newline at end of file
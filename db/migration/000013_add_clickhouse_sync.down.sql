@@ -0,0 +1 @@
+DROP TABLE IF EXISTS "clickhouse_sync";
\ No newline at end of file
@@ -0,0 +1,10 @@
+CREATE TABLE "clickhouse_sync" (
+  "file_id" bigint PRIMARY KEY REFERENCES "files" ("id") ON DELETE CASCADE,
+  "queued_at" timestamptz NOT NULL DEFAULT (now()),
+  "synced_at" timestamptz,
+  "rows_synced" integer NOT NULL DEFAULT 0,
+  "attempts" integer NOT NULL DEFAULT 0,
+  "last_error" text
+);
+
+CREATE INDEX ON "clickhouse_sync" ("queued_at") WHERE "synced_at" IS NULL;
\ No newline at end of file
@@ -0,0 +1,55 @@
+DROP PUBLICATION IF EXISTS "tsv_cdc";
+
+DROP TRIGGER IF EXISTS "files_cdc_touch" ON "files";
+
+DROP TRIGGER IF EXISTS "device_data_cdc_touch" ON "device_data";
+
+DROP TRIGGER IF EXISTS "processing_errors_cdc_touch" ON "processing_errors";
+
+DROP TRIGGER IF EXISTS "reports_cdc_touch" ON "reports";
+
+DROP TRIGGER IF EXISTS "api_logs_cdc_touch" ON "api_logs";
+
+DROP TRIGGER IF EXISTS "idempotency_keys_cdc_touch" ON "idempotency_keys";
+
+DROP TRIGGER IF EXISTS "unit_daily_summary_cdc_touch" ON "unit_daily_summary";
+
+DROP TRIGGER IF EXISTS "archived_partitions_cdc_touch" ON "archived_partitions";
+
+DROP TRIGGER IF EXISTS "clickhouse_sync_cdc_touch" ON "clickhouse_sync";
+
+ALTER TABLE "files" DROP COLUMN IF EXISTS "change_seq";
+
+ALTER TABLE "device_data" DROP COLUMN IF EXISTS "change_seq";
+
+ALTER TABLE "processing_errors" DROP COLUMN IF EXISTS "change_seq";
+
+ALTER TABLE "reports" DROP COLUMN IF EXISTS "change_seq";
+
+ALTER TABLE "api_logs" DROP COLUMN IF EXISTS "change_seq";
+
+ALTER TABLE "idempotency_keys" DROP COLUMN IF EXISTS "change_seq";
+
+ALTER TABLE "unit_daily_summary" DROP COLUMN IF EXISTS "change_seq";
+
+ALTER TABLE "archived_partitions" DROP COLUMN IF EXISTS "change_seq";
+
+ALTER TABLE "clickhouse_sync" DROP COLUMN IF EXISTS "change_seq";
+
+ALTER TABLE "device_data" DROP COLUMN IF EXISTS "updated_at";
+
+ALTER TABLE "processing_errors" DROP COLUMN IF EXISTS "updated_at";
+
+ALTER TABLE "reports" DROP COLUMN IF EXISTS "updated_at";
+
+ALTER TABLE "api_logs" DROP COLUMN IF EXISTS "updated_at";
+
+ALTER TABLE "idempotency_keys" DROP COLUMN IF EXISTS "updated_at";
+
+ALTER TABLE "archived_partitions" DROP COLUMN IF EXISTS "updated_at";
+
+ALTER TABLE "clickhouse_sync" DROP COLUMN IF EXISTS "updated_at";
+
+DROP FUNCTION IF EXISTS "cdc_touch"();
+
+DROP SEQUENCE IF EXISTS "cdc_change_seq";
\ No newline at end of file
@@ -0,0 +1,100 @@
+-- CDC (логическое декодирование): все таблицы ведут updated_at и change_seq
+-- триггером cdc_touch; change_seq - общий монотонный счётчик изменений.
+-- Новые таблицы должны получать те же столбцы, триггер и попадать в публикацию tsv_cdc.
+CREATE SEQUENCE "cdc_change_seq";
+
+CREATE FUNCTION "cdc_touch"() RETURNS trigger AS $$
+BEGIN
+  NEW."updated_at" := now();
+  NEW."change_seq" := nextval('cdc_change_seq');
+  RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+ALTER TABLE "device_data" ADD COLUMN "updated_at" timestamptz DEFAULT (now());
+
+ALTER TABLE "processing_errors" ADD COLUMN "updated_at" timestamptz DEFAULT (now());
+
+ALTER TABLE "reports" ADD COLUMN "updated_at" timestamptz DEFAULT (now());
+
+ALTER TABLE "api_logs" ADD COLUMN "updated_at" timestamptz DEFAULT (now());
+
+ALTER TABLE "idempotency_keys" ADD COLUMN "updated_at" timestamptz DEFAULT (now());
+
+ALTER TABLE "archived_partitions" ADD COLUMN "updated_at" timestamptz DEFAULT (now());
+
+ALTER TABLE "clickhouse_sync" ADD COLUMN "updated_at" timestamptz DEFAULT (now());
+
+ALTER TABLE "files" ADD COLUMN "change_seq" bigint NOT NULL DEFAULT nextval('cdc_change_seq');
+
+ALTER TABLE "device_data" ADD COLUMN "change_seq" bigint NOT NULL DEFAULT nextval('cdc_change_seq');
+
+ALTER TABLE "processing_errors" ADD COLUMN "change_seq" bigint NOT NULL DEFAULT nextval('cdc_change_seq');
+
+ALTER TABLE "reports" ADD COLUMN "change_seq" bigint NOT NULL DEFAULT nextval('cdc_change_seq');
+
+ALTER TABLE "api_logs" ADD COLUMN "change_seq" bigint NOT NULL DEFAULT nextval('cdc_change_seq');
+
+ALTER TABLE "idempotency_keys" ADD COLUMN "change_seq" bigint NOT NULL DEFAULT nextval('cdc_change_seq');
+
+ALTER TABLE "unit_daily_summary" ADD COLUMN "change_seq" bigint NOT NULL DEFAULT nextval('cdc_change_seq');
+
+ALTER TABLE "archived_partitions" ADD COLUMN "change_seq" bigint NOT NULL DEFAULT nextval('cdc_change_seq');
+
+ALTER TABLE "clickhouse_sync" ADD COLUMN "change_seq" bigint NOT NULL DEFAULT nextval('cdc_change_seq');
+
+CREATE INDEX ON "files" ("change_seq");
+
+CREATE INDEX ON "device_data" ("change_seq");
+
+CREATE INDEX ON "processing_errors" ("change_seq");
+
+CREATE INDEX ON "reports" ("change_seq");
+
+CREATE INDEX ON "api_logs" ("change_seq");
+
+CREATE INDEX ON "idempotency_keys" ("change_seq");
+
+CREATE INDEX ON "unit_daily_summary" ("change_seq");
+
+CREATE INDEX ON "archived_partitions" ("change_seq");
+
+CREATE INDEX ON "clickhouse_sync" ("change_seq");
+
+CREATE TRIGGER "files_cdc_touch" BEFORE INSERT OR UPDATE ON "files"
+  FOR EACH ROW EXECUTE FUNCTION "cdc_touch"();
+
+CREATE TRIGGER "device_data_cdc_touch" BEFORE INSERT OR UPDATE ON "device_data"
+  FOR EACH ROW EXECUTE FUNCTION "cdc_touch"();
+
+CREATE TRIGGER "processing_errors_cdc_touch" BEFORE INSERT OR UPDATE ON "processing_errors"
+  FOR EACH ROW EXECUTE FUNCTION "cdc_touch"();
+
+CREATE TRIGGER "reports_cdc_touch" BEFORE INSERT OR UPDATE ON "reports"
+  FOR EACH ROW EXECUTE FUNCTION "cdc_touch"();
+
+CREATE TRIGGER "api_logs_cdc_touch" BEFORE INSERT OR UPDATE ON "api_logs"
+  FOR EACH ROW EXECUTE FUNCTION "cdc_touch"();
+
+CREATE TRIGGER "idempotency_keys_cdc_touch" BEFORE INSERT OR UPDATE ON "idempotency_keys"
+  FOR EACH ROW EXECUTE FUNCTION "cdc_touch"();
+
+CREATE TRIGGER "unit_daily_summary_cdc_touch" BEFORE INSERT OR UPDATE ON "unit_daily_summary"
+  FOR EACH ROW EXECUTE FUNCTION "cdc_touch"();
+
+CREATE TRIGGER "archived_partitions_cdc_touch" BEFORE INSERT OR UPDATE ON "archived_partitions"
+  FOR EACH ROW EXECUTE FUNCTION "cdc_touch"();
+
+CREATE TRIGGER "clickhouse_sync_cdc_touch" BEFORE INSERT OR UPDATE ON "clickhouse_sync"
+  FOR EACH ROW EXECUTE FUNCTION "cdc_touch"();
+
+CREATE PUBLICATION "tsv_cdc" FOR TABLE
+  "files",
+  "device_data",
+  "processing_errors",
+  "reports",
+  "api_logs",
+  "idempotency_keys",
+  "unit_daily_summary",
+  "archived_partitions",
+  "clickhouse_sync";
\ No newline at end of file
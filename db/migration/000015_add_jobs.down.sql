@@ -0,0 +1,3 @@
+ALTER PUBLICATION "tsv_cdc" DROP TABLE "jobs";
+
+DROP TABLE IF EXISTS "jobs";
\ No newline at end of file
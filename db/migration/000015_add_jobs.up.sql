@@ -0,0 +1,27 @@
+CREATE TABLE "jobs" (
+  "id" bigserial PRIMARY KEY,
+  "kind" varchar NOT NULL,
+  "subject" varchar NOT NULL DEFAULT '',
+  "state" varchar NOT NULL DEFAULT 'queued',
+  "priority" integer NOT NULL DEFAULT 0,
+  "owner" varchar NOT NULL DEFAULT '',
+  "attempts" integer NOT NULL DEFAULT 0,
+  "max_attempts" integer NOT NULL DEFAULT 1,
+  "last_error" text,
+  "created_at" timestamptz NOT NULL DEFAULT (now()),
+  "started_at" timestamptz,
+  "finished_at" timestamptz,
+  "updated_at" timestamptz DEFAULT (now()),
+  "change_seq" bigint NOT NULL DEFAULT nextval('cdc_change_seq')
+);
+
+CREATE INDEX ON "jobs" ("kind", "created_at");
+
+CREATE INDEX ON "jobs" ("state", "priority" DESC, "created_at");
+
+CREATE INDEX ON "jobs" ("change_seq");
+
+CREATE TRIGGER "jobs_cdc_touch" BEFORE INSERT OR UPDATE ON "jobs"
+  FOR EACH ROW EXECUTE FUNCTION "cdc_touch"();
+
+ALTER PUBLICATION "tsv_cdc" ADD TABLE "jobs";
\ No newline at end of file
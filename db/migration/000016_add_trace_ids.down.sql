@@ -0,0 +1,7 @@
+ALTER TABLE "reports" DROP COLUMN IF EXISTS "span_id";
+
+ALTER TABLE "reports" DROP COLUMN IF EXISTS "trace_id";
+
+ALTER TABLE "files" DROP COLUMN IF EXISTS "span_id";
+
+ALTER TABLE "files" DROP COLUMN IF EXISTS "trace_id";
\ No newline at end of file
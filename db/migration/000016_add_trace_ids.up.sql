@@ -0,0 +1,10 @@
+-- Трасса (W3C Trace Context), в которой обработан файл и сгенерирован отчёт
+ALTER TABLE "files" ADD COLUMN "trace_id" varchar(32);
+
+ALTER TABLE "files" ADD COLUMN "span_id" varchar(16);
+
+ALTER TABLE "reports" ADD COLUMN "trace_id" varchar(32);
+
+ALTER TABLE "reports" ADD COLUMN "span_id" varchar(16);
+
+CREATE INDEX ON "files" ("trace_id");
\ No newline at end of file
@@ -0,0 +1,3 @@
+ALTER PUBLICATION "tsv_cdc" DROP TABLE "file_events";
+
+DROP TABLE IF EXISTS "file_events";
\ No newline at end of file
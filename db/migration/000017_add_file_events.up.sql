@@ -0,0 +1,18 @@
+CREATE TABLE "file_events" (
+  "id" bigserial PRIMARY KEY,
+  "filename" varchar NOT NULL,
+  "event" varchar NOT NULL,
+  "detail" text NOT NULL DEFAULT '',
+  "occurred_at" timestamptz NOT NULL,
+  "updated_at" timestamptz DEFAULT (now()),
+  "change_seq" bigint NOT NULL DEFAULT nextval('cdc_change_seq')
+);
+
+CREATE INDEX ON "file_events" ("filename", "occurred_at");
+
+CREATE INDEX ON "file_events" ("change_seq");
+
+CREATE TRIGGER "file_events_cdc_touch" BEFORE INSERT OR UPDATE ON "file_events"
+  FOR EACH ROW EXECUTE FUNCTION "cdc_touch"();
+
+ALTER PUBLICATION "tsv_cdc" ADD TABLE "file_events";
\ No newline at end of file
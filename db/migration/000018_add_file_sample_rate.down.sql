@@ -0,0 +1 @@
+ALTER TABLE "files" DROP COLUMN IF EXISTS "sample_rate";
\ No newline at end of file
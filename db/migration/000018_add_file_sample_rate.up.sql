@@ -0,0 +1,2 @@
+-- Доля строк данных, обработанных в режиме выборки (NULL - файл загружен полностью)
+ALTER TABLE "files" ADD COLUMN "sample_rate" double precision;
\ No newline at end of file
@@ -0,0 +1,5 @@
+ALTER TABLE "files" DROP COLUMN IF EXISTS "correction_note";
+
+ALTER TABLE "files" DROP COLUMN IF EXISTS "rows_corrected";
+
+ALTER TABLE "files" DROP COLUMN IF EXISTS "corrects_file_id";
\ No newline at end of file
@@ -0,0 +1,11 @@
+-- Возвращённый исправленный <name>.rejected.tsv: ссылка на исходный файл
+ALTER TABLE "files" ADD COLUMN "corrects_file_id" bigint;
+
+ALTER TABLE "files" ADD FOREIGN KEY ("corrects_file_id") REFERENCES "files" ("id");
+
+CREATE INDEX ON "files" ("corrects_file_id");
+
+-- Исходный файл: строк, исправленных повторной отправкой, и пометка об исправлении
+ALTER TABLE "files" ADD COLUMN "rows_corrected" integer DEFAULT 0;
+
+ALTER TABLE "files" ADD COLUMN "correction_note" text;
\ No newline at end of file
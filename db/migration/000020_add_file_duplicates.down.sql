@@ -0,0 +1,7 @@
+ALTER TABLE "files" DROP COLUMN IF EXISTS "rows_duplicate_in_file";
+
+ALTER TABLE "files" DROP COLUMN IF EXISTS "rows_duplicate_existing";
+
+ALTER PUBLICATION "tsv_cdc" DROP TABLE "file_duplicates";
+
+DROP TABLE IF EXISTS "file_duplicates";
\ No newline at end of file
@@ -0,0 +1,31 @@
+-- Дубликаты строк файла: kind = existing - такая же строка уже есть в device_data
+-- (duplicate_of_* - её файл и строка), in_file - повтор строки того же файла
+CREATE TABLE "file_duplicates" (
+  "id" bigserial PRIMARY KEY,
+  "file_id" bigint NOT NULL,
+  "line_number" integer NOT NULL,
+  "kind" varchar NOT NULL,
+  "unit_guid" uuid NOT NULL,
+  "msg_id" varchar,
+  "duplicate_of_file_id" bigint NOT NULL,
+  "duplicate_of_line" integer NOT NULL,
+  "created_at" timestamptz DEFAULT (now()),
+  "updated_at" timestamptz DEFAULT (now()),
+  "change_seq" bigint NOT NULL DEFAULT nextval('cdc_change_seq')
+);
+
+CREATE INDEX ON "file_duplicates" ("file_id", "line_number");
+
+CREATE INDEX ON "file_duplicates" ("change_seq");
+
+ALTER TABLE "file_duplicates" ADD FOREIGN KEY ("file_id") REFERENCES "files" ("id");
+
+CREATE TRIGGER "file_duplicates_cdc_touch" BEFORE INSERT OR UPDATE ON "file_duplicates"
+  FOR EACH ROW EXECUTE FUNCTION "cdc_touch"();
+
+ALTER PUBLICATION "tsv_cdc" ADD TABLE "file_duplicates";
+
+-- Итог поиска дубликатов (NULL - файл обработан без поиска дубликатов)
+ALTER TABLE "files" ADD COLUMN "rows_duplicate_existing" integer;
+
+ALTER TABLE "files" ADD COLUMN "rows_duplicate_in_file" integer;
\ No newline at end of file
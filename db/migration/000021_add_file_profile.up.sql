@@ -0,0 +1,2 @@
+-- Профиль значений полей, посчитанный при разборе ({} - не считался)
+ALTER TABLE "files" ADD COLUMN "profile" jsonb NOT NULL DEFAULT '{}';
\ No newline at end of file
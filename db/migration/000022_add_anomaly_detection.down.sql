@@ -0,0 +1,5 @@
+ALTER TABLE "files" DROP COLUMN IF EXISTS "anomalies";
+
+ALTER PUBLICATION "tsv_cdc" DROP TABLE "file_ingest_stats";
+
+DROP TABLE IF EXISTS "file_ingest_stats";
\ No newline at end of file
@@ -0,0 +1,28 @@
+-- Показатели обработанных файлов - история для базовых значений детектора аномалий
+CREATE TABLE "file_ingest_stats" (
+  "file_id" bigint PRIMARY KEY,
+  "source" varchar NOT NULL,
+  "unit_guid" uuid,
+  "rows" integer NOT NULL,
+  "error_rate" double precision NOT NULL,
+  "class_shares" jsonb NOT NULL DEFAULT '{}',
+  "created_at" timestamptz DEFAULT (now()),
+  "updated_at" timestamptz DEFAULT (now()),
+  "change_seq" bigint NOT NULL DEFAULT nextval('cdc_change_seq')
+);
+
+CREATE INDEX ON "file_ingest_stats" ("source", "file_id");
+
+CREATE INDEX ON "file_ingest_stats" ("unit_guid", "file_id");
+
+CREATE INDEX ON "file_ingest_stats" ("change_seq");
+
+ALTER TABLE "file_ingest_stats" ADD FOREIGN KEY ("file_id") REFERENCES "files" ("id");
+
+CREATE TRIGGER "file_ingest_stats_cdc_touch" BEFORE INSERT OR UPDATE ON "file_ingest_stats"
+  FOR EACH ROW EXECUTE FUNCTION "cdc_touch"();
+
+ALTER PUBLICATION "tsv_cdc" ADD TABLE "file_ingest_stats";
+
+-- Отклонения файла от базовых значений источника и устройства ([] - не найдено)
+ALTER TABLE "files" ADD COLUMN "anomalies" jsonb NOT NULL DEFAULT '[]';
\ No newline at end of file
@@ -0,0 +1,7 @@
+ALTER PUBLICATION "tsv_cdc" DROP TABLE "report_deliveries";
+
+DROP TABLE IF EXISTS "report_deliveries";
+
+ALTER PUBLICATION "tsv_cdc" DROP TABLE "report_subscriptions";
+
+DROP TABLE IF EXISTS "report_subscriptions";
\ No newline at end of file
@@ -0,0 +1,48 @@
+-- Подписки внешних систем на новые отчёты (unit_guid NULL - все устройства)
+CREATE TABLE "report_subscriptions" (
+  "id" bigserial PRIMARY KEY,
+  "unit_guid" uuid,
+  "callback_url" varchar NOT NULL,
+  "secret" varchar NOT NULL,
+  "active" boolean NOT NULL DEFAULT true,
+  "created_at" timestamptz DEFAULT (now()),
+  "updated_at" timestamptz DEFAULT (now()),
+  "change_seq" bigint NOT NULL DEFAULT nextval('cdc_change_seq')
+);
+
+CREATE INDEX ON "report_subscriptions" ("unit_guid");
+
+CREATE INDEX ON "report_subscriptions" ("change_seq");
+
+CREATE TRIGGER "report_subscriptions_cdc_touch" BEFORE INSERT OR UPDATE ON "report_subscriptions"
+  FOR EACH ROW EXECUTE FUNCTION "cdc_touch"();
+
+ALTER PUBLICATION "tsv_cdc" ADD TABLE "report_subscriptions";
+
+-- Доставки уведомлений о новых отчётах: статус, попытки и время следующей попытки
+CREATE TABLE "report_deliveries" (
+  "id" bigserial PRIMARY KEY,
+  "subscription_id" bigint NOT NULL REFERENCES "report_subscriptions" ("id") ON DELETE CASCADE,
+  "report_id" bigint NOT NULL REFERENCES "reports" ("id") ON DELETE CASCADE,
+  "status" varchar NOT NULL DEFAULT 'pending',
+  "attempts" integer NOT NULL DEFAULT 0,
+  "last_error" text,
+  "response_status" integer,
+  "next_attempt_at" timestamptz NOT NULL DEFAULT (now()),
+  "delivered_at" timestamptz,
+  "created_at" timestamptz DEFAULT (now()),
+  "updated_at" timestamptz DEFAULT (now()),
+  "change_seq" bigint NOT NULL DEFAULT nextval('cdc_change_seq'),
+  UNIQUE ("subscription_id", "report_id")
+);
+
+CREATE INDEX ON "report_deliveries" ("next_attempt_at") WHERE "status" = 'pending';
+
+CREATE INDEX ON "report_deliveries" ("subscription_id", "id");
+
+CREATE INDEX ON "report_deliveries" ("change_seq");
+
+CREATE TRIGGER "report_deliveries_cdc_touch" BEFORE INSERT OR UPDATE ON "report_deliveries"
+  FOR EACH ROW EXECUTE FUNCTION "cdc_touch"();
+
+ALTER PUBLICATION "tsv_cdc" ADD TABLE "report_deliveries";
\ No newline at end of file
@@ -0,0 +1,5 @@
+ALTER PUBLICATION "tsv_cdc" DROP TABLE "audit_log";
+
+DROP TABLE IF EXISTS "audit_log";
+
+DROP FUNCTION IF EXISTS "audit_log_append_only"();
\ No newline at end of file
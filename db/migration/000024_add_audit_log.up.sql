@@ -0,0 +1,38 @@
+-- Журнал событий загрузки для аудита: только добавление, записи связаны
+-- цепочкой хешей (hash записи включает hash предыдущей)
+CREATE TABLE "audit_log" (
+  "id" bigserial PRIMARY KEY,
+  "event" varchar NOT NULL,
+  "subject" varchar NOT NULL,
+  "actor" varchar NOT NULL,
+  -- текст, а не jsonb: хешируются исходные байты, jsonb их нормализует
+  "payload" text NOT NULL,
+  -- UNIQUE: у записи не больше одного продолжения, цепочка не ветвится
+  "prev_hash" varchar NOT NULL UNIQUE,
+  "hash" varchar NOT NULL UNIQUE,
+  "created_at" timestamptz NOT NULL,
+  "updated_at" timestamptz DEFAULT (now()),
+  "change_seq" bigint NOT NULL DEFAULT nextval('cdc_change_seq')
+);
+
+CREATE INDEX ON "audit_log" ("subject", "id");
+
+CREATE INDEX ON "audit_log" ("change_seq");
+
+CREATE TRIGGER "audit_log_cdc_touch" BEFORE INSERT OR UPDATE ON "audit_log"
+  FOR EACH ROW EXECUTE FUNCTION "cdc_touch"();
+
+-- Изменение и удаление записей запрещены и для владельца таблицы
+CREATE FUNCTION "audit_log_append_only"() RETURNS trigger AS $$
+BEGIN
+  RAISE EXCEPTION 'audit_log is append-only';
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER "audit_log_append_only" BEFORE UPDATE OR DELETE ON "audit_log"
+  FOR EACH ROW EXECUTE FUNCTION "audit_log_append_only"();
+
+CREATE TRIGGER "audit_log_no_truncate" BEFORE TRUNCATE ON "audit_log"
+  FOR EACH STATEMENT EXECUTE FUNCTION "audit_log_append_only"();
+
+ALTER PUBLICATION "tsv_cdc" ADD TABLE "audit_log";
\ No newline at end of file
@@ -0,0 +1,5 @@
+ALTER TABLE "files" DROP COLUMN IF EXISTS "seq_gaps";
+
+ALTER TABLE "files" DROP COLUMN IF EXISTS "seq_last";
+
+ALTER TABLE "files" DROP COLUMN IF EXISTS "seq_first";
\ No newline at end of file
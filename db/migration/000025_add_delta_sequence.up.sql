@@ -0,0 +1,10 @@
+-- Диапазон последовательности n, покрытый файлом (источники в режиме delta;
+-- NULL - файл загружен без проверки последовательности)
+ALTER TABLE "files" ADD COLUMN "seq_first" bigint;
+
+ALTER TABLE "files" ADD COLUMN "seq_last" bigint;
+
+-- Пропущено номеров n: внутри файла и между файлом и предыдущим файлом источника
+ALTER TABLE "files" ADD COLUMN "seq_gaps" integer;
+
+CREATE INDEX ON "files" ("source", "id") WHERE "seq_last" IS NOT NULL;
\ No newline at end of file
@@ -0,0 +1,3 @@
+ALTER PUBLICATION "tsv_cdc" DROP TABLE "unit_sequence_runs";
+
+DROP TABLE IF EXISTS "unit_sequence_runs";
\ No newline at end of file
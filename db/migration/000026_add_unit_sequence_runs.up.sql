@@ -0,0 +1,27 @@
+-- Непрерывные отрезки номеров n строк устройства в файле: по ним
+-- ищутся пропуски и нарушения порядка последовательности устройства
+-- между файлами (строки device_data номер n не хранят)
+CREATE TABLE "unit_sequence_runs" (
+  "id" bigserial PRIMARY KEY,
+  "file_id" bigint NOT NULL,
+  "unit_guid" uuid NOT NULL,
+  "seq_first" bigint NOT NULL,
+  "seq_last" bigint NOT NULL,
+  "rows" integer NOT NULL,
+  "created_at" timestamptz NOT NULL DEFAULT (now()),
+  "updated_at" timestamptz DEFAULT (now()),
+  "change_seq" bigint NOT NULL DEFAULT nextval('cdc_change_seq')
+);
+
+ALTER TABLE "unit_sequence_runs" ADD FOREIGN KEY ("file_id") REFERENCES "files" ("id");
+
+CREATE INDEX ON "unit_sequence_runs" ("unit_guid", "created_at");
+
+CREATE INDEX ON "unit_sequence_runs" ("file_id");
+
+CREATE INDEX ON "unit_sequence_runs" ("change_seq");
+
+CREATE TRIGGER "unit_sequence_runs_cdc_touch" BEFORE INSERT OR UPDATE ON "unit_sequence_runs"
+  FOR EACH ROW EXECUTE FUNCTION "cdc_touch"();
+
+ALTER PUBLICATION "tsv_cdc" ADD TABLE "unit_sequence_runs";
\ No newline at end of file
@@ -0,0 +1,3 @@
+ALTER PUBLICATION "tsv_cdc" DROP TABLE "reexport_requests";
+
+DROP TABLE IF EXISTS "reexport_requests";
\ No newline at end of file
@@ -0,0 +1,33 @@
+-- Запросы повторной выгрузки пропущенных номеров n устройства у партнёра
+-- (webhook sequence_check.reexport): статус, попытки и файл, закрывший пропуск
+CREATE TABLE "reexport_requests" (
+  "id" bigserial PRIMARY KEY,
+  "unit_guid" uuid NOT NULL,
+  "seq_from" bigint NOT NULL,
+  "seq_to" bigint NOT NULL,
+  "after_file" varchar NOT NULL,
+  "before_file" varchar NOT NULL,
+  "status" varchar NOT NULL DEFAULT 'pending',
+  "attempts" integer NOT NULL DEFAULT 0,
+  "last_error" text,
+  "response_status" integer,
+  "next_attempt_at" timestamptz NOT NULL DEFAULT (now()),
+  "requested_at" timestamptz,
+  "fulfilled_at" timestamptz,
+  "fulfilled_file_id" bigint REFERENCES "files" ("id"),
+  "created_at" timestamptz NOT NULL DEFAULT (now()),
+  "updated_at" timestamptz DEFAULT (now()),
+  "change_seq" bigint NOT NULL DEFAULT nextval('cdc_change_seq'),
+  UNIQUE ("unit_guid", "seq_from", "seq_to")
+);
+
+CREATE INDEX ON "reexport_requests" ("next_attempt_at") WHERE "status" = 'pending';
+
+CREATE INDEX ON "reexport_requests" ("status", "id");
+
+CREATE INDEX ON "reexport_requests" ("change_seq");
+
+CREATE TRIGGER "reexport_requests_cdc_touch" BEFORE INSERT OR UPDATE ON "reexport_requests"
+  FOR EACH ROW EXECUTE FUNCTION "cdc_touch"();
+
+ALTER PUBLICATION "tsv_cdc" ADD TABLE "reexport_requests";
\ No newline at end of file
@@ -0,0 +1,2 @@
+-- Метаданные из имени файла (именованные группы filename_metadata.pattern)
+ALTER TABLE "files" ADD COLUMN "metadata" jsonb NOT NULL DEFAULT '{}';
\ No newline at end of file
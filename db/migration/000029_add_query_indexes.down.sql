@@ -0,0 +1,9 @@
+DROP INDEX IF EXISTS "processing_errors_file_id_line_number_idx";
+
+DROP INDEX IF EXISTS "device_data_text_trgm_idx";
+
+DROP INDEX IF EXISTS "device_data_file_id_class_line_number_idx";
+
+DROP INDEX IF EXISTS "device_data_file_id_line_number_idx";
+
+DROP INDEX IF EXISTS "device_data_unit_guid_created_at_id_idx";
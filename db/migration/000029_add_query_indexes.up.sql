@@ -0,0 +1,17 @@
+-- Индексы под фильтры запросов API, отчётов и обработки. Имена заданы
+-- явно: при запуске сервис проверяет их наличие (database.RequiredIndexes)
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+
+-- Отчёт и данные устройства: unit_guid, новые записи первыми, курсор по id
+CREATE INDEX IF NOT EXISTS "device_data_unit_guid_created_at_id_idx" ON "device_data" ("unit_guid", "created_at" DESC, "id" DESC);
+
+-- Строки файла по порядку (в том числе с фильтром по class)
+CREATE INDEX IF NOT EXISTS "device_data_file_id_line_number_idx" ON "device_data" ("file_id", "line_number");
+
+CREATE INDEX IF NOT EXISTS "device_data_file_id_class_line_number_idx" ON "device_data" ("file_id", "class", "line_number");
+
+-- Поиск по тексту сообщения (ILIKE '%...%')
+CREATE INDEX IF NOT EXISTS "device_data_text_trgm_idx" ON "device_data" USING GIN ("text" gin_trgm_ops);
+
+-- Ошибки файла по порядку строк
+CREATE INDEX IF NOT EXISTS "processing_errors_file_id_line_number_idx" ON "processing_errors" ("file_id", "line_number");
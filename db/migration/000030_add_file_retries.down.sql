@@ -0,0 +1,3 @@
+ALTER PUBLICATION "tsv_cdc" DROP TABLE "file_retries";
+
+DROP TABLE IF EXISTS "file_retries";
@@ -0,0 +1,20 @@
+-- Повторная обработка файлов после временных ошибок (БД недоступна,
+-- таймаут блокировки): число попыток и время следующей попытки по имени файла
+CREATE TABLE "file_retries" (
+  "filename" varchar PRIMARY KEY,
+  "attempts" integer NOT NULL DEFAULT 0,
+  "next_retry_at" timestamptz NOT NULL,
+  "last_error" text,
+  "created_at" timestamptz NOT NULL DEFAULT (now()),
+  "updated_at" timestamptz DEFAULT (now()),
+  "change_seq" bigint NOT NULL DEFAULT nextval('cdc_change_seq')
+);
+
+CREATE INDEX ON "file_retries" ("next_retry_at");
+
+CREATE INDEX ON "file_retries" ("change_seq");
+
+CREATE TRIGGER "file_retries_cdc_touch" BEFORE INSERT OR UPDATE ON "file_retries"
+  FOR EACH ROW EXECUTE FUNCTION "cdc_touch"();
+
+ALTER PUBLICATION "tsv_cdc" ADD TABLE "file_retries";
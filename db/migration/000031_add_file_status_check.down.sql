@@ -0,0 +1 @@
+ALTER TABLE "files" DROP CONSTRAINT IF EXISTS "files_status_check";
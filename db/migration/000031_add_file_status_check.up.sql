@@ -0,0 +1,10 @@
+-- Статусы, которых нет в filestatus.All (записанные вручную или старыми
+-- версиями), переводятся в failed: такие файлы не считаются обработанными
+UPDATE "files"
+SET "status" = 'failed',
+    "error_message" = COALESCE("error_message", '[check] unknown status ' || "status"),
+    "updated_at" = CURRENT_TIMESTAMP
+WHERE "status" NOT IN ('pending', 'processing', 'completed', 'partial', 'failed', 'cancelled', 'retrying');
+
+ALTER TABLE "files" ADD CONSTRAINT "files_status_check"
+  CHECK ("status" IN ('pending', 'processing', 'completed', 'partial', 'failed', 'cancelled', 'retrying'));
@@ -0,0 +1,3 @@
+ALTER PUBLICATION "tsv_cdc" DROP TABLE "feature_flags";
+
+DROP TABLE IF EXISTS "feature_flags";
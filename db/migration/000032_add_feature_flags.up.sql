@@ -0,0 +1,19 @@
+-- Переопределения флагов рискованных возможностей (internal/features):
+-- включение и выключение без перезапуска, для всех (tenant '') или для tenant
+CREATE TABLE "feature_flags" (
+  "name" varchar NOT NULL,
+  "tenant" varchar NOT NULL DEFAULT '',
+  "enabled" boolean NOT NULL,
+  "updated_by" varchar,
+  "created_at" timestamptz NOT NULL DEFAULT (now()),
+  "updated_at" timestamptz DEFAULT (now()),
+  "change_seq" bigint NOT NULL DEFAULT nextval('cdc_change_seq'),
+  PRIMARY KEY ("name", "tenant")
+);
+
+CREATE INDEX ON "feature_flags" ("change_seq");
+
+CREATE TRIGGER "feature_flags_cdc_touch" BEFORE INSERT OR UPDATE ON "feature_flags"
+  FOR EACH ROW EXECUTE FUNCTION "cdc_touch"();
+
+ALTER PUBLICATION "tsv_cdc" ADD TABLE "feature_flags";
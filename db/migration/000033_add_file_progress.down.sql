@@ -0,0 +1,3 @@
+ALTER PUBLICATION "tsv_cdc" DROP TABLE "file_progress";
+
+DROP TABLE IF EXISTS "file_progress";
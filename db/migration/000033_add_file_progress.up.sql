@@ -0,0 +1,22 @@
+-- Прогресс обрабатываемых файлов: пишется вне транзакции файла, поэтому
+-- виден до commit (в том числе другим экземплярам). Строка удаляется по
+-- окончании обработки, итоговые счётчики остаются в files
+CREATE TABLE "file_progress" (
+  "filename" varchar PRIMARY KEY,
+  "file_id" bigint NOT NULL,
+  "stage" varchar NOT NULL,
+  "rows_total" integer NOT NULL DEFAULT 0,
+  "rows_processed" integer NOT NULL DEFAULT 0,
+  "rows_failed" integer NOT NULL DEFAULT 0,
+  "started_at" timestamptz NOT NULL,
+  "created_at" timestamptz NOT NULL DEFAULT (now()),
+  "updated_at" timestamptz DEFAULT (now()),
+  "change_seq" bigint NOT NULL DEFAULT nextval('cdc_change_seq')
+);
+
+CREATE INDEX ON "file_progress" ("change_seq");
+
+CREATE TRIGGER "file_progress_cdc_touch" BEFORE INSERT OR UPDATE ON "file_progress"
+  FOR EACH ROW EXECUTE FUNCTION "cdc_touch"();
+
+ALTER PUBLICATION "tsv_cdc" ADD TABLE "file_progress";
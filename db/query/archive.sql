@@ -0,0 +1,39 @@
+-- name: ListUnitsWithDataBefore :many
+SELECT DISTINCT unit_guid FROM device_data
+WHERE created_at < $1
+ORDER BY unit_guid;
+
+-- name: GetOldestDeviceDataTime :one
+SELECT created_at FROM device_data
+WHERE unit_guid = $1 AND created_at < $2
+ORDER BY created_at
+LIMIT 1;
+
+-- name: ListDeviceDataForArchive :many
+SELECT * FROM device_data
+WHERE unit_guid = $1 AND created_at >= $2 AND created_at < $3 AND id > $4
+ORDER BY id
+LIMIT $5;
+
+-- name: DeleteArchivedDeviceData :execrows
+DELETE FROM device_data
+WHERE unit_guid = $1 AND created_at >= $2 AND created_at < $3 AND id >= $4 AND id <= $5;
+
+-- name: CreateArchivedPartition :one
+INSERT INTO archived_partitions (
+    unit_guid,
+    month,
+    object_key,
+    row_count,
+    size_bytes,
+    checksum,
+    min_id,
+    max_id
+) VALUES (
+    $1, $2, $3, $4, $5, $6, $7, $8
+) RETURNING *;
+
+-- name: ListArchivedPartitionsByUnit :many
+SELECT * FROM archived_partitions
+WHERE unit_guid = $1 AND month >= $2 AND month < $3
+ORDER BY month, min_id;
\ No newline at end of file
@@ -0,0 +1,26 @@
+-- name: AppendAuditEntry :one
+INSERT INTO audit_log (
+    event,
+    subject,
+    actor,
+    payload,
+    prev_hash,
+    hash,
+    created_at
+) VALUES (
+    $1, $2, $3, $4, $5, $6, $7
+) RETURNING *;
+
+-- name: GetLastAuditEntry :one
+SELECT * FROM audit_log
+ORDER BY id DESC
+LIMIT 1;
+
+-- name: ListAuditEntries :many
+SELECT * FROM audit_log
+WHERE id > $1
+ORDER BY id
+LIMIT $2;
+
+-- name: CountAuditEntries :one
+SELECT COUNT(*) FROM audit_log;
\ No newline at end of file
@@ -0,0 +1,44 @@
+-- name: EnqueueClickhouseSync :exec
+INSERT INTO clickhouse_sync (file_id) VALUES ($1)
+ON CONFLICT (file_id) DO UPDATE
+SET queued_at = CURRENT_TIMESTAMP, synced_at = NULL, rows_synced = 0, attempts = 0, last_error = NULL;
+
+-- name: EnqueueClickhouseReplay :execrows
+INSERT INTO clickhouse_sync (file_id)
+SELECT id FROM files
+WHERE created_at >= $1 AND created_at < $2
+  AND status IN ('completed', 'partial') AND superseded_by IS NULL
+ON CONFLICT (file_id) DO UPDATE
+SET queued_at = CURRENT_TIMESTAMP, synced_at = NULL, rows_synced = 0, attempts = 0, last_error = NULL;
+
+-- name: ListPendingClickhouseSync :many
+SELECT * FROM clickhouse_sync
+WHERE synced_at IS NULL
+ORDER BY queued_at, file_id
+LIMIT $1;
+
+-- name: CountPendingClickhouseSync :one
+SELECT COUNT(*) FROM clickhouse_sync
+WHERE synced_at IS NULL;
+
+-- name: GetOldestPendingClickhouseSync :one
+SELECT queued_at FROM clickhouse_sync
+WHERE synced_at IS NULL
+ORDER BY queued_at
+LIMIT 1;
+
+-- name: ListDeviceDataForClickhouse :many
+SELECT * FROM device_data
+WHERE file_id = $1 AND id > $2
+ORDER BY id
+LIMIT $3;
+
+-- name: MarkClickhouseSynced :exec
+UPDATE clickhouse_sync
+SET synced_at = CURRENT_TIMESTAMP, rows_synced = $2, last_error = NULL
+WHERE file_id = $1;
+
+-- name: MarkClickhouseSyncFailed :exec
+UPDATE clickhouse_sync
+SET attempts = attempts + 1, last_error = $2
+WHERE file_id = $1;
\ No newline at end of file
@@ -20,6 +20,29 @@ INSERT INTO device_data (
     $1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16
 ) RETURNING *;
 
+-- name: CreateDeviceDataVersion :one
+INSERT INTO device_data (
+    file_id,
+    unit_guid,
+    mqtt,
+    invid,
+    msg_id,
+    text,
+    context,
+    class,
+    level,
+    area,
+    addr,
+    block,
+    type,
+    bit,
+    invert_bit,
+    line_number,
+    version
+) VALUES (
+    $1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17
+) RETURNING *;
+
 -- name: BulkInsertDeviceData :exec
 INSERT INTO device_data (
     file_id,
@@ -55,10 +78,23 @@ ORDER BY line_number;
 -- name: ListDeviceDataByUnit :many
 SELECT * FROM device_data
 WHERE unit_guid = $1
-ORDER BY created_at DESC
+AND file_id NOT IN (SELECT id FROM files WHERE superseded_by IS NOT NULL)
+ORDER BY created_at DESC NULLS FIRST, id DESC
 LIMIT $2
 OFFSET $3;
 
+-- name: ListDeviceDataByUnitBefore :many
+SELECT * FROM device_data
+WHERE unit_guid = $1
+AND file_id NOT IN (SELECT id FROM files WHERE superseded_by IS NOT NULL)
+AND (
+    created_at < $2
+    OR (created_at = $2 AND id < $3)
+    OR ($2 IS NULL AND (created_at IS NOT NULL OR id < $3))
+)
+ORDER BY created_at DESC NULLS FIRST, id DESC
+LIMIT $4;
+
 -- name: ListDeviceDataByClass :many
 SELECT * FROM device_data
 WHERE class = $1 AND file_id = $2
@@ -80,6 +116,74 @@ SELECT
 FROM device_data
 WHERE file_id = $1;
 
+-- name: GetLatestDeviceDataByKey :one
+SELECT * FROM device_data
+WHERE unit_guid = $1 AND msg_id = $2
+ORDER BY version DESC, id DESC
+LIMIT 1;
+
+-- name: GetIdenticalDeviceData :one
+SELECT * FROM device_data
+WHERE unit_guid = $1
+  AND mqtt IS NOT DISTINCT FROM $2
+  AND invid IS NOT DISTINCT FROM $3
+  AND msg_id IS NOT DISTINCT FROM $4
+  AND text IS NOT DISTINCT FROM $5
+  AND class IS NOT DISTINCT FROM $6
+  AND level IS NOT DISTINCT FROM $7
+  AND area IS NOT DISTINCT FROM $8
+  AND addr IS NOT DISTINCT FROM $9
+  AND block IS NOT DISTINCT FROM $10
+  AND type IS NOT DISTINCT FROM $11
+  AND bit IS NOT DISTINCT FROM $12
+  AND invert_bit IS NOT DISTINCT FROM $13
+  AND file_id <> $14
+ORDER BY id
+LIMIT 1;
+
+-- name: GetIdenticalDeviceDataInFile :one
+SELECT line_number FROM device_data
+WHERE unit_guid = $1
+  AND mqtt IS NOT DISTINCT FROM $2
+  AND invid IS NOT DISTINCT FROM $3
+  AND msg_id IS NOT DISTINCT FROM $4
+  AND text IS NOT DISTINCT FROM $5
+  AND class IS NOT DISTINCT FROM $6
+  AND level IS NOT DISTINCT FROM $7
+  AND area IS NOT DISTINCT FROM $8
+  AND addr IS NOT DISTINCT FROM $9
+  AND block IS NOT DISTINCT FROM $10
+  AND type IS NOT DISTINCT FROM $11
+  AND bit IS NOT DISTINCT FROM $12
+  AND invert_bit IS NOT DISTINCT FROM $13
+  AND file_id = $14
+  AND line_number < $15
+ORDER BY line_number
+LIMIT 1;
+
+-- name: OverwriteDeviceData :one
+UPDATE device_data
+SET
+    file_id = $2,
+    unit_guid = $3,
+    mqtt = $4,
+    invid = $5,
+    msg_id = $6,
+    text = $7,
+    context = $8,
+    class = $9,
+    level = $10,
+    area = $11,
+    addr = $12,
+    block = $13,
+    type = $14,
+    bit = $15,
+    invert_bit = $16,
+    line_number = $17,
+    created_at = CURRENT_TIMESTAMP
+WHERE id = $1
+RETURNING *;
+
 -- name: UpdateDeviceData :one
 UPDATE device_data
 SET
@@ -0,0 +1,20 @@
+-- name: ListFeatureFlags :many
+SELECT * FROM feature_flags
+ORDER BY name, tenant;
+
+-- name: UpsertFeatureFlag :one
+INSERT INTO feature_flags (
+    name,
+    tenant,
+    enabled,
+    updated_by
+) VALUES (
+    $1, $2, $3, $4
+)
+ON CONFLICT (name, tenant) DO UPDATE
+SET enabled = EXCLUDED.enabled, updated_by = EXCLUDED.updated_by
+RETURNING *;
+
+-- name: DeleteFeatureFlag :execrows
+DELETE FROM feature_flags
+WHERE name = $1 AND tenant = $2;
@@ -2,9 +2,15 @@
 INSERT INTO files (
     filename, 
     file_hash, 
-    status
+    status,
+    source,
+    file_mtime,
+    arrived_at,
+    trace_id,
+    span_id,
+    sample_rate
 ) VALUES (
-    $1, $2, $3
+    $1, $2, $3, $4, $5, $6, $7, $8, $9
 ) RETURNING *;
 
 -- name: GetFileByID :one
@@ -15,12 +21,30 @@ WHERE id = $1 LIMIT 1;
 SELECT * FROM files
 WHERE filename = $1 LIMIT 1;
 
+-- name: GetFileByHash :one
+SELECT * FROM files
+WHERE file_hash = $1
+  AND status IN ('completed', 'partial')
+ORDER BY id
+LIMIT 1;
+
+-- name: GetLastSequencedFile :one
+SELECT * FROM files
+WHERE source = $1
+  AND seq_last IS NOT NULL
+  AND status IN ('completed', 'partial')
+ORDER BY id DESC
+LIMIT 1;
+
 -- name: ListFiles :many
 SELECT * FROM files
 ORDER BY created_at DESC
 LIMIT $1
 OFFSET $2;
 
+-- name: CountFiles :one
+SELECT COUNT(*) FROM files;
+
 -- name: ListFilesByStatus :many
 SELECT * FROM files
 WHERE status = $1
@@ -39,6 +63,88 @@ SET
 WHERE id = $1
 RETURNING *;
 
+-- name: CompleteFile :one
+UPDATE files
+SET
+    status = $2,
+    completed_at = $3,
+    updated_at = CURRENT_TIMESTAMP
+WHERE id = $1
+RETURNING *;
+
+-- name: MergeFileCorrection :one
+UPDATE files
+SET
+    status = $2,
+    rows_processed = $3,
+    rows_failed = $4,
+    rows_corrected = $5,
+    correction_note = $6,
+    updated_at = CURRENT_TIMESTAMP
+WHERE id = $1
+RETURNING *;
+
+-- name: SetFileCorrects :one
+UPDATE files
+SET
+    corrects_file_id = $2,
+    updated_at = CURRENT_TIMESTAMP
+WHERE id = $1
+RETURNING *;
+
+-- name: SupersedeFile :one
+UPDATE files
+SET
+    superseded_by = $2,
+    superseded_at = CURRENT_TIMESTAMP,
+    updated_at = CURRENT_TIMESTAMP
+WHERE id = $1
+RETURNING *;
+
+-- name: UpdateFileAnomalies :one
+UPDATE files
+SET
+    anomalies = $2,
+    updated_at = CURRENT_TIMESTAMP
+WHERE id = $1
+RETURNING *;
+
+-- name: UpdateFileConflictStats :one
+UPDATE files
+SET
+    conflict_policy = $2,
+    rows_skipped = $3,
+    rows_overwritten = $4,
+    rows_versioned = $5,
+    updated_at = CURRENT_TIMESTAMP
+WHERE id = $1
+RETURNING *;
+
+-- name: UpdateFileDuplicateStats :one
+UPDATE files
+SET
+    rows_duplicate_existing = $2,
+    rows_duplicate_in_file = $3,
+    updated_at = CURRENT_TIMESTAMP
+WHERE id = $1
+RETURNING *;
+
+-- name: UpdateFileMetadata :one
+UPDATE files
+SET
+    metadata = $2,
+    updated_at = CURRENT_TIMESTAMP
+WHERE id = $1
+RETURNING *;
+
+-- name: UpdateFileProfile :one
+UPDATE files
+SET
+    profile = $2,
+    updated_at = CURRENT_TIMESTAMP
+WHERE id = $1
+RETURNING *;
+
 -- name: UpdateFileProgress :one
 UPDATE files
 SET
@@ -48,6 +154,24 @@ SET
 WHERE id = $1
 RETURNING *;
 
+-- name: IncrementFileProgress :exec
+UPDATE files
+SET
+    rows_processed = COALESCE(rows_processed, 0) + sqlc.arg(processed_delta),
+    rows_failed = COALESCE(rows_failed, 0) + sqlc.arg(failed_delta),
+    updated_at = CURRENT_TIMESTAMP
+WHERE id = sqlc.arg(id);
+
+-- name: UpdateFileSequence :one
+UPDATE files
+SET
+    seq_first = $2,
+    seq_last = $3,
+    seq_gaps = $4,
+    updated_at = CURRENT_TIMESTAMP
+WHERE id = $1
+RETURNING *;
+
 -- name: UpdateFileWithError :one
 UPDATE files
 SET
@@ -0,0 +1,23 @@
+-- name: CreateFileDuplicate :exec
+INSERT INTO file_duplicates (
+    file_id,
+    line_number,
+    kind,
+    unit_guid,
+    msg_id,
+    duplicate_of_file_id,
+    duplicate_of_line
+) VALUES (
+    $1, $2, $3, $4, $5, $6, $7
+);
+
+-- name: ListFileDuplicatesByFilePaged :many
+SELECT * FROM file_duplicates
+WHERE file_id = $1 AND kind = COALESCE(NULLIF($2, ''), kind)
+ORDER BY line_number, id
+LIMIT $3
+OFFSET $4;
+
+-- name: CountFileDuplicatesByFile :one
+SELECT COUNT(*) FROM file_duplicates
+WHERE file_id = $1 AND kind = COALESCE(NULLIF($2, ''), kind);
\ No newline at end of file
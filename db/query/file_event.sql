@@ -0,0 +1,18 @@
+-- name: CreateFileEvent :exec
+INSERT INTO file_events (
+    filename,
+    event,
+    detail,
+    occurred_at
+) VALUES (
+    $1, $2, $3, $4
+);
+
+-- name: ListFileEvents :many
+SELECT * FROM file_events
+WHERE filename = $1
+ORDER BY occurred_at, id;
+
+-- name: DeleteOldFileEvents :exec
+DELETE FROM file_events
+WHERE occurred_at < CURRENT_TIMESTAMP - interval '30 days';
\ No newline at end of file
@@ -0,0 +1,23 @@
+-- name: CreateFileIngestStats :exec
+INSERT INTO file_ingest_stats (
+    file_id,
+    source,
+    unit_guid,
+    rows,
+    error_rate,
+    class_shares
+) VALUES (
+    $1, $2, $3, $4, $5, $6
+);
+
+-- name: ListRecentIngestStatsBySource :many
+SELECT * FROM file_ingest_stats
+WHERE source = $1 AND file_id <> $2
+ORDER BY file_id DESC
+LIMIT $3;
+
+-- name: ListRecentIngestStatsByUnit :many
+SELECT * FROM file_ingest_stats
+WHERE unit_guid = $1 AND file_id <> $2
+ORDER BY file_id DESC
+LIMIT $3;
\ No newline at end of file
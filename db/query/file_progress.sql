@@ -0,0 +1,23 @@
+-- name: GetFileProgress :one
+SELECT * FROM file_progress
+WHERE filename = $1 LIMIT 1;
+
+-- name: UpsertFileProgress :exec
+INSERT INTO file_progress (
+    filename,
+    file_id,
+    stage,
+    rows_total,
+    rows_processed,
+    rows_failed,
+    started_at
+) VALUES (
+    $1, $2, $3, $4, $5, $6, $7
+)
+ON CONFLICT (filename) DO UPDATE
+SET file_id = EXCLUDED.file_id, stage = EXCLUDED.stage, rows_total = EXCLUDED.rows_total,
+    rows_processed = EXCLUDED.rows_processed, rows_failed = EXCLUDED.rows_failed, started_at = EXCLUDED.started_at;
+
+-- name: DeleteFileProgress :exec
+DELETE FROM file_progress
+WHERE filename = $1;
@@ -0,0 +1,23 @@
+-- name: GetFileRetry :one
+SELECT * FROM file_retries
+WHERE filename = $1 LIMIT 1;
+
+-- name: ListFileRetries :many
+SELECT * FROM file_retries
+ORDER BY next_retry_at, filename;
+
+-- name: UpsertFileRetry :exec
+INSERT INTO file_retries (
+    filename,
+    attempts,
+    next_retry_at,
+    last_error
+) VALUES (
+    $1, $2, $3, $4
+)
+ON CONFLICT (filename) DO UPDATE
+SET attempts = EXCLUDED.attempts, next_retry_at = EXCLUDED.next_retry_at, last_error = EXCLUDED.last_error;
+
+-- name: DeleteFileRetry :exec
+DELETE FROM file_retries
+WHERE filename = $1;
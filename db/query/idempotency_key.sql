@@ -0,0 +1,37 @@
+-- name: ReserveIdempotencyKey :execrows
+INSERT INTO idempotency_keys (
+    key,
+    endpoint,
+    status_code,
+    response_body,
+    expires_at,
+    pending
+) VALUES (
+    $1, $2, 0, '', $3, true
+) ON CONFLICT (key, endpoint) DO UPDATE SET
+    status_code = 0,
+    response_body = '',
+    expires_at = EXCLUDED.expires_at,
+    pending = true
+WHERE idempotency_keys.expires_at <= CURRENT_TIMESTAMP;
+
+-- name: CompleteIdempotencyKey :exec
+UPDATE idempotency_keys
+SET status_code = $3,
+    response_body = $4,
+    expires_at = $5,
+    pending = false
+WHERE key = $1 AND endpoint = $2 AND pending;
+
+-- name: ReleaseIdempotencyKey :exec
+DELETE FROM idempotency_keys
+WHERE key = $1 AND endpoint = $2 AND pending;
+
+-- name: GetIdempotencyKey :one
+SELECT * FROM idempotency_keys
+WHERE key = $1 AND endpoint = $2 AND expires_at > CURRENT_TIMESTAMP
+LIMIT 1;
+
+-- name: DeleteExpiredIdempotencyKeys :exec
+DELETE FROM idempotency_keys
+WHERE expires_at < CURRENT_TIMESTAMP;
@@ -0,0 +1,38 @@
+-- name: CreateJob :one
+INSERT INTO jobs (
+    kind,
+    subject,
+    priority,
+    owner,
+    max_attempts
+) VALUES (
+    $1, $2, $3, $4, $5
+) RETURNING *;
+
+-- name: GetJob :one
+SELECT * FROM jobs
+WHERE id = $1 LIMIT 1;
+
+-- name: StartJob :exec
+UPDATE jobs
+SET state = 'running', attempts = attempts + 1, owner = $2, started_at = CURRENT_TIMESTAMP
+WHERE id = $1;
+
+-- name: RetryJob :exec
+UPDATE jobs
+SET state = 'queued', last_error = $2
+WHERE id = $1;
+
+-- name: FinishJob :exec
+UPDATE jobs
+SET state = $2, last_error = COALESCE($3, last_error), finished_at = CURRENT_TIMESTAMP
+WHERE id = $1;
+
+-- name: FailInterruptedJobs :execrows
+UPDATE jobs
+SET state = 'failed', last_error = 'interrupted by service restart', finished_at = CURRENT_TIMESTAMP
+WHERE state IN ('queued', 'running');
+
+-- name: DeleteOldJobs :exec
+DELETE FROM jobs
+WHERE finished_at < CURRENT_TIMESTAMP - interval '30 days';
\ No newline at end of file
@@ -4,9 +4,11 @@ INSERT INTO processing_errors (
     line_number,
     raw_line,
     error_message,
-    field_name
+    field_name,
+    unit_guid,
+    partial
 ) VALUES (
-    $1, $2, $3, $4, $5
+    $1, $2, $3, $4, $5, $6, $7
 ) RETURNING *;
 
 -- name: GetProcessingErrorByID :one
@@ -18,6 +20,17 @@ SELECT * FROM processing_errors
 WHERE file_id = $1
 ORDER BY line_number;
 
+-- name: ListProcessingErrorsByFilePaged :many
+SELECT * FROM processing_errors
+WHERE file_id = $1
+ORDER BY line_number
+LIMIT $2
+OFFSET $3;
+
+-- name: CountProcessingErrorsByFile :one
+SELECT COUNT(*) FROM processing_errors
+WHERE file_id = $1;
+
 -- name: ListProcessingErrorsSummary :many
 SELECT
     error_message,
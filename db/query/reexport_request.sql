@@ -0,0 +1,60 @@
+-- name: CreateReexportRequest :execrows
+INSERT INTO reexport_requests (
+    unit_guid,
+    seq_from,
+    seq_to,
+    after_file,
+    before_file,
+    next_attempt_at
+) VALUES (
+    $1, $2, $3, $4, $5, $6
+)
+ON CONFLICT (unit_guid, seq_from, seq_to) DO NOTHING;
+
+-- name: CountOpenReexportRequestsCovering :one
+SELECT COUNT(*) FROM reexport_requests
+WHERE unit_guid = $1 AND seq_from <= $2 AND seq_to >= $3 AND status IN ('pending', 'requested');
+
+-- name: ListDueReexportRequests :many
+SELECT * FROM reexport_requests
+WHERE status = 'pending' AND next_attempt_at <= $1
+ORDER BY next_attempt_at, id
+LIMIT $2;
+
+-- name: ListOpenReexportRequests :many
+SELECT * FROM reexport_requests
+WHERE status IN ('pending', 'requested', 'failed')
+ORDER BY unit_guid, id;
+
+-- name: ListReexportRequests :many
+SELECT * FROM reexport_requests
+WHERE status = COALESCE(NULLIF($1, ''), status)
+ORDER BY id DESC
+LIMIT $2
+OFFSET $3;
+
+-- name: CountReexportRequests :one
+SELECT COUNT(*) FROM reexport_requests
+WHERE status = COALESCE(NULLIF($1, ''), status);
+
+-- name: MarkReexportRequested :exec
+UPDATE reexport_requests
+SET status = 'requested', attempts = attempts + 1, response_status = $2,
+    last_error = NULL, requested_at = CURRENT_TIMESTAMP
+WHERE id = $1;
+
+-- name: MarkReexportFailed :exec
+UPDATE reexport_requests
+SET status = $2, attempts = attempts + 1, response_status = $3,
+    last_error = $4, next_attempt_at = $5
+WHERE id = $1;
+
+-- name: MarkReexportFulfilled :exec
+UPDATE reexport_requests
+SET status = 'fulfilled', fulfilled_file_id = $2, fulfilled_at = CURRENT_TIMESTAMP
+WHERE id = $1;
+
+-- name: ExpireReexportRequests :execrows
+UPDATE reexport_requests
+SET status = 'expired'
+WHERE status IN ('pending', 'requested', 'failed') AND created_at < $1;
\ No newline at end of file
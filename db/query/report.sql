@@ -2,20 +2,47 @@
 INSERT INTO reports (
     unit_guid,
     report_type,
-    file_path
+    file_path,
+    checksum,
+    report_group,
+    part,
+    trace_id,
+    span_id
 ) VALUES (
-    $1, $2, $3
+    $1, $2, $3, $4, $5, $6, $7, $8
 ) RETURNING *;
 
 -- name: GetReportByID :one
 SELECT * FROM reports
 WHERE id = $1 LIMIT 1;
 
+-- name: GetReportByChecksum :one
+SELECT * FROM reports
+WHERE checksum = $1
+ORDER BY generated_at DESC
+LIMIT 1;
+
 -- name: GetReportsByUnit :many
 SELECT * FROM reports
 WHERE unit_guid = $1
 ORDER BY generated_at DESC;
 
+-- name: ListReportsByUnit :many
+SELECT * FROM reports
+WHERE unit_guid = $1
+ORDER BY generated_at DESC
+LIMIT $2
+OFFSET $3;
+
+-- name: CountReportsByUnit :one
+SELECT COUNT(*) FROM reports
+WHERE unit_guid = $1;
+
+-- name: ListReportsByGroup :many
+SELECT * FROM reports
+WHERE report_group = $1
+ORDER BY part;
+
 -- name: ListRecentReports :many
 SELECT * FROM reports
 ORDER BY generated_at DESC
@@ -0,0 +1,60 @@
+-- name: CreateReportSubscription :one
+INSERT INTO report_subscriptions (
+    unit_guid,
+    callback_url,
+    secret
+) VALUES (
+    $1, $2, $3
+) RETURNING *;
+
+-- name: GetReportSubscription :one
+SELECT * FROM report_subscriptions
+WHERE id = $1 LIMIT 1;
+
+-- name: ListReportSubscriptions :many
+SELECT * FROM report_subscriptions
+ORDER BY id;
+
+-- name: DeleteReportSubscription :execrows
+DELETE FROM report_subscriptions
+WHERE id = $1;
+
+-- name: EnqueueReportDeliveries :execrows
+INSERT INTO report_deliveries (subscription_id, report_id, next_attempt_at)
+SELECT id, $1, $2 FROM report_subscriptions
+WHERE active AND (unit_guid IS NULL OR unit_guid = $3)
+ON CONFLICT (subscription_id, report_id) DO NOTHING;
+
+-- name: ListDueReportDeliveries :many
+SELECT d.id, d.subscription_id, d.report_id, d.attempts,
+       s.callback_url, s.secret,
+       r.unit_guid, r.report_type, r.report_group, r.part, r.generated_at
+FROM report_deliveries d
+JOIN report_subscriptions s ON s.id = d.subscription_id
+JOIN reports r ON r.id = d.report_id
+WHERE d.status = 'pending' AND d.next_attempt_at <= $1 AND s.active
+ORDER BY d.next_attempt_at, d.id
+LIMIT $2;
+
+-- name: CountReportDeliveriesBySubscription :one
+SELECT COUNT(*) FROM report_deliveries
+WHERE subscription_id = $1 AND status = COALESCE(NULLIF($2, ''), status);
+
+-- name: ListReportDeliveriesBySubscription :many
+SELECT * FROM report_deliveries
+WHERE subscription_id = $1 AND status = COALESCE(NULLIF($2, ''), status)
+ORDER BY id DESC
+LIMIT $3
+OFFSET $4;
+
+-- name: MarkReportDeliveryDelivered :exec
+UPDATE report_deliveries
+SET status = 'delivered', attempts = attempts + 1, response_status = $2,
+    last_error = NULL, delivered_at = CURRENT_TIMESTAMP
+WHERE id = $1;
+
+-- name: MarkReportDeliveryFailed :exec
+UPDATE report_deliveries
+SET status = $2, attempts = attempts + 1, response_status = $3,
+    last_error = $4, next_attempt_at = $5
+WHERE id = $1;
\ No newline at end of file
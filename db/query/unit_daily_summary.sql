@@ -0,0 +1,24 @@
+-- name: UpsertUnitDailySummary :exec
+INSERT INTO unit_daily_summary (
+    unit_guid,
+    day,
+    records,
+    alarms,
+    max_level
+) VALUES (
+    $1, $2, $3, $4, $5
+)
+ON CONFLICT (unit_guid, day) DO UPDATE SET
+    records = unit_daily_summary.records + excluded.records,
+    alarms = unit_daily_summary.alarms + excluded.alarms,
+    max_level = CASE
+        WHEN unit_daily_summary.max_level IS NULL OR excluded.max_level > unit_daily_summary.max_level
+        THEN excluded.max_level
+        ELSE unit_daily_summary.max_level
+    END,
+    updated_at = CURRENT_TIMESTAMP;
+
+-- name: ListUnitDailySummary :many
+SELECT * FROM unit_daily_summary
+WHERE unit_guid = $1 AND day >= $2 AND day <= $3
+ORDER BY day;
\ No newline at end of file
@@ -0,0 +1,24 @@
+-- name: CreateUnitSequenceRun :exec
+INSERT INTO unit_sequence_runs (
+    file_id,
+    unit_guid,
+    seq_first,
+    seq_last,
+    rows
+) VALUES (
+    $1, $2, $3, $4, $5
+);
+
+-- name: ListUnitSequenceRuns :many
+SELECT r.id, r.file_id, f.filename, r.unit_guid, r.seq_first, r.seq_last, r.rows, r.created_at
+FROM unit_sequence_runs r
+JOIN files f ON f.id = r.file_id
+WHERE r.unit_guid = $1 AND r.created_at >= $2 AND f.superseded_by IS NULL
+ORDER BY r.id;
+
+-- name: ListSequenceRunsSince :many
+SELECT r.id, r.file_id, f.filename, r.unit_guid, r.seq_first, r.seq_last, r.rows, r.created_at
+FROM unit_sequence_runs r
+JOIN files f ON f.id = r.file_id
+WHERE r.created_at >= $1 AND f.superseded_by IS NULL
+ORDER BY r.unit_guid, r.id;
\ No newline at end of file
@@ -30,7 +30,7 @@ INSERT INTO api_logs (
     status_code
 ) VALUES (
     $1, $2, $3, $4
-) RETURNING id, endpoint, unit_guid, response_time_ms, status_code, created_at
+) RETURNING id, endpoint, unit_guid, response_time_ms, status_code, created_at, updated_at, change_seq
 `
 
 type CreateApiLogParams struct {
@@ -55,6 +55,8 @@ func (q *Queries) CreateApiLog(ctx context.Context, arg CreateApiLogParams) (Api
 		&i.ResponseTimeMs,
 		&i.StatusCode,
 		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.ChangeSeq,
 	)
 	return i, err
 }
@@ -70,7 +72,7 @@ func (q *Queries) DeleteApiLog(ctx context.Context, id int64) error {
 }
 
 const getApiLogByID = `-- name: GetApiLogByID :one
-SELECT id, endpoint, unit_guid, response_time_ms, status_code, created_at FROM api_logs
+SELECT id, endpoint, unit_guid, response_time_ms, status_code, created_at, updated_at, change_seq FROM api_logs
 WHERE id = $1 LIMIT 1
 `
 
@@ -84,6 +86,8 @@ func (q *Queries) GetApiLogByID(ctx context.Context, id int64) (ApiLog, error) {
 		&i.ResponseTimeMs,
 		&i.StatusCode,
 		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.ChangeSeq,
 	)
 	return i, err
 }
@@ -136,7 +140,7 @@ func (q *Queries) GetApiStatistics(ctx context.Context) ([]GetApiStatisticsRow,
 }
 
 const listApiErrors = `-- name: ListApiErrors :many
-SELECT id, endpoint, unit_guid, response_time_ms, status_code, created_at FROM api_logs
+SELECT id, endpoint, unit_guid, response_time_ms, status_code, created_at, updated_at, change_seq FROM api_logs
 WHERE status_code >= $1
 ORDER BY created_at DESC
 `
@@ -157,6 +161,8 @@ func (q *Queries) ListApiErrors(ctx context.Context, statusCode sql.NullInt32) (
 			&i.ResponseTimeMs,
 			&i.StatusCode,
 			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.ChangeSeq,
 		); err != nil {
 			return nil, err
 		}
@@ -172,7 +178,7 @@ func (q *Queries) ListApiErrors(ctx context.Context, statusCode sql.NullInt32) (
 }
 
 const listApiLogs = `-- name: ListApiLogs :many
-SELECT id, endpoint, unit_guid, response_time_ms, status_code, created_at FROM api_logs
+SELECT id, endpoint, unit_guid, response_time_ms, status_code, created_at, updated_at, change_seq FROM api_logs
 ORDER BY created_at DESC
 LIMIT $1
 OFFSET $2
@@ -199,6 +205,8 @@ func (q *Queries) ListApiLogs(ctx context.Context, arg ListApiLogsParams) ([]Api
 			&i.ResponseTimeMs,
 			&i.StatusCode,
 			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.ChangeSeq,
 		); err != nil {
 			return nil, err
 		}
@@ -214,7 +222,7 @@ func (q *Queries) ListApiLogs(ctx context.Context, arg ListApiLogsParams) ([]Api
 }
 
 const listApiLogsByEndpoint = `-- name: ListApiLogsByEndpoint :many
-SELECT id, endpoint, unit_guid, response_time_ms, status_code, created_at FROM api_logs
+SELECT id, endpoint, unit_guid, response_time_ms, status_code, created_at, updated_at, change_seq FROM api_logs
 WHERE endpoint = $1
 ORDER BY created_at DESC
 `
@@ -235,6 +243,8 @@ func (q *Queries) ListApiLogsByEndpoint(ctx context.Context, endpoint string) ([
 			&i.ResponseTimeMs,
 			&i.StatusCode,
 			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.ChangeSeq,
 		); err != nil {
 			return nil, err
 		}
@@ -250,7 +260,7 @@ func (q *Queries) ListApiLogsByEndpoint(ctx context.Context, endpoint string) ([
 }
 
 const listApiLogsByUnit = `-- name: ListApiLogsByUnit :many
-SELECT id, endpoint, unit_guid, response_time_ms, status_code, created_at FROM api_logs
+SELECT id, endpoint, unit_guid, response_time_ms, status_code, created_at, updated_at, change_seq FROM api_logs
 WHERE unit_guid = $1
 ORDER BY created_at DESC
 `
@@ -271,6 +281,8 @@ func (q *Queries) ListApiLogsByUnit(ctx context.Context, unitGuid uuid.NullUUID)
 			&i.ResponseTimeMs,
 			&i.StatusCode,
 			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.ChangeSeq,
 		); err != nil {
 			return nil, err
 		}
@@ -286,7 +298,7 @@ func (q *Queries) ListApiLogsByUnit(ctx context.Context, unitGuid uuid.NullUUID)
 }
 
 const listSlowRequests = `-- name: ListSlowRequests :many
-SELECT id, endpoint, unit_guid, response_time_ms, status_code, created_at FROM api_logs
+SELECT id, endpoint, unit_guid, response_time_ms, status_code, created_at, updated_at, change_seq FROM api_logs
 WHERE response_time_ms > $1
 ORDER BY response_time_ms DESC
 `
@@ -307,6 +319,8 @@ func (q *Queries) ListSlowRequests(ctx context.Context, responseTimeMs sql.NullI
 			&i.ResponseTimeMs,
 			&i.StatusCode,
 			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.ChangeSeq,
 		); err != nil {
 			return nil, err
 		}
@@ -326,7 +340,7 @@ UPDATE api_logs
 SET
     response_time_ms = $2
 WHERE id = $1
-RETURNING id, endpoint, unit_guid, response_time_ms, status_code, created_at
+RETURNING id, endpoint, unit_guid, response_time_ms, status_code, created_at, updated_at, change_seq
 `
 
 type UpdateApiLogParams struct {
@@ -344,6 +358,8 @@ func (q *Queries) UpdateApiLog(ctx context.Context, arg UpdateApiLogParams) (Api
 		&i.ResponseTimeMs,
 		&i.StatusCode,
 		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.ChangeSeq,
 	)
 	return i, err
 }
@@ -33,7 +33,7 @@ INSERT INTO device_data (
 ) VALUES 
     ( $1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16 ),
     ( $17, $18, $19, $20, $21, $22, $23, $24, $25, $26, $27, $28, $29, $30, $31, $32 )
-RETURNING id, file_id, unit_guid, mqtt, invid, msg_id, text, context, class, level, area, addr, block, type, bit, invert_bit, line_number, created_at
+RETURNING id, file_id, unit_guid, mqtt, invid, msg_id, text, context, class, level, area, addr, block, type, bit, invert_bit, line_number, created_at, version, updated_at, change_seq
 `
 
 type BulkInsertDeviceDataParams struct {
@@ -129,7 +129,7 @@ INSERT INTO device_data (
     line_number
 ) VALUES (
     $1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16
-) RETURNING id, file_id, unit_guid, mqtt, invid, msg_id, text, context, class, level, area, addr, block, type, bit, invert_bit, line_number, created_at
+) RETURNING id, file_id, unit_guid, mqtt, invid, msg_id, text, context, class, level, area, addr, block, type, bit, invert_bit, line_number, created_at, version, updated_at, change_seq
 `
 
 type CreateDeviceDataParams struct {
@@ -190,6 +190,100 @@ func (q *Queries) CreateDeviceData(ctx context.Context, arg CreateDeviceDataPara
 		&i.InvertBit,
 		&i.LineNumber,
 		&i.CreatedAt,
+		&i.Version,
+		&i.UpdatedAt,
+		&i.ChangeSeq,
+	)
+	return i, err
+}
+
+const createDeviceDataVersion = `-- name: CreateDeviceDataVersion :one
+INSERT INTO device_data (
+    file_id,
+    unit_guid,
+    mqtt,
+    invid,
+    msg_id,
+    text,
+    context,
+    class,
+    level,
+    area,
+    addr,
+    block,
+    type,
+    bit,
+    invert_bit,
+    line_number,
+    version
+) VALUES (
+    $1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17
+) RETURNING id, file_id, unit_guid, mqtt, invid, msg_id, text, context, class, level, area, addr, block, type, bit, invert_bit, line_number, created_at, version, updated_at, change_seq
+`
+
+type CreateDeviceDataVersionParams struct {
+	FileID     int64          `json:"file_id"`
+	UnitGuid   uuid.UUID      `json:"unit_guid"`
+	Mqtt       sql.NullString `json:"mqtt"`
+	Invid      sql.NullString `json:"invid"`
+	MsgID      sql.NullString `json:"msg_id"`
+	Text       sql.NullString `json:"text"`
+	Context    sql.NullString `json:"context"`
+	Class      sql.NullString `json:"class"`
+	Level      sql.NullInt32  `json:"level"`
+	Area       sql.NullString `json:"area"`
+	Addr       sql.NullString `json:"addr"`
+	Block      sql.NullString `json:"block"`
+	Type       sql.NullString `json:"type"`
+	Bit        sql.NullInt32  `json:"bit"`
+	InvertBit  sql.NullBool   `json:"invert_bit"`
+	LineNumber int32          `json:"line_number"`
+	Version    int32          `json:"version"`
+}
+
+func (q *Queries) CreateDeviceDataVersion(ctx context.Context, arg CreateDeviceDataVersionParams) (DeviceDatum, error) {
+	row := q.db.QueryRowContext(ctx, createDeviceDataVersion,
+		arg.FileID,
+		arg.UnitGuid,
+		arg.Mqtt,
+		arg.Invid,
+		arg.MsgID,
+		arg.Text,
+		arg.Context,
+		arg.Class,
+		arg.Level,
+		arg.Area,
+		arg.Addr,
+		arg.Block,
+		arg.Type,
+		arg.Bit,
+		arg.InvertBit,
+		arg.LineNumber,
+		arg.Version,
+	)
+	var i DeviceDatum
+	err := row.Scan(
+		&i.ID,
+		&i.FileID,
+		&i.UnitGuid,
+		&i.Mqtt,
+		&i.Invid,
+		&i.MsgID,
+		&i.Text,
+		&i.Context,
+		&i.Class,
+		&i.Level,
+		&i.Area,
+		&i.Addr,
+		&i.Block,
+		&i.Type,
+		&i.Bit,
+		&i.InvertBit,
+		&i.LineNumber,
+		&i.CreatedAt,
+		&i.Version,
+		&i.UpdatedAt,
+		&i.ChangeSeq,
 	)
 	return i, err
 }
@@ -215,7 +309,7 @@ func (q *Queries) DeleteDeviceDataByFileID(ctx context.Context, fileID int64) er
 }
 
 const getDeviceDataByFileID = `-- name: GetDeviceDataByFileID :many
-SELECT id, file_id, unit_guid, mqtt, invid, msg_id, text, context, class, level, area, addr, block, type, bit, invert_bit, line_number, created_at FROM device_data
+SELECT id, file_id, unit_guid, mqtt, invid, msg_id, text, context, class, level, area, addr, block, type, bit, invert_bit, line_number, created_at, version, updated_at, change_seq FROM device_data
 WHERE file_id = $1
 ORDER BY line_number
 `
@@ -248,6 +342,9 @@ func (q *Queries) GetDeviceDataByFileID(ctx context.Context, fileID int64) ([]De
 			&i.InvertBit,
 			&i.LineNumber,
 			&i.CreatedAt,
+			&i.Version,
+			&i.UpdatedAt,
+			&i.ChangeSeq,
 		); err != nil {
 			return nil, err
 		}
@@ -263,7 +360,7 @@ func (q *Queries) GetDeviceDataByFileID(ctx context.Context, fileID int64) ([]De
 }
 
 const getDeviceDataByID = `-- name: GetDeviceDataByID :one
-SELECT id, file_id, unit_guid, mqtt, invid, msg_id, text, context, class, level, area, addr, block, type, bit, invert_bit, line_number, created_at FROM device_data
+SELECT id, file_id, unit_guid, mqtt, invid, msg_id, text, context, class, level, area, addr, block, type, bit, invert_bit, line_number, created_at, version, updated_at, change_seq FROM device_data
 WHERE id = $1 LIMIT 1
 `
 
@@ -289,6 +386,9 @@ func (q *Queries) GetDeviceDataByID(ctx context.Context, id int64) (DeviceDatum,
 		&i.InvertBit,
 		&i.LineNumber,
 		&i.CreatedAt,
+		&i.Version,
+		&i.UpdatedAt,
+		&i.ChangeSeq,
 	)
 	return i, err
 }
@@ -325,8 +425,192 @@ func (q *Queries) GetDeviceStatistics(ctx context.Context, fileID int64) (GetDev
 	return i, err
 }
 
+const getIdenticalDeviceData = `-- name: GetIdenticalDeviceData :one
+SELECT id, file_id, unit_guid, mqtt, invid, msg_id, text, context, class, level, area, addr, block, type, bit, invert_bit, line_number, created_at, version, updated_at, change_seq FROM device_data
+WHERE unit_guid = $1
+  AND mqtt IS NOT DISTINCT FROM $2
+  AND invid IS NOT DISTINCT FROM $3
+  AND msg_id IS NOT DISTINCT FROM $4
+  AND text IS NOT DISTINCT FROM $5
+  AND class IS NOT DISTINCT FROM $6
+  AND level IS NOT DISTINCT FROM $7
+  AND area IS NOT DISTINCT FROM $8
+  AND addr IS NOT DISTINCT FROM $9
+  AND block IS NOT DISTINCT FROM $10
+  AND type IS NOT DISTINCT FROM $11
+  AND bit IS NOT DISTINCT FROM $12
+  AND invert_bit IS NOT DISTINCT FROM $13
+  AND file_id <> $14
+ORDER BY id
+LIMIT 1
+`
+
+type GetIdenticalDeviceDataParams struct {
+	UnitGuid  uuid.UUID      `json:"unit_guid"`
+	Mqtt      sql.NullString `json:"mqtt"`
+	Invid     sql.NullString `json:"invid"`
+	MsgID     sql.NullString `json:"msg_id"`
+	Text      sql.NullString `json:"text"`
+	Class     sql.NullString `json:"class"`
+	Level     sql.NullInt32  `json:"level"`
+	Area      sql.NullString `json:"area"`
+	Addr      sql.NullString `json:"addr"`
+	Block     sql.NullString `json:"block"`
+	Type      sql.NullString `json:"type"`
+	Bit       sql.NullInt32  `json:"bit"`
+	InvertBit sql.NullBool   `json:"invert_bit"`
+	FileID    int64          `json:"file_id"`
+}
+
+func (q *Queries) GetIdenticalDeviceData(ctx context.Context, arg GetIdenticalDeviceDataParams) (DeviceDatum, error) {
+	row := q.db.QueryRowContext(ctx, getIdenticalDeviceData,
+		arg.UnitGuid,
+		arg.Mqtt,
+		arg.Invid,
+		arg.MsgID,
+		arg.Text,
+		arg.Class,
+		arg.Level,
+		arg.Area,
+		arg.Addr,
+		arg.Block,
+		arg.Type,
+		arg.Bit,
+		arg.InvertBit,
+		arg.FileID,
+	)
+	var i DeviceDatum
+	err := row.Scan(
+		&i.ID,
+		&i.FileID,
+		&i.UnitGuid,
+		&i.Mqtt,
+		&i.Invid,
+		&i.MsgID,
+		&i.Text,
+		&i.Context,
+		&i.Class,
+		&i.Level,
+		&i.Area,
+		&i.Addr,
+		&i.Block,
+		&i.Type,
+		&i.Bit,
+		&i.InvertBit,
+		&i.LineNumber,
+		&i.CreatedAt,
+		&i.Version,
+		&i.UpdatedAt,
+		&i.ChangeSeq,
+	)
+	return i, err
+}
+
+const getIdenticalDeviceDataInFile = `-- name: GetIdenticalDeviceDataInFile :one
+SELECT line_number FROM device_data
+WHERE unit_guid = $1
+  AND mqtt IS NOT DISTINCT FROM $2
+  AND invid IS NOT DISTINCT FROM $3
+  AND msg_id IS NOT DISTINCT FROM $4
+  AND text IS NOT DISTINCT FROM $5
+  AND class IS NOT DISTINCT FROM $6
+  AND level IS NOT DISTINCT FROM $7
+  AND area IS NOT DISTINCT FROM $8
+  AND addr IS NOT DISTINCT FROM $9
+  AND block IS NOT DISTINCT FROM $10
+  AND type IS NOT DISTINCT FROM $11
+  AND bit IS NOT DISTINCT FROM $12
+  AND invert_bit IS NOT DISTINCT FROM $13
+  AND file_id = $14
+  AND line_number < $15
+ORDER BY line_number
+LIMIT 1
+`
+
+type GetIdenticalDeviceDataInFileParams struct {
+	UnitGuid   uuid.UUID      `json:"unit_guid"`
+	Mqtt       sql.NullString `json:"mqtt"`
+	Invid      sql.NullString `json:"invid"`
+	MsgID      sql.NullString `json:"msg_id"`
+	Text       sql.NullString `json:"text"`
+	Class      sql.NullString `json:"class"`
+	Level      sql.NullInt32  `json:"level"`
+	Area       sql.NullString `json:"area"`
+	Addr       sql.NullString `json:"addr"`
+	Block      sql.NullString `json:"block"`
+	Type       sql.NullString `json:"type"`
+	Bit        sql.NullInt32  `json:"bit"`
+	InvertBit  sql.NullBool   `json:"invert_bit"`
+	FileID     int64          `json:"file_id"`
+	LineNumber int32          `json:"line_number"`
+}
+
+func (q *Queries) GetIdenticalDeviceDataInFile(ctx context.Context, arg GetIdenticalDeviceDataInFileParams) (int32, error) {
+	row := q.db.QueryRowContext(ctx, getIdenticalDeviceDataInFile,
+		arg.UnitGuid,
+		arg.Mqtt,
+		arg.Invid,
+		arg.MsgID,
+		arg.Text,
+		arg.Class,
+		arg.Level,
+		arg.Area,
+		arg.Addr,
+		arg.Block,
+		arg.Type,
+		arg.Bit,
+		arg.InvertBit,
+		arg.FileID,
+		arg.LineNumber,
+	)
+	var line_number int32
+	err := row.Scan(&line_number)
+	return line_number, err
+}
+
+const getLatestDeviceDataByKey = `-- name: GetLatestDeviceDataByKey :one
+SELECT id, file_id, unit_guid, mqtt, invid, msg_id, text, context, class, level, area, addr, block, type, bit, invert_bit, line_number, created_at, version, updated_at, change_seq FROM device_data
+WHERE unit_guid = $1 AND msg_id = $2
+ORDER BY version DESC, id DESC
+LIMIT 1
+`
+
+type GetLatestDeviceDataByKeyParams struct {
+	UnitGuid uuid.UUID      `json:"unit_guid"`
+	MsgID    sql.NullString `json:"msg_id"`
+}
+
+func (q *Queries) GetLatestDeviceDataByKey(ctx context.Context, arg GetLatestDeviceDataByKeyParams) (DeviceDatum, error) {
+	row := q.db.QueryRowContext(ctx, getLatestDeviceDataByKey, arg.UnitGuid, arg.MsgID)
+	var i DeviceDatum
+	err := row.Scan(
+		&i.ID,
+		&i.FileID,
+		&i.UnitGuid,
+		&i.Mqtt,
+		&i.Invid,
+		&i.MsgID,
+		&i.Text,
+		&i.Context,
+		&i.Class,
+		&i.Level,
+		&i.Area,
+		&i.Addr,
+		&i.Block,
+		&i.Type,
+		&i.Bit,
+		&i.InvertBit,
+		&i.LineNumber,
+		&i.CreatedAt,
+		&i.Version,
+		&i.UpdatedAt,
+		&i.ChangeSeq,
+	)
+	return i, err
+}
+
 const listDeviceDataByClass = `-- name: ListDeviceDataByClass :many
-SELECT id, file_id, unit_guid, mqtt, invid, msg_id, text, context, class, level, area, addr, block, type, bit, invert_bit, line_number, created_at FROM device_data
+SELECT id, file_id, unit_guid, mqtt, invid, msg_id, text, context, class, level, area, addr, block, type, bit, invert_bit, line_number, created_at, version, updated_at, change_seq FROM device_data
 WHERE class = $1 AND file_id = $2
 ORDER BY line_number
 `
@@ -364,6 +648,9 @@ func (q *Queries) ListDeviceDataByClass(ctx context.Context, arg ListDeviceDataB
 			&i.InvertBit,
 			&i.LineNumber,
 			&i.CreatedAt,
+			&i.Version,
+			&i.UpdatedAt,
+			&i.ChangeSeq,
 		); err != nil {
 			return nil, err
 		}
@@ -379,9 +666,10 @@ func (q *Queries) ListDeviceDataByClass(ctx context.Context, arg ListDeviceDataB
 }
 
 const listDeviceDataByUnit = `-- name: ListDeviceDataByUnit :many
-SELECT id, file_id, unit_guid, mqtt, invid, msg_id, text, context, class, level, area, addr, block, type, bit, invert_bit, line_number, created_at FROM device_data
+SELECT id, file_id, unit_guid, mqtt, invid, msg_id, text, context, class, level, area, addr, block, type, bit, invert_bit, line_number, created_at, version, updated_at, change_seq FROM device_data
 WHERE unit_guid = $1
-ORDER BY created_at DESC
+AND file_id NOT IN (SELECT id FROM files WHERE superseded_by IS NOT NULL)
+ORDER BY created_at DESC NULLS FIRST, id DESC
 LIMIT $2
 OFFSET $3
 `
@@ -420,6 +708,9 @@ func (q *Queries) ListDeviceDataByUnit(ctx context.Context, arg ListDeviceDataBy
 			&i.InvertBit,
 			&i.LineNumber,
 			&i.CreatedAt,
+			&i.Version,
+			&i.UpdatedAt,
+			&i.ChangeSeq,
 		); err != nil {
 			return nil, err
 		}
@@ -434,8 +725,169 @@ func (q *Queries) ListDeviceDataByUnit(ctx context.Context, arg ListDeviceDataBy
 	return items, nil
 }
 
+const listDeviceDataByUnitBefore = `-- name: ListDeviceDataByUnitBefore :many
+SELECT id, file_id, unit_guid, mqtt, invid, msg_id, text, context, class, level, area, addr, block, type, bit, invert_bit, line_number, created_at, version, updated_at, change_seq FROM device_data
+WHERE unit_guid = $1
+AND file_id NOT IN (SELECT id FROM files WHERE superseded_by IS NOT NULL)
+AND (
+    created_at < $2
+    OR (created_at = $2 AND id < $3)
+    OR ($2 IS NULL AND (created_at IS NOT NULL OR id < $3))
+)
+ORDER BY created_at DESC NULLS FIRST, id DESC
+LIMIT $4
+`
+
+type ListDeviceDataByUnitBeforeParams struct {
+	UnitGuid  uuid.UUID    `json:"unit_guid"`
+	CreatedAt sql.NullTime `json:"created_at"`
+	ID        int64        `json:"id"`
+	Limit     int32        `json:"limit"`
+}
+
+func (q *Queries) ListDeviceDataByUnitBefore(ctx context.Context, arg ListDeviceDataByUnitBeforeParams) ([]DeviceDatum, error) {
+	rows, err := q.db.QueryContext(ctx, listDeviceDataByUnitBefore,
+		arg.UnitGuid,
+		arg.CreatedAt,
+		arg.ID,
+		arg.Limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []DeviceDatum{}
+	for rows.Next() {
+		var i DeviceDatum
+		if err := rows.Scan(
+			&i.ID,
+			&i.FileID,
+			&i.UnitGuid,
+			&i.Mqtt,
+			&i.Invid,
+			&i.MsgID,
+			&i.Text,
+			&i.Context,
+			&i.Class,
+			&i.Level,
+			&i.Area,
+			&i.Addr,
+			&i.Block,
+			&i.Type,
+			&i.Bit,
+			&i.InvertBit,
+			&i.LineNumber,
+			&i.CreatedAt,
+			&i.Version,
+			&i.UpdatedAt,
+			&i.ChangeSeq,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const overwriteDeviceData = `-- name: OverwriteDeviceData :one
+UPDATE device_data
+SET
+    file_id = $2,
+    unit_guid = $3,
+    mqtt = $4,
+    invid = $5,
+    msg_id = $6,
+    text = $7,
+    context = $8,
+    class = $9,
+    level = $10,
+    area = $11,
+    addr = $12,
+    block = $13,
+    type = $14,
+    bit = $15,
+    invert_bit = $16,
+    line_number = $17,
+    created_at = CURRENT_TIMESTAMP
+WHERE id = $1
+RETURNING id, file_id, unit_guid, mqtt, invid, msg_id, text, context, class, level, area, addr, block, type, bit, invert_bit, line_number, created_at, version, updated_at, change_seq
+`
+
+type OverwriteDeviceDataParams struct {
+	ID         int64          `json:"id"`
+	FileID     int64          `json:"file_id"`
+	UnitGuid   uuid.UUID      `json:"unit_guid"`
+	Mqtt       sql.NullString `json:"mqtt"`
+	Invid      sql.NullString `json:"invid"`
+	MsgID      sql.NullString `json:"msg_id"`
+	Text       sql.NullString `json:"text"`
+	Context    sql.NullString `json:"context"`
+	Class      sql.NullString `json:"class"`
+	Level      sql.NullInt32  `json:"level"`
+	Area       sql.NullString `json:"area"`
+	Addr       sql.NullString `json:"addr"`
+	Block      sql.NullString `json:"block"`
+	Type       sql.NullString `json:"type"`
+	Bit        sql.NullInt32  `json:"bit"`
+	InvertBit  sql.NullBool   `json:"invert_bit"`
+	LineNumber int32          `json:"line_number"`
+}
+
+func (q *Queries) OverwriteDeviceData(ctx context.Context, arg OverwriteDeviceDataParams) (DeviceDatum, error) {
+	row := q.db.QueryRowContext(ctx, overwriteDeviceData,
+		arg.ID,
+		arg.FileID,
+		arg.UnitGuid,
+		arg.Mqtt,
+		arg.Invid,
+		arg.MsgID,
+		arg.Text,
+		arg.Context,
+		arg.Class,
+		arg.Level,
+		arg.Area,
+		arg.Addr,
+		arg.Block,
+		arg.Type,
+		arg.Bit,
+		arg.InvertBit,
+		arg.LineNumber,
+	)
+	var i DeviceDatum
+	err := row.Scan(
+		&i.ID,
+		&i.FileID,
+		&i.UnitGuid,
+		&i.Mqtt,
+		&i.Invid,
+		&i.MsgID,
+		&i.Text,
+		&i.Context,
+		&i.Class,
+		&i.Level,
+		&i.Area,
+		&i.Addr,
+		&i.Block,
+		&i.Type,
+		&i.Bit,
+		&i.InvertBit,
+		&i.LineNumber,
+		&i.CreatedAt,
+		&i.Version,
+		&i.UpdatedAt,
+		&i.ChangeSeq,
+	)
+	return i, err
+}
+
 const searchDeviceDataText = `-- name: SearchDeviceDataText :many
-SELECT id, file_id, unit_guid, mqtt, invid, msg_id, text, context, class, level, area, addr, block, type, bit, invert_bit, line_number, created_at FROM device_data
+SELECT id, file_id, unit_guid, mqtt, invid, msg_id, text, context, class, level, area, addr, block, type, bit, invert_bit, line_number, created_at, version, updated_at, change_seq FROM device_data
 WHERE text ILIKE '%' || $1 || '%'
 AND file_id = $2
 ORDER BY line_number
@@ -474,6 +926,9 @@ func (q *Queries) SearchDeviceDataText(ctx context.Context, arg SearchDeviceData
 			&i.InvertBit,
 			&i.LineNumber,
 			&i.CreatedAt,
+			&i.Version,
+			&i.UpdatedAt,
+			&i.ChangeSeq,
 		); err != nil {
 			return nil, err
 		}
@@ -495,7 +950,7 @@ SET
     level = $3,
     class = $4
 WHERE id = $1
-RETURNING id, file_id, unit_guid, mqtt, invid, msg_id, text, context, class, level, area, addr, block, type, bit, invert_bit, line_number, created_at
+RETURNING id, file_id, unit_guid, mqtt, invid, msg_id, text, context, class, level, area, addr, block, type, bit, invert_bit, line_number, created_at, version, updated_at, change_seq
 `
 
 type UpdateDeviceDataParams struct {
@@ -532,6 +987,9 @@ func (q *Queries) UpdateDeviceData(ctx context.Context, arg UpdateDeviceDataPara
 		&i.InvertBit,
 		&i.LineNumber,
 		&i.CreatedAt,
+		&i.Version,
+		&i.UpdatedAt,
+		&i.ChangeSeq,
 	)
 	return i, err
 }
@@ -8,26 +8,118 @@ package sqlc
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
 )
 
+const completeFile = `-- name: CompleteFile :one
+UPDATE files
+SET
+    status = $2,
+    completed_at = $3,
+    updated_at = CURRENT_TIMESTAMP
+WHERE id = $1
+RETURNING id, filename, file_hash, status, rows_processed, rows_failed, error_message, created_at, updated_at, source, file_mtime, arrived_at, completed_at, conflict_policy, rows_skipped, rows_overwritten, rows_versioned, superseded_by, superseded_at, change_seq, trace_id, span_id, sample_rate, corrects_file_id, rows_corrected, correction_note, rows_duplicate_existing, rows_duplicate_in_file, profile, anomalies, seq_first, seq_last, seq_gaps, metadata
+`
+
+type CompleteFileParams struct {
+	ID          int64          `json:"id"`
+	Status      sql.NullString `json:"status"`
+	CompletedAt sql.NullTime   `json:"completed_at"`
+}
+
+func (q *Queries) CompleteFile(ctx context.Context, arg CompleteFileParams) (File, error) {
+	row := q.db.QueryRowContext(ctx, completeFile, arg.ID, arg.Status, arg.CompletedAt)
+	var i File
+	err := row.Scan(
+		&i.ID,
+		&i.Filename,
+		&i.FileHash,
+		&i.Status,
+		&i.RowsProcessed,
+		&i.RowsFailed,
+		&i.ErrorMessage,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Source,
+		&i.FileMtime,
+		&i.ArrivedAt,
+		&i.CompletedAt,
+		&i.ConflictPolicy,
+		&i.RowsSkipped,
+		&i.RowsOverwritten,
+		&i.RowsVersioned,
+		&i.SupersededBy,
+		&i.SupersededAt,
+		&i.ChangeSeq,
+		&i.TraceID,
+		&i.SpanID,
+		&i.SampleRate,
+		&i.CorrectsFileID,
+		&i.RowsCorrected,
+		&i.CorrectionNote,
+		&i.RowsDuplicateExisting,
+		&i.RowsDuplicateInFile,
+		&i.Profile,
+		&i.Anomalies,
+		&i.SeqFirst,
+		&i.SeqLast,
+		&i.SeqGaps,
+		&i.Metadata,
+	)
+	return i, err
+}
+
+const countFiles = `-- name: CountFiles :one
+SELECT COUNT(*) FROM files
+`
+
+func (q *Queries) CountFiles(ctx context.Context) (int64, error) {
+	row := q.db.QueryRowContext(ctx, countFiles)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
 const createFile = `-- name: CreateFile :one
 INSERT INTO files (
     filename, 
     file_hash, 
-    status
+    status,
+    source,
+    file_mtime,
+    arrived_at,
+    trace_id,
+    span_id,
+    sample_rate
 ) VALUES (
-    $1, $2, $3
-) RETURNING id, filename, file_hash, status, rows_processed, rows_failed, error_message, created_at, updated_at
+    $1, $2, $3, $4, $5, $6, $7, $8, $9
+) RETURNING id, filename, file_hash, status, rows_processed, rows_failed, error_message, created_at, updated_at, source, file_mtime, arrived_at, completed_at, conflict_policy, rows_skipped, rows_overwritten, rows_versioned, superseded_by, superseded_at, change_seq, trace_id, span_id, sample_rate, corrects_file_id, rows_corrected, correction_note, rows_duplicate_existing, rows_duplicate_in_file, profile, anomalies, seq_first, seq_last, seq_gaps, metadata
 `
 
 type CreateFileParams struct {
-	Filename string         `json:"filename"`
-	FileHash string         `json:"file_hash"`
-	Status   sql.NullString `json:"status"`
+	Filename   string          `json:"filename"`
+	FileHash   string          `json:"file_hash"`
+	Status     sql.NullString  `json:"status"`
+	Source     string          `json:"source"`
+	FileMtime  sql.NullTime    `json:"file_mtime"`
+	ArrivedAt  sql.NullTime    `json:"arrived_at"`
+	TraceID    sql.NullString  `json:"trace_id"`
+	SpanID     sql.NullString  `json:"span_id"`
+	SampleRate sql.NullFloat64 `json:"sample_rate"`
 }
 
 func (q *Queries) CreateFile(ctx context.Context, arg CreateFileParams) (File, error) {
-	row := q.db.QueryRowContext(ctx, createFile, arg.Filename, arg.FileHash, arg.Status)
+	row := q.db.QueryRowContext(ctx, createFile,
+		arg.Filename,
+		arg.FileHash,
+		arg.Status,
+		arg.Source,
+		arg.FileMtime,
+		arg.ArrivedAt,
+		arg.TraceID,
+		arg.SpanID,
+		arg.SampleRate,
+	)
 	var i File
 	err := row.Scan(
 		&i.ID,
@@ -39,6 +131,31 @@ func (q *Queries) CreateFile(ctx context.Context, arg CreateFileParams) (File, e
 		&i.ErrorMessage,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.Source,
+		&i.FileMtime,
+		&i.ArrivedAt,
+		&i.CompletedAt,
+		&i.ConflictPolicy,
+		&i.RowsSkipped,
+		&i.RowsOverwritten,
+		&i.RowsVersioned,
+		&i.SupersededBy,
+		&i.SupersededAt,
+		&i.ChangeSeq,
+		&i.TraceID,
+		&i.SpanID,
+		&i.SampleRate,
+		&i.CorrectsFileID,
+		&i.RowsCorrected,
+		&i.CorrectionNote,
+		&i.RowsDuplicateExisting,
+		&i.RowsDuplicateInFile,
+		&i.Profile,
+		&i.Anomalies,
+		&i.SeqFirst,
+		&i.SeqLast,
+		&i.SeqGaps,
+		&i.Metadata,
 	)
 	return i, err
 }
@@ -65,7 +182,7 @@ func (q *Queries) DeleteOldFiles(ctx context.Context, status sql.NullString) err
 }
 
 const getFileByFilename = `-- name: GetFileByFilename :one
-SELECT id, filename, file_hash, status, rows_processed, rows_failed, error_message, created_at, updated_at FROM files
+SELECT id, filename, file_hash, status, rows_processed, rows_failed, error_message, created_at, updated_at, source, file_mtime, arrived_at, completed_at, conflict_policy, rows_skipped, rows_overwritten, rows_versioned, superseded_by, superseded_at, change_seq, trace_id, span_id, sample_rate, corrects_file_id, rows_corrected, correction_note, rows_duplicate_existing, rows_duplicate_in_file, profile, anomalies, seq_first, seq_last, seq_gaps, metadata FROM files
 WHERE filename = $1 LIMIT 1
 `
 
@@ -82,12 +199,87 @@ func (q *Queries) GetFileByFilename(ctx context.Context, filename string) (File,
 		&i.ErrorMessage,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.Source,
+		&i.FileMtime,
+		&i.ArrivedAt,
+		&i.CompletedAt,
+		&i.ConflictPolicy,
+		&i.RowsSkipped,
+		&i.RowsOverwritten,
+		&i.RowsVersioned,
+		&i.SupersededBy,
+		&i.SupersededAt,
+		&i.ChangeSeq,
+		&i.TraceID,
+		&i.SpanID,
+		&i.SampleRate,
+		&i.CorrectsFileID,
+		&i.RowsCorrected,
+		&i.CorrectionNote,
+		&i.RowsDuplicateExisting,
+		&i.RowsDuplicateInFile,
+		&i.Profile,
+		&i.Anomalies,
+		&i.SeqFirst,
+		&i.SeqLast,
+		&i.SeqGaps,
+		&i.Metadata,
+	)
+	return i, err
+}
+
+const getFileByHash = `-- name: GetFileByHash :one
+SELECT id, filename, file_hash, status, rows_processed, rows_failed, error_message, created_at, updated_at, source, file_mtime, arrived_at, completed_at, conflict_policy, rows_skipped, rows_overwritten, rows_versioned, superseded_by, superseded_at, change_seq, trace_id, span_id, sample_rate, corrects_file_id, rows_corrected, correction_note, rows_duplicate_existing, rows_duplicate_in_file, profile, anomalies, seq_first, seq_last, seq_gaps, metadata FROM files
+WHERE file_hash = $1
+  AND status IN ('completed', 'partial')
+ORDER BY id
+LIMIT 1
+`
+
+func (q *Queries) GetFileByHash(ctx context.Context, fileHash string) (File, error) {
+	row := q.db.QueryRowContext(ctx, getFileByHash, fileHash)
+	var i File
+	err := row.Scan(
+		&i.ID,
+		&i.Filename,
+		&i.FileHash,
+		&i.Status,
+		&i.RowsProcessed,
+		&i.RowsFailed,
+		&i.ErrorMessage,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Source,
+		&i.FileMtime,
+		&i.ArrivedAt,
+		&i.CompletedAt,
+		&i.ConflictPolicy,
+		&i.RowsSkipped,
+		&i.RowsOverwritten,
+		&i.RowsVersioned,
+		&i.SupersededBy,
+		&i.SupersededAt,
+		&i.ChangeSeq,
+		&i.TraceID,
+		&i.SpanID,
+		&i.SampleRate,
+		&i.CorrectsFileID,
+		&i.RowsCorrected,
+		&i.CorrectionNote,
+		&i.RowsDuplicateExisting,
+		&i.RowsDuplicateInFile,
+		&i.Profile,
+		&i.Anomalies,
+		&i.SeqFirst,
+		&i.SeqLast,
+		&i.SeqGaps,
+		&i.Metadata,
 	)
 	return i, err
 }
 
 const getFileByID = `-- name: GetFileByID :one
-SELECT id, filename, file_hash, status, rows_processed, rows_failed, error_message, created_at, updated_at FROM files
+SELECT id, filename, file_hash, status, rows_processed, rows_failed, error_message, created_at, updated_at, source, file_mtime, arrived_at, completed_at, conflict_policy, rows_skipped, rows_overwritten, rows_versioned, superseded_by, superseded_at, change_seq, trace_id, span_id, sample_rate, corrects_file_id, rows_corrected, correction_note, rows_duplicate_existing, rows_duplicate_in_file, profile, anomalies, seq_first, seq_last, seq_gaps, metadata FROM files
 WHERE id = $1 LIMIT 1
 `
 
@@ -104,12 +296,108 @@ func (q *Queries) GetFileByID(ctx context.Context, id int64) (File, error) {
 		&i.ErrorMessage,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.Source,
+		&i.FileMtime,
+		&i.ArrivedAt,
+		&i.CompletedAt,
+		&i.ConflictPolicy,
+		&i.RowsSkipped,
+		&i.RowsOverwritten,
+		&i.RowsVersioned,
+		&i.SupersededBy,
+		&i.SupersededAt,
+		&i.ChangeSeq,
+		&i.TraceID,
+		&i.SpanID,
+		&i.SampleRate,
+		&i.CorrectsFileID,
+		&i.RowsCorrected,
+		&i.CorrectionNote,
+		&i.RowsDuplicateExisting,
+		&i.RowsDuplicateInFile,
+		&i.Profile,
+		&i.Anomalies,
+		&i.SeqFirst,
+		&i.SeqLast,
+		&i.SeqGaps,
+		&i.Metadata,
 	)
 	return i, err
 }
 
+const getLastSequencedFile = `-- name: GetLastSequencedFile :one
+SELECT id, filename, file_hash, status, rows_processed, rows_failed, error_message, created_at, updated_at, source, file_mtime, arrived_at, completed_at, conflict_policy, rows_skipped, rows_overwritten, rows_versioned, superseded_by, superseded_at, change_seq, trace_id, span_id, sample_rate, corrects_file_id, rows_corrected, correction_note, rows_duplicate_existing, rows_duplicate_in_file, profile, anomalies, seq_first, seq_last, seq_gaps, metadata FROM files
+WHERE source = $1
+  AND seq_last IS NOT NULL
+  AND status IN ('completed', 'partial')
+ORDER BY id DESC
+LIMIT 1
+`
+
+func (q *Queries) GetLastSequencedFile(ctx context.Context, source string) (File, error) {
+	row := q.db.QueryRowContext(ctx, getLastSequencedFile, source)
+	var i File
+	err := row.Scan(
+		&i.ID,
+		&i.Filename,
+		&i.FileHash,
+		&i.Status,
+		&i.RowsProcessed,
+		&i.RowsFailed,
+		&i.ErrorMessage,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Source,
+		&i.FileMtime,
+		&i.ArrivedAt,
+		&i.CompletedAt,
+		&i.ConflictPolicy,
+		&i.RowsSkipped,
+		&i.RowsOverwritten,
+		&i.RowsVersioned,
+		&i.SupersededBy,
+		&i.SupersededAt,
+		&i.ChangeSeq,
+		&i.TraceID,
+		&i.SpanID,
+		&i.SampleRate,
+		&i.CorrectsFileID,
+		&i.RowsCorrected,
+		&i.CorrectionNote,
+		&i.RowsDuplicateExisting,
+		&i.RowsDuplicateInFile,
+		&i.Profile,
+		&i.Anomalies,
+		&i.SeqFirst,
+		&i.SeqLast,
+		&i.SeqGaps,
+		&i.Metadata,
+	)
+	return i, err
+}
+
+const incrementFileProgress = `-- name: IncrementFileProgress :exec
+UPDATE files
+SET
+    rows_processed = COALESCE(rows_processed, 0) + $1,
+    rows_failed = COALESCE(rows_failed, 0) + $2,
+    updated_at = CURRENT_TIMESTAMP
+WHERE id = $3
+`
+
+type IncrementFileProgressParams struct {
+	ProcessedDelta int32 `json:"processed_delta"`
+	FailedDelta    int32 `json:"failed_delta"`
+	ID             int64 `json:"id"`
+}
+
+func (q *Queries) IncrementFileProgress(ctx context.Context, arg IncrementFileProgressParams) error {
+	_, err := q.db.ExecContext(ctx, incrementFileProgress, arg.ProcessedDelta, arg.FailedDelta, arg.ID)
+	return err
+}
+
 const listFiles = `-- name: ListFiles :many
-SELECT id, filename, file_hash, status, rows_processed, rows_failed, error_message, created_at, updated_at FROM files
+SELECT id, filename, file_hash, status, rows_processed, rows_failed, error_message, created_at, updated_at, source, file_mtime, arrived_at, completed_at, conflict_policy, rows_skipped, rows_overwritten, rows_versioned, superseded_by, superseded_at, change_seq, trace_id, span_id, sample_rate, corrects_file_id, rows_corrected, correction_note, rows_duplicate_existing, rows_duplicate_in_file, profile, anomalies, seq_first, seq_last, seq_gaps, metadata FROM files
 ORDER BY created_at DESC
 LIMIT $1
 OFFSET $2
@@ -139,6 +427,31 @@ func (q *Queries) ListFiles(ctx context.Context, arg ListFilesParams) ([]File, e
 			&i.ErrorMessage,
 			&i.CreatedAt,
 			&i.UpdatedAt,
+			&i.Source,
+			&i.FileMtime,
+			&i.ArrivedAt,
+			&i.CompletedAt,
+			&i.ConflictPolicy,
+			&i.RowsSkipped,
+			&i.RowsOverwritten,
+			&i.RowsVersioned,
+			&i.SupersededBy,
+			&i.SupersededAt,
+			&i.ChangeSeq,
+			&i.TraceID,
+			&i.SpanID,
+			&i.SampleRate,
+			&i.CorrectsFileID,
+			&i.RowsCorrected,
+			&i.CorrectionNote,
+			&i.RowsDuplicateExisting,
+			&i.RowsDuplicateInFile,
+			&i.Profile,
+			&i.Anomalies,
+			&i.SeqFirst,
+			&i.SeqLast,
+			&i.SeqGaps,
+			&i.Metadata,
 		); err != nil {
 			return nil, err
 		}
@@ -154,7 +467,7 @@ func (q *Queries) ListFiles(ctx context.Context, arg ListFilesParams) ([]File, e
 }
 
 const listFilesByDateRange = `-- name: ListFilesByDateRange :many
-SELECT id, filename, file_hash, status, rows_processed, rows_failed, error_message, created_at, updated_at FROM files
+SELECT id, filename, file_hash, status, rows_processed, rows_failed, error_message, created_at, updated_at, source, file_mtime, arrived_at, completed_at, conflict_policy, rows_skipped, rows_overwritten, rows_versioned, superseded_by, superseded_at, change_seq, trace_id, span_id, sample_rate, corrects_file_id, rows_corrected, correction_note, rows_duplicate_existing, rows_duplicate_in_file, profile, anomalies, seq_first, seq_last, seq_gaps, metadata FROM files
 WHERE created_at BETWEEN $1 AND $2
 ORDER BY created_at DESC
 `
@@ -183,6 +496,31 @@ func (q *Queries) ListFilesByDateRange(ctx context.Context, arg ListFilesByDateR
 			&i.ErrorMessage,
 			&i.CreatedAt,
 			&i.UpdatedAt,
+			&i.Source,
+			&i.FileMtime,
+			&i.ArrivedAt,
+			&i.CompletedAt,
+			&i.ConflictPolicy,
+			&i.RowsSkipped,
+			&i.RowsOverwritten,
+			&i.RowsVersioned,
+			&i.SupersededBy,
+			&i.SupersededAt,
+			&i.ChangeSeq,
+			&i.TraceID,
+			&i.SpanID,
+			&i.SampleRate,
+			&i.CorrectsFileID,
+			&i.RowsCorrected,
+			&i.CorrectionNote,
+			&i.RowsDuplicateExisting,
+			&i.RowsDuplicateInFile,
+			&i.Profile,
+			&i.Anomalies,
+			&i.SeqFirst,
+			&i.SeqLast,
+			&i.SeqGaps,
+			&i.Metadata,
 		); err != nil {
 			return nil, err
 		}
@@ -198,7 +536,7 @@ func (q *Queries) ListFilesByDateRange(ctx context.Context, arg ListFilesByDateR
 }
 
 const listFilesByStatus = `-- name: ListFilesByStatus :many
-SELECT id, filename, file_hash, status, rows_processed, rows_failed, error_message, created_at, updated_at FROM files
+SELECT id, filename, file_hash, status, rows_processed, rows_failed, error_message, created_at, updated_at, source, file_mtime, arrived_at, completed_at, conflict_policy, rows_skipped, rows_overwritten, rows_versioned, superseded_by, superseded_at, change_seq, trace_id, span_id, sample_rate, corrects_file_id, rows_corrected, correction_note, rows_duplicate_existing, rows_duplicate_in_file, profile, anomalies, seq_first, seq_last, seq_gaps, metadata FROM files
 WHERE status = $1
 ORDER BY created_at DESC
 `
@@ -222,6 +560,31 @@ func (q *Queries) ListFilesByStatus(ctx context.Context, status sql.NullString)
 			&i.ErrorMessage,
 			&i.CreatedAt,
 			&i.UpdatedAt,
+			&i.Source,
+			&i.FileMtime,
+			&i.ArrivedAt,
+			&i.CompletedAt,
+			&i.ConflictPolicy,
+			&i.RowsSkipped,
+			&i.RowsOverwritten,
+			&i.RowsVersioned,
+			&i.SupersededBy,
+			&i.SupersededAt,
+			&i.ChangeSeq,
+			&i.TraceID,
+			&i.SpanID,
+			&i.SampleRate,
+			&i.CorrectsFileID,
+			&i.RowsCorrected,
+			&i.CorrectionNote,
+			&i.RowsDuplicateExisting,
+			&i.RowsDuplicateInFile,
+			&i.Profile,
+			&i.Anomalies,
+			&i.SeqFirst,
+			&i.SeqLast,
+			&i.SeqGaps,
+			&i.Metadata,
 		); err != nil {
 			return nil, err
 		}
@@ -236,6 +599,484 @@ func (q *Queries) ListFilesByStatus(ctx context.Context, status sql.NullString)
 	return items, nil
 }
 
+const mergeFileCorrection = `-- name: MergeFileCorrection :one
+UPDATE files
+SET
+    status = $2,
+    rows_processed = $3,
+    rows_failed = $4,
+    rows_corrected = $5,
+    correction_note = $6,
+    updated_at = CURRENT_TIMESTAMP
+WHERE id = $1
+RETURNING id, filename, file_hash, status, rows_processed, rows_failed, error_message, created_at, updated_at, source, file_mtime, arrived_at, completed_at, conflict_policy, rows_skipped, rows_overwritten, rows_versioned, superseded_by, superseded_at, change_seq, trace_id, span_id, sample_rate, corrects_file_id, rows_corrected, correction_note, rows_duplicate_existing, rows_duplicate_in_file, profile, anomalies, seq_first, seq_last, seq_gaps, metadata
+`
+
+type MergeFileCorrectionParams struct {
+	ID             int64          `json:"id"`
+	Status         sql.NullString `json:"status"`
+	RowsProcessed  sql.NullInt32  `json:"rows_processed"`
+	RowsFailed     sql.NullInt32  `json:"rows_failed"`
+	RowsCorrected  sql.NullInt32  `json:"rows_corrected"`
+	CorrectionNote sql.NullString `json:"correction_note"`
+}
+
+func (q *Queries) MergeFileCorrection(ctx context.Context, arg MergeFileCorrectionParams) (File, error) {
+	row := q.db.QueryRowContext(ctx, mergeFileCorrection,
+		arg.ID,
+		arg.Status,
+		arg.RowsProcessed,
+		arg.RowsFailed,
+		arg.RowsCorrected,
+		arg.CorrectionNote,
+	)
+	var i File
+	err := row.Scan(
+		&i.ID,
+		&i.Filename,
+		&i.FileHash,
+		&i.Status,
+		&i.RowsProcessed,
+		&i.RowsFailed,
+		&i.ErrorMessage,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Source,
+		&i.FileMtime,
+		&i.ArrivedAt,
+		&i.CompletedAt,
+		&i.ConflictPolicy,
+		&i.RowsSkipped,
+		&i.RowsOverwritten,
+		&i.RowsVersioned,
+		&i.SupersededBy,
+		&i.SupersededAt,
+		&i.ChangeSeq,
+		&i.TraceID,
+		&i.SpanID,
+		&i.SampleRate,
+		&i.CorrectsFileID,
+		&i.RowsCorrected,
+		&i.CorrectionNote,
+		&i.RowsDuplicateExisting,
+		&i.RowsDuplicateInFile,
+		&i.Profile,
+		&i.Anomalies,
+		&i.SeqFirst,
+		&i.SeqLast,
+		&i.SeqGaps,
+		&i.Metadata,
+	)
+	return i, err
+}
+
+const setFileCorrects = `-- name: SetFileCorrects :one
+UPDATE files
+SET
+    corrects_file_id = $2,
+    updated_at = CURRENT_TIMESTAMP
+WHERE id = $1
+RETURNING id, filename, file_hash, status, rows_processed, rows_failed, error_message, created_at, updated_at, source, file_mtime, arrived_at, completed_at, conflict_policy, rows_skipped, rows_overwritten, rows_versioned, superseded_by, superseded_at, change_seq, trace_id, span_id, sample_rate, corrects_file_id, rows_corrected, correction_note, rows_duplicate_existing, rows_duplicate_in_file, profile, anomalies, seq_first, seq_last, seq_gaps, metadata
+`
+
+type SetFileCorrectsParams struct {
+	ID             int64         `json:"id"`
+	CorrectsFileID sql.NullInt64 `json:"corrects_file_id"`
+}
+
+func (q *Queries) SetFileCorrects(ctx context.Context, arg SetFileCorrectsParams) (File, error) {
+	row := q.db.QueryRowContext(ctx, setFileCorrects, arg.ID, arg.CorrectsFileID)
+	var i File
+	err := row.Scan(
+		&i.ID,
+		&i.Filename,
+		&i.FileHash,
+		&i.Status,
+		&i.RowsProcessed,
+		&i.RowsFailed,
+		&i.ErrorMessage,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Source,
+		&i.FileMtime,
+		&i.ArrivedAt,
+		&i.CompletedAt,
+		&i.ConflictPolicy,
+		&i.RowsSkipped,
+		&i.RowsOverwritten,
+		&i.RowsVersioned,
+		&i.SupersededBy,
+		&i.SupersededAt,
+		&i.ChangeSeq,
+		&i.TraceID,
+		&i.SpanID,
+		&i.SampleRate,
+		&i.CorrectsFileID,
+		&i.RowsCorrected,
+		&i.CorrectionNote,
+		&i.RowsDuplicateExisting,
+		&i.RowsDuplicateInFile,
+		&i.Profile,
+		&i.Anomalies,
+		&i.SeqFirst,
+		&i.SeqLast,
+		&i.SeqGaps,
+		&i.Metadata,
+	)
+	return i, err
+}
+
+const supersedeFile = `-- name: SupersedeFile :one
+UPDATE files
+SET
+    superseded_by = $2,
+    superseded_at = CURRENT_TIMESTAMP,
+    updated_at = CURRENT_TIMESTAMP
+WHERE id = $1
+RETURNING id, filename, file_hash, status, rows_processed, rows_failed, error_message, created_at, updated_at, source, file_mtime, arrived_at, completed_at, conflict_policy, rows_skipped, rows_overwritten, rows_versioned, superseded_by, superseded_at, change_seq, trace_id, span_id, sample_rate, corrects_file_id, rows_corrected, correction_note, rows_duplicate_existing, rows_duplicate_in_file, profile, anomalies, seq_first, seq_last, seq_gaps, metadata
+`
+
+type SupersedeFileParams struct {
+	ID           int64         `json:"id"`
+	SupersededBy sql.NullInt64 `json:"superseded_by"`
+}
+
+func (q *Queries) SupersedeFile(ctx context.Context, arg SupersedeFileParams) (File, error) {
+	row := q.db.QueryRowContext(ctx, supersedeFile, arg.ID, arg.SupersededBy)
+	var i File
+	err := row.Scan(
+		&i.ID,
+		&i.Filename,
+		&i.FileHash,
+		&i.Status,
+		&i.RowsProcessed,
+		&i.RowsFailed,
+		&i.ErrorMessage,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Source,
+		&i.FileMtime,
+		&i.ArrivedAt,
+		&i.CompletedAt,
+		&i.ConflictPolicy,
+		&i.RowsSkipped,
+		&i.RowsOverwritten,
+		&i.RowsVersioned,
+		&i.SupersededBy,
+		&i.SupersededAt,
+		&i.ChangeSeq,
+		&i.TraceID,
+		&i.SpanID,
+		&i.SampleRate,
+		&i.CorrectsFileID,
+		&i.RowsCorrected,
+		&i.CorrectionNote,
+		&i.RowsDuplicateExisting,
+		&i.RowsDuplicateInFile,
+		&i.Profile,
+		&i.Anomalies,
+		&i.SeqFirst,
+		&i.SeqLast,
+		&i.SeqGaps,
+		&i.Metadata,
+	)
+	return i, err
+}
+
+const updateFileAnomalies = `-- name: UpdateFileAnomalies :one
+UPDATE files
+SET
+    anomalies = $2,
+    updated_at = CURRENT_TIMESTAMP
+WHERE id = $1
+RETURNING id, filename, file_hash, status, rows_processed, rows_failed, error_message, created_at, updated_at, source, file_mtime, arrived_at, completed_at, conflict_policy, rows_skipped, rows_overwritten, rows_versioned, superseded_by, superseded_at, change_seq, trace_id, span_id, sample_rate, corrects_file_id, rows_corrected, correction_note, rows_duplicate_existing, rows_duplicate_in_file, profile, anomalies, seq_first, seq_last, seq_gaps, metadata
+`
+
+type UpdateFileAnomaliesParams struct {
+	ID        int64           `json:"id"`
+	Anomalies json.RawMessage `json:"anomalies"`
+}
+
+func (q *Queries) UpdateFileAnomalies(ctx context.Context, arg UpdateFileAnomaliesParams) (File, error) {
+	row := q.db.QueryRowContext(ctx, updateFileAnomalies, arg.ID, arg.Anomalies)
+	var i File
+	err := row.Scan(
+		&i.ID,
+		&i.Filename,
+		&i.FileHash,
+		&i.Status,
+		&i.RowsProcessed,
+		&i.RowsFailed,
+		&i.ErrorMessage,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Source,
+		&i.FileMtime,
+		&i.ArrivedAt,
+		&i.CompletedAt,
+		&i.ConflictPolicy,
+		&i.RowsSkipped,
+		&i.RowsOverwritten,
+		&i.RowsVersioned,
+		&i.SupersededBy,
+		&i.SupersededAt,
+		&i.ChangeSeq,
+		&i.TraceID,
+		&i.SpanID,
+		&i.SampleRate,
+		&i.CorrectsFileID,
+		&i.RowsCorrected,
+		&i.CorrectionNote,
+		&i.RowsDuplicateExisting,
+		&i.RowsDuplicateInFile,
+		&i.Profile,
+		&i.Anomalies,
+		&i.SeqFirst,
+		&i.SeqLast,
+		&i.SeqGaps,
+		&i.Metadata,
+	)
+	return i, err
+}
+
+const updateFileConflictStats = `-- name: UpdateFileConflictStats :one
+UPDATE files
+SET
+    conflict_policy = $2,
+    rows_skipped = $3,
+    rows_overwritten = $4,
+    rows_versioned = $5,
+    updated_at = CURRENT_TIMESTAMP
+WHERE id = $1
+RETURNING id, filename, file_hash, status, rows_processed, rows_failed, error_message, created_at, updated_at, source, file_mtime, arrived_at, completed_at, conflict_policy, rows_skipped, rows_overwritten, rows_versioned, superseded_by, superseded_at, change_seq, trace_id, span_id, sample_rate, corrects_file_id, rows_corrected, correction_note, rows_duplicate_existing, rows_duplicate_in_file, profile, anomalies, seq_first, seq_last, seq_gaps, metadata
+`
+
+type UpdateFileConflictStatsParams struct {
+	ID              int64          `json:"id"`
+	ConflictPolicy  sql.NullString `json:"conflict_policy"`
+	RowsSkipped     sql.NullInt32  `json:"rows_skipped"`
+	RowsOverwritten sql.NullInt32  `json:"rows_overwritten"`
+	RowsVersioned   sql.NullInt32  `json:"rows_versioned"`
+}
+
+func (q *Queries) UpdateFileConflictStats(ctx context.Context, arg UpdateFileConflictStatsParams) (File, error) {
+	row := q.db.QueryRowContext(ctx, updateFileConflictStats,
+		arg.ID,
+		arg.ConflictPolicy,
+		arg.RowsSkipped,
+		arg.RowsOverwritten,
+		arg.RowsVersioned,
+	)
+	var i File
+	err := row.Scan(
+		&i.ID,
+		&i.Filename,
+		&i.FileHash,
+		&i.Status,
+		&i.RowsProcessed,
+		&i.RowsFailed,
+		&i.ErrorMessage,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Source,
+		&i.FileMtime,
+		&i.ArrivedAt,
+		&i.CompletedAt,
+		&i.ConflictPolicy,
+		&i.RowsSkipped,
+		&i.RowsOverwritten,
+		&i.RowsVersioned,
+		&i.SupersededBy,
+		&i.SupersededAt,
+		&i.ChangeSeq,
+		&i.TraceID,
+		&i.SpanID,
+		&i.SampleRate,
+		&i.CorrectsFileID,
+		&i.RowsCorrected,
+		&i.CorrectionNote,
+		&i.RowsDuplicateExisting,
+		&i.RowsDuplicateInFile,
+		&i.Profile,
+		&i.Anomalies,
+		&i.SeqFirst,
+		&i.SeqLast,
+		&i.SeqGaps,
+		&i.Metadata,
+	)
+	return i, err
+}
+
+const updateFileDuplicateStats = `-- name: UpdateFileDuplicateStats :one
+UPDATE files
+SET
+    rows_duplicate_existing = $2,
+    rows_duplicate_in_file = $3,
+    updated_at = CURRENT_TIMESTAMP
+WHERE id = $1
+RETURNING id, filename, file_hash, status, rows_processed, rows_failed, error_message, created_at, updated_at, source, file_mtime, arrived_at, completed_at, conflict_policy, rows_skipped, rows_overwritten, rows_versioned, superseded_by, superseded_at, change_seq, trace_id, span_id, sample_rate, corrects_file_id, rows_corrected, correction_note, rows_duplicate_existing, rows_duplicate_in_file, profile, anomalies, seq_first, seq_last, seq_gaps, metadata
+`
+
+type UpdateFileDuplicateStatsParams struct {
+	ID                    int64         `json:"id"`
+	RowsDuplicateExisting sql.NullInt32 `json:"rows_duplicate_existing"`
+	RowsDuplicateInFile   sql.NullInt32 `json:"rows_duplicate_in_file"`
+}
+
+func (q *Queries) UpdateFileDuplicateStats(ctx context.Context, arg UpdateFileDuplicateStatsParams) (File, error) {
+	row := q.db.QueryRowContext(ctx, updateFileDuplicateStats, arg.ID, arg.RowsDuplicateExisting, arg.RowsDuplicateInFile)
+	var i File
+	err := row.Scan(
+		&i.ID,
+		&i.Filename,
+		&i.FileHash,
+		&i.Status,
+		&i.RowsProcessed,
+		&i.RowsFailed,
+		&i.ErrorMessage,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Source,
+		&i.FileMtime,
+		&i.ArrivedAt,
+		&i.CompletedAt,
+		&i.ConflictPolicy,
+		&i.RowsSkipped,
+		&i.RowsOverwritten,
+		&i.RowsVersioned,
+		&i.SupersededBy,
+		&i.SupersededAt,
+		&i.ChangeSeq,
+		&i.TraceID,
+		&i.SpanID,
+		&i.SampleRate,
+		&i.CorrectsFileID,
+		&i.RowsCorrected,
+		&i.CorrectionNote,
+		&i.RowsDuplicateExisting,
+		&i.RowsDuplicateInFile,
+		&i.Profile,
+		&i.Anomalies,
+		&i.SeqFirst,
+		&i.SeqLast,
+		&i.SeqGaps,
+		&i.Metadata,
+	)
+	return i, err
+}
+
+const updateFileMetadata = `-- name: UpdateFileMetadata :one
+UPDATE files
+SET
+    metadata = $2,
+    updated_at = CURRENT_TIMESTAMP
+WHERE id = $1
+RETURNING id, filename, file_hash, status, rows_processed, rows_failed, error_message, created_at, updated_at, source, file_mtime, arrived_at, completed_at, conflict_policy, rows_skipped, rows_overwritten, rows_versioned, superseded_by, superseded_at, change_seq, trace_id, span_id, sample_rate, corrects_file_id, rows_corrected, correction_note, rows_duplicate_existing, rows_duplicate_in_file, profile, anomalies, seq_first, seq_last, seq_gaps, metadata
+`
+
+type UpdateFileMetadataParams struct {
+	ID       int64           `json:"id"`
+	Metadata json.RawMessage `json:"metadata"`
+}
+
+func (q *Queries) UpdateFileMetadata(ctx context.Context, arg UpdateFileMetadataParams) (File, error) {
+	row := q.db.QueryRowContext(ctx, updateFileMetadata, arg.ID, arg.Metadata)
+	var i File
+	err := row.Scan(
+		&i.ID,
+		&i.Filename,
+		&i.FileHash,
+		&i.Status,
+		&i.RowsProcessed,
+		&i.RowsFailed,
+		&i.ErrorMessage,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Source,
+		&i.FileMtime,
+		&i.ArrivedAt,
+		&i.CompletedAt,
+		&i.ConflictPolicy,
+		&i.RowsSkipped,
+		&i.RowsOverwritten,
+		&i.RowsVersioned,
+		&i.SupersededBy,
+		&i.SupersededAt,
+		&i.ChangeSeq,
+		&i.TraceID,
+		&i.SpanID,
+		&i.SampleRate,
+		&i.CorrectsFileID,
+		&i.RowsCorrected,
+		&i.CorrectionNote,
+		&i.RowsDuplicateExisting,
+		&i.RowsDuplicateInFile,
+		&i.Profile,
+		&i.Anomalies,
+		&i.SeqFirst,
+		&i.SeqLast,
+		&i.SeqGaps,
+		&i.Metadata,
+	)
+	return i, err
+}
+
+const updateFileProfile = `-- name: UpdateFileProfile :one
+UPDATE files
+SET
+    profile = $2,
+    updated_at = CURRENT_TIMESTAMP
+WHERE id = $1
+RETURNING id, filename, file_hash, status, rows_processed, rows_failed, error_message, created_at, updated_at, source, file_mtime, arrived_at, completed_at, conflict_policy, rows_skipped, rows_overwritten, rows_versioned, superseded_by, superseded_at, change_seq, trace_id, span_id, sample_rate, corrects_file_id, rows_corrected, correction_note, rows_duplicate_existing, rows_duplicate_in_file, profile, anomalies, seq_first, seq_last, seq_gaps, metadata
+`
+
+type UpdateFileProfileParams struct {
+	ID      int64           `json:"id"`
+	Profile json.RawMessage `json:"profile"`
+}
+
+func (q *Queries) UpdateFileProfile(ctx context.Context, arg UpdateFileProfileParams) (File, error) {
+	row := q.db.QueryRowContext(ctx, updateFileProfile, arg.ID, arg.Profile)
+	var i File
+	err := row.Scan(
+		&i.ID,
+		&i.Filename,
+		&i.FileHash,
+		&i.Status,
+		&i.RowsProcessed,
+		&i.RowsFailed,
+		&i.ErrorMessage,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Source,
+		&i.FileMtime,
+		&i.ArrivedAt,
+		&i.CompletedAt,
+		&i.ConflictPolicy,
+		&i.RowsSkipped,
+		&i.RowsOverwritten,
+		&i.RowsVersioned,
+		&i.SupersededBy,
+		&i.SupersededAt,
+		&i.ChangeSeq,
+		&i.TraceID,
+		&i.SpanID,
+		&i.SampleRate,
+		&i.CorrectsFileID,
+		&i.RowsCorrected,
+		&i.CorrectionNote,
+		&i.RowsDuplicateExisting,
+		&i.RowsDuplicateInFile,
+		&i.Profile,
+		&i.Anomalies,
+		&i.SeqFirst,
+		&i.SeqLast,
+		&i.SeqGaps,
+		&i.Metadata,
+	)
+	return i, err
+}
+
 const updateFileProgress = `-- name: UpdateFileProgress :one
 UPDATE files
 SET
@@ -243,7 +1084,7 @@ SET
     rows_failed = $3,
     updated_at = CURRENT_TIMESTAMP
 WHERE id = $1
-RETURNING id, filename, file_hash, status, rows_processed, rows_failed, error_message, created_at, updated_at
+RETURNING id, filename, file_hash, status, rows_processed, rows_failed, error_message, created_at, updated_at, source, file_mtime, arrived_at, completed_at, conflict_policy, rows_skipped, rows_overwritten, rows_versioned, superseded_by, superseded_at, change_seq, trace_id, span_id, sample_rate, corrects_file_id, rows_corrected, correction_note, rows_duplicate_existing, rows_duplicate_in_file, profile, anomalies, seq_first, seq_last, seq_gaps, metadata
 `
 
 type UpdateFileProgressParams struct {
@@ -265,6 +1106,96 @@ func (q *Queries) UpdateFileProgress(ctx context.Context, arg UpdateFileProgress
 		&i.ErrorMessage,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.Source,
+		&i.FileMtime,
+		&i.ArrivedAt,
+		&i.CompletedAt,
+		&i.ConflictPolicy,
+		&i.RowsSkipped,
+		&i.RowsOverwritten,
+		&i.RowsVersioned,
+		&i.SupersededBy,
+		&i.SupersededAt,
+		&i.ChangeSeq,
+		&i.TraceID,
+		&i.SpanID,
+		&i.SampleRate,
+		&i.CorrectsFileID,
+		&i.RowsCorrected,
+		&i.CorrectionNote,
+		&i.RowsDuplicateExisting,
+		&i.RowsDuplicateInFile,
+		&i.Profile,
+		&i.Anomalies,
+		&i.SeqFirst,
+		&i.SeqLast,
+		&i.SeqGaps,
+		&i.Metadata,
+	)
+	return i, err
+}
+
+const updateFileSequence = `-- name: UpdateFileSequence :one
+UPDATE files
+SET
+    seq_first = $2,
+    seq_last = $3,
+    seq_gaps = $4,
+    updated_at = CURRENT_TIMESTAMP
+WHERE id = $1
+RETURNING id, filename, file_hash, status, rows_processed, rows_failed, error_message, created_at, updated_at, source, file_mtime, arrived_at, completed_at, conflict_policy, rows_skipped, rows_overwritten, rows_versioned, superseded_by, superseded_at, change_seq, trace_id, span_id, sample_rate, corrects_file_id, rows_corrected, correction_note, rows_duplicate_existing, rows_duplicate_in_file, profile, anomalies, seq_first, seq_last, seq_gaps, metadata
+`
+
+type UpdateFileSequenceParams struct {
+	ID       int64         `json:"id"`
+	SeqFirst sql.NullInt64 `json:"seq_first"`
+	SeqLast  sql.NullInt64 `json:"seq_last"`
+	SeqGaps  sql.NullInt32 `json:"seq_gaps"`
+}
+
+func (q *Queries) UpdateFileSequence(ctx context.Context, arg UpdateFileSequenceParams) (File, error) {
+	row := q.db.QueryRowContext(ctx, updateFileSequence,
+		arg.ID,
+		arg.SeqFirst,
+		arg.SeqLast,
+		arg.SeqGaps,
+	)
+	var i File
+	err := row.Scan(
+		&i.ID,
+		&i.Filename,
+		&i.FileHash,
+		&i.Status,
+		&i.RowsProcessed,
+		&i.RowsFailed,
+		&i.ErrorMessage,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Source,
+		&i.FileMtime,
+		&i.ArrivedAt,
+		&i.CompletedAt,
+		&i.ConflictPolicy,
+		&i.RowsSkipped,
+		&i.RowsOverwritten,
+		&i.RowsVersioned,
+		&i.SupersededBy,
+		&i.SupersededAt,
+		&i.ChangeSeq,
+		&i.TraceID,
+		&i.SpanID,
+		&i.SampleRate,
+		&i.CorrectsFileID,
+		&i.RowsCorrected,
+		&i.CorrectionNote,
+		&i.RowsDuplicateExisting,
+		&i.RowsDuplicateInFile,
+		&i.Profile,
+		&i.Anomalies,
+		&i.SeqFirst,
+		&i.SeqLast,
+		&i.SeqGaps,
+		&i.Metadata,
 	)
 	return i, err
 }
@@ -275,7 +1206,7 @@ SET
     status = $2,
     updated_at = CURRENT_TIMESTAMP
 WHERE id = $1
-RETURNING id, filename, file_hash, status, rows_processed, rows_failed, error_message, created_at, updated_at
+RETURNING id, filename, file_hash, status, rows_processed, rows_failed, error_message, created_at, updated_at, source, file_mtime, arrived_at, completed_at, conflict_policy, rows_skipped, rows_overwritten, rows_versioned, superseded_by, superseded_at, change_seq, trace_id, span_id, sample_rate, corrects_file_id, rows_corrected, correction_note, rows_duplicate_existing, rows_duplicate_in_file, profile, anomalies, seq_first, seq_last, seq_gaps, metadata
 `
 
 type UpdateFileStatusParams struct {
@@ -296,6 +1227,31 @@ func (q *Queries) UpdateFileStatus(ctx context.Context, arg UpdateFileStatusPara
 		&i.ErrorMessage,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.Source,
+		&i.FileMtime,
+		&i.ArrivedAt,
+		&i.CompletedAt,
+		&i.ConflictPolicy,
+		&i.RowsSkipped,
+		&i.RowsOverwritten,
+		&i.RowsVersioned,
+		&i.SupersededBy,
+		&i.SupersededAt,
+		&i.ChangeSeq,
+		&i.TraceID,
+		&i.SpanID,
+		&i.SampleRate,
+		&i.CorrectsFileID,
+		&i.RowsCorrected,
+		&i.CorrectionNote,
+		&i.RowsDuplicateExisting,
+		&i.RowsDuplicateInFile,
+		&i.Profile,
+		&i.Anomalies,
+		&i.SeqFirst,
+		&i.SeqLast,
+		&i.SeqGaps,
+		&i.Metadata,
 	)
 	return i, err
 }
@@ -307,7 +1263,7 @@ SET
     error_message = $3,
     updated_at = CURRENT_TIMESTAMP
 WHERE id = $1
-RETURNING id, filename, file_hash, status, rows_processed, rows_failed, error_message, created_at, updated_at
+RETURNING id, filename, file_hash, status, rows_processed, rows_failed, error_message, created_at, updated_at, source, file_mtime, arrived_at, completed_at, conflict_policy, rows_skipped, rows_overwritten, rows_versioned, superseded_by, superseded_at, change_seq, trace_id, span_id, sample_rate, corrects_file_id, rows_corrected, correction_note, rows_duplicate_existing, rows_duplicate_in_file, profile, anomalies, seq_first, seq_last, seq_gaps, metadata
 `
 
 type UpdateFileWithErrorParams struct {
@@ -329,6 +1285,31 @@ func (q *Queries) UpdateFileWithError(ctx context.Context, arg UpdateFileWithErr
 		&i.ErrorMessage,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.Source,
+		&i.FileMtime,
+		&i.ArrivedAt,
+		&i.CompletedAt,
+		&i.ConflictPolicy,
+		&i.RowsSkipped,
+		&i.RowsOverwritten,
+		&i.RowsVersioned,
+		&i.SupersededBy,
+		&i.SupersededAt,
+		&i.ChangeSeq,
+		&i.TraceID,
+		&i.SpanID,
+		&i.SampleRate,
+		&i.CorrectsFileID,
+		&i.RowsCorrected,
+		&i.CorrectionNote,
+		&i.RowsDuplicateExisting,
+		&i.RowsDuplicateInFile,
+		&i.Profile,
+		&i.Anomalies,
+		&i.SeqFirst,
+		&i.SeqLast,
+		&i.SeqGaps,
+		&i.Metadata,
 	)
 	return i, err
 }
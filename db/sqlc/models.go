@@ -6,6 +6,8 @@ package sqlc
 
 import (
 	"database/sql"
+	"encoding/json"
+	"time"
 
 	"github.com/google/uuid"
 )
@@ -17,6 +19,47 @@ type ApiLog struct {
 	ResponseTimeMs sql.NullInt32 `json:"response_time_ms"`
 	StatusCode     sql.NullInt32 `json:"status_code"`
 	CreatedAt      sql.NullTime  `json:"created_at"`
+	UpdatedAt      sql.NullTime  `json:"updated_at"`
+	ChangeSeq      int64         `json:"change_seq"`
+}
+
+type ArchivedPartition struct {
+	ID         int64        `json:"id"`
+	UnitGuid   uuid.UUID    `json:"unit_guid"`
+	Month      time.Time    `json:"month"`
+	ObjectKey  string       `json:"object_key"`
+	RowCount   int32        `json:"row_count"`
+	SizeBytes  int64        `json:"size_bytes"`
+	Checksum   string       `json:"checksum"`
+	MinID      int64        `json:"min_id"`
+	MaxID      int64        `json:"max_id"`
+	ArchivedAt sql.NullTime `json:"archived_at"`
+	UpdatedAt  sql.NullTime `json:"updated_at"`
+	ChangeSeq  int64        `json:"change_seq"`
+}
+
+type AuditLog struct {
+	ID        int64        `json:"id"`
+	Event     string       `json:"event"`
+	Subject   string       `json:"subject"`
+	Actor     string       `json:"actor"`
+	Payload   string       `json:"payload"`
+	PrevHash  string       `json:"prev_hash"`
+	Hash      string       `json:"hash"`
+	CreatedAt time.Time    `json:"created_at"`
+	UpdatedAt sql.NullTime `json:"updated_at"`
+	ChangeSeq int64        `json:"change_seq"`
+}
+
+type ClickhouseSync struct {
+	FileID     int64          `json:"file_id"`
+	QueuedAt   time.Time      `json:"queued_at"`
+	SyncedAt   sql.NullTime   `json:"synced_at"`
+	RowsSynced int32          `json:"rows_synced"`
+	Attempts   int32          `json:"attempts"`
+	LastError  sql.NullString `json:"last_error"`
+	UpdatedAt  sql.NullTime   `json:"updated_at"`
+	ChangeSeq  int64          `json:"change_seq"`
 }
 
 type DeviceDatum struct {
@@ -38,28 +81,179 @@ type DeviceDatum struct {
 	InvertBit  sql.NullBool   `json:"invert_bit"`
 	LineNumber int32          `json:"line_number"`
 	CreatedAt  sql.NullTime   `json:"created_at"`
+	Version    int32          `json:"version"`
+	UpdatedAt  sql.NullTime   `json:"updated_at"`
+	ChangeSeq  int64          `json:"change_seq"`
+}
+
+type FeatureFlag struct {
+	Name      string         `json:"name"`
+	Tenant    string         `json:"tenant"`
+	Enabled   bool           `json:"enabled"`
+	UpdatedBy sql.NullString `json:"updated_by"`
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt sql.NullTime   `json:"updated_at"`
+	ChangeSeq int64          `json:"change_seq"`
 }
 
 type File struct {
-	ID            int64          `json:"id"`
-	Filename      string         `json:"filename"`
-	FileHash      string         `json:"file_hash"`
-	Status        sql.NullString `json:"status"`
-	RowsProcessed sql.NullInt32  `json:"rows_processed"`
-	RowsFailed    sql.NullInt32  `json:"rows_failed"`
-	ErrorMessage  sql.NullString `json:"error_message"`
-	CreatedAt     sql.NullTime   `json:"created_at"`
-	UpdatedAt     sql.NullTime   `json:"updated_at"`
+	ID                    int64           `json:"id"`
+	Filename              string          `json:"filename"`
+	FileHash              string          `json:"file_hash"`
+	Status                sql.NullString  `json:"status"`
+	RowsProcessed         sql.NullInt32   `json:"rows_processed"`
+	RowsFailed            sql.NullInt32   `json:"rows_failed"`
+	ErrorMessage          sql.NullString  `json:"error_message"`
+	CreatedAt             sql.NullTime    `json:"created_at"`
+	UpdatedAt             sql.NullTime    `json:"updated_at"`
+	Source                string          `json:"source"`
+	FileMtime             sql.NullTime    `json:"file_mtime"`
+	ArrivedAt             sql.NullTime    `json:"arrived_at"`
+	CompletedAt           sql.NullTime    `json:"completed_at"`
+	ConflictPolicy        sql.NullString  `json:"conflict_policy"`
+	RowsSkipped           sql.NullInt32   `json:"rows_skipped"`
+	RowsOverwritten       sql.NullInt32   `json:"rows_overwritten"`
+	RowsVersioned         sql.NullInt32   `json:"rows_versioned"`
+	SupersededBy          sql.NullInt64   `json:"superseded_by"`
+	SupersededAt          sql.NullTime    `json:"superseded_at"`
+	ChangeSeq             int64           `json:"change_seq"`
+	TraceID               sql.NullString  `json:"trace_id"`
+	SpanID                sql.NullString  `json:"span_id"`
+	SampleRate            sql.NullFloat64 `json:"sample_rate"`
+	CorrectsFileID        sql.NullInt64   `json:"corrects_file_id"`
+	RowsCorrected         sql.NullInt32   `json:"rows_corrected"`
+	CorrectionNote        sql.NullString  `json:"correction_note"`
+	RowsDuplicateExisting sql.NullInt32   `json:"rows_duplicate_existing"`
+	RowsDuplicateInFile   sql.NullInt32   `json:"rows_duplicate_in_file"`
+	Profile               json.RawMessage `json:"profile"`
+	Anomalies             json.RawMessage `json:"anomalies"`
+	SeqFirst              sql.NullInt64   `json:"seq_first"`
+	SeqLast               sql.NullInt64   `json:"seq_last"`
+	SeqGaps               sql.NullInt32   `json:"seq_gaps"`
+	Metadata              json.RawMessage `json:"metadata"`
+}
+
+type FileDuplicate struct {
+	ID                int64          `json:"id"`
+	FileID            int64          `json:"file_id"`
+	LineNumber        int32          `json:"line_number"`
+	Kind              string         `json:"kind"`
+	UnitGuid          uuid.UUID      `json:"unit_guid"`
+	MsgID             sql.NullString `json:"msg_id"`
+	DuplicateOfFileID int64          `json:"duplicate_of_file_id"`
+	DuplicateOfLine   int32          `json:"duplicate_of_line"`
+	CreatedAt         sql.NullTime   `json:"created_at"`
+	UpdatedAt         sql.NullTime   `json:"updated_at"`
+	ChangeSeq         int64          `json:"change_seq"`
+}
+
+type FileEvent struct {
+	ID         int64        `json:"id"`
+	Filename   string       `json:"filename"`
+	Event      string       `json:"event"`
+	Detail     string       `json:"detail"`
+	OccurredAt time.Time    `json:"occurred_at"`
+	UpdatedAt  sql.NullTime `json:"updated_at"`
+	ChangeSeq  int64        `json:"change_seq"`
+}
+
+type FileProgress struct {
+	Filename      string       `json:"filename"`
+	FileID        int64        `json:"file_id"`
+	Stage         string       `json:"stage"`
+	RowsTotal     int32        `json:"rows_total"`
+	RowsProcessed int32        `json:"rows_processed"`
+	RowsFailed    int32        `json:"rows_failed"`
+	StartedAt     time.Time    `json:"started_at"`
+	CreatedAt     time.Time    `json:"created_at"`
+	UpdatedAt     sql.NullTime `json:"updated_at"`
+	ChangeSeq     int64        `json:"change_seq"`
+}
+
+type FileRetry struct {
+	Filename    string         `json:"filename"`
+	Attempts    int32          `json:"attempts"`
+	NextRetryAt time.Time      `json:"next_retry_at"`
+	LastError   sql.NullString `json:"last_error"`
+	CreatedAt   time.Time      `json:"created_at"`
+	UpdatedAt   sql.NullTime   `json:"updated_at"`
+	ChangeSeq   int64          `json:"change_seq"`
+}
+
+type FileIngestStat struct {
+	FileID      int64           `json:"file_id"`
+	Source      string          `json:"source"`
+	UnitGuid    uuid.NullUUID   `json:"unit_guid"`
+	Rows        int32           `json:"rows"`
+	ErrorRate   float64         `json:"error_rate"`
+	ClassShares json.RawMessage `json:"class_shares"`
+	CreatedAt   sql.NullTime    `json:"created_at"`
+	UpdatedAt   sql.NullTime    `json:"updated_at"`
+	ChangeSeq   int64           `json:"change_seq"`
+}
+
+type IdempotencyKey struct {
+	ID           int64        `json:"id"`
+	Key          string       `json:"key"`
+	Endpoint     string       `json:"endpoint"`
+	StatusCode   int32        `json:"status_code"`
+	ResponseBody string       `json:"response_body"`
+	CreatedAt    sql.NullTime `json:"created_at"`
+	ExpiresAt    time.Time    `json:"expires_at"`
+	UpdatedAt    sql.NullTime `json:"updated_at"`
+	ChangeSeq    int64        `json:"change_seq"`
+	Pending      bool         `json:"pending"`
+}
+
+type Job struct {
+	ID          int64          `json:"id"`
+	Kind        string         `json:"kind"`
+	Subject     string         `json:"subject"`
+	State       string         `json:"state"`
+	Priority    int32          `json:"priority"`
+	Owner       string         `json:"owner"`
+	Attempts    int32          `json:"attempts"`
+	MaxAttempts int32          `json:"max_attempts"`
+	LastError   sql.NullString `json:"last_error"`
+	CreatedAt   time.Time      `json:"created_at"`
+	StartedAt   sql.NullTime   `json:"started_at"`
+	FinishedAt  sql.NullTime   `json:"finished_at"`
+	UpdatedAt   sql.NullTime   `json:"updated_at"`
+	ChangeSeq   int64          `json:"change_seq"`
 }
 
 type ProcessingError struct {
-	ID           int64          `json:"id"`
-	FileID       int64          `json:"file_id"`
-	LineNumber   sql.NullInt32  `json:"line_number"`
-	RawLine      sql.NullString `json:"raw_line"`
-	ErrorMessage string         `json:"error_message"`
-	FieldName    sql.NullString `json:"field_name"`
-	CreatedAt    sql.NullTime   `json:"created_at"`
+	ID           int64           `json:"id"`
+	FileID       int64           `json:"file_id"`
+	LineNumber   sql.NullInt32   `json:"line_number"`
+	RawLine      sql.NullString  `json:"raw_line"`
+	ErrorMessage string          `json:"error_message"`
+	FieldName    sql.NullString  `json:"field_name"`
+	CreatedAt    sql.NullTime    `json:"created_at"`
+	UnitGuid     uuid.NullUUID   `json:"unit_guid"`
+	Partial      json.RawMessage `json:"partial"`
+	UpdatedAt    sql.NullTime    `json:"updated_at"`
+	ChangeSeq    int64           `json:"change_seq"`
+}
+
+type ReexportRequest struct {
+	ID              int64          `json:"id"`
+	UnitGuid        uuid.UUID      `json:"unit_guid"`
+	SeqFrom         int64          `json:"seq_from"`
+	SeqTo           int64          `json:"seq_to"`
+	AfterFile       string         `json:"after_file"`
+	BeforeFile      string         `json:"before_file"`
+	Status          string         `json:"status"`
+	Attempts        int32          `json:"attempts"`
+	LastError       sql.NullString `json:"last_error"`
+	ResponseStatus  sql.NullInt32  `json:"response_status"`
+	NextAttemptAt   time.Time      `json:"next_attempt_at"`
+	RequestedAt     sql.NullTime   `json:"requested_at"`
+	FulfilledAt     sql.NullTime   `json:"fulfilled_at"`
+	FulfilledFileID sql.NullInt64  `json:"fulfilled_file_id"`
+	CreatedAt       time.Time      `json:"created_at"`
+	UpdatedAt       sql.NullTime   `json:"updated_at"`
+	ChangeSeq       int64          `json:"change_seq"`
 }
 
 type Report struct {
@@ -68,4 +262,59 @@ type Report struct {
 	ReportType  sql.NullString `json:"report_type"`
 	FilePath    string         `json:"file_path"`
 	GeneratedAt sql.NullTime   `json:"generated_at"`
+	Checksum    sql.NullString `json:"checksum"`
+	ReportGroup uuid.NullUUID  `json:"report_group"`
+	Part        sql.NullInt32  `json:"part"`
+	UpdatedAt   sql.NullTime   `json:"updated_at"`
+	ChangeSeq   int64          `json:"change_seq"`
+	TraceID     sql.NullString `json:"trace_id"`
+	SpanID      sql.NullString `json:"span_id"`
+}
+
+type ReportDelivery struct {
+	ID             int64          `json:"id"`
+	SubscriptionID int64          `json:"subscription_id"`
+	ReportID       int64          `json:"report_id"`
+	Status         string         `json:"status"`
+	Attempts       int32          `json:"attempts"`
+	LastError      sql.NullString `json:"last_error"`
+	ResponseStatus sql.NullInt32  `json:"response_status"`
+	NextAttemptAt  time.Time      `json:"next_attempt_at"`
+	DeliveredAt    sql.NullTime   `json:"delivered_at"`
+	CreatedAt      sql.NullTime   `json:"created_at"`
+	UpdatedAt      sql.NullTime   `json:"updated_at"`
+	ChangeSeq      int64          `json:"change_seq"`
+}
+
+type ReportSubscription struct {
+	ID          int64         `json:"id"`
+	UnitGuid    uuid.NullUUID `json:"unit_guid"`
+	CallbackUrl string        `json:"callback_url"`
+	Secret      string        `json:"secret"`
+	Active      bool          `json:"active"`
+	CreatedAt   sql.NullTime  `json:"created_at"`
+	UpdatedAt   sql.NullTime  `json:"updated_at"`
+	ChangeSeq   int64         `json:"change_seq"`
+}
+
+type UnitDailySummary struct {
+	UnitGuid  uuid.UUID     `json:"unit_guid"`
+	Day       time.Time     `json:"day"`
+	Records   int32         `json:"records"`
+	Alarms    int32         `json:"alarms"`
+	MaxLevel  sql.NullInt32 `json:"max_level"`
+	UpdatedAt sql.NullTime  `json:"updated_at"`
+	ChangeSeq int64         `json:"change_seq"`
+}
+
+type UnitSequenceRun struct {
+	ID        int64        `json:"id"`
+	FileID    int64        `json:"file_id"`
+	UnitGuid  uuid.UUID    `json:"unit_guid"`
+	SeqFirst  int64        `json:"seq_first"`
+	SeqLast   int64        `json:"seq_last"`
+	Rows      int32        `json:"rows"`
+	CreatedAt time.Time    `json:"created_at"`
+	UpdatedAt sql.NullTime `json:"updated_at"`
+	ChangeSeq int64        `json:"change_seq"`
 }
@@ -8,26 +8,45 @@ package sqlc
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
+
+	"github.com/google/uuid"
 )
 
+const countProcessingErrorsByFile = `-- name: CountProcessingErrorsByFile :one
+SELECT COUNT(*) FROM processing_errors
+WHERE file_id = $1
+`
+
+func (q *Queries) CountProcessingErrorsByFile(ctx context.Context, fileID int64) (int64, error) {
+	row := q.db.QueryRowContext(ctx, countProcessingErrorsByFile, fileID)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
 const createProcessingError = `-- name: CreateProcessingError :one
 INSERT INTO processing_errors (
     file_id,
     line_number,
     raw_line,
     error_message,
-    field_name
+    field_name,
+    unit_guid,
+    partial
 ) VALUES (
-    $1, $2, $3, $4, $5
-) RETURNING id, file_id, line_number, raw_line, error_message, field_name, created_at
+    $1, $2, $3, $4, $5, $6, $7
+) RETURNING id, file_id, line_number, raw_line, error_message, field_name, created_at, unit_guid, partial, updated_at, change_seq
 `
 
 type CreateProcessingErrorParams struct {
-	FileID       int64          `json:"file_id"`
-	LineNumber   sql.NullInt32  `json:"line_number"`
-	RawLine      sql.NullString `json:"raw_line"`
-	ErrorMessage string         `json:"error_message"`
-	FieldName    sql.NullString `json:"field_name"`
+	FileID       int64           `json:"file_id"`
+	LineNumber   sql.NullInt32   `json:"line_number"`
+	RawLine      sql.NullString  `json:"raw_line"`
+	ErrorMessage string          `json:"error_message"`
+	FieldName    sql.NullString  `json:"field_name"`
+	UnitGuid     uuid.NullUUID   `json:"unit_guid"`
+	Partial      json.RawMessage `json:"partial"`
 }
 
 func (q *Queries) CreateProcessingError(ctx context.Context, arg CreateProcessingErrorParams) (ProcessingError, error) {
@@ -37,6 +56,8 @@ func (q *Queries) CreateProcessingError(ctx context.Context, arg CreateProcessin
 		arg.RawLine,
 		arg.ErrorMessage,
 		arg.FieldName,
+		arg.UnitGuid,
+		arg.Partial,
 	)
 	var i ProcessingError
 	err := row.Scan(
@@ -47,6 +68,10 @@ func (q *Queries) CreateProcessingError(ctx context.Context, arg CreateProcessin
 		&i.ErrorMessage,
 		&i.FieldName,
 		&i.CreatedAt,
+		&i.UnitGuid,
+		&i.Partial,
+		&i.UpdatedAt,
+		&i.ChangeSeq,
 	)
 	return i, err
 }
@@ -72,7 +97,7 @@ func (q *Queries) DeleteProcessingErrorsByFile(ctx context.Context, fileID int64
 }
 
 const getProcessingErrorByID = `-- name: GetProcessingErrorByID :one
-SELECT id, file_id, line_number, raw_line, error_message, field_name, created_at FROM processing_errors
+SELECT id, file_id, line_number, raw_line, error_message, field_name, created_at, unit_guid, partial, updated_at, change_seq FROM processing_errors
 WHERE id = $1 LIMIT 1
 `
 
@@ -87,12 +112,16 @@ func (q *Queries) GetProcessingErrorByID(ctx context.Context, id int64) (Process
 		&i.ErrorMessage,
 		&i.FieldName,
 		&i.CreatedAt,
+		&i.UnitGuid,
+		&i.Partial,
+		&i.UpdatedAt,
+		&i.ChangeSeq,
 	)
 	return i, err
 }
 
 const listProcessingErrorsByFile = `-- name: ListProcessingErrorsByFile :many
-SELECT id, file_id, line_number, raw_line, error_message, field_name, created_at FROM processing_errors
+SELECT id, file_id, line_number, raw_line, error_message, field_name, created_at, unit_guid, partial, updated_at, change_seq FROM processing_errors
 WHERE file_id = $1
 ORDER BY line_number
 `
@@ -114,6 +143,59 @@ func (q *Queries) ListProcessingErrorsByFile(ctx context.Context, fileID int64)
 			&i.ErrorMessage,
 			&i.FieldName,
 			&i.CreatedAt,
+			&i.UnitGuid,
+			&i.Partial,
+			&i.UpdatedAt,
+			&i.ChangeSeq,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listProcessingErrorsByFilePaged = `-- name: ListProcessingErrorsByFilePaged :many
+SELECT id, file_id, line_number, raw_line, error_message, field_name, created_at, unit_guid, partial, updated_at, change_seq FROM processing_errors
+WHERE file_id = $1
+ORDER BY line_number
+LIMIT $2
+OFFSET $3
+`
+
+type ListProcessingErrorsByFilePagedParams struct {
+	FileID int64 `json:"file_id"`
+	Limit  int32 `json:"limit"`
+	Offset int32 `json:"offset"`
+}
+
+func (q *Queries) ListProcessingErrorsByFilePaged(ctx context.Context, arg ListProcessingErrorsByFilePagedParams) ([]ProcessingError, error) {
+	rows, err := q.db.QueryContext(ctx, listProcessingErrorsByFilePaged, arg.FileID, arg.Limit, arg.Offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []ProcessingError{}
+	for rows.Next() {
+		var i ProcessingError
+		if err := rows.Scan(
+			&i.ID,
+			&i.FileID,
+			&i.LineNumber,
+			&i.RawLine,
+			&i.ErrorMessage,
+			&i.FieldName,
+			&i.CreatedAt,
+			&i.UnitGuid,
+			&i.Partial,
+			&i.UpdatedAt,
+			&i.ChangeSeq,
 		); err != nil {
 			return nil, err
 		}
@@ -184,7 +266,7 @@ SET
     error_message = $2,
     field_name = $3
 WHERE id = $1
-RETURNING id, file_id, line_number, raw_line, error_message, field_name, created_at
+RETURNING id, file_id, line_number, raw_line, error_message, field_name, created_at, unit_guid, partial, updated_at, change_seq
 `
 
 type UpdateProcessingErrorParams struct {
@@ -204,6 +286,10 @@ func (q *Queries) UpdateProcessingError(ctx context.Context, arg UpdateProcessin
 		&i.ErrorMessage,
 		&i.FieldName,
 		&i.CreatedAt,
+		&i.UnitGuid,
+		&i.Partial,
+		&i.UpdatedAt,
+		&i.ChangeSeq,
 	)
 	return i, err
 }
@@ -12,24 +12,55 @@ import (
 	"github.com/google/uuid"
 )
 
+const countReportsByUnit = `-- name: CountReportsByUnit :one
+SELECT COUNT(*) FROM reports
+WHERE unit_guid = $1
+`
+
+func (q *Queries) CountReportsByUnit(ctx context.Context, unitGuid uuid.UUID) (int64, error) {
+	row := q.db.QueryRowContext(ctx, countReportsByUnit, unitGuid)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
 const createReport = `-- name: CreateReport :one
 INSERT INTO reports (
     unit_guid,
     report_type,
-    file_path
+    file_path,
+    checksum,
+    report_group,
+    part,
+    trace_id,
+    span_id
 ) VALUES (
-    $1, $2, $3
-) RETURNING id, unit_guid, report_type, file_path, generated_at
+    $1, $2, $3, $4, $5, $6, $7, $8
+) RETURNING id, unit_guid, report_type, file_path, generated_at, checksum, report_group, part, updated_at, change_seq, trace_id, span_id
 `
 
 type CreateReportParams struct {
-	UnitGuid   uuid.UUID      `json:"unit_guid"`
-	ReportType sql.NullString `json:"report_type"`
-	FilePath   string         `json:"file_path"`
+	UnitGuid    uuid.UUID      `json:"unit_guid"`
+	ReportType  sql.NullString `json:"report_type"`
+	FilePath    string         `json:"file_path"`
+	Checksum    sql.NullString `json:"checksum"`
+	ReportGroup uuid.NullUUID  `json:"report_group"`
+	Part        sql.NullInt32  `json:"part"`
+	TraceID     sql.NullString `json:"trace_id"`
+	SpanID      sql.NullString `json:"span_id"`
 }
 
 func (q *Queries) CreateReport(ctx context.Context, arg CreateReportParams) (Report, error) {
-	row := q.db.QueryRowContext(ctx, createReport, arg.UnitGuid, arg.ReportType, arg.FilePath)
+	row := q.db.QueryRowContext(ctx, createReport,
+		arg.UnitGuid,
+		arg.ReportType,
+		arg.FilePath,
+		arg.Checksum,
+		arg.ReportGroup,
+		arg.Part,
+		arg.TraceID,
+		arg.SpanID,
+	)
 	var i Report
 	err := row.Scan(
 		&i.ID,
@@ -37,6 +68,13 @@ func (q *Queries) CreateReport(ctx context.Context, arg CreateReportParams) (Rep
 		&i.ReportType,
 		&i.FilePath,
 		&i.GeneratedAt,
+		&i.Checksum,
+		&i.ReportGroup,
+		&i.Part,
+		&i.UpdatedAt,
+		&i.ChangeSeq,
+		&i.TraceID,
+		&i.SpanID,
 	)
 	return i, err
 }
@@ -61,8 +99,35 @@ func (q *Queries) DeleteReport(ctx context.Context, id int64) error {
 	return err
 }
 
+const getReportByChecksum = `-- name: GetReportByChecksum :one
+SELECT id, unit_guid, report_type, file_path, generated_at, checksum, report_group, part, updated_at, change_seq, trace_id, span_id FROM reports
+WHERE checksum = $1
+ORDER BY generated_at DESC
+LIMIT 1
+`
+
+func (q *Queries) GetReportByChecksum(ctx context.Context, checksum sql.NullString) (Report, error) {
+	row := q.db.QueryRowContext(ctx, getReportByChecksum, checksum)
+	var i Report
+	err := row.Scan(
+		&i.ID,
+		&i.UnitGuid,
+		&i.ReportType,
+		&i.FilePath,
+		&i.GeneratedAt,
+		&i.Checksum,
+		&i.ReportGroup,
+		&i.Part,
+		&i.UpdatedAt,
+		&i.ChangeSeq,
+		&i.TraceID,
+		&i.SpanID,
+	)
+	return i, err
+}
+
 const getReportByID = `-- name: GetReportByID :one
-SELECT id, unit_guid, report_type, file_path, generated_at FROM reports
+SELECT id, unit_guid, report_type, file_path, generated_at, checksum, report_group, part, updated_at, change_seq, trace_id, span_id FROM reports
 WHERE id = $1 LIMIT 1
 `
 
@@ -75,12 +140,19 @@ func (q *Queries) GetReportByID(ctx context.Context, id int64) (Report, error) {
 		&i.ReportType,
 		&i.FilePath,
 		&i.GeneratedAt,
+		&i.Checksum,
+		&i.ReportGroup,
+		&i.Part,
+		&i.UpdatedAt,
+		&i.ChangeSeq,
+		&i.TraceID,
+		&i.SpanID,
 	)
 	return i, err
 }
 
 const getReportsByDateRange = `-- name: GetReportsByDateRange :many
-SELECT id, unit_guid, report_type, file_path, generated_at FROM reports
+SELECT id, unit_guid, report_type, file_path, generated_at, checksum, report_group, part, updated_at, change_seq, trace_id, span_id FROM reports
 WHERE generated_at BETWEEN $1 AND $2
 ORDER BY generated_at DESC
 `
@@ -105,6 +177,13 @@ func (q *Queries) GetReportsByDateRange(ctx context.Context, arg GetReportsByDat
 			&i.ReportType,
 			&i.FilePath,
 			&i.GeneratedAt,
+			&i.Checksum,
+			&i.ReportGroup,
+			&i.Part,
+			&i.UpdatedAt,
+			&i.ChangeSeq,
+			&i.TraceID,
+			&i.SpanID,
 		); err != nil {
 			return nil, err
 		}
@@ -120,7 +199,7 @@ func (q *Queries) GetReportsByDateRange(ctx context.Context, arg GetReportsByDat
 }
 
 const getReportsByUnit = `-- name: GetReportsByUnit :many
-SELECT id, unit_guid, report_type, file_path, generated_at FROM reports
+SELECT id, unit_guid, report_type, file_path, generated_at, checksum, report_group, part, updated_at, change_seq, trace_id, span_id FROM reports
 WHERE unit_guid = $1
 ORDER BY generated_at DESC
 `
@@ -140,6 +219,13 @@ func (q *Queries) GetReportsByUnit(ctx context.Context, unitGuid uuid.UUID) ([]R
 			&i.ReportType,
 			&i.FilePath,
 			&i.GeneratedAt,
+			&i.Checksum,
+			&i.ReportGroup,
+			&i.Part,
+			&i.UpdatedAt,
+			&i.ChangeSeq,
+			&i.TraceID,
+			&i.SpanID,
 		); err != nil {
 			return nil, err
 		}
@@ -155,7 +241,7 @@ func (q *Queries) GetReportsByUnit(ctx context.Context, unitGuid uuid.UUID) ([]R
 }
 
 const listRecentReports = `-- name: ListRecentReports :many
-SELECT id, unit_guid, report_type, file_path, generated_at FROM reports
+SELECT id, unit_guid, report_type, file_path, generated_at, checksum, report_group, part, updated_at, change_seq, trace_id, span_id FROM reports
 ORDER BY generated_at DESC
 LIMIT $1
 OFFSET $2
@@ -181,6 +267,105 @@ func (q *Queries) ListRecentReports(ctx context.Context, arg ListRecentReportsPa
 			&i.ReportType,
 			&i.FilePath,
 			&i.GeneratedAt,
+			&i.Checksum,
+			&i.ReportGroup,
+			&i.Part,
+			&i.UpdatedAt,
+			&i.ChangeSeq,
+			&i.TraceID,
+			&i.SpanID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listReportsByGroup = `-- name: ListReportsByGroup :many
+SELECT id, unit_guid, report_type, file_path, generated_at, checksum, report_group, part, updated_at, change_seq, trace_id, span_id FROM reports
+WHERE report_group = $1
+ORDER BY part
+`
+
+func (q *Queries) ListReportsByGroup(ctx context.Context, reportGroup uuid.NullUUID) ([]Report, error) {
+	rows, err := q.db.QueryContext(ctx, listReportsByGroup, reportGroup)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Report{}
+	for rows.Next() {
+		var i Report
+		if err := rows.Scan(
+			&i.ID,
+			&i.UnitGuid,
+			&i.ReportType,
+			&i.FilePath,
+			&i.GeneratedAt,
+			&i.Checksum,
+			&i.ReportGroup,
+			&i.Part,
+			&i.UpdatedAt,
+			&i.ChangeSeq,
+			&i.TraceID,
+			&i.SpanID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listReportsByUnit = `-- name: ListReportsByUnit :many
+SELECT id, unit_guid, report_type, file_path, generated_at, checksum, report_group, part, updated_at, change_seq, trace_id, span_id FROM reports
+WHERE unit_guid = $1
+ORDER BY generated_at DESC
+LIMIT $2
+OFFSET $3
+`
+
+type ListReportsByUnitParams struct {
+	UnitGuid uuid.UUID `json:"unit_guid"`
+	Limit    int32     `json:"limit"`
+	Offset   int32     `json:"offset"`
+}
+
+func (q *Queries) ListReportsByUnit(ctx context.Context, arg ListReportsByUnitParams) ([]Report, error) {
+	rows, err := q.db.QueryContext(ctx, listReportsByUnit, arg.UnitGuid, arg.Limit, arg.Offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Report{}
+	for rows.Next() {
+		var i Report
+		if err := rows.Scan(
+			&i.ID,
+			&i.UnitGuid,
+			&i.ReportType,
+			&i.FilePath,
+			&i.GeneratedAt,
+			&i.Checksum,
+			&i.ReportGroup,
+			&i.Part,
+			&i.UpdatedAt,
+			&i.ChangeSeq,
+			&i.TraceID,
+			&i.SpanID,
 		); err != nil {
 			return nil, err
 		}
@@ -200,7 +385,7 @@ UPDATE reports
 SET
     file_path = $2
 WHERE id = $1
-RETURNING id, unit_guid, report_type, file_path, generated_at
+RETURNING id, unit_guid, report_type, file_path, generated_at, checksum, report_group, part, updated_at, change_seq, trace_id, span_id
 `
 
 type UpdateReportPathParams struct {
@@ -217,6 +402,13 @@ func (q *Queries) UpdateReportPath(ctx context.Context, arg UpdateReportPathPara
 		&i.ReportType,
 		&i.FilePath,
 		&i.GeneratedAt,
+		&i.Checksum,
+		&i.ReportGroup,
+		&i.Part,
+		&i.UpdatedAt,
+		&i.ChangeSeq,
+		&i.TraceID,
+		&i.SpanID,
 	)
 	return i, err
 }
@@ -2,6 +2,8 @@ package handlers
 
 import (
 	"TSVProcessingService/db/sqlc"
+	"TSVProcessingService/internal/apilog"
+	"TSVProcessingService/internal/processor"
 	"context"
 	"database/sql"
 	"encoding/json"
@@ -16,6 +18,13 @@ type Handler struct {
 	queries *sqlc.Queries
 	db      *sql.DB
 	config  *Config
+	apiLogs *apilog.Writer
+	reports ReportQueue
+}
+
+// ReportQueue - система заданий на отчёт (processor.Processor)
+type ReportQueue interface {
+	EnqueueUnitReport(unitGuid uuid.UUID, opts processor.ReportOptions) (uuid.UUID, error)
 }
 
 type Config struct {
@@ -2,10 +2,10 @@ package handlers
 
 import (
 	"TSVProcessingService/db/sqlc"
+	"TSVProcessingService/internal/safepath"
 	"database/sql"
 	"net/http"
 	"os"
-	"path/filepath"
 
 	"github.com/gorilla/mux"
 )
@@ -14,7 +14,12 @@ func (h *Handler) ProcessFileHandler(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	filename := vars["filename"]
 
-	filePath := filepath.Join(h.config.InputDir, filename)
+	// Имя из URL не должно выводить за пределы входной директории
+	filePath, err := safepath.Join(h.config.InputDir, filename)
+	if err != nil {
+		h.respondWithError(w, http.StatusBadRequest, "Invalid filename")
+		return
+	}
 
 	if _, err := os.Stat(filePath); os.IsNotExist(err) {
 		h.respondWithError(w, http.StatusNotFound, "File not found")
@@ -22,7 +27,7 @@ func (h *Handler) ProcessFileHandler(w http.ResponseWriter, r *http.Request) {
 	}
 
 	ctx := r.Context()
-	_, err := h.queries.GetFileByFilename(ctx, filename)
+	_, err = h.queries.GetFileByFilename(ctx, filename)
 	if err == nil {
 		h.respondWithError(w, http.StatusConflict, "File already processed")
 		return
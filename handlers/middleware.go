@@ -1,9 +1,7 @@
 package handlers
 
 import (
-	"TSVProcessingService/db/sqlc"
-	"context"
-	"database/sql"
+	"TSVProcessingService/internal/apilog"
 	"net/http"
 	"time"
 
@@ -40,19 +38,24 @@ func (h *Handler) LoggingMiddleware(next http.Handler) http.Handler {
 			}
 		}
 
-		// Асинхронно логируем запрос
-		go func() {
-			ctx := context.Background()
-			h.queries.CreateApiLog(ctx, sqlc.CreateApiLogParams{
+		// Запись через ограниченную очередь: без горутины на каждый запрос
+		if h.apiLogs != nil {
+			h.apiLogs.Log(apilog.Entry{
 				Endpoint:       r.URL.Path,
 				UnitGuid:       unitGuid,
-				ResponseTimeMs: sql.NullInt32{Int32: int32(duration.Milliseconds()), Valid: true},
-				StatusCode:     sql.NullInt32{Int32: int32(rw.statusCode), Valid: true},
+				ResponseTimeMs: int32(duration.Milliseconds()),
+				StatusCode:     int32(rw.statusCode),
+				CreatedAt:      start,
 			})
-		}()
+		}
 	})
 }
 
+// SetAPILogWriter задаёт асинхронный writer журнала для LoggingMiddleware
+func (h *Handler) SetAPILogWriter(w *apilog.Writer) {
+	h.apiLogs = w
+}
+
 func (h *Handler) RecoveryMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		defer func() {
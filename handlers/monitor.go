@@ -2,7 +2,7 @@ package handlers
 
 import (
 	"context"
-	"log"
+	"log/slog"
 	"os"
 	"path/filepath"
 	"strings"
@@ -12,19 +12,19 @@ import (
 func (h *Handler) StartDirectoryMonitor() {
 	// Создаем директории при старте
 	if err := os.MkdirAll(h.config.InputDir, 0755); err != nil {
-		log.Printf("Failed to create input directory: %v", err)
+		slog.Error("Failed to create input directory", "error", err)
 		return
 	}
 
 	if err := os.MkdirAll(h.config.OutputDir, 0755); err != nil {
-		log.Printf("Failed to create output directory: %v", err)
+		slog.Error("Failed to create output directory", "error", err)
 		return
 	}
 
 	ticker := time.NewTicker(h.config.ScanInterval)
 	defer ticker.Stop()
 
-	log.Printf("Directory monitor started, scanning every %v", h.config.ScanInterval)
+	slog.Info("Directory monitor started", "scan_interval", h.config.ScanInterval)
 
 	for range ticker.C {
 		h.scanDirectory()
@@ -34,7 +34,7 @@ func (h *Handler) StartDirectoryMonitor() {
 func (h *Handler) scanDirectory() {
 	files, err := os.ReadDir(h.config.InputDir)
 	if err != nil {
-		log.Printf("Failed to read directory: %v", err)
+		slog.Error("Failed to read directory", "error", err)
 		return
 	}
 
@@ -57,6 +57,6 @@ func (h *Handler) scanDirectory() {
 		filePath := filepath.Join(h.config.InputDir, file.Name())
 		go h.processFile(file.Name(), filePath)
 
-		log.Printf("Started processing file: %s", file.Name())
+		slog.Info("Started processing file", "file", file.Name())
 	}
 }
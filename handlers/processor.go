@@ -2,177 +2,123 @@ package handlers
 
 import (
 	"TSVProcessingService/db/sqlc"
+	"TSVProcessingService/internal/filestatus"
+	"TSVProcessingService/internal/ingest"
 	"context"
 	"database/sql"
-	"encoding/csv"
-	"errors"
 	"fmt"
-	"io"
-	"os"
-	"strconv"
-	"strings"
-
-	"github.com/google/uuid"
+	"log/slog"
+	"time"
 )
 
 func (h *Handler) processFile(filename, filePath string) {
 	ctx := context.Background()
 
-	fileHash := h.calculateFileHash(filePath)
+	// Хеш, проверка и разбор - общие с воркерами (internal/ingest)
+	fileHash, err := ingest.HashFile(filePath)
+	if err != nil {
+		slog.Error("Failed to hash file", "file", filename, "error", err)
+		return
+	}
 	fileRecord, err := h.queries.CreateFile(ctx, sqlc.CreateFileParams{
 		Filename: filename,
 		FileHash: fileHash,
-		Status:   sql.NullString{String: "processing", Valid: true},
+		Status:   filestatus.Processing.NullString(),
+		Source:   "directory",
 	})
 	if err != nil {
-		fmt.Printf("Failed to create file record: %v\n", err)
+		slog.Error("Failed to create file record", "file", filename, "error", err)
 		return
 	}
 
-	file, err := os.Open(filePath)
-	if err != nil {
-		h.updateFileWithError(ctx, fileRecord.ID, "Failed to open file", err)
+	if err := ingest.Validate(filePath); err != nil {
+		h.updateFileWithError(ctx, fileRecord, "File rejected", err)
 		return
 	}
-	defer file.Close()
 
-	reader := csv.NewReader(file)
-	reader.Comma = '\t'
-	reader.LazyQuotes = true
-
-	if _, err := reader.Read(); err != nil {
-		h.updateFileWithError(ctx, fileRecord.ID, "Failed to read header", err)
-		return
+	rows, rowErrors := ingest.ParseFile(ctx, filePath)
+	for _, rowErr := range rowErrors {
+		h.queries.CreateProcessingError(ctx, rowErr.ProcessingErrorParams(fileRecord.ID))
 	}
 
-	var rowsProcessed int32 = 0
-	var rowsFailed int32 = 0
-	var deviceData []DeviceData
-	lineNumber := 1
+	rowsProcessed, rowsFailed := h.saveDeviceData(ctx, fileRecord.ID, rows)
 
-	for {
-		lineNumber++
-		record, err := reader.Read()
-		if err == io.EOF {
-			break
-		}
-		if err != nil {
-			rowsFailed++
-			h.createProcessingError(ctx, fileRecord.ID, lineNumber, strings.Join(record, "\t"), "Failed to parse line", "")
-			continue
-		}
-
-		data, parseErr := h.parseTSVRecord(record)
-		if parseErr != nil {
-			rowsFailed++
-			h.createProcessingError(ctx, fileRecord.ID, lineNumber, strings.Join(record, "\t"), parseErr.Error(), "")
-			continue
-		}
-
-		deviceData = append(deviceData, data)
-		rowsProcessed++
+	// Счётчики - приращением, как у воркеров (прогресс мог уже публиковаться)
+	if err := h.queries.IncrementFileProgress(ctx, sqlc.IncrementFileProgressParams{
+		ProcessedDelta: rowsProcessed,
+		FailedDelta:    rowsFailed,
+		ID:             fileRecord.ID,
+	}); err != nil {
+		slog.Error("Failed to update file progress", "file", filename, "error", err)
 	}
 
-	if err := h.saveDeviceData(ctx, fileRecord.ID, deviceData); err != nil {
-		h.updateFileWithError(ctx, fileRecord.ID, "Failed to save device data", err)
-		return
+	deviceData := make([]DeviceData, 0, len(rows))
+	for _, row := range rows {
+		deviceData = append(deviceData, deviceDataFromRow(row))
 	}
-
-	h.queries.UpdateFileProgress(ctx, sqlc.UpdateFileProgressParams{
-		ID:            fileRecord.ID,
-		RowsProcessed: sql.NullInt32{Int32: rowsProcessed, Valid: true},
-		RowsFailed:    sql.NullInt32{Int32: rowsFailed, Valid: true},
-	})
-
 	h.generateReports(ctx, fileRecord.ID, deviceData)
 
-	h.queries.UpdateFileStatus(ctx, sqlc.UpdateFileStatusParams{
-		ID:     fileRecord.ID,
-		Status: sql.NullString{String: "completed", Valid: true},
-	})
-}
-
-func (h *Handler) parseTSVRecord(record []string) (DeviceData, error) {
-	if len(record) < 14 {
-		return DeviceData{}, errors.New("invalid number of columns")
+	status := filestatus.Completed
+	if rowsProcessed == 0 {
+		status = filestatus.Failed
+	} else if rowsFailed > 0 {
+		status = filestatus.Partial
+	}
+	if err := filestatus.Transition(filestatus.Of(fileRecord.Status), status); err != nil {
+		slog.Error("Failed to complete file", "file", filename, "error", err)
+		return
+	}
+	if _, err := h.queries.CompleteFile(ctx, sqlc.CompleteFileParams{
+		ID:          fileRecord.ID,
+		Status:      status.NullString(),
+		CompletedAt: sql.NullTime{Time: time.Now(), Valid: true},
+	}); err != nil {
+		slog.Error("Failed to update file status", "file", filename, "error", err)
 	}
-
-	level, _ := strconv.Atoi(record[8])
-	bit, _ := strconv.Atoi(record[13])
-	invertBit := record[14] == "1" || strings.ToLower(record[14]) == "true"
-
-	return DeviceData{
-		Mqtt:      record[1],
-		Invid:     record[2],
-		UnitGuid:  record[3],
-		MsgID:     record[4],
-		Text:      record[5],
-		Context:   record[6],
-		Class:     record[7],
-		Level:     level,
-		Area:      record[9],
-		Addr:      record[10],
-		Block:     record[11],
-		Type:      record[12],
-		Bit:       bit,
-		InvertBit: invertBit,
-	}, nil
 }
 
-func (h *Handler) saveDeviceData(ctx context.Context, fileID int64, data []DeviceData) error {
-	for _, item := range data {
-		unitGuid, err := uuid.Parse(item.UnitGuid)
-		if err != nil {
+// saveDeviceData сохраняет строки и возвращает число сохранённых и неудачных
+func (h *Handler) saveDeviceData(ctx context.Context, fileID int64, rows []ingest.Row) (int32, int32) {
+	var processed, failed int32
+	for _, row := range rows {
+		if _, err := h.queries.CreateDeviceData(ctx, row.DeviceDataParams(fileID)); err != nil {
+			failed++
 			continue
 		}
-
-		_, err = h.queries.CreateDeviceData(ctx, sqlc.CreateDeviceDataParams{
-			FileID:     fileID,
-			UnitGuid:   unitGuid,
-			Mqtt:       sql.NullString{String: item.Mqtt, Valid: item.Mqtt != ""},
-			Invid:      sql.NullString{String: item.Invid, Valid: item.Invid != ""},
-			MsgID:      sql.NullString{String: item.MsgID, Valid: item.MsgID != ""},
-			Text:       sql.NullString{String: item.Text, Valid: item.Text != ""},
-			Context:    sql.NullString{String: item.Context, Valid: item.Context != ""},
-			Class:      sql.NullString{String: item.Class, Valid: item.Class != ""},
-			Level:      sql.NullInt32{Int32: int32(item.Level), Valid: true},
-			Area:       sql.NullString{String: item.Area, Valid: item.Area != ""},
-			Addr:       sql.NullString{String: item.Addr, Valid: item.Addr != ""},
-			Block:      sql.NullString{String: item.Block, Valid: item.Block != ""},
-			Type:       sql.NullString{String: item.Type, Valid: item.Type != ""},
-			Bit:        sql.NullInt32{Int32: int32(item.Bit), Valid: true},
-			InvertBit:  sql.NullBool{Bool: item.InvertBit, Valid: true},
-			LineNumber: 0,
-		})
-		if err != nil {
-			return err
-		}
+		processed++
 	}
-	return nil
+	return processed, failed
 }
 
-func (h *Handler) calculateFileHash(filePath string) string {
-	info, err := os.Stat(filePath)
-	if err != nil {
-		return ""
+func deviceDataFromRow(row ingest.Row) DeviceData {
+	return DeviceData{
+		Mqtt:      row.Mqtt.String,
+		Invid:     row.Invid.String,
+		UnitGuid:  row.UnitGuid.String(),
+		MsgID:     row.MsgID.String,
+		Text:      row.Text.String,
+		Context:   row.Context.String,
+		Class:     row.Class.String,
+		Level:     int(row.Level.Int32),
+		Area:      row.Area.String,
+		Addr:      row.Addr.String,
+		Block:     row.Block.String,
+		Type:      row.Type.String,
+		Bit:       int(row.Bit.Int32),
+		InvertBit: row.InvertBit.Bool,
 	}
-	return fmt.Sprintf("%d_%d", info.Size(), info.ModTime().Unix())
 }
 
-func (h *Handler) updateFileWithError(ctx context.Context, fileID int64, message string, err error) {
-	h.queries.UpdateFileWithError(ctx, sqlc.UpdateFileWithErrorParams{
-		ID:           fileID,
-		Status:       sql.NullString{String: "failed", Valid: true},
+func (h *Handler) updateFileWithError(ctx context.Context, file sqlc.File, message string, err error) {
+	if err := filestatus.Transition(filestatus.Of(file.Status), filestatus.Failed); err != nil {
+		slog.Error("Failed to mark file as failed", "file", file.Filename, "error", err)
+		return
+	}
+	if _, updateErr := h.queries.UpdateFileWithError(ctx, sqlc.UpdateFileWithErrorParams{
+		ID:           file.ID,
+		Status:       filestatus.Failed.NullString(),
 		ErrorMessage: sql.NullString{String: fmt.Sprintf("%s: %v", message, err), Valid: true},
-	})
-}
-
-func (h *Handler) createProcessingError(ctx context.Context, fileID int64, lineNumber int, rawLine, errorMessage, fieldName string) {
-	h.queries.CreateProcessingError(ctx, sqlc.CreateProcessingErrorParams{
-		FileID:       fileID,
-		LineNumber:   sql.NullInt32{Int32: int32(lineNumber), Valid: true},
-		RawLine:      sql.NullString{String: rawLine, Valid: rawLine != ""},
-		ErrorMessage: errorMessage,
-		FieldName:    sql.NullString{String: fieldName, Valid: fieldName != ""},
-	})
+	}); updateErr != nil {
+		slog.Error("Failed to update file status", "file", file.Filename, "error", updateErr)
+	}
 }
@@ -1,12 +1,15 @@
 package handlers
 
 import (
-	"TSVProcessingService/db/sqlc"
+	"TSVProcessingService/internal/processor"
+	"TSVProcessingService/internal/safepath"
 	"database/sql"
+	"mime"
 	"net/http"
 	"os"
 	"path/filepath"
 	"strconv"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/gorilla/mux"
@@ -53,19 +56,32 @@ func (h *Handler) DownloadReport(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Путь из БД не должен выводить за пределы директории отчётов
+	path, err := safepath.Within(report.FilePath, h.config.OutputDir)
+	if err != nil {
+		h.respondWithError(w, http.StatusForbidden, "Report file is outside the reports directory")
+		return
+	}
+
 	// Проверяем существование файла
-	if _, err := os.Stat(report.FilePath); os.IsNotExist(err) {
+	if _, err := os.Stat(path); os.IsNotExist(err) {
 		h.respondWithError(w, http.StatusNotFound, "Report file not found")
 		return
 	}
 
 	// Отправляем файл
-	filename := filepath.Base(report.FilePath)
-	w.Header().Set("Content-Disposition", "attachment; filename="+filename)
-	w.Header().Set("Content-Type", "application/pdf")
-	http.ServeFile(w, r, report.FilePath)
+	w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": filepath.Base(path)}))
+	// Content-Type определяется по расширению файла
+	http.ServeFile(w, r, path)
 }
 
+// SetReportQueue задаёт очередь заданий для GenerateReport
+func (h *Handler) SetReportQueue(q ReportQueue) {
+	h.reports = q
+}
+
+// GenerateReport ставит отчёт по всем данным устройства (с необязательным
+// фильтром class/from/to) в очередь заданий и сразу отвечает 202 с ID задания.
 func (h *Handler) GenerateReport(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	unitGuidStr := vars["unit_guid"]
@@ -75,65 +91,33 @@ func (h *Handler) GenerateReport(w http.ResponseWriter, r *http.Request) {
 		h.respondWithError(w, http.StatusBadRequest, "Invalid unit_guid format")
 		return
 	}
-
-	// Получаем данные устройства
-	ctx := r.Context()
-	page, limit := h.getPaginationParams(r)
-	offset := (page - 1) * limit
-
-	params := sqlc.ListDeviceDataByUnitParams{
-		UnitGuid: unitGuid,
-		Limit:    int32(limit),
-		Offset:   int32(offset),
-	}
-
-	data, err := h.queries.ListDeviceDataByUnit(ctx, params)
-	if err != nil {
-		h.respondWithError(w, http.StatusInternalServerError, "Failed to fetch device data")
+	if h.reports == nil {
+		h.respondWithError(w, http.StatusServiceUnavailable, "Report queue is not configured")
 		return
 	}
 
-	// Конвертируем в структуру DeviceData для генерации отчета
-	var deviceData []DeviceData
-	for _, d := range data {
-		deviceData = append(deviceData, DeviceData{
-			Mqtt:      d.Mqtt.String,
-			Invid:     d.Invid.String,
-			UnitGuid:  d.UnitGuid.String(),
-			MsgID:     d.MsgID.String,
-			Text:      d.Text.String,
-			Context:   d.Context.String,
-			Class:     d.Class.String,
-			Level:     int(d.Level.Int32),
-			Area:      d.Area.String,
-			Addr:      d.Addr.String,
-			Block:     d.Block.String,
-			Type:      d.Type.String,
-			Bit:       int(d.Bit.Int32),
-			InvertBit: d.InvertBit.Bool,
-		})
+	opts := processor.ReportOptions{Class: r.URL.Query().Get("class")}
+	if v := r.URL.Query().Get("from"); v != "" {
+		if opts.From, err = time.Parse(time.RFC3339, v); err != nil {
+			h.respondWithError(w, http.StatusBadRequest, "from must be RFC3339")
+			return
+		}
 	}
-
-	// Генерируем отчет
-	reportPath, err := h.createPDFReport(unitGuid, deviceData)
-	if err != nil {
-		h.respondWithError(w, http.StatusInternalServerError, "Failed to generate report")
-		return
+	if v := r.URL.Query().Get("to"); v != "" {
+		if opts.To, err = time.Parse(time.RFC3339, v); err != nil {
+			h.respondWithError(w, http.StatusBadRequest, "to must be RFC3339")
+			return
+		}
 	}
 
-	// Сохраняем информацию об отчете
-	report, err := h.queries.CreateReport(ctx, sqlc.CreateReportParams{
-		UnitGuid:   unitGuid,
-		ReportType: sql.NullString{String: "pdf", Valid: true},
-		FilePath:   reportPath,
-	})
+	jobID, err := h.reports.EnqueueUnitReport(unitGuid, opts)
 	if err != nil {
-		h.respondWithError(w, http.StatusInternalServerError, "Failed to save report record")
+		h.respondWithError(w, http.StatusServiceUnavailable, "Report queue is full")
 		return
 	}
 
-	h.respondWithJSON(w, http.StatusCreated, SuccessResponse{
-		Message: "Report generated successfully",
-		Data:    report,
+	h.respondWithJSON(w, http.StatusAccepted, SuccessResponse{
+		Message: "Report generation started",
+		Data:    map[string]string{"job_id": jobID.String(), "unit_guid": unitGuid.String()},
 	})
 }
@@ -0,0 +1,121 @@
+// internal/alert/alert.go
+package alert
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"time"
+)
+
+// Уровни важности оповещений
+const (
+	SeverityInfo     = "info"
+	SeverityWarning  = "warning"
+	SeverityCritical = "critical"
+)
+
+// Alert - оповещение о состоянии сервиса
+type Alert struct {
+	Source   string    `json:"source"` // подсистема: database, backlog, ...
+	Severity string    `json:"severity"`
+	Title    string    `json:"title"`
+	Message  string    `json:"message"`
+	Time     time.Time `json:"time"`
+}
+
+// Notifier - канал доставки оповещений
+type Notifier interface {
+	Name() string
+	Notify(ctx context.Context, a Alert) error
+}
+
+// Dispatcher рассылает оповещение во все зарегистрированные каналы.
+// Ошибка одного канала не мешает доставке в остальные.
+type Dispatcher struct {
+	notifiers  []Notifier
+	suppressed func() bool // оповещения подавлены (окно обслуживания), nil - никогда
+}
+
+// NewDispatcher создаёт диспетчер с указанными каналами
+func NewDispatcher(notifiers ...Notifier) *Dispatcher {
+	return &Dispatcher{notifiers: notifiers}
+}
+
+// Add регистрирует дополнительный канал
+func (d *Dispatcher) Add(n Notifier) {
+	d.notifiers = append(d.notifiers, n)
+}
+
+// SetSuppressed задаёт проверку подавления: пока она возвращает true,
+// оповещения только пишутся в лог
+func (d *Dispatcher) SetSuppressed(suppressed func() bool) {
+	d.suppressed = suppressed
+}
+
+// Send доставляет оповещение во все каналы
+func (d *Dispatcher) Send(ctx context.Context, a Alert) {
+	if a.Time.IsZero() {
+		a.Time = time.Now()
+	}
+	if d.suppressed != nil && d.suppressed() {
+		slog.Info("🔕 Alert suppressed by maintenance window", "severity", a.Severity, "source", a.Source, "title", a.Title)
+		return
+	}
+	for _, n := range d.notifiers {
+		if err := n.Notify(ctx, a); err != nil {
+			slog.Warn("⚠️ Alert channel failed", "channel", n.Name(), "error", err)
+		}
+	}
+}
+
+// LogNotifier - оповещения в лог сервиса
+type LogNotifier struct{}
+
+func (LogNotifier) Name() string { return "log" }
+
+func (LogNotifier) Notify(ctx context.Context, a Alert) error {
+	slog.Warn("🚨 Alert", "severity", a.Severity, "source", a.Source, "title", a.Title, "message", a.Message)
+	return nil
+}
+
+// WebhookNotifier - отправка оповещения JSON POST-запросом
+// (Slack-совместимые шлюзы, Alertmanager webhook receiver и т.п.)
+type WebhookNotifier struct {
+	url    string
+	client *http.Client
+}
+
+// NewWebhookNotifier создаёт webhook-канал
+func NewWebhookNotifier(url string, timeout time.Duration) *WebhookNotifier {
+	return &WebhookNotifier{url: url, client: &http.Client{Timeout: timeout}}
+}
+
+func (n *WebhookNotifier) Name() string { return "webhook" }
+
+func (n *WebhookNotifier) Notify(ctx context.Context, a Alert) error {
+	body, err := json.Marshal(a)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, n.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := n.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook returned status %d", resp.StatusCode)
+	}
+	return nil
+}
@@ -0,0 +1,63 @@
+package alert
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type recordingNotifier struct {
+	alerts []Alert
+	err    error
+}
+
+func (n *recordingNotifier) Name() string { return "recording" }
+
+func (n *recordingNotifier) Notify(ctx context.Context, a Alert) error {
+	n.alerts = append(n.alerts, a)
+	return n.err
+}
+
+func TestDispatcher_SendsToAllChannels(t *testing.T) {
+	failing := &recordingNotifier{err: errors.New("down")}
+	ok := &recordingNotifier{}
+	d := NewDispatcher(failing)
+	d.Add(ok)
+
+	d.Send(context.Background(), Alert{Source: "database", Severity: SeverityWarning, Title: "flapping"})
+
+	require.Len(t, failing.alerts, 1)
+	require.Len(t, ok.alerts, 1)
+	assert.False(t, ok.alerts[0].Time.IsZero())
+}
+
+func TestWebhookNotifier(t *testing.T) {
+	var received Alert
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&received))
+	}))
+	defer server.Close()
+
+	n := NewWebhookNotifier(server.URL, time.Second)
+	err := n.Notify(context.Background(), Alert{Source: "database", Title: "flapping"})
+	require.NoError(t, err)
+	assert.Equal(t, "flapping", received.Title)
+}
+
+func TestWebhookNotifier_ErrorStatus(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer server.Close()
+
+	n := NewWebhookNotifier(server.URL, time.Second)
+	assert.Error(t, n.Notify(context.Background(), Alert{}))
+}
@@ -0,0 +1,308 @@
+// internal/alert/incident.go
+package alert
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Действия с инцидентом во внешней системе дежурств
+const (
+	ActionTrigger     = "trigger"
+	ActionAcknowledge = "acknowledge"
+	ActionResolve     = "resolve"
+)
+
+// IncidentEvent - открытие/подтверждение/закрытие инцидента. DedupKey
+// одинаков для всех событий одного условия, поэтому повторное открытие
+// того же условия не создаёт новый инцидент у провайдера.
+type IncidentEvent struct {
+	Action   string    `json:"action"`
+	DedupKey string    `json:"dedup_key"`
+	Source   string    `json:"source"`
+	Severity string    `json:"severity"`
+	Summary  string    `json:"summary"`
+	Details  string    `json:"details,omitempty"`
+	Time     time.Time `json:"time"`
+}
+
+// IncidentNotifier - система дежурств (PagerDuty, Opsgenie)
+type IncidentNotifier interface {
+	Name() string
+	Incident(ctx context.Context, e IncidentEvent) error
+}
+
+// ---------------------------------------------------------------------
+// Провайдеры
+// ---------------------------------------------------------------------
+
+// PagerDutyNotifier - PagerDuty Events API v2
+type PagerDutyNotifier struct {
+	routingKey string
+	url        string
+	client     *http.Client
+}
+
+// NewPagerDutyNotifier создаёт канал PagerDuty (пустой url - облачный Events API)
+func NewPagerDutyNotifier(routingKey, url string, timeout time.Duration) *PagerDutyNotifier {
+	if url == "" {
+		url = "https://events.pagerduty.com/v2/enqueue"
+	}
+	return &PagerDutyNotifier{routingKey: routingKey, url: url, client: &http.Client{Timeout: timeout}}
+}
+
+func (n *PagerDutyNotifier) Name() string { return "pagerduty" }
+
+func (n *PagerDutyNotifier) Incident(ctx context.Context, e IncidentEvent) error {
+	body := map[string]interface{}{
+		"routing_key":  n.routingKey,
+		"event_action": e.Action,
+		"dedup_key":    e.DedupKey,
+	}
+	if e.Action == ActionTrigger {
+		// PagerDuty принимает critical/error/warning/info
+		body["payload"] = map[string]interface{}{
+			"summary":        e.Summary,
+			"source":         e.Source,
+			"severity":       e.Severity,
+			"timestamp":      e.Time.UTC().Format(time.RFC3339),
+			"custom_details": map[string]string{"details": e.Details},
+		}
+	}
+	return postJSON(ctx, n.client, n.url, nil, body)
+}
+
+// OpsgenieNotifier - Opsgenie Alert API (alias - ключ дедупликации)
+type OpsgenieNotifier struct {
+	apiKey string
+	url    string
+	client *http.Client
+}
+
+// NewOpsgenieNotifier создаёт канал Opsgenie (пустой url - api.opsgenie.com)
+func NewOpsgenieNotifier(apiKey, url string, timeout time.Duration) *OpsgenieNotifier {
+	if url == "" {
+		url = "https://api.opsgenie.com"
+	}
+	return &OpsgenieNotifier{apiKey: apiKey, url: strings.TrimRight(url, "/"), client: &http.Client{Timeout: timeout}}
+}
+
+func (n *OpsgenieNotifier) Name() string { return "opsgenie" }
+
+func (n *OpsgenieNotifier) Incident(ctx context.Context, e IncidentEvent) error {
+	headers := map[string]string{"Authorization": "GenieKey " + n.apiKey}
+	alias := url.PathEscape(e.DedupKey)
+
+	switch e.Action {
+	case ActionTrigger:
+		return postJSON(ctx, n.client, n.url+"/v2/alerts", headers, map[string]interface{}{
+			"message":     e.Summary,
+			"alias":       e.DedupKey,
+			"description": e.Details,
+			"source":      e.Source,
+			"priority":    opsgeniePriority(e.Severity),
+		})
+	case ActionAcknowledge:
+		return postJSON(ctx, n.client, n.url+"/v2/alerts/"+alias+"/acknowledge?identifierType=alias",
+			headers, map[string]interface{}{"source": e.Source})
+	case ActionResolve:
+		return postJSON(ctx, n.client, n.url+"/v2/alerts/"+alias+"/close?identifierType=alias",
+			headers, map[string]interface{}{"source": e.Source})
+	}
+	return fmt.Errorf("unknown incident action %q", e.Action)
+}
+
+// opsgeniePriority - приоритет Opsgenie по уровню важности
+func opsgeniePriority(severity string) string {
+	switch severity {
+	case SeverityCritical:
+		return "P1"
+	case SeverityWarning:
+		return "P3"
+	}
+	return "P5"
+}
+
+// postJSON отправляет JSON POST-запрос и проверяет статус ответа
+func postJSON(ctx context.Context, client *http.Client, url string, headers map[string]string, body interface{}) error {
+	data, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("%s returned status %d", req.URL.Host, resp.StatusCode)
+	}
+	return nil
+}
+
+// ---------------------------------------------------------------------
+// Устойчивые условия
+// ---------------------------------------------------------------------
+
+// Condition - условие, открывающее инцидент, если держится дольше For.
+// Инцидент закрывается, когда условие не выполняется дольше ResolveAfter:
+// кратковременные восстановления (флаппинг) не закрывают и не открывают
+// инцидент повторно.
+type Condition struct {
+	Key          string // часть ключа дедупликации: database-down, backlog, ...
+	Source       string
+	Severity     string
+	Title        string
+	For          time.Duration
+	ResolveAfter time.Duration
+}
+
+// Incident - открытый инцидент
+type Incident struct {
+	DedupKey     string    `json:"dedup_key"`
+	Source       string    `json:"source"`
+	Severity     string    `json:"severity"`
+	Title        string    `json:"title"`
+	Details      string    `json:"details"`
+	OpenedAt     time.Time `json:"opened_at"`
+	Acknowledged bool      `json:"acknowledged"`
+	Clearing     bool      `json:"clearing"` // условие уже не выполняется, ждём ResolveAfter
+}
+
+// conditionState - состояние одного условия
+type conditionState struct {
+	activeSince time.Time // начало текущего периода выполнения условия
+	clearSince  time.Time // начало восстановления открытого инцидента
+	incident    *Incident // nil - инцидент не открыт
+}
+
+// IncidentManager отслеживает устойчивые условия и открывает/закрывает
+// по ним инциденты во всех каналах дежурств.
+type IncidentManager struct {
+	prefix    string // префикс ключей дедупликации (имя сервиса/инсталляции)
+	notifiers []IncidentNotifier
+
+	mu     sync.Mutex
+	states map[string]*conditionState
+}
+
+// NewIncidentManager создаёт менеджер инцидентов
+func NewIncidentManager(prefix string, notifiers ...IncidentNotifier) *IncidentManager {
+	return &IncidentManager{prefix: prefix, notifiers: notifiers, states: make(map[string]*conditionState)}
+}
+
+// Observe учитывает очередную проверку условия.
+func (m *IncidentManager) Observe(ctx context.Context, c Condition, active bool, details string, now time.Time) {
+	dedupKey := m.prefix + ":" + c.Key
+
+	m.mu.Lock()
+	state, ok := m.states[dedupKey]
+	if !ok {
+		state = &conditionState{}
+		m.states[dedupKey] = state
+	}
+
+	var event *IncidentEvent
+	switch {
+	case active:
+		state.clearSince = time.Time{}
+		if state.activeSince.IsZero() {
+			state.activeSince = now
+		}
+		if state.incident != nil {
+			state.incident.Details = details
+			state.incident.Clearing = false
+		} else if now.Sub(state.activeSince) >= c.For {
+			state.incident = &Incident{
+				DedupKey: dedupKey, Source: c.Source, Severity: c.Severity,
+				Title: c.Title, Details: details, OpenedAt: now,
+			}
+			event = &IncidentEvent{Action: ActionTrigger, DedupKey: dedupKey, Source: c.Source,
+				Severity: c.Severity, Summary: c.Title, Details: details, Time: now}
+		}
+	case state.incident != nil:
+		if state.clearSince.IsZero() {
+			state.clearSince = now
+			state.incident.Clearing = true
+		}
+		if now.Sub(state.clearSince) >= c.ResolveAfter {
+			state.incident = nil
+			state.activeSince = time.Time{}
+			state.clearSince = time.Time{}
+			event = &IncidentEvent{Action: ActionResolve, DedupKey: dedupKey, Source: c.Source,
+				Severity: c.Severity, Summary: c.Title, Time: now}
+		}
+	default:
+		state.activeSince = time.Time{}
+	}
+	m.mu.Unlock()
+
+	if event != nil {
+		m.send(ctx, *event)
+	}
+}
+
+// Acknowledge подтверждает открытый инцидент (дежурный взял в работу).
+// Возвращает false, если инцидента с таким ключом нет.
+func (m *IncidentManager) Acknowledge(ctx context.Context, dedupKey string, now time.Time) bool {
+	m.mu.Lock()
+	state, ok := m.states[dedupKey]
+	if !ok || state.incident == nil {
+		m.mu.Unlock()
+		return false
+	}
+	incident := *state.incident
+	state.incident.Acknowledged = true
+	m.mu.Unlock()
+
+	if !incident.Acknowledged {
+		m.send(ctx, IncidentEvent{Action: ActionAcknowledge, DedupKey: dedupKey, Source: incident.Source,
+			Severity: incident.Severity, Summary: incident.Title, Time: now})
+	}
+	return true
+}
+
+// Open - открытые инциденты (по ключу дедупликации)
+func (m *IncidentManager) Open() []Incident {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	incidents := make([]Incident, 0)
+	for _, state := range m.states {
+		if state.incident != nil {
+			incidents = append(incidents, *state.incident)
+		}
+	}
+	sort.Slice(incidents, func(i, j int) bool { return incidents[i].DedupKey < incidents[j].DedupKey })
+	return incidents
+}
+
+// send доставляет событие во все каналы дежурств
+func (m *IncidentManager) send(ctx context.Context, e IncidentEvent) {
+	slog.Info("📟 Incident", "action", e.Action, "dedup_key", e.DedupKey, "summary", e.Summary)
+	for _, n := range m.notifiers {
+		if err := n.Incident(ctx, e); err != nil {
+			slog.Warn("⚠️ Incident channel failed", "channel", n.Name(), "error", err)
+		}
+	}
+}
@@ -0,0 +1,137 @@
+package alert
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type recordingIncidents struct {
+	events []IncidentEvent
+}
+
+func (n *recordingIncidents) Name() string { return "recording" }
+
+func (n *recordingIncidents) Incident(ctx context.Context, e IncidentEvent) error {
+	n.events = append(n.events, e)
+	return nil
+}
+
+func (n *recordingIncidents) actions() []string {
+	var actions []string
+	for _, e := range n.events {
+		actions = append(actions, e.Action)
+	}
+	return actions
+}
+
+var dbDown = Condition{Key: "database-down", Source: "database", Severity: SeverityCritical,
+	Title: "Database down", For: 5 * time.Minute, ResolveAfter: 2 * time.Minute}
+
+func TestIncidentManager_OpensAfterSustainedCondition(t *testing.T) {
+	rec := &recordingIncidents{}
+	m := NewIncidentManager("tsv", rec)
+	ctx := context.Background()
+	start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	for i := 0; i <= 4; i++ {
+		m.Observe(ctx, dbDown, true, "connection refused", start.Add(time.Duration(i)*time.Minute))
+	}
+	assert.Empty(t, rec.events)
+
+	m.Observe(ctx, dbDown, true, "connection refused", start.Add(5*time.Minute))
+	m.Observe(ctx, dbDown, true, "connection refused", start.Add(6*time.Minute))
+	require.Equal(t, []string{ActionTrigger}, rec.actions())
+	assert.Equal(t, "tsv:database-down", rec.events[0].DedupKey)
+	require.Len(t, m.Open(), 1)
+
+	m.Observe(ctx, dbDown, false, "", start.Add(7*time.Minute))
+	m.Observe(ctx, dbDown, false, "", start.Add(9*time.Minute))
+	assert.Equal(t, []string{ActionTrigger, ActionResolve}, rec.actions())
+	assert.Empty(t, m.Open())
+}
+
+func TestIncidentManager_FlappingDoesNotRepage(t *testing.T) {
+	rec := &recordingIncidents{}
+	m := NewIncidentManager("tsv", rec)
+	ctx := context.Background()
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	// Короткие сбои не открывают инцидент
+	for i := 0; i < 20; i++ {
+		m.Observe(ctx, dbDown, i%2 == 0, "", now)
+		now = now.Add(time.Minute)
+	}
+	assert.Empty(t, rec.events)
+
+	// Открытый инцидент не закрывается кратковременными восстановлениями
+	for i := 0; i <= 5; i++ {
+		m.Observe(ctx, dbDown, true, "", now)
+		now = now.Add(time.Minute)
+	}
+	for i := 0; i < 20; i++ {
+		m.Observe(ctx, dbDown, i%2 == 0, "", now)
+		now = now.Add(time.Minute)
+	}
+	assert.Equal(t, []string{ActionTrigger}, rec.actions())
+}
+
+func TestIncidentManager_Acknowledge(t *testing.T) {
+	rec := &recordingIncidents{}
+	m := NewIncidentManager("tsv", rec)
+	ctx := context.Background()
+	now := time.Now()
+
+	assert.False(t, m.Acknowledge(ctx, "tsv:database-down", now))
+
+	m.Observe(ctx, dbDown, true, "", now)
+	m.Observe(ctx, dbDown, true, "", now.Add(5*time.Minute))
+	assert.True(t, m.Acknowledge(ctx, "tsv:database-down", now))
+	assert.True(t, m.Acknowledge(ctx, "tsv:database-down", now))
+
+	assert.Equal(t, []string{ActionTrigger, ActionAcknowledge}, rec.actions())
+	assert.True(t, m.Open()[0].Acknowledged)
+}
+
+func TestPagerDutyNotifier(t *testing.T) {
+	var received map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&received))
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer server.Close()
+
+	n := NewPagerDutyNotifier("routing-key", server.URL, time.Second)
+	err := n.Incident(context.Background(), IncidentEvent{Action: ActionTrigger, DedupKey: "tsv:backlog",
+		Source: "backlog", Severity: SeverityWarning, Summary: "Backlog growing", Time: time.Now()})
+	require.NoError(t, err)
+
+	assert.Equal(t, "routing-key", received["routing_key"])
+	assert.Equal(t, "trigger", received["event_action"])
+	assert.Equal(t, "tsv:backlog", received["dedup_key"])
+	payload := received["payload"].(map[string]interface{})
+	assert.Equal(t, "Backlog growing", payload["summary"])
+}
+
+func TestOpsgenieNotifier_ClosesByAlias(t *testing.T) {
+	var path, query, auth string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path, query, auth = r.URL.EscapedPath(), r.URL.RawQuery, r.Header.Get("Authorization")
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer server.Close()
+
+	n := NewOpsgenieNotifier("api-key", server.URL, time.Second)
+	err := n.Incident(context.Background(), IncidentEvent{Action: ActionResolve, DedupKey: "tsv:backlog"})
+	require.NoError(t, err)
+
+	assert.Equal(t, "/v2/alerts/tsv:backlog/close", path)
+	assert.Equal(t, "identifierType=alias", query)
+	assert.Equal(t, "GenieKey api-key", auth)
+}
@@ -0,0 +1,307 @@
+// internal/anomaly/detector.go
+package anomaly
+
+import (
+	"TSVProcessingService/db/sqlc"
+	"TSVProcessingService/internal/alert"
+	"TSVProcessingService/internal/processor"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Показатели файла, сравниваемые с базовой линией
+const (
+	MetricRows      = "rows"
+	MetricErrorRate = "error_rate"
+	MetricClass     = "class_share" // доля строк class=<Class>
+)
+
+// Базовые линии
+const (
+	ScopeSource = "source"
+	ScopeUnit   = "unit"
+)
+
+// Options - настройки детектора
+type Options struct {
+	MinHistory int     // файлов в истории, меньше - сравнение не выполняется
+	Window     int     // последних файлов в базовой линии
+	Threshold  float64 // отклонение от среднего в стандартных отклонениях
+}
+
+// Sample - показатели одного файла
+type Sample struct {
+	Rows        float64
+	ErrorRate   float64
+	ClassShares map[string]float64
+}
+
+// Finding - показатель файла, вышедший за пределы базовой линии
+type Finding struct {
+	Scope  string  `json:"scope"` // source / unit
+	Key    string  `json:"key"`   // имя источника или unit_guid
+	Metric string  `json:"metric"`
+	Class  string  `json:"class,omitempty"` // для class_share
+	Value  float64 `json:"value"`
+	Mean   float64 `json:"mean"`
+	StdDev float64 `json:"stddev"`
+	Z      float64 `json:"z"`
+}
+
+// String - описание отклонения для оповещения
+func (f Finding) String() string {
+	metric := f.Metric
+	if f.Class != "" {
+		metric += "(" + f.Class + ")"
+	}
+	return fmt.Sprintf("%s %s: %s=%.4g (mean %.4g, stddev %.4g, z=%.1f)",
+		f.Scope, f.Key, metric, f.Value, f.Mean, f.StdDev, f.Z)
+}
+
+// Минимальные стандартные отклонения: стабильная история не должна
+// превращать любое малое изменение в аномалию
+const (
+	minRowsStdDevShare = 0.1  // доля среднего числа строк
+	minShareStdDev     = 0.01 // для долей (ошибки, class)
+)
+
+// Evaluate сравнивает показатели файла с историей и возвращает отклонения
+// больше threshold стандартных отклонений. Scope и Key заполняет вызывающий.
+func Evaluate(current Sample, history []Sample, threshold float64) []Finding {
+	var findings []Finding
+	check := func(metric, class string, value float64, values []float64, minStdDev float64) {
+		mean, stddev := meanStdDev(values)
+		stddev = math.Max(stddev, minStdDev)
+		if metric == MetricRows {
+			stddev = math.Max(stddev, math.Max(mean*minRowsStdDevShare, 1))
+		}
+		z := (value - mean) / stddev
+		if math.Abs(z) > threshold {
+			findings = append(findings, Finding{
+				Metric: metric, Class: class,
+				Value: value, Mean: mean, StdDev: stddev, Z: z,
+			})
+		}
+	}
+
+	rows := make([]float64, len(history))
+	errorRates := make([]float64, len(history))
+	classes := make(map[string]bool)
+	for class := range current.ClassShares {
+		classes[class] = true
+	}
+	for i, h := range history {
+		rows[i] = h.Rows
+		errorRates[i] = h.ErrorRate
+		for class := range h.ClassShares {
+			classes[class] = true
+		}
+	}
+	check(MetricRows, "", current.Rows, rows, 0)
+	check(MetricErrorRate, "", current.ErrorRate, errorRates, minShareStdDev)
+
+	// Порядок class фиксирован, чтобы список отклонений был стабильным
+	names := make([]string, 0, len(classes))
+	for class := range classes {
+		names = append(names, class)
+	}
+	sort.Strings(names)
+	for _, class := range names {
+		shares := make([]float64, len(history))
+		for i, h := range history {
+			shares[i] = h.ClassShares[class]
+		}
+		check(MetricClass, class, current.ClassShares[class], shares, minShareStdDev)
+	}
+	return findings
+}
+
+// meanStdDev - среднее и стандартное отклонение (по генеральной совокупности)
+func meanStdDev(values []float64) (float64, float64) {
+	if len(values) == 0 {
+		return 0, 0
+	}
+	var sum float64
+	for _, v := range values {
+		sum += v
+	}
+	mean := sum / float64(len(values))
+	var sq float64
+	for _, v := range values {
+		sq += (v - mean) * (v - mean)
+	}
+	return mean, math.Sqrt(sq / float64(len(values)))
+}
+
+// SampleOf - показатели обработанного файла
+func SampleOf(result processor.ProcessResult) Sample {
+	total := result.RowsProcessed + result.RowsFailed + result.ParseErrors
+	s := Sample{Rows: float64(total), ClassShares: make(map[string]float64)}
+	if total > 0 {
+		s.ErrorRate = float64(result.RowsFailed+result.ParseErrors) / float64(total)
+	}
+	var parsed int32
+	for _, n := range result.ClassCounts {
+		parsed += n
+	}
+	if parsed > 0 {
+		for class, n := range result.ClassCounts {
+			s.ClassShares[class] = float64(n) / float64(parsed)
+		}
+	}
+	return s
+}
+
+// Detector - post-processing hook: сравнивает файл с последними файлами
+// того же источника (и устройства, если файл содержит одно устройство),
+// сохраняет отклонения в files.anomalies и отправляет оповещение.
+type Detector struct {
+	queries *sqlc.Queries
+	alerts  *alert.Dispatcher
+	opts    Options
+	now     func() time.Time
+}
+
+// NewDetector создаёт детектор аномалий.
+func NewDetector(queries *sqlc.Queries, alerts *alert.Dispatcher, opts Options) *Detector {
+	if opts.MinHistory <= 1 {
+		opts.MinHistory = 10
+	}
+	if opts.Window < opts.MinHistory {
+		opts.Window = opts.MinHistory
+	}
+	if opts.Threshold <= 0 {
+		opts.Threshold = 3
+	}
+	return &Detector{queries: queries, alerts: alerts, opts: opts, now: time.Now}
+}
+
+// Name - имя post-processing hook
+func (d *Detector) Name() string {
+	return "anomaly"
+}
+
+// AfterProcess сравнивает файл с базовыми линиями и добавляет его в историю.
+func (d *Detector) AfterProcess(ctx context.Context, result processor.ProcessResult) error {
+	file := result.File
+	// Исправления и выборочная обработка не отражают обычный объём файла
+	if file.CorrectsFileID.Valid || file.SampleRate.Valid {
+		return nil
+	}
+
+	sample := SampleOf(result)
+	unit := uuid.NullUUID{}
+	if len(result.UnitGuids) == 1 {
+		unit = uuid.NullUUID{UUID: result.UnitGuids[0], Valid: true}
+	}
+
+	bySource, err := d.queries.ListRecentIngestStatsBySource(ctx, sqlc.ListRecentIngestStatsBySourceParams{
+		Source: file.Source,
+		FileID: file.ID,
+		Limit:  int32(d.opts.Window),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to load source baseline: %w", err)
+	}
+	findings := d.evaluate(ScopeSource, sourceKey(file.Source), sample, bySource)
+
+	if unit.Valid {
+		byUnit, err := d.queries.ListRecentIngestStatsByUnit(ctx, sqlc.ListRecentIngestStatsByUnitParams{
+			UnitGuid: unit,
+			FileID:   file.ID,
+			Limit:    int32(d.opts.Window),
+		})
+		if err != nil {
+			return fmt.Errorf("failed to load unit baseline: %w", err)
+		}
+		findings = append(findings, d.evaluate(ScopeUnit, unit.UUID.String(), sample, byUnit)...)
+	}
+
+	if len(findings) > 0 {
+		if err := d.flag(ctx, result, findings); err != nil {
+			return err
+		}
+	}
+
+	// Файл становится частью истории после сравнения, чтобы не сравниваться с собой
+	shares, err := json.Marshal(sample.ClassShares)
+	if err != nil {
+		return err
+	}
+	return d.queries.CreateFileIngestStats(ctx, sqlc.CreateFileIngestStatsParams{
+		FileID:      file.ID,
+		Source:      file.Source,
+		UnitGuid:    unit,
+		Rows:        int32(sample.Rows),
+		ErrorRate:   sample.ErrorRate,
+		ClassShares: shares,
+	})
+}
+
+// evaluate сравнивает показатели с одной базовой линией
+func (d *Detector) evaluate(scope, key string, sample Sample, stats []sqlc.FileIngestStat) []Finding {
+	if len(stats) < d.opts.MinHistory {
+		return nil
+	}
+	history := make([]Sample, 0, len(stats))
+	for _, st := range stats {
+		h := Sample{Rows: float64(st.Rows), ErrorRate: st.ErrorRate}
+		if err := json.Unmarshal(st.ClassShares, &h.ClassShares); err != nil {
+			slog.Warn("⚠️ Bad class shares", "component", "anomaly", "file_id", st.FileID, "error", err)
+			continue
+		}
+		history = append(history, h)
+	}
+	findings := Evaluate(sample, history, d.opts.Threshold)
+	for i := range findings {
+		findings[i].Scope = scope
+		findings[i].Key = key
+	}
+	return findings
+}
+
+// flag сохраняет отклонения в записи файла и отправляет оповещение
+func (d *Detector) flag(ctx context.Context, result processor.ProcessResult, findings []Finding) error {
+	data, err := json.Marshal(findings)
+	if err != nil {
+		return err
+	}
+	if _, err := d.queries.UpdateFileAnomalies(ctx, sqlc.UpdateFileAnomaliesParams{
+		ID:        result.File.ID,
+		Anomalies: data,
+	}); err != nil {
+		return fmt.Errorf("failed to save anomalies: %w", err)
+	}
+
+	lines := make([]string, len(findings))
+	for i, f := range findings {
+		lines[i] = f.String()
+	}
+	slog.Warn("🔍 File deviates from baseline", "component", "anomaly", "file", result.FileInfo.Name, "deviations", strings.Join(lines, "; "))
+	if d.alerts != nil {
+		d.alerts.Send(ctx, alert.Alert{
+			Source:   "ingestion",
+			Severity: alert.SeverityWarning,
+			Title:    "Anomalous file " + result.FileInfo.Name,
+			Message:  strings.Join(lines, "\n"),
+			Time:     d.now(),
+		})
+	}
+	return nil
+}
+
+// sourceKey - имя источника для оповещения (пустой источник - watch-директория)
+func sourceKey(source string) string {
+	if source == "" {
+		return "watch"
+	}
+	return source
+}
@@ -0,0 +1,191 @@
+package anomaly
+
+import (
+	"TSVProcessingService/db/sqlc"
+	"TSVProcessingService/internal/alert"
+	"TSVProcessingService/internal/processor"
+	"TSVProcessingService/internal/watcher"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	_ "modernc.org/sqlite"
+)
+
+type recordingNotifier struct {
+	alerts []alert.Alert
+}
+
+func (n *recordingNotifier) Name() string { return "recording" }
+
+func (n *recordingNotifier) Notify(ctx context.Context, a alert.Alert) error {
+	n.alerts = append(n.alerts, a)
+	return nil
+}
+
+func stableHistory(n int) []Sample {
+	history := make([]Sample, n)
+	for i := range history {
+		history[i] = Sample{
+			Rows:        float64(1000 + i%5*10),
+			ErrorRate:   0.01,
+			ClassShares: map[string]float64{"alarm": 0.2, "event": 0.8},
+		}
+	}
+	return history
+}
+
+func TestEvaluate_WithinBaseline(t *testing.T) {
+	current := Sample{Rows: 1030, ErrorRate: 0.015, ClassShares: map[string]float64{"alarm": 0.21, "event": 0.79}}
+	assert.Empty(t, Evaluate(current, stableHistory(20), 3))
+}
+
+func TestEvaluate_FlagsOutliers(t *testing.T) {
+	current := Sample{Rows: 150, ErrorRate: 0.3, ClassShares: map[string]float64{"alarm": 0.9, "event": 0.1}}
+	findings := Evaluate(current, stableHistory(20), 3)
+
+	require.Len(t, findings, 4)
+	assert.Equal(t, MetricRows, findings[0].Metric)
+	assert.Less(t, findings[0].Z, -3.0)
+	assert.Equal(t, MetricErrorRate, findings[1].Metric)
+	assert.Greater(t, findings[1].Z, 3.0)
+	assert.Equal(t, "alarm", findings[2].Class)
+	assert.Equal(t, "event", findings[3].Class)
+}
+
+func TestEvaluate_NewClass(t *testing.T) {
+	current := Sample{Rows: 1000, ErrorRate: 0.01,
+		ClassShares: map[string]float64{"alarm": 0.2, "event": 0.6, "warning": 0.2}}
+	findings := Evaluate(current, stableHistory(20), 3)
+
+	var classes []string
+	for _, f := range findings {
+		classes = append(classes, f.Class)
+	}
+	assert.Contains(t, classes, "warning")
+}
+
+func TestSampleOf(t *testing.T) {
+	s := SampleOf(processor.ProcessResult{
+		RowsProcessed: 90, RowsFailed: 5, ParseErrors: 5,
+		ClassCounts: map[string]int32{"alarm": 30, "event": 60},
+	})
+	assert.Equal(t, 100.0, s.Rows)
+	assert.InDelta(t, 0.1, s.ErrorRate, 1e-9)
+	assert.InDelta(t, 1.0/3, s.ClassShares["alarm"], 1e-9)
+}
+
+func setupDetectorDB(t *testing.T) *sql.DB {
+	db, err := sql.Open("sqlite", ":memory:")
+	require.NoError(t, err)
+	db.SetMaxOpenConns(1)
+	t.Cleanup(func() { db.Close() })
+
+	_, err = db.Exec(`
+	CREATE TABLE files (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		filename TEXT UNIQUE NOT NULL,
+		file_hash TEXT NOT NULL,
+		status TEXT DEFAULT 'pending',
+		rows_processed INTEGER DEFAULT 0,
+		rows_failed INTEGER DEFAULT 0,
+		error_message TEXT,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		source TEXT NOT NULL DEFAULT 'unknown',
+		file_mtime DATETIME,
+		arrived_at DATETIME,
+		completed_at DATETIME,
+		conflict_policy TEXT,
+		rows_skipped INTEGER DEFAULT 0,
+		rows_overwritten INTEGER DEFAULT 0,
+		rows_versioned INTEGER DEFAULT 0,
+		superseded_by INTEGER,
+		superseded_at DATETIME,
+		change_seq INTEGER NOT NULL DEFAULT 0,
+		trace_id TEXT,
+		span_id TEXT,
+		sample_rate REAL,
+		corrects_file_id INTEGER,
+		rows_corrected INTEGER DEFAULT 0,
+		correction_note TEXT,
+		rows_duplicate_existing INTEGER,
+		rows_duplicate_in_file INTEGER,
+		profile BLOB NOT NULL DEFAULT X'7B7D',
+		anomalies BLOB NOT NULL DEFAULT X'5B5D',
+		seq_first INTEGER,
+		seq_last INTEGER,
+		seq_gaps INTEGER,
+		metadata BLOB NOT NULL DEFAULT X'7B7D'
+	);
+	CREATE TABLE file_ingest_stats (
+		file_id INTEGER PRIMARY KEY,
+		source TEXT NOT NULL,
+		unit_guid TEXT,
+		rows INTEGER NOT NULL,
+		error_rate REAL NOT NULL,
+		class_shares BLOB NOT NULL DEFAULT X'7B7D',
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		change_seq INTEGER NOT NULL DEFAULT 0
+	);`)
+	require.NoError(t, err)
+	return db
+}
+
+func TestDetector_FlagsAnomalousFile(t *testing.T) {
+	db := setupDetectorDB(t)
+	queries := sqlc.New(db)
+	notifier := &recordingNotifier{}
+	d := NewDetector(queries, alert.NewDispatcher(notifier), Options{MinHistory: 5, Window: 20, Threshold: 3})
+	ctx := context.Background()
+	unit := uuid.New()
+
+	process := func(name string, rows, failed int32) sqlc.File {
+		file, err := queries.CreateFile(ctx, sqlc.CreateFileParams{Filename: name, FileHash: name, Status: sql.NullString{String: "completed", Valid: true}, Source: "sftp"})
+		require.NoError(t, err)
+		err = d.AfterProcess(ctx, processor.ProcessResult{
+			File:          file,
+			FileInfo:      watcher.FileInfo{Name: name},
+			Status:        "completed",
+			RowsProcessed: rows,
+			RowsFailed:    failed,
+			ClassCounts:   map[string]int32{"event": rows + failed},
+			UnitGuids:     []uuid.UUID{unit},
+		})
+		require.NoError(t, err)
+		file, err = queries.GetFileByID(ctx, file.ID)
+		require.NoError(t, err)
+		return file
+	}
+
+	for i, name := range []string{"a.tsv", "b.tsv", "c.tsv", "d.tsv", "e.tsv", "f.tsv"} {
+		file := process(name, int32(1000+i*10), 5)
+		assert.JSONEq(t, `[]`, string(file.Anomalies))
+	}
+	assert.Empty(t, notifier.alerts)
+
+	file := process("g.tsv", 40, 60)
+	var findings []Finding
+	require.NoError(t, json.Unmarshal(file.Anomalies, &findings))
+	scopes := map[string]bool{}
+	for _, f := range findings {
+		scopes[f.Scope] = true
+	}
+	assert.True(t, scopes[ScopeSource])
+	assert.True(t, scopes[ScopeUnit])
+
+	require.Len(t, notifier.alerts, 1)
+	assert.Equal(t, "ingestion", notifier.alerts[0].Source)
+	assert.Contains(t, notifier.alerts[0].Title, "g.tsv")
+
+	// Аномальный файл тоже попадает в историю
+	stats, err := queries.ListRecentIngestStatsBySource(ctx, sqlc.ListRecentIngestStatsBySourceParams{
+		Source: "sftp", FileID: 0, Limit: 100})
+	require.NoError(t, err)
+	assert.Len(t, stats, 7)
+}
@@ -0,0 +1,162 @@
+// internal/apilog/writer.go
+package apilog
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Entry - запись журнала API-запросов (таблица api_logs)
+type Entry struct {
+	Endpoint       string
+	UnitGuid       uuid.NullUUID
+	ResponseTimeMs int32
+	StatusCode     int32
+	CreatedAt      time.Time
+}
+
+// Inserter сохраняет пачку записей журнала
+type Inserter interface {
+	InsertApiLogs(ctx context.Context, entries []Entry) error
+}
+
+// Stats - счётчики асинхронной записи журнала
+type Stats struct {
+	Queued  int   `json:"queued"`
+	Written int64 `json:"written"`
+	Dropped int64 `json:"dropped"` // очередь была переполнена
+	Failed  int64 `json:"failed"`  // ошибка записи в БД
+}
+
+// Writer - асинхронная запись журнала API через ограниченную очередь.
+// Запросы никогда не ждут БД: при переполнении очереди запись
+// отбрасывается и учитывается в счётчике Dropped.
+type Writer struct {
+	inserter      Inserter
+	entries       chan Entry
+	batchSize     int
+	flushInterval time.Duration
+	timeout       time.Duration
+
+	written atomic.Int64
+	dropped atomic.Int64
+	failed  atomic.Int64
+
+	mu     sync.RWMutex
+	closed bool
+	done   chan struct{}
+}
+
+// NewWriter создаёт writer с очередью на queueSize записей. Записи
+// сохраняются пачками по batchSize или раз в flushInterval.
+func NewWriter(inserter Inserter, queueSize, batchSize int, flushInterval time.Duration) *Writer {
+	if batchSize < 1 {
+		batchSize = 1
+	}
+	return &Writer{
+		inserter:      inserter,
+		entries:       make(chan Entry, queueSize),
+		batchSize:     batchSize,
+		flushInterval: flushInterval,
+		timeout:       5 * time.Second,
+		done:          make(chan struct{}),
+	}
+}
+
+// Log ставит запись в очередь без блокировки
+func (w *Writer) Log(e Entry) bool {
+	if e.CreatedAt.IsZero() {
+		e.CreatedAt = time.Now()
+	}
+
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	if w.closed {
+		w.dropped.Add(1)
+		return false
+	}
+
+	select {
+	case w.entries <- e:
+		return true
+	default:
+		w.dropped.Add(1)
+		return false
+	}
+}
+
+// Run сохраняет записи из очереди пачками до вызова Close
+func (w *Writer) Run() {
+	defer close(w.done)
+
+	ticker := time.NewTicker(w.flushInterval)
+	defer ticker.Stop()
+
+	batch := make([]Entry, 0, w.batchSize)
+	for {
+		select {
+		case e, ok := <-w.entries:
+			if !ok {
+				w.flush(batch)
+				return
+			}
+			batch = append(batch, e)
+			if len(batch) >= w.batchSize {
+				w.flush(batch)
+				batch = batch[:0]
+			}
+		case <-ticker.C:
+			w.flush(batch)
+			batch = batch[:0]
+		}
+	}
+}
+
+// Close прекращает приём записей и дожидается сохранения оставшихся
+// (не дольше дедлайна ctx).
+func (w *Writer) Close(ctx context.Context) error {
+	w.mu.Lock()
+	if !w.closed {
+		w.closed = true
+		close(w.entries)
+	}
+	w.mu.Unlock()
+
+	select {
+	case <-w.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Stats возвращает текущие счётчики
+func (w *Writer) Stats() Stats {
+	return Stats{
+		Queued:  len(w.entries),
+		Written: w.written.Load(),
+		Dropped: w.dropped.Load(),
+		Failed:  w.failed.Load(),
+	}
+}
+
+func (w *Writer) flush(batch []Entry) {
+	if len(batch) == 0 {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), w.timeout)
+	defer cancel()
+
+	if err := w.inserter.InsertApiLogs(ctx, batch); err != nil {
+		w.failed.Add(int64(len(batch)))
+		slog.Warn("⚠️ Failed to write API log entries", "count", len(batch), "error", err)
+		return
+	}
+	w.written.Add(int64(len(batch)))
+}
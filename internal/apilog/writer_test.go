@@ -0,0 +1,63 @@
+package apilog
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type recordingInserter struct {
+	mu      sync.Mutex
+	batches [][]Entry
+	err     error
+}
+
+func (r *recordingInserter) InsertApiLogs(ctx context.Context, entries []Entry) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.batches = append(r.batches, append([]Entry{}, entries...))
+	return r.err
+}
+
+func TestWriter_FlushesInBatches(t *testing.T) {
+	inserter := &recordingInserter{}
+	w := NewWriter(inserter, 100, 2, time.Hour)
+	go w.Run()
+
+	for i := 0; i < 5; i++ {
+		assert.True(t, w.Log(Entry{Endpoint: "/api/v1/files", StatusCode: 200}))
+	}
+	require.NoError(t, w.Close(context.Background()))
+
+	require.Len(t, inserter.batches, 3)
+	assert.Len(t, inserter.batches[0], 2)
+	assert.Len(t, inserter.batches[2], 1)
+	assert.False(t, inserter.batches[0][0].CreatedAt.IsZero())
+	assert.EqualValues(t, 5, w.Stats().Written)
+}
+
+func TestWriter_DropsWhenSaturated(t *testing.T) {
+	w := NewWriter(&recordingInserter{}, 1, 10, time.Hour)
+
+	assert.True(t, w.Log(Entry{Endpoint: "/a"}))
+	assert.False(t, w.Log(Entry{Endpoint: "/b"}))
+	assert.EqualValues(t, 1, w.Stats().Dropped)
+	assert.Equal(t, 1, w.Stats().Queued)
+}
+
+func TestWriter_PeriodicFlushAndFailures(t *testing.T) {
+	inserter := &recordingInserter{err: errors.New("db down")}
+	w := NewWriter(inserter, 10, 100, 10*time.Millisecond)
+	go w.Run()
+
+	w.Log(Entry{Endpoint: "/a"})
+	assert.Eventually(t, func() bool { return w.Stats().Failed == 1 }, time.Second, 5*time.Millisecond)
+
+	require.NoError(t, w.Close(context.Background()))
+	assert.False(t, w.Log(Entry{Endpoint: "/after-close"}))
+}
@@ -0,0 +1,257 @@
+// internal/archive/archiver.go
+package archive
+
+import (
+	"TSVProcessingService/db/sqlc"
+	"TSVProcessingService/internal/jobs"
+	"bytes"
+	"context"
+	"database/sql"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Options - настройки архивации device_data
+type Options struct {
+	OlderThanMonths int           // архивируются полные месяцы старше N месяцев
+	Interval        time.Duration // период запуска
+	BatchRows       int           // строк в одном файле Parquet
+	Prefix          string        // префикс ключей в хранилище
+
+	// OnArchived вызывается после удаления данных устройства из БД
+	// (например, для сброса кэша)
+	OnArchived func(unitGuid uuid.UUID)
+
+	// Jobs - журнал заданий (nil - запуски не записываются)
+	Jobs *jobs.Manager
+
+	// Wait вызывается перед каждым запуском (например, ожидание окна
+	// обслуживания); nil - запуск сразу
+	Wait func(ctx context.Context) error
+}
+
+// Result - итог запуска архивации
+type Result struct {
+	Files int   `json:"files"`
+	Rows  int64 `json:"rows"`
+	Bytes int64 `json:"bytes"`
+}
+
+// Archiver выгружает старые записи device_data в Parquet (по месяцу
+// и устройству), проверяет выгруженный объект и только затем удаляет
+// строки из БД. Суточные сводки (unit_daily_summary) не удаляются.
+type Archiver struct {
+	db      *sql.DB
+	queries *sqlc.Queries
+	store   ObjectStore
+	opts    Options
+	ctx     context.Context // отменяется Stop
+	cancel  context.CancelFunc
+	now     func() time.Time
+}
+
+// NewArchiver создаёт архиватор.
+func NewArchiver(db *sql.DB, queries *sqlc.Queries, store ObjectStore, opts Options) *Archiver {
+	if opts.BatchRows <= 0 {
+		opts.BatchRows = 100000
+	}
+	if opts.Prefix == "" {
+		opts.Prefix = "device_data"
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Archiver{
+		db:      db,
+		queries: queries,
+		store:   store,
+		opts:    opts,
+		ctx:     ctx,
+		cancel:  cancel,
+		now:     time.Now,
+	}
+}
+
+// Run запускает архивацию сразу и затем каждые Interval до вызова Stop.
+func (a *Archiver) Run() {
+	ticker := time.NewTicker(a.opts.Interval)
+	defer ticker.Stop()
+	for {
+		if a.opts.Wait != nil {
+			if err := a.opts.Wait(a.ctx); err != nil {
+				slog.Info("Archiver stopped", "component", "archive")
+				return
+			}
+		}
+
+		var result Result
+		spec := jobs.Spec{Kind: jobs.KindArchive, Subject: a.Cutoff().Format("2006-01"), Owner: "archiver"}
+		err := a.opts.Jobs.Run(a.ctx, spec, func(ctx context.Context) (err error) {
+			result, err = a.Archive(ctx)
+			return err
+		})
+		if err != nil {
+			slog.Error("❌ Archival failed", "component", "archive", "error", err)
+		} else if result.Files > 0 {
+			slog.Info("🧊 Archived rows", "component", "archive", "rows", result.Rows, "files", result.Files, "bytes", result.Bytes)
+		}
+
+		select {
+		case <-ticker.C:
+		case <-a.ctx.Done():
+			slog.Info("Archiver stopped", "component", "archive")
+			return
+		}
+	}
+}
+
+// Stop останавливает Run (текущая выгрузка прерывается, удаление
+// строк выполняется только для проверенных файлов).
+func (a *Archiver) Stop() {
+	a.cancel()
+}
+
+// Cutoff - начало первого месяца, который ещё хранится в БД
+func (a *Archiver) Cutoff() time.Time {
+	now := a.now().UTC()
+	return time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC).AddDate(0, -a.opts.OlderThanMonths, 0)
+}
+
+// Archive выгружает все записи старше Cutoff
+func (a *Archiver) Archive(ctx context.Context) (Result, error) {
+	var result Result
+	cutoff := a.Cutoff()
+	units, err := a.queries.ListUnitsWithDataBefore(ctx, sql.NullTime{Time: cutoff, Valid: true})
+	if err != nil {
+		return result, fmt.Errorf("failed to list units: %w", err)
+	}
+
+	for _, unitGuid := range units {
+		oldest, err := a.queries.GetOldestDeviceDataTime(ctx, sqlc.GetOldestDeviceDataTimeParams{
+			UnitGuid:  unitGuid,
+			CreatedAt: sql.NullTime{Time: cutoff, Valid: true},
+		})
+		if err != nil {
+			return result, fmt.Errorf("failed to find oldest data of unit %s: %w", unitGuid, err)
+		}
+		t := oldest.Time.UTC()
+		for month := time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC); month.Before(cutoff); month = month.AddDate(0, 1, 0) {
+			if err := a.archivePartition(ctx, unitGuid, month, &result); err != nil {
+				return result, err
+			}
+		}
+		if a.opts.OnArchived != nil {
+			a.opts.OnArchived(unitGuid)
+		}
+	}
+	return result, nil
+}
+
+// archivePartition выгружает месяц month устройства файлами по BatchRows строк
+func (a *Archiver) archivePartition(ctx context.Context, unitGuid uuid.UUID, month time.Time, result *Result) error {
+	from := sql.NullTime{Time: month, Valid: true}
+	to := sql.NullTime{Time: month.AddDate(0, 1, 0), Valid: true}
+	afterID := int64(0)
+	for {
+		rows, err := a.queries.ListDeviceDataForArchive(ctx, sqlc.ListDeviceDataForArchiveParams{
+			UnitGuid:    unitGuid,
+			CreatedAt:   from,
+			CreatedAt_2: to,
+			ID:          afterID,
+			Limit:       int32(a.opts.BatchRows),
+		})
+		if err != nil {
+			return fmt.Errorf("failed to read data of unit %s: %w", unitGuid, err)
+		}
+		if len(rows) == 0 {
+			return nil
+		}
+		minID, maxID := rows[0].ID, rows[len(rows)-1].ID
+		key := ObjectKey(a.opts.Prefix, month, unitGuid, minID)
+
+		data := EncodeParquet(rows)
+		if err := a.store.Put(ctx, key, data); err != nil {
+			return fmt.Errorf("failed to upload %s: %w", key, err)
+		}
+		if err := a.verify(ctx, key, data, rows); err != nil {
+			return fmt.Errorf("verification of %s failed: %w", key, err)
+		}
+		if err := a.commit(ctx, sqlc.CreateArchivedPartitionParams{
+			UnitGuid:  unitGuid,
+			Month:     month,
+			ObjectKey: key,
+			RowCount:  int32(len(rows)),
+			SizeBytes: int64(len(data)),
+			Checksum:  sha256Hex(data),
+			MinID:     minID,
+			MaxID:     maxID,
+		}, from, to); err != nil {
+			return err
+		}
+
+		result.Files++
+		result.Rows += int64(len(rows))
+		result.Bytes += int64(len(data))
+		afterID = maxID
+	}
+}
+
+// verify - объект в хранилище совпадает с выгрузкой и читается обратно
+func (a *Archiver) verify(ctx context.Context, key string, data []byte, rows []sqlc.DeviceDatum) error {
+	stored, err := a.store.Get(ctx, key)
+	if err != nil {
+		return err
+	}
+	if !bytes.Equal(stored, data) {
+		return fmt.Errorf("stored object differs (%d bytes, expected %d)", len(stored), len(data))
+	}
+	decoded, err := DecodeParquet(stored)
+	if err != nil {
+		return err
+	}
+	if len(decoded) != len(rows) {
+		return fmt.Errorf("stored object has %d rows, expected %d", len(decoded), len(rows))
+	}
+	for i := range rows {
+		if decoded[i].ID != rows[i].ID {
+			return fmt.Errorf("row %d: id %d, expected %d", i, decoded[i].ID, rows[i].ID)
+		}
+	}
+	return nil
+}
+
+// commit регистрирует файл архива и удаляет выгруженные строки
+// в одной транзакции
+func (a *Archiver) commit(ctx context.Context, part sqlc.CreateArchivedPartitionParams, from, to sql.NullTime) error {
+	tx, err := a.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+	qtx := a.queries.WithTx(tx)
+
+	if _, err := qtx.CreateArchivedPartition(ctx, part); err != nil {
+		return fmt.Errorf("failed to register %s: %w", part.ObjectKey, err)
+	}
+	deleted, err := qtx.DeleteArchivedDeviceData(ctx, sqlc.DeleteArchivedDeviceDataParams{
+		UnitGuid:    part.UnitGuid,
+		CreatedAt:   from,
+		CreatedAt_2: to,
+		ID:          part.MinID,
+		ID_2:        part.MaxID,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to delete archived rows: %w", err)
+	}
+	if deleted != int64(part.RowCount) {
+		return fmt.Errorf("%s: deleted %d rows, archived %d", part.ObjectKey, deleted, part.RowCount)
+	}
+	return tx.Commit()
+}
+
+// ObjectKey - ключ файла архива: <prefix>/month=YYYY-MM/unit_guid=<guid>/part-<min id>.parquet
+// (разбиение в стиле Hive понимают DuckDB, Spark, Trino и т.п.)
+func ObjectKey(prefix string, month time.Time, unitGuid uuid.UUID, minID int64) string {
+	return fmt.Sprintf("%s/month=%s/unit_guid=%s/part-%d.parquet", prefix, month.Format("2006-01"), unitGuid, minID)
+}
@@ -0,0 +1,148 @@
+package archive
+
+import (
+	"TSVProcessingService/db/sqlc"
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	_ "modernc.org/sqlite"
+)
+
+func setupArchiveDB(t *testing.T) *sql.DB {
+	db, err := sql.Open("sqlite", ":memory:")
+	require.NoError(t, err)
+	db.SetMaxOpenConns(1)
+	t.Cleanup(func() { db.Close() })
+
+	_, err = db.Exec(`
+	CREATE TABLE device_data (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		file_id INTEGER NOT NULL,
+		unit_guid TEXT NOT NULL,
+		mqtt TEXT,
+		invid TEXT,
+		msg_id TEXT,
+		text TEXT,
+		context TEXT,
+		class TEXT,
+		level INTEGER,
+		area TEXT,
+		addr TEXT,
+		block TEXT,
+		type TEXT,
+		bit INTEGER,
+		invert_bit INTEGER DEFAULT 0,
+		line_number INTEGER NOT NULL,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		version INTEGER NOT NULL DEFAULT 1,
+		updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		change_seq INTEGER NOT NULL DEFAULT 0
+	);
+	CREATE TABLE archived_partitions (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		unit_guid TEXT NOT NULL,
+		month DATE NOT NULL,
+		object_key TEXT UNIQUE NOT NULL,
+		row_count INTEGER NOT NULL,
+		size_bytes INTEGER NOT NULL,
+		checksum TEXT NOT NULL,
+		min_id INTEGER NOT NULL,
+		max_id INTEGER NOT NULL,
+		archived_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		change_seq INTEGER NOT NULL DEFAULT 0
+	);`)
+	require.NoError(t, err)
+	return db
+}
+
+func TestArchiver_ArchivesOldMonths(t *testing.T) {
+	db := setupArchiveDB(t)
+	ctx := context.Background()
+	guid := uuid.MustParse("01749246-95f6-57db-b7c3-2ae0e8be671f")
+
+	insert := func(createdAt time.Time, msgID string) {
+		_, err := db.Exec(`INSERT INTO device_data (file_id, unit_guid, msg_id, line_number, created_at)
+			VALUES (1, ?, ?, 1, ?)`, guid, msgID, createdAt)
+		require.NoError(t, err)
+	}
+	insert(time.Date(2025, 1, 5, 10, 0, 0, 0, time.UTC), "jan_1")
+	insert(time.Date(2025, 1, 20, 10, 0, 0, 0, time.UTC), "jan_2")
+	insert(time.Date(2025, 1, 31, 23, 0, 0, 0, time.UTC), "jan_3")
+	insert(time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC), "mar_1")
+	insert(time.Date(2025, 6, 15, 0, 0, 0, 0, time.UTC), "jun_1") // младше порога
+
+	store := NewDirStore(t.TempDir())
+	var archivedUnits []uuid.UUID
+	archiver := NewArchiver(db, sqlc.New(db), store, Options{
+		OlderThanMonths: 3,
+		BatchRows:       2,
+		OnArchived:      func(u uuid.UUID) { archivedUnits = append(archivedUnits, u) },
+	})
+	archiver.now = func() time.Time { return time.Date(2025, 7, 10, 0, 0, 0, 0, time.UTC) }
+	assert.Equal(t, time.Date(2025, 4, 1, 0, 0, 0, 0, time.UTC), archiver.Cutoff())
+
+	result, err := archiver.Archive(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 3, result.Files) // январь - два файла по 2 и 1 строке, март - один
+	assert.Equal(t, int64(4), result.Rows)
+	assert.Equal(t, []uuid.UUID{guid}, archivedUnits)
+
+	var left []string
+	rows, err := db.Query(`SELECT msg_id FROM device_data`)
+	require.NoError(t, err)
+	for rows.Next() {
+		var s string
+		require.NoError(t, rows.Scan(&s))
+		left = append(left, s)
+	}
+	rows.Close()
+	assert.Equal(t, []string{"jun_1"}, left)
+
+	keys, err := store.List(ctx, "device_data/month=2025-01/")
+	require.NoError(t, err)
+	require.Len(t, keys, 2)
+	data, err := store.Get(ctx, keys[0])
+	require.NoError(t, err)
+	archived, err := DecodeParquet(data)
+	require.NoError(t, err)
+	require.Len(t, archived, 2)
+	assert.Equal(t, "jan_1", archived[0].MsgID.String)
+	assert.Equal(t, guid, archived[0].UnitGuid)
+
+	var partitions int
+	require.NoError(t, db.QueryRow(`SELECT COUNT(*) FROM archived_partitions`).Scan(&partitions))
+	assert.Equal(t, 3, partitions)
+
+	// Повторный запуск ничего не выгружает
+	result, err = archiver.Archive(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 0, result.Files)
+}
+
+// brokenStore теряет загруженные объекты
+type brokenStore struct{ DirStore }
+
+func (s *brokenStore) Put(ctx context.Context, key string, data []byte) error { return nil }
+
+func TestArchiver_KeepsRowsWhenVerificationFails(t *testing.T) {
+	db := setupArchiveDB(t)
+	_, err := db.Exec(`INSERT INTO device_data (file_id, unit_guid, line_number, created_at) VALUES (1, ?, 1, ?)`,
+		uuid.New(), time.Date(2025, 1, 5, 0, 0, 0, 0, time.UTC))
+	require.NoError(t, err)
+
+	archiver := NewArchiver(db, sqlc.New(db), &brokenStore{DirStore{root: t.TempDir()}}, Options{OlderThanMonths: 1})
+	archiver.now = func() time.Time { return time.Date(2025, 7, 10, 0, 0, 0, 0, time.UTC) }
+
+	_, err = archiver.Archive(context.Background())
+	assert.Error(t, err)
+
+	var count int
+	require.NoError(t, db.QueryRow(`SELECT COUNT(*) FROM device_data`).Scan(&count))
+	assert.Equal(t, 1, count)
+}
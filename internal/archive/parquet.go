@@ -0,0 +1,495 @@
+// internal/archive/parquet.go
+package archive
+
+import (
+	"TSVProcessingService/db/sqlc"
+	"bytes"
+	"database/sql"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Минимальный кодек Parquet для архива device_data: одна группа строк,
+// по одной странице данных (v1) на колонку, кодировка PLAIN без сжатия,
+// уровни определения - RLE. Этого достаточно для чтения файлов DuckDB,
+// Spark, ClickHouse и т.п.; читатель поддерживает только такие файлы.
+
+const parquetMagic = "PAR1"
+
+// Физические типы Parquet
+const (
+	ptBoolean   = 0
+	ptInt32     = 1
+	ptInt64     = 2
+	ptByteArray = 6
+)
+
+// Converted types Parquet
+const (
+	convUTF8            = 0
+	convTimestampMicros = 10
+)
+
+const (
+	encodingPlain = 0
+	encodingRLE   = 3
+
+	pageTypeData = 0
+)
+
+// column - колонка device_data в файле архива
+type column struct {
+	name      string
+	typ       int32
+	converted int32 // -1 - без converted type
+	optional  bool
+	get       func(d *sqlc.DeviceDatum) interface{}          // nil - NULL
+	set       func(d *sqlc.DeviceDatum, v interface{}) error // v == nil - NULL
+}
+
+func stringColumn(name string, field func(d *sqlc.DeviceDatum) *sql.NullString) column {
+	return column{
+		name: name, typ: ptByteArray, converted: convUTF8, optional: true,
+		get: func(d *sqlc.DeviceDatum) interface{} {
+			if v := field(d); v.Valid {
+				return []byte(v.String)
+			}
+			return nil
+		},
+		set: func(d *sqlc.DeviceDatum, v interface{}) error {
+			if v != nil {
+				*field(d) = sql.NullString{String: string(v.([]byte)), Valid: true}
+			}
+			return nil
+		},
+	}
+}
+
+func int32Column(name string, field func(d *sqlc.DeviceDatum) *sql.NullInt32) column {
+	return column{
+		name: name, typ: ptInt32, converted: -1, optional: true,
+		get: func(d *sqlc.DeviceDatum) interface{} {
+			if v := field(d); v.Valid {
+				return v.Int32
+			}
+			return nil
+		},
+		set: func(d *sqlc.DeviceDatum, v interface{}) error {
+			if v != nil {
+				*field(d) = sql.NullInt32{Int32: v.(int32), Valid: true}
+			}
+			return nil
+		},
+	}
+}
+
+// timeColumn - метка времени с точностью до микросекунд (UTC)
+func timeColumn(name string, field func(d *sqlc.DeviceDatum) *sql.NullTime) column {
+	return column{
+		name: name, typ: ptInt64, converted: convTimestampMicros, optional: true,
+		get: func(d *sqlc.DeviceDatum) interface{} {
+			if v := field(d); v.Valid {
+				return v.Time.UnixMicro()
+			}
+			return nil
+		},
+		set: func(d *sqlc.DeviceDatum, v interface{}) error {
+			if v != nil {
+				*field(d) = sql.NullTime{Time: time.UnixMicro(v.(int64)).UTC(), Valid: true}
+			}
+			return nil
+		},
+	}
+}
+
+// deviceDataColumns - схема файла архива (порядок колонок фиксирован;
+// новые колонки добавляются в конец, DecodeParquet читает колонки по имени)
+var deviceDataColumns = []column{
+	{
+		name: "id", typ: ptInt64, converted: -1,
+		get: func(d *sqlc.DeviceDatum) interface{} { return d.ID },
+		set: func(d *sqlc.DeviceDatum, v interface{}) error { d.ID = v.(int64); return nil },
+	},
+	{
+		name: "file_id", typ: ptInt64, converted: -1,
+		get: func(d *sqlc.DeviceDatum) interface{} { return d.FileID },
+		set: func(d *sqlc.DeviceDatum, v interface{}) error { d.FileID = v.(int64); return nil },
+	},
+	{
+		name: "unit_guid", typ: ptByteArray, converted: convUTF8,
+		get: func(d *sqlc.DeviceDatum) interface{} { return []byte(d.UnitGuid.String()) },
+		set: func(d *sqlc.DeviceDatum, v interface{}) (err error) {
+			d.UnitGuid, err = uuid.ParseBytes(v.([]byte))
+			return err
+		},
+	},
+	stringColumn("mqtt", func(d *sqlc.DeviceDatum) *sql.NullString { return &d.Mqtt }),
+	stringColumn("invid", func(d *sqlc.DeviceDatum) *sql.NullString { return &d.Invid }),
+	stringColumn("msg_id", func(d *sqlc.DeviceDatum) *sql.NullString { return &d.MsgID }),
+	stringColumn("text", func(d *sqlc.DeviceDatum) *sql.NullString { return &d.Text }),
+	stringColumn("context", func(d *sqlc.DeviceDatum) *sql.NullString { return &d.Context }),
+	stringColumn("class", func(d *sqlc.DeviceDatum) *sql.NullString { return &d.Class }),
+	int32Column("level", func(d *sqlc.DeviceDatum) *sql.NullInt32 { return &d.Level }),
+	stringColumn("area", func(d *sqlc.DeviceDatum) *sql.NullString { return &d.Area }),
+	stringColumn("addr", func(d *sqlc.DeviceDatum) *sql.NullString { return &d.Addr }),
+	stringColumn("block", func(d *sqlc.DeviceDatum) *sql.NullString { return &d.Block }),
+	stringColumn("type", func(d *sqlc.DeviceDatum) *sql.NullString { return &d.Type }),
+	int32Column("bit", func(d *sqlc.DeviceDatum) *sql.NullInt32 { return &d.Bit }),
+	{
+		name: "invert_bit", typ: ptBoolean, converted: -1, optional: true,
+		get: func(d *sqlc.DeviceDatum) interface{} {
+			if d.InvertBit.Valid {
+				return d.InvertBit.Bool
+			}
+			return nil
+		},
+		set: func(d *sqlc.DeviceDatum, v interface{}) error {
+			if v != nil {
+				d.InvertBit = sql.NullBool{Bool: v.(bool), Valid: true}
+			}
+			return nil
+		},
+	},
+	{
+		name: "line_number", typ: ptInt32, converted: -1,
+		get: func(d *sqlc.DeviceDatum) interface{} { return d.LineNumber },
+		set: func(d *sqlc.DeviceDatum, v interface{}) error { d.LineNumber = v.(int32); return nil },
+	},
+	timeColumn("created_at", func(d *sqlc.DeviceDatum) *sql.NullTime { return &d.CreatedAt }),
+	{
+		name: "version", typ: ptInt32, converted: -1,
+		get: func(d *sqlc.DeviceDatum) interface{} { return d.Version },
+		set: func(d *sqlc.DeviceDatum, v interface{}) error { d.Version = v.(int32); return nil },
+	},
+	timeColumn("updated_at", func(d *sqlc.DeviceDatum) *sql.NullTime { return &d.UpdatedAt }),
+	{
+		name: "change_seq", typ: ptInt64, converted: -1,
+		get: func(d *sqlc.DeviceDatum) interface{} { return d.ChangeSeq },
+		set: func(d *sqlc.DeviceDatum, v interface{}) error { d.ChangeSeq = v.(int64); return nil },
+	},
+}
+
+// EncodeParquet кодирует записи device_data в файл Parquet
+func EncodeParquet(rows []sqlc.DeviceDatum) []byte {
+	out := []byte(parquetMagic)
+
+	chunks := &thriftWriter{}
+	var totalSize int64
+	chunks.elemStruct() // RowGroup
+	chunks.listBegin(1, ctStruct, len(deviceDataColumns))
+	for _, col := range deviceDataColumns {
+		page := encodePage(col, rows)
+
+		header := &thriftWriter{}
+		header.elemStruct()
+		header.i32(1, pageTypeData)
+		header.i32(2, int32(len(page)))
+		header.i32(3, int32(len(page)))
+		header.structBegin(5) // DataPageHeader
+		header.i32(1, int32(len(rows)))
+		header.i32(2, encodingPlain)
+		header.i32(3, encodingRLE)
+		header.i32(4, encodingRLE)
+		header.structEnd()
+		header.structEnd()
+
+		offset := int64(len(out))
+		out = append(out, header.buf...)
+		out = append(out, page...)
+		size := int64(len(header.buf) + len(page))
+		totalSize += size
+
+		chunks.elemStruct() // ColumnChunk
+		chunks.i64(2, offset)
+		chunks.structBegin(3) // ColumnMetaData
+		chunks.i32(1, col.typ)
+		chunks.listBegin(2, ctI32, 2)
+		chunks.elemI32(encodingPlain)
+		chunks.elemI32(encodingRLE)
+		chunks.listBegin(3, ctBinary, 1)
+		chunks.elemString(col.name)
+		chunks.i32(4, 0) // UNCOMPRESSED
+		chunks.i64(5, int64(len(rows)))
+		chunks.i64(6, size)
+		chunks.i64(7, size)
+		chunks.i64(9, offset)
+		chunks.structEnd()
+		chunks.structEnd()
+	}
+	chunks.i64(2, totalSize)
+	chunks.i64(3, int64(len(rows)))
+	chunks.structEnd()
+
+	meta := &thriftWriter{}
+	meta.elemStruct() // FileMetaData
+	meta.i32(1, 1)
+	meta.listBegin(2, ctStruct, len(deviceDataColumns)+1)
+	meta.elemStruct()
+	meta.string(4, "device_data")
+	meta.i32(5, int32(len(deviceDataColumns)))
+	meta.structEnd()
+	for _, col := range deviceDataColumns {
+		meta.elemStruct()
+		meta.i32(1, col.typ)
+		repetition := int32(0) // REQUIRED
+		if col.optional {
+			repetition = 1 // OPTIONAL
+		}
+		meta.i32(3, repetition)
+		meta.string(4, col.name)
+		if col.converted >= 0 {
+			meta.i32(6, col.converted)
+		}
+		meta.structEnd()
+	}
+	meta.i64(3, int64(len(rows)))
+	meta.listBegin(4, ctStruct, 1)
+	meta.buf = append(meta.buf, chunks.buf...)
+	meta.string(6, "TSVProcessingService")
+	meta.structEnd()
+
+	out = append(out, meta.buf...)
+	out = binary.LittleEndian.AppendUint32(out, uint32(len(meta.buf)))
+	return append(out, parquetMagic...)
+}
+
+// encodePage - уровни определения (для nullable колонок) и значения PLAIN
+func encodePage(col column, rows []sqlc.DeviceDatum) []byte {
+	var levels, values []byte
+	var bits byte
+	var nbits int
+	var run, runValue int
+
+	flushRun := func() {
+		if run > 0 {
+			levels = binary.AppendUvarint(levels, uint64(run)<<1)
+			levels = append(levels, byte(runValue))
+		}
+	}
+	for i := range rows {
+		v := col.get(&rows[i])
+		if col.optional {
+			level := 0
+			if v != nil {
+				level = 1
+			}
+			if run > 0 && level != runValue {
+				flushRun()
+				run = 0
+			}
+			runValue = level
+			run++
+		}
+		switch v := v.(type) {
+		case nil:
+		case int32:
+			values = binary.LittleEndian.AppendUint32(values, uint32(v))
+		case int64:
+			values = binary.LittleEndian.AppendUint64(values, uint64(v))
+		case []byte:
+			values = binary.LittleEndian.AppendUint32(values, uint32(len(v)))
+			values = append(values, v...)
+		case bool:
+			if v {
+				bits |= 1 << nbits
+			}
+			if nbits++; nbits == 8 {
+				values = append(values, bits)
+				bits, nbits = 0, 0
+			}
+		}
+	}
+	if nbits > 0 {
+		values = append(values, bits)
+	}
+	if !col.optional {
+		return values
+	}
+	flushRun()
+
+	page := binary.LittleEndian.AppendUint32(nil, uint32(len(levels)))
+	page = append(page, levels...)
+	return append(page, values...)
+}
+
+// DecodeParquet читает записи device_data из файла, записанного EncodeParquet
+func DecodeParquet(data []byte) ([]sqlc.DeviceDatum, error) {
+	meta, err := parquetFooter(data)
+	if err != nil {
+		return nil, err
+	}
+	numRows := meta.int(3)
+	if numRows < 0 || numRows > int64(len(data)) {
+		return nil, fmt.Errorf("invalid row count %d", numRows)
+	}
+	columns := make(map[string]column, len(deviceDataColumns))
+	for _, col := range deviceDataColumns {
+		columns[col.name] = col
+	}
+
+	rows := make([]sqlc.DeviceDatum, numRows)
+	for _, rg := range meta.list(4) {
+		group, _ := rg.(tstruct)
+		for _, cc := range group.list(1) {
+			chunk, _ := cc.(tstruct)
+			cm := chunk.sub(3)
+			path := cm.list(3)
+			if len(path) != 1 {
+				return nil, errors.New("nested columns are not supported")
+			}
+			name, _ := path[0].([]byte)
+			col, ok := columns[string(name)]
+			if !ok {
+				continue // колонки из будущих версий схемы
+			}
+			if cm.int(4) != 0 {
+				return nil, fmt.Errorf("column %s: compressed pages are not supported", name)
+			}
+			if err := decodeColumn(data, cm.int(9), col, rows); err != nil {
+				return nil, fmt.Errorf("column %s: %w", name, err)
+			}
+		}
+	}
+	return rows, nil
+}
+
+// parquetFooter - метаданные файла (FileMetaData)
+func parquetFooter(data []byte) (tstruct, error) {
+	if len(data) < 12 || string(data[:4]) != parquetMagic || string(data[len(data)-4:]) != parquetMagic {
+		return nil, errors.New("not a parquet file")
+	}
+	size := int(binary.LittleEndian.Uint32(data[len(data)-8:]))
+	if size <= 0 || size > len(data)-12 {
+		return nil, errors.New("invalid parquet footer size")
+	}
+	r := &thriftReader{buf: data[len(data)-8-size : len(data)-8]}
+	return r.readStruct()
+}
+
+// decodeColumn читает страницу данных колонки в rows
+func decodeColumn(data []byte, offset int64, col column, rows []sqlc.DeviceDatum) error {
+	if offset < 4 || offset >= int64(len(data)) {
+		return errors.New("invalid page offset")
+	}
+	r := &thriftReader{buf: data, pos: int(offset)}
+	header, err := r.readStruct()
+	if err != nil {
+		return err
+	}
+	if header.int(1) != pageTypeData || !header.has(5) {
+		return errors.New("only data pages v1 are supported")
+	}
+	size := header.int(3)
+	if size < 0 || int64(r.pos)+size > int64(len(data)) {
+		return errors.New("page out of bounds")
+	}
+	page := data[r.pos : r.pos+int(size)]
+	if n := header.sub(5).int(1); n != int64(len(rows)) {
+		return fmt.Errorf("page has %d values, expected %d", n, len(rows))
+	}
+
+	present := make([]bool, len(rows))
+	if col.optional {
+		if len(page) < 4 {
+			return errors.New("truncated definition levels")
+		}
+		n := int(binary.LittleEndian.Uint32(page))
+		if n > len(page)-4 {
+			return errors.New("truncated definition levels")
+		}
+		if err := decodeLevels(page[4:4+n], present); err != nil {
+			return err
+		}
+		page = page[4+n:]
+	} else {
+		for i := range present {
+			present[i] = true
+		}
+	}
+
+	values := bytes.NewReader(page)
+	var bits byte
+	var nbits int
+	for i := range rows {
+		if !present[i] {
+			continue
+		}
+		var v interface{}
+		switch col.typ {
+		case ptInt32:
+			var x int32
+			err = binary.Read(values, binary.LittleEndian, &x)
+			v = x
+		case ptInt64:
+			var x int64
+			err = binary.Read(values, binary.LittleEndian, &x)
+			v = x
+		case ptByteArray:
+			var n uint32
+			if err = binary.Read(values, binary.LittleEndian, &n); err == nil {
+				if int64(n) > int64(values.Len()) {
+					return errors.New("truncated value")
+				}
+				b := make([]byte, n)
+				_, err = values.Read(b)
+				v = b
+			}
+		case ptBoolean:
+			if nbits == 0 {
+				bits, err = values.ReadByte()
+				nbits = 8
+			}
+			v = bits&1 == 1
+			bits >>= 1
+			nbits--
+		}
+		if err != nil {
+			return fmt.Errorf("truncated values: %w", err)
+		}
+		if err := col.set(&rows[i], v); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// decodeLevels - уровни определения (RLE/bit-packed hybrid, ширина 1 бит)
+func decodeLevels(buf []byte, present []bool) error {
+	i := 0
+	for i < len(present) {
+		header, n := binary.Uvarint(buf)
+		if n <= 0 {
+			return errors.New("truncated definition levels")
+		}
+		buf = buf[n:]
+		if header&1 == 0 {
+			// RLE: длина серии и значение в одном байте
+			if len(buf) < 1 {
+				return errors.New("truncated definition levels")
+			}
+			for run := header >> 1; run > 0 && i < len(present); run-- {
+				present[i] = buf[0] == 1
+				i++
+			}
+			buf = buf[1:]
+		} else {
+			// bit-packed: группы по 8 значений
+			groups := int(header >> 1)
+			if groups > len(buf) {
+				return errors.New("truncated definition levels")
+			}
+			for _, b := range buf[:groups] {
+				for bit := 0; bit < 8 && i < len(present); bit++ {
+					present[i] = b>>bit&1 == 1
+					i++
+				}
+			}
+			buf = buf[groups:]
+		}
+	}
+	return nil
+}
@@ -0,0 +1,273 @@
+package archive
+
+import (
+	"TSVProcessingService/db/sqlc"
+	"database/sql"
+	"encoding/binary"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testRows(n int) []sqlc.DeviceDatum {
+	guid := uuid.MustParse("01749246-95f6-57db-b7c3-2ae0e8be671f")
+	created := time.Date(2025, 3, 14, 10, 30, 0, 123000, time.UTC)
+	rows := make([]sqlc.DeviceDatum, n)
+	for i := range rows {
+		rows[i] = sqlc.DeviceDatum{
+			ID:         int64(i + 1),
+			FileID:     7,
+			UnitGuid:   guid,
+			MsgID:      sql.NullString{String: fmt.Sprintf("msg_%d", i), Valid: true},
+			Class:      sql.NullString{String: "alarm", Valid: true},
+			LineNumber: int32(i + 2),
+			CreatedAt:  sql.NullTime{Time: created.Add(time.Duration(i) * time.Minute), Valid: true},
+			Version:    1,
+			ChangeSeq:  int64(1000 + i),
+		}
+		// NULL в части строк
+		if i%3 != 0 {
+			rows[i].Text = sql.NullString{String: "Разморозка", Valid: true}
+		}
+		if i%2 == 0 {
+			rows[i].Level = sql.NullInt32{Int32: int32(i * 10), Valid: true}
+		}
+		if i%5 != 0 {
+			rows[i].InvertBit = sql.NullBool{Bool: i%4 == 1, Valid: true}
+		}
+		if i%4 == 0 {
+			rows[i].UpdatedAt = sql.NullTime{Time: created.Add(time.Duration(i) * time.Hour), Valid: true}
+		}
+	}
+	return rows
+}
+
+func TestParquet_RoundTrip(t *testing.T) {
+	rows := testRows(37)
+
+	data := EncodeParquet(rows)
+	assert.Equal(t, "PAR1", string(data[:4]))
+	assert.Equal(t, "PAR1", string(data[len(data)-4:]))
+
+	decoded, err := DecodeParquet(data)
+	require.NoError(t, err)
+	assert.Equal(t, rows, decoded)
+}
+
+func TestParquet_Empty(t *testing.T) {
+	decoded, err := DecodeParquet(EncodeParquet(nil))
+	require.NoError(t, err)
+	assert.Empty(t, decoded)
+}
+
+func TestParquet_RejectsCorruptData(t *testing.T) {
+	data := EncodeParquet(testRows(3))
+
+	_, err := DecodeParquet(data[:len(data)-5])
+	assert.Error(t, err)
+
+	_, err = DecodeParquet([]byte("not a parquet file at all"))
+	assert.Error(t, err)
+}
+
+// readParquetBySpec читает файл по спецификации Parquet декодером
+// compactDecoder, не используя кодек архива: колонка -> значения строк
+// (nil - NULL). Проверяет метаданные, которые нужны сторонним читателям.
+func readParquetBySpec(t *testing.T, data []byte) map[string][]interface{} {
+	t.Helper()
+	require.Equal(t, "PAR1", string(data[:4]))
+	require.Equal(t, "PAR1", string(data[len(data)-4:]))
+	size := int(binary.LittleEndian.Uint32(data[len(data)-8:]))
+	footer := data[len(data)-8-size : len(data)-8]
+	meta, n := decodeCompact(t, footer)
+	require.Equal(t, size, n, "footer length")
+
+	// FileMetaData: version, schema, num_rows, row_groups
+	assert.Equal(t, int64(1), meta[1])
+	numRows := int(meta[3].(int64))
+	schema := meta[2].([]interface{})
+	root := schema[0].(map[int16]interface{})
+	assert.Equal(t, []byte("device_data"), root[4])
+	require.Equal(t, int64(len(schema)-1), root[5], "num_children")
+
+	type element struct {
+		typ      int64
+		optional bool
+	}
+	elements := make(map[string]element)
+	for _, e := range schema[1:] {
+		fields := e.(map[int16]interface{})
+		name := string(fields[4].([]byte))
+		repetition := fields[3].(int64)
+		require.Contains(t, []int64{0, 1}, repetition, name) // REQUIRED или OPTIONAL
+		elements[name] = element{typ: fields[1].(int64), optional: repetition == 1}
+	}
+
+	result := make(map[string][]interface{})
+	groups := meta[4].([]interface{})
+	require.Len(t, groups, 1)
+	group := groups[0].(map[int16]interface{})
+	assert.Equal(t, int64(numRows), group[3])
+	chunks := group[1].([]interface{})
+	require.Len(t, chunks, len(elements))
+	var totalSize int64
+	for _, c := range chunks {
+		chunk := c.(map[int16]interface{})
+		cm := chunk[3].(map[int16]interface{})
+		path := cm[3].([]interface{})
+		require.Len(t, path, 1)
+		name := string(path[0].([]byte))
+		el, ok := elements[name]
+		require.True(t, ok, name)
+		assert.Equal(t, el.typ, cm[1], name)
+		assert.Equal(t, int64(0), cm[4], name) // UNCOMPRESSED
+		assert.Equal(t, int64(numRows), cm[5], name)
+		assert.Equal(t, cm[6], cm[7], name)
+		assert.Equal(t, chunk[2], cm[9], name)
+		totalSize += cm[7].(int64)
+
+		// PageHeader: DATA_PAGE, PLAIN, уровни RLE
+		offset := int(cm[9].(int64))
+		header, headerLen := decodeCompact(t, data[offset:])
+		assert.Equal(t, int64(0), header[1], name)
+		assert.Equal(t, header[2], header[3], name)
+		dph := header[5].(map[int16]interface{})
+		assert.Equal(t, int64(numRows), dph[1], name)
+		assert.Equal(t, int64(0), dph[2], name)
+		assert.Equal(t, int64(3), dph[3], name)
+		pageSize := int(header[3].(int64))
+		assert.Equal(t, int64(headerLen+pageSize), cm[7], name)
+		page := data[offset+headerLen : offset+headerLen+pageSize]
+
+		present := make([]bool, numRows)
+		if el.optional {
+			n := int(binary.LittleEndian.Uint32(page))
+			readHybridLevels(t, page[4:4+n], present)
+			page = page[4+n:]
+		} else {
+			for i := range present {
+				present[i] = true
+			}
+		}
+
+		values := make([]interface{}, numRows)
+		pos, bit := 0, 0
+		for i := range values {
+			if !present[i] {
+				continue
+			}
+			switch el.typ {
+			case 0: // BOOLEAN: bit-packed, младший бит первым
+				values[i] = page[pos]>>bit&1 == 1
+				if bit++; bit == 8 {
+					pos, bit = pos+1, 0
+				}
+			case 1: // INT32
+				values[i] = int32(binary.LittleEndian.Uint32(page[pos:]))
+				pos += 4
+			case 2: // INT64
+				values[i] = int64(binary.LittleEndian.Uint64(page[pos:]))
+				pos += 8
+			case 6: // BYTE_ARRAY
+				n := int(binary.LittleEndian.Uint32(page[pos:]))
+				values[i] = string(page[pos+4 : pos+4+n])
+				pos += 4 + n
+			default:
+				t.Fatalf("column %s: unexpected type %d", name, el.typ)
+			}
+		}
+		if bit > 0 {
+			pos++
+		}
+		assert.Equal(t, len(page), pos, "column %s: trailing bytes", name)
+		result[name] = values
+	}
+	assert.Equal(t, totalSize, group[2])
+	return result
+}
+
+// readHybridLevels - уровни определения ширины 1 бит (RLE/bit-packed hybrid)
+func readHybridLevels(t *testing.T, buf []byte, present []bool) {
+	t.Helper()
+	i := 0
+	for i < len(present) {
+		header, n := binary.Uvarint(buf)
+		require.Positive(t, n)
+		buf = buf[n:]
+		if header&1 == 1 {
+			groups := int(header >> 1)
+			for _, b := range buf[:groups] {
+				for bit := 0; bit < 8 && i < len(present); bit++ {
+					present[i] = b>>bit&1 == 1
+					i++
+				}
+			}
+			buf = buf[groups:]
+			continue
+		}
+		for run := int(header >> 1); run > 0; run-- {
+			present[i] = buf[0] == 1
+			i++
+		}
+		buf = buf[1:]
+	}
+	assert.Empty(t, buf)
+}
+
+func TestParquet_ReadableBySpec(t *testing.T) {
+	rows := testRows(37)
+	columns := readParquetBySpec(t, EncodeParquet(rows))
+
+	assert.Len(t, columns, 21)
+	for i, row := range rows {
+		assert.Equal(t, row.ID, columns["id"][i])
+		assert.Equal(t, row.UnitGuid.String(), columns["unit_guid"][i])
+		assert.Equal(t, row.MsgID.String, columns["msg_id"][i])
+		assert.Equal(t, row.LineNumber, columns["line_number"][i])
+		assert.Equal(t, row.CreatedAt.Time.UnixMicro(), columns["created_at"][i])
+		assert.Equal(t, row.ChangeSeq, columns["change_seq"][i])
+		if row.Text.Valid {
+			assert.Equal(t, row.Text.String, columns["text"][i])
+		} else {
+			assert.Nil(t, columns["text"][i])
+		}
+		if row.Level.Valid {
+			assert.Equal(t, row.Level.Int32, columns["level"][i])
+		} else {
+			assert.Nil(t, columns["level"][i])
+		}
+		if row.InvertBit.Valid {
+			assert.Equal(t, row.InvertBit.Bool, columns["invert_bit"][i])
+		} else {
+			assert.Nil(t, columns["invert_bit"][i])
+		}
+		if row.UpdatedAt.Valid {
+			assert.Equal(t, row.UpdatedAt.Time.UnixMicro(), columns["updated_at"][i])
+		} else {
+			assert.Nil(t, columns["updated_at"][i])
+		}
+		assert.Nil(t, columns["mqtt"][i])
+	}
+
+	// Пустой файл - та же схема без строк
+	assert.Len(t, readParquetBySpec(t, EncodeParquet(nil)), 21)
+}
+
+func TestParquet_DecodesFilesWithoutNewColumns(t *testing.T) {
+	rows := testRows(5)
+	saved := deviceDataColumns
+	deviceDataColumns = deviceDataColumns[:len(deviceDataColumns)-2] // архивы до updated_at и change_seq
+	data := EncodeParquet(rows)
+	deviceDataColumns = saved
+
+	decoded, err := DecodeParquet(data)
+	require.NoError(t, err)
+	require.Len(t, decoded, 5)
+	assert.Equal(t, rows[1].ID, decoded[1].ID)
+	assert.False(t, decoded[0].UpdatedAt.Valid)
+	assert.Zero(t, decoded[0].ChangeSeq)
+}
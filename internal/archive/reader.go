@@ -0,0 +1,65 @@
+// internal/archive/reader.go
+package archive
+
+import (
+	"TSVProcessingService/db/sqlc"
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Reader читает архивные записи устройства по манифесту archived_partitions
+// (чтение без DuckDB: файлы скачиваются и декодируются DecodeParquet)
+type Reader struct {
+	queries *sqlc.Queries
+	store   ObjectStore
+}
+
+// NewReader создаёт читателя архива.
+func NewReader(queries *sqlc.Queries, store ObjectStore) *Reader {
+	return &Reader{queries: queries, store: store}
+}
+
+// Query - архивные записи устройства с created_at в [from, to), новые первыми
+// (created_at DESC, id DESC - как у device_data по умолчанию)
+func (r *Reader) Query(ctx context.Context, unitGuid uuid.UUID, from, to time.Time) ([]sqlc.DeviceDatum, error) {
+	from, to = from.UTC(), to.UTC()
+	parts, err := r.queries.ListArchivedPartitionsByUnit(ctx, sqlc.ListArchivedPartitionsByUnitParams{
+		UnitGuid: unitGuid,
+		Month:    time.Date(from.Year(), from.Month(), 1, 0, 0, 0, 0, time.UTC),
+		Month_2:  to,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list archived partitions: %w", err)
+	}
+
+	items := []sqlc.DeviceDatum{}
+	for _, part := range parts {
+		data, err := r.store.Get(ctx, part.ObjectKey)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", part.ObjectKey, err)
+		}
+		rows, err := DecodeParquet(data)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode %s: %w", part.ObjectKey, err)
+		}
+		for _, row := range rows {
+			if !row.CreatedAt.Valid || row.CreatedAt.Time.Before(from) || !row.CreatedAt.Time.Before(to) {
+				continue
+			}
+			items = append(items, row)
+		}
+	}
+
+	sort.Slice(items, func(i, j int) bool {
+		ti, tj := items[i].CreatedAt.Time, items[j].CreatedAt.Time
+		if !ti.Equal(tj) {
+			return ti.After(tj)
+		}
+		return items[i].ID > items[j].ID
+	})
+	return items, nil
+}
@@ -0,0 +1,56 @@
+package archive
+
+import (
+	"TSVProcessingService/db/sqlc"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReader_QueryFiltersRangeAndOrders(t *testing.T) {
+	db := setupArchiveDB(t)
+	ctx := context.Background()
+	guid := uuid.MustParse("01749246-95f6-57db-b7c3-2ae0e8be671f")
+	other := uuid.MustParse("a6a2a8e1-5c1e-4d44-9a47-3b0f0d2f7c11")
+
+	insert := func(unitGuid uuid.UUID, createdAt time.Time, msgID string) {
+		_, err := db.Exec(`INSERT INTO device_data (file_id, unit_guid, msg_id, line_number, created_at)
+			VALUES (1, ?, ?, 1, ?)`, unitGuid, msgID, createdAt)
+		require.NoError(t, err)
+	}
+	insert(guid, time.Date(2025, 1, 5, 10, 0, 0, 0, time.UTC), "jan_1")
+	insert(guid, time.Date(2025, 1, 20, 10, 0, 0, 0, time.UTC), "jan_2")
+	insert(guid, time.Date(2025, 2, 10, 0, 0, 0, 0, time.UTC), "feb_1")
+	insert(guid, time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC), "mar_1")
+	insert(other, time.Date(2025, 1, 10, 0, 0, 0, 0, time.UTC), "other")
+
+	queries := sqlc.New(db)
+	store := NewDirStore(t.TempDir())
+	archiver := NewArchiver(db, queries, store, Options{OlderThanMonths: 3})
+	archiver.now = func() time.Time { return time.Date(2025, 7, 10, 0, 0, 0, 0, time.UTC) }
+	_, err := archiver.Archive(ctx)
+	require.NoError(t, err)
+
+	reader := NewReader(queries, store)
+	rows, err := reader.Query(ctx, guid,
+		time.Date(2025, 1, 10, 0, 0, 0, 0, time.UTC),
+		time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC))
+	require.NoError(t, err)
+
+	var msgIDs []string
+	for _, row := range rows {
+		assert.Equal(t, guid, row.UnitGuid)
+		msgIDs = append(msgIDs, row.MsgID.String)
+	}
+	assert.Equal(t, []string{"feb_1", "jan_2"}, msgIDs)
+
+	rows, err = reader.Query(ctx, guid,
+		time.Date(2025, 4, 1, 0, 0, 0, 0, time.UTC),
+		time.Date(2025, 5, 1, 0, 0, 0, 0, time.UTC))
+	require.NoError(t, err)
+	assert.Empty(t, rows)
+}
@@ -0,0 +1,295 @@
+// internal/archive/thrift.go
+package archive
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+)
+
+// Минимальная реализация Thrift Compact Protocol - ровно то, что нужно
+// для заголовков страниц и метаданных Parquet.
+
+// Типы полей compact protocol
+const (
+	ctBoolTrue  = 1
+	ctBoolFalse = 2
+	ctByte      = 3
+	ctI16       = 4
+	ctI32       = 5
+	ctI64       = 6
+	ctDouble    = 7
+	ctBinary    = 8
+	ctList      = 9
+	ctSet       = 10
+	ctMap       = 11
+	ctStruct    = 12
+)
+
+// thriftWriter - кодировщик структур compact protocol
+type thriftWriter struct {
+	buf    []byte
+	last   int16   // id последнего поля текущей структуры
+	parent []int16 // last вложенных структур
+}
+
+func (w *thriftWriter) uvarint(v uint64) {
+	w.buf = binary.AppendUvarint(w.buf, v)
+}
+
+func (w *thriftWriter) fieldHeader(id int16, typ byte) {
+	if delta := id - w.last; delta > 0 && delta <= 15 {
+		w.buf = append(w.buf, byte(delta)<<4|typ)
+	} else {
+		w.buf = append(w.buf, typ)
+		w.uvarint(uint64((int64(id) << 1) ^ (int64(id) >> 63)))
+	}
+	w.last = id
+}
+
+func (w *thriftWriter) i32(id int16, v int32) {
+	w.fieldHeader(id, ctI32)
+	w.buf = binary.AppendVarint(w.buf, int64(v))
+}
+
+func (w *thriftWriter) i64(id int16, v int64) {
+	w.fieldHeader(id, ctI64)
+	w.buf = binary.AppendVarint(w.buf, v)
+}
+
+func (w *thriftWriter) binary(id int16, v []byte) {
+	w.fieldHeader(id, ctBinary)
+	w.uvarint(uint64(len(v)))
+	w.buf = append(w.buf, v...)
+}
+
+func (w *thriftWriter) string(id int16, v string) {
+	w.binary(id, []byte(v))
+}
+
+// structBegin открывает поле-структуру id
+func (w *thriftWriter) structBegin(id int16) {
+	w.fieldHeader(id, ctStruct)
+	w.push()
+}
+
+// structEnd закрывает структуру (поле или элемент списка)
+func (w *thriftWriter) structEnd() {
+	w.buf = append(w.buf, 0)
+	w.last, w.parent = w.parent[len(w.parent)-1], w.parent[:len(w.parent)-1]
+}
+
+// listBegin открывает поле-список из n элементов типа elem
+func (w *thriftWriter) listBegin(id int16, elem byte, n int) {
+	w.fieldHeader(id, ctList)
+	if n < 15 {
+		w.buf = append(w.buf, byte(n)<<4|elem)
+	} else {
+		w.buf = append(w.buf, 0xF0|elem)
+		w.uvarint(uint64(n))
+	}
+}
+
+// elemStruct открывает структуру - элемент списка
+func (w *thriftWriter) elemStruct() {
+	w.push()
+}
+
+func (w *thriftWriter) elemI32(v int32) {
+	w.buf = binary.AppendVarint(w.buf, int64(v))
+}
+
+func (w *thriftWriter) elemString(v string) {
+	w.uvarint(uint64(len(v)))
+	w.buf = append(w.buf, v...)
+}
+
+func (w *thriftWriter) push() {
+	w.parent = append(w.parent, w.last)
+	w.last = 0
+}
+
+// ---------------------------------------------------------------------
+// Чтение
+// ---------------------------------------------------------------------
+
+// tstruct - прочитанная структура: id поля -> значение (int64, bool, float64,
+// []byte, tstruct или []interface{} для списков). Неизвестные поля
+// сохраняются, но не используются.
+type tstruct map[int16]interface{}
+
+var errThriftTruncated = errors.New("truncated thrift data")
+
+// thriftReader - декодер compact protocol
+type thriftReader struct {
+	buf []byte
+	pos int
+}
+
+// readStruct читает структуру до STOP
+func (r *thriftReader) readStruct() (tstruct, error) {
+	s := make(tstruct)
+	var last int16
+	for {
+		b, err := r.byte()
+		if err != nil {
+			return nil, err
+		}
+		if b == 0 {
+			return s, nil
+		}
+		typ := b & 0x0F
+		id := last + int16(b>>4)
+		if b>>4 == 0 {
+			v, err := r.varint()
+			if err != nil {
+				return nil, err
+			}
+			id = int16(v)
+		}
+		last = id
+
+		var v interface{}
+		switch typ {
+		case ctBoolTrue:
+			v = true
+		case ctBoolFalse:
+			v = false
+		default:
+			if v, err = r.value(typ); err != nil {
+				return nil, err
+			}
+		}
+		s[id] = v
+	}
+}
+
+// value читает значение типа typ (кроме bool полей структуры)
+func (r *thriftReader) value(typ byte) (interface{}, error) {
+	switch typ {
+	case ctBoolTrue, ctBoolFalse:
+		// bool в элементах списка занимает байт
+		b, err := r.byte()
+		return b == ctBoolTrue, err
+	case ctByte:
+		b, err := r.byte()
+		return int64(int8(b)), err
+	case ctI16, ctI32, ctI64:
+		return r.varint()
+	case ctDouble:
+		if r.pos+8 > len(r.buf) {
+			return nil, errThriftTruncated
+		}
+		r.pos += 8
+		return nil, nil
+	case ctBinary:
+		n, err := r.uvarint()
+		if err != nil {
+			return nil, err
+		}
+		if uint64(len(r.buf)-r.pos) < n {
+			return nil, errThriftTruncated
+		}
+		v := r.buf[r.pos : r.pos+int(n)]
+		r.pos += int(n)
+		return v, nil
+	case ctList, ctSet:
+		b, err := r.byte()
+		if err != nil {
+			return nil, err
+		}
+		n := uint64(b >> 4)
+		if n == 15 {
+			if n, err = r.uvarint(); err != nil {
+				return nil, err
+			}
+		}
+		if n > uint64(len(r.buf)-r.pos) {
+			return nil, errThriftTruncated
+		}
+		list := make([]interface{}, 0, n)
+		for i := uint64(0); i < n; i++ {
+			v, err := r.value(b & 0x0F)
+			if err != nil {
+				return nil, err
+			}
+			list = append(list, v)
+		}
+		return list, nil
+	case ctMap:
+		n, err := r.uvarint()
+		if err != nil || n == 0 {
+			return nil, err
+		}
+		types, err := r.byte()
+		if err != nil {
+			return nil, err
+		}
+		for i := uint64(0); i < n; i++ {
+			if _, err := r.value(types >> 4); err != nil {
+				return nil, err
+			}
+			if _, err := r.value(types & 0x0F); err != nil {
+				return nil, err
+			}
+		}
+		return nil, nil
+	case ctStruct:
+		return r.readStruct()
+	}
+	return nil, fmt.Errorf("unknown thrift type %d", typ)
+}
+
+func (r *thriftReader) byte() (byte, error) {
+	if r.pos >= len(r.buf) {
+		return 0, errThriftTruncated
+	}
+	b := r.buf[r.pos]
+	r.pos++
+	return b, nil
+}
+
+func (r *thriftReader) uvarint() (uint64, error) {
+	v, n := binary.Uvarint(r.buf[r.pos:])
+	if n <= 0 {
+		return 0, errThriftTruncated
+	}
+	r.pos += n
+	return v, nil
+}
+
+func (r *thriftReader) varint() (int64, error) {
+	v, n := binary.Varint(r.buf[r.pos:])
+	if n <= 0 {
+		return 0, errThriftTruncated
+	}
+	r.pos += n
+	return v, nil
+}
+
+// Доступ к полям прочитанной структуры (отсутствующее поле - нулевое значение)
+
+func (s tstruct) int(id int16) int64 {
+	v, _ := s[id].(int64)
+	return v
+}
+
+func (s tstruct) bytes(id int16) []byte {
+	v, _ := s[id].([]byte)
+	return v
+}
+
+func (s tstruct) sub(id int16) tstruct {
+	v, _ := s[id].(tstruct)
+	return v
+}
+
+func (s tstruct) list(id int16) []interface{} {
+	v, _ := s[id].([]interface{})
+	return v
+}
+
+func (s tstruct) has(id int16) bool {
+	_, ok := s[id]
+	return ok
+}
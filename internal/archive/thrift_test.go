@@ -0,0 +1,163 @@
+package archive
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// compactDecoder - декодер Thrift Compact Protocol, написанный по
+// спецификации отдельно от thriftReader: им тесты проверяют, что
+// thriftWriter и EncodeParquet пишут то, что прочитает любой читатель.
+// Структура - map[id поля]значение, список - []interface{}.
+type compactDecoder struct {
+	buf []byte
+	pos int
+}
+
+func (d *compactDecoder) readByte() byte {
+	if d.pos >= len(d.buf) {
+		panic("compact: unexpected end of data")
+	}
+	b := d.buf[d.pos]
+	d.pos++
+	return b
+}
+
+func (d *compactDecoder) uvarint() uint64 {
+	var v uint64
+	for shift := 0; ; shift += 7 {
+		b := d.readByte()
+		v |= uint64(b&0x7f) << shift
+		if b&0x80 == 0 {
+			return v
+		}
+	}
+}
+
+// zigzag - целое со знаком (i16, i32, i64)
+func (d *compactDecoder) zigzag() int64 {
+	u := d.uvarint()
+	return int64(u>>1) ^ -int64(u&1)
+}
+
+func (d *compactDecoder) value(typ byte) interface{} {
+	switch typ {
+	case 1:
+		return true
+	case 2:
+		return false
+	case 3:
+		return int64(int8(d.readByte()))
+	case 4, 5, 6:
+		return d.zigzag()
+	case 7:
+		if d.pos+8 > len(d.buf) {
+			panic("compact: unexpected end of data")
+		}
+		v := math.Float64frombits(binary.LittleEndian.Uint64(d.buf[d.pos:]))
+		d.pos += 8
+		return v
+	case 8:
+		n := int(d.uvarint())
+		if d.pos+n > len(d.buf) {
+			panic("compact: unexpected end of data")
+		}
+		v := d.buf[d.pos : d.pos+n]
+		d.pos += n
+		return v
+	case 9, 10:
+		header := d.readByte()
+		size, elem := int(header>>4), header&0x0f
+		if size == 15 {
+			size = int(d.uvarint())
+		}
+		list := make([]interface{}, size)
+		for i := range list {
+			list[i] = d.value(elem)
+		}
+		return list
+	case 12:
+		return d.structure()
+	}
+	panic(fmt.Sprintf("compact: unsupported type %d", typ))
+}
+
+func (d *compactDecoder) structure() map[int16]interface{} {
+	fields := make(map[int16]interface{})
+	var last int16
+	for {
+		header := d.readByte()
+		if header == 0 {
+			return fields
+		}
+		id := last + int16(header>>4)
+		if header>>4 == 0 {
+			id = int16(d.zigzag())
+		}
+		fields[id] = d.value(header & 0x0f)
+		last = id
+	}
+}
+
+// decodeCompact читает структуру с начала buf; возвращает её и длину
+func decodeCompact(t *testing.T, buf []byte) (fields map[int16]interface{}, n int) {
+	t.Helper()
+	d := &compactDecoder{buf: buf}
+	require.NotPanics(t, func() { fields = d.structure() })
+	return fields, d.pos
+}
+
+func TestThriftWriter_CompactProtocol(t *testing.T) {
+	w := &thriftWriter{}
+	w.elemStruct()
+	w.i32(1, 1)
+	w.i64(2, -300)
+	w.string(4, "device_data")
+	w.i32(20, math.MaxInt32) // разница id больше 15 - длинный заголовок поля
+	w.structBegin(21)
+	w.i64(1, math.MinInt64)
+	w.structEnd()
+	w.listBegin(22, ctI32, 20) // 15 и больше элементов - размер отдельным varint
+	for i := 0; i < 20; i++ {
+		w.elemI32(int32(i - 10))
+	}
+	w.listBegin(23, ctStruct, 2)
+	for _, name := range []string{"a", "bc"} {
+		w.elemStruct()
+		w.string(4, name)
+		w.structEnd()
+	}
+	w.structEnd()
+
+	// Заголовок поля 1 (i32, delta 1) и zigzag-значение 1
+	assert.Equal(t, []byte{0x15, 0x02}, w.buf[:2])
+
+	fields, n := decodeCompact(t, w.buf)
+	assert.Equal(t, len(w.buf), n)
+	assert.Equal(t, int64(1), fields[1])
+	assert.Equal(t, int64(-300), fields[2])
+	assert.Equal(t, []byte("device_data"), fields[4])
+	assert.Equal(t, int64(math.MaxInt32), fields[20])
+	assert.Equal(t, map[int16]interface{}{1: int64(math.MinInt64)}, fields[21])
+	list := fields[22].([]interface{})
+	require.Len(t, list, 20)
+	assert.Equal(t, int64(-10), list[0])
+	assert.Equal(t, int64(9), list[19])
+	assert.Equal(t, []interface{}{
+		map[int16]interface{}{4: []byte("a")},
+		map[int16]interface{}{4: []byte("bc")},
+	}, fields[23])
+
+	// thriftReader читает то же самое
+	r := &thriftReader{buf: w.buf}
+	s, err := r.readStruct()
+	require.NoError(t, err)
+	assert.Equal(t, int64(math.MaxInt32), s.int(20))
+	assert.Equal(t, int64(math.MinInt64), s.sub(21).int(1))
+	assert.Len(t, s.list(22), 20)
+}
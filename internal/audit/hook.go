@@ -0,0 +1,46 @@
+// internal/audit/hook.go
+package audit
+
+import (
+	"TSVProcessingService/internal/processor"
+	"context"
+)
+
+// ingestPayload - итог обработки файла в журнале аудита
+type ingestPayload struct {
+	FileID        int64  `json:"file_id"`
+	FileHash      string `json:"file_hash"`
+	Size          int64  `json:"size"`
+	Status        string `json:"status"`
+	RowsProcessed int32  `json:"rows_processed"`
+	RowsFailed    int32  `json:"rows_failed"`
+	ParseErrors   int32  `json:"parse_errors"`
+	Corrects      int64  `json:"corrects_file_id,omitempty"`
+}
+
+// Hook - post-processing hook, записывающий итог каждого файла
+type Hook struct {
+	log *Log
+}
+
+// NewHook создаёт hook журнала аудита.
+func NewHook(log *Log) *Hook {
+	return &Hook{log: log}
+}
+
+func (h *Hook) Name() string { return "audit" }
+
+// AfterProcess добавляет запись file.ingested; actor - источник файла
+func (h *Hook) AfterProcess(ctx context.Context, result processor.ProcessResult) error {
+	_, err := h.log.Append(ctx, EventFileIngested, result.File.Filename, result.File.Source, ingestPayload{
+		FileID:        result.File.ID,
+		FileHash:      result.File.FileHash,
+		Size:          result.FileInfo.Size,
+		Status:        string(result.Status),
+		RowsProcessed: result.RowsProcessed,
+		RowsFailed:    result.RowsFailed,
+		ParseErrors:   result.ParseErrors,
+		Corrects:      result.File.CorrectsFileID.Int64,
+	})
+	return err
+}
@@ -0,0 +1,235 @@
+// internal/audit/log.go
+package audit
+
+import (
+	"TSVProcessingService/db/sqlc"
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// События журнала
+const (
+	EventFileIngested         = "file.ingested"            // файл обработан (любой итог)
+	EventFileReprocess        = "file.reprocess_requested" // ручная постановка в очередь через API
+	EventFileSuperseded       = "file.superseded"
+	EventFileCancelled        = "file.cancelled"
+	EventFilePrioritized      = "file.prioritized"
+	EventRetentionCleanup     = "retention.cleanup"    // удаление старых данных по сроку хранения
+	EventFeatureFlagChanged   = "feature_flag.changed" // переопределение флага через API
+	EventDrainRequested       = "service.drain_requested"
+	EventIncidentAcknowledged = "incident.acknowledged"
+	EventReplayRequested      = "clickhouse.replay_requested"
+)
+
+// GenesisHash - prev_hash первой записи журнала
+var GenesisHash = strings.Repeat("0", 64)
+
+// ErrChainBroken - запись не продолжает цепочку или её hash не совпадает
+var ErrChainBroken = errors.New("audit chain broken")
+
+// appendAttempts - попыток добавления при гонке за конец цепочки
+// (несколько экземпляров сервиса пишут в один журнал)
+const appendAttempts = 3
+
+// Entry - запись журнала в формате экспорта. Payload - исходный текст
+// JSON, именно эти байты входят в hash.
+type Entry struct {
+	ID        int64     `json:"id"`
+	Event     string    `json:"event"`
+	Subject   string    `json:"subject"`
+	Actor     string    `json:"actor"`
+	Payload   string    `json:"payload"`
+	PrevHash  string    `json:"prev_hash"`
+	Hash      string    `json:"hash"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// fromRow - запись журнала из строки audit_log
+func fromRow(row sqlc.AuditLog) Entry {
+	return Entry{
+		ID:        row.ID,
+		Event:     row.Event,
+		Subject:   row.Subject,
+		Actor:     row.Actor,
+		Payload:   row.Payload,
+		PrevHash:  row.PrevHash,
+		Hash:      row.Hash,
+		CreatedAt: row.CreatedAt.UTC(),
+	}
+}
+
+// ComputeHash - hash записи: hex(SHA-256) от полей prev_hash, created_at
+// (RFC 3339 в UTC, как в экспорте), event, subject, actor и payload,
+// каждое в виде netstring "<длина в байтах>:<значение>,". ID в hash
+// не входит: последовательность может иметь пропуски.
+func ComputeHash(e Entry) string {
+	h := sha256.New()
+	for _, field := range []string{
+		e.PrevHash,
+		e.CreatedAt.UTC().Format(time.RFC3339Nano),
+		e.Event,
+		e.Subject,
+		e.Actor,
+		e.Payload,
+	} {
+		h.Write([]byte(strconv.Itoa(len(field)) + ":" + field + ","))
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// Log - журнал событий загрузки: только добавление, записи связаны
+// цепочкой хешей, поэтому изменение или удаление любой записи
+// обнаруживается проверкой (Verify) и по экспорту.
+type Log struct {
+	queries *sqlc.Queries
+	now     func() time.Time
+
+	mu sync.Mutex // добавление в конец цепочки внутри экземпляра
+}
+
+// NewLog создаёт журнал аудита.
+func NewLog(queries *sqlc.Queries) *Log {
+	return &Log{queries: queries, now: time.Now}
+}
+
+// Append добавляет запись в конец цепочки. payload сериализуется в JSON.
+// Если другой экземпляр сервиса успел продолжить цепочку (UNIQUE на
+// prev_hash), запись пересчитывается от нового конца.
+func (l *Log) Append(ctx context.Context, event, subject, actor string, payload interface{}) (Entry, error) {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return Entry{}, fmt.Errorf("failed to encode audit payload: %w", err)
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	for attempt := 1; ; attempt++ {
+		prev := GenesisHash
+		last, err := l.queries.GetLastAuditEntry(ctx)
+		switch {
+		case err == nil:
+			prev = last.Hash
+		case !errors.Is(err, sql.ErrNoRows):
+			return Entry{}, fmt.Errorf("failed to read audit chain head: %w", err)
+		}
+
+		// Точность timestamptz - микросекунды: hash считается от сохраняемого значения
+		entry := Entry{
+			Event:     event,
+			Subject:   subject,
+			Actor:     actor,
+			Payload:   string(body),
+			PrevHash:  prev,
+			CreatedAt: l.now().UTC().Truncate(time.Microsecond),
+		}
+		entry.Hash = ComputeHash(entry)
+
+		row, err := l.queries.AppendAuditEntry(ctx, sqlc.AppendAuditEntryParams{
+			Event:     entry.Event,
+			Subject:   entry.Subject,
+			Actor:     entry.Actor,
+			Payload:   entry.Payload,
+			PrevHash:  entry.PrevHash,
+			Hash:      entry.Hash,
+			CreatedAt: entry.CreatedAt,
+		})
+		if err == nil {
+			entry.ID = row.ID
+			return entry, nil
+		}
+		if attempt >= appendAttempts || ctx.Err() != nil {
+			return Entry{}, fmt.Errorf("failed to append audit entry: %w", err)
+		}
+	}
+}
+
+// Export передаёт fn записи журнала после afterID по порядку.
+func (l *Log) Export(ctx context.Context, afterID int64, fn func(Entry) error) error {
+	for {
+		rows, err := l.queries.ListAuditEntries(ctx, sqlc.ListAuditEntriesParams{ID: afterID, Limit: 1000})
+		if err != nil {
+			return fmt.Errorf("failed to list audit entries: %w", err)
+		}
+		for _, row := range rows {
+			if err := fn(fromRow(row)); err != nil {
+				return err
+			}
+			afterID = row.ID
+		}
+		if len(rows) < 1000 {
+			return nil
+		}
+	}
+}
+
+// VerifyResult - итог проверки цепочки
+type VerifyResult struct {
+	Valid    bool   `json:"valid"`
+	Entries  int64  `json:"entries"`             // проверено записей
+	Head     string `json:"head"`                // hash последней корректной записи
+	BrokenAt *int64 `json:"broken_at,omitempty"` // ID первой некорректной записи
+	Error    string `json:"error,omitempty"`
+}
+
+// Verify проверяет всю цепочку от первой записи.
+func (l *Log) Verify(ctx context.Context) (VerifyResult, error) {
+	v := NewVerifier()
+	err := l.Export(ctx, 0, v.Add)
+	result := VerifyResult{Valid: err == nil, Entries: v.Entries(), Head: v.Head()}
+	if errors.Is(err, ErrChainBroken) {
+		id := v.brokenAt
+		result.BrokenAt = &id
+		result.Error = err.Error()
+		return result, nil
+	}
+	return result, err
+}
+
+// Verifier проверяет записи цепочки по порядку (в том числе по экспорту,
+// без доступа к БД).
+type Verifier struct {
+	prev     string
+	entries  int64
+	brokenAt int64
+}
+
+// NewVerifier - проверка цепочки с первой записи
+func NewVerifier() *Verifier {
+	return &Verifier{prev: GenesisHash}
+}
+
+// Add проверяет очередную запись: prev_hash равен hash предыдущей,
+// hash пересчитывается из полей записи.
+func (v *Verifier) Add(e Entry) error {
+	if e.PrevHash != v.prev {
+		v.brokenAt = e.ID
+		return fmt.Errorf("%w at entry %d: prev_hash %s does not match previous hash %s", ErrChainBroken, e.ID, e.PrevHash, v.prev)
+	}
+	if hash := ComputeHash(e); hash != e.Hash {
+		v.brokenAt = e.ID
+		return fmt.Errorf("%w at entry %d: stored hash %s, computed %s", ErrChainBroken, e.ID, e.Hash, hash)
+	}
+	v.prev = e.Hash
+	v.entries++
+	return nil
+}
+
+// Entries - число проверенных записей
+func (v *Verifier) Entries() int64 {
+	return v.entries
+}
+
+// Head - hash последней проверенной записи
+func (v *Verifier) Head() string {
+	return v.prev
+}
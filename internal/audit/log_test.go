@@ -0,0 +1,125 @@
+package audit
+
+import (
+	"TSVProcessingService/db/sqlc"
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	_ "modernc.org/sqlite"
+)
+
+func setupAuditDB(t *testing.T) *sql.DB {
+	db, err := sql.Open("sqlite", ":memory:")
+	require.NoError(t, err)
+	db.SetMaxOpenConns(1)
+	t.Cleanup(func() { db.Close() })
+
+	_, err = db.Exec(`
+	CREATE TABLE audit_log (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		event TEXT NOT NULL,
+		subject TEXT NOT NULL,
+		actor TEXT NOT NULL,
+		payload TEXT NOT NULL,
+		prev_hash TEXT NOT NULL UNIQUE,
+		hash TEXT NOT NULL UNIQUE,
+		created_at DATETIME NOT NULL,
+		updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		change_seq INTEGER NOT NULL DEFAULT 0
+	);`)
+	require.NoError(t, err)
+	return db
+}
+
+func newTestLog(db *sql.DB) *Log {
+	l := NewLog(sqlc.New(db))
+	now := time.Date(2026, 3, 2, 10, 0, 0, 123456789, time.UTC)
+	l.now = func() time.Time {
+		now = now.Add(time.Second)
+		return now
+	}
+	return l
+}
+
+func TestLog_AppendBuildsHashChain(t *testing.T) {
+	db := setupAuditDB(t)
+	ctx := context.Background()
+	l := newTestLog(db)
+
+	first, err := l.Append(ctx, EventFileIngested, "a.tsv", "watch", map[string]interface{}{"file_id": 1, "rows_processed": 10})
+	require.NoError(t, err)
+	second, err := l.Append(ctx, EventFileSuperseded, "a.tsv", "api:0123456789ab", map[string]interface{}{"superseded_by": "b.tsv"})
+	require.NoError(t, err)
+
+	assert.Equal(t, GenesisHash, first.PrevHash)
+	assert.Equal(t, first.Hash, second.PrevHash)
+	assert.Len(t, second.Hash, 64)
+	assert.Equal(t, ComputeHash(second), second.Hash)
+
+	result, err := l.Verify(ctx)
+	require.NoError(t, err)
+	assert.True(t, result.Valid)
+	assert.Equal(t, int64(2), result.Entries)
+	assert.Equal(t, second.Hash, result.Head)
+}
+
+func TestLog_VerifyDetectsRewrittenEntry(t *testing.T) {
+	db := setupAuditDB(t)
+	ctx := context.Background()
+	l := newTestLog(db)
+
+	for _, rows := range []int{10, 20, 30} {
+		_, err := l.Append(ctx, EventFileIngested, "a.tsv", "watch", map[string]int{"rows_processed": rows})
+		require.NoError(t, err)
+	}
+
+	// В PostgreSQL изменение запрещено триггером; здесь имитируем обход
+	_, err := db.Exec(`UPDATE audit_log SET payload = '{"rows_processed":25}' WHERE id = 2`)
+	require.NoError(t, err)
+
+	result, err := l.Verify(ctx)
+	require.NoError(t, err)
+	assert.False(t, result.Valid)
+	require.NotNil(t, result.BrokenAt)
+	assert.Equal(t, int64(2), *result.BrokenAt)
+	assert.Equal(t, int64(1), result.Entries)
+	assert.Contains(t, result.Error, "computed")
+}
+
+func TestVerifier_ExportedEntries(t *testing.T) {
+	db := setupAuditDB(t)
+	ctx := context.Background()
+	l := newTestLog(db)
+
+	for _, subject := range []string{"a.tsv", "b <&> \"c\".tsv", "d.tsv"} {
+		_, err := l.Append(ctx, EventFileIngested, subject, "watch", map[string]string{"note": "x\ny"})
+		require.NoError(t, err)
+	}
+
+	// Экспорт в NDJSON и проверка без доступа к БД
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	require.NoError(t, l.Export(ctx, 0, func(e Entry) error { return enc.Encode(e) }))
+
+	dec := json.NewDecoder(&buf)
+	v := NewVerifier()
+	var entries []Entry
+	for dec.More() {
+		var e Entry
+		require.NoError(t, dec.Decode(&e))
+		require.NoError(t, v.Add(e))
+		entries = append(entries, e)
+	}
+	assert.Equal(t, int64(3), v.Entries())
+
+	// Удалённая из середины запись разрывает цепочку
+	v = NewVerifier()
+	require.NoError(t, v.Add(entries[0]))
+	assert.ErrorIs(t, v.Add(entries[2]), ErrChainBroken)
+}
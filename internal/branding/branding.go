@@ -0,0 +1,140 @@
+// internal/branding/branding.go
+package branding
+
+import (
+	"TSVProcessingService/internal/config"
+	"encoding/base64"
+	"fmt"
+	"log/slog"
+	"mime"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/jung-kurt/gofpdf/v2"
+)
+
+// Resolve возвращает оформление для источника файла: настройки tenant
+// (source "tenant:<name>") поверх общих. Для остальных источников и
+// отчётов без источника - общие настройки.
+func Resolve(cfg config.ReportConfig, source string) config.BrandingConfig {
+	b := cfg.Branding
+	name, ok := strings.CutPrefix(source, "tenant:")
+	if !ok {
+		return b
+	}
+	tenant, ok := cfg.Tenants[name]
+	if !ok {
+		return b
+	}
+
+	override := func(dst *string, value string) {
+		if value != "" {
+			*dst = value
+		}
+	}
+	override(&b.CompanyName, tenant.CompanyName)
+	override(&b.LogoPath, tenant.LogoPath)
+	override(&b.HeaderText, tenant.HeaderText)
+	override(&b.FooterText, tenant.FooterText)
+	override(&b.PrimaryColor, tenant.PrimaryColor)
+	override(&b.TextColor, tenant.TextColor)
+	return b
+}
+
+// RGB разбирает цвет #RRGGBB; ok=false для пустого или некорректного значения
+func RGB(hex string) (r, g, b int, ok bool) {
+	if len(hex) != 7 || hex[0] != '#' {
+		return 0, 0, 0, false
+	}
+	v, err := strconv.ParseUint(hex[1:], 16, 32)
+	if err != nil {
+		return 0, 0, 0, false
+	}
+	return int(v >> 16 & 0xFF), int(v >> 8 & 0xFF), int(v & 0xFF), true
+}
+
+// ApplyPDF настраивает колонтитулы документа (логотип, название компании,
+// тексты, номер страницы) и цвет текста. Вызывается до первого AddPage.
+// Встроенные шрифты PDF поддерживают только латиницу (cp1252).
+func ApplyPDF(pdf *gofpdf.Fpdf, b config.BrandingConfig) {
+	tr := pdf.UnicodeTranslatorFromDescriptor("")
+
+	logo := ""
+	if b.LogoPath != "" {
+		pdf.RegisterImageOptions(b.LogoPath, gofpdf.ImageOptions{ReadDpi: true})
+		if err := pdf.Error(); err != nil {
+			// Отчёт важнее логотипа: продолжаем без него
+			slog.Warn("⚠️ Failed to load logo", "component", "branding", "logo", b.LogoPath, "error", err)
+			pdf.ClearError()
+		} else {
+			logo = b.LogoPath
+		}
+	}
+
+	if logo != "" || b.CompanyName != "" || b.HeaderText != "" {
+		pdf.SetHeaderFunc(func() {
+			left, top, right, _ := pdf.GetMargins()
+			x := left
+			if logo != "" {
+				pdf.ImageOptions(logo, left, top, 0, 12, false, gofpdf.ImageOptions{}, 0, "")
+				x += 30
+			}
+			pdf.SetXY(x, top)
+			pdf.SetFont("Arial", "B", 12)
+			setColor(pdf.SetTextColor, b.PrimaryColor)
+			pdf.CellFormat(0, 6, tr(b.CompanyName), "", 2, "L", false, 0, "")
+			pdf.SetFont("Arial", "", 9)
+			setColor(pdf.SetTextColor, b.TextColor)
+			pdf.CellFormat(0, 5, tr(b.HeaderText), "", 2, "L", false, 0, "")
+
+			width, _ := pdf.GetPageSize()
+			setColor(pdf.SetDrawColor, b.PrimaryColor)
+			pdf.Line(left, top+14, width-right, top+14)
+			pdf.SetXY(left, top+18)
+		})
+	}
+
+	pdf.SetFooterFunc(func() {
+		left, _, _, _ := pdf.GetMargins()
+		pdf.SetY(-15)
+		pdf.SetFont("Arial", "I", 8)
+		setColor(pdf.SetTextColor, b.TextColor)
+		if b.FooterText != "" {
+			pdf.CellFormat(0, 10, tr(b.FooterText), "", 0, "L", false, 0, "")
+			pdf.SetX(left)
+		}
+		pdf.CellFormat(0, 10, fmt.Sprintf("Page %d", pdf.PageNo()), "", 0, "R", false, 0, "")
+	})
+
+	setColor(pdf.SetTextColor, b.TextColor)
+}
+
+// Title выводит заголовок отчёта основным цветом оформления
+func Title(pdf *gofpdf.Fpdf, b config.BrandingConfig, h float64, text string) {
+	setColor(pdf.SetTextColor, b.PrimaryColor)
+	pdf.Cell(40, h, text)
+	setColor(pdf.SetTextColor, b.TextColor)
+}
+
+// LogoDataURI - логотип в виде data: URI для HTML-отчётов (письма
+// не загружают внешние картинки). Пустая строка, если логотипа нет.
+func LogoDataURI(b config.BrandingConfig) string {
+	if b.LogoPath == "" {
+		return ""
+	}
+	data, err := os.ReadFile(b.LogoPath)
+	if err != nil {
+		slog.Warn("⚠️ Failed to read logo", "component", "branding", "logo", b.LogoPath, "error", err)
+		return ""
+	}
+	contentType := mime.TypeByExtension(strings.ToLower(filepath.Ext(b.LogoPath)))
+	return "data:" + contentType + ";base64," + base64.StdEncoding.EncodeToString(data)
+}
+
+// setColor применяет цвет #RRGGBB; некорректный цвет - чёрный
+func setColor(set func(r, g, b int), hex string) {
+	r, g, b, _ := RGB(hex)
+	set(r, g, b)
+}
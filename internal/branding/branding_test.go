@@ -0,0 +1,95 @@
+package branding
+
+import (
+	"TSVProcessingService/internal/config"
+	"bytes"
+	"image"
+	"image/color"
+	"image/png"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/jung-kurt/gofpdf/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeLogo(t *testing.T) string {
+	img := image.NewRGBA(image.Rect(0, 0, 4, 4))
+	img.Set(1, 1, color.RGBA{R: 255, A: 255})
+	path := filepath.Join(t.TempDir(), "logo.png")
+	f, err := os.Create(path)
+	require.NoError(t, err)
+	require.NoError(t, png.Encode(f, img))
+	require.NoError(t, f.Close())
+	return path
+}
+
+func render(t *testing.T, b config.BrandingConfig) []byte {
+	pdf := gofpdf.New("P", "mm", "A4", "")
+	ApplyPDF(pdf, b)
+	pdf.AddPage()
+	pdf.SetFont("Arial", "B", 16)
+	Title(pdf, b, 10, "Device Report")
+
+	var buf bytes.Buffer
+	require.NoError(t, pdf.Output(&buf))
+	return buf.Bytes()
+}
+
+func TestResolve(t *testing.T) {
+	cfg := config.ReportConfig{
+		Branding: config.BrandingConfig{CompanyName: "Acme", FooterText: "Confidential", PrimaryColor: "#112233"},
+		Tenants: map[string]config.BrandingConfig{
+			"partner": {CompanyName: "Partner Ltd", PrimaryColor: "#AA0000"},
+		},
+	}
+
+	b := Resolve(cfg, "tenant:partner")
+	assert.Equal(t, "Partner Ltd", b.CompanyName)
+	assert.Equal(t, "#AA0000", b.PrimaryColor)
+	assert.Equal(t, "Confidential", b.FooterText) // наследуется
+
+	assert.Equal(t, cfg.Branding, Resolve(cfg, "tenant:other"))
+	assert.Equal(t, cfg.Branding, Resolve(cfg, "directory"))
+	assert.Equal(t, cfg.Branding, Resolve(cfg, ""))
+}
+
+func TestRGB(t *testing.T) {
+	r, g, b, ok := RGB("#2D3E50")
+	assert.True(t, ok)
+	assert.Equal(t, []int{0x2D, 0x3E, 0x50}, []int{r, g, b})
+
+	for _, bad := range []string{"", "2D3E50", "#2D3E5", "#GGGGGG"} {
+		_, _, _, ok := RGB(bad)
+		assert.False(t, ok, bad)
+	}
+}
+
+func TestApplyPDF(t *testing.T) {
+	logo := writeLogo(t)
+
+	out := render(t, config.BrandingConfig{
+		CompanyName:  "Acme Café",
+		LogoPath:     logo,
+		HeaderText:   "Device monitoring",
+		FooterText:   "Confidential",
+		PrimaryColor: "#AA0000",
+	})
+	assert.True(t, bytes.HasPrefix(out, []byte("%PDF")))
+	assert.Contains(t, string(out), "/Subtype /Image")
+
+	// Недоступный логотип не мешает сформировать отчёт
+	out = render(t, config.BrandingConfig{CompanyName: "Acme", LogoPath: filepath.Join(t.TempDir(), "missing.png")})
+	assert.True(t, bytes.HasPrefix(out, []byte("%PDF")))
+	assert.NotContains(t, string(out), "/Subtype /Image")
+}
+
+func TestLogoDataURI(t *testing.T) {
+	assert.Empty(t, LogoDataURI(config.BrandingConfig{}))
+
+	uri := LogoDataURI(config.BrandingConfig{LogoPath: writeLogo(t)})
+	assert.True(t, strings.HasPrefix(uri, "data:image/png;base64,"), uri)
+}
@@ -0,0 +1,193 @@
+// internal/breaker/breaker.go
+package breaker
+
+import (
+	"TSVProcessingService/internal/clock"
+	"errors"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// Состояния автомата
+const (
+	StateClosed   = "closed"    // запросы проходят
+	StateOpen     = "open"      // запросы отклоняются сразу
+	StateHalfOpen = "half_open" // пропускаются пробные запросы
+)
+
+// ErrOpen - запрос отклонён: автомат разомкнут или пробные запросы заняты
+var ErrOpen = errors.New("circuit breaker is open")
+
+// Config - пороги автомата
+type Config struct {
+	FailureThreshold int              // неудачных запросов подряд до размыкания
+	OpenTimeout      time.Duration    // время в разомкнутом состоянии до пробных запросов
+	HalfOpenProbes   int              // одновременных пробных запросов; столько же успехов замыкает
+	IsFailure        func(error) bool // ошибки, считающиеся отказом (nil - любая ошибка)
+}
+
+// Stats - состояние автомата для /health/ready и метрик
+type Stats struct {
+	State               string     `json:"state"`
+	ConsecutiveFailures int        `json:"consecutive_failures"`
+	Opens               int64      `json:"opens"`    // сколько раз размыкался
+	Rejected            int64      `json:"rejected"` // отклонённых запросов
+	OpenedAt            *time.Time `json:"opened_at,omitempty"`
+	LastError           string     `json:"last_error,omitempty"`
+}
+
+// Breaker - автомат защиты (circuit breaker). После FailureThreshold
+// отказов подряд размыкается и OpenTimeout отклоняет запросы без
+// обращения к ресурсу, затем пропускает HalfOpenProbes пробных
+// запросов: их успех замыкает автомат, отказ снова размыкает.
+type Breaker struct {
+	name  string
+	cfg   Config
+	clock clock.Clock
+
+	mu        sync.Mutex
+	state     string
+	failures  int // отказов подряд
+	probes    int // пробных запросов в работе
+	successes int // успешных запросов в half_open
+	openedAt  time.Time
+	opens     int64
+	rejected  int64
+	lastError string
+}
+
+// New создаёт замкнутый автомат
+func New(name string, cfg Config, c clock.Clock) *Breaker {
+	if cfg.FailureThreshold <= 0 {
+		cfg.FailureThreshold = 1
+	}
+	if cfg.HalfOpenProbes <= 0 {
+		cfg.HalfOpenProbes = 1
+	}
+	return &Breaker{name: name, cfg: cfg, clock: c, state: StateClosed}
+}
+
+// Allow решает, пропускать ли запрос. done освобождает место пробного
+// запроса и должна быть вызвана по его завершении.
+func (b *Breaker) Allow() (done func(), err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.state == StateOpen {
+		if b.clock.Since(b.openedAt) < b.cfg.OpenTimeout {
+			b.rejected++
+			return nil, ErrOpen
+		}
+		b.setState(StateHalfOpen)
+		b.probes, b.successes = 0, 0
+	}
+	if b.state == StateClosed {
+		return func() {}, nil
+	}
+
+	if b.probes >= b.cfg.HalfOpenProbes {
+		b.rejected++
+		return nil, ErrOpen
+	}
+	b.probes++
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			b.mu.Lock()
+			if b.probes > 0 {
+				b.probes--
+			}
+			b.mu.Unlock()
+		})
+	}, nil
+}
+
+// Record учитывает результат обращения к ресурсу. Ошибки, не
+// считающиеся отказом (например, sql.ErrNoRows), равны успеху.
+func (b *Breaker) Record(err error) {
+	failure := err != nil && (b.cfg.IsFailure == nil || b.cfg.IsFailure(err))
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if !failure {
+		switch b.state {
+		case StateClosed:
+			b.failures = 0
+		case StateHalfOpen:
+			b.successes++
+			if b.successes >= b.cfg.HalfOpenProbes {
+				b.failures = 0
+				b.setState(StateClosed)
+			}
+		}
+		return
+	}
+
+	b.lastError = err.Error()
+	switch b.state {
+	case StateClosed:
+		b.failures++
+		if b.failures >= b.cfg.FailureThreshold {
+			b.trip()
+		}
+	case StateHalfOpen:
+		b.failures++
+		b.trip()
+	}
+	// В open результаты запросов, начатых до размыкания, не учитываются
+}
+
+// RetryAfter - через сколько стоит повторить отклонённый запрос
+func (b *Breaker) RetryAfter() time.Duration {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.state == StateOpen {
+		if left := b.cfg.OpenTimeout - b.clock.Since(b.openedAt); left > time.Second {
+			return left
+		}
+	}
+	return time.Second
+}
+
+// State - текущее состояние (open с истёкшим OpenTimeout до первого
+// запроса остаётся open)
+func (b *Breaker) State() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.state
+}
+
+// Stats - снимок состояния
+func (b *Breaker) Stats() Stats {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	stats := Stats{
+		State:               b.state,
+		ConsecutiveFailures: b.failures,
+		Opens:               b.opens,
+		Rejected:            b.rejected,
+		LastError:           b.lastError,
+	}
+	if b.state != StateClosed {
+		openedAt := b.openedAt
+		stats.OpenedAt = &openedAt
+	}
+	return stats
+}
+
+// trip размыкает автомат (b.mu должен быть захвачен)
+func (b *Breaker) trip() {
+	b.openedAt = b.clock.Now()
+	b.opens++
+	b.setState(StateOpen)
+}
+
+func (b *Breaker) setState(state string) {
+	if b.state == state {
+		return
+	}
+	slog.Warn("Circuit breaker state changed", "component", "breaker", "name", b.name, "from", b.state, "to", state, "failures", b.failures, "last_error", b.lastError)
+	b.state = state
+}
@@ -0,0 +1,117 @@
+package breaker
+
+import (
+	"TSVProcessingService/internal/clock"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	errTimeout  = errors.New("timeout")
+	errNotFound = errors.New("not found")
+)
+
+func newTestBreaker() (*Breaker, *clock.Fake) {
+	fake := clock.NewFake(time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC))
+	b := New("test", Config{
+		FailureThreshold: 3,
+		OpenTimeout:      30 * time.Second,
+		HalfOpenProbes:   1,
+		IsFailure:        func(err error) bool { return errors.Is(err, errTimeout) },
+	}, fake)
+	return b, fake
+}
+
+func TestBreaker_OpensAfterConsecutiveFailures(t *testing.T) {
+	b, _ := newTestBreaker()
+
+	b.Record(errTimeout)
+	b.Record(errTimeout)
+	b.Record(nil) // успех сбрасывает счётчик
+	b.Record(errTimeout)
+	b.Record(errNotFound) // не отказ
+	b.Record(errTimeout)
+	assert.Equal(t, StateClosed, b.State())
+
+	b.Record(errTimeout)
+	b.Record(errTimeout)
+	assert.Equal(t, StateOpen, b.State())
+
+	_, err := b.Allow()
+	assert.ErrorIs(t, err, ErrOpen)
+	stats := b.Stats()
+	assert.Equal(t, int64(1), stats.Opens)
+	assert.Equal(t, int64(1), stats.Rejected)
+	assert.Equal(t, "timeout", stats.LastError)
+	require.NotNil(t, stats.OpenedAt)
+}
+
+func tripBreaker(b *Breaker) {
+	for i := 0; i < 3; i++ {
+		b.Record(errTimeout)
+	}
+}
+
+func TestBreaker_RetryAfter(t *testing.T) {
+	b, fake := newTestBreaker()
+	tripBreaker(b)
+
+	assert.Equal(t, 30*time.Second, b.RetryAfter())
+	fake.Advance(29500 * time.Millisecond)
+	assert.Equal(t, time.Second, b.RetryAfter())
+}
+
+func TestBreaker_HalfOpenProbeCloses(t *testing.T) {
+	b, fake := newTestBreaker()
+	tripBreaker(b)
+
+	fake.Advance(30 * time.Second)
+	done, err := b.Allow()
+	require.NoError(t, err)
+	assert.Equal(t, StateHalfOpen, b.State())
+
+	// Пока пробный запрос выполняется, остальные отклоняются
+	_, err = b.Allow()
+	assert.ErrorIs(t, err, ErrOpen)
+
+	b.Record(nil)
+	done()
+	assert.Equal(t, StateClosed, b.State())
+	_, err = b.Allow()
+	assert.NoError(t, err)
+}
+
+func TestBreaker_HalfOpenProbeFailureReopens(t *testing.T) {
+	b, fake := newTestBreaker()
+	tripBreaker(b)
+
+	fake.Advance(30 * time.Second)
+	done, err := b.Allow()
+	require.NoError(t, err)
+	b.Record(errTimeout)
+	done()
+
+	assert.Equal(t, StateOpen, b.State())
+	assert.Equal(t, int64(2), b.Stats().Opens)
+	_, err = b.Allow()
+	assert.ErrorIs(t, err, ErrOpen)
+}
+
+func TestBreaker_ProbeWithoutQueryFreesSlot(t *testing.T) {
+	b, fake := newTestBreaker()
+	tripBreaker(b)
+
+	fake.Advance(30 * time.Second)
+	done, err := b.Allow()
+	require.NoError(t, err)
+	done()
+	done() // повторный вызов безопасен
+
+	_, err = b.Allow()
+	assert.NoError(t, err)
+	assert.Equal(t, StateHalfOpen, b.State())
+}
@@ -0,0 +1,131 @@
+// internal/cache/cache.go
+package cache
+
+import (
+	"encoding/json"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Cache - хранилище закэшированных ответов. Значения хранятся
+// сериализованными, чтобы реализации были взаимозаменяемы.
+type Cache interface {
+	Get(key string) ([]byte, bool)
+	Set(key string, value []byte, ttl time.Duration)
+	Delete(keys ...string)
+	DeletePrefix(prefix string)
+}
+
+// GetJSON читает значение из кэша и десериализует его в dest.
+func GetJSON(c Cache, key string, dest interface{}) bool {
+	data, ok := c.Get(key)
+	if !ok {
+		return false
+	}
+	return json.Unmarshal(data, dest) == nil
+}
+
+// SetJSON сериализует значение и сохраняет его в кэш.
+func SetJSON(c Cache, key string, value interface{}, ttl time.Duration) {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return
+	}
+	c.Set(key, data, ttl)
+}
+
+// entry - значение in-memory кэша
+type entry struct {
+	value     []byte
+	expiresAt time.Time
+}
+
+// MemoryCache - in-process кэш с TTL и ограничением количества записей.
+type MemoryCache struct {
+	mu         sync.Mutex
+	entries    map[string]entry
+	maxEntries int
+	now        func() time.Time
+}
+
+// NewMemoryCache создаёт in-memory кэш не более чем на maxEntries записей.
+func NewMemoryCache(maxEntries int) *MemoryCache {
+	return &MemoryCache{
+		entries:    make(map[string]entry),
+		maxEntries: maxEntries,
+		now:        time.Now,
+	}
+}
+
+func (c *MemoryCache) Get(key string) ([]byte, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	e, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+	if c.now().After(e.expiresAt) {
+		delete(c.entries, key)
+		return nil, false
+	}
+	return e.value, true
+}
+
+func (c *MemoryCache) Set(key string, value []byte, ttl time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if _, exists := c.entries[key]; !exists && c.maxEntries > 0 && len(c.entries) >= c.maxEntries {
+		c.evict()
+	}
+	c.entries[key] = entry{value: value, expiresAt: c.now().Add(ttl)}
+}
+
+func (c *MemoryCache) Delete(keys ...string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for _, key := range keys {
+		delete(c.entries, key)
+	}
+}
+
+func (c *MemoryCache) DeletePrefix(prefix string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for key := range c.entries {
+		if strings.HasPrefix(key, prefix) {
+			delete(c.entries, key)
+		}
+	}
+}
+
+// evict освобождает место: сначала удаляет просроченные записи,
+// затем, если этого недостаточно, произвольную запись.
+// Вызывается под мьютексом.
+func (c *MemoryCache) evict() {
+	now := c.now()
+	for key, e := range c.entries {
+		if now.After(e.expiresAt) {
+			delete(c.entries, key)
+		}
+	}
+	if len(c.entries) < c.maxEntries {
+		return
+	}
+	for key := range c.entries {
+		delete(c.entries, key)
+		return
+	}
+}
+
+// NopCache - отключённый кэш: ничего не хранит.
+type NopCache struct{}
+
+func (NopCache) Get(string) ([]byte, bool)         { return nil, false }
+func (NopCache) Set(string, []byte, time.Duration) {}
+func (NopCache) Delete(...string)                  {}
+func (NopCache) DeletePrefix(string)               {}
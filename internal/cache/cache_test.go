@@ -0,0 +1,78 @@
+package cache
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func setupTestCache(maxEntries int) (*MemoryCache, *time.Time) {
+	c := NewMemoryCache(maxEntries)
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	c.now = func() time.Time { return now }
+	return c, &now
+}
+
+func TestMemoryCache_TTL(t *testing.T) {
+	c, now := setupTestCache(0)
+
+	c.Set("a", []byte("1"), 30*time.Second)
+	value, ok := c.Get("a")
+	assert.True(t, ok)
+	assert.Equal(t, []byte("1"), value)
+
+	*now = now.Add(31 * time.Second)
+	_, ok = c.Get("a")
+	assert.False(t, ok)
+}
+
+func TestMemoryCache_DeleteAndPrefix(t *testing.T) {
+	c, _ := setupTestCache(0)
+
+	c.Set("unit:1:meta", []byte("1"), time.Minute)
+	c.Set("unit:1:data", []byte("2"), time.Minute)
+	c.Set("unit:2:meta", []byte("3"), time.Minute)
+	c.Set("other", []byte("4"), time.Minute)
+
+	c.DeletePrefix("unit:1:")
+	_, ok := c.Get("unit:1:meta")
+	assert.False(t, ok)
+	_, ok = c.Get("unit:1:data")
+	assert.False(t, ok)
+	_, ok = c.Get("unit:2:meta")
+	assert.True(t, ok)
+
+	c.Delete("other", "missing")
+	_, ok = c.Get("other")
+	assert.False(t, ok)
+}
+
+func TestMemoryCache_MaxEntries(t *testing.T) {
+	c, now := setupTestCache(2)
+
+	c.Set("expired", []byte("1"), time.Second)
+	c.Set("live", []byte("2"), time.Minute)
+	*now = now.Add(2 * time.Second)
+
+	// Сначала вытесняются просроченные записи
+	c.Set("new", []byte("3"), time.Minute)
+	_, ok := c.Get("live")
+	assert.True(t, ok)
+	_, ok = c.Get("new")
+	assert.True(t, ok)
+
+	c.Set("another", []byte("4"), time.Minute)
+	assert.Len(t, c.entries, 2)
+}
+
+func TestJSONHelpers(t *testing.T) {
+	c, _ := setupTestCache(0)
+
+	SetJSON(c, "k", map[string]int{"total": 5}, time.Minute)
+	var dest map[string]int
+	assert.True(t, GetJSON(c, "k", &dest))
+	assert.Equal(t, 5, dest["total"])
+
+	assert.False(t, GetJSON(NopCache{}, "k", &dest))
+}
@@ -0,0 +1,324 @@
+// internal/cache/redis.go
+package cache
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// RedisOptions - параметры подключения к Redis
+type RedisOptions struct {
+	Address     string
+	Password    string
+	DB          int
+	KeyPrefix   string // пространство имён ключей сервиса
+	PoolSize    int
+	DialTimeout time.Duration
+	OpTimeout   time.Duration
+}
+
+// RedisCache - кэш в Redis, общий для нескольких экземпляров сервиса.
+// Реализует минимальное подмножество протокола RESP (GET/SET/DEL/SCAN).
+// Ошибки Redis не прерывают запросы: кэш считается промахом и
+// данные читаются из БД.
+type RedisCache struct {
+	opts RedisOptions
+	pool chan *redisConn
+}
+
+// redisConn - соединение с Redis
+type redisConn struct {
+	conn net.Conn
+	r    *bufio.Reader
+	w    *bufio.Writer
+}
+
+// errRedisNil - ответ Redis "ключ отсутствует"
+var errRedisNil = errors.New("redis: nil")
+
+// NewRedisCache создаёт кэш и проверяет доступность Redis.
+func NewRedisCache(opts RedisOptions) (*RedisCache, error) {
+	if opts.PoolSize <= 0 {
+		opts.PoolSize = 10
+	}
+	if opts.DialTimeout <= 0 {
+		opts.DialTimeout = 2 * time.Second
+	}
+	if opts.OpTimeout <= 0 {
+		opts.OpTimeout = time.Second
+	}
+
+	c := &RedisCache{
+		opts: opts,
+		pool: make(chan *redisConn, opts.PoolSize),
+	}
+
+	if _, err := c.do("PING"); err != nil {
+		return nil, fmt.Errorf("failed to connect to redis at %s: %w", opts.Address, err)
+	}
+	return c, nil
+}
+
+func (c *RedisCache) Get(key string) ([]byte, bool) {
+	reply, err := c.do("GET", c.opts.KeyPrefix+key)
+	if err != nil {
+		if !errors.Is(err, errRedisNil) {
+			slog.Warn("⚠️ Redis GET failed", "error", err)
+		}
+		return nil, false
+	}
+	value, ok := reply.([]byte)
+	return value, ok
+}
+
+func (c *RedisCache) Set(key string, value []byte, ttl time.Duration) {
+	args := []string{"SET", c.opts.KeyPrefix + key, string(value)}
+	if ttl > 0 {
+		args = append(args, "PX", strconv.FormatInt(ttl.Milliseconds(), 10))
+	}
+	if _, err := c.do(args...); err != nil {
+		slog.Warn("⚠️ Redis SET failed", "error", err)
+	}
+}
+
+func (c *RedisCache) Delete(keys ...string) {
+	if len(keys) == 0 {
+		return
+	}
+	args := make([]string, 0, len(keys)+1)
+	args = append(args, "DEL")
+	for _, key := range keys {
+		args = append(args, c.opts.KeyPrefix+key)
+	}
+	if _, err := c.do(args...); err != nil {
+		slog.Warn("⚠️ Redis DEL failed", "error", err)
+	}
+}
+
+func (c *RedisCache) DeletePrefix(prefix string) {
+	pattern := escapeRedisPattern(c.opts.KeyPrefix+prefix) + "*"
+	cursor := "0"
+	for {
+		reply, err := c.do("SCAN", cursor, "MATCH", pattern, "COUNT", "100")
+		if err != nil {
+			slog.Warn("⚠️ Redis SCAN failed", "error", err)
+			return
+		}
+		next, keys, err := parseScanReply(reply)
+		if err != nil {
+			slog.Warn("⚠️ Redis SCAN failed", "error", err)
+			return
+		}
+		if len(keys) > 0 {
+			if _, err := c.do(append([]string{"DEL"}, keys...)...); err != nil {
+				slog.Warn("⚠️ Redis DEL failed", "error", err)
+				return
+			}
+		}
+		if next == "0" {
+			return
+		}
+		cursor = next
+	}
+}
+
+// Close закрывает соединения пула.
+func (c *RedisCache) Close() {
+	for {
+		select {
+		case rc := <-c.pool:
+			rc.conn.Close()
+		default:
+			return
+		}
+	}
+}
+
+// do выполняет команду на соединении из пула. Соединение с ошибкой
+// ввода-вывода закрывается и в пул не возвращается.
+func (c *RedisCache) do(args ...string) (interface{}, error) {
+	rc, err := c.getConn()
+	if err != nil {
+		return nil, err
+	}
+
+	rc.conn.SetDeadline(time.Now().Add(c.opts.OpTimeout))
+	reply, err := rc.command(args...)
+	if err != nil && !isRedisReplyError(err) {
+		rc.conn.Close()
+		return nil, err
+	}
+	c.putConn(rc)
+	return reply, err
+}
+
+func (c *RedisCache) getConn() (*redisConn, error) {
+	select {
+	case rc := <-c.pool:
+		return rc, nil
+	default:
+	}
+
+	conn, err := net.DialTimeout("tcp", c.opts.Address, c.opts.DialTimeout)
+	if err != nil {
+		return nil, err
+	}
+	rc := &redisConn{conn: conn, r: bufio.NewReader(conn), w: bufio.NewWriter(conn)}
+
+	conn.SetDeadline(time.Now().Add(c.opts.OpTimeout))
+	if c.opts.Password != "" {
+		if _, err := rc.command("AUTH", c.opts.Password); err != nil {
+			conn.Close()
+			return nil, fmt.Errorf("auth: %w", err)
+		}
+	}
+	if c.opts.DB != 0 {
+		if _, err := rc.command("SELECT", strconv.Itoa(c.opts.DB)); err != nil {
+			conn.Close()
+			return nil, fmt.Errorf("select db: %w", err)
+		}
+	}
+	return rc, nil
+}
+
+func (c *RedisCache) putConn(rc *redisConn) {
+	select {
+	case c.pool <- rc:
+	default:
+		rc.conn.Close()
+	}
+}
+
+func (rc *redisConn) command(args ...string) (interface{}, error) {
+	if err := writeCommand(rc.w, args); err != nil {
+		return nil, err
+	}
+	if err := rc.w.Flush(); err != nil {
+		return nil, err
+	}
+	return readReply(rc.r)
+}
+
+// ---------------------------------------------------------------------
+// Протокол RESP
+// ---------------------------------------------------------------------
+
+// redisReplyError - ошибка, которую вернул сам Redis (-ERR ...).
+// Соединение после неё остаётся рабочим.
+type redisReplyError string
+
+func (e redisReplyError) Error() string { return "redis: " + string(e) }
+
+func isRedisReplyError(err error) bool {
+	var replyErr redisReplyError
+	return errors.Is(err, errRedisNil) || errors.As(err, &replyErr)
+}
+
+// writeCommand кодирует команду как массив bulk-строк.
+func writeCommand(w *bufio.Writer, args []string) error {
+	if _, err := fmt.Fprintf(w, "*%d\r\n", len(args)); err != nil {
+		return err
+	}
+	for _, arg := range args {
+		if _, err := fmt.Fprintf(w, "$%d\r\n%s\r\n", len(arg), arg); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// readReply читает ответ: string (+), int64 (:), []byte ($), []interface{} (*).
+func readReply(r *bufio.Reader) (interface{}, error) {
+	line, err := r.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	line = strings.TrimSuffix(line, "\r\n")
+	if line == "" {
+		return nil, errors.New("redis: empty reply")
+	}
+
+	switch line[0] {
+	case '+':
+		return line[1:], nil
+	case '-':
+		return nil, redisReplyError(line[1:])
+	case ':':
+		return strconv.ParseInt(line[1:], 10, 64)
+	case '$':
+		size, err := strconv.Atoi(line[1:])
+		if err != nil {
+			return nil, err
+		}
+		if size < 0 {
+			return nil, errRedisNil
+		}
+		buf := make([]byte, size+2)
+		if _, err := io.ReadFull(r, buf); err != nil {
+			return nil, err
+		}
+		return buf[:size], nil
+	case '*':
+		count, err := strconv.Atoi(line[1:])
+		if err != nil {
+			return nil, err
+		}
+		if count < 0 {
+			return nil, errRedisNil
+		}
+		items := make([]interface{}, 0, count)
+		for i := 0; i < count; i++ {
+			item, err := readReply(r)
+			if err != nil && !errors.Is(err, errRedisNil) {
+				return nil, err
+			}
+			items = append(items, item)
+		}
+		return items, nil
+	default:
+		return nil, fmt.Errorf("redis: unexpected reply %q", line)
+	}
+}
+
+// parseScanReply разбирает ответ SCAN: [cursor, [keys...]].
+func parseScanReply(reply interface{}) (string, []string, error) {
+	items, ok := reply.([]interface{})
+	if !ok || len(items) != 2 {
+		return "", nil, errors.New("redis: malformed SCAN reply")
+	}
+	cursor, ok := items[0].([]byte)
+	if !ok {
+		return "", nil, errors.New("redis: malformed SCAN cursor")
+	}
+	rawKeys, ok := items[1].([]interface{})
+	if !ok {
+		return "", nil, errors.New("redis: malformed SCAN keys")
+	}
+	keys := make([]string, 0, len(rawKeys))
+	for _, raw := range rawKeys {
+		if key, ok := raw.([]byte); ok {
+			keys = append(keys, string(key))
+		}
+	}
+	return string(cursor), keys, nil
+}
+
+// escapeRedisPattern экранирует спецсимволы glob-шаблона MATCH.
+func escapeRedisPattern(s string) string {
+	var b strings.Builder
+	for _, ch := range s {
+		switch ch {
+		case '*', '?', '[', ']', '\\':
+			b.WriteByte('\\')
+		}
+		b.WriteRune(ch)
+	}
+	return b.String()
+}
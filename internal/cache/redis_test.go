@@ -0,0 +1,60 @@
+package cache
+
+import (
+	"bufio"
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteCommand(t *testing.T) {
+	var buf bytes.Buffer
+	w := bufio.NewWriter(&buf)
+
+	require.NoError(t, writeCommand(w, []string{"SET", "k", "value"}))
+	require.NoError(t, w.Flush())
+	assert.Equal(t, "*3\r\n$3\r\nSET\r\n$1\r\nk\r\n$5\r\nvalue\r\n", buf.String())
+}
+
+func TestReadReply(t *testing.T) {
+	read := func(raw string) (interface{}, error) {
+		return readReply(bufio.NewReader(strings.NewReader(raw)))
+	}
+
+	reply, err := read("+OK\r\n")
+	require.NoError(t, err)
+	assert.Equal(t, "OK", reply)
+
+	reply, err = read(":3\r\n")
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), reply)
+
+	reply, err = read("$5\r\nhel\r\n\r\n")
+	require.NoError(t, err)
+	assert.Equal(t, []byte("hel\r\n"), reply)
+
+	_, err = read("$-1\r\n")
+	assert.ErrorIs(t, err, errRedisNil)
+
+	_, err = read("-ERR unknown command\r\n")
+	assert.EqualError(t, err, "redis: ERR unknown command")
+	assert.True(t, isRedisReplyError(err))
+}
+
+func TestParseScanReply(t *testing.T) {
+	reply, err := readReply(bufio.NewReader(strings.NewReader(
+		"*2\r\n$2\r\n17\r\n*2\r\n$6\r\ntsv:k1\r\n$6\r\ntsv:k2\r\n")))
+	require.NoError(t, err)
+
+	cursor, keys, err := parseScanReply(reply)
+	require.NoError(t, err)
+	assert.Equal(t, "17", cursor)
+	assert.Equal(t, []string{"tsv:k1", "tsv:k2"}, keys)
+}
+
+func TestEscapeRedisPattern(t *testing.T) {
+	assert.Equal(t, `tsv:unit:\*\?\[x\]:`, escapeRedisPattern("tsv:unit:*?[x]:"))
+}
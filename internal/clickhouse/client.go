@@ -0,0 +1,127 @@
+// internal/clickhouse/client.go
+package clickhouse
+
+import (
+	"TSVProcessingService/db/sqlc"
+	"TSVProcessingService/internal/dto"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Options - подключение к HTTP-интерфейсу ClickHouse
+type Options struct {
+	URL      string // например http://clickhouse:8123
+	Database string
+	Table    string
+	User     string
+	Password string
+	Timeout  time.Duration
+}
+
+// Client - минимальный клиент HTTP-интерфейса ClickHouse (запросы
+// и INSERT в формате JSONEachRow)
+type Client struct {
+	opts   Options
+	client *http.Client
+}
+
+// NewClient создаёт клиент ClickHouse.
+func NewClient(opts Options) *Client {
+	if opts.Timeout <= 0 {
+		opts.Timeout = 30 * time.Second
+	}
+	if opts.Database == "" {
+		opts.Database = "default"
+	}
+	return &Client{
+		opts:   opts,
+		client: &http.Client{Timeout: opts.Timeout},
+	}
+}
+
+// table - полное имя таблицы
+func (c *Client) table() string {
+	return quoteIdent(c.opts.Database) + "." + quoteIdent(c.opts.Table)
+}
+
+// CreateTable создаёт таблицу device_data, если её нет. ReplacingMergeTree
+// по (unit_guid, id) схлопывает повторно выгруженные строки.
+func (c *Client) CreateTable(ctx context.Context) error {
+	return c.exec(ctx, `CREATE TABLE IF NOT EXISTS `+c.table()+` (
+	id Int64,
+	file_id Int64,
+	unit_guid UUID,
+	mqtt Nullable(String),
+	invid Nullable(String),
+	msg_id Nullable(String),
+	text Nullable(String),
+	context Nullable(String),
+	class Nullable(String),
+	level Nullable(Int32),
+	area Nullable(String),
+	addr Nullable(String),
+	block Nullable(String),
+	type Nullable(String),
+	bit Nullable(Int32),
+	invert_bit Nullable(Bool),
+	line_number Int32,
+	created_at DateTime64(3, 'UTC'),
+	version Int32
+) ENGINE = ReplacingMergeTree
+PARTITION BY toYYYYMM(created_at)
+ORDER BY (unit_guid, id)`, nil)
+}
+
+// Insert записывает строки одним INSERT ... FORMAT JSONEachRow
+func (c *Client) Insert(ctx context.Context, rows []sqlc.DeviceDatum) error {
+	var body bytes.Buffer
+	enc := json.NewEncoder(&body)
+	for _, row := range rows {
+		if err := enc.Encode(dto.FromDeviceDatum(row)); err != nil {
+			return err
+		}
+	}
+	return c.exec(ctx, "INSERT INTO "+c.table()+" FORMAT JSONEachRow", body.Bytes())
+}
+
+// exec выполняет запрос query; body - данные для INSERT
+func (c *Client) exec(ctx context.Context, query string, body []byte) error {
+	params := url.Values{
+		"query":                            {query},
+		"database":                         {c.opts.Database},
+		"date_time_input_format":           {"best_effort"},
+		"input_format_skip_unknown_fields": {"1"},
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost,
+		strings.TrimRight(c.opts.URL, "/")+"/?"+params.Encode(), bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	if c.opts.User != "" {
+		req.Header.Set("X-ClickHouse-User", c.opts.User)
+		req.Header.Set("X-ClickHouse-Key", c.opts.Password)
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("clickhouse: %s: %s", resp.Status, strings.TrimSpace(string(msg)))
+	}
+	return nil
+}
+
+// quoteIdent - идентификатор в обратных кавычках
+func quoteIdent(name string) string {
+	return "`" + strings.ReplaceAll(name, "`", "\\`") + "`"
+}
@@ -0,0 +1,15 @@
+// internal/clickhouse/metrics.go
+package clickhouse
+
+import "TSVProcessingService/internal/metrics"
+
+var (
+	rowsSynced = metrics.Default.NewCounter("tsv_clickhouse_rows_total",
+		"Device rows written to ClickHouse")
+	syncErrors = metrics.Default.NewCounter("tsv_clickhouse_errors_total",
+		"Failed ClickHouse sync attempts")
+	pendingFiles = metrics.Default.NewGauge("tsv_clickhouse_pending_files",
+		"Processed files not yet written to ClickHouse")
+	lagSeconds = metrics.Default.NewGauge("tsv_clickhouse_lag_seconds",
+		"Age of the oldest file waiting for ClickHouse sync")
+)
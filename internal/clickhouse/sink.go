@@ -0,0 +1,263 @@
+// internal/clickhouse/sink.go
+package clickhouse
+
+import (
+	"TSVProcessingService/db/sqlc"
+	"TSVProcessingService/internal/processor"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// SinkOptions - настройки выгрузки в ClickHouse
+type SinkOptions struct {
+	BatchRows  int           // строк в одном INSERT
+	Interval   time.Duration // период проверки очереди
+	LagWarning time.Duration // предупреждение в лог при большем отставании (0 - выключено)
+}
+
+// Result - итог одного прохода выгрузки
+type Result struct {
+	Files int   `json:"files"`
+	Rows  int64 `json:"rows"`
+}
+
+// Lag - отставание ClickHouse от PostgreSQL
+type Lag struct {
+	PendingFiles   int64      `json:"pending_files"`
+	OldestQueuedAt *time.Time `json:"oldest_queued_at"`
+	LagSeconds     float64    `json:"lag_seconds"`
+	LastSyncAt     *time.Time `json:"last_sync_at"`
+	LastError      string     `json:"last_error,omitempty"`
+}
+
+// Sink выгружает строки device_data обработанных файлов в ClickHouse.
+// Очередь файлов хранится в таблице clickhouse_sync: пока ClickHouse
+// недоступен, файлы остаются в очереди и выгружаются после восстановления
+// (из PostgreSQL, по file_id), в порядке постановки.
+type Sink struct {
+	queries *sqlc.Queries
+	client  *Client
+	opts    SinkOptions
+	ctx     context.Context // отменяется Stop
+	cancel  context.CancelFunc
+	wake    chan struct{}
+	now     func() time.Time
+
+	mu         sync.Mutex
+	lastSyncAt time.Time
+	lastError  string
+}
+
+// NewSink создаёт выгрузку в ClickHouse.
+func NewSink(queries *sqlc.Queries, client *Client, opts SinkOptions) *Sink {
+	if opts.BatchRows <= 0 {
+		opts.BatchRows = 10000
+	}
+	if opts.Interval <= 0 {
+		opts.Interval = 5 * time.Second
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Sink{
+		queries: queries,
+		client:  client,
+		opts:    opts,
+		ctx:     ctx,
+		cancel:  cancel,
+		wake:    make(chan struct{}, 1),
+		now:     time.Now,
+	}
+}
+
+// Name - имя post-processing hook
+func (s *Sink) Name() string {
+	return "clickhouse"
+}
+
+// AfterProcess ставит обработанный файл в очередь выгрузки
+func (s *Sink) AfterProcess(ctx context.Context, result processor.ProcessResult) error {
+	if !result.Status.Processed() {
+		return nil
+	}
+	if err := s.queries.EnqueueClickhouseSync(ctx, result.File.ID); err != nil {
+		return fmt.Errorf("failed to queue file for ClickHouse: %w", err)
+	}
+	s.Notify()
+	return nil
+}
+
+// Notify запускает выгрузку, не дожидаясь Interval
+func (s *Sink) Notify() {
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+}
+
+// Run выгружает очередь каждые Interval (и по Notify) до вызова Stop.
+func (s *Sink) Run() {
+	ticker := time.NewTicker(s.opts.Interval)
+	defer ticker.Stop()
+	for {
+		if result, err := s.Sync(s.ctx); err != nil {
+			if s.ctx.Err() == nil {
+				slog.Error("❌ Sync failed", "component", "clickhouse", "error", err)
+			}
+		} else if result.Files > 0 {
+			slog.Info("📤 Synced rows", "component", "clickhouse", "rows", result.Rows, "files", result.Files)
+		}
+		s.checkLag(s.ctx)
+
+		select {
+		case <-ticker.C:
+		case <-s.wake:
+		case <-s.ctx.Done():
+			slog.Info("Sink stopped", "component", "clickhouse")
+			return
+		}
+	}
+}
+
+// Stop останавливает Run
+func (s *Sink) Stop() {
+	s.cancel()
+}
+
+// Sync выгружает все файлы из очереди. При ошибке выгрузка
+// прекращается: файл остаётся в очереди и будет повторён.
+func (s *Sink) Sync(ctx context.Context) (Result, error) {
+	var result Result
+	for {
+		pending, err := s.queries.ListPendingClickhouseSync(ctx, 100)
+		if err != nil {
+			return result, fmt.Errorf("failed to list queued files: %w", err)
+		}
+		if len(pending) == 0 {
+			return result, nil
+		}
+		for _, item := range pending {
+			rows, err := s.syncFile(ctx, item.FileID)
+			if err != nil {
+				syncErrors.Inc()
+				s.setError(err)
+				if markErr := s.queries.MarkClickhouseSyncFailed(ctx, sqlc.MarkClickhouseSyncFailedParams{
+					FileID:    item.FileID,
+					LastError: sql.NullString{String: err.Error(), Valid: true},
+				}); markErr != nil {
+					slog.Error("❌ Failed to record sync error", "component", "clickhouse", "file_id", item.FileID, "error", markErr)
+				}
+				return result, fmt.Errorf("file %d: %w", item.FileID, err)
+			}
+			if err := s.queries.MarkClickhouseSynced(ctx, sqlc.MarkClickhouseSyncedParams{
+				FileID:     item.FileID,
+				RowsSynced: int32(rows),
+			}); err != nil {
+				return result, fmt.Errorf("failed to mark file %d synced: %w", item.FileID, err)
+			}
+			rowsSynced.Add(float64(rows))
+			s.setSynced()
+			result.Files++
+			result.Rows += rows
+		}
+	}
+}
+
+// syncFile выгружает строки файла пачками по BatchRows
+func (s *Sink) syncFile(ctx context.Context, fileID int64) (int64, error) {
+	var total int64
+	afterID := int64(0)
+	for {
+		rows, err := s.queries.ListDeviceDataForClickhouse(ctx, sqlc.ListDeviceDataForClickhouseParams{
+			FileID: fileID,
+			ID:     afterID,
+			Limit:  int32(s.opts.BatchRows),
+		})
+		if err != nil {
+			return total, fmt.Errorf("failed to read device data: %w", err)
+		}
+		if len(rows) == 0 {
+			return total, nil
+		}
+		if err := s.client.Insert(ctx, rows); err != nil {
+			return total, err
+		}
+		total += int64(len(rows))
+		afterID = rows[len(rows)-1].ID
+	}
+}
+
+// Lag возвращает текущее отставание выгрузки
+func (s *Sink) Lag(ctx context.Context) (Lag, error) {
+	var lag Lag
+	pending, err := s.queries.CountPendingClickhouseSync(ctx)
+	if err != nil {
+		return lag, err
+	}
+	lag.PendingFiles = pending
+	if pending > 0 {
+		oldest, err := s.queries.GetOldestPendingClickhouseSync(ctx)
+		if err != nil && !errors.Is(err, sql.ErrNoRows) {
+			return lag, err
+		}
+		if err == nil {
+			lag.OldestQueuedAt = &oldest
+			lag.LagSeconds = s.now().Sub(oldest).Seconds()
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.lastSyncAt.IsZero() {
+		lastSyncAt := s.lastSyncAt
+		lag.LastSyncAt = &lastSyncAt
+	}
+	lag.LastError = s.lastError
+	return lag, nil
+}
+
+// checkLag обновляет метрики отставания и предупреждает о большом отставании
+func (s *Sink) checkLag(ctx context.Context) {
+	lag, err := s.Lag(ctx)
+	if err != nil {
+		if ctx.Err() == nil {
+			slog.Warn("⚠️ Failed to check lag", "component", "clickhouse", "error", err)
+		}
+		return
+	}
+	pendingFiles.Set(float64(lag.PendingFiles))
+	lagSeconds.Set(lag.LagSeconds)
+	if s.opts.LagWarning > 0 && lag.LagSeconds > s.opts.LagWarning.Seconds() {
+		slog.Warn("⚠️ Sink is behind", "component", "clickhouse", "lag_seconds", lag.LagSeconds, "pending_files", lag.PendingFiles)
+	}
+}
+
+// Replay ставит в очередь повторной выгрузки обработанные файлы,
+// созданные в [from, to). Возвращает число файлов.
+func (s *Sink) Replay(ctx context.Context, from, to time.Time) (int64, error) {
+	n, err := s.queries.EnqueueClickhouseReplay(ctx, sqlc.EnqueueClickhouseReplayParams{
+		CreatedAt:   sql.NullTime{Time: from, Valid: true},
+		CreatedAt_2: sql.NullTime{Time: to, Valid: true},
+	})
+	if err != nil {
+		return 0, err
+	}
+	s.Notify()
+	return n, nil
+}
+
+func (s *Sink) setSynced() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.lastSyncAt = s.now()
+	s.lastError = ""
+}
+
+func (s *Sink) setError(err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.lastError = err.Error()
+}
@@ -0,0 +1,189 @@
+package clickhouse
+
+import (
+	"TSVProcessingService/db/sqlc"
+	"TSVProcessingService/internal/processor"
+	"bufio"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	_ "modernc.org/sqlite"
+)
+
+// fakeClickHouse принимает INSERT ... FORMAT JSONEachRow
+type fakeClickHouse struct {
+	mu      sync.Mutex
+	down    bool
+	queries []string
+	rows    []map[string]interface{}
+}
+
+func (f *fakeClickHouse) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.down {
+		http.Error(w, "Code: 210. DB::NetException: Connection refused", http.StatusServiceUnavailable)
+		return
+	}
+	query := r.URL.Query().Get("query")
+	f.queries = append(f.queries, query)
+	if strings.HasPrefix(query, "INSERT") {
+		scanner := bufio.NewScanner(r.Body)
+		for scanner.Scan() {
+			var row map[string]interface{}
+			if err := json.Unmarshal(scanner.Bytes(), &row); err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			f.rows = append(f.rows, row)
+		}
+	}
+}
+
+func setupSinkDB(t *testing.T) *sql.DB {
+	db, err := sql.Open("sqlite", ":memory:")
+	require.NoError(t, err)
+	db.SetMaxOpenConns(1)
+	t.Cleanup(func() { db.Close() })
+
+	_, err = db.Exec(`
+	CREATE TABLE files (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		filename TEXT UNIQUE NOT NULL,
+		status TEXT DEFAULT 'pending',
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		superseded_by INTEGER,
+		updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		change_seq INTEGER NOT NULL DEFAULT 0
+	);
+	CREATE TABLE device_data (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		file_id INTEGER NOT NULL,
+		unit_guid TEXT NOT NULL,
+		mqtt TEXT,
+		invid TEXT,
+		msg_id TEXT,
+		text TEXT,
+		context TEXT,
+		class TEXT,
+		level INTEGER,
+		area TEXT,
+		addr TEXT,
+		block TEXT,
+		type TEXT,
+		bit INTEGER,
+		invert_bit INTEGER DEFAULT 0,
+		line_number INTEGER NOT NULL,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		version INTEGER NOT NULL DEFAULT 1,
+		updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		change_seq INTEGER NOT NULL DEFAULT 0
+	);
+	CREATE TABLE clickhouse_sync (
+		file_id INTEGER PRIMARY KEY,
+		queued_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+		synced_at DATETIME,
+		rows_synced INTEGER NOT NULL DEFAULT 0,
+		attempts INTEGER NOT NULL DEFAULT 0,
+		last_error TEXT,
+		updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		change_seq INTEGER NOT NULL DEFAULT 0
+	);`)
+	require.NoError(t, err)
+	return db
+}
+
+func TestSink_SyncsQueuedFilesAfterOutage(t *testing.T) {
+	db := setupSinkDB(t)
+	ctx := context.Background()
+	guid := uuid.MustParse("01749246-95f6-57db-b7c3-2ae0e8be671f")
+
+	_, err := db.Exec(`INSERT INTO files (id, filename, status) VALUES (1, 'a.tsv', 'completed'), (2, 'b.tsv', 'failed')`)
+	require.NoError(t, err)
+	for i := 1; i <= 5; i++ {
+		_, err := db.Exec(`INSERT INTO device_data (file_id, unit_guid, msg_id, line_number) VALUES (1, ?, ?, ?)`,
+			guid, "msg", i)
+		require.NoError(t, err)
+	}
+
+	fake := &fakeClickHouse{down: true}
+	server := httptest.NewServer(fake)
+	defer server.Close()
+
+	queries := sqlc.New(db)
+	sink := NewSink(queries, NewClient(Options{URL: server.URL, Table: "device_data"}), SinkOptions{BatchRows: 2})
+
+	require.NoError(t, sink.AfterProcess(ctx, processor.ProcessResult{File: sqlc.File{ID: 1}, Status: "completed"}))
+	require.NoError(t, sink.AfterProcess(ctx, processor.ProcessResult{File: sqlc.File{ID: 2}, Status: "failed"}))
+
+	// ClickHouse недоступен: файл остаётся в очереди
+	_, err = sink.Sync(ctx)
+	require.Error(t, err)
+	lag, err := sink.Lag(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), lag.PendingFiles)
+	assert.NotNil(t, lag.OldestQueuedAt)
+	assert.Contains(t, lag.LastError, "503")
+
+	var attempts int
+	require.NoError(t, db.QueryRow(`SELECT attempts FROM clickhouse_sync WHERE file_id = 1`).Scan(&attempts))
+	assert.Equal(t, 1, attempts)
+
+	// После восстановления файл выгружается пачками по BatchRows
+	fake.mu.Lock()
+	fake.down = false
+	fake.mu.Unlock()
+	result, err := sink.Sync(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, Result{Files: 1, Rows: 5}, result)
+	assert.Len(t, fake.queries, 3)
+	assert.Equal(t, "INSERT INTO `default`.`device_data` FORMAT JSONEachRow", fake.queries[0])
+	require.Len(t, fake.rows, 5)
+	assert.Equal(t, guid.String(), fake.rows[0]["unit_guid"])
+
+	lag, err = sink.Lag(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, int64(0), lag.PendingFiles)
+	assert.NotNil(t, lag.LastSyncAt)
+	assert.Empty(t, lag.LastError)
+}
+
+func TestSink_Replay(t *testing.T) {
+	db := setupSinkDB(t)
+	ctx := context.Background()
+
+	_, err := db.Exec(`INSERT INTO files (id, filename, status, created_at, superseded_by) VALUES
+		(1, 'a.tsv', 'completed', ?, NULL),
+		(2, 'b.tsv', 'partial', ?, NULL),
+		(3, 'c.tsv', 'failed', ?, NULL),
+		(4, 'd.tsv', 'completed', ?, 1),
+		(5, 'e.tsv', 'completed', ?, NULL)`,
+		time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC),
+		time.Date(2025, 3, 2, 0, 0, 0, 0, time.UTC),
+		time.Date(2025, 3, 3, 0, 0, 0, 0, time.UTC),
+		time.Date(2025, 3, 4, 0, 0, 0, 0, time.UTC),
+		time.Date(2025, 4, 1, 0, 0, 0, 0, time.UTC))
+	require.NoError(t, err)
+	_, err = db.Exec(`INSERT INTO clickhouse_sync (file_id, synced_at) VALUES (1, CURRENT_TIMESTAMP)`)
+	require.NoError(t, err)
+
+	sink := NewSink(sqlc.New(db), NewClient(Options{URL: "http://127.0.0.1:0", Table: "device_data"}), SinkOptions{})
+	n, err := sink.Replay(ctx, time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC), time.Date(2025, 4, 1, 0, 0, 0, 0, time.UTC))
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), n)
+
+	pending, err := sqlc.New(db).ListPendingClickhouseSync(ctx, 10)
+	require.NoError(t, err)
+	require.Len(t, pending, 2)
+	assert.ElementsMatch(t, []int64{1, 2}, []int64{pending[0].FileID, pending[1].FileID})
+}
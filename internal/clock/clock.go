@@ -0,0 +1,62 @@
+// internal/clock/clock.go
+package clock
+
+import (
+	"context"
+	"time"
+)
+
+// Clock - источник времени и таймеров. Компоненты, поведение которых
+// зависит от времени (сканирование watcher, ожидание записи файла,
+// расписание очистки), получают Clock, чтобы тесты управляли временем
+// через Fake, а не ждали реальных интервалов.
+type Clock interface {
+	Now() time.Time
+	Since(t time.Time) time.Duration
+	After(d time.Duration) <-chan time.Time
+	Sleep(d time.Duration)
+	NewTicker(d time.Duration) Ticker
+}
+
+// Ticker - периодический таймер (аналог *time.Ticker)
+type Ticker interface {
+	Chan() <-chan time.Time
+	Stop()
+}
+
+// Real - системные часы
+var Real Clock = realClock{}
+
+type realClock struct{}
+
+func (realClock) Now() time.Time                         { return time.Now() }
+func (realClock) Since(t time.Time) time.Duration        { return time.Since(t) }
+func (realClock) After(d time.Duration) <-chan time.Time { return time.After(d) }
+func (realClock) Sleep(d time.Duration)                  { time.Sleep(d) }
+
+func (realClock) NewTicker(d time.Duration) Ticker {
+	return realTicker{time.NewTicker(d)}
+}
+
+type realTicker struct {
+	*time.Ticker
+}
+
+func (t realTicker) Chan() <-chan time.Time { return t.C }
+
+// Every вызывает fn сразу и затем каждые interval, пока ctx не завершён.
+// Следующий вызов не начинается, пока не закончился предыдущий.
+func Every(ctx context.Context, c Clock, interval time.Duration, fn func()) {
+	ticker := c.NewTicker(interval)
+	defer ticker.Stop()
+
+	fn()
+	for {
+		select {
+		case <-ticker.Chan():
+			fn()
+		case <-ctx.Done():
+			return
+		}
+	}
+}
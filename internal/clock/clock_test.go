@@ -0,0 +1,93 @@
+package clock
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var epoch = time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+
+func TestFake_AfterFiresOnAdvance(t *testing.T) {
+	f := NewFake(epoch)
+	ch := f.After(time.Minute)
+
+	f.Advance(59 * time.Second)
+	select {
+	case <-ch:
+		t.Fatal("timer fired too early")
+	default:
+	}
+
+	f.Advance(time.Second)
+	select {
+	case at := <-ch:
+		assert.Equal(t, epoch.Add(time.Minute), at)
+	default:
+		t.Fatal("timer did not fire")
+	}
+	assert.Equal(t, time.Minute, f.Since(epoch))
+}
+
+func TestFake_SleepBlocksUntilAdvance(t *testing.T) {
+	f := NewFake(epoch)
+	done := make(chan time.Time)
+	go func() {
+		f.Sleep(time.Second)
+		done <- f.Now()
+	}()
+
+	f.BlockUntil(1)
+	f.Advance(time.Second)
+	assert.Equal(t, epoch.Add(time.Second), <-done)
+}
+
+func TestFake_TickerDoesNotAccumulateTicks(t *testing.T) {
+	f := NewFake(epoch)
+	ticker := f.NewTicker(time.Second)
+
+	f.Advance(3 * time.Second)
+	assert.Equal(t, epoch.Add(time.Second), <-ticker.Chan())
+	select {
+	case <-ticker.Chan():
+		t.Fatal("missed ticks must be dropped")
+	default:
+	}
+
+	f.Advance(time.Second)
+	assert.Equal(t, epoch.Add(4*time.Second), <-ticker.Chan())
+
+	ticker.Stop()
+	f.Advance(time.Second)
+	select {
+	case <-ticker.Chan():
+		t.Fatal("stopped ticker fired")
+	default:
+	}
+}
+
+func TestEvery(t *testing.T) {
+	f := NewFake(epoch)
+	ctx, cancel := context.WithCancel(context.Background())
+	calls := make(chan time.Time, 10)
+	stopped := make(chan struct{})
+	go func() {
+		Every(ctx, f, time.Hour, func() { calls <- f.Now() })
+		close(stopped)
+	}()
+
+	require.Equal(t, epoch, <-calls)
+	for i := 1; i <= 2; i++ {
+		f.BlockUntil(1)
+		f.Advance(time.Hour)
+		assert.Equal(t, epoch.Add(time.Duration(i)*time.Hour), <-calls)
+	}
+
+	cancel()
+	<-stopped
+	f.Advance(time.Hour)
+	assert.Empty(t, calls)
+}
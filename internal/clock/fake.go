@@ -0,0 +1,127 @@
+// internal/clock/fake.go
+package clock
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// Fake - часы для тестов: время стоит, пока его не сдвинут Advance.
+// Таймеры After/Sleep и тикеры срабатывают при сдвиге времени в порядке
+// наступления; BlockUntil позволяет дождаться, пока тестируемая горутина
+// заведёт таймер, и только затем сдвигать время.
+type Fake struct {
+	mu      sync.Mutex
+	cond    *sync.Cond
+	now     time.Time
+	waiters []*fakeTimer
+}
+
+type fakeTimer struct {
+	at     time.Time
+	period time.Duration // > 0 - тикер
+	ch     chan time.Time
+}
+
+// NewFake создаёт Fake с текущим временем now
+func NewFake(now time.Time) *Fake {
+	f := &Fake{now: now}
+	f.cond = sync.NewCond(&f.mu)
+	return f
+}
+
+func (f *Fake) Now() time.Time {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.now
+}
+
+func (f *Fake) Since(t time.Time) time.Duration {
+	return f.Now().Sub(t)
+}
+
+func (f *Fake) After(d time.Duration) <-chan time.Time {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	ch := make(chan time.Time, 1)
+	if d <= 0 {
+		ch <- f.now
+		return ch
+	}
+	f.add(&fakeTimer{at: f.now.Add(d), ch: ch})
+	return ch
+}
+
+func (f *Fake) Sleep(d time.Duration) {
+	<-f.After(d)
+}
+
+func (f *Fake) NewTicker(d time.Duration) Ticker {
+	if d <= 0 {
+		panic("clock: non-positive interval for NewTicker")
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	t := &fakeTimer{at: f.now.Add(d), period: d, ch: make(chan time.Time, 1)}
+	f.add(t)
+	return &fakeTicker{clock: f, timer: t}
+}
+
+// Advance сдвигает время на d, срабатывают все наступившие таймеры
+func (f *Fake) Advance(d time.Duration) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	target := f.now.Add(d)
+	for len(f.waiters) > 0 && !f.waiters[0].at.After(target) {
+		t := f.waiters[0]
+		f.waiters = f.waiters[1:]
+		f.now = t.at
+		// Как и у time.Ticker, пропущенные срабатывания не накапливаются
+		select {
+		case t.ch <- t.at:
+		default:
+		}
+		if t.period > 0 {
+			t.at = t.at.Add(t.period)
+			f.add(t)
+		}
+	}
+	f.now = target
+}
+
+// BlockUntil ждёт, пока число ожидающих таймеров и тикеров достигнет n
+func (f *Fake) BlockUntil(n int) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for len(f.waiters) < n {
+		f.cond.Wait()
+	}
+}
+
+// add регистрирует таймер (f.mu должен быть захвачен)
+func (f *Fake) add(t *fakeTimer) {
+	f.waiters = append(f.waiters, t)
+	sort.SliceStable(f.waiters, func(i, j int) bool { return f.waiters[i].at.Before(f.waiters[j].at) })
+	f.cond.Broadcast()
+}
+
+func (f *Fake) remove(t *fakeTimer) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for i, w := range f.waiters {
+		if w == t {
+			f.waiters = append(f.waiters[:i], f.waiters[i+1:]...)
+			return
+		}
+	}
+}
+
+type fakeTicker struct {
+	clock *Fake
+	timer *fakeTimer
+}
+
+func (t *fakeTicker) Chan() <-chan time.Time { return t.timer.ch }
+func (t *fakeTicker) Stop()                  { t.clock.remove(t.timer) }
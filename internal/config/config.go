@@ -3,9 +3,21 @@
 package config
 
 import (
+	"TSVProcessingService/internal/dto"
+	"TSVProcessingService/internal/features"
+	"TSVProcessingService/internal/filemeta"
+	"TSVProcessingService/internal/jobs"
+	"TSVProcessingService/internal/locale"
+	"TSVProcessingService/internal/maintenance"
+	"TSVProcessingService/internal/watcher"
 	"fmt"
-	"log"
+	"log/slog"
+	"net/url"
+	"os"
 	"path/filepath"
+	"regexp"
+	"runtime"
+	"slices"
 	"strings"
 	"time"
 
@@ -15,12 +27,39 @@ import (
 
 // AppConfig - главная структура конфигурации
 type AppConfig struct {
-	Database  DatabaseConfig  `mapstructure:"database"`
-	Directory DirectoryConfig `mapstructure:"directory"`
-	Server    ServerConfig    `mapstructure:"server"`
-	Worker    WorkerConfig    `mapstructure:"worker"`
-	Logging   LoggingConfig   `mapstructure:"logging"`
-	Debug     bool            `mapstructure:"debug"` // ← Добавлено
+	Database      DatabaseConfig      `mapstructure:"database"`
+	Directory     DirectoryConfig     `mapstructure:"directory"`
+	Server        ServerConfig        `mapstructure:"server"`
+	Worker        WorkerConfig        `mapstructure:"worker"`
+	Watcher       WatcherConfig       `mapstructure:"watcher"`
+	Logging       LoggingConfig       `mapstructure:"logging"`
+	Throttle      ThrottleConfig      `mapstructure:"throttle"`
+	Backlog       BacklogConfig       `mapstructure:"backlog"`
+	PostProcess   PostProcessConfig   `mapstructure:"post_process"`
+	Cache         CacheConfig         `mapstructure:"cache"`
+	Health        HealthConfig        `mapstructure:"health"`
+	ReadBreaker   ReadBreakerConfig   `mapstructure:"read_breaker"`
+	Alerts        AlertsConfig        `mapstructure:"alerts"`
+	Incidents     IncidentsConfig     `mapstructure:"incidents"`
+	Maintenance   MaintenanceConfig   `mapstructure:"maintenance"`
+	Supervisor    SupervisorConfig    `mapstructure:"supervisor"`
+	APILog        APILogConfig        `mapstructure:"api_log"`
+	SLA           SLAConfig           `mapstructure:"sla"`
+	Digest        DigestConfig        `mapstructure:"digest"`
+	Report        ReportConfig        `mapstructure:"report"`
+	Archive       ArchiveConfig       `mapstructure:"archive"`
+	ClickHouse    ClickHouseConfig    `mapstructure:"clickhouse"`
+	Anomaly       AnomalyConfig       `mapstructure:"anomaly"`
+	Subscriptions SubscriptionsConfig `mapstructure:"subscriptions"`
+	Audit         AuditConfig         `mapstructure:"audit"`
+	Access        AccessConfig        `mapstructure:"access"`
+	Downloads     DownloadsConfig     `mapstructure:"downloads"`
+	Storage       StorageConfig       `mapstructure:"storage_usage"`
+	Sequence      SequenceConfig      `mapstructure:"sequence_check"`
+	FileMetadata  FileMetadataConfig  `mapstructure:"filename_metadata"`
+	Features      FeaturesConfig      `mapstructure:"features"`
+	Sandbox       SandboxConfig       `mapstructure:"sandbox"`
+	Debug         bool                `mapstructure:"debug"` // ← Добавлено
 }
 
 // DatabaseConfig - конфигурация базы данных
@@ -34,6 +73,23 @@ type DatabaseConfig struct {
 	MaxOpenConns int           `mapstructure:"max_open_conns"`
 	MaxIdleConns int           `mapstructure:"max_idle_conns"`
 	MaxIdleTime  time.Duration `mapstructure:"max_idle_time"`
+
+	SlowQueryThreshold time.Duration `mapstructure:"slow_query_threshold"` // запросы дольше пишутся в лог (0 - не пишутся)
+
+	// Ограничения сессии, задаются при подключении: сервер прерывает
+	// запрос дольше statement_timeout и ожидание блокировки дольше
+	// lock_timeout (0 - без ограничения)
+	StatementTimeout time.Duration `mapstructure:"statement_timeout"`
+	LockTimeout      time.Duration `mapstructure:"lock_timeout"`
+
+	// Доля max_open_conns, доступная фоновой подсистеме (cleanup, report,
+	// archive, backfill, statistics), чтобы она не останавливала загрузку
+	PoolShares map[string]float64 `mapstructure:"pool_shares"`
+
+	// search_path сессии для неквалифицированных имён, пусто - по умолчанию
+	// сервера. Задаётся sandbox.schema; public остаётся в пути, в нём
+	// расширения (pg_trgm для gin_trgm_ops и similarity).
+	SearchPath string `mapstructure:"-"`
 }
 
 // DirectoryConfig - конфигурация директорий
@@ -42,7 +98,14 @@ type DirectoryConfig struct {
 	OutputPath  string `mapstructure:"output_path"`
 	ArchivePath string `mapstructure:"archive_path"`
 	ErrorPath   string `mapstructure:"error_path"`
+	HoldPath    string `mapstructure:"hold_path"` // файлы, обработка которых отменена (POST /files/{filename}/cancel)
 	TempPath    string `mapstructure:"temp_path"`
+	DoneMarker  string `mapstructure:"done_marker"` // none / empty / json - маркер <name>.done в архиве
+
+	RejectedFile bool `mapstructure:"rejected_file"` // <name>.rejected.tsv с ошибочными строками в error_path
+
+	IgnorePatterns []string `mapstructure:"ignore_patterns"` // имена (filepath.Match), которые watcher не обрабатывает
+	StateFile      string   `mapstructure:"state_file"`      // состояние backlog watcher между перезапусками (пусто - не сохраняется)
 }
 
 // ServerConfig - конфигурация сервера
@@ -55,6 +118,17 @@ type ServerConfig struct {
 	ShutdownTimeout    time.Duration `mapstructure:"shutdown_timeout"`
 	EnableCORS         bool          `mapstructure:"enable_cors"`
 	CORSAllowedOrigins []string      `mapstructure:"cors_allowed_origins"`
+	IdempotencyTTL     time.Duration `mapstructure:"idempotency_ttl"`
+
+	// Перезапуск без разрыва соединений (cmd/api/listener.go)
+	ReusePort     bool          `mapstructure:"reuse_port"`     // SO_REUSEPORT: новый экземпляр слушает тот же порт, пока старый дообрабатывает очередь
+	ShutdownDelay time.Duration `mapstructure:"shutdown_delay"` // /health/ready отвечает 503 столько до закрытия порта (балансировщик успевает снять экземпляр)
+}
+
+// WatcherConfig - обнаружение новых файлов в watch-директории
+type WatcherConfig struct {
+	Mode     string        `mapstructure:"mode"`     // poll - раз в worker.scan_interval; fsnotify - по событиям и раз в scan_interval
+	Debounce time.Duration `mapstructure:"debounce"` // ожидание после события до сканирования (fsnotify)
 }
 
 // WorkerConfig - конфигурация воркеров
@@ -62,20 +136,389 @@ type WorkerConfig struct {
 	MaxWorkers    int           `mapstructure:"max_workers"`
 	MaxQueueSize  int           `mapstructure:"max_queue_size"`
 	ScanInterval  time.Duration `mapstructure:"scan_interval"`
-	RetryAttempts int           `mapstructure:"retry_attempts"`
-	RetryDelay    time.Duration `mapstructure:"retry_delay"`
+	MinFileAge    time.Duration `mapstructure:"min_file_age"`   // файл моложе (по mtime) не ставится в очередь
+	RetryAttempts int           `mapstructure:"retry_attempts"` // повторов файла после временной ошибки БД (0 - сразу в error_path)
+	RetryDelay    time.Duration `mapstructure:"retry_delay"`    // пауза перед первым повтором, далее удваивается
+	BatchSize     int           `mapstructure:"batch_size"`     // строк в одном INSERT device_data (append)
+
+	// Параллелизм этапов: max_workers - файлов в обработке, этапы внутри
+	// ограничиваются отдельно (0 - по числу CPU и пулу соединений БД)
+	HashWorkers   int `mapstructure:"hash_workers"`   // хеширование файлов при сканировании (диск)
+	ParseWorkers  int `mapstructure:"parse_workers"`  // одновременный разбор файлов (CPU)
+	InsertWorkers int `mapstructure:"insert_workers"` // одновременная вставка строк (БД)
+
+	// Потоковая обработка больших файлов: разбор и сохранение частями
+	StreamMinSizeMB int `mapstructure:"stream_min_size_mb"` // файлы от этого размера (0 - выключено)
+	StreamChunkRows int `mapstructure:"stream_chunk_rows"`  // строк данных в части
+
+	// Общий таймаут обработки файла и доли этапов (0 - без отдельного дедлайна)
+	ProcessTimeout time.Duration `mapstructure:"process_timeout"`
+	ParseBudget    float64       `mapstructure:"parse_budget"`
+	InsertBudget   float64       `mapstructure:"insert_budget"`
+	ReportBudget   float64       `mapstructure:"report_budget"`
+
+	// Асинхронная генерация отчётов (не задерживает обработку файлов)
+	AsyncReports    bool          `mapstructure:"async_reports"`
+	ReportWorkers   int           `mapstructure:"report_workers"`
+	ReportQueueSize int           `mapstructure:"report_queue_size"`
+	ReportTimeout   time.Duration `mapstructure:"report_timeout"`
+	ReportMemoryMB  int           `mapstructure:"report_memory_mb"` // строки файла для отчётов сверх бюджета - во временные файлы (0 - без ограничения)
+
+	// Частота обновления прогресса обработки (строк / интервал времени)
+	ProgressEvery    int           `mapstructure:"progress_every"`
+	ProgressInterval time.Duration `mapstructure:"progress_interval"`
+
+	// Последовательная обработка файлов и отчётов одного unit_guid
+	// (разные устройства по-прежнему обрабатываются параллельно)
+	SerializePerUnit bool `mapstructure:"serialize_per_unit"`
+
+	// Распределение файлов по воркерам: shared, round_robin, hash, least_busy
+	Assignment string `mapstructure:"assignment"`
+
+	// Политика конфликтов вставки строк по умолчанию: append, skip,
+	// overwrite, version (файлы API могут указать свою)
+	ConflictPolicy string `mapstructure:"conflict_policy"`
+
+	// Поиск дубликатов строк при обработке (GET /files/{filename}/duplicates)
+	DuplicateReport bool `mapstructure:"duplicate_report"`
+
+	// Обработка файла, содержимое которого (SHA256) совпадает с уже
+	// обработанным файлом под другим именем; false - файл пропускается
+	AllowDuplicateContent bool `mapstructure:"allow_duplicate_content"`
+
+	// Профиль значений полей файла (GET /files/{filename}/profile)
+	ProfileFields bool `mapstructure:"profile_fields"`
+
+	// Источники (files.source), выгружающие только новые строки: номера n
+	// проверяются на непрерывность относительно предыдущего файла источника
+	DeltaSources []string `mapstructure:"delta_sources"`
+}
+
+// ThrottleConfig - ограничение скорости приёма файлов по источникам
+type ThrottleConfig struct {
+	Enabled               bool           `mapstructure:"enabled"`
+	DefaultFilesPerMinute int            `mapstructure:"default_files_per_minute"` // 0 - без ограничений
+	SourceLimits          map[string]int `mapstructure:"source_limits"`            // источник -> файлов в минуту
+	Burst                 int            `mapstructure:"burst"`
+	TenantSeparator       string         `mapstructure:"tenant_separator"` // префикс имени файла до разделителя - tenant
+	MaxSpillover          int            `mapstructure:"max_spillover"`
+}
+
+// BacklogConfig - пороги тревоги по необработанным файлам в watch-директории (0 - отключено)
+type BacklogConfig struct {
+	AlertMaxFiles int           `mapstructure:"alert_max_files"`
+	AlertMaxBytes int64         `mapstructure:"alert_max_bytes"`
+	AlertMaxAge   time.Duration `mapstructure:"alert_max_age"`
+}
+
+// PostProcessConfig - действия после обработки файла (до перемещения в архив)
+type PostProcessConfig struct {
+	CopyToDir      string        `mapstructure:"copy_to_dir"` // например, смонтированная FTP-директория
+	Command        string        `mapstructure:"command"`     // внешний скрипт, получает путь к файлу
+	CommandTimeout time.Duration `mapstructure:"command_timeout"`
+}
+
+// CacheConfig - кэш данных для часто опрашиваемых endpoint'ов
+type CacheConfig struct {
+	Backend       string        `mapstructure:"backend"` // memory / redis / none
+	TTL           time.Duration `mapstructure:"ttl"`     // счётчики устройств
+	StatisticsTTL time.Duration `mapstructure:"statistics_ttl"`
+	ListingTTL    time.Duration `mapstructure:"listing_ttl"` // списки файлов и страницы данных устройств
+	MaxEntries    int           `mapstructure:"max_entries"`
+	Redis         RedisConfig   `mapstructure:"redis"`
+}
+
+// RedisConfig - подключение к Redis для общего кэша нескольких экземпляров
+type RedisConfig struct {
+	Address     string        `mapstructure:"address"`
+	Password    string        `mapstructure:"password"`
+	DB          int           `mapstructure:"db"`
+	KeyPrefix   string        `mapstructure:"key_prefix"`
+	PoolSize    int           `mapstructure:"pool_size"`
+	DialTimeout time.Duration `mapstructure:"dial_timeout"`
+}
+
+// HealthConfig - периодические проверки здоровья и определение флаппинга
+type HealthConfig struct {
+	CheckInterval time.Duration `mapstructure:"check_interval"`
+	HistorySize   int           `mapstructure:"history_size"`   // сколько последних проверок хранить
+	FlapWindow    int           `mapstructure:"flap_window"`    // окно (в проверках) для подсчёта переключений
+	FlapThreshold int           `mapstructure:"flap_threshold"` // 0 - не определять флаппинг
+}
+
+// ReadBreakerConfig - автомат защиты чтения API: при перегрузке БД
+// GET-запросы получают 503 с Retry-After, а не ждут таймаута
+type ReadBreakerConfig struct {
+	Enabled          bool          `mapstructure:"enabled"`
+	FailureThreshold int           `mapstructure:"failure_threshold"` // отказов БД подряд до размыкания
+	OpenTimeout      time.Duration `mapstructure:"open_timeout"`      // время без обращений к БД до пробных запросов
+	HalfOpenProbes   int           `mapstructure:"half_open_probes"`  // пробных запросов; столько же успехов замыкает
+}
+
+// AlertsConfig - каналы доставки оповещений (лог используется всегда)
+type AlertsConfig struct {
+	WebhookURL     string        `mapstructure:"webhook_url"`
+	WebhookTimeout time.Duration `mapstructure:"webhook_timeout"`
+}
+
+// IncidentsConfig - инциденты в системе дежурств для устойчивых проблем
+// (кратковременные сбои остаются оповещениями alerts)
+type IncidentsConfig struct {
+	Provider        string        `mapstructure:"provider"`    // "" (выключено), pagerduty, opsgenie
+	RoutingKey      string        `mapstructure:"routing_key"` // PagerDuty Events API v2 integration key
+	APIKey          string        `mapstructure:"api_key"`     // Opsgenie API key
+	URL             string        `mapstructure:"url"`         // свой endpoint (EU-регион Opsgenie, прокси)
+	Timeout         time.Duration `mapstructure:"timeout"`
+	DedupPrefix     string        `mapstructure:"dedup_prefix"`      // префикс ключей дедупликации (имя инсталляции)
+	DatabaseDownFor time.Duration `mapstructure:"database_down_for"` // БД недоступна дольше - инцидент
+	BacklogFiles    int           `mapstructure:"backlog_files"`     // backlog больше N файлов ...
+	BacklogFor      time.Duration `mapstructure:"backlog_for"`       // ... дольше M - инцидент (0 файлов - выключено)
+	FailedFiles     int           `mapstructure:"failed_files"`      // файлов в папке ошибок за failed_window (0 - выключено)
+	FailedWindow    time.Duration `mapstructure:"failed_window"`
+	ResolveAfter    time.Duration `mapstructure:"resolve_after"` // условие не выполняется дольше - инцидент закрывается
+}
+
+// MaintenanceConfig - окна обслуживания: приём файлов приостанавливается,
+// оповещения подавляются, тяжёлые задачи переносятся в окно
+type MaintenanceConfig struct {
+	Timezone          string                    `mapstructure:"timezone"`             // часовой пояс расписаний (IANA), по умолчанию UTC
+	HeavyTaskMaxDelay time.Duration             `mapstructure:"heavy_task_max_delay"` // очистка/архивация ждут окна не дольше
+	Windows           []MaintenanceWindowConfig `mapstructure:"windows"`
+}
+
+// MaintenanceWindowConfig - одно окно обслуживания
+type MaintenanceWindowConfig struct {
+	Name           string        `mapstructure:"name"`
+	Schedule       string        `mapstructure:"schedule"` // cron: минута час день месяц день_недели - начало окна
+	Duration       time.Duration `mapstructure:"duration"`
+	PauseIngestion bool          `mapstructure:"pause_ingestion"`
+	SuppressAlerts bool          `mapstructure:"suppress_alerts"`
+	HeavyTasks     bool          `mapstructure:"heavy_tasks"` // окно для очистки и архивации
+}
+
+// SupervisorConfig - перезапуск упавших горутин watcher'а и воркеров
+type SupervisorConfig struct {
+	MinBackoff        time.Duration `mapstructure:"min_backoff"`
+	MaxBackoff        time.Duration `mapstructure:"max_backoff"`
+	HeartbeatInterval time.Duration `mapstructure:"heartbeat_interval"` // heartbeat простаивающих воркеров
+	StallTimeout      time.Duration `mapstructure:"stall_timeout"`      // запас сверх scan_interval / process_timeout
+}
+
+// APILogConfig - журнал API-запросов в таблице api_logs
+type APILogConfig struct {
+	Enabled       bool          `mapstructure:"enabled"`
+	QueueSize     int           `mapstructure:"queue_size"` // при переполнении записи отбрасываются
 	BatchSize     int           `mapstructure:"batch_size"`
+	FlushInterval time.Duration `mapstructure:"flush_interval"`
+}
+
+// SLAConfig - целевые показатели задержки загрузки данных
+type SLAConfig struct {
+	IngestLatency time.Duration `mapstructure:"ingest_latency"` // от поступления файла до фиксации данных
+	Window        time.Duration `mapstructure:"window"`         // окно статистики задержек
+}
+
+// DigestConfig - периодический дайджест работы сервиса
+type DigestConfig struct {
+	Enabled   bool       `mapstructure:"enabled"`
+	Schedules []string   `mapstructure:"schedules"` // weekly, monthly
+	Hour      int        `mapstructure:"hour"`      // час формирования (локальное время)
+	Formats   []string   `mapstructure:"formats"`   // html, pdf
+	OutputDir string     `mapstructure:"output_dir"`
+	TopUnits  int        `mapstructure:"top_units"` // размер списка самых «шумных» устройств
+	SMTP      SMTPConfig `mapstructure:"smtp"`
+
+	PDFPasswords map[string]string `mapstructure:"pdf_passwords"` // пароль PDF по расписанию (weekly, monthly)
+}
+
+// SMTPConfig - рассылка дайджеста по почте (пустой host - без рассылки)
+type SMTPConfig struct {
+	Host     string   `mapstructure:"host"`
+	Port     int      `mapstructure:"port"`
+	Username string   `mapstructure:"username"`
+	Password string   `mapstructure:"password"`
+	From     string   `mapstructure:"from"`
+	To       []string `mapstructure:"to"`
+}
+
+// ReportConfig - оформление PDF/HTML отчётов и дайджестов
+type ReportConfig struct {
+	Branding BrandingConfig            `mapstructure:"branding"`
+	Tenants  map[string]BrandingConfig `mapstructure:"tenants"`   // по имени tenant из source "tenant:<name>"
+	PartSize int                       `mapstructure:"part_size"` // записей в части отчёта по устройству
+	Language string                    `mapstructure:"language"`  // форматирование дат и чисел: en, ru, de
+	Timezone string                    `mapstructure:"timezone"`  // часовой пояс дат отчёта (IANA)
+
+	// Шифрование PDF (стандартная защита PDF). Пустой пароль - без шифрования.
+	Password        string            `mapstructure:"password"`
+	OwnerPassword   string            `mapstructure:"owner_password"`   // полный доступ; "" - случайный
+	TenantPasswords map[string]string `mapstructure:"tenant_passwords"` // по имени tenant
+}
+
+// BrandingConfig - логотип, тексты колонтитулов и цвета отчёта.
+// Пустые поля настроек tenant наследуются из общих.
+type BrandingConfig struct {
+	CompanyName  string `mapstructure:"company_name"`
+	LogoPath     string `mapstructure:"logo_path"` // PNG или JPEG
+	HeaderText   string `mapstructure:"header_text"`
+	FooterText   string `mapstructure:"footer_text"`
+	PrimaryColor string `mapstructure:"primary_color"` // #RRGGBB - заголовки и линии
+	TextColor    string `mapstructure:"text_color"`    // #RRGGBB - основной текст
+}
+
+// ArchiveConfig - выгрузка старых device_data в Parquet и удаление из БД
+type ArchiveConfig struct {
+	Enabled         bool          `mapstructure:"enabled"`
+	OlderThanMonths int           `mapstructure:"older_than_months"` // полные месяцы старше N месяцев
+	Interval        time.Duration `mapstructure:"interval"`
+	BatchRows       int           `mapstructure:"batch_rows"` // строк в одном файле Parquet
+	Prefix          string        `mapstructure:"prefix"`     // префикс ключей объектов
+	Storage         string        `mapstructure:"storage"`    // dir / s3
+	Dir             string        `mapstructure:"dir"`        // для storage: dir (например, смонтированный bucket)
+	S3              S3Config      `mapstructure:"s3"`
+}
+
+// StorageConfig - периодический подсчёт использования хранилища
+// (GET /api/v1/statistics/storage)
+type StorageConfig struct {
+	Enabled  bool          `mapstructure:"enabled"`
+	Interval time.Duration `mapstructure:"interval"`  // подсчёт проходит по всей device_data и директориям
+	TopUnits int           `mapstructure:"top_units"` // устройств с наибольшим объёмом в ответе
+}
+
+// SequenceConfig - поиск пропусков и нарушений порядка номеров n строк
+// устройств между файлами (GET /api/v1/admin/sequence)
+type SequenceConfig struct {
+	Enabled  bool           `mapstructure:"enabled"`  // включает и запись отрезков n при загрузке
+	Interval time.Duration  `mapstructure:"interval"` // период фоновой проверки
+	Lookback time.Duration  `mapstructure:"lookback"` // проверяются файлы за этот период
+	Alert    bool           `mapstructure:"alert"`    // оповещать о новых пропусках
+	Reexport ReexportConfig `mapstructure:"reexport"`
+}
+
+// ReexportConfig - webhook партнёра для повторной выгрузки пропущенных
+// номеров n (пустой url - не запрашивается); запрос подписывается так же,
+// как уведомления подписок
+type ReexportConfig struct {
+	URL         string        `mapstructure:"url"`
+	Secret      string        `mapstructure:"secret"`
+	Timeout     time.Duration `mapstructure:"timeout"`
+	MaxAttempts int           `mapstructure:"max_attempts"`
+	RetryBase   time.Duration `mapstructure:"retry_base"`  // пауза после первой неудачи, далее удваивается
+	MaxBackoff  time.Duration `mapstructure:"max_backoff"` // предел паузы между попытками
+}
+
+// FileMetadataConfig - разбор имени файла (SITE42_20240615_full.tsv) по
+// регулярному выражению с именованными группами; поля сохраняются в
+// files.metadata и доступны в фильтре GET /files?meta.<поле>=
+type FileMetadataConfig struct {
+	Pattern    string `mapstructure:"pattern"`     // пусто - не разбирать
+	ArchiveDir string `mapstructure:"archive_dir"` // подкаталог архива, например "{site}/{date}"
+	ReportName string `mapstructure:"report_name"` // имя PDF-отчёта, например "{site}_{unit_guid}_{timestamp}"
+}
+
+// FeaturesConfig - флаги рискованных возможностей (internal/features).
+// Переопределения в БД (PUT /admin/features/{name}) имеют приоритет.
+type FeaturesConfig struct {
+	Flags           map[string]bool            `mapstructure:"flags"`            // флаг -> значение для всех
+	Tenants         map[string]map[string]bool `mapstructure:"tenants"`          // tenant -> флаг -> значение
+	RefreshInterval time.Duration              `mapstructure:"refresh_interval"` // перечитывание переопределений из БД (другие экземпляры)
+}
+
+// SandboxConfig - режим имитации обработки для staging: данные пишутся
+// в отдельную схему БД, исходные файлы не перемещаются и не удаляются
+// (в архив, ошибки и hold попадают копии). Staging может читать зеркало
+// входящей директории production, не забирая из неё файлы.
+type SandboxConfig struct {
+	Enabled bool   `mapstructure:"enabled"`
+	Schema  string `mapstructure:"schema"` // схема БД, миграции применяются в неё отдельно
+}
+
+// S3Config - S3-совместимое объектное хранилище
+type S3Config struct {
+	Endpoint  string        `mapstructure:"endpoint"` // host[:port]
+	Region    string        `mapstructure:"region"`
+	Bucket    string        `mapstructure:"bucket"`
+	AccessKey string        `mapstructure:"access_key"`
+	SecretKey string        `mapstructure:"secret_key"`
+	UseSSL    bool          `mapstructure:"use_ssl"`
+	Timeout   time.Duration `mapstructure:"timeout"`
+}
+
+// ClickHouseConfig - дополнительная выгрузка device_data в ClickHouse
+// для аналитики (независимо от PostgreSQL)
+type ClickHouseConfig struct {
+	Enabled       bool          `mapstructure:"enabled"`
+	URL           string        `mapstructure:"url"` // HTTP-интерфейс, например http://clickhouse:8123
+	Database      string        `mapstructure:"database"`
+	Table         string        `mapstructure:"table"`
+	User          string        `mapstructure:"user"`
+	Password      string        `mapstructure:"password"`
+	CreateTable   bool          `mapstructure:"create_table"`   // CREATE TABLE IF NOT EXISTS при запуске
+	BatchRows     int           `mapstructure:"batch_rows"`     // строк в одном INSERT
+	FlushInterval time.Duration `mapstructure:"flush_interval"` // период проверки очереди
+	Timeout       time.Duration `mapstructure:"timeout"`
+	LagWarning    time.Duration `mapstructure:"lag_warning"` // предупреждение в лог при большем отставании
 }
 
-// LoggingConfig - конфигурация логирования
+// AnomalyConfig - поиск аномалий загрузки (число строк, доля ошибок,
+// распределение class) относительно истории источника/устройства
+type AnomalyConfig struct {
+	Enabled    bool    `mapstructure:"enabled"`
+	MinHistory int     `mapstructure:"min_history"` // файлов в истории, меньше - сравнение не выполняется
+	Window     int     `mapstructure:"window"`      // последних файлов в базовой линии
+	Threshold  float64 `mapstructure:"threshold"`   // отклонение от среднего в стандартных отклонениях
+}
+
+// SubscriptionsConfig - подписки внешних систем на новые отчёты:
+// подписанное уведомление со ссылкой на скачивание, с повторами
+type SubscriptionsConfig struct {
+	Enabled     bool          `mapstructure:"enabled"`
+	PublicURL   string        `mapstructure:"public_url"` // внешний адрес API для download_url, например https://tsv.example.com
+	Interval    time.Duration `mapstructure:"interval"`   // период проверки очереди доставок
+	Timeout     time.Duration `mapstructure:"timeout"`    // таймаут запроса к callback URL
+	MaxAttempts int           `mapstructure:"max_attempts"`
+	RetryBase   time.Duration `mapstructure:"retry_base"`  // пауза после первой неудачи, далее удваивается
+	MaxBackoff  time.Duration `mapstructure:"max_backoff"` // предел паузы между попытками
+}
+
+// AuditConfig - журнал аудита загрузки (таблица audit_log): только
+// добавление, записи связаны цепочкой хешей
+type AuditConfig struct {
+	Enabled bool `mapstructure:"enabled"`
+}
+
+// AccessConfig - роли клиентов API по X-API-Key; для роли viewer
+// значения полей masked_fields в ответах скрываются
+type AccessConfig struct {
+	DefaultRole  string            `mapstructure:"default_role"` // запросы без ключа или с неизвестным ключом
+	Keys         []AccessKeyConfig `mapstructure:"keys"`
+	MaskedFields []string          `mapstructure:"masked_fields"` // поля данных устройства (addr, invid, ...)
+}
+
+// DownloadsConfig - подписанные ссылки на скачивание отчётов
+// (HMAC со сроком действия) для передачи браузеру без ключа API
+type DownloadsConfig struct {
+	SigningKey       string        `mapstructure:"signing_key"`       // пусто - случайный ключ при запуске
+	URLTTL           time.Duration `mapstructure:"url_ttl"`           // срок действия выданной ссылки
+	RequireSignature bool          `mapstructure:"require_signature"` // скачивание только по подписанным ссылкам
+}
+
+// AccessKeyConfig - роль одного API-ключа
+type AccessKeyConfig struct {
+	Key  string `mapstructure:"key"`
+	Role string `mapstructure:"role"` // viewer, operator, admin
+}
+
+// LoggingConfig - конфигурация логирования (internal/logger)
 type LoggingConfig struct {
-	Level      string `mapstructure:"level"`
-	Format     string `mapstructure:"format"`
-	Output     string `mapstructure:"output"`
-	FilePath   string `mapstructure:"file_path"`
-	MaxSizeMB  int    `mapstructure:"max_size_mb"`
-	MaxBackups int    `mapstructure:"max_backups"`
-	MaxAgeDays int    `mapstructure:"max_age_days"`
+	Level      string `mapstructure:"level"`        // debug, info, warn, error
+	Format     string `mapstructure:"format"`       // json или text (key=value)
+	Output     string `mapstructure:"output"`       // stdout, stderr или file
+	FilePath   string `mapstructure:"file_path"`    // файл журнала для output: file
+	MaxSizeMB  int    `mapstructure:"max_size_mb"`  // размер файла до ротации (0 - без ротации)
+	MaxBackups int    `mapstructure:"max_backups"`  // хранимых копий после ротации (0 - все)
+	MaxAgeDays int    `mapstructure:"max_age_days"` // срок хранения копий (0 - без ограничения)
 }
 
 // LoadConfig - загружает конфигурацию из файла и переменных окружения
@@ -100,7 +543,7 @@ func LoadConfig(configPath string) (*AppConfig, error) {
 	// Чтение конфигурационного файла
 	if err := v.ReadInConfig(); err != nil {
 		if _, ok := err.(viper.ConfigFileNotFoundError); ok {
-			log.Println("Config file not found, using defaults and environment variables")
+			slog.Info("Config file not found, using defaults and environment variables")
 		} else {
 			return nil, fmt.Errorf("error reading config file: %w", err)
 		}
@@ -123,6 +566,11 @@ func LoadConfig(configPath string) (*AppConfig, error) {
 		return nil, fmt.Errorf("unable to decode config: %w", err)
 	}
 
+	// Уровень и формат журнала - без учёта регистра (TSV_LOGGING_LEVEL=INFO)
+	cfg.Logging.Level = strings.ToLower(cfg.Logging.Level)
+	cfg.Logging.Format = strings.ToLower(cfg.Logging.Format)
+	cfg.Logging.Output = strings.ToLower(cfg.Logging.Output)
+
 	// Валидация
 	if err := validateConfig(&cfg); err != nil {
 		return nil, fmt.Errorf("config validation failed: %w", err)
@@ -130,6 +578,10 @@ func LoadConfig(configPath string) (*AppConfig, error) {
 
 	// Нормализация путей
 	normalizePaths(&cfg)
+	resolveConcurrency(&cfg)
+	if cfg.Sandbox.Enabled {
+		cfg.Database.SearchPath = cfg.Sandbox.Schema + ",public"
+	}
 
 	return &cfg, nil
 }
@@ -137,13 +589,38 @@ func LoadConfig(configPath string) (*AppConfig, error) {
 // GetDSN - возвращает строку подключения к PostgreSQL
 func (c *DatabaseConfig) GetDSN() string {
 	return fmt.Sprintf("host=%s port=%d user=%s password=%s dbname=%s sslmode=%s",
-		c.Host, c.Port, c.User, c.Password, c.Name, c.SSLMode)
+		dsnValue(c.Host), c.Port, dsnValue(c.User), dsnValue(c.Password), dsnValue(c.Name), dsnValue(c.SSLMode)) + c.sessionParams()
 }
 
 // GetDSNWithoutCredentials - возвращает DSN без пароля (для логирования)
 func (c *DatabaseConfig) GetDSNWithoutCredentials() string {
 	return fmt.Sprintf("host=%s port=%d user=%s dbname=%s sslmode=%s",
-		c.Host, c.Port, c.User, c.Name, c.SSLMode)
+		dsnValue(c.Host), c.Port, dsnValue(c.User), dsnValue(c.Name), dsnValue(c.SSLMode)) + c.sessionParams()
+}
+
+// dsnValue - значение параметра DSN (key=value): пустое или с пробелами,
+// кавычками и обратной косой чертой заключается в одинарные кавычки
+func dsnValue(v string) string {
+	if v != "" && !strings.ContainsAny(v, " \t\n\r'\\") {
+		return v
+	}
+	return "'" + strings.NewReplacer(`\`, `\\`, `'`, `\'`).Replace(v) + "'"
+}
+
+// sessionParams - параметры сессии PostgreSQL в DSN: lib/pq передаёт
+// неизвестные ему параметры серверу при установке соединения
+func (c *DatabaseConfig) sessionParams() string {
+	var params string
+	if c.StatementTimeout > 0 {
+		params += fmt.Sprintf(" statement_timeout=%d", c.StatementTimeout.Milliseconds())
+	}
+	if c.LockTimeout > 0 {
+		params += fmt.Sprintf(" lock_timeout=%d", c.LockTimeout.Milliseconds())
+	}
+	if c.SearchPath != "" {
+		params += " search_path=" + dsnValue(c.SearchPath)
+	}
+	return params
 }
 
 // GetListenAddr - возвращает адрес для прослушивания сервера
@@ -193,12 +670,25 @@ func setDefaults(v *viper.Viper) {
 	v.SetDefault("database.max_open_conns", 25)
 	v.SetDefault("database.max_idle_conns", 5)
 	v.SetDefault("database.max_idle_time", "5m")
+	v.SetDefault("database.slow_query_threshold", "500ms")
+	v.SetDefault("database.statement_timeout", "0s")
+	v.SetDefault("database.lock_timeout", "0s")
+	v.SetDefault("database.pool_shares", map[string]float64{
+		jobs.KindCleanup:         0.1,
+		jobs.KindReport:          0.25,
+		jobs.KindArchive:         0.1,
+		jobs.KindBackfill:        0.1,
+		jobs.SubsystemStatistics: 0.05,
+	})
 
 	// Директории
 	v.SetDefault("directory.watch_path", "./incoming")
 	v.SetDefault("directory.output_path", "./reports")
 	v.SetDefault("directory.archive_path", "./archive")
 	v.SetDefault("directory.temp_path", "./tmp")
+	v.SetDefault("directory.hold_path", "./hold")
+	v.SetDefault("directory.done_marker", "none")
+	v.SetDefault("directory.rejected_file", false)
 
 	// Сервер
 	v.SetDefault("server.host", "0.0.0.0")
@@ -209,14 +699,222 @@ func setDefaults(v *viper.Viper) {
 	v.SetDefault("server.shutdown_timeout", "10s")
 	v.SetDefault("server.enable_cors", true)
 	v.SetDefault("server.cors_allowed_origins", []string{"*"})
+	v.SetDefault("server.idempotency_ttl", "24h")
+	v.SetDefault("server.reuse_port", false)
+	v.SetDefault("server.shutdown_delay", "0s")
 
 	// Воркеры
 	v.SetDefault("worker.max_workers", 3)
 	v.SetDefault("worker.max_queue_size", 100)
 	v.SetDefault("worker.scan_interval", "30s")
+	v.SetDefault("worker.min_file_age", "0s")
 	v.SetDefault("worker.retry_attempts", 3)
 	v.SetDefault("worker.retry_delay", "10s")
 	v.SetDefault("worker.batch_size", 1000)
+	v.SetDefault("worker.hash_workers", 0)
+	v.SetDefault("worker.parse_workers", 0)
+	v.SetDefault("worker.insert_workers", 0)
+	v.SetDefault("worker.stream_min_size_mb", 0)
+	v.SetDefault("worker.stream_chunk_rows", 10000)
+	v.SetDefault("worker.process_timeout", "10m")
+	v.SetDefault("worker.parse_budget", 0.2)
+	v.SetDefault("worker.insert_budget", 0.5)
+	v.SetDefault("worker.report_budget", 0.2)
+	v.SetDefault("worker.async_reports", true)
+	v.SetDefault("worker.report_workers", 1)
+	v.SetDefault("worker.report_queue_size", 100)
+	v.SetDefault("worker.report_timeout", "2m")
+	v.SetDefault("worker.report_memory_mb", 256)
+	v.SetDefault("worker.progress_every", 500)
+	v.SetDefault("worker.progress_interval", "2s")
+	v.SetDefault("worker.serialize_per_unit", false)
+	v.SetDefault("worker.assignment", "shared")
+	v.SetDefault("worker.conflict_policy", "append")
+	v.SetDefault("worker.duplicate_report", false)
+	v.SetDefault("worker.allow_duplicate_content", false)
+	v.SetDefault("worker.profile_fields", false)
+	v.SetDefault("worker.delta_sources", []string{})
+
+	// Ограничение скорости приёма
+	v.SetDefault("throttle.enabled", false)
+	v.SetDefault("throttle.default_files_per_minute", 0)
+	v.SetDefault("throttle.burst", 5)
+	v.SetDefault("throttle.tenant_separator", "")
+	v.SetDefault("throttle.max_spillover", 10000)
+
+	// Backlog watch-директории
+	v.SetDefault("backlog.alert_max_files", 500)
+	v.SetDefault("backlog.alert_max_bytes", 0)
+	v.SetDefault("backlog.alert_max_age", "1h")
+
+	// Post-processing
+	v.SetDefault("post_process.copy_to_dir", "")
+	v.SetDefault("post_process.command", "")
+	v.SetDefault("post_process.command_timeout", "30s")
+
+	// Кэш
+	v.SetDefault("cache.backend", "memory")
+	v.SetDefault("cache.ttl", "30s")
+	v.SetDefault("cache.statistics_ttl", "10s")
+	v.SetDefault("cache.listing_ttl", "5s")
+	v.SetDefault("cache.max_entries", 10000)
+	v.SetDefault("cache.redis.address", "localhost:6379")
+	v.SetDefault("cache.redis.db", 0)
+	v.SetDefault("cache.redis.key_prefix", "tsv:")
+	v.SetDefault("cache.redis.pool_size", 10)
+	v.SetDefault("cache.redis.dial_timeout", "2s")
+
+	// Health checks
+	v.SetDefault("health.check_interval", "30s")
+	v.SetDefault("health.history_size", 120)
+	v.SetDefault("health.flap_window", 10)
+	v.SetDefault("health.flap_threshold", 4)
+
+	// Автомат защиты чтения
+	v.SetDefault("read_breaker.enabled", true)
+	v.SetDefault("read_breaker.failure_threshold", 5)
+	v.SetDefault("read_breaker.open_timeout", "30s")
+	v.SetDefault("read_breaker.half_open_probes", 1)
+
+	// Супервизор внутренних горутин
+	v.SetDefault("supervisor.min_backoff", "1s")
+	v.SetDefault("supervisor.max_backoff", "1m")
+	v.SetDefault("supervisor.heartbeat_interval", "10s")
+	v.SetDefault("supervisor.stall_timeout", "1m")
+
+	// Журнал API-запросов
+	v.SetDefault("api_log.enabled", true)
+	v.SetDefault("api_log.queue_size", 10000)
+	v.SetDefault("api_log.batch_size", 100)
+	v.SetDefault("api_log.flush_interval", "1s")
+
+	// SLA задержки загрузки
+	v.SetDefault("sla.ingest_latency", "15m")
+	v.SetDefault("sla.window", "24h")
+
+	// Дайджест
+	v.SetDefault("digest.enabled", false)
+	v.SetDefault("digest.schedules", []string{"weekly"})
+	v.SetDefault("digest.hour", 7)
+	v.SetDefault("digest.formats", []string{"html", "pdf"})
+	v.SetDefault("digest.output_dir", "./reports/digests")
+	v.SetDefault("digest.top_units", 10)
+	v.SetDefault("digest.smtp.host", "")
+	v.SetDefault("digest.smtp.port", 587)
+
+	// Использование хранилища
+	v.SetDefault("storage_usage.enabled", true)
+	v.SetDefault("storage_usage.interval", "6h")
+	v.SetDefault("storage_usage.top_units", 100)
+
+	// Проверка последовательностей n устройств
+	v.SetDefault("sequence_check.enabled", false)
+	v.SetDefault("sequence_check.interval", "1h")
+	v.SetDefault("sequence_check.lookback", "720h")
+	v.SetDefault("sequence_check.alert", true)
+	v.SetDefault("sequence_check.reexport.url", "")
+	v.SetDefault("sequence_check.reexport.secret", "")
+	v.SetDefault("sequence_check.reexport.timeout", "10s")
+	v.SetDefault("sequence_check.reexport.max_attempts", 5)
+	v.SetDefault("sequence_check.reexport.retry_base", "1h")
+	v.SetDefault("sequence_check.reexport.max_backoff", "24h")
+
+	// Метаданные из имени файла
+	v.SetDefault("watcher.mode", "poll")
+	v.SetDefault("watcher.debounce", "500ms")
+
+	v.SetDefault("filename_metadata.pattern", "")
+	v.SetDefault("filename_metadata.archive_dir", "")
+	v.SetDefault("filename_metadata.report_name", "")
+
+	v.SetDefault("features.flags", map[string]bool{})
+	v.SetDefault("features.tenants", map[string]map[string]bool{})
+	v.SetDefault("features.refresh_interval", "30s")
+
+	v.SetDefault("sandbox.enabled", false)
+	v.SetDefault("sandbox.schema", "sandbox")
+
+	// Оформление отчётов
+	v.SetDefault("report.branding.company_name", "")
+	v.SetDefault("report.branding.logo_path", "")
+	v.SetDefault("report.branding.header_text", "")
+	v.SetDefault("report.branding.footer_text", "")
+	v.SetDefault("report.branding.primary_color", "#2D3E50")
+	v.SetDefault("report.branding.text_color", "#000000")
+	v.SetDefault("report.part_size", 10000)
+	v.SetDefault("report.language", "en")
+	v.SetDefault("report.timezone", "UTC")
+	v.SetDefault("report.password", "")
+	v.SetDefault("report.owner_password", "")
+
+	// Архивация старых данных
+	v.SetDefault("archive.enabled", false)
+	v.SetDefault("archive.older_than_months", 12)
+	v.SetDefault("archive.interval", "24h")
+	v.SetDefault("archive.batch_rows", 100000)
+	v.SetDefault("archive.prefix", "device_data")
+	v.SetDefault("archive.storage", "dir")
+	v.SetDefault("archive.dir", "./cold-storage")
+	v.SetDefault("archive.s3.region", "us-east-1")
+	v.SetDefault("archive.s3.use_ssl", true)
+	v.SetDefault("archive.s3.timeout", "1m")
+
+	v.SetDefault("clickhouse.enabled", false)
+	v.SetDefault("clickhouse.url", "http://localhost:8123")
+	v.SetDefault("clickhouse.database", "default")
+	v.SetDefault("clickhouse.table", "device_data")
+	v.SetDefault("clickhouse.user", "default")
+	v.SetDefault("clickhouse.create_table", true)
+	v.SetDefault("clickhouse.batch_rows", 10000)
+	v.SetDefault("clickhouse.flush_interval", "5s")
+	v.SetDefault("clickhouse.timeout", "30s")
+	v.SetDefault("clickhouse.lag_warning", "15m")
+
+	// Аномалии загрузки
+	v.SetDefault("anomaly.enabled", false)
+	v.SetDefault("anomaly.min_history", 10)
+	v.SetDefault("anomaly.window", 50)
+	v.SetDefault("anomaly.threshold", 3.0)
+
+	// Подписки на отчёты
+	v.SetDefault("subscriptions.enabled", false)
+	v.SetDefault("subscriptions.public_url", "http://localhost:8080")
+	v.SetDefault("subscriptions.interval", "10s")
+	v.SetDefault("subscriptions.timeout", "10s")
+	v.SetDefault("subscriptions.max_attempts", 8)
+	v.SetDefault("subscriptions.retry_base", "30s")
+	v.SetDefault("subscriptions.max_backoff", "1h")
+
+	// Журнал аудита
+	v.SetDefault("audit.enabled", false)
+
+	// Роли клиентов API
+	v.SetDefault("access.default_role", "admin")
+	v.SetDefault("access.masked_fields", []string{"addr", "invid"})
+
+	// Подписанные ссылки на скачивание
+	v.SetDefault("downloads.signing_key", "")
+	v.SetDefault("downloads.url_ttl", "15m")
+	v.SetDefault("downloads.require_signature", false)
+
+	// Оповещения
+	v.SetDefault("alerts.webhook_url", "")
+	v.SetDefault("alerts.webhook_timeout", "5s")
+
+	// Инциденты (PagerDuty / Opsgenie)
+	v.SetDefault("incidents.provider", "")
+	v.SetDefault("incidents.timeout", "10s")
+	v.SetDefault("incidents.dedup_prefix", "tsv")
+	v.SetDefault("incidents.database_down_for", "5m")
+	v.SetDefault("incidents.backlog_files", 1000)
+	v.SetDefault("incidents.backlog_for", "15m")
+	v.SetDefault("incidents.failed_files", 5)
+	v.SetDefault("incidents.failed_window", "30m")
+	v.SetDefault("incidents.resolve_after", "5m")
+
+	// Окна обслуживания
+	v.SetDefault("maintenance.timezone", "UTC")
+	v.SetDefault("maintenance.heavy_task_max_delay", "12h")
 
 	// Логирование
 	v.SetDefault("logging.level", "info")
@@ -244,18 +942,333 @@ func validateConfig(cfg *AppConfig) error {
 	if cfg.Database.Name == "" {
 		errors = append(errors, "database.name is required")
 	}
+	if cfg.Database.SlowQueryThreshold < 0 {
+		errors = append(errors, "database.slow_query_threshold must not be negative")
+	}
+	if cfg.Database.StatementTimeout < 0 || cfg.Database.LockTimeout < 0 {
+		errors = append(errors, "database.statement_timeout and lock_timeout must not be negative")
+	}
+	if cfg.Database.StatementTimeout > 0 && cfg.Database.StatementTimeout < time.Millisecond ||
+		cfg.Database.LockTimeout > 0 && cfg.Database.LockTimeout < time.Millisecond {
+		errors = append(errors, "database.statement_timeout and lock_timeout must be at least 1ms")
+	}
+	var sharesTotal float64
+	for subsystem, share := range cfg.Database.PoolShares {
+		if !slices.Contains(jobs.PoolSubsystems, subsystem) {
+			errors = append(errors, fmt.Sprintf("database.pool_shares: unknown subsystem %q (allowed: %s)",
+				subsystem, strings.Join(jobs.PoolSubsystems, ", ")))
+		}
+		if share <= 0 || share > 1 {
+			errors = append(errors, fmt.Sprintf("database.pool_shares.%s must be in (0, 1]", subsystem))
+		}
+		sharesTotal += share
+	}
+	if sharesTotal >= 1 {
+		errors = append(errors, "database.pool_shares must sum to less than 1 to leave connections for ingestion and API")
+	}
+	if cfg.Server.ShutdownDelay < 0 {
+		errors = append(errors, "server.shutdown_delay must not be negative")
+	}
+	switch cfg.Logging.Level {
+	case "debug", "info", "warn", "error":
+	default:
+		errors = append(errors, "logging.level must be one of: debug, info, warn, error")
+	}
+	switch cfg.Logging.Format {
+	case "json", "text":
+	default:
+		errors = append(errors, "logging.format must be one of: json, text")
+	}
+	switch cfg.Logging.Output {
+	case "stdout", "stderr":
+	case "file":
+		if cfg.Logging.FilePath == "" {
+			errors = append(errors, "logging.file_path is required when logging.output is file")
+		}
+	default:
+		errors = append(errors, "logging.output must be one of: stdout, stderr, file")
+	}
+	if cfg.Logging.MaxSizeMB < 0 || cfg.Logging.MaxBackups < 0 || cfg.Logging.MaxAgeDays < 0 {
+		errors = append(errors, "logging.max_size_mb, max_backups and max_age_days must not be negative")
+	}
 	if cfg.Directory.WatchPath == "" {
 		errors = append(errors, "directory.watch_path is required")
 	}
 	if cfg.Directory.OutputPath == "" {
 		errors = append(errors, "directory.output_path is required")
 	}
+	switch cfg.Directory.DoneMarker {
+	case "", "none", "empty", "json":
+	default:
+		errors = append(errors, "directory.done_marker must be one of: none, empty, json")
+	}
+	for _, pattern := range cfg.Directory.IgnorePatterns {
+		if _, err := filepath.Match(pattern, ""); err != nil {
+			errors = append(errors, fmt.Sprintf("directory.ignore_patterns: invalid pattern %q", pattern))
+		}
+	}
+	switch cfg.Cache.Backend {
+	case "", "none", "memory":
+	case "redis":
+		if cfg.Cache.Redis.Address == "" {
+			errors = append(errors, "cache.redis.address is required for redis cache backend")
+		}
+	default:
+		errors = append(errors, "cache.backend must be one of: none, memory, redis")
+	}
+	if cfg.Health.CheckInterval <= 0 {
+		errors = append(errors, "health.check_interval must be greater than 0")
+	}
+	if cfg.Health.HistorySize <= 0 {
+		errors = append(errors, "health.history_size must be greater than 0")
+	}
+	if cfg.ReadBreaker.Enabled {
+		if cfg.ReadBreaker.FailureThreshold <= 0 || cfg.ReadBreaker.HalfOpenProbes <= 0 {
+			errors = append(errors, "read_breaker.failure_threshold and read_breaker.half_open_probes must be greater than 0")
+		}
+		if cfg.ReadBreaker.OpenTimeout <= 0 {
+			errors = append(errors, "read_breaker.open_timeout must be greater than 0")
+		}
+	}
+	if cfg.Supervisor.HeartbeatInterval <= 0 {
+		errors = append(errors, "supervisor.heartbeat_interval must be greater than 0")
+	}
+	if cfg.APILog.Enabled && cfg.APILog.FlushInterval <= 0 {
+		errors = append(errors, "api_log.flush_interval must be greater than 0")
+	}
+	if cfg.SLA.IngestLatency <= 0 || cfg.SLA.Window <= 0 {
+		errors = append(errors, "sla.ingest_latency and sla.window must be greater than 0")
+	}
+	if cfg.Digest.Enabled {
+		for _, schedule := range cfg.Digest.Schedules {
+			if schedule != "weekly" && schedule != "monthly" {
+				errors = append(errors, "digest.schedules must contain only: weekly, monthly")
+				break
+			}
+		}
+		for _, format := range cfg.Digest.Formats {
+			if format != "html" && format != "pdf" {
+				errors = append(errors, "digest.formats must contain only: html, pdf")
+				break
+			}
+		}
+		if len(cfg.Digest.Schedules) == 0 {
+			errors = append(errors, "digest.schedules is required when digest is enabled")
+		}
+		if cfg.Digest.Hour < 0 || cfg.Digest.Hour > 23 {
+			errors = append(errors, "digest.hour must be between 0 and 23")
+		}
+		if cfg.Digest.SMTP.Host != "" && (cfg.Digest.SMTP.From == "" || len(cfg.Digest.SMTP.To) == 0) {
+			errors = append(errors, "digest.smtp.from and digest.smtp.to are required when digest.smtp.host is set")
+		}
+	}
+	if cfg.Storage.Enabled && (cfg.Storage.Interval <= 0 || cfg.Storage.TopUnits <= 0) {
+		errors = append(errors, "storage_usage.interval and storage_usage.top_units must be greater than 0")
+	}
+	if cfg.Sequence.Enabled && (cfg.Sequence.Interval <= 0 || cfg.Sequence.Lookback <= 0) {
+		errors = append(errors, "sequence_check.interval and sequence_check.lookback must be greater than 0")
+	}
+	if reexport := cfg.Sequence.Reexport; cfg.Sequence.Enabled && reexport.URL != "" {
+		if u, err := url.Parse(reexport.URL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			errors = append(errors, "sequence_check.reexport.url must be an http(s) URL")
+		}
+		if reexport.Secret == "" {
+			errors = append(errors, "sequence_check.reexport.secret is required when sequence_check.reexport.url is set")
+		}
+		if reexport.MaxAttempts <= 0 {
+			errors = append(errors, "sequence_check.reexport.max_attempts must be greater than 0")
+		}
+	}
+	for name := range cfg.Features.Flags {
+		if _, err := features.Parse(name); err != nil {
+			errors = append(errors, fmt.Sprintf("features.flags: %v", err))
+		}
+	}
+	for tenant, flags := range cfg.Features.Tenants {
+		for name := range flags {
+			if _, err := features.Parse(name); err != nil {
+				errors = append(errors, fmt.Sprintf("features.tenants.%s: %v", tenant, err))
+			}
+		}
+	}
+	if cfg.Features.RefreshInterval <= 0 {
+		errors = append(errors, "features.refresh_interval must be greater than 0")
+	}
+	if cfg.Sandbox.Enabled {
+		if !schemaNamePattern.MatchString(cfg.Sandbox.Schema) {
+			errors = append(errors, "sandbox.schema must be a lowercase identifier")
+		} else if cfg.Sandbox.Schema == "public" {
+			errors = append(errors, "sandbox.schema must not be public")
+		}
+	}
+	if meta := cfg.FileMetadata; meta.Pattern != "" {
+		if pattern, err := filemeta.Compile(meta.Pattern); err != nil {
+			errors = append(errors, fmt.Sprintf("filename_metadata.pattern: %v", err))
+		} else {
+			if err := filemeta.Validate(meta.ArchiveDir, pattern.Fields()); err != nil {
+				errors = append(errors, fmt.Sprintf("filename_metadata.archive_dir: %v", err))
+			}
+			reportFields := append(pattern.Fields(), filemeta.FieldUnitGuid, filemeta.FieldTimestamp)
+			if err := filemeta.Validate(meta.ReportName, reportFields); err != nil {
+				errors = append(errors, fmt.Sprintf("filename_metadata.report_name: %v", err))
+			}
+		}
+	} else if meta.ArchiveDir != "" || meta.ReportName != "" {
+		errors = append(errors, "filename_metadata.pattern is required for archive_dir and report_name")
+	}
+	if cfg.Report.PartSize <= 0 {
+		errors = append(errors, "report.part_size must be greater than 0")
+	}
+	if !locale.Supported(cfg.Report.Language) {
+		errors = append(errors, "report.language must be one of: en, ru, de")
+	}
+	if _, err := time.LoadLocation(cfg.Report.Timezone); err != nil {
+		errors = append(errors, fmt.Sprintf("report.timezone: %v", err))
+	}
+	errors = append(errors, validateBranding("report.branding", cfg.Report.Branding)...)
+	for tenant, branding := range cfg.Report.Tenants {
+		errors = append(errors, validateBranding("report.tenants."+tenant, branding)...)
+	}
 	if cfg.Worker.MaxWorkers <= 0 {
 		errors = append(errors, "worker.max_workers must be greater than 0")
 	}
+	if cfg.Worker.MinFileAge < 0 {
+		errors = append(errors, "worker.min_file_age must not be negative")
+	}
 	if cfg.Worker.ScanInterval <= 0 {
 		errors = append(errors, "worker.scan_interval must be greater than 0")
 	}
+	if cfg.Worker.RetryAttempts < 0 {
+		errors = append(errors, "worker.retry_attempts must not be negative")
+	}
+	if cfg.Worker.RetryAttempts > 0 && cfg.Worker.RetryDelay <= 0 {
+		errors = append(errors, "worker.retry_delay must be greater than 0 when retry_attempts is set")
+	}
+	if cfg.Worker.ProcessTimeout <= 0 {
+		errors = append(errors, "worker.process_timeout must be greater than 0")
+	}
+	if cfg.Worker.ReportMemoryMB < 0 {
+		errors = append(errors, "worker.report_memory_mb must not be negative")
+	}
+	if cfg.Worker.BatchSize <= 0 {
+		errors = append(errors, "worker.batch_size must be greater than 0")
+	}
+	if !slices.Contains(watcher.Modes, cfg.Watcher.Mode) {
+		errors = append(errors, "watcher.mode must be one of: "+strings.Join(watcher.Modes, ", "))
+	}
+	if cfg.Worker.HashWorkers < 0 || cfg.Worker.ParseWorkers < 0 || cfg.Worker.InsertWorkers < 0 {
+		errors = append(errors, "worker.hash_workers, parse_workers and insert_workers must not be negative")
+	}
+	if cfg.Worker.StreamMinSizeMB < 0 {
+		errors = append(errors, "worker.stream_min_size_mb must not be negative")
+	}
+	if cfg.Worker.StreamChunkRows <= 0 {
+		errors = append(errors, "worker.stream_chunk_rows must be greater than 0")
+	}
+	switch cfg.Worker.Assignment {
+	case "", "shared", "round_robin", "hash", "least_busy":
+	default:
+		errors = append(errors, "worker.assignment must be one of: shared, round_robin, hash, least_busy")
+	}
+	switch cfg.Worker.ConflictPolicy {
+	case "", "append", "skip", "overwrite", "version":
+	default:
+		errors = append(errors, "worker.conflict_policy must be one of: append, skip, overwrite, version")
+	}
+	if budget := cfg.Worker.ParseBudget + cfg.Worker.InsertBudget + cfg.Worker.ReportBudget; budget > 1 {
+		errors = append(errors, "worker parse/insert/report budgets must not exceed 1 in total")
+	}
+	if cfg.Archive.Enabled {
+		if cfg.Archive.OlderThanMonths <= 0 {
+			errors = append(errors, "archive.older_than_months must be greater than 0")
+		}
+		if cfg.Archive.Interval <= 0 {
+			errors = append(errors, "archive.interval must be greater than 0")
+		}
+		switch cfg.Archive.Storage {
+		case "dir":
+			if cfg.Archive.Dir == "" {
+				errors = append(errors, "archive.dir is required for dir storage")
+			}
+		case "s3":
+			if cfg.Archive.S3.Endpoint == "" || cfg.Archive.S3.Bucket == "" {
+				errors = append(errors, "archive.s3.endpoint and archive.s3.bucket are required for s3 storage")
+			}
+		default:
+			errors = append(errors, "archive.storage must be one of: dir, s3")
+		}
+	}
+	if cfg.ClickHouse.Enabled {
+		if cfg.ClickHouse.URL == "" || cfg.ClickHouse.Table == "" {
+			errors = append(errors, "clickhouse.url and clickhouse.table are required")
+		}
+		if cfg.ClickHouse.BatchRows <= 0 {
+			errors = append(errors, "clickhouse.batch_rows must be greater than 0")
+		}
+		if cfg.ClickHouse.FlushInterval <= 0 {
+			errors = append(errors, "clickhouse.flush_interval must be greater than 0")
+		}
+	}
+	if _, err := time.LoadLocation(cfg.Maintenance.Timezone); err != nil {
+		errors = append(errors, fmt.Sprintf("maintenance.timezone: %v", err))
+	}
+	for i, w := range cfg.Maintenance.Windows {
+		if _, err := maintenance.ParseSchedule(w.Schedule); err != nil {
+			errors = append(errors, fmt.Sprintf("maintenance.windows[%d].schedule: %v", i, err))
+		}
+		if w.Duration <= 0 {
+			errors = append(errors, fmt.Sprintf("maintenance.windows[%d].duration must be greater than 0", i))
+		}
+	}
+	switch cfg.Incidents.Provider {
+	case "":
+	case "pagerduty":
+		if cfg.Incidents.RoutingKey == "" {
+			errors = append(errors, "incidents.routing_key is required for pagerduty")
+		}
+	case "opsgenie":
+		if cfg.Incidents.APIKey == "" {
+			errors = append(errors, "incidents.api_key is required for opsgenie")
+		}
+	default:
+		errors = append(errors, "incidents.provider must be one of: pagerduty, opsgenie")
+	}
+	if cfg.Anomaly.Enabled {
+		if cfg.Anomaly.MinHistory <= 1 || cfg.Anomaly.Window < cfg.Anomaly.MinHistory {
+			errors = append(errors, "anomaly.min_history must be greater than 1 and not exceed anomaly.window")
+		}
+		if cfg.Anomaly.Threshold <= 0 {
+			errors = append(errors, "anomaly.threshold must be greater than 0")
+		}
+	}
+	if cfg.Subscriptions.Enabled {
+		if cfg.Subscriptions.PublicURL == "" {
+			errors = append(errors, "subscriptions.public_url is required")
+		}
+		if cfg.Subscriptions.MaxAttempts <= 0 {
+			errors = append(errors, "subscriptions.max_attempts must be greater than 0")
+		}
+	}
+	if !slices.Contains(dto.Roles, cfg.Access.DefaultRole) {
+		errors = append(errors, "access.default_role must be one of: viewer, operator, admin")
+	}
+	for i, k := range cfg.Access.Keys {
+		if k.Key == "" || !slices.Contains(dto.Roles, k.Role) {
+			errors = append(errors, fmt.Sprintf("access.keys[%d]: key is required and role must be one of: viewer, operator, admin", i))
+		}
+	}
+	if _, err := dto.ParseFields(strings.Join(cfg.Access.MaskedFields, ","), dto.DeviceData{}); err != nil {
+		errors = append(errors, fmt.Sprintf("access.masked_fields: %v", err))
+	}
+	if cfg.Downloads.URLTTL <= 0 {
+		errors = append(errors, "downloads.url_ttl must be positive")
+	}
+	if cfg.Downloads.SigningKey != "" && len(cfg.Downloads.SigningKey) < 32 {
+		errors = append(errors, "downloads.signing_key must be at least 32 characters")
+	}
+	if cfg.Throttle.DefaultFilesPerMinute < 0 {
+		errors = append(errors, "throttle.default_files_per_minute must not be negative")
+	}
 
 	if len(errors) > 0 {
 		return fmt.Errorf("config validation errors: %s", strings.Join(errors, ", "))
@@ -264,13 +1277,73 @@ func validateConfig(cfg *AppConfig) error {
 	return nil
 }
 
+var hexColorPattern = regexp.MustCompile(`^#[0-9A-Fa-f]{6}$`)
+
+// schemaNamePattern - имя схемы sandbox: подставляется в DSN без кавычек
+var schemaNamePattern = regexp.MustCompile(`^[a-z_][a-z0-9_]*$`)
+
+// validateBranding - проверка цветов и логотипа отчёта
+func validateBranding(key string, b BrandingConfig) []string {
+	var errors []string
+	if b.PrimaryColor != "" && !hexColorPattern.MatchString(b.PrimaryColor) {
+		errors = append(errors, key+".primary_color must be #RRGGBB")
+	}
+	if b.TextColor != "" && !hexColorPattern.MatchString(b.TextColor) {
+		errors = append(errors, key+".text_color must be #RRGGBB")
+	}
+	if b.LogoPath != "" {
+		switch strings.ToLower(filepath.Ext(b.LogoPath)) {
+		case ".png", ".jpg", ".jpeg":
+			if _, err := os.Stat(b.LogoPath); err != nil {
+				errors = append(errors, fmt.Sprintf("%s.logo_path: %v", key, err))
+			}
+		default:
+			errors = append(errors, fmt.Sprintf("%s.logo_path must be a PNG or JPEG image", key))
+		}
+	}
+	return errors
+}
+
 // normalizePaths - нормализует пути (делает их абсолютными)
 func normalizePaths(cfg *AppConfig) {
 	cfg.Directory.WatchPath = normalizePath(cfg.Directory.WatchPath)
 	cfg.Directory.OutputPath = normalizePath(cfg.Directory.OutputPath)
 	cfg.Directory.ArchivePath = normalizePath(cfg.Directory.ArchivePath)
 	cfg.Directory.TempPath = normalizePath(cfg.Directory.TempPath)
+	cfg.Directory.HoldPath = normalizePath(cfg.Directory.HoldPath)
+	cfg.Directory.StateFile = normalizePath(cfg.Directory.StateFile)
 	cfg.Logging.FilePath = normalizePath(cfg.Logging.FilePath)
+	cfg.PostProcess.CopyToDir = normalizePath(cfg.PostProcess.CopyToDir)
+	cfg.Digest.OutputDir = normalizePath(cfg.Digest.OutputDir)
+	cfg.Archive.Dir = normalizePath(cfg.Archive.Dir)
+	cfg.Report.Branding.LogoPath = normalizePath(cfg.Report.Branding.LogoPath)
+	for tenant, branding := range cfg.Report.Tenants {
+		branding.LogoPath = normalizePath(branding.LogoPath)
+		cfg.Report.Tenants[tenant] = branding
+	}
+}
+
+// dbReservedConns - соединения пула БД, оставляемые API и фоновым
+// задачам при выборе insert_workers по умолчанию
+const dbReservedConns = 2
+
+// resolveConcurrency - значения по умолчанию параллелизма этапов:
+// хеширование упирается в диск (2 файла на CPU), разбор - в CPU
+// (GOMAXPROCS), вставка - в пул соединений БД (без резерва API;
+// без ограничения пула - max_workers)
+func resolveConcurrency(cfg *AppConfig) {
+	if cfg.Worker.HashWorkers == 0 {
+		cfg.Worker.HashWorkers = 2 * runtime.NumCPU()
+	}
+	if cfg.Worker.ParseWorkers == 0 {
+		cfg.Worker.ParseWorkers = runtime.GOMAXPROCS(0)
+	}
+	if cfg.Worker.InsertWorkers == 0 {
+		cfg.Worker.InsertWorkers = cfg.Worker.MaxWorkers
+		if cfg.Database.MaxOpenConns > 0 {
+			cfg.Worker.InsertWorkers = max(cfg.Database.MaxOpenConns-dbReservedConns, 1)
+		}
+	}
 }
 
 // normalizePath - преобразует относительный путь в абсолютный
@@ -288,13 +1361,14 @@ func normalizePath(path string) string {
 
 // PrintConfig - выводит конфигурацию (без секретов)
 func (c *AppConfig) PrintConfig() {
-	log.Println("=== Loaded Configuration ===")
-	log.Printf("Database: host=%s, port=%d, name=%s", c.Database.Host, c.Database.Port, c.Database.Name)
-	log.Printf("Directories: watch=%s, output=%s", c.Directory.WatchPath, c.Directory.OutputPath)
-	log.Printf("Server: listen=%s:%d", c.Server.Host, c.Server.Port)
-	log.Printf("Workers: max=%d, scan_interval=%v", c.Worker.MaxWorkers, c.Worker.ScanInterval)
-	log.Printf("Logging: level=%s, format=%s", c.Logging.Level, c.Logging.Format)
-	log.Println("===========================")
+	slog.Info("=== Loaded Configuration ===")
+	slog.Info("Database", "host", c.Database.Host, "port", c.Database.Port, "name", c.Database.Name)
+	slog.Info("Directories", "watch", c.Directory.WatchPath, "output", c.Directory.OutputPath)
+	slog.Info("Server", "host", c.Server.Host, "port", c.Server.Port)
+	slog.Info("Workers", "max_workers", c.Worker.MaxWorkers, "scan_interval", c.Worker.ScanInterval)
+	slog.Info("Stage concurrency", "hash", c.Worker.HashWorkers, "parse", c.Worker.ParseWorkers, "insert", c.Worker.InsertWorkers)
+	slog.Info("Logging", "level", c.Logging.Level, "format", c.Logging.Format)
+	slog.Info("===========================")
 }
 
 // IsDebugMode - проверяет, включен ли режим отладки
@@ -306,7 +1380,7 @@ func (c *AppConfig) IsDebugMode() bool {
 func bindEnvVariables(v *viper.Viper) {
 	bind := func(key, env string) {
 		if err := v.BindEnv(key, env); err != nil {
-			log.Printf("Warning: failed to bind env variable %s to %s: %v", env, key, err)
+			slog.Warn("⚠️ Failed to bind env variable", "env", env, "key", key, "error", err)
 		}
 	}
 
@@ -322,14 +1396,73 @@ func bindEnvVariables(v *viper.Viper) {
 	bind("directory.watch_path", "TSV_DIRECTORY_WATCH_PATH")
 	bind("directory.output_path", "TSV_DIRECTORY_OUTPUT_PATH")
 	bind("directory.archive_path", "TSV_DIRECTORY_ARCHIVE_PATH")
+	bind("directory.hold_path", "TSV_DIRECTORY_HOLD_PATH")
+	bind("directory.state_file", "TSV_DIRECTORY_STATE_FILE")
+	bind("directory.rejected_file", "TSV_DIRECTORY_REJECTED_FILE")
 
 	// Сервер
 	bind("server.host", "TSV_SERVER_HOST")
 	bind("server.port", "TSV_SERVER_PORT")
 
+	// Кэш
+	bind("cache.backend", "TSV_CACHE_BACKEND")
+	bind("cache.redis.address", "TSV_CACHE_REDIS_ADDRESS")
+	bind("cache.redis.password", "TSV_CACHE_REDIS_PASSWORD")
+
+	// Автомат защиты чтения
+	bind("read_breaker.enabled", "TSV_READ_BREAKER_ENABLED")
+
+	// Оповещения
+	bind("alerts.webhook_url", "TSV_ALERTS_WEBHOOK_URL")
+	bind("incidents.provider", "TSV_INCIDENTS_PROVIDER")
+	bind("incidents.routing_key", "TSV_INCIDENTS_ROUTING_KEY")
+	bind("incidents.api_key", "TSV_INCIDENTS_API_KEY")
+
+	// Дайджест
+	bind("digest.enabled", "TSV_DIGEST_ENABLED")
+	bind("digest.smtp.host", "TSV_DIGEST_SMTP_HOST")
+	bind("digest.smtp.username", "TSV_DIGEST_SMTP_USERNAME")
+	bind("digest.smtp.password", "TSV_DIGEST_SMTP_PASSWORD")
+
+	// Оформление отчётов
+	bind("report.branding.company_name", "TSV_REPORT_BRANDING_COMPANY_NAME")
+	bind("report.branding.logo_path", "TSV_REPORT_BRANDING_LOGO_PATH")
+	bind("report.password", "TSV_REPORT_PASSWORD")
+	bind("report.owner_password", "TSV_REPORT_OWNER_PASSWORD")
+
+	// Архивация
+	bind("archive.enabled", "TSV_ARCHIVE_ENABLED")
+	bind("archive.s3.access_key", "TSV_ARCHIVE_S3_ACCESS_KEY")
+	bind("archive.s3.secret_key", "TSV_ARCHIVE_S3_SECRET_KEY")
+
+	// ClickHouse
+	bind("clickhouse.enabled", "TSV_CLICKHOUSE_ENABLED")
+	bind("clickhouse.url", "TSV_CLICKHOUSE_URL")
+	bind("clickhouse.user", "TSV_CLICKHOUSE_USER")
+	bind("clickhouse.password", "TSV_CLICKHOUSE_PASSWORD")
+
+	// Журнал аудита
+	bind("audit.enabled", "TSV_AUDIT_ENABLED")
+	bind("access.default_role", "TSV_ACCESS_DEFAULT_ROLE")
+	bind("downloads.signing_key", "TSV_DOWNLOADS_SIGNING_KEY")
+	bind("downloads.require_signature", "TSV_DOWNLOADS_REQUIRE_SIGNATURE")
+
+	// Подписки на отчёты
+	bind("subscriptions.enabled", "TSV_SUBSCRIPTIONS_ENABLED")
+	bind("subscriptions.public_url", "TSV_SUBSCRIPTIONS_PUBLIC_URL")
+
+	// Повторная выгрузка пропусков
+	bind("sequence_check.reexport.url", "TSV_SEQUENCE_CHECK_REEXPORT_URL")
+	bind("sequence_check.reexport.secret", "TSV_SEQUENCE_CHECK_REEXPORT_SECRET")
+
 	// Логирование
 	bind("logging.level", "TSV_LOGGING_LEVEL")
 	bind("logging.format", "TSV_LOGGING_FORMAT")
+	bind("logging.output", "TSV_LOGGING_OUTPUT")
+
+	// Режим sandbox (staging)
+	bind("sandbox.enabled", "TSV_SANDBOX_ENABLED")
+	bind("sandbox.schema", "TSV_SANDBOX_SCHEMA")
 
 	// Отладка
 	bind("debug", "TSV_DEBUG")
@@ -0,0 +1,82 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// loadTestConfig загружает конфигурацию из YAML во временном каталоге
+func loadTestConfig(t *testing.T, yaml string) *AppConfig {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(yaml), 0644))
+	t.Chdir(t.TempDir()) // без .env рабочего каталога
+	cfg, err := LoadConfig(path)
+	require.NoError(t, err)
+	return cfg
+}
+
+func TestLoadConfig_SessionLimitsDisabledByDefault(t *testing.T) {
+	cfg := loadTestConfig(t, "database:\n  host: db\n")
+	assert.Zero(t, cfg.Database.StatementTimeout)
+	assert.Zero(t, cfg.Database.LockTimeout)
+
+	pqCfg, err := pq.NewConfig(cfg.Database.GetDSN())
+	require.NoError(t, err)
+	assert.NotContains(t, pqCfg.Runtime, "statement_timeout")
+	assert.NotContains(t, pqCfg.Runtime, "lock_timeout")
+}
+
+func TestDatabaseConfig_GetDSN(t *testing.T) {
+	cfg := DatabaseConfig{
+		Host:             "db.internal",
+		Port:             5433,
+		User:             "tsv user",
+		Password:         `p'a\ss word`,
+		Name:             "tsv_db",
+		SSLMode:          "require",
+		StatementTimeout: 90 * time.Second,
+		LockTimeout:      1500 * time.Millisecond,
+	}
+
+	// Строку разбирает сам драйвер: значения не обрезаются на пробелах и кавычках
+	pqCfg, err := pq.NewConfig(cfg.GetDSN())
+	require.NoError(t, err)
+	assert.Equal(t, "db.internal", pqCfg.Host)
+	assert.Equal(t, uint16(5433), pqCfg.Port)
+	assert.Equal(t, "tsv user", pqCfg.User)
+	assert.Equal(t, `p'a\ss word`, pqCfg.Password)
+	assert.Equal(t, "tsv_db", pqCfg.Database)
+	assert.Equal(t, "90000", pqCfg.Runtime["statement_timeout"])
+	assert.Equal(t, "1500", pqCfg.Runtime["lock_timeout"])
+	assert.NotContains(t, pqCfg.Runtime, "search_path")
+
+	assert.Equal(t, `host=db.internal port=5433 user='tsv user' dbname=tsv_db sslmode=require statement_timeout=90000 lock_timeout=1500`,
+		cfg.GetDSNWithoutCredentials())
+
+	// Пустой пароль - пустое значение, а не следующий параметр
+	cfg.Password = ""
+	pqCfg, err = pq.NewConfig(cfg.GetDSN())
+	require.NoError(t, err)
+	assert.Empty(t, pqCfg.Password)
+	assert.Equal(t, "tsv_db", pqCfg.Database)
+}
+
+func TestLoadConfig_SandboxSearchPath(t *testing.T) {
+	cfg := loadTestConfig(t, "sandbox:\n  enabled: true\n  schema: staging_mirror\n")
+	assert.Equal(t, "staging_mirror,public", cfg.Database.SearchPath)
+
+	// Таблицы ищутся в схеме sandbox, расширения (pg_trgm) - в public
+	pqCfg, err := pq.NewConfig(cfg.Database.GetDSN())
+	require.NoError(t, err)
+	assert.Equal(t, "staging_mirror,public", pqCfg.Runtime["search_path"])
+
+	cfg = loadTestConfig(t, "sandbox:\n  enabled: false\n  schema: staging_mirror\n")
+	assert.Empty(t, cfg.Database.SearchPath)
+}
@@ -0,0 +1,89 @@
+// internal/database/breaker.go
+package database
+
+import (
+	"TSVProcessingService/db/sqlc"
+	"TSVProcessingService/internal/breaker"
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"net"
+
+	"github.com/lib/pq"
+)
+
+// guardedDB - sqlc.DBTX, сообщающий автомату защиты результат каждого
+// запроса. Запросы не блокируются: отклонение новых запросов при
+// разомкнутом автомате выполняется на входе API.
+type guardedDB struct {
+	db      sqlc.DBTX
+	breaker *breaker.Breaker
+}
+
+// GuardDB оборачивает db для учёта отказов в автомате b
+func GuardDB(db sqlc.DBTX, b *breaker.Breaker) sqlc.DBTX {
+	return &guardedDB{db: db, breaker: b}
+}
+
+func (g *guardedDB) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	result, err := g.db.ExecContext(ctx, query, args...)
+	g.breaker.Record(err)
+	return result, err
+}
+
+func (g *guardedDB) PrepareContext(ctx context.Context, query string) (*sql.Stmt, error) {
+	stmt, err := g.db.PrepareContext(ctx, query)
+	g.breaker.Record(err)
+	return stmt, err
+}
+
+func (g *guardedDB) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	rows, err := g.db.QueryContext(ctx, query, args...)
+	g.breaker.Record(err)
+	return rows, err
+}
+
+func (g *guardedDB) QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row {
+	row := g.db.QueryRowContext(ctx, query, args...)
+	g.breaker.Record(row.Err())
+	return row
+}
+
+// IsOverload сообщает, указывает ли ошибка запроса на перегрузку или
+// недоступность БД: истёк таймаут, оборвано соединение, не хватает
+// ресурсов (класс 53), запрос прерван сервером (класс 57) или ошибка
+// соединения (класс 08). Ошибки самого запроса и отмена клиентом не
+// считаются.
+func IsOverload(err error) bool {
+	if errors.Is(err, context.DeadlineExceeded) || errors.Is(err, driver.ErrBadConn) {
+		return true
+	}
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) {
+		switch pqErr.Code.Class() {
+		case "08", "53", "57":
+			return true
+		}
+		return false
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr)
+}
+
+// IsTransient сообщает, что запрос может пройти при повторе: кроме
+// перегрузки и недоступности БД (IsOverload) - конфликт сериализации,
+// взаимная блокировка и превышение lock_timeout
+func IsTransient(err error) bool {
+	if IsOverload(err) {
+		return true
+	}
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) {
+		switch pqErr.Code {
+		case "40001", "40P01", "55P03": // serialization_failure, deadlock_detected, lock_not_available
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,61 @@
+package database
+
+import (
+	"TSVProcessingService/internal/breaker"
+	"TSVProcessingService/internal/clock"
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIsOverload(t *testing.T) {
+	assert.True(t, IsOverload(context.DeadlineExceeded))
+	assert.True(t, IsOverload(fmt.Errorf("query: %w", driver.ErrBadConn)))
+	assert.True(t, IsOverload(&pq.Error{Code: "53300"})) // too_many_connections
+	assert.True(t, IsOverload(&pq.Error{Code: "57014"})) // query_canceled
+	assert.True(t, IsOverload(&pq.Error{Code: "08006"})) // connection_failure
+
+	assert.False(t, IsOverload(sql.ErrNoRows))
+	assert.False(t, IsOverload(context.Canceled))
+	assert.False(t, IsOverload(&pq.Error{Code: "23505"})) // unique_violation
+}
+
+func TestIsTransient(t *testing.T) {
+	assert.True(t, IsTransient(&pq.Error{Code: "08006"}))
+	assert.True(t, IsTransient(fmt.Errorf("insert: %w", &pq.Error{Code: "55P03"}))) // lock_not_available
+	assert.True(t, IsTransient(&pq.Error{Code: "40P01"}))                           // deadlock_detected
+
+	assert.False(t, IsTransient(&pq.Error{Code: "23505"}))
+	assert.False(t, IsTransient(fmt.Errorf("invalid file")))
+}
+
+func TestStoreGuard_RecordsQueryFailures(t *testing.T) {
+	store, cleanup := setupTestStore(t)
+	defer cleanup()
+
+	b := breaker.New("test", breaker.Config{
+		FailureThreshold: 2,
+		OpenTimeout:      time.Minute,
+		IsFailure:        IsOverload,
+	}, clock.Real)
+	store.Guard(b)
+
+	expired, cancel := context.WithDeadline(context.Background(), time.Now().Add(-time.Second))
+	defer cancel()
+
+	_, err := store.CountFiles(expired)
+	require.Error(t, err)
+	_, err = store.ListFilesSorted(expired, "id", nil, 10, 0)
+	require.Error(t, err)
+	assert.Equal(t, breaker.StateOpen, b.State())
+
+	// HealthCheck идёт в обход автомата
+	assert.NoError(t, store.HealthCheck(context.Background()))
+}
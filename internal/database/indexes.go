@@ -0,0 +1,64 @@
+// internal/database/indexes.go
+package database
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+)
+
+// RequiredIndex - индекс, на который рассчитаны запросы сервиса
+type RequiredIndex struct {
+	Table string
+	Name  string // имя в pg_indexes (для CREATE INDEX ON без имени - <таблица>_<колонки>_idx)
+	Usage string // какие запросы без него читают таблицу целиком
+}
+
+// RequiredIndexes - индексы из миграций под фильтры по unit_guid, status,
+// created_at, class и text
+var RequiredIndexes = []RequiredIndex{
+	{Table: "files", Name: "files_status_created_at_idx", Usage: "files by status"},
+	{Table: "files", Name: "files_file_hash_idx", Usage: "duplicate content check"},
+	{Table: "device_data", Name: "device_data_unit_guid_created_at_id_idx", Usage: "unit data and reports"},
+	{Table: "device_data", Name: "device_data_file_id_line_number_idx", Usage: "rows of a file"},
+	{Table: "device_data", Name: "device_data_file_id_class_line_number_idx", Usage: "rows of a file by class"},
+	{Table: "device_data", Name: "device_data_class_created_at_idx", Usage: "class statistics"},
+	{Table: "device_data", Name: "device_data_text_trgm_idx", Usage: "text search"},
+	{Table: "processing_errors", Name: "processing_errors_file_id_line_number_idx", Usage: "errors of a file"},
+	{Table: "processing_errors", Name: "processing_errors_unit_guid_created_at_idx", Usage: "errors of a unit"},
+	{Table: "reports", Name: "reports_unit_guid_generated_at_idx", Usage: "reports of a unit"},
+}
+
+// CheckIndexes возвращает отсутствующие индексы RequiredIndexes и пишет
+// предупреждение о каждом: запросы работают и без них, но медленно
+func (s *Store) CheckIndexes(ctx context.Context) ([]RequiredIndex, error) {
+	rows, err := s.db.QueryContext(ctx, `SELECT tablename, indexname FROM pg_indexes WHERE schemaname = current_schema()`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list indexes: %w", err)
+	}
+	defer rows.Close()
+
+	existing := make(map[RequiredIndex]bool)
+	for rows.Next() {
+		var idx RequiredIndex
+		if err := rows.Scan(&idx.Table, &idx.Name); err != nil {
+			return nil, fmt.Errorf("failed to list indexes: %w", err)
+		}
+		existing[idx] = true
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list indexes: %w", err)
+	}
+
+	var missing []RequiredIndex
+	for _, idx := range RequiredIndexes {
+		if !existing[RequiredIndex{Table: idx.Table, Name: idx.Name}] {
+			slog.Warn("⚠️ Index is missing, queries will scan the table", "index", idx.Name, "table", idx.Table, "usage", idx.Usage)
+			missing = append(missing, idx)
+		}
+	}
+	if len(missing) == 0 {
+		slog.Info("✅ All required indexes exist")
+	}
+	return missing, nil
+}
@@ -0,0 +1,95 @@
+package database
+
+import (
+	"TSVProcessingService/internal/filestatus"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestMigrationsFollowCDCConventions - каждая таблица из миграций ведёт
+// updated_at/change_seq триггером cdc_touch и входит в публикацию CDC
+func TestMigrationsFollowCDCConventions(t *testing.T) {
+	schema := readMigrations(t)
+
+	var published []string
+	for _, m := range regexp.MustCompile(`(?s)(?:CREATE PUBLICATION "`+CDCPublication+`" FOR|ALTER PUBLICATION "`+CDCPublication+`" ADD) TABLE(.*?);`).FindAllStringSubmatch(schema, -1) {
+		published = append(published, regexp.MustCompile(`"(\w+)"`).FindAllString(m[1], -1)...)
+	}
+
+	tables := regexp.MustCompile(`CREATE TABLE "(\w+)"`).FindAllStringSubmatch(schema, -1)
+	require.NotEmpty(t, tables)
+	for _, m := range tables {
+		table := m[1]
+		quoted := regexp.QuoteMeta(`"` + table + `"`)
+		assert.Regexp(t, `CREATE TRIGGER "\w+" BEFORE INSERT OR UPDATE ON `+quoted+`\s+FOR EACH ROW EXECUTE FUNCTION "cdc_touch"\(\)`, schema, "table %s: no cdc_touch trigger", table)
+		assert.Regexp(t, `(?s)(ALTER TABLE `+quoted+` ADD COLUMN "change_seq"|CREATE TABLE `+quoted+` \([^;]*"change_seq")`, schema, "table %s: no change_seq", table)
+		assert.Regexp(t, `(?s)(ALTER TABLE `+quoted+` ADD COLUMN "updated_at"|CREATE TABLE `+quoted+` \([^;]*"updated_at")`, schema, "table %s: no updated_at", table)
+		assert.Contains(t, published, `"`+table+`"`, "table %s is not in publication %s", table, CDCPublication)
+	}
+}
+
+// TestMigrationsCreateRequiredIndexes - индексы, наличие которых проверяется
+// при запуске, создаются миграциями под теми же именами
+func TestMigrationsCreateRequiredIndexes(t *testing.T) {
+	schema := readMigrations(t)
+
+	created := make(map[RequiredIndex]bool)
+	for _, m := range regexp.MustCompile(`CREATE INDEX (?:IF NOT EXISTS )?"(\w+)" ON "(\w+)"`).FindAllStringSubmatch(schema, -1) {
+		created[RequiredIndex{Table: m[2], Name: m[1]}] = true
+	}
+	// Имя индекса без имени PostgreSQL составляет из таблицы и колонок
+	for _, m := range regexp.MustCompile(`CREATE INDEX ON "(\w+)" \(([^)]*)\)`).FindAllStringSubmatch(schema, -1) {
+		name := []string{m[1]}
+		for _, column := range regexp.MustCompile(`"(\w+)"`).FindAllStringSubmatch(m[2], -1) {
+			name = append(name, column[1])
+		}
+		created[RequiredIndex{Table: m[1], Name: strings.Join(append(name, "idx"), "_")}] = true
+	}
+
+	for _, idx := range RequiredIndexes {
+		assert.True(t, created[RequiredIndex{Table: idx.Table, Name: idx.Name}], "index %s on %s is not created by migrations", idx.Name, idx.Table)
+	}
+}
+
+// TestMigrationsCheckFileStatuses - ограничение files_status_check
+// допускает ровно статусы filestatus.All
+func TestMigrationsCheckFileStatuses(t *testing.T) {
+	schema := readMigrations(t)
+
+	m := regexp.MustCompile(`CONSTRAINT "files_status_check"\s+CHECK \("status" IN \(([^)]*)\)\)`).FindStringSubmatch(schema)
+	require.NotNil(t, m, "files_status_check is not created by migrations")
+
+	var checked []string
+	for _, value := range regexp.MustCompile(`'(\w+)'`).FindAllStringSubmatch(m[1], -1) {
+		checked = append(checked, value[1])
+	}
+	var statuses []string
+	for _, status := range filestatus.All {
+		statuses = append(statuses, string(status))
+	}
+	assert.ElementsMatch(t, statuses, checked)
+}
+
+// readMigrations - все миграции up по порядку одной строкой
+func readMigrations(t *testing.T) string {
+	paths, err := filepath.Glob("../../db/migration/*.up.sql")
+	require.NoError(t, err)
+	require.NotEmpty(t, paths)
+	sort.Strings(paths)
+
+	var all strings.Builder
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		require.NoError(t, err)
+		all.Write(data)
+		all.WriteString("\n")
+	}
+	return all.String()
+}
@@ -3,11 +3,17 @@ package database
 
 import (
 	"TSVProcessingService/db/sqlc"
+	"TSVProcessingService/internal/apilog"
+	"TSVProcessingService/internal/breaker"
 	"TSVProcessingService/internal/config"
+	"TSVProcessingService/internal/filestatus"
+	"TSVProcessingService/internal/sorting"
 	"context"
 	"database/sql"
 	"fmt"
-	"log"
+	"log/slog"
+	"sort"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
@@ -17,7 +23,8 @@ import (
 // Store - обертка для sqlc с дополнительными методами
 type Store struct {
 	*sqlc.Queries
-	db *sql.DB
+	db   *sql.DB
+	conn sqlc.DBTX // запросы чтения и записи (db или db под автоматом защиты)
 }
 
 // NewStore - создание нового хранилища
@@ -25,9 +32,24 @@ func NewStore(db *sql.DB) *Store {
 	return &Store{
 		Queries: sqlc.New(db),
 		db:      db,
+		conn:    db,
 	}
 }
 
+// Guard направляет запросы Store через автомат защиты b. HealthCheck
+// и Ping обращаются к БД напрямую, чтобы видеть её восстановление.
+func (s *Store) Guard(b *breaker.Breaker) {
+	s.conn = GuardDB(s.conn, b)
+	s.Queries = sqlc.New(s.conn)
+}
+
+// Instrument учитывает длительность запросов Store (см. InstrumentDB).
+// Вызывается до Guard, чтобы отклонённые автоматом запросы тоже учитывались.
+func (s *Store) Instrument(slow time.Duration) {
+	s.conn = InstrumentDB(s.conn, slow)
+	s.Queries = sqlc.New(s.conn)
+}
+
 // GetDB возвращает подключение к базе данных
 func (s *Store) GetDB() *sql.DB {
 	return s.db
@@ -35,11 +57,11 @@ func (s *Store) GetDB() *sql.DB {
 
 // Connect - подключение к базе данных
 func Connect(cfg *config.DatabaseConfig) (*sql.DB, error) {
-	log.Println("🗄️  Connecting to database via sqlc...")
+	slog.Info("🗄️  Connecting to database via sqlc...")
 
 	// Формируем DSN строку
 	dsn := cfg.GetDSN()
-	log.Printf("  Database: %s", cfg.GetDSNWithoutCredentials())
+	slog.Info("Database", "dsn", cfg.GetDSNWithoutCredentials())
 
 	// Открываем соединение
 	db, err := sql.Open("postgres", dsn)
@@ -62,12 +84,11 @@ func Connect(cfg *config.DatabaseConfig) (*sql.DB, error) {
 		return nil, fmt.Errorf("database ping failed: %w", err)
 	}
 
-	log.Println("  ✓ Database connection established")
+	slog.Info("✓ Database connection established")
 
 	// Выводим статистику
 	stats := db.Stats()
-	log.Printf("  Pool stats: OpenConnections=%d, InUse=%d, Idle=%d",
-		stats.OpenConnections, stats.InUse, stats.Idle)
+	slog.Debug("Pool stats", "open_connections", stats.OpenConnections, "in_use", stats.InUse, "idle", stats.Idle)
 
 	return db, nil
 }
@@ -111,12 +132,12 @@ func (s *Store) GetStats() sql.DBStats {
 
 // CheckTablesExist - проверка существования таблиц
 func (s *Store) CheckTablesExist(ctx context.Context) error {
-	tables := []string{"files", "device_data", "processing_errors", "reports", "api_logs"}
+	tables := []string{"files", "device_data", "processing_errors", "reports", "api_logs", "idempotency_keys", "unit_daily_summary"}
 
 	for _, table := range tables {
 		query := `SELECT EXISTS (
             SELECT FROM information_schema.tables 
-            WHERE table_schema = 'public' 
+            WHERE table_schema = current_schema()
             AND table_name = $1
         )`
 
@@ -127,105 +148,956 @@ func (s *Store) CheckTablesExist(ctx context.Context) error {
 		}
 
 		if !exists {
-			log.Printf("⚠️  Table %s does not exist", table)
+			slog.Warn("⚠️ Table does not exist", "table", table)
 			return fmt.Errorf("table %s does not exist", table)
 		}
 	}
 
-	log.Println("✅ All required tables exist")
+	slog.Info("✅ All required tables exist")
 	return nil
 }
 
+// activeData - условие, исключающее данные заменённых файлов
+// (files.superseded_by): они хранятся для аудита, но не попадают
+// в выборки по умолчанию
+const activeData = `file_id NOT IN (SELECT id FROM files WHERE superseded_by IS NOT NULL)`
+
 // CountDeviceDataByUnit - подсчет количества записей по unit_guid
 func (s *Store) CountDeviceDataByUnit(ctx context.Context, unitGuid uuid.UUID) (int, error) {
 	var count int
-	query := `SELECT COUNT(*) FROM device_data WHERE unit_guid = $1`
-	err := s.db.QueryRowContext(ctx, query, unitGuid).Scan(&count)
+	query := `SELECT COUNT(*) FROM device_data WHERE unit_guid = $1 AND ` + activeData
+	err := s.conn.QueryRowContext(ctx, query, unitGuid).Scan(&count)
+	return count, err
+}
+
+// UnitMetadata - сводные данные устройства для часто опрашиваемых endpoint'ов
+type UnitMetadata struct {
+	TotalRecords int64      `json:"total_records"`
+	LastActivity *time.Time `json:"last_activity"`
+}
+
+// GetUnitMetadata - количество записей и время последней активности устройства
+func (s *Store) GetUnitMetadata(ctx context.Context, unitGuid uuid.UUID) (UnitMetadata, error) {
+	var meta UnitMetadata
+	var lastActivity sql.NullTime
+	query := `SELECT COUNT(*), MAX(created_at) FROM device_data WHERE unit_guid = $1 AND ` + activeData
+	if err := s.conn.QueryRowContext(ctx, query, unitGuid).Scan(&meta.TotalRecords, &lastActivity); err != nil {
+		return meta, err
+	}
+	if lastActivity.Valid {
+		meta.LastActivity = &lastActivity.Time
+	}
+	return meta, nil
+}
+
+// Поля, доступные для сортировки списков (параметр sort)
+var (
+	DeviceDataSortFields = sorting.AllowList{
+		"created_at":  "created_at",
+		"level":       "level",
+		"class":       "class",
+		"line_number": "line_number",
+	}
+	FileSortFields = sorting.AllowList{
+		"created_at": "created_at",
+		"filename":   "filename",
+		"status":     "status",
+	}
+)
+
+// ListDeviceDataByUnitSorted - страница данных устройства с сортировкой.
+// orderBy должен быть собран sorting.OrderBy по DeviceDataSortFields.
+func (s *Store) ListDeviceDataByUnitSorted(ctx context.Context, unitGuid uuid.UUID, orderBy string, limit, offset int32) ([]sqlc.DeviceDatum, error) {
+	query := `SELECT id, file_id, unit_guid, mqtt, invid, msg_id, text, context, class, level, area, addr, block, type, bit, invert_bit, line_number, created_at, version
+		FROM device_data WHERE unit_guid = $1 AND ` + activeData + ` ` + orderBy + ` LIMIT $2 OFFSET $3`
+
+	rows, err := s.conn.QueryContext(ctx, query, unitGuid, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	items := []sqlc.DeviceDatum{}
+	for rows.Next() {
+		var i sqlc.DeviceDatum
+		if err := rows.Scan(
+			&i.ID, &i.FileID, &i.UnitGuid, &i.Mqtt, &i.Invid, &i.MsgID, &i.Text,
+			&i.Context, &i.Class, &i.Level, &i.Area, &i.Addr, &i.Block, &i.Type,
+			&i.Bit, &i.InvertBit, &i.LineNumber, &i.CreatedAt, &i.Version,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	return items, rows.Err()
+}
+
+// ListDeviceDataByUnitRange - страница данных устройства с created_at
+// в [from, to) (нулевые границы не ограничивают) и общее количество
+// таких записей. orderBy собирается как для ListDeviceDataByUnitSorted.
+func (s *Store) ListDeviceDataByUnitRange(ctx context.Context, unitGuid uuid.UUID, from, to time.Time, orderBy string, limit, offset int32) ([]sqlc.DeviceDatum, int64, error) {
+	args := []interface{}{unitGuid}
+	where := ` WHERE unit_guid = $1 AND ` + activeData
+	if !from.IsZero() {
+		args = append(args, from)
+		where += fmt.Sprintf(" AND created_at >= $%d", len(args))
+	}
+	if !to.IsZero() {
+		args = append(args, to)
+		where += fmt.Sprintf(" AND created_at < $%d", len(args))
+	}
+
+	var total int64
+	if err := s.conn.QueryRowContext(ctx, `SELECT COUNT(*) FROM device_data`+where, args...).Scan(&total); err != nil {
+		return nil, 0, fmt.Errorf("failed to count device data: %w", err)
+	}
+
+	query := fmt.Sprintf(`SELECT id, file_id, unit_guid, mqtt, invid, msg_id, text, context, class, level, area, addr, block, type, bit, invert_bit, line_number, created_at, version
+		FROM device_data%s %s LIMIT $%d OFFSET $%d`, where, orderBy, len(args)+1, len(args)+2)
+	rows, err := s.conn.QueryContext(ctx, query, append(args, limit, offset)...)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to list device data: %w", err)
+	}
+	defer rows.Close()
+
+	items := []sqlc.DeviceDatum{}
+	for rows.Next() {
+		var i sqlc.DeviceDatum
+		if err := rows.Scan(
+			&i.ID, &i.FileID, &i.UnitGuid, &i.Mqtt, &i.Invid, &i.MsgID, &i.Text,
+			&i.Context, &i.Class, &i.Level, &i.Area, &i.Addr, &i.Block, &i.Type,
+			&i.Bit, &i.InvertBit, &i.LineNumber, &i.CreatedAt, &i.Version,
+		); err != nil {
+			return nil, 0, err
+		}
+		items = append(items, i)
+	}
+	return items, total, rows.Err()
+}
+
+// FileUnitGuids - устройства, данные которых загружены из файла
+func (s *Store) FileUnitGuids(ctx context.Context, fileID int64) ([]uuid.UUID, error) {
+	rows, err := s.conn.QueryContext(ctx, `SELECT DISTINCT unit_guid FROM device_data WHERE file_id = $1`, fileID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var guids []uuid.UUID
+	for rows.Next() {
+		var guid uuid.UUID
+		if err := rows.Scan(&guid); err != nil {
+			return nil, err
+		}
+		guids = append(guids, guid)
+	}
+	return guids, rows.Err()
+}
+
+// fileMetadataWhere - условие WHERE по полям files.metadata (поле = значение
+// для каждой пары meta) и его параметры; номера параметров начинаются с 1
+func fileMetadataWhere(meta map[string]string) (string, []interface{}) {
+	if len(meta) == 0 {
+		return "", nil
+	}
+	keys := make([]string, 0, len(meta))
+	for k := range meta {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	conds := make([]string, 0, len(keys))
+	args := make([]interface{}, 0, len(keys)*2)
+	for i, k := range keys {
+		conds = append(conds, fmt.Sprintf("metadata ->> CAST($%d AS text) = $%d", i*2+1, i*2+2))
+		args = append(args, k, meta[k])
+	}
+	return "WHERE " + strings.Join(conds, " AND ") + " ", args
+}
+
+// CountFilesByMetadata - количество файлов с полями имени meta
+// (см. ListFilesSorted)
+func (s *Store) CountFilesByMetadata(ctx context.Context, meta map[string]string) (int64, error) {
+	where, args := fileMetadataWhere(meta)
+	var count int64
+	err := s.conn.QueryRowContext(ctx, `SELECT COUNT(*) FROM files `+where, args...).Scan(&count)
 	return count, err
 }
 
+// ListFilesSorted - страница списка файлов с сортировкой.
+// orderBy должен быть собран sorting.OrderBy по FileSortFields.
+// meta - фильтр по полям имени файла (files.metadata), пустой - все файлы.
+func (s *Store) ListFilesSorted(ctx context.Context, orderBy string, meta map[string]string, limit, offset int32) ([]sqlc.File, error) {
+	where, args := fileMetadataWhere(meta)
+	query := `SELECT id, filename, file_hash, status, rows_processed, rows_failed, error_message, created_at, updated_at, source,
+			file_mtime, arrived_at, completed_at, conflict_policy, rows_skipped, rows_overwritten, rows_versioned,
+			superseded_by, superseded_at, trace_id, span_id, sample_rate,
+			corrects_file_id, rows_corrected, correction_note, rows_duplicate_existing, rows_duplicate_in_file, profile, anomalies,
+			seq_first, seq_last, seq_gaps, metadata
+		FROM files ` + where + orderBy + fmt.Sprintf(` LIMIT $%d OFFSET $%d`, len(args)+1, len(args)+2)
+
+	rows, err := s.conn.QueryContext(ctx, query, append(args, limit, offset)...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	items := []sqlc.File{}
+	for rows.Next() {
+		var i sqlc.File
+		if err := rows.Scan(
+			&i.ID, &i.Filename, &i.FileHash, &i.Status, &i.RowsProcessed,
+			&i.RowsFailed, &i.ErrorMessage, &i.CreatedAt, &i.UpdatedAt, &i.Source,
+			&i.FileMtime, &i.ArrivedAt, &i.CompletedAt, &i.ConflictPolicy,
+			&i.RowsSkipped, &i.RowsOverwritten, &i.RowsVersioned, &i.SupersededBy, &i.SupersededAt,
+			&i.TraceID, &i.SpanID, &i.SampleRate,
+			&i.CorrectsFileID, &i.RowsCorrected, &i.CorrectionNote, &i.RowsDuplicateExisting, &i.RowsDuplicateInFile, &i.Profile,
+			&i.Anomalies, &i.SeqFirst, &i.SeqLast, &i.SeqGaps, &i.Metadata,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	return items, rows.Err()
+}
+
+// apiLogInsertChunk - строк в одном INSERT (5 параметров на строку,
+// с запасом до лимита PostgreSQL в 65535 параметров)
+const apiLogInsertChunk = 1000
+
+// InsertApiLogs - сохранение пачки записей журнала API многострочным INSERT
+func (s *Store) InsertApiLogs(ctx context.Context, entries []apilog.Entry) error {
+	for start := 0; start < len(entries); start += apiLogInsertChunk {
+		end := start + apiLogInsertChunk
+		if end > len(entries) {
+			end = len(entries)
+		}
+		if err := s.insertApiLogChunk(ctx, entries[start:end]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *Store) insertApiLogChunk(ctx context.Context, entries []apilog.Entry) error {
+	var query strings.Builder
+	query.WriteString(`INSERT INTO api_logs (endpoint, unit_guid, response_time_ms, status_code, created_at) VALUES `)
+
+	args := make([]interface{}, 0, len(entries)*5)
+	for i, e := range entries {
+		if i > 0 {
+			query.WriteString(", ")
+		}
+		n := i * 5
+		fmt.Fprintf(&query, "($%d, $%d, $%d, $%d, $%d)", n+1, n+2, n+3, n+4, n+5)
+		args = append(args, e.Endpoint, e.UnitGuid, e.ResponseTimeMs, e.StatusCode, e.CreatedAt)
+	}
+
+	_, err := s.conn.ExecContext(ctx, query.String(), args...)
+	return err
+}
+
+// Statistics - общая статистика по сервису
+type Statistics struct {
+	TotalFiles         int64            `json:"total_files"`
+	TotalDeviceRecords int64            `json:"total_device_records"`
+	TotalErrors        int64            `json:"total_errors"`
+	TotalReports       int64            `json:"total_reports"`
+	FilesByStatus      map[string]int64 `json:"files_by_status"`
+	RecentFiles        []RecentFile     `json:"recent_files"` // последние 5 файлов
+}
+
+// RecentFile - недавно поступивший файл
+type RecentFile struct {
+	Filename  string    `json:"filename"`
+	Status    string    `json:"status"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
 // GetStatistics возвращает общую статистику по сервису
-func (s *Store) GetStatistics(ctx context.Context) (map[string]interface{}, error) {
-	stats := make(map[string]interface{})
+func (s *Store) GetStatistics(ctx context.Context) (Statistics, error) {
+	var stats Statistics
 
 	// 1. Количество файлов
-	var totalFiles int64
-	err := s.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM files`).Scan(&totalFiles)
+	err := s.conn.QueryRowContext(ctx, `SELECT COUNT(*) FROM files`).Scan(&stats.TotalFiles)
 	if err != nil {
-		return nil, fmt.Errorf("failed to count files: %w", err)
+		return stats, fmt.Errorf("failed to count files: %w", err)
 	}
-	stats["total_files"] = totalFiles
 
 	// 2. Количество обработанных записей
-	var totalRecords int64
-	err = s.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM device_data`).Scan(&totalRecords)
+	err = s.conn.QueryRowContext(ctx, `SELECT COUNT(*) FROM device_data`).Scan(&stats.TotalDeviceRecords)
 	if err != nil {
-		return nil, fmt.Errorf("failed to count device_data: %w", err)
+		return stats, fmt.Errorf("failed to count device_data: %w", err)
 	}
-	stats["total_device_records"] = totalRecords
 
 	// 3. Количество ошибок обработки
-	var totalErrors int64
-	err = s.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM processing_errors`).Scan(&totalErrors)
+	err = s.conn.QueryRowContext(ctx, `SELECT COUNT(*) FROM processing_errors`).Scan(&stats.TotalErrors)
 	if err != nil {
-		return nil, fmt.Errorf("failed to count errors: %w", err)
+		return stats, fmt.Errorf("failed to count errors: %w", err)
 	}
-	stats["total_errors"] = totalErrors
 
 	// 4. Количество отчётов
-	var totalReports int64
-	err = s.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM reports`).Scan(&totalReports)
+	err = s.conn.QueryRowContext(ctx, `SELECT COUNT(*) FROM reports`).Scan(&stats.TotalReports)
 	if err != nil {
-		return nil, fmt.Errorf("failed to count reports: %w", err)
+		return stats, fmt.Errorf("failed to count reports: %w", err)
 	}
-	stats["total_reports"] = totalReports
 
 	// 5. Статистика по статусам файлов
-	rows, err := s.db.QueryContext(ctx, `
+	rows, err := s.conn.QueryContext(ctx, `
         SELECT status, COUNT(*) 
         FROM files 
         GROUP BY status
     `)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get file status stats: %w", err)
+		return stats, fmt.Errorf("failed to get file status stats: %w", err)
 	}
 	defer rows.Close()
 
-	fileStats := make(map[string]int64)
+	stats.FilesByStatus = make(map[string]int64)
 	for rows.Next() {
 		var status string
 		var count int64
 		if err := rows.Scan(&status, &count); err == nil {
-			fileStats[status] = count
+			stats.FilesByStatus[status] = count
 		}
 	}
-	stats["files_by_status"] = fileStats
 
 	// 6. Последние 5 обработанных файлов
-	lastFiles, err := s.db.QueryContext(ctx, `
+	lastFiles, err := s.conn.QueryContext(ctx, `
         SELECT filename, status, created_at 
         FROM files 
         ORDER BY created_at DESC 
         LIMIT 5
     `)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get recent files: %w", err)
+		return stats, fmt.Errorf("failed to get recent files: %w", err)
 	}
 	defer lastFiles.Close()
 
-	recentFiles := make([]map[string]interface{}, 0)
+	stats.RecentFiles = make([]RecentFile, 0)
 	for lastFiles.Next() {
-		var filename, status string
-		var createdAt time.Time
-		if err := lastFiles.Scan(&filename, &status, &createdAt); err == nil {
-			recentFiles = append(recentFiles, map[string]interface{}{
-				"filename":   filename,
-				"status":     status,
-				"created_at": createdAt,
-			})
+		var f RecentFile
+		if err := lastFiles.Scan(&f.Filename, &f.Status, &f.CreatedAt); err == nil {
+			stats.RecentFiles = append(stats.RecentFiles, f)
+		}
+	}
+
+	return stats, nil
+}
+
+// SourceStatistics - качество данных и задержка обработки по источнику файлов
+type SourceStatistics struct {
+	Source          string           `json:"source"`
+	Files           int64            `json:"files"`
+	FilesByStatus   map[string]int64 `json:"files_by_status"`
+	RowsProcessed   int64            `json:"rows_processed"`
+	RowsFailed      int64            `json:"rows_failed"`
+	RowErrorRate    float64          `json:"row_error_rate"`    // rows_failed / все строки
+	FileFailureRate float64          `json:"file_failure_rate"` // доля файлов со статусом failed
+	LatencyAvgMs    int64            `json:"latency_avg_ms"`    // от создания записи до финального статуса
+	LatencyP95Ms    int64            `json:"latency_p95_ms"`
+}
+
+// GetSourceStatistics возвращает статистику файлов, поступивших начиная
+// с since, сгруппированную по источнику. Пустой source - все источники.
+// Агрегация выполняется в Go, чтобы считать перцентили задержки.
+func (s *Store) GetSourceStatistics(ctx context.Context, since time.Time, source string) ([]SourceStatistics, error) {
+	query := `SELECT source, status, rows_processed, rows_failed, created_at, updated_at
+		FROM files WHERE created_at >= $1`
+	args := []interface{}{since}
+	if source != "" {
+		query += ` AND source = $2`
+		args = append(args, source)
+	}
+
+	rows, err := s.conn.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get source statistics: %w", err)
+	}
+	defer rows.Close()
+
+	bySource := make(map[string]*SourceStatistics)
+	latencies := make(map[string][]int64)
+	for rows.Next() {
+		var (
+			src                  string
+			status               sql.NullString
+			processed, failed    sql.NullInt32
+			createdAt, updatedAt sql.NullTime
+		)
+		if err := rows.Scan(&src, &status, &processed, &failed, &createdAt, &updatedAt); err != nil {
+			return nil, err
+		}
+
+		stats, ok := bySource[src]
+		if !ok {
+			stats = &SourceStatistics{Source: src, FilesByStatus: make(map[string]int64)}
+			bySource[src] = stats
+		}
+		stats.Files++
+		stats.FilesByStatus[status.String]++
+		stats.RowsProcessed += int64(processed.Int32)
+		stats.RowsFailed += int64(failed.Int32)
+
+		switch filestatus.Of(status) {
+		case filestatus.Completed, filestatus.Partial, filestatus.Failed:
+			if createdAt.Valid && updatedAt.Valid {
+				latencies[src] = append(latencies[src], updatedAt.Time.Sub(createdAt.Time).Milliseconds())
+			}
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	result := make([]SourceStatistics, 0, len(bySource))
+	for src, stats := range bySource {
+		if total := stats.RowsProcessed + stats.RowsFailed; total > 0 {
+			stats.RowErrorRate = float64(stats.RowsFailed) / float64(total)
+		}
+		stats.FileFailureRate = float64(stats.FilesByStatus[string(filestatus.Failed)]) / float64(stats.Files)
+		stats.LatencyAvgMs, stats.LatencyP95Ms = latencySummary(latencies[src])
+		result = append(result, *stats)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Source < result[j].Source })
+	return result, nil
+}
+
+// latencySummary - среднее и 95-й перцентиль задержек
+func latencySummary(values []int64) (avg, p95 int64) {
+	if len(values) == 0 {
+		return 0, 0
+	}
+	sort.Slice(values, func(i, j int) bool { return values[i] < values[j] })
+	var sum int64
+	for _, v := range values {
+		sum += v
+	}
+	return sum / int64(len(values)), percentile(values, 95)
+}
+
+// percentile - p-й перцентиль отсортированных значений (nearest-rank)
+func percentile(sorted []int64, p int) int64 {
+	if len(sorted) == 0 {
+		return 0
+	}
+	return sorted[(len(sorted)*p+99)/100-1]
+}
+
+// IngestLatencyStatistics - задержка загрузки данных за окно: от
+// поступления файла (arrived_at) и от его выгрузки партнёром (file_mtime)
+// до фиксации данных (completed_at)
+type IngestLatencyStatistics struct {
+	Files          int64   `json:"files"`
+	AvgMs          int64   `json:"avg_ms"`
+	P50Ms          int64   `json:"p50_ms"`
+	P95Ms          int64   `json:"p95_ms"`
+	MaxMs          int64   `json:"max_ms"`
+	ExportAvgMs    int64   `json:"export_avg_ms"` // от file_mtime
+	ExportP95Ms    int64   `json:"export_p95_ms"`
+	SLAMs          int64   `json:"sla_ms"`
+	WithinSLA      int64   `json:"within_sla"`
+	WithinSLARatio float64 `json:"within_sla_ratio"`
+}
+
+// GetIngestLatency считает задержку загрузки файлов с данными
+// (completed/partial), зафиксированных в интервале [from, to)
+func (s *Store) GetIngestLatency(ctx context.Context, from, to time.Time, sla time.Duration) (IngestLatencyStatistics, error) {
+	stats := IngestLatencyStatistics{SLAMs: sla.Milliseconds()}
+
+	rows, err := s.conn.QueryContext(ctx, `
+		SELECT file_mtime, arrived_at, completed_at
+		FROM files
+		WHERE completed_at >= $1 AND completed_at < $2
+		AND status IN ('completed', 'partial')`, from, to)
+	if err != nil {
+		return stats, fmt.Errorf("failed to get ingest latency: %w", err)
+	}
+	defer rows.Close()
+
+	var ingest, export []int64
+	for rows.Next() {
+		var mtime, arrivedAt, completedAt sql.NullTime
+		if err := rows.Scan(&mtime, &arrivedAt, &completedAt); err != nil {
+			return stats, err
+		}
+		if arrivedAt.Valid {
+			latency := completedAt.Time.Sub(arrivedAt.Time).Milliseconds()
+			ingest = append(ingest, latency)
+			if latency <= stats.SLAMs {
+				stats.WithinSLA++
+			}
+		}
+		if mtime.Valid {
+			export = append(export, completedAt.Time.Sub(mtime.Time).Milliseconds())
 		}
 	}
-	stats["recent_files"] = recentFiles
+	if err := rows.Err(); err != nil {
+		return stats, err
+	}
 
+	stats.Files = int64(len(ingest))
+	if stats.Files == 0 {
+		return stats, nil
+	}
+	stats.AvgMs, stats.P95Ms = latencySummary(ingest)
+	stats.P50Ms = percentile(ingest, 50)
+	stats.MaxMs = ingest[len(ingest)-1]
+	stats.ExportAvgMs, stats.ExportP95Ms = latencySummary(export)
+	stats.WithinSLARatio = float64(stats.WithinSLA) / float64(stats.Files)
 	return stats, nil
 }
+
+// DailyFileSummary - обработанные файлы и ошибки за сутки (UTC)
+type DailyFileSummary struct {
+	Date          string `json:"date"` // YYYY-MM-DD
+	Files         int64  `json:"files"`
+	FailedFiles   int64  `json:"failed_files"`
+	RowsProcessed int64  `json:"rows_processed"`
+	RowsFailed    int64  `json:"rows_failed"`
+}
+
+// GetDailyFileSummary возвращает посуточную сводку по файлам,
+// поступившим в интервале [from, to). Дни без файлов не включаются.
+func (s *Store) GetDailyFileSummary(ctx context.Context, from, to time.Time) ([]DailyFileSummary, error) {
+	rows, err := s.conn.QueryContext(ctx, `
+		SELECT status, rows_processed, rows_failed, created_at
+		FROM files
+		WHERE created_at >= $1 AND created_at < $2`, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get daily file summary: %w", err)
+	}
+	defer rows.Close()
+
+	byDay := make(map[string]*DailyFileSummary)
+	for rows.Next() {
+		var (
+			status            sql.NullString
+			processed, failed sql.NullInt32
+			createdAt         sql.NullTime
+		)
+		if err := rows.Scan(&status, &processed, &failed, &createdAt); err != nil {
+			return nil, err
+		}
+		day := createdAt.Time.UTC().Format("2006-01-02")
+		summary, ok := byDay[day]
+		if !ok {
+			summary = &DailyFileSummary{Date: day}
+			byDay[day] = summary
+		}
+		summary.Files++
+		if filestatus.Of(status) == filestatus.Failed {
+			summary.FailedFiles++
+		}
+		summary.RowsProcessed += int64(processed.Int32)
+		summary.RowsFailed += int64(failed.Int32)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	result := make([]DailyFileSummary, 0, len(byDay))
+	for _, summary := range byDay {
+		result = append(result, *summary)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Date < result[j].Date })
+	return result, nil
+}
+
+// UnitActivity - количество сообщений устройства за период
+type UnitActivity struct {
+	UnitGuid uuid.UUID `json:"unit_guid"`
+	Messages int64     `json:"messages"`
+	Alarms   int64     `json:"alarms"`
+}
+
+// GetTopUnits возвращает устройства с наибольшим числом сообщений
+// в интервале [from, to)
+func (s *Store) GetTopUnits(ctx context.Context, from, to time.Time, limit int32) ([]UnitActivity, error) {
+	rows, err := s.conn.QueryContext(ctx, `
+		SELECT unit_guid, COUNT(*) AS messages,
+			SUM(CASE WHEN class = 'alarm' THEN 1 ELSE 0 END) AS alarms
+		FROM device_data
+		WHERE created_at >= $1 AND created_at < $2 AND `+activeData+`
+		GROUP BY unit_guid
+		ORDER BY messages DESC, unit_guid
+		LIMIT $3`, from, to, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get top units: %w", err)
+	}
+	defer rows.Close()
+
+	units := []UnitActivity{}
+	for rows.Next() {
+		var unit UnitActivity
+		if err := rows.Scan(&unit.UnitGuid, &unit.Messages, &unit.Alarms); err != nil {
+			return nil, err
+		}
+		units = append(units, unit)
+	}
+	return units, rows.Err()
+}
+
+// ReportFilter - фильтр списка отчётов; нулевые поля не ограничивают выборку
+type ReportFilter struct {
+	From       time.Time // generated_at >= From
+	To         time.Time // generated_at < To
+	UnitGuid   uuid.UUID
+	ReportType string
+}
+
+// ListReports возвращает страницу отчётов по фильтру (новые первыми)
+// и общее количество подходящих отчётов
+func (s *Store) ListReports(ctx context.Context, filter ReportFilter, limit, offset int32) ([]sqlc.Report, int64, error) {
+	var (
+		conds []string
+		args  []interface{}
+	)
+	add := func(cond string, arg interface{}) {
+		args = append(args, arg)
+		conds = append(conds, fmt.Sprintf(cond, len(args)))
+	}
+	if !filter.From.IsZero() {
+		add("generated_at >= $%d", filter.From)
+	}
+	if !filter.To.IsZero() {
+		add("generated_at < $%d", filter.To)
+	}
+	if filter.UnitGuid != uuid.Nil {
+		add("unit_guid = $%d", filter.UnitGuid)
+	}
+	if filter.ReportType != "" {
+		add("report_type = $%d", filter.ReportType)
+	}
+	where := ""
+	if len(conds) > 0 {
+		where = " WHERE " + strings.Join(conds, " AND ")
+	}
+
+	var total int64
+	if err := s.conn.QueryRowContext(ctx, `SELECT COUNT(*) FROM reports`+where, args...).Scan(&total); err != nil {
+		return nil, 0, fmt.Errorf("failed to count reports: %w", err)
+	}
+
+	query := fmt.Sprintf(`SELECT id, unit_guid, report_type, file_path, generated_at, checksum, report_group, part,
+			trace_id, span_id
+		FROM reports%s
+		ORDER BY generated_at DESC, id DESC
+		LIMIT $%d OFFSET $%d`, where, len(args)+1, len(args)+2)
+	rows, err := s.conn.QueryContext(ctx, query, append(args, limit, offset)...)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to list reports: %w", err)
+	}
+	defer rows.Close()
+
+	reports := []sqlc.Report{}
+	for rows.Next() {
+		var r sqlc.Report
+		if err := rows.Scan(&r.ID, &r.UnitGuid, &r.ReportType, &r.FilePath, &r.GeneratedAt,
+			&r.Checksum, &r.ReportGroup, &r.Part, &r.TraceID, &r.SpanID); err != nil {
+			return nil, 0, err
+		}
+		reports = append(reports, r)
+	}
+	return reports, total, rows.Err()
+}
+
+// UnitProcessingError - ошибка разбора строки устройства с именем файла
+type UnitProcessingError struct {
+	sqlc.ProcessingError
+	Filename string
+}
+
+// ListProcessingErrorsByUnit возвращает страницу ошибок разбора строк
+// устройства по всем файлам (новые первыми) и их общее количество
+func (s *Store) ListProcessingErrorsByUnit(ctx context.Context, unitGuid uuid.UUID, limit, offset int32) ([]UnitProcessingError, int64, error) {
+	var total int64
+	if err := s.conn.QueryRowContext(ctx,
+		`SELECT COUNT(*) FROM processing_errors WHERE unit_guid = $1`, unitGuid).Scan(&total); err != nil {
+		return nil, 0, fmt.Errorf("failed to count unit errors: %w", err)
+	}
+
+	rows, err := s.conn.QueryContext(ctx, `
+		SELECT e.id, e.file_id, e.line_number, e.raw_line, e.error_message, e.field_name, e.created_at, e.unit_guid, e.partial,
+			f.filename
+		FROM processing_errors e
+		JOIN files f ON f.id = e.file_id
+		WHERE e.unit_guid = $1
+		ORDER BY e.created_at DESC, e.id DESC
+		LIMIT $2 OFFSET $3`, unitGuid, limit, offset)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to list unit errors: %w", err)
+	}
+	defer rows.Close()
+
+	errs := []UnitProcessingError{}
+	for rows.Next() {
+		var e UnitProcessingError
+		if err := rows.Scan(&e.ID, &e.FileID, &e.LineNumber, &e.RawLine, &e.ErrorMessage, &e.FieldName,
+			&e.CreatedAt, &e.UnitGuid, &e.Partial, &e.Filename); err != nil {
+			return nil, 0, err
+		}
+		errs = append(errs, e)
+	}
+	return errs, total, rows.Err()
+}
+
+// CDCPublication - публикация для логического декодирования (миграция 000014).
+// Все таблицы ведут updated_at и change_seq триггером cdc_touch.
+const CDCPublication = "tsv_cdc"
+
+// PublicationColumn - столбец таблицы публикации
+type PublicationColumn struct {
+	Name     string `json:"name"`
+	Type     string `json:"type"`
+	Nullable bool   `json:"nullable"`
+}
+
+// PublicationTable - таблица публикации и её столбцы
+type PublicationTable struct {
+	Table   string              `json:"table"`
+	Columns []PublicationColumn `json:"columns"`
+}
+
+// GetPublicationSchema - схема таблиц публикации (для настройки downstream ETL)
+func (s *Store) GetPublicationSchema(ctx context.Context, publication string) ([]PublicationTable, error) {
+	rows, err := s.conn.QueryContext(ctx, `
+		SELECT p.tablename, c.column_name, c.data_type, c.is_nullable = 'YES'
+		FROM pg_publication_tables p
+		JOIN information_schema.columns c ON c.table_schema = p.schemaname AND c.table_name = p.tablename
+		WHERE p.pubname = $1
+		ORDER BY p.tablename, c.ordinal_position`, publication)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read publication schema: %w", err)
+	}
+	defer rows.Close()
+
+	tables := []PublicationTable{}
+	for rows.Next() {
+		var table string
+		var col PublicationColumn
+		if err := rows.Scan(&table, &col.Name, &col.Type, &col.Nullable); err != nil {
+			return nil, err
+		}
+		if len(tables) == 0 || tables[len(tables)-1].Table != table {
+			tables = append(tables, PublicationTable{Table: table})
+		}
+		last := &tables[len(tables)-1]
+		last.Columns = append(last.Columns, col)
+	}
+	return tables, rows.Err()
+}
+
+// JobFilter - фильтр журнала заданий; пустые поля не ограничивают выборку
+type JobFilter struct {
+	Kind    string
+	State   string
+	Owner   string
+	Subject string
+}
+
+// ListJobs возвращает страницу заданий по фильтру (новые первыми)
+// и общее количество подходящих заданий
+func (s *Store) ListJobs(ctx context.Context, filter JobFilter, limit, offset int32) ([]sqlc.Job, int64, error) {
+	var (
+		conds []string
+		args  []interface{}
+	)
+	add := func(cond string, arg interface{}) {
+		args = append(args, arg)
+		conds = append(conds, fmt.Sprintf(cond, len(args)))
+	}
+	if filter.Kind != "" {
+		add("kind = $%d", filter.Kind)
+	}
+	if filter.State != "" {
+		add("state = $%d", filter.State)
+	}
+	if filter.Owner != "" {
+		add("owner = $%d", filter.Owner)
+	}
+	if filter.Subject != "" {
+		add("subject = $%d", filter.Subject)
+	}
+	where := ""
+	if len(conds) > 0 {
+		where = " WHERE " + strings.Join(conds, " AND ")
+	}
+
+	var total int64
+	if err := s.conn.QueryRowContext(ctx, `SELECT COUNT(*) FROM jobs`+where, args...).Scan(&total); err != nil {
+		return nil, 0, fmt.Errorf("failed to count jobs: %w", err)
+	}
+
+	query := fmt.Sprintf(`SELECT id, kind, subject, state, priority, owner, attempts, max_attempts, last_error,
+			created_at, started_at, finished_at, updated_at, change_seq
+		FROM jobs%s
+		ORDER BY created_at DESC, id DESC
+		LIMIT $%d OFFSET $%d`, where, len(args)+1, len(args)+2)
+	rows, err := s.conn.QueryContext(ctx, query, append(args, limit, offset)...)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to list jobs: %w", err)
+	}
+	defer rows.Close()
+
+	jobs := []sqlc.Job{}
+	for rows.Next() {
+		var j sqlc.Job
+		if err := rows.Scan(&j.ID, &j.Kind, &j.Subject, &j.State, &j.Priority, &j.Owner,
+			&j.Attempts, &j.MaxAttempts, &j.LastError, &j.CreatedAt, &j.StartedAt,
+			&j.FinishedAt, &j.UpdatedAt, &j.ChangeSeq); err != nil {
+			return nil, 0, err
+		}
+		jobs = append(jobs, j)
+	}
+	return jobs, total, rows.Err()
+}
+
+// TableSize - размер таблицы БД
+type TableSize struct {
+	Table      string `json:"table"`
+	Rows       int64  `json:"rows"`        // оценка по статистике PostgreSQL
+	TotalBytes int64  `json:"total_bytes"` // данные, индексы и TOAST
+	IndexBytes int64  `json:"index_bytes"`
+}
+
+// GetTableSizes возвращает размеры пользовательских таблиц (самые большие первыми)
+func (s *Store) GetTableSizes(ctx context.Context) ([]TableSize, error) {
+	rows, err := s.conn.QueryContext(ctx, `
+		SELECT relname, n_live_tup, pg_total_relation_size(relid), pg_indexes_size(relid)
+		FROM pg_stat_user_tables
+		ORDER BY 3 DESC, relname`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get table sizes: %w", err)
+	}
+	defer rows.Close()
+
+	tables := []TableSize{}
+	for rows.Next() {
+		var t TableSize
+		if err := rows.Scan(&t.Table, &t.Rows, &t.TotalBytes, &t.IndexBytes); err != nil {
+			return nil, err
+		}
+		tables = append(tables, t)
+	}
+	return tables, rows.Err()
+}
+
+// UnitStorage - объём данных устройства в БД и в архиве
+type UnitStorage struct {
+	UnitGuid      uuid.UUID `json:"unit_guid"`
+	Rows          int64     `json:"rows"`
+	Bytes         int64     `json:"bytes"` // сумма размеров строк device_data (без индексов)
+	ArchivedRows  int64     `json:"archived_rows"`
+	ArchivedBytes int64     `json:"archived_bytes"` // размер Parquet-файлов
+}
+
+// GetUnitStorage возвращает объём данных по устройствам (самые большие первыми)
+func (s *Store) GetUnitStorage(ctx context.Context) ([]UnitStorage, error) {
+	byUnit := make(map[uuid.UUID]*UnitStorage)
+	unit := func(guid uuid.UUID) *UnitStorage {
+		u, ok := byUnit[guid]
+		if !ok {
+			u = &UnitStorage{UnitGuid: guid}
+			byUnit[guid] = u
+		}
+		return u
+	}
+
+	rows, err := s.conn.QueryContext(ctx, `
+		SELECT unit_guid, COUNT(*), COALESCE(SUM(pg_column_size(d.*)), 0)
+		FROM device_data d
+		GROUP BY unit_guid`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get unit storage: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var (
+			guid        uuid.UUID
+			count, size int64
+		)
+		if err := rows.Scan(&guid, &count, &size); err != nil {
+			return nil, err
+		}
+		u := unit(guid)
+		u.Rows, u.Bytes = count, size
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	archived, err := s.conn.QueryContext(ctx, `
+		SELECT unit_guid, COALESCE(SUM(row_count), 0), COALESCE(SUM(size_bytes), 0)
+		FROM archived_partitions
+		GROUP BY unit_guid`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get archived unit storage: %w", err)
+	}
+	defer archived.Close()
+	for archived.Next() {
+		var (
+			guid        uuid.UUID
+			count, size int64
+		)
+		if err := archived.Scan(&guid, &count, &size); err != nil {
+			return nil, err
+		}
+		u := unit(guid)
+		u.ArchivedRows, u.ArchivedBytes = count, size
+	}
+	if err := archived.Err(); err != nil {
+		return nil, err
+	}
+
+	result := make([]UnitStorage, 0, len(byUnit))
+	for _, u := range byUnit {
+		result = append(result, *u)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Bytes != result[j].Bytes {
+			return result[i].Bytes > result[j].Bytes
+		}
+		return result[i].UnitGuid.String() < result[j].UnitGuid.String()
+	})
+	return result, nil
+}
+
+// SourceStorage - объём данных, загруженных из источника
+type SourceStorage struct {
+	Source string `json:"source"`
+	Tenant string `json:"tenant,omitempty"` // для источников "tenant:<name>"
+	Files  int64  `json:"files"`            // файлов с данными
+	Units  int64  `json:"units"`
+	Rows   int64  `json:"rows"`
+	Bytes  int64  `json:"bytes"` // сумма размеров строк device_data (без индексов)
+}
+
+// GetSourceStorage возвращает объём device_data по источникам файлов
+// (самые большие первыми)
+func (s *Store) GetSourceStorage(ctx context.Context) ([]SourceStorage, error) {
+	rows, err := s.conn.QueryContext(ctx, `
+		SELECT f.source, COUNT(DISTINCT d.file_id), COUNT(DISTINCT d.unit_guid),
+			COUNT(*), COALESCE(SUM(pg_column_size(d.*)), 0)
+		FROM device_data d
+		JOIN files f ON f.id = d.file_id
+		GROUP BY f.source
+		ORDER BY 5 DESC, f.source`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get source storage: %w", err)
+	}
+	defer rows.Close()
+
+	sources := []SourceStorage{}
+	for rows.Next() {
+		var src SourceStorage
+		if err := rows.Scan(&src.Source, &src.Files, &src.Units, &src.Rows, &src.Bytes); err != nil {
+			return nil, err
+		}
+		if tenant, ok := strings.CutPrefix(src.Source, "tenant:"); ok {
+			src.Tenant = tenant
+		}
+		sources = append(sources, src)
+	}
+	return sources, rows.Err()
+}
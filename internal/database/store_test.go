@@ -1,9 +1,13 @@
 package database
 
 import (
+	"TSVProcessingService/db/sqlc"
+	"TSVProcessingService/internal/apilog"
+	"TSVProcessingService/internal/sorting"
 	"context"
 	"database/sql"
 	"testing"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
@@ -26,7 +30,32 @@ func setupTestStore(t *testing.T) (*Store, func()) {
 		rows_failed INTEGER DEFAULT 0,
 		error_message TEXT,
 		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
-		updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+		updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		source TEXT NOT NULL DEFAULT 'unknown',
+		file_mtime DATETIME,
+		arrived_at DATETIME,
+		completed_at DATETIME,
+		conflict_policy TEXT,
+		rows_skipped INTEGER DEFAULT 0,
+		rows_overwritten INTEGER DEFAULT 0,
+		rows_versioned INTEGER DEFAULT 0,
+		superseded_by INTEGER,
+		superseded_at DATETIME,
+		change_seq INTEGER NOT NULL DEFAULT 0,
+		trace_id TEXT,
+		span_id TEXT,
+		sample_rate REAL,
+		corrects_file_id INTEGER,
+		rows_corrected INTEGER DEFAULT 0,
+		correction_note TEXT,
+		rows_duplicate_existing INTEGER,
+		rows_duplicate_in_file INTEGER,
+		profile BLOB NOT NULL DEFAULT X'7B7D',
+		anomalies BLOB NOT NULL DEFAULT X'5B5D',
+		seq_first INTEGER,
+		seq_last INTEGER,
+		seq_gaps INTEGER,
+		metadata BLOB NOT NULL DEFAULT X'7B7D'
 	);
 	CREATE TABLE device_data (
 		id INTEGER PRIMARY KEY AUTOINCREMENT,
@@ -47,6 +76,9 @@ func setupTestStore(t *testing.T) (*Store, func()) {
 		invert_bit INTEGER DEFAULT 0,
 		line_number INTEGER NOT NULL,
 		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		version INTEGER NOT NULL DEFAULT 1,
+		updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		change_seq INTEGER NOT NULL DEFAULT 0,
 		FOREIGN KEY (file_id) REFERENCES files(id) ON DELETE CASCADE
 	);
 	CREATE TABLE processing_errors (
@@ -57,14 +89,51 @@ func setupTestStore(t *testing.T) (*Store, func()) {
 		error_message TEXT NOT NULL,
 		field_name TEXT,
 		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		unit_guid TEXT,
+		partial BLOB NOT NULL DEFAULT X'7B7D',
+		updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		change_seq INTEGER NOT NULL DEFAULT 0,
 		FOREIGN KEY (file_id) REFERENCES files(id) ON DELETE CASCADE
 	);
+	CREATE TABLE api_logs (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		endpoint TEXT NOT NULL,
+		unit_guid TEXT,
+		response_time_ms INTEGER,
+		status_code INTEGER,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		change_seq INTEGER NOT NULL DEFAULT 0
+	);
 	CREATE TABLE reports (
 		id INTEGER PRIMARY KEY AUTOINCREMENT,
 		unit_guid TEXT NOT NULL,
 		report_type TEXT DEFAULT 'pdf',
 		file_path TEXT NOT NULL,
-		generated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+		generated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		checksum TEXT,
+		report_group TEXT,
+		part INTEGER,
+		updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		change_seq INTEGER NOT NULL DEFAULT 0,
+		trace_id TEXT,
+		span_id TEXT
+	);
+	CREATE TABLE jobs (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		kind TEXT NOT NULL,
+		subject TEXT NOT NULL DEFAULT '',
+		state TEXT NOT NULL DEFAULT 'queued',
+		priority INTEGER NOT NULL DEFAULT 0,
+		owner TEXT NOT NULL DEFAULT '',
+		attempts INTEGER NOT NULL DEFAULT 0,
+		max_attempts INTEGER NOT NULL DEFAULT 1,
+		last_error TEXT,
+		created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+		started_at DATETIME,
+		finished_at DATETIME,
+		updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		change_seq INTEGER NOT NULL DEFAULT 0
 	);
 	`
 	_, err = db.Exec(schema)
@@ -151,27 +220,415 @@ func TestGetStatistics(t *testing.T) {
 	stats, err := store.GetStatistics(ctx)
 	require.NoError(t, err)
 
-	assert.EqualValues(t, 0, stats["total_files"])
-	assert.EqualValues(t, 0, stats["total_device_records"])
-	assert.EqualValues(t, 0, stats["total_errors"])
-	assert.EqualValues(t, 0, stats["total_reports"])
-	assert.NotNil(t, stats["files_by_status"])
-	assert.Empty(t, stats["recent_files"])
+	assert.EqualValues(t, 0, stats.TotalFiles)
+	assert.EqualValues(t, 0, stats.TotalDeviceRecords)
+	assert.EqualValues(t, 0, stats.TotalErrors)
+	assert.EqualValues(t, 0, stats.TotalReports)
+	assert.NotNil(t, stats.FilesByStatus)
+	assert.Empty(t, stats.RecentFiles)
 
 	insertTestData(t, store.db)
 
 	stats, err = store.GetStatistics(ctx)
 	require.NoError(t, err)
 
-	assert.EqualValues(t, 2, stats["total_files"])
-	assert.EqualValues(t, 3, stats["total_device_records"])
-	assert.EqualValues(t, 1, stats["total_errors"])
-	assert.EqualValues(t, 1, stats["total_reports"])
+	assert.EqualValues(t, 2, stats.TotalFiles)
+	assert.EqualValues(t, 3, stats.TotalDeviceRecords)
+	assert.EqualValues(t, 1, stats.TotalErrors)
+	assert.EqualValues(t, 1, stats.TotalReports)
+
+	assert.EqualValues(t, 1, stats.FilesByStatus["completed"])
+	assert.EqualValues(t, 1, stats.FilesByStatus["failed"])
+
+	assert.Len(t, stats.RecentFiles, 2)
+}
+
+func TestListDeviceDataByUnitSorted(t *testing.T) {
+	store, cleanup := setupTestStore(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	insertTestData(t, store.db)
+
+	var guidStr string
+	err := store.db.QueryRow(`SELECT unit_guid FROM device_data WHERE invid = 'INV001' LIMIT 1`).Scan(&guidStr)
+	require.NoError(t, err)
+	unitGuid := uuid.MustParse(guidStr)
+
+	fields, err := sorting.Parse("level", DeviceDataSortFields)
+	require.NoError(t, err)
+	orderBy := sorting.OrderBy(fields, DeviceDataSortFields, "created_at DESC")
+
+	data, err := store.ListDeviceDataByUnitSorted(ctx, unitGuid, orderBy, 10, 0)
+	require.NoError(t, err)
+	require.Len(t, data, 2)
+	assert.EqualValues(t, 50, data[0].Level.Int32)
+	assert.EqualValues(t, 100, data[1].Level.Int32)
+}
+
+func TestListDeviceDataByUnitRange(t *testing.T) {
+	store, cleanup := setupTestStore(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	guid := uuid.New()
+	_, err := store.db.Exec(`INSERT INTO files (id, filename, file_hash, status) VALUES (1, 'a.tsv', 'h1', 'completed')`)
+	require.NoError(t, err)
+	for i, day := range []int{1, 5, 10, 20} {
+		_, err := store.db.Exec(`INSERT INTO device_data (file_id, unit_guid, line_number, created_at) VALUES (1, ?, ?, ?)`,
+			guid, i+1, time.Date(2025, 3, day, 12, 0, 0, 0, time.UTC))
+		require.NoError(t, err)
+	}
+
+	from := time.Date(2025, 3, 5, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2025, 3, 20, 0, 0, 0, 0, time.UTC)
+	data, total, err := store.ListDeviceDataByUnitRange(ctx, guid, from, to, "ORDER BY created_at DESC", 1, 0)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), total)
+	require.Len(t, data, 1)
+	assert.EqualValues(t, 3, data[0].LineNumber)
+
+	data, total, err = store.ListDeviceDataByUnitRange(ctx, guid, from, time.Time{}, "ORDER BY created_at DESC", 10, 0)
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), total)
+	assert.Len(t, data, 3)
+}
+
+func TestListFilesSorted(t *testing.T) {
+	store, cleanup := setupTestStore(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	insertTestData(t, store.db)
+
+	fields, err := sorting.Parse("-filename", FileSortFields)
+	require.NoError(t, err)
+	orderBy := sorting.OrderBy(fields, FileSortFields, "created_at DESC")
+
+	files, err := store.ListFilesSorted(ctx, orderBy, nil, 10, 0)
+	require.NoError(t, err)
+	require.Len(t, files, 2)
+	assert.Equal(t, "test2.tsv", files[0].Filename)
+	assert.Equal(t, "test1.tsv", files[1].Filename)
+
+	files, err = store.ListFilesSorted(ctx, orderBy, nil, 1, 1)
+	require.NoError(t, err)
+	require.Len(t, files, 1)
+	assert.Equal(t, "test1.tsv", files[0].Filename)
+}
+
+func TestListFilesSorted_MetadataFilter(t *testing.T) {
+	store, cleanup := setupTestStore(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	insertTestData(t, store.db)
+	_, err := store.db.Exec(`UPDATE files SET metadata = CAST('{"site":"SITE42","type":"full"}' AS BLOB) WHERE filename = 'test2.tsv'`)
+	require.NoError(t, err)
+
+	orderBy := sorting.OrderBy(nil, FileSortFields, "created_at DESC")
+	meta := map[string]string{"site": "SITE42", "type": "full"}
+	files, err := store.ListFilesSorted(ctx, orderBy, meta, 10, 0)
+	require.NoError(t, err)
+	require.Len(t, files, 1)
+	assert.Equal(t, "test2.tsv", files[0].Filename)
+	assert.JSONEq(t, `{"site":"SITE42","type":"full"}`, string(files[0].Metadata))
+
+	count, err := store.CountFilesByMetadata(ctx, meta)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), count)
+
+	count, err = store.CountFilesByMetadata(ctx, map[string]string{"site": "SITE7"})
+	require.NoError(t, err)
+	assert.Equal(t, int64(0), count)
+}
+
+func TestInsertApiLogs(t *testing.T) {
+	store, cleanup := setupTestStore(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	guid := uuid.New()
+	entries := []apilog.Entry{
+		{Endpoint: "/api/v1/files", ResponseTimeMs: 3, StatusCode: 200, CreatedAt: time.Now()},
+		{Endpoint: "/api/v1/devices/x/data", UnitGuid: uuid.NullUUID{UUID: guid, Valid: true}, ResponseTimeMs: 12, StatusCode: 200, CreatedAt: time.Now()},
+		{Endpoint: "/api/v1/files/missing.tsv", ResponseTimeMs: 1, StatusCode: 404, CreatedAt: time.Now()},
+	}
+	require.NoError(t, store.InsertApiLogs(ctx, entries))
+
+	var total, withUnit, notFound int
+	require.NoError(t, store.db.QueryRow(`SELECT COUNT(*) FROM api_logs`).Scan(&total))
+	require.NoError(t, store.db.QueryRow(`SELECT COUNT(*) FROM api_logs WHERE unit_guid = ?`, guid.String()).Scan(&withUnit))
+	require.NoError(t, store.db.QueryRow(`SELECT COUNT(*) FROM api_logs WHERE status_code = 404`).Scan(&notFound))
+	assert.Equal(t, 3, total)
+	assert.Equal(t, 1, withUnit)
+	assert.Equal(t, 1, notFound)
+}
+
+func TestGetSourceStatistics(t *testing.T) {
+	store, cleanup := setupTestStore(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	now := time.Now().UTC()
+	old := now.Add(-48 * time.Hour)
+
+	_, err := store.db.Exec(`
+		INSERT INTO files (filename, file_hash, status, rows_processed, rows_failed, source, created_at, updated_at) VALUES
+		('a1.tsv', 'h1', 'completed', 90, 10, 'tenant:a', ?, ?),
+		('a2.tsv', 'h2', 'failed', 0, 0, 'tenant:a', ?, ?),
+		('b1.tsv', 'h3', 'completed', 50, 0, 'directory', ?, ?),
+		('b0.tsv', 'h4', 'completed', 50, 0, 'directory', ?, ?)
+	`, now, now.Add(2*time.Second), now, now.Add(4*time.Second),
+		now, now.Add(time.Second), old, old)
+	require.NoError(t, err)
+
+	stats, err := store.GetSourceStatistics(ctx, now.Add(-time.Hour), "")
+	require.NoError(t, err)
+	require.Len(t, stats, 2)
+
+	assert.Equal(t, "directory", stats[0].Source)
+	assert.Equal(t, int64(1), stats[0].Files)
+
+	a := stats[1]
+	assert.Equal(t, "tenant:a", a.Source)
+	assert.Equal(t, int64(2), a.Files)
+	assert.Equal(t, int64(1), a.FilesByStatus["failed"])
+	assert.InDelta(t, 0.1, a.RowErrorRate, 0.0001)
+	assert.InDelta(t, 0.5, a.FileFailureRate, 0.0001)
+	assert.Equal(t, int64(3000), a.LatencyAvgMs)
+	assert.Equal(t, int64(4000), a.LatencyP95Ms)
 
-	filesByStatus := stats["files_by_status"].(map[string]int64)
-	assert.EqualValues(t, 1, filesByStatus["completed"])
-	assert.EqualValues(t, 1, filesByStatus["failed"])
+	stats, err = store.GetSourceStatistics(ctx, now.Add(-time.Hour), "directory")
+	require.NoError(t, err)
+	require.Len(t, stats, 1)
+	assert.Equal(t, "directory", stats[0].Source)
+}
+
+func TestGetIngestLatency(t *testing.T) {
+	store, cleanup := setupTestStore(t)
+	defer cleanup()
 
-	recentFiles := stats["recent_files"].([]map[string]interface{})
-	assert.Len(t, recentFiles, 2)
+	ctx := context.Background()
+	now := time.Now().UTC()
+
+	_, err := store.db.Exec(`
+		INSERT INTO files (filename, file_hash, status, file_mtime, arrived_at, completed_at) VALUES
+		('fast.tsv', 'h1', 'completed', ?, ?, ?),
+		('slow.tsv', 'h2', 'partial', ?, ?, ?),
+		('failed.tsv', 'h3', 'failed', ?, ?, ?)
+	`, now.Add(-2*time.Minute), now.Add(-time.Minute), now,
+		now.Add(-30*time.Minute), now.Add(-20*time.Minute), now,
+		now.Add(-time.Hour), now.Add(-time.Hour), now)
+	require.NoError(t, err)
+
+	stats, err := store.GetIngestLatency(ctx, now.Add(-time.Hour), now.Add(time.Second), 15*time.Minute)
+	require.NoError(t, err)
+
+	assert.Equal(t, int64(2), stats.Files)
+	assert.Equal(t, int64(time.Minute/time.Millisecond), stats.P50Ms)
+	assert.Equal(t, int64(20*time.Minute/time.Millisecond), stats.MaxMs)
+	assert.Equal(t, int64(16*time.Minute/time.Millisecond), stats.ExportAvgMs)
+	assert.Equal(t, int64(1), stats.WithinSLA)
+	assert.InDelta(t, 0.5, stats.WithinSLARatio, 0.0001)
+}
+
+func TestGetDailyFileSummary(t *testing.T) {
+	store, cleanup := setupTestStore(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	day1 := time.Date(2026, 10, 5, 10, 0, 0, 0, time.UTC)
+	day2 := day1.AddDate(0, 0, 1)
+
+	_, err := store.db.Exec(`
+		INSERT INTO files (filename, file_hash, status, rows_processed, rows_failed, created_at) VALUES
+		('a.tsv', 'h1', 'completed', 100, 5, ?),
+		('b.tsv', 'h2', 'failed', 0, 0, ?),
+		('c.tsv', 'h3', 'completed', 40, 0, ?),
+		('old.tsv', 'h4', 'completed', 10, 0, ?)
+	`, day1, day1.Add(time.Hour), day2, day1.AddDate(0, 0, -7))
+	require.NoError(t, err)
+
+	days, err := store.GetDailyFileSummary(ctx, day1.Add(-10*time.Hour), day2.AddDate(0, 0, 1))
+	require.NoError(t, err)
+	require.Len(t, days, 2)
+
+	assert.Equal(t, "2026-10-05", days[0].Date)
+	assert.Equal(t, int64(2), days[0].Files)
+	assert.Equal(t, int64(1), days[0].FailedFiles)
+	assert.Equal(t, int64(100), days[0].RowsProcessed)
+	assert.Equal(t, int64(5), days[0].RowsFailed)
+	assert.Equal(t, "2026-10-06", days[1].Date)
+	assert.Equal(t, int64(1), days[1].Files)
+}
+
+func TestGetTopUnits(t *testing.T) {
+	store, cleanup := setupTestStore(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	now := time.Now().UTC()
+	noisy := uuid.New()
+	quiet := uuid.New()
+
+	_, err := store.db.Exec(`
+		INSERT INTO device_data (file_id, unit_guid, class, line_number, created_at) VALUES
+		(1, ?, 'alarm', 1, ?),
+		(1, ?, 'info', 2, ?),
+		(1, ?, 'alarm', 3, ?),
+		(1, ?, 'info', 4, ?)
+	`, noisy.String(), now, noisy.String(), now, noisy.String(), now, quiet.String(), now)
+	require.NoError(t, err)
+
+	units, err := store.GetTopUnits(ctx, now.Add(-time.Hour), now.Add(time.Hour), 1)
+	require.NoError(t, err)
+	require.Len(t, units, 1)
+	assert.Equal(t, noisy, units[0].UnitGuid)
+	assert.Equal(t, int64(3), units[0].Messages)
+	assert.Equal(t, int64(2), units[0].Alarms)
+}
+
+func TestListReports(t *testing.T) {
+	store, cleanup := setupTestStore(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	now := time.Now().UTC()
+	unit := uuid.New()
+	other := uuid.New()
+
+	_, err := store.db.Exec(`
+		INSERT INTO reports (unit_guid, report_type, file_path, generated_at) VALUES
+		(?, 'pdf', '/reports/r1.pdf', ?),
+		(?, 'pdf', '/reports/r2.pdf', ?),
+		(?, 'html', '/reports/r3.html', ?),
+		(?, 'pdf', '/reports/r4.pdf', ?)
+	`, unit.String(), now.Add(-72*time.Hour), unit.String(), now.Add(-time.Hour),
+		unit.String(), now, other.String(), now)
+	require.NoError(t, err)
+
+	reports, total, err := store.ListReports(ctx, ReportFilter{}, 2, 0)
+	require.NoError(t, err)
+	assert.Equal(t, int64(4), total)
+	assert.Len(t, reports, 2)
+
+	reports, total, err = store.ListReports(ctx, ReportFilter{
+		From:       now.Add(-24 * time.Hour),
+		UnitGuid:   unit,
+		ReportType: "pdf",
+	}, 10, 0)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), total)
+	require.Len(t, reports, 1)
+	assert.Equal(t, "/reports/r2.pdf", reports[0].FilePath)
+
+	_, total, err = store.ListReports(ctx, ReportFilter{To: now.Add(-2 * time.Hour)}, 10, 0)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), total)
+}
+
+func TestSupersededFileExcluded(t *testing.T) {
+	store, cleanup := setupTestStore(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	guid := uuid.New()
+	_, err := store.db.Exec(`
+		INSERT INTO files (id, filename, file_hash, status) VALUES
+		(1, 'old.tsv', 'h1', 'completed'),
+		(2, 'new.tsv', 'h2', 'completed')`)
+	require.NoError(t, err)
+	_, err = store.db.Exec(`
+		INSERT INTO device_data (file_id, unit_guid, msg_id, line_number) VALUES
+		(1, ?, 'a', 1), (1, ?, 'b', 2), (2, ?, 'a', 1)`, guid, guid, guid)
+	require.NoError(t, err)
+
+	count, err := store.CountDeviceDataByUnit(ctx, guid)
+	require.NoError(t, err)
+	assert.Equal(t, 3, count)
+
+	_, err = sqlc.New(store.db).SupersedeFile(ctx, sqlc.SupersedeFileParams{
+		ID:           1,
+		SupersededBy: sql.NullInt64{Int64: 2, Valid: true},
+	})
+	require.NoError(t, err)
+
+	count, err = store.CountDeviceDataByUnit(ctx, guid)
+	require.NoError(t, err)
+	assert.Equal(t, 1, count)
+
+	data, err := store.ListDeviceDataByUnitSorted(ctx, guid, "ORDER BY id", 10, 0)
+	require.NoError(t, err)
+	require.Len(t, data, 1)
+	assert.Equal(t, int64(2), data[0].FileID)
+
+	guids, err := store.FileUnitGuids(ctx, 1)
+	require.NoError(t, err)
+	assert.Equal(t, []uuid.UUID{guid}, guids)
+}
+
+func TestListProcessingErrorsByUnit(t *testing.T) {
+	store, cleanup := setupTestStore(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	guid := uuid.New()
+	_, err := store.db.Exec(`
+		INSERT INTO files (id, filename, file_hash, status) VALUES
+		(1, 'a.tsv', 'h1', 'partial'),
+		(2, 'b.tsv', 'h2', 'partial')`)
+	require.NoError(t, err)
+	_, err = store.db.Exec(`
+		INSERT INTO processing_errors (file_id, line_number, raw_line, error_message, unit_guid, created_at) VALUES
+		(1, 3, 'line a', 'invalid level', ?, '2024-01-01 10:00:00'),
+		(2, 7, 'line b', 'invalid class value', ?, '2024-01-02 10:00:00'),
+		(2, 8, 'line c', 'invalid unit_guid', NULL, '2024-01-02 10:00:00')`, guid, guid)
+	require.NoError(t, err)
+
+	errs, total, err := store.ListProcessingErrorsByUnit(ctx, guid, 10, 0)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), total)
+	require.Len(t, errs, 2)
+	assert.Equal(t, "b.tsv", errs[0].Filename)
+	assert.Equal(t, "invalid class value", errs[0].ErrorMessage)
+	assert.Equal(t, "a.tsv", errs[1].Filename)
+
+	errs, _, err = store.ListProcessingErrorsByUnit(ctx, guid, 1, 1)
+	require.NoError(t, err)
+	require.Len(t, errs, 1)
+	assert.Equal(t, int32(3), errs[0].LineNumber.Int32)
+}
+
+func TestListJobs(t *testing.T) {
+	store, cleanup := setupTestStore(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	now := time.Now().UTC()
+	_, err := store.db.Exec(`
+		INSERT INTO jobs (kind, subject, state, owner, created_at) VALUES
+		('file', 'a.tsv', 'succeeded', 'worker-1', ?),
+		('file', 'b.tsv', 'failed', 'worker-2', ?),
+		('report', 'unit-1', 'running', 'report-worker-1', ?),
+		('cleanup', '', 'succeeded', 'scheduler', ?)
+	`, now.Add(-3*time.Hour), now.Add(-2*time.Hour), now.Add(-time.Hour), now)
+	require.NoError(t, err)
+
+	jobs, total, err := store.ListJobs(ctx, JobFilter{}, 2, 0)
+	require.NoError(t, err)
+	assert.Equal(t, int64(4), total)
+	require.Len(t, jobs, 2)
+	assert.Equal(t, "cleanup", jobs[0].Kind, "newest first")
+
+	jobs, total, err = store.ListJobs(ctx, JobFilter{Kind: "file", State: "failed"}, 10, 0)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), total)
+	require.Len(t, jobs, 1)
+	assert.Equal(t, "b.tsv", jobs[0].Subject)
+	assert.Equal(t, "worker-2", jobs[0].Owner)
+
+	_, total, err = store.ListJobs(ctx, JobFilter{Owner: "scheduler"}, 10, 0)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), total)
 }
@@ -0,0 +1,114 @@
+// internal/database/timing.go
+package database
+
+import (
+	"TSVProcessingService/db/sqlc"
+	"TSVProcessingService/internal/metrics"
+	"context"
+	"database/sql"
+	"errors"
+	"log/slog"
+	"strings"
+	"time"
+)
+
+// rawQuery - метка запросов, написанных вручную (без "-- name:" sqlc)
+const rawQuery = "raw"
+
+// slowQueryText - символов текста запроса без имени в журнале медленных запросов
+const slowQueryText = 200
+
+// Бакеты длительности запроса: от 1 мс до ~16 секунд
+var queryDurationBuckets = metrics.ExponentialBuckets(0.001, 2, 15)
+
+var (
+	queryDuration = metrics.Default.NewHistogram("tsv_db_query_duration_seconds",
+		"Database query duration by sqlc query name", queryDurationBuckets, "query", "status")
+	slowQueries = metrics.Default.NewCounter("tsv_db_slow_queries_total",
+		"Database queries slower than database.slow_query_threshold", "query")
+)
+
+// timedDB - sqlc.DBTX, учитывающий длительность каждого запроса по имени
+// запроса sqlc. Для QueryContext учитывается время до получения первых
+// строк, чтение результата вызывающим не входит.
+type timedDB struct {
+	db   sqlc.DBTX
+	slow time.Duration
+}
+
+// InstrumentDB оборачивает db для учёта длительности запросов в метрике
+// tsv_db_query_duration_seconds; запросы дольше slow пишутся в лог
+// (0 - журнал медленных запросов отключен)
+func InstrumentDB(db sqlc.DBTX, slow time.Duration) sqlc.DBTX {
+	return &timedDB{db: db, slow: slow}
+}
+
+func (t *timedDB) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	start := time.Now()
+	result, err := t.db.ExecContext(ctx, query, args...)
+	t.observe(query, start, err)
+	return result, err
+}
+
+func (t *timedDB) PrepareContext(ctx context.Context, query string) (*sql.Stmt, error) {
+	start := time.Now()
+	stmt, err := t.db.PrepareContext(ctx, query)
+	t.observe(query, start, err)
+	return stmt, err
+}
+
+func (t *timedDB) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	start := time.Now()
+	rows, err := t.db.QueryContext(ctx, query, args...)
+	t.observe(query, start, err)
+	return rows, err
+}
+
+func (t *timedDB) QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row {
+	start := time.Now()
+	row := t.db.QueryRowContext(ctx, query, args...)
+	t.observe(query, start, row.Err())
+	return row
+}
+
+func (t *timedDB) observe(query string, start time.Time, err error) {
+	elapsed := time.Since(start)
+	name := QueryName(query)
+	status := "ok"
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		status = "error"
+	}
+	queryDuration.Observe(elapsed.Seconds(), name, status)
+
+	if t.slow <= 0 || elapsed < t.slow {
+		return
+	}
+	slowQueries.Inc(name)
+	if name == rawQuery {
+		slog.Warn("🐢 Slow query", "component", "database", "elapsed", elapsed.Round(time.Millisecond), "threshold", t.slow, "query", compactQuery(query))
+		return
+	}
+	slog.Warn("🐢 Slow query", "component", "database", "name", name, "elapsed", elapsed.Round(time.Millisecond), "threshold", t.slow)
+}
+
+// QueryName - имя запроса sqlc из первой строки "-- name: <Имя> :<вид>";
+// для остальных запросов - "raw"
+func QueryName(query string) string {
+	rest, ok := strings.CutPrefix(strings.TrimSpace(query), "-- name: ")
+	if !ok {
+		return rawQuery
+	}
+	if name, _, ok := strings.Cut(rest, " "); ok && name != "" {
+		return name
+	}
+	return rawQuery
+}
+
+// compactQuery - текст запроса в одну строку с ограничением длины
+func compactQuery(query string) string {
+	query = strings.Join(strings.Fields(query), " ")
+	if len(query) > slowQueryText {
+		return query[:slowQueryText] + "..."
+	}
+	return query
+}
@@ -0,0 +1,38 @@
+package database
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestQueryName(t *testing.T) {
+	assert.Equal(t, "CountFiles", QueryName("-- name: CountFiles :one\nSELECT COUNT(*) FROM files"))
+	assert.Equal(t, "ListJobs", QueryName("\n-- name: ListJobs :many\nSELECT 1"))
+	assert.Equal(t, "raw", QueryName("SELECT COUNT(*) FROM device_data"))
+	assert.Equal(t, "raw", QueryName("-- name: "))
+}
+
+func TestStoreInstrument_RecordsQueryDuration(t *testing.T) {
+	store, cleanup := setupTestStore(t)
+	defer cleanup()
+	store.Instrument(time.Nanosecond)
+	ctx := context.Background()
+
+	named := queryDuration.Snapshot("CountFiles", "ok").Count
+	raw := queryDuration.Snapshot(rawQuery, "ok").Count
+	slow := slowQueries.Value("CountFiles")
+
+	_, err := store.CountFiles(ctx)
+	require.NoError(t, err)
+	_, err = store.CountDeviceDataByUnit(ctx, uuid.New())
+	require.NoError(t, err)
+
+	assert.Equal(t, named+1, queryDuration.Snapshot("CountFiles", "ok").Count)
+	assert.Equal(t, raw+1, queryDuration.Snapshot(rawQuery, "ok").Count)
+	assert.Equal(t, slow+1, slowQueries.Value("CountFiles"))
+}
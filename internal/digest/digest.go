@@ -0,0 +1,121 @@
+// internal/digest/digest.go
+package digest
+
+import (
+	"TSVProcessingService/internal/database"
+	"context"
+	"fmt"
+	"time"
+)
+
+// Периодичность дайджеста
+const (
+	Weekly  = "weekly"
+	Monthly = "monthly"
+)
+
+// Period - отчётный период [From, To)
+type Period struct {
+	Kind string    `json:"kind"`
+	From time.Time `json:"from"`
+	To   time.Time `json:"to"`
+}
+
+// String - период в виде "2026-10-05 - 2026-10-11"
+func (p Period) String() string {
+	return fmt.Sprintf("%s - %s", p.From.Format("2006-01-02"), p.To.Add(-time.Nanosecond).Format("2006-01-02"))
+}
+
+// PreviousPeriod возвращает последний завершённый период относительно now:
+// прошлую неделю (с понедельника) или прошлый календарный месяц.
+func PreviousPeriod(kind string, now time.Time) (Period, error) {
+	day := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	switch kind {
+	case Weekly:
+		// Понедельник текущей недели
+		offset := (int(day.Weekday()) + 6) % 7
+		to := day.AddDate(0, 0, -offset)
+		return Period{Kind: kind, From: to.AddDate(0, 0, -7), To: to}, nil
+	case Monthly:
+		to := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location())
+		return Period{Kind: kind, From: to.AddDate(0, -1, 0), To: to}, nil
+	}
+	return Period{}, fmt.Errorf("unknown digest period %q", kind)
+}
+
+// NextRun возвращает ближайший момент после now, когда нужно отправить
+// дайджест: понедельник или 1-е число месяца в hour часов.
+func NextRun(kind string, now time.Time, hour int) (time.Time, error) {
+	period, err := PreviousPeriod(kind, now)
+	if err != nil {
+		return time.Time{}, err
+	}
+	run := period.To.Add(time.Duration(hour) * time.Hour)
+	if !run.After(now) {
+		// Запуск текущего периода уже прошёл - следующий период
+		next := period.To
+		if kind == Weekly {
+			next = next.AddDate(0, 0, 7)
+		} else {
+			next = next.AddDate(0, 1, 0)
+		}
+		run = next.Add(time.Duration(hour) * time.Hour)
+	}
+	return run, nil
+}
+
+// Store - данные, из которых собирается дайджест
+type Store interface {
+	GetDailyFileSummary(ctx context.Context, from, to time.Time) ([]database.DailyFileSummary, error)
+	GetTopUnits(ctx context.Context, from, to time.Time, limit int32) ([]database.UnitActivity, error)
+	GetIngestLatency(ctx context.Context, from, to time.Time, sla time.Duration) (database.IngestLatencyStatistics, error)
+}
+
+// Digest - сводка работы сервиса за период
+type Digest struct {
+	Period        Period                           `json:"period"`
+	GeneratedAt   time.Time                        `json:"generated_at"`
+	Files         int64                            `json:"files"`
+	FailedFiles   int64                            `json:"failed_files"`
+	RowsProcessed int64                            `json:"rows_processed"`
+	RowsFailed    int64                            `json:"rows_failed"`
+	RowErrorRate  float64                          `json:"row_error_rate"`
+	Days          []database.DailyFileSummary      `json:"days"` // динамика ошибок
+	TopUnits      []database.UnitActivity          `json:"top_units"`
+	Latency       database.IngestLatencyStatistics `json:"ingest_latency"` // соблюдение SLA
+}
+
+// Build собирает дайджест за период. topUnits - размер списка самых
+// «шумных» устройств, sla - целевая задержка загрузки.
+func Build(ctx context.Context, store Store, period Period, topUnits int32, sla time.Duration) (*Digest, error) {
+	days, err := store.GetDailyFileSummary(ctx, period.From, period.To)
+	if err != nil {
+		return nil, err
+	}
+	units, err := store.GetTopUnits(ctx, period.From, period.To, topUnits)
+	if err != nil {
+		return nil, err
+	}
+	latency, err := store.GetIngestLatency(ctx, period.From, period.To, sla)
+	if err != nil {
+		return nil, err
+	}
+
+	d := &Digest{
+		Period:      period,
+		GeneratedAt: time.Now(),
+		Days:        days,
+		TopUnits:    units,
+		Latency:     latency,
+	}
+	for _, day := range days {
+		d.Files += day.Files
+		d.FailedFiles += day.FailedFiles
+		d.RowsProcessed += day.RowsProcessed
+		d.RowsFailed += day.RowsFailed
+	}
+	if total := d.RowsProcessed + d.RowsFailed; total > 0 {
+		d.RowErrorRate = float64(d.RowsFailed) / float64(total)
+	}
+	return d, nil
+}
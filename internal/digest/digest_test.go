@@ -0,0 +1,169 @@
+package digest
+
+import (
+	"TSVProcessingService/internal/config"
+	"TSVProcessingService/internal/database"
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeStore struct{}
+
+func (fakeStore) GetDailyFileSummary(ctx context.Context, from, to time.Time) ([]database.DailyFileSummary, error) {
+	return []database.DailyFileSummary{
+		{Date: "2026-10-05", Files: 3, FailedFiles: 1, RowsProcessed: 90, RowsFailed: 5},
+		{Date: "2026-10-06", Files: 1, RowsProcessed: 10, RowsFailed: 5},
+	}, nil
+}
+
+func (fakeStore) GetTopUnits(ctx context.Context, from, to time.Time, limit int32) ([]database.UnitActivity, error) {
+	return []database.UnitActivity{{UnitGuid: uuid.New(), Messages: 42, Alarms: 7}}, nil
+}
+
+func (fakeStore) GetIngestLatency(ctx context.Context, from, to time.Time, sla time.Duration) (database.IngestLatencyStatistics, error) {
+	return database.IngestLatencyStatistics{Files: 4, WithinSLA: 3, WithinSLARatio: 0.75, SLAMs: sla.Milliseconds()}, nil
+}
+
+type fakeSender struct {
+	subject     string
+	attachments []Attachment
+}
+
+func (s *fakeSender) Send(subject string, html []byte, attachments []Attachment) error {
+	s.subject = subject
+	s.attachments = attachments
+	return nil
+}
+
+func TestPreviousPeriod(t *testing.T) {
+	// Среда, 15 октября 2026
+	now := time.Date(2026, 10, 15, 12, 0, 0, 0, time.UTC)
+
+	week, err := PreviousPeriod(Weekly, now)
+	require.NoError(t, err)
+	assert.Equal(t, time.Date(2026, 10, 5, 0, 0, 0, 0, time.UTC), week.From)
+	assert.Equal(t, time.Date(2026, 10, 12, 0, 0, 0, 0, time.UTC), week.To)
+	assert.Equal(t, "2026-10-05 - 2026-10-11", week.String())
+
+	month, err := PreviousPeriod(Monthly, now)
+	require.NoError(t, err)
+	assert.Equal(t, time.Date(2026, 9, 1, 0, 0, 0, 0, time.UTC), month.From)
+	assert.Equal(t, time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC), month.To)
+
+	_, err = PreviousPeriod("daily", now)
+	assert.Error(t, err)
+}
+
+func TestNextRun(t *testing.T) {
+	monday := time.Date(2026, 10, 12, 0, 0, 0, 0, time.UTC)
+
+	// До часа отправки в понедельник - сегодня
+	run, err := NextRun(Weekly, monday.Add(5*time.Hour), 7)
+	require.NoError(t, err)
+	assert.Equal(t, monday.Add(7*time.Hour), run)
+
+	// После - в следующий понедельник
+	run, err = NextRun(Weekly, monday.Add(8*time.Hour), 7)
+	require.NoError(t, err)
+	assert.Equal(t, monday.AddDate(0, 0, 7).Add(7*time.Hour), run)
+
+	run, err = NextRun(Monthly, monday, 7)
+	require.NoError(t, err)
+	assert.Equal(t, time.Date(2026, 11, 1, 7, 0, 0, 0, time.UTC), run)
+}
+
+func TestBuild(t *testing.T) {
+	period, err := PreviousPeriod(Weekly, time.Now())
+	require.NoError(t, err)
+
+	d, err := Build(context.Background(), fakeStore{}, period, 10, 15*time.Minute)
+	require.NoError(t, err)
+
+	assert.Equal(t, int64(4), d.Files)
+	assert.Equal(t, int64(1), d.FailedFiles)
+	assert.Equal(t, int64(100), d.RowsProcessed)
+	assert.Equal(t, int64(10), d.RowsFailed)
+	assert.InDelta(t, 10.0/110.0, d.RowErrorRate, 0.0001)
+	assert.Len(t, d.TopUnits, 1)
+	assert.Equal(t, int64(15*time.Minute/time.Millisecond), d.Latency.SLAMs)
+}
+
+func TestRender(t *testing.T) {
+	period, err := PreviousPeriod(Monthly, time.Now())
+	require.NoError(t, err)
+	d, err := Build(context.Background(), fakeStore{}, period, 10, 15*time.Minute)
+	require.NoError(t, err)
+
+	html, err := RenderHTML(d, config.BrandingConfig{})
+	require.NoError(t, err)
+	assert.Contains(t, string(html), "monthly digest")
+	assert.Contains(t, string(html), d.TopUnits[0].UnitGuid.String())
+	assert.Contains(t, string(html), "75.00%")
+
+	pdf, err := RenderPDF(d, config.BrandingConfig{}, "")
+	require.NoError(t, err)
+	assert.True(t, bytes.HasPrefix(pdf, []byte("%PDF")))
+	assert.NotContains(t, string(pdf), "/Encrypt")
+
+	pdf, err = RenderPDF(d, config.BrandingConfig{}, "secret")
+	require.NoError(t, err)
+	assert.Contains(t, string(pdf), "/Encrypt")
+
+	html, err = RenderHTML(d, config.BrandingConfig{
+		CompanyName:  "Acme",
+		FooterText:   "Confidential",
+		PrimaryColor: "#AA0000",
+		TextColor:    "red; background: url(x)", // некорректный цвет не попадает в CSS
+	})
+	require.NoError(t, err)
+	assert.Contains(t, string(html), `<span class="company">Acme</span>`)
+	assert.Contains(t, string(html), "<footer>Confidential</footer>")
+	assert.Contains(t, string(html), "color: #AA0000")
+	assert.NotContains(t, string(html), "url(x)")
+}
+
+func TestScheduler_Generate(t *testing.T) {
+	dir := t.TempDir()
+	sender := &fakeSender{}
+	s := NewScheduler(fakeStore{}, Options{
+		Schedules: []string{Weekly},
+		Formats:   []string{FormatHTML, FormatPDF},
+		OutputDir: dir,
+		TopUnits:  5,
+		SLA:       time.Minute,
+	}, sender)
+	s.now = func() time.Time { return time.Date(2026, 10, 15, 7, 0, 0, 0, time.UTC) }
+
+	d, paths, err := s.Generate(context.Background(), Weekly, true)
+	require.NoError(t, err)
+	assert.Equal(t, int64(4), d.Files)
+	require.Len(t, paths, 2)
+	assert.Equal(t, filepath.Join(dir, "digest_weekly_20261005.html"), paths[0])
+	for _, path := range paths {
+		_, err := os.Stat(path)
+		assert.NoError(t, err)
+	}
+
+	assert.Contains(t, sender.subject, "2026-10-05 - 2026-10-11")
+	require.Len(t, sender.attachments, 2)
+	assert.Equal(t, "application/pdf", sender.attachments[1].ContentType)
+}
+
+func TestBuildMessage(t *testing.T) {
+	msg, err := buildMessage("tsv@example.com", []string{"ops@example.com"}, "Digest",
+		[]byte("<p>hi</p>"), []Attachment{{Filename: "d.pdf", ContentType: "application/pdf", Data: []byte("%PDF")}})
+	require.NoError(t, err)
+
+	text := string(msg)
+	assert.Contains(t, text, "To: ops@example.com\r\n")
+	assert.Contains(t, text, "Content-Type: multipart/mixed; boundary=")
+	assert.Contains(t, text, `attachment; filename=d.pdf`)
+}
@@ -0,0 +1,187 @@
+// internal/digest/render.go
+package digest
+
+import (
+	"TSVProcessingService/internal/branding"
+	"TSVProcessingService/internal/config"
+	"bytes"
+	"fmt"
+	"html/template"
+	"time"
+
+	"github.com/jung-kurt/gofpdf/v2"
+)
+
+// Форматы дайджеста
+const (
+	FormatHTML = "html"
+	FormatPDF  = "pdf"
+)
+
+// htmlData - данные шаблона: дайджест и оформление
+type htmlData struct {
+	*Digest
+	Brand        config.BrandingConfig
+	Logo         template.URL
+	PrimaryColor template.CSS
+	TextColor    template.CSS
+}
+
+var htmlTemplate = template.Must(template.New("digest").Funcs(template.FuncMap{
+	"percent":  func(v float64) string { return fmt.Sprintf("%.2f%%", v*100) },
+	"duration": func(ms int64) string { return (time.Duration(ms) * time.Millisecond).Round(time.Second).String() },
+}).Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>TSV Processing Service - {{.Period.Kind}} digest {{.Period}}</title>
+<style>
+body { font-family: sans-serif; color: {{.TextColor}}; }
+h1, h2, .company { color: {{.PrimaryColor}}; }
+header { border-bottom: 2px solid {{.PrimaryColor}}; margin-bottom: 1em; padding-bottom: 0.5em; }
+header img { max-height: 48px; vertical-align: middle; margin-right: 1em; }
+.company { font-size: 1.3em; font-weight: bold; }
+footer { border-top: 1px solid #ccc; margin-top: 2em; padding-top: 0.5em; font-size: 0.85em; }
+table { border-collapse: collapse; margin-bottom: 1.5em; }
+th, td { border: 1px solid #ccc; padding: 4px 8px; text-align: right; }
+th:first-child, td:first-child { text-align: left; }
+</style>
+</head>
+<body>
+{{if or .Logo .Brand.CompanyName .Brand.HeaderText}}<header>
+{{if .Logo}}<img src="{{.Logo}}" alt="">{{end}}<span class="company">{{.Brand.CompanyName}}</span>
+{{if .Brand.HeaderText}}<div>{{.Brand.HeaderText}}</div>{{end}}
+</header>
+{{end}}<h1>TSV Processing Service - {{.Period.Kind}} digest</h1>
+<p>Period: {{.Period}}. Generated: {{.GeneratedAt.Format "2006-01-02 15:04 MST"}}.</p>
+
+<h2>Summary</h2>
+<table>
+<tr><td>Files processed</td><td>{{.Files}}</td></tr>
+<tr><td>Failed files</td><td>{{.FailedFiles}}</td></tr>
+<tr><td>Rows processed</td><td>{{.RowsProcessed}}</td></tr>
+<tr><td>Rows failed</td><td>{{.RowsFailed}}</td></tr>
+<tr><td>Row error rate</td><td>{{percent .RowErrorRate}}</td></tr>
+</table>
+
+<h2>SLA compliance</h2>
+<table>
+<tr><td>Target ingest latency</td><td>{{duration .Latency.SLAMs}}</td></tr>
+<tr><td>Files within SLA</td><td>{{.Latency.WithinSLA}} of {{.Latency.Files}} ({{percent .Latency.WithinSLARatio}})</td></tr>
+<tr><td>Latency p50 / p95 / max</td><td>{{duration .Latency.P50Ms}} / {{duration .Latency.P95Ms}} / {{duration .Latency.MaxMs}}</td></tr>
+<tr><td>From partner export, avg / p95</td><td>{{duration .Latency.ExportAvgMs}} / {{duration .Latency.ExportP95Ms}}</td></tr>
+</table>
+
+<h2>Error trend</h2>
+<table>
+<tr><th>Date</th><th>Files</th><th>Failed files</th><th>Rows processed</th><th>Rows failed</th></tr>
+{{range .Days}}<tr><td>{{.Date}}</td><td>{{.Files}}</td><td>{{.FailedFiles}}</td><td>{{.RowsProcessed}}</td><td>{{.RowsFailed}}</td></tr>
+{{else}}<tr><td colspan="5">No files in this period</td></tr>
+{{end}}</table>
+
+<h2>Top noisy units</h2>
+<table>
+<tr><th>Unit GUID</th><th>Messages</th><th>Alarms</th></tr>
+{{range .TopUnits}}<tr><td>{{.UnitGuid}}</td><td>{{.Messages}}</td><td>{{.Alarms}}</td></tr>
+{{else}}<tr><td colspan="3">No device data in this period</td></tr>
+{{end}}</table>
+{{if .Brand.FooterText}}<footer>{{.Brand.FooterText}}</footer>
+{{end}}</body>
+</html>
+`))
+
+// RenderHTML формирует дайджест в виде HTML-страницы (тело письма)
+// в оформлении brand.
+func RenderHTML(d *Digest, brand config.BrandingConfig) ([]byte, error) {
+	data := htmlData{
+		Digest:       d,
+		Brand:        brand,
+		Logo:         template.URL(branding.LogoDataURI(brand)),
+		PrimaryColor: cssColor(brand.PrimaryColor, "#222222"),
+		TextColor:    cssColor(brand.TextColor, "#222222"),
+	}
+	var buf bytes.Buffer
+	if err := htmlTemplate.Execute(&buf, data); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// cssColor - цвет #RRGGBB для CSS (проверенный, чтобы не внедрить стили)
+func cssColor(hex, fallback string) template.CSS {
+	if _, _, _, ok := branding.RGB(hex); ok {
+		return template.CSS(hex)
+	}
+	return template.CSS(fallback)
+}
+
+// RenderPDF формирует дайджест в формате PDF в оформлении brand.
+// Непустой password шифрует документ (пароль владельца - случайный).
+func RenderPDF(d *Digest, brand config.BrandingConfig, password string) ([]byte, error) {
+	pdf := gofpdf.New("P", "mm", "A4", "")
+	if password != "" {
+		pdf.SetProtection(gofpdf.CnProtectPrint, password, "")
+	}
+	branding.ApplyPDF(pdf, brand)
+	pdf.AddPage()
+	pdf.SetFont("Arial", "B", 16)
+	branding.Title(pdf, brand, 10, fmt.Sprintf("TSV Processing Service - %s digest", d.Period.Kind))
+	pdf.Ln(10)
+
+	pdf.SetFont("Arial", "", 11)
+	pdf.Cell(40, 8, "Period: "+d.Period.String())
+	pdf.Ln(6)
+	pdf.Cell(40, 8, "Generated: "+d.GeneratedAt.Format(time.RFC3339))
+	pdf.Ln(10)
+
+	section := func(title string) {
+		pdf.SetFont("Arial", "B", 12)
+		branding.Title(pdf, brand, 8, title)
+		pdf.Ln(8)
+		pdf.SetFont("Arial", "", 10)
+	}
+	line := func(format string, args ...interface{}) {
+		pdf.Cell(40, 5, fmt.Sprintf(format, args...))
+		pdf.Ln(5)
+	}
+	duration := func(ms int64) string {
+		return (time.Duration(ms) * time.Millisecond).Round(time.Second).String()
+	}
+
+	section("Summary")
+	line("Files processed: %d (failed: %d)", d.Files, d.FailedFiles)
+	line("Rows processed: %d, rows failed: %d (%.2f%%)", d.RowsProcessed, d.RowsFailed, d.RowErrorRate*100)
+	pdf.Ln(4)
+
+	section("SLA compliance")
+	line("Target ingest latency: %s", duration(d.Latency.SLAMs))
+	line("Files within SLA: %d of %d (%.2f%%)", d.Latency.WithinSLA, d.Latency.Files, d.Latency.WithinSLARatio*100)
+	line("Latency p50 / p95 / max: %s / %s / %s",
+		duration(d.Latency.P50Ms), duration(d.Latency.P95Ms), duration(d.Latency.MaxMs))
+	line("From partner export, avg / p95: %s / %s", duration(d.Latency.ExportAvgMs), duration(d.Latency.ExportP95Ms))
+	pdf.Ln(4)
+
+	section("Error trend")
+	if len(d.Days) == 0 {
+		line("No files in this period")
+	}
+	for _, day := range d.Days {
+		line("%s: files %d, failed %d, rows %d, rows failed %d",
+			day.Date, day.Files, day.FailedFiles, day.RowsProcessed, day.RowsFailed)
+	}
+	pdf.Ln(4)
+
+	section("Top noisy units")
+	if len(d.TopUnits) == 0 {
+		line("No device data in this period")
+	}
+	for _, unit := range d.TopUnits {
+		line("%s: %d messages, %d alarms", unit.UnitGuid, unit.Messages, unit.Alarms)
+	}
+
+	var buf bytes.Buffer
+	if err := pdf.Output(&buf); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,164 @@
+// internal/digest/scheduler.go
+package digest
+
+import (
+	"TSVProcessingService/internal/config"
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// Options - настройки планировщика дайджестов
+type Options struct {
+	Schedules []string // weekly / monthly
+	Hour      int      // час отправки (локальное время)
+	Formats   []string // html / pdf
+	OutputDir string   // куда сохранять файлы дайджеста
+	TopUnits  int32
+	SLA       time.Duration
+	Branding  config.BrandingConfig
+
+	PDFPasswords map[string]string // пароль PDF по расписанию; нет пароля - без шифрования
+}
+
+// Scheduler формирует дайджесты по расписанию, сохраняет их в OutputDir
+// и рассылает через Sender (если задан). Пропущенные во время простоя
+// сервиса запуски не догоняются.
+type Scheduler struct {
+	store  Store
+	opts   Options
+	sender Sender // nil - только сохранение в файлы
+	stop   chan struct{}
+	now    func() time.Time
+}
+
+// NewScheduler создаёт планировщик дайджестов.
+func NewScheduler(store Store, opts Options, sender Sender) *Scheduler {
+	return &Scheduler{
+		store:  store,
+		opts:   opts,
+		sender: sender,
+		stop:   make(chan struct{}),
+		now:    time.Now,
+	}
+}
+
+// Run ждёт ближайшего запуска по расписанию до вызова Stop.
+func (s *Scheduler) Run() {
+	for {
+		kind, at, err := s.nextRun()
+		if err != nil {
+			slog.Error("❌ Scheduler stopped", "component", "digest", "error", err)
+			return
+		}
+		slog.Info("Next digest scheduled", "component", "digest", "kind", kind, "at", at.Format(time.RFC3339))
+
+		timer := time.NewTimer(at.Sub(s.now()))
+		select {
+		case <-timer.C:
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+			if _, _, err := s.Generate(ctx, kind, true); err != nil {
+				slog.Error("❌ Failed to generate digest", "component", "digest", "kind", kind, "error", err)
+			}
+			cancel()
+		case <-s.stop:
+			timer.Stop()
+			slog.Info("Scheduler stopped", "component", "digest")
+			return
+		}
+	}
+}
+
+// Stop останавливает Run.
+func (s *Scheduler) Stop() {
+	close(s.stop)
+}
+
+// nextRun - ближайший запуск среди всех расписаний
+func (s *Scheduler) nextRun() (string, time.Time, error) {
+	var kind string
+	var at time.Time
+	now := s.now()
+	for _, schedule := range s.opts.Schedules {
+		run, err := NextRun(schedule, now, s.opts.Hour)
+		if err != nil {
+			return "", time.Time{}, err
+		}
+		if at.IsZero() || run.Before(at) {
+			kind, at = schedule, run
+		}
+	}
+	if at.IsZero() {
+		return "", time.Time{}, fmt.Errorf("no digest schedules configured")
+	}
+	return kind, at, nil
+}
+
+// Generate формирует дайджест за последний завершённый период kind,
+// сохраняет его во всех форматах и, если send, рассылает письмом.
+// Возвращает дайджест и пути сохранённых файлов.
+func (s *Scheduler) Generate(ctx context.Context, kind string, send bool) (*Digest, []string, error) {
+	period, err := PreviousPeriod(kind, s.now())
+	if err != nil {
+		return nil, nil, err
+	}
+	d, err := Build(ctx, s.store, period, s.opts.TopUnits, s.opts.SLA)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if err := os.MkdirAll(s.opts.OutputDir, 0755); err != nil {
+		return nil, nil, err
+	}
+
+	var html []byte
+	var paths []string
+	var attachments []Attachment
+	base := fmt.Sprintf("digest_%s_%s", kind, period.From.Format("20060102"))
+	for _, format := range s.opts.Formats {
+		var data []byte
+		var contentType string
+		switch format {
+		case FormatHTML:
+			data, err = RenderHTML(d, s.opts.Branding)
+			html, contentType = data, "text/html; charset=utf-8"
+		case FormatPDF:
+			data, err = RenderPDF(d, s.opts.Branding, s.opts.PDFPasswords[kind])
+			contentType = "application/pdf"
+		default:
+			err = fmt.Errorf("unknown digest format %q", format)
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+
+		path := filepath.Join(s.opts.OutputDir, base+"."+format)
+		if err := os.WriteFile(path, data, 0644); err != nil {
+			return nil, nil, err
+		}
+		paths = append(paths, path)
+		attachments = append(attachments, Attachment{
+			Filename:    filepath.Base(path),
+			ContentType: contentType,
+			Data:        data,
+		})
+	}
+	slog.Info("📄 Digest saved", "component", "digest", "kind", kind, "period", period, "paths", paths)
+
+	if send && s.sender != nil {
+		if html == nil {
+			if html, err = RenderHTML(d, s.opts.Branding); err != nil {
+				return nil, nil, err
+			}
+		}
+		subject := fmt.Sprintf("TSV Processing Service %s digest: %s", kind, period)
+		if err := s.sender.Send(subject, html, attachments); err != nil {
+			return d, paths, err
+		}
+		slog.Info("✉️ Digest sent", "component", "digest", "kind", kind, "period", period)
+	}
+	return d, paths, nil
+}
@@ -0,0 +1,143 @@
+// internal/dispatch/dispatcher.go
+package dispatch
+
+import (
+	"TSVProcessingService/internal/watcher"
+	"fmt"
+	"hash/fnv"
+	"log/slog"
+	"sync"
+)
+
+// Стратегии распределения файлов по воркерам
+const (
+	StrategyShared     = "shared"      // общая очередь: файл берёт первый свободный воркер
+	StrategyRoundRobin = "round_robin" // по очереди
+	StrategyHash       = "hash"        // по хешу ключа (tenant/unit): кэши воркера остаются «тёплыми»
+	StrategyLeastBusy  = "least_busy"  // воркеру с наименьшим числом файлов в работе и в очереди
+)
+
+// ValidStrategy проверяет название стратегии из конфигурации.
+func ValidStrategy(strategy string) bool {
+	switch strategy {
+	case StrategyShared, StrategyRoundRobin, StrategyHash, StrategyLeastBusy:
+		return true
+	}
+	return false
+}
+
+// KeyFunc возвращает ключ файла для стратегии hash
+type KeyFunc func(fileInfo watcher.FileInfo) string
+
+// Dispatcher раздаёт файлы из общей очереди в собственные очереди
+// воркеров. Файлы с одинаковым ключом при стратегии hash всегда попадают
+// к одному воркеру.
+type Dispatcher struct {
+	strategy string
+	key      KeyFunc
+	queues   []chan watcher.FileInfo
+
+	mu   sync.Mutex
+	busy []int // файлов в обработке у воркера
+	next int   // следующий воркер для round_robin
+}
+
+// NewDispatcher создаёт Dispatcher для workers воркеров с очередью
+// queueSize у каждого.
+func NewDispatcher(strategy string, workers, queueSize int, key KeyFunc) (*Dispatcher, error) {
+	if !ValidStrategy(strategy) || strategy == StrategyShared {
+		return nil, fmt.Errorf("unsupported dispatch strategy %q", strategy)
+	}
+	if workers <= 0 {
+		return nil, fmt.Errorf("workers must be greater than 0")
+	}
+	if queueSize <= 0 {
+		queueSize = 1
+	}
+	d := &Dispatcher{
+		strategy: strategy,
+		key:      key,
+		queues:   make([]chan watcher.FileInfo, workers),
+		busy:     make([]int, workers),
+	}
+	for i := range d.queues {
+		d.queues[i] = make(chan watcher.FileInfo, queueSize)
+	}
+	return d, nil
+}
+
+// Queue возвращает очередь воркера (индекс с 0).
+func (d *Dispatcher) Queue(worker int) <-chan watcher.FileInfo {
+	return d.queues[worker]
+}
+
+// Run читает входную очередь до её закрытия и раздаёт файлы воркерам.
+// Если очередь выбранного воркера заполнена, Run ждёт её освобождения
+// (порядок файлов одного ключа сохраняется).
+func (d *Dispatcher) Run(in <-chan watcher.FileInfo) {
+	for fileInfo := range in {
+		worker := d.pick(fileInfo)
+		d.queues[worker] <- fileInfo
+	}
+	for _, q := range d.queues {
+		close(q)
+	}
+	slog.Info("Input queue closed, dispatcher stopped", "component", "dispatch")
+}
+
+// Started и Finished отмечают начало и конец обработки файла воркером
+// (учитываются стратегией least_busy).
+func (d *Dispatcher) Started(worker int) {
+	d.mu.Lock()
+	d.busy[worker]++
+	d.mu.Unlock()
+}
+
+func (d *Dispatcher) Finished(worker int) {
+	d.mu.Lock()
+	if d.busy[worker] > 0 {
+		d.busy[worker]--
+	}
+	d.mu.Unlock()
+}
+
+// Load возвращает число файлов в очереди и в обработке по воркерам.
+func (d *Dispatcher) Load() []int {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	load := make([]int, len(d.queues))
+	for i, q := range d.queues {
+		load[i] = len(q) + d.busy[i]
+	}
+	return load
+}
+
+// pick выбирает воркера для файла
+func (d *Dispatcher) pick(fileInfo watcher.FileInfo) int {
+	switch d.strategy {
+	case StrategyHash:
+		return Partition(d.key(fileInfo), len(d.queues))
+	case StrategyLeastBusy:
+		load := d.Load()
+		best := 0
+		for i := range load {
+			if load[i] < load[best] {
+				best = i
+			}
+		}
+		return best
+	default:
+		d.mu.Lock()
+		defer d.mu.Unlock()
+		worker := d.next
+		d.next = (d.next + 1) % len(d.queues)
+		return worker
+	}
+}
+
+// Partition - номер воркера для ключа (FNV-1a по модулю числа воркеров)
+func Partition(key string, workers int) int {
+	h := fnv.New32a()
+	h.Write([]byte(key))
+	return int(h.Sum32() % uint32(workers))
+}
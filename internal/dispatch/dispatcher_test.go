@@ -0,0 +1,72 @@
+package dispatch
+
+import (
+	"TSVProcessingService/internal/watcher"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func dispatchAll(t *testing.T, d *Dispatcher, files []watcher.FileInfo) [][]string {
+	in := make(chan watcher.FileInfo, len(files))
+	for _, f := range files {
+		in <- f
+	}
+	close(in)
+	d.Run(in)
+
+	assigned := make([][]string, len(d.queues))
+	for i := range d.queues {
+		for f := range d.Queue(i) {
+			assigned[i] = append(assigned[i], f.Name)
+		}
+	}
+	return assigned
+}
+
+func TestNewDispatcher_Validation(t *testing.T) {
+	_, err := NewDispatcher(StrategyShared, 2, 10, nil)
+	assert.Error(t, err)
+	_, err = NewDispatcher("random", 2, 10, nil)
+	assert.Error(t, err)
+	_, err = NewDispatcher(StrategyHash, 0, 10, nil)
+	assert.Error(t, err)
+}
+
+func TestDispatcher_RoundRobin(t *testing.T) {
+	d, err := NewDispatcher(StrategyRoundRobin, 2, 10, nil)
+	require.NoError(t, err)
+
+	assigned := dispatchAll(t, d, []watcher.FileInfo{{Name: "a"}, {Name: "b"}, {Name: "c"}})
+	assert.Equal(t, [][]string{{"a", "c"}, {"b"}}, assigned)
+}
+
+func TestDispatcher_HashKeepsKeyOnOneWorker(t *testing.T) {
+	key := func(f watcher.FileInfo) string { return f.Source }
+	d, err := NewDispatcher(StrategyHash, 3, 10, key)
+	require.NoError(t, err)
+
+	files := []watcher.FileInfo{
+		{Name: "a1", Source: "tenant:a"}, {Name: "b1", Source: "tenant:b"},
+		{Name: "a2", Source: "tenant:a"}, {Name: "b2", Source: "tenant:b"},
+	}
+	assigned := dispatchAll(t, d, files)
+
+	assert.Contains(t, assigned[Partition("tenant:a", 3)], "a1")
+	assert.Contains(t, assigned[Partition("tenant:a", 3)], "a2")
+	assert.Contains(t, assigned[Partition("tenant:b", 3)], "b1")
+	assert.Contains(t, assigned[Partition("tenant:b", 3)], "b2")
+}
+
+func TestDispatcher_LeastBusy(t *testing.T) {
+	d, err := NewDispatcher(StrategyLeastBusy, 2, 10, nil)
+	require.NoError(t, err)
+	d.Started(0)
+
+	assigned := dispatchAll(t, d, []watcher.FileInfo{{Name: "a"}, {Name: "b"}, {Name: "c"}})
+	assert.Equal(t, [][]string{{"b"}, {"a", "c"}}, assigned)
+
+	d.Finished(0)
+	assert.Equal(t, []int{0, 0}, d.Load())
+}
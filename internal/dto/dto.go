@@ -0,0 +1,538 @@
+// internal/dto/dto.go
+package dto
+
+import (
+	"TSVProcessingService/db/sqlc"
+	"database/sql"
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Модели ответов API. В отличие от моделей sqlc, nullable поля
+// сериализуются как значение либо null, а не как {"String": ..., "Valid": ...}.
+
+// DeviceData - запись данных устройства
+type DeviceData struct {
+	ID         int64      `json:"id"`
+	FileID     int64      `json:"file_id"`
+	UnitGuid   uuid.UUID  `json:"unit_guid"`
+	Mqtt       *string    `json:"mqtt"`
+	Invid      *string    `json:"invid"`
+	MsgID      *string    `json:"msg_id"`
+	Text       *string    `json:"text"`
+	Context    *string    `json:"context"`
+	Class      *string    `json:"class"`
+	Level      *int32     `json:"level"`
+	Area       *string    `json:"area"`
+	Addr       *string    `json:"addr"`
+	Block      *string    `json:"block"`
+	Type       *string    `json:"type"`
+	Bit        *int32     `json:"bit"`
+	InvertBit  *bool      `json:"invert_bit"`
+	LineNumber int32      `json:"line_number"`
+	CreatedAt  *time.Time `json:"created_at"`
+	Version    int32      `json:"version"`
+	Archived   bool       `json:"archived,omitempty"` // запись прочитана из архива Parquet
+}
+
+// File - обработанный файл
+type File struct {
+	ID            int64      `json:"id"`
+	Filename      string     `json:"filename"`
+	FileHash      string     `json:"file_hash"`
+	Status        *string    `json:"status"`
+	RowsProcessed *int32     `json:"rows_processed"`
+	RowsFailed    *int32     `json:"rows_failed"`
+	ErrorMessage  *string    `json:"error_message"`
+	CreatedAt     *time.Time `json:"created_at"`
+	UpdatedAt     *time.Time `json:"updated_at"`
+	Source        string     `json:"source"`
+	FileMtime     *time.Time `json:"file_mtime"`
+	ArrivedAt     *time.Time `json:"arrived_at"`
+	CompletedAt   *time.Time `json:"completed_at"`
+
+	// Политика конфликтов при вставке строк и её итог
+	ConflictPolicy  *string `json:"conflict_policy"`
+	RowsSkipped     *int32  `json:"rows_skipped"`
+	RowsOverwritten *int32  `json:"rows_overwritten"`
+	RowsVersioned   *int32  `json:"rows_versioned"`
+
+	// Файл, заменивший этот (данные исключены из выборок по умолчанию)
+	SupersededBy *int64     `json:"superseded_by"`
+	SupersededAt *time.Time `json:"superseded_at"`
+
+	// Трасса обработки (W3C Trace Context) для поиска в Jaeger/Tempo
+	TraceID *string `json:"trace_id"`
+	SpanID  *string `json:"span_id"`
+
+	// Доля обработанных строк данных при загрузке выборкой (null - полная загрузка)
+	SampleRate *float64 `json:"sample_rate"`
+
+	// Исправление ошибочных строк повторной отправкой <name>.rejected.tsv:
+	// у исправления - исходный файл, у исходного - число исправленных строк
+	CorrectsFileID *int64  `json:"corrects_file_id"`
+	RowsCorrected  *int32  `json:"rows_corrected"`
+	CorrectionNote *string `json:"correction_note"`
+
+	// Дубликаты строк (null - файл обработан без worker.duplicate_report)
+	RowsDuplicateExisting *int32 `json:"rows_duplicate_existing"`
+	RowsDuplicateInFile   *int32 `json:"rows_duplicate_in_file"`
+
+	// Отклонения от истории источника/устройства (anomaly.enabled)
+	Anomalies json.RawMessage `json:"anomalies"`
+
+	// Диапазон n delta-файла и число пропущенных номеров (null - не delta)
+	SeqFirst *int64 `json:"seq_first"`
+	SeqLast  *int64 `json:"seq_last"`
+	SeqGaps  *int32 `json:"seq_gaps"`
+
+	// Поля имени файла по filename_metadata.pattern ({} - не разобрано)
+	Metadata json.RawMessage `json:"metadata"`
+}
+
+// FileDuplicate - строка файла, повторяющая уже загруженную строку
+// (kind = existing) или предыдущую строку того же файла (kind = in_file)
+type FileDuplicate struct {
+	ID                int64     `json:"id"`
+	FileID            int64     `json:"file_id"`
+	LineNumber        int32     `json:"line_number"`
+	Kind              string    `json:"kind"`
+	UnitGuid          uuid.UUID `json:"unit_guid"`
+	MsgID             *string   `json:"msg_id"`
+	DuplicateOfFileID int64     `json:"duplicate_of_file_id"`
+	DuplicateOfLine   int32     `json:"duplicate_of_line"`
+}
+
+// ProcessingError - ошибка разбора строки файла
+type ProcessingError struct {
+	ID           int64      `json:"id"`
+	FileID       int64      `json:"file_id"`
+	LineNumber   *int32     `json:"line_number"`
+	RawLine      *string    `json:"raw_line"`
+	ErrorMessage string     `json:"error_message"`
+	FieldName    *string    `json:"field_name"`
+	CreatedAt    *time.Time `json:"created_at"`
+	UnitGuid     *uuid.UUID `json:"unit_guid"`
+
+	// Поля, которые удалось разобрать в отклонённой строке
+	Partial json.RawMessage `json:"partial,omitempty"`
+}
+
+// Report - сгенерированный отчёт
+type Report struct {
+	ID          int64      `json:"id"`
+	UnitGuid    uuid.UUID  `json:"unit_guid"`
+	ReportType  *string    `json:"report_type"`
+	FilePath    string     `json:"file_path"`
+	GeneratedAt *time.Time `json:"generated_at"`
+	Checksum    *string    `json:"checksum"`     // SHA256 файла отчёта
+	ReportGroup *uuid.UUID `json:"report_group"` // общий для частей одного отчёта
+	Part        *int32     `json:"part"`         // номер части, с 1
+	TraceID     *string    `json:"trace_id"`     // трасса генерации (W3C Trace Context)
+	SpanID      *string    `json:"span_id"`
+}
+
+// ReportSubscription - подписка внешней системы на новые отчёты
+// (секрет подписи возвращается только при создании)
+type ReportSubscription struct {
+	ID          int64      `json:"id"`
+	UnitGuid    *uuid.UUID `json:"unit_guid"` // null - все устройства
+	CallbackURL string     `json:"callback_url"`
+	Active      bool       `json:"active"`
+	CreatedAt   *time.Time `json:"created_at"`
+}
+
+// ReportDelivery - доставка уведомления о новом отчёте подписчику
+type ReportDelivery struct {
+	ID             int64      `json:"id"`
+	SubscriptionID int64      `json:"subscription_id"`
+	ReportID       int64      `json:"report_id"`
+	Status         string     `json:"status"` // pending / delivered / failed
+	Attempts       int32      `json:"attempts"`
+	LastError      *string    `json:"last_error"`
+	ResponseStatus *int32     `json:"response_status"` // HTTP-статус последнего ответа получателя
+	NextAttemptAt  *time.Time `json:"next_attempt_at"` // только для pending
+	DeliveredAt    *time.Time `json:"delivered_at"`
+	CreatedAt      *time.Time `json:"created_at"`
+}
+
+// ReexportRequest - запрос повторной выгрузки пропущенных номеров n устройства
+type ReexportRequest struct {
+	ID              int64      `json:"id"`
+	UnitGuid        uuid.UUID  `json:"unit_guid"`
+	SeqFrom         int64      `json:"seq_from"`
+	SeqTo           int64      `json:"seq_to"`
+	AfterFile       string     `json:"after_file"`
+	BeforeFile      string     `json:"before_file"`
+	Status          string     `json:"status"` // pending / requested / fulfilled / failed / expired
+	Attempts        int32      `json:"attempts"`
+	LastError       *string    `json:"last_error"`
+	ResponseStatus  *int32     `json:"response_status"` // HTTP-статус последнего ответа webhook
+	NextAttemptAt   *time.Time `json:"next_attempt_at"` // только для pending
+	RequestedAt     *time.Time `json:"requested_at"`
+	FulfilledAt     *time.Time `json:"fulfilled_at"`
+	FulfilledFileID *int64     `json:"fulfilled_file_id"` // файл, закрывший пропуск
+	CreatedAt       time.Time  `json:"created_at"`
+}
+
+// Job - задание журнала заданий (обработка файла, отчёт, очистка, архивация, backfill)
+type Job struct {
+	ID          int64      `json:"id"`
+	Kind        string     `json:"kind"`
+	Subject     string     `json:"subject"`
+	State       string     `json:"state"`
+	Priority    int32      `json:"priority"`
+	Owner       string     `json:"owner"`
+	Attempts    int32      `json:"attempts"`
+	MaxAttempts int32      `json:"max_attempts"`
+	LastError   *string    `json:"last_error"`
+	CreatedAt   time.Time  `json:"created_at"`
+	StartedAt   *time.Time `json:"started_at"` // начало последней попытки
+	FinishedAt  *time.Time `json:"finished_at"`
+}
+
+// UnitDailySummary - суточная сводка устройства (UTC)
+type UnitDailySummary struct {
+	Day      string `json:"day"` // YYYY-MM-DD
+	Records  int32  `json:"records"`
+	Alarms   int32  `json:"alarms"`
+	MaxLevel *int32 `json:"max_level"`
+}
+
+// ---------------------------------------------------------------------
+// Преобразование моделей sqlc
+// ---------------------------------------------------------------------
+
+// FromDeviceDatum преобразует запись данных устройства
+func FromDeviceDatum(d sqlc.DeviceDatum) DeviceData {
+	return DeviceData{
+		ID:         d.ID,
+		FileID:     d.FileID,
+		UnitGuid:   d.UnitGuid,
+		Mqtt:       nullString(d.Mqtt),
+		Invid:      nullString(d.Invid),
+		MsgID:      nullString(d.MsgID),
+		Text:       nullString(d.Text),
+		Context:    nullString(d.Context),
+		Class:      nullString(d.Class),
+		Level:      nullInt32(d.Level),
+		Area:       nullString(d.Area),
+		Addr:       nullString(d.Addr),
+		Block:      nullString(d.Block),
+		Type:       nullString(d.Type),
+		Bit:        nullInt32(d.Bit),
+		InvertBit:  nullBool(d.InvertBit),
+		LineNumber: d.LineNumber,
+		CreatedAt:  nullTime(d.CreatedAt),
+		Version:    d.Version,
+	}
+}
+
+// FromDeviceData преобразует список записей данных устройства
+func FromDeviceData(data []sqlc.DeviceDatum) []DeviceData {
+	result := make([]DeviceData, 0, len(data))
+	for _, d := range data {
+		result = append(result, FromDeviceDatum(d))
+	}
+	return result
+}
+
+// FromFile преобразует запись о файле
+func FromFile(f sqlc.File) File {
+	return File{
+		ID:            f.ID,
+		Filename:      f.Filename,
+		FileHash:      f.FileHash,
+		Status:        nullString(f.Status),
+		RowsProcessed: nullInt32(f.RowsProcessed),
+		RowsFailed:    nullInt32(f.RowsFailed),
+		ErrorMessage:  nullString(f.ErrorMessage),
+		CreatedAt:     nullTime(f.CreatedAt),
+		UpdatedAt:     nullTime(f.UpdatedAt),
+		Source:        f.Source,
+		FileMtime:     nullTime(f.FileMtime),
+		ArrivedAt:     nullTime(f.ArrivedAt),
+		CompletedAt:   nullTime(f.CompletedAt),
+
+		ConflictPolicy:  nullString(f.ConflictPolicy),
+		RowsSkipped:     nullInt32(f.RowsSkipped),
+		RowsOverwritten: nullInt32(f.RowsOverwritten),
+		RowsVersioned:   nullInt32(f.RowsVersioned),
+
+		SupersededBy: nullInt64(f.SupersededBy),
+		SupersededAt: nullTime(f.SupersededAt),
+
+		TraceID: nullString(f.TraceID),
+		SpanID:  nullString(f.SpanID),
+
+		SampleRate: nullFloat64(f.SampleRate),
+
+		CorrectsFileID: nullInt64(f.CorrectsFileID),
+		RowsCorrected:  nullInt32(f.RowsCorrected),
+		CorrectionNote: nullString(f.CorrectionNote),
+
+		RowsDuplicateExisting: nullInt32(f.RowsDuplicateExisting),
+		RowsDuplicateInFile:   nullInt32(f.RowsDuplicateInFile),
+
+		Anomalies: f.Anomalies,
+
+		SeqFirst: nullInt64(f.SeqFirst),
+		SeqLast:  nullInt64(f.SeqLast),
+		SeqGaps:  nullInt32(f.SeqGaps),
+
+		Metadata: f.Metadata,
+	}
+}
+
+// FromFiles преобразует список файлов
+func FromFiles(files []sqlc.File) []File {
+	result := make([]File, 0, len(files))
+	for _, f := range files {
+		result = append(result, FromFile(f))
+	}
+	return result
+}
+
+// FromProcessingError преобразует ошибку обработки
+func FromProcessingError(e sqlc.ProcessingError) ProcessingError {
+	return ProcessingError{
+		ID:           e.ID,
+		FileID:       e.FileID,
+		LineNumber:   nullInt32(e.LineNumber),
+		RawLine:      nullString(e.RawLine),
+		ErrorMessage: e.ErrorMessage,
+		FieldName:    nullString(e.FieldName),
+		CreatedAt:    nullTime(e.CreatedAt),
+		UnitGuid:     nullUUID(e.UnitGuid),
+		Partial:      partial(e.Partial),
+	}
+}
+
+// FromProcessingErrors преобразует список ошибок обработки
+func FromProcessingErrors(errs []sqlc.ProcessingError) []ProcessingError {
+	result := make([]ProcessingError, 0, len(errs))
+	for _, e := range errs {
+		result = append(result, FromProcessingError(e))
+	}
+	return result
+}
+
+// FromFileDuplicates преобразует список дубликатов строк файла
+func FromFileDuplicates(duplicates []sqlc.FileDuplicate) []FileDuplicate {
+	result := make([]FileDuplicate, 0, len(duplicates))
+	for _, d := range duplicates {
+		result = append(result, FileDuplicate{
+			ID:                d.ID,
+			FileID:            d.FileID,
+			LineNumber:        d.LineNumber,
+			Kind:              d.Kind,
+			UnitGuid:          d.UnitGuid,
+			MsgID:             nullString(d.MsgID),
+			DuplicateOfFileID: d.DuplicateOfFileID,
+			DuplicateOfLine:   d.DuplicateOfLine,
+		})
+	}
+	return result
+}
+
+// FromReport преобразует запись об отчёте
+func FromReport(r sqlc.Report) Report {
+	return Report{
+		ID:          r.ID,
+		UnitGuid:    r.UnitGuid,
+		ReportType:  nullString(r.ReportType),
+		FilePath:    r.FilePath,
+		GeneratedAt: nullTime(r.GeneratedAt),
+		Checksum:    nullString(r.Checksum),
+		ReportGroup: nullUUID(r.ReportGroup),
+		Part:        nullInt32(r.Part),
+		TraceID:     nullString(r.TraceID),
+		SpanID:      nullString(r.SpanID),
+	}
+}
+
+// FromReports преобразует список отчётов
+func FromReports(reports []sqlc.Report) []Report {
+	result := make([]Report, 0, len(reports))
+	for _, r := range reports {
+		result = append(result, FromReport(r))
+	}
+	return result
+}
+
+// FromReportSubscription преобразует подписку (без секрета)
+func FromReportSubscription(s sqlc.ReportSubscription) ReportSubscription {
+	return ReportSubscription{
+		ID:          s.ID,
+		UnitGuid:    nullUUID(s.UnitGuid),
+		CallbackURL: s.CallbackUrl,
+		Active:      s.Active,
+		CreatedAt:   nullTime(s.CreatedAt),
+	}
+}
+
+// FromReportSubscriptions преобразует список подписок
+func FromReportSubscriptions(subscriptions []sqlc.ReportSubscription) []ReportSubscription {
+	result := make([]ReportSubscription, 0, len(subscriptions))
+	for _, s := range subscriptions {
+		result = append(result, FromReportSubscription(s))
+	}
+	return result
+}
+
+// FromReportDeliveries преобразует доставки уведомлений
+func FromReportDeliveries(deliveries []sqlc.ReportDelivery) []ReportDelivery {
+	result := make([]ReportDelivery, 0, len(deliveries))
+	for _, d := range deliveries {
+		item := ReportDelivery{
+			ID:             d.ID,
+			SubscriptionID: d.SubscriptionID,
+			ReportID:       d.ReportID,
+			Status:         d.Status,
+			Attempts:       d.Attempts,
+			LastError:      nullString(d.LastError),
+			ResponseStatus: nullInt32(d.ResponseStatus),
+			DeliveredAt:    nullTime(d.DeliveredAt),
+			CreatedAt:      nullTime(d.CreatedAt),
+		}
+		if d.Status == "pending" {
+			next := d.NextAttemptAt
+			item.NextAttemptAt = &next
+		}
+		result = append(result, item)
+	}
+	return result
+}
+
+// FromReexportRequests преобразует запросы повторной выгрузки
+func FromReexportRequests(requests []sqlc.ReexportRequest) []ReexportRequest {
+	result := make([]ReexportRequest, 0, len(requests))
+	for _, r := range requests {
+		item := ReexportRequest{
+			ID:              r.ID,
+			UnitGuid:        r.UnitGuid,
+			SeqFrom:         r.SeqFrom,
+			SeqTo:           r.SeqTo,
+			AfterFile:       r.AfterFile,
+			BeforeFile:      r.BeforeFile,
+			Status:          r.Status,
+			Attempts:        r.Attempts,
+			LastError:       nullString(r.LastError),
+			ResponseStatus:  nullInt32(r.ResponseStatus),
+			RequestedAt:     nullTime(r.RequestedAt),
+			FulfilledAt:     nullTime(r.FulfilledAt),
+			FulfilledFileID: nullInt64(r.FulfilledFileID),
+			CreatedAt:       r.CreatedAt,
+		}
+		if r.Status == "pending" {
+			next := r.NextAttemptAt
+			item.NextAttemptAt = &next
+		}
+		result = append(result, item)
+	}
+	return result
+}
+
+// FromJob преобразует запись журнала заданий
+func FromJob(j sqlc.Job) Job {
+	return Job{
+		ID:          j.ID,
+		Kind:        j.Kind,
+		Subject:     j.Subject,
+		State:       j.State,
+		Priority:    j.Priority,
+		Owner:       j.Owner,
+		Attempts:    j.Attempts,
+		MaxAttempts: j.MaxAttempts,
+		LastError:   nullString(j.LastError),
+		CreatedAt:   j.CreatedAt,
+		StartedAt:   nullTime(j.StartedAt),
+		FinishedAt:  nullTime(j.FinishedAt),
+	}
+}
+
+// FromJobs преобразует список заданий
+func FromJobs(jobs []sqlc.Job) []Job {
+	result := make([]Job, 0, len(jobs))
+	for _, j := range jobs {
+		result = append(result, FromJob(j))
+	}
+	return result
+}
+
+// FromUnitDailySummaries преобразует суточные сводки устройства
+func FromUnitDailySummaries(days []sqlc.UnitDailySummary) []UnitDailySummary {
+	result := make([]UnitDailySummary, 0, len(days))
+	for _, d := range days {
+		result = append(result, UnitDailySummary{
+			Day:      d.Day.UTC().Format("2006-01-02"),
+			Records:  d.Records,
+			Alarms:   d.Alarms,
+			MaxLevel: nullInt32(d.MaxLevel),
+		})
+	}
+	return result
+}
+
+// ---------------------------------------------------------------------
+// Nullable типы database/sql
+// ---------------------------------------------------------------------
+
+func nullString(v sql.NullString) *string {
+	if !v.Valid {
+		return nil
+	}
+	return &v.String
+}
+
+func nullInt32(v sql.NullInt32) *int32 {
+	if !v.Valid {
+		return nil
+	}
+	return &v.Int32
+}
+
+func nullInt64(v sql.NullInt64) *int64 {
+	if !v.Valid {
+		return nil
+	}
+	return &v.Int64
+}
+
+func nullFloat64(v sql.NullFloat64) *float64 {
+	if !v.Valid {
+		return nil
+	}
+	return &v.Float64
+}
+
+func nullBool(v sql.NullBool) *bool {
+	if !v.Valid {
+		return nil
+	}
+	return &v.Bool
+}
+
+func nullUUID(v uuid.NullUUID) *uuid.UUID {
+	if !v.Valid {
+		return nil
+	}
+	return &v.UUID
+}
+
+// partial - nil для ошибок без разобранных полей ({})
+func partial(v json.RawMessage) json.RawMessage {
+	if len(v) == 0 || string(v) == "{}" {
+		return nil
+	}
+	return v
+}
+
+func nullTime(v sql.NullTime) *time.Time {
+	if !v.Valid {
+		return nil
+	}
+	return &v.Time
+}
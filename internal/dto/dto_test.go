@@ -0,0 +1,122 @@
+package dto
+
+import (
+	"TSVProcessingService/db/sqlc"
+	"database/sql"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFromFile_NullableFields(t *testing.T) {
+	createdAt := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	file := sqlc.File{
+		ID:            1,
+		Filename:      "test.tsv",
+		FileHash:      "abc",
+		Status:        sql.NullString{String: "completed", Valid: true},
+		RowsProcessed: sql.NullInt32{Int32: 10, Valid: true},
+		CreatedAt:     sql.NullTime{Time: createdAt, Valid: true},
+	}
+
+	data, err := json.Marshal(FromFile(file))
+	require.NoError(t, err)
+
+	var decoded map[string]interface{}
+	require.NoError(t, json.Unmarshal(data, &decoded))
+	assert.Equal(t, "completed", decoded["status"])
+	assert.Equal(t, float64(10), decoded["rows_processed"])
+	assert.Nil(t, decoded["rows_failed"])
+	assert.Nil(t, decoded["error_message"])
+	assert.Equal(t, "2024-01-01T12:00:00Z", decoded["created_at"])
+	assert.Contains(t, decoded, "updated_at")
+	assert.Nil(t, decoded["updated_at"])
+	assert.Contains(t, decoded, "trace_id")
+	assert.Nil(t, decoded["trace_id"])
+}
+
+func TestFromDeviceData(t *testing.T) {
+	unitGuid := uuid.New()
+	data := FromDeviceData([]sqlc.DeviceDatum{{
+		ID:        1,
+		UnitGuid:  unitGuid,
+		Text:      sql.NullString{String: "alarm", Valid: true},
+		InvertBit: sql.NullBool{Bool: false, Valid: true},
+	}})
+
+	require.Len(t, data, 1)
+	assert.Equal(t, unitGuid, data[0].UnitGuid)
+	require.NotNil(t, data[0].Text)
+	assert.Equal(t, "alarm", *data[0].Text)
+	require.NotNil(t, data[0].InvertBit)
+	assert.False(t, *data[0].InvertBit)
+	assert.Nil(t, data[0].Level)
+
+	// Пустой список сериализуется как [], а не null
+	encoded, err := json.Marshal(FromDeviceData(nil))
+	require.NoError(t, err)
+	assert.Equal(t, "[]", string(encoded))
+}
+
+func TestParseFields(t *testing.T) {
+	fields, err := ParseFields("text, level,text", DeviceData{})
+	require.NoError(t, err)
+	assert.Equal(t, Fields{"text", "level"}, fields)
+
+	_, err = ParseFields("text,password", DeviceData{})
+	assert.Error(t, err)
+
+	fields, err = ParseFields("", DeviceData{})
+	require.NoError(t, err)
+	assert.Empty(t, fields)
+}
+
+func TestProject(t *testing.T) {
+	data := FromDeviceData([]sqlc.DeviceDatum{{
+		ID:    7,
+		Text:  sql.NullString{String: "alarm", Valid: true},
+		Class: sql.NullString{String: "critical", Valid: true},
+	}})
+
+	encoded, err := json.Marshal(Project(data, Fields{"id", "text", "level"}))
+	require.NoError(t, err)
+	assert.JSONEq(t, `[{"id": 7, "text": "alarm", "level": null}]`, string(encoded))
+
+	// Без выбора полей ответ не меняется
+	assert.Equal(t, data, Project(data, nil))
+}
+
+func TestMask(t *testing.T) {
+	data := FromDeviceData([]sqlc.DeviceDatum{{
+		ID:    7,
+		Addr:  sql.NullString{String: "10.0.0.5", Valid: true},
+		Text:  sql.NullString{String: "alarm", Valid: true},
+		Invid: sql.NullString{},
+	}})
+	Mask(data, Fields{"addr", "invid"})
+	require.NotNil(t, data[0].Addr)
+	assert.Equal(t, MaskedValue, *data[0].Addr)
+	assert.Nil(t, data[0].Invid)
+	assert.Equal(t, "alarm", *data[0].Text)
+
+	// Ошибки разбора: разобранные поля и исходная строка целиком
+	type wrapped struct {
+		ProcessingError
+		Filename string `json:"filename"`
+	}
+	errs := []wrapped{{
+		ProcessingError: FromProcessingError(sqlc.ProcessingError{
+			RawLine: sql.NullString{String: "guid\t10.0.0.5\tbad", Valid: true},
+			Partial: json.RawMessage(`{"addr":"10.0.0.5","text":"alarm"}`),
+		}),
+		Filename: "a.tsv",
+	}}
+	Mask(errs, Fields{"addr"})
+	assert.Equal(t, MaskedValue, *errs[0].RawLine)
+	assert.JSONEq(t, `{"addr":"***","text":"alarm"}`, string(errs[0].Partial))
+	assert.Equal(t, "a.tsv", errs[0].Filename)
+}
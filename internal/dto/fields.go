@@ -0,0 +1,83 @@
+// internal/dto/fields.go
+package dto
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// Fields - выбранные клиентом поля ответа (параметр fields=a,b,c).
+// Пустой набор означает полный ответ.
+type Fields []string
+
+// ParseFields разбирает параметр fields и проверяет, что все поля есть
+// в JSON-представлении модели model.
+func ParseFields(param string, model interface{}) (Fields, error) {
+	param = strings.TrimSpace(param)
+	if param == "" {
+		return nil, nil
+	}
+
+	known := jsonFieldIndex(reflect.TypeOf(model))
+	var fields Fields
+	seen := make(map[string]bool)
+	for _, name := range strings.Split(param, ",") {
+		name = strings.TrimSpace(name)
+		if name == "" || seen[name] {
+			continue
+		}
+		if _, ok := known[name]; !ok {
+			return nil, fmt.Errorf("unknown field %q", name)
+		}
+		seen[name] = true
+		fields = append(fields, name)
+	}
+	return fields, nil
+}
+
+// Project оставляет в элементах среза items только выбранные поля.
+// Если поля не выбраны, items возвращается без изменений.
+func Project(items interface{}, fields Fields) interface{} {
+	if len(fields) == 0 {
+		return items
+	}
+
+	v := reflect.ValueOf(items)
+	if v.Kind() != reflect.Slice {
+		return items
+	}
+	index := jsonFieldIndex(v.Type().Elem())
+
+	result := make([]map[string]interface{}, 0, v.Len())
+	for i := 0; i < v.Len(); i++ {
+		item := v.Index(i)
+		projected := make(map[string]interface{}, len(fields))
+		for _, name := range fields {
+			if fieldIndex, ok := index[name]; ok {
+				projected[name] = item.Field(fieldIndex).Interface()
+			}
+		}
+		result = append(result, projected)
+	}
+	return result
+}
+
+// jsonFieldIndex - соответствие JSON-имён полей структуры их индексам
+func jsonFieldIndex(t reflect.Type) map[string]int {
+	index := make(map[string]int)
+	if t.Kind() != reflect.Struct {
+		return index
+	}
+	for i := 0; i < t.NumField(); i++ {
+		if name := jsonName(t.Field(i)); name != "" && name != "-" {
+			index[name] = i
+		}
+	}
+	return index
+}
+
+// jsonName - JSON-имя поля структуры по тегу json
+func jsonName(f reflect.StructField) string {
+	return strings.Split(f.Tag.Get("json"), ",")[0]
+}
@@ -0,0 +1,112 @@
+// internal/dto/mask.go
+package dto
+
+import (
+	"encoding/json"
+	"reflect"
+)
+
+// Роли клиентов API
+const (
+	RoleViewer   = "viewer"   // только чтение, поля из access.masked_fields скрыты
+	RoleOperator = "operator" // полные значения
+	RoleAdmin    = "admin"    // полные значения
+)
+
+// Roles - допустимые роли
+var Roles = []string{RoleViewer, RoleOperator, RoleAdmin}
+
+// MaskedValue - значение скрытого поля
+const MaskedValue = "***"
+
+// Mask скрывает значения полей masked в элементах среза items (элементы
+// изменяются на месте, вложенные встроенные структуры тоже): строковые
+// поля с этими JSON-именами заменяются на MaskedValue (null остаётся
+// null), те же ключи - в разобранных полях partial. raw_line содержит
+// все поля исходной строки и скрывается целиком.
+func Mask(items interface{}, masked Fields) {
+	if len(masked) == 0 {
+		return
+	}
+	v := reflect.ValueOf(items)
+	if v.Kind() != reflect.Slice {
+		return
+	}
+	set := make(map[string]bool, len(masked))
+	for _, name := range masked {
+		set[name] = true
+	}
+	for i := 0; i < v.Len(); i++ {
+		maskStruct(v.Index(i), set)
+	}
+}
+
+// maskStruct скрывает поля одной структуры
+func maskStruct(v reflect.Value, masked map[string]bool) {
+	if v.Kind() == reflect.Pointer {
+		if v.IsNil() {
+			return
+		}
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.Struct || !v.CanSet() {
+		return
+	}
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := v.Field(i)
+		sf := t.Field(i)
+		if sf.Anonymous {
+			maskStruct(field, masked)
+			continue
+		}
+		switch name := jsonName(sf); {
+		case name == "raw_line":
+			maskString(field)
+		case name == "partial":
+			if raw, ok := field.Interface().(json.RawMessage); ok {
+				field.Set(reflect.ValueOf(maskPartial(raw, masked)))
+			}
+		case masked[name]:
+			maskString(field)
+		}
+	}
+}
+
+// maskString заменяет значение поля string или *string (кроме null)
+func maskString(field reflect.Value) {
+	switch {
+	case field.Kind() == reflect.String:
+		if field.Len() > 0 {
+			field.SetString(MaskedValue)
+		}
+	case field.Kind() == reflect.Pointer && field.Type().Elem().Kind() == reflect.String:
+		if !field.IsNil() {
+			value := MaskedValue
+			field.Set(reflect.ValueOf(&value))
+		}
+	}
+}
+
+// maskPartial скрывает ключи masked в JSON-объекте разобранных полей
+func maskPartial(raw json.RawMessage, masked map[string]bool) json.RawMessage {
+	var partial map[string]interface{}
+	if len(raw) == 0 || json.Unmarshal(raw, &partial) != nil {
+		return raw
+	}
+	changed := false
+	for key, value := range partial {
+		if masked[key] && value != nil {
+			partial[key] = MaskedValue
+			changed = true
+		}
+	}
+	if !changed {
+		return raw
+	}
+	data, err := json.Marshal(partial)
+	if err != nil {
+		return raw
+	}
+	return data
+}
@@ -0,0 +1,193 @@
+// internal/features/features.go
+package features
+
+import (
+	"TSVProcessingService/db/sqlc"
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Flag - рискованная возможность, которую можно выключить без выпуска
+type Flag string
+
+const (
+	LenientParsing Flag = "lenient_parsing" // lenient из sidecar/API: неверные поля строки -> NULL
+	ContentDedup   Flag = "content_dedup"   // пропуск файлов с тем же содержимым под другим именем
+	DeltaMode      Flag = "delta_mode"      // проверка непрерывности n для worker.delta_sources
+)
+
+// All - известные флаги
+var All = []Flag{LenientParsing, ContentDedup, DeltaMode}
+
+// defaults - значения без конфигурации и переопределений (поведение до
+// появления флагов)
+var defaults = map[Flag]bool{
+	LenientParsing: true,
+	ContentDedup:   true,
+	DeltaMode:      true,
+}
+
+// Источники значения флага (State.Source)
+const (
+	SourceDefault        = "default"
+	SourceConfig         = "config"          // features.flags
+	SourceTenantConfig   = "tenant_config"   // features.tenants
+	SourceOverride       = "override"        // feature_flags, tenant ''
+	SourceTenantOverride = "tenant_override" // feature_flags для tenant
+)
+
+// Parse проверяет имя флага
+func Parse(name string) (Flag, error) {
+	for _, flag := range All {
+		if string(flag) == name {
+			return flag, nil
+		}
+	}
+	return "", fmt.Errorf("unknown feature flag %q", name)
+}
+
+// TenantOf - tenant источника файла ("tenant:<name>"), "" - источник без tenant
+func TenantOf(source string) string {
+	tenant, _ := strings.CutPrefix(source, "tenant:")
+	if tenant == source {
+		return ""
+	}
+	return tenant
+}
+
+// Store - чтение переопределений из БД (sqlc.Queries)
+type Store interface {
+	ListFeatureFlags(ctx context.Context) ([]sqlc.FeatureFlag, error)
+}
+
+// State - значение флага для tenant и его источник
+type State struct {
+	Name    Flag   `json:"name"`
+	Enabled bool   `json:"enabled"`
+	Source  string `json:"source"`
+}
+
+// Flags - значения флагов. Приоритет (от высшего): переопределение в БД
+// для tenant, переопределение в БД для всех, features.tenants,
+// features.flags, значение по умолчанию. Переопределение для всех
+// перекрывает конфигурацию tenant, чтобы флаг можно было выключить
+// везде одной записью. nil - только значения по умолчанию.
+type Flags struct {
+	config  map[Flag]bool
+	tenants map[string]map[Flag]bool
+
+	mu        sync.RWMutex
+	overrides map[string]map[Flag]bool // tenant ("" - все) -> флаг
+}
+
+// New создаёт флаги по конфигурации (имена проверены при загрузке)
+func New(flags map[string]bool, tenants map[string]map[string]bool) *Flags {
+	f := &Flags{
+		config:    toFlags(flags),
+		tenants:   make(map[string]map[Flag]bool, len(tenants)),
+		overrides: make(map[string]map[Flag]bool),
+	}
+	for tenant, flags := range tenants {
+		f.tenants[strings.ToLower(tenant)] = toFlags(flags)
+	}
+	return f
+}
+
+func toFlags(m map[string]bool) map[Flag]bool {
+	flags := make(map[Flag]bool, len(m))
+	for name, enabled := range m {
+		flags[Flag(name)] = enabled
+	}
+	return flags
+}
+
+// Enabled - включён ли флаг для tenant ("" - без tenant)
+func (f *Flags) Enabled(flag Flag, tenant string) bool {
+	return f.State(flag, tenant).Enabled
+}
+
+// State - значение флага для tenant с источником значения
+func (f *Flags) State(flag Flag, tenant string) State {
+	state := State{Name: flag, Enabled: defaults[flag], Source: SourceDefault}
+	if f == nil {
+		return state
+	}
+	tenant = strings.ToLower(tenant)
+
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	if tenant != "" {
+		if enabled, ok := f.overrides[tenant][flag]; ok {
+			return State{Name: flag, Enabled: enabled, Source: SourceTenantOverride}
+		}
+	}
+	if enabled, ok := f.overrides[""][flag]; ok {
+		return State{Name: flag, Enabled: enabled, Source: SourceOverride}
+	}
+	if enabled, ok := f.tenants[tenant][flag]; ok && tenant != "" {
+		return State{Name: flag, Enabled: enabled, Source: SourceTenantConfig}
+	}
+	if enabled, ok := f.config[flag]; ok {
+		return State{Name: flag, Enabled: enabled, Source: SourceConfig}
+	}
+	return state
+}
+
+// States - значения всех флагов для tenant
+func (f *Flags) States(tenant string) []State {
+	states := make([]State, 0, len(All))
+	for _, flag := range All {
+		states = append(states, f.State(flag, tenant))
+	}
+	return states
+}
+
+// Refresh перечитывает переопределения из БД. Записи с неизвестными
+// именами (флаг удалён в новой версии) пропускаются.
+func (f *Flags) Refresh(ctx context.Context, store Store) error {
+	rows, err := store.ListFeatureFlags(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to load feature flags: %w", err)
+	}
+	overrides := make(map[string]map[Flag]bool)
+	for _, row := range rows {
+		flag, err := Parse(row.Name)
+		if err != nil {
+			continue
+		}
+		tenant := strings.ToLower(row.Tenant)
+		if overrides[tenant] == nil {
+			overrides[tenant] = make(map[Flag]bool)
+		}
+		overrides[tenant][flag] = row.Enabled
+	}
+
+	f.mu.Lock()
+	f.overrides = overrides
+	f.mu.Unlock()
+	return nil
+}
+
+// Tenants - tenant с настройками флагов в конфигурации или БД
+func (f *Flags) Tenants() []string {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	seen := make(map[string]bool)
+	for tenant := range f.tenants {
+		seen[tenant] = true
+	}
+	for tenant := range f.overrides {
+		if tenant != "" {
+			seen[tenant] = true
+		}
+	}
+	tenants := make([]string, 0, len(seen))
+	for tenant := range seen {
+		tenants = append(tenants, tenant)
+	}
+	sort.Strings(tenants)
+	return tenants
+}
@@ -0,0 +1,61 @@
+package features
+
+import (
+	"TSVProcessingService/db/sqlc"
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeStore []sqlc.FeatureFlag
+
+func (s fakeStore) ListFeatureFlags(ctx context.Context) ([]sqlc.FeatureFlag, error) {
+	return s, nil
+}
+
+func TestFlags_Precedence(t *testing.T) {
+	f := New(
+		map[string]bool{"delta_mode": false},
+		map[string]map[string]bool{"ACME": {"delta_mode": true, "content_dedup": false}},
+	)
+	assert.Equal(t, State{Name: DeltaMode, Enabled: false, Source: SourceConfig}, f.State(DeltaMode, ""))
+	assert.Equal(t, State{Name: DeltaMode, Enabled: true, Source: SourceTenantConfig}, f.State(DeltaMode, "acme"))
+	assert.Equal(t, State{Name: LenientParsing, Enabled: true, Source: SourceDefault}, f.State(LenientParsing, "acme"))
+
+	// Переопределение для всех перекрывает конфигурацию tenant,
+	// переопределение для tenant - всё остальное
+	require.NoError(t, f.Refresh(context.Background(), fakeStore{
+		{Name: "content_dedup", Tenant: "", Enabled: true},
+		{Name: "delta_mode", Tenant: "", Enabled: false},
+		{Name: "delta_mode", Tenant: "beta", Enabled: true},
+		{Name: "removed_flag", Tenant: "", Enabled: true},
+	}))
+	assert.Equal(t, State{Name: ContentDedup, Enabled: true, Source: SourceOverride}, f.State(ContentDedup, "acme"))
+	assert.Equal(t, State{Name: DeltaMode, Enabled: false, Source: SourceOverride}, f.State(DeltaMode, "acme"))
+	assert.Equal(t, State{Name: DeltaMode, Enabled: true, Source: SourceTenantOverride}, f.State(DeltaMode, "Beta"))
+	assert.Equal(t, []string{"acme", "beta"}, f.Tenants())
+
+	// Удалённое переопределение больше не действует
+	require.NoError(t, f.Refresh(context.Background(), fakeStore{}))
+	assert.Equal(t, SourceTenantConfig, f.State(DeltaMode, "acme").Source)
+}
+
+func TestFlags_Nil(t *testing.T) {
+	var f *Flags
+	for _, flag := range All {
+		assert.True(t, f.Enabled(flag, "acme"), flag)
+	}
+}
+
+func TestParseAndTenantOf(t *testing.T) {
+	flag, err := Parse("lenient_parsing")
+	require.NoError(t, err)
+	assert.Equal(t, LenientParsing, flag)
+	_, err = Parse("kafka_sink")
+	assert.EqualError(t, err, `unknown feature flag "kafka_sink"`)
+
+	assert.Equal(t, "acme", TenantOf("tenant:acme"))
+	assert.Equal(t, "", TenantOf("directory"))
+}
@@ -0,0 +1,106 @@
+// internal/filemeta/pattern.go
+package filemeta
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Pattern - шаблон имени файла: именованные группы регулярного
+// выражения становятся полями метаданных файла, например
+// ^(?P<site>[A-Z0-9]+)_(?P<date>\d{8})_(?P<type>\w+)\.tsv$
+type Pattern struct {
+	re *regexp.Regexp
+}
+
+// Compile разбирает шаблон; нужна хотя бы одна именованная группа
+func Compile(expr string) (*Pattern, error) {
+	re, err := regexp.Compile(expr)
+	if err != nil {
+		return nil, err
+	}
+	p := &Pattern{re: re}
+	if len(p.Fields()) == 0 {
+		return nil, errors.New("pattern has no named groups")
+	}
+	return p, nil
+}
+
+// Fields - имена групп шаблона
+func (p *Pattern) Fields() []string {
+	var fields []string
+	for _, name := range p.re.SubexpNames() {
+		if name != "" {
+			fields = append(fields, name)
+		}
+	}
+	return fields
+}
+
+// Match извлекает метаданные из имени файла; false - имя не
+// соответствует шаблону. Необязательные группы без совпадения
+// в метаданные не попадают.
+func (p *Pattern) Match(filename string) (map[string]string, bool) {
+	m := p.re.FindStringSubmatchIndex(filename)
+	if m == nil {
+		return nil, false
+	}
+	meta := make(map[string]string)
+	for i, name := range p.re.SubexpNames() {
+		if name == "" || m[2*i] < 0 {
+			continue
+		}
+		meta[name] = filename[m[2*i]:m[2*i+1]]
+	}
+	return meta, true
+}
+
+// placeholder - {поле} в шаблоне пути или имени
+var placeholder = regexp.MustCompile(`\{(\w+)\}`)
+
+// Placeholders - поля, на которые ссылается шаблон template
+func Placeholders(template string) []string {
+	var names []string
+	for _, m := range placeholder.FindAllStringSubmatch(template, -1) {
+		names = append(names, m[1])
+	}
+	return names
+}
+
+// Expand подставляет значения полей в шаблон ("{site}/{date}").
+// false - поле отсутствует или его значение не годится как элемент
+// пути (пусто, ".", "..", содержит разделитель каталогов).
+func Expand(template string, values map[string]string) (string, bool) {
+	ok := true
+	result := placeholder.ReplaceAllStringFunc(template, func(m string) string {
+		value, found := values[m[1:len(m)-1]]
+		if !found || value == "" || value == "." || value == ".." || strings.ContainsAny(value, `/\`) {
+			ok = false
+		}
+		return value
+	})
+	return result, ok
+}
+
+// Validate проверяет, что шаблон template ссылается только на поля
+// fields
+func Validate(template string, fields []string) error {
+	known := make(map[string]bool, len(fields))
+	for _, f := range fields {
+		known[f] = true
+	}
+	for _, name := range Placeholders(template) {
+		if !known[name] {
+			return fmt.Errorf("unknown field {%s}", name)
+		}
+	}
+	return nil
+}
+
+// Поля, доступные в шаблоне имени отчёта помимо полей имени файла
+const (
+	FieldUnitGuid  = "unit_guid"
+	FieldTimestamp = "timestamp" // время генерации, 20060102_150405
+)
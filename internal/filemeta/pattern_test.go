@@ -0,0 +1,46 @@
+package filemeta
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPattern_Match(t *testing.T) {
+	p, err := Compile(`^(?P<site>[A-Z0-9]+)_(?P<date>\d{8})_(?P<type>full|delta)(?:_(?P<part>\d+))?\.tsv$`)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"site", "date", "type", "part"}, p.Fields())
+
+	meta, ok := p.Match("SITE42_20240615_full.tsv")
+	require.True(t, ok)
+	assert.Equal(t, map[string]string{"site": "SITE42", "date": "20240615", "type": "full"}, meta)
+
+	meta, ok = p.Match("SITE42_20240615_delta_2.tsv")
+	require.True(t, ok)
+	assert.Equal(t, "2", meta["part"])
+
+	_, ok = p.Match("export.tsv")
+	assert.False(t, ok)
+
+	_, err = Compile(`^\w+\.tsv$`)
+	assert.Error(t, err)
+	_, err = Compile(`(?P<site>`)
+	assert.Error(t, err)
+}
+
+func TestExpand(t *testing.T) {
+	values := map[string]string{"site": "SITE42", "date": "20240615", "dots": ".."}
+
+	path, ok := Expand("{site}/{date}", values)
+	assert.True(t, ok)
+	assert.Equal(t, "SITE42/20240615", path)
+
+	_, ok = Expand("{site}/{type}", values)
+	assert.False(t, ok)
+	_, ok = Expand("{dots}/{site}", values)
+	assert.False(t, ok)
+
+	assert.NoError(t, Validate("{site}_{unit_guid}", []string{"site", "unit_guid"}))
+	assert.EqualError(t, Validate("{site}_{region}", []string{"site"}), "unknown field {region}")
+}
@@ -0,0 +1,101 @@
+// internal/filestatus/filestatus.go
+package filestatus
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+)
+
+// Status - статус обработки файла (files.status). Допустимые значения
+// совпадают с ограничением files_status_check (миграция 000031).
+type Status string
+
+const (
+	None       Status = ""           // записи о файле ещё нет
+	Pending    Status = "pending"    // значение колонки по умолчанию
+	Processing Status = "processing" // транзакция обработки открыта
+	Completed  Status = "completed"  // все строки сохранены
+	Partial    Status = "partial"    // часть строк отклонена
+	Failed     Status = "failed"     // ошибка обработки или ни одной сохранённой строки
+	Cancelled  Status = "cancelled"  // отменён оператором, файл в hold_path
+	Retrying   Status = "retrying"   // временная ошибка, назначен повтор
+)
+
+// All - допустимые статусы записи о файле
+var All = []Status{Pending, Processing, Completed, Partial, Failed, Cancelled, Retrying}
+
+// ErrInvalidTransition - переход между статусами, которого нет в transitions
+var ErrInvalidTransition = errors.New("invalid file status transition")
+
+// transitions - разрешённые переходы. Переход в тот же статус разрешён
+// всегда (повторная отметка). Отменённые файлы и файлы с назначенным
+// повтором перед новой обработкой удаляются, поэтому из cancelled
+// переходов нет. failed и partial становятся partial или completed,
+// когда засчитываются строки исправленного rejected-файла; completed и
+// partial становятся failed при panic после фиксации (в хуках).
+var transitions = map[Status][]Status{
+	None:       {Pending, Processing, Failed, Cancelled, Retrying},
+	Pending:    {Processing, Failed, Cancelled},
+	Processing: {Completed, Partial, Failed, Cancelled, Retrying},
+	Retrying:   {Processing, Failed, Cancelled},
+	Completed:  {Failed},
+	Partial:    {Completed, Failed},
+	Failed:     {Partial, Completed},
+}
+
+// Parse проверяет значение статуса
+func Parse(s string) (Status, error) {
+	status := Status(s)
+	if !status.Valid() {
+		return "", fmt.Errorf("unknown file status %q", s)
+	}
+	return status, nil
+}
+
+// Of - статус записи о файле; NULL - статус по умолчанию (pending)
+func Of(status sql.NullString) Status {
+	if !status.Valid {
+		return Pending
+	}
+	return Status(status.String)
+}
+
+// Valid сообщает, что статус допустим для записи о файле
+func (s Status) Valid() bool {
+	for _, status := range All {
+		if s == status {
+			return true
+		}
+	}
+	return false
+}
+
+// Processed сообщает, что данные файла зафиксированы (completed или partial)
+func (s Status) Processed() bool {
+	return s == Completed || s == Partial
+}
+
+// NullString - значение для колонки files.status
+func (s Status) NullString() sql.NullString {
+	return sql.NullString{String: string(s), Valid: true}
+}
+
+// Transition проверяет переход записи о файле из from в to
+func Transition(from, to Status) error {
+	if !to.Valid() {
+		return fmt.Errorf("%w: unknown status %q", ErrInvalidTransition, to)
+	}
+	if from == to {
+		return nil
+	}
+	for _, next := range transitions[from] {
+		if next == to {
+			return nil
+		}
+	}
+	if from == None {
+		return fmt.Errorf("%w: new file -> %s", ErrInvalidTransition, to)
+	}
+	return fmt.Errorf("%w: %s -> %s", ErrInvalidTransition, from, to)
+}
@@ -0,0 +1,61 @@
+package filestatus
+
+import (
+	"database/sql"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTransition(t *testing.T) {
+	tests := []struct {
+		from, to Status
+		ok       bool
+	}{
+		{None, Processing, true},
+		{None, Failed, true},
+		{Processing, Completed, true},
+		{Processing, Retrying, true},
+		{Retrying, Retrying, true},
+		{Failed, Partial, true},
+		{Partial, Completed, true},
+		{Completed, Completed, true},
+		{Completed, Failed, true},
+		{Completed, Processing, false},
+		{Partial, Retrying, false},
+		{Cancelled, Completed, false},
+		{None, Completed, false},
+		{Processing, "done", false},
+	}
+	for _, tt := range tests {
+		err := Transition(tt.from, tt.to)
+		if tt.ok {
+			assert.NoError(t, err, "%q -> %q", tt.from, tt.to)
+			continue
+		}
+		assert.ErrorIs(t, err, ErrInvalidTransition, "%q -> %q", tt.from, tt.to)
+	}
+
+	err := Transition(Completed, Processing)
+	require.Error(t, err)
+	assert.Equal(t, "invalid file status transition: completed -> processing", err.Error())
+}
+
+func TestParse(t *testing.T) {
+	status, err := Parse("partial")
+	require.NoError(t, err)
+	assert.Equal(t, Partial, status)
+
+	_, err = Parse("done")
+	assert.EqualError(t, err, `unknown file status "done"`)
+	_, err = Parse("")
+	assert.Error(t, err)
+}
+
+func TestOf(t *testing.T) {
+	assert.Equal(t, Failed, Of(sql.NullString{String: "failed", Valid: true}))
+	assert.Equal(t, Pending, Of(sql.NullString{}))
+	assert.True(t, Completed.Processed())
+	assert.False(t, Failed.Processed())
+}
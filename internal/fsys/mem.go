@@ -0,0 +1,184 @@
+// internal/fsys/mem.go
+package fsys
+
+import (
+	"bytes"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Mem - файловая система в памяти для тестов. Время модификации файлов
+// берётся из функции now (обычно clock.Fake.Now), поэтому возраст файла
+// и его "дописывание" воспроизводятся без реальных пауз.
+type Mem struct {
+	mu    sync.Mutex
+	now   func() time.Time
+	files map[string]*memFile
+}
+
+type memFile struct {
+	data    []byte
+	modTime time.Time
+	dir     bool
+}
+
+// NewMem создаёт пустую Mem
+func NewMem(now func() time.Time) *Mem {
+	return &Mem{now: now, files: make(map[string]*memFile)}
+}
+
+// WriteFile записывает файл целиком (родительские директории создаются)
+func (m *Mem) WriteFile(name string, data []byte) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.mkdirAll(filepath.Dir(name))
+	m.files[filepath.Clean(name)] = &memFile{data: append([]byte(nil), data...), modTime: m.now()}
+}
+
+// Append дописывает данные в конец файла (эмуляция медленной записи)
+func (m *Mem) Append(name string, data []byte) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	f, ok := m.files[filepath.Clean(name)]
+	if !ok {
+		m.mkdirAll(filepath.Dir(name))
+		f = &memFile{}
+		m.files[filepath.Clean(name)] = f
+	}
+	f.data = append(f.data, data...)
+	f.modTime = m.now()
+}
+
+func (m *Mem) Stat(name string) (fs.FileInfo, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	name = filepath.Clean(name)
+	f, ok := m.files[name]
+	if !ok {
+		return nil, &fs.PathError{Op: "stat", Path: name, Err: fs.ErrNotExist}
+	}
+	return memInfo{name: filepath.Base(name), file: *f}, nil
+}
+
+func (m *Mem) ReadDir(name string) ([]fs.DirEntry, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	name = filepath.Clean(name)
+	if f, ok := m.files[name]; !ok || !f.dir {
+		return nil, &fs.PathError{Op: "readdir", Path: name, Err: fs.ErrNotExist}
+	}
+
+	var entries []fs.DirEntry
+	prefix := strings.TrimSuffix(name, string(filepath.Separator)) + string(filepath.Separator)
+	for path, f := range m.files {
+		if rest, ok := strings.CutPrefix(path, prefix); ok && rest != "" && !strings.ContainsRune(rest, filepath.Separator) {
+			entries = append(entries, fs.FileInfoToDirEntry(memInfo{name: rest, file: *f}))
+		}
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Name() < entries[j].Name() })
+	return entries, nil
+}
+
+func (m *Mem) Open(name string) (io.ReadCloser, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	f, ok := m.files[filepath.Clean(name)]
+	if !ok || f.dir {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrNotExist}
+	}
+	return io.NopCloser(bytes.NewReader(append([]byte(nil), f.data...))), nil
+}
+
+func (m *Mem) Create(name string) (io.WriteCloser, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if f, ok := m.files[filepath.Dir(filepath.Clean(name))]; !ok || !f.dir {
+		return nil, &fs.PathError{Op: "create", Path: name, Err: fs.ErrNotExist}
+	}
+	m.files[filepath.Clean(name)] = &memFile{modTime: m.now()}
+	return &memWriter{mem: m, name: filepath.Clean(name)}, nil
+}
+
+func (m *Mem) Rename(oldpath, newpath string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	oldpath, newpath = filepath.Clean(oldpath), filepath.Clean(newpath)
+	f, ok := m.files[oldpath]
+	if !ok {
+		return &os.LinkError{Op: "rename", Old: oldpath, New: newpath, Err: fs.ErrNotExist}
+	}
+	if d, ok := m.files[filepath.Dir(newpath)]; !ok || !d.dir {
+		return &os.LinkError{Op: "rename", Old: oldpath, New: newpath, Err: fs.ErrNotExist}
+	}
+	delete(m.files, oldpath)
+	m.files[newpath] = f
+	return nil
+}
+
+func (m *Mem) Remove(name string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	name = filepath.Clean(name)
+	if _, ok := m.files[name]; !ok {
+		return &fs.PathError{Op: "remove", Path: name, Err: fs.ErrNotExist}
+	}
+	delete(m.files, name)
+	return nil
+}
+
+func (m *Mem) MkdirAll(path string, perm fs.FileMode) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.mkdirAll(path)
+	return nil
+}
+
+// mkdirAll создаёт директорию и всех родителей (m.mu должен быть захвачен)
+func (m *Mem) mkdirAll(path string) {
+	for path = filepath.Clean(path); ; path = filepath.Dir(path) {
+		if _, ok := m.files[path]; !ok {
+			m.files[path] = &memFile{dir: true, modTime: m.now()}
+		}
+		if parent := filepath.Dir(path); parent == path {
+			return
+		}
+	}
+}
+
+// memWriter дописывает данные в файл Mem
+type memWriter struct {
+	mem  *Mem
+	name string
+}
+
+func (w *memWriter) Write(p []byte) (int, error) {
+	w.mem.Append(w.name, p)
+	return len(p), nil
+}
+
+func (w *memWriter) Close() error { return nil }
+
+// memInfo - fs.FileInfo файла Mem
+type memInfo struct {
+	name string
+	file memFile
+}
+
+func (i memInfo) Name() string       { return i.name }
+func (i memInfo) Size() int64        { return int64(len(i.file.data)) }
+func (i memInfo) ModTime() time.Time { return i.file.modTime }
+func (i memInfo) IsDir() bool        { return i.file.dir }
+func (i memInfo) Sys() interface{}   { return nil }
+
+func (i memInfo) Mode() fs.FileMode {
+	if i.file.dir {
+		return fs.ModeDir | 0755
+	}
+	return 0644
+}
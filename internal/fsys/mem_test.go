@@ -0,0 +1,75 @@
+package fsys
+
+import (
+	"io"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMem_WriteStatAndModTime(t *testing.T) {
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	m := NewMem(func() time.Time { return now })
+
+	m.WriteFile("/watch/a.tsv", []byte("abc"))
+	now = now.Add(time.Minute)
+	m.Append("/watch/a.tsv", []byte("de"))
+
+	info, err := m.Stat("/watch/a.tsv")
+	require.NoError(t, err)
+	assert.Equal(t, int64(5), info.Size())
+	assert.Equal(t, now, info.ModTime())
+	assert.False(t, info.IsDir())
+
+	_, err = m.Stat("/watch/missing.tsv")
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestMem_ReadDirListsDirectChildren(t *testing.T) {
+	m := NewMem(time.Now)
+	m.WriteFile("/watch/b.tsv", nil)
+	m.WriteFile("/watch/a.tsv", nil)
+	m.WriteFile("/watch/sub/c.tsv", nil)
+
+	entries, err := m.ReadDir("/watch")
+	require.NoError(t, err)
+	var names []string
+	for _, e := range entries {
+		names = append(names, e.Name())
+	}
+	assert.Equal(t, []string{"a.tsv", "b.tsv", "sub"}, names)
+	assert.True(t, entries[2].IsDir())
+
+	_, err = m.ReadDir("/missing")
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestMem_CreateOpenRenameRemove(t *testing.T) {
+	m := NewMem(time.Now)
+	require.NoError(t, m.MkdirAll("/archive", 0755))
+
+	_, err := m.Create("/nodir/x.tsv")
+	assert.True(t, os.IsNotExist(err))
+
+	w, err := m.Create("/archive/x.tsv")
+	require.NoError(t, err)
+	_, err = w.Write([]byte("hello"))
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+
+	require.NoError(t, m.Rename("/archive/x.tsv", "/archive/y.tsv"))
+	assert.True(t, os.IsNotExist(m.Rename("/archive/x.tsv", "/archive/z.tsv")))
+
+	r, err := m.Open("/archive/y.tsv")
+	require.NoError(t, err)
+	data, err := io.ReadAll(r)
+	require.NoError(t, err)
+	assert.Equal(t, "hello", string(data))
+
+	require.NoError(t, m.Remove("/archive/y.tsv"))
+	_, err = m.Open("/archive/y.tsv")
+	assert.True(t, os.IsNotExist(err))
+}
@@ -0,0 +1,163 @@
+// internal/health/history.go
+package health
+
+import (
+	"sync"
+	"time"
+)
+
+// Sample - результат одной проверки здоровья
+type Sample struct {
+	Time      time.Time `json:"time"`
+	Healthy   bool      `json:"healthy"`
+	LatencyMs int64     `json:"latency_ms"`
+	Error     string    `json:"error,omitempty"`
+}
+
+// Event - изменение состояния, о котором стоит сообщить
+type Event int
+
+const (
+	EventNone         Event = iota
+	EventDown               // проверка начала падать
+	EventRecovered          // проверка снова проходит
+	EventFlapping           // состояние часто меняется
+	EventFlapResolved       // состояние стабилизировалось
+)
+
+// History хранит последние результаты проверок (кольцевой буфер)
+// и определяет "флаппинг" - частое переключение healthy/unhealthy.
+// Пока состояние флаппит, отдельные переходы Down/Recovered не
+// сообщаются, чтобы не засыпать каналы оповещений.
+type History struct {
+	mu            sync.RWMutex
+	samples       []Sample
+	next          int
+	full          bool
+	flapWindow    int
+	flapThreshold int
+	flapping      bool
+}
+
+// NewHistory создаёт историю на size проверок. Флаппинг фиксируется,
+// если за последние flapWindow проверок состояние менялось не менее
+// flapThreshold раз.
+func NewHistory(size, flapWindow, flapThreshold int) *History {
+	if size < 1 {
+		size = 1
+	}
+	if flapWindow > size {
+		flapWindow = size
+	}
+	return &History{
+		samples:       make([]Sample, size),
+		flapWindow:    flapWindow,
+		flapThreshold: flapThreshold,
+	}
+}
+
+// Record добавляет результат проверки и возвращает событие, если
+// состояние изменилось.
+func (h *History) Record(s Sample) Event {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	prev, hasPrev := h.latest()
+	h.samples[h.next] = s
+	h.next = (h.next + 1) % len(h.samples)
+	if h.next == 0 {
+		h.full = true
+	}
+
+	transitions := h.transitions(h.flapWindow)
+	if h.flapThreshold > 0 {
+		switch {
+		case !h.flapping && transitions >= h.flapThreshold:
+			h.flapping = true
+			return EventFlapping
+		case h.flapping && transitions == 0:
+			// Гистерезис: флаппинг завершается только после полного окна
+			// без переходов
+			h.flapping = false
+			return EventFlapResolved
+		case h.flapping:
+			return EventNone
+		}
+	}
+
+	if !hasPrev {
+		if !s.Healthy {
+			return EventDown
+		}
+		return EventNone
+	}
+	switch {
+	case prev.Healthy && !s.Healthy:
+		return EventDown
+	case !prev.Healthy && s.Healthy:
+		return EventRecovered
+	}
+	return EventNone
+}
+
+// Samples возвращает до limit последних проверок, начиная с самой новой
+func (h *History) Samples(limit int) []Sample {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	ordered := h.ordered()
+	if limit <= 0 || limit > len(ordered) {
+		limit = len(ordered)
+	}
+	result := make([]Sample, 0, limit)
+	for i := len(ordered) - 1; i >= 0 && len(result) < limit; i-- {
+		result = append(result, ordered[i])
+	}
+	return result
+}
+
+// Flapping - флаппит ли состояние сейчас
+func (h *History) Flapping() bool {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return h.flapping
+}
+
+// Transitions - количество переходов в окне флаппинга
+func (h *History) Transitions() int {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return h.transitions(h.flapWindow)
+}
+
+// ordered - проверки от старой к новой. Вызывается под мьютексом.
+func (h *History) ordered() []Sample {
+	if !h.full {
+		return h.samples[:h.next]
+	}
+	return append(append([]Sample{}, h.samples[h.next:]...), h.samples[:h.next]...)
+}
+
+// latest - последняя проверка. Вызывается под мьютексом.
+func (h *History) latest() (Sample, bool) {
+	if !h.full && h.next == 0 {
+		return Sample{}, false
+	}
+	return h.samples[(h.next-1+len(h.samples))%len(h.samples)], true
+}
+
+// transitions считает смены состояния среди последних window проверок.
+// Вызывается под мьютексом.
+func (h *History) transitions(window int) int {
+	ordered := h.ordered()
+	if window > 0 && len(ordered) > window {
+		ordered = ordered[len(ordered)-window:]
+	}
+	count := 0
+	for i := 1; i < len(ordered); i++ {
+		if ordered[i].Healthy != ordered[i-1].Healthy {
+			count++
+		}
+	}
+	return count
+}
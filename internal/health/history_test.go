@@ -0,0 +1,60 @@
+package health
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func sample(healthy bool) Sample {
+	return Sample{Time: time.Now(), Healthy: healthy}
+}
+
+func TestHistory_Transitions(t *testing.T) {
+	h := NewHistory(10, 10, 0)
+
+	assert.Equal(t, EventNone, h.Record(sample(true)))
+	assert.Equal(t, EventNone, h.Record(sample(true)))
+	assert.Equal(t, EventDown, h.Record(sample(false)))
+	assert.Equal(t, EventNone, h.Record(sample(false)))
+	assert.Equal(t, EventRecovered, h.Record(sample(true)))
+	assert.Equal(t, 2, h.Transitions())
+}
+
+func TestHistory_RingBuffer(t *testing.T) {
+	h := NewHistory(3, 3, 0)
+	for i := 0; i < 5; i++ {
+		h.Record(Sample{Time: time.Unix(int64(i), 0), Healthy: true})
+	}
+
+	samples := h.Samples(0)
+	assert.Len(t, samples, 3)
+	assert.Equal(t, int64(4), samples[0].Time.Unix())
+	assert.Equal(t, int64(2), samples[2].Time.Unix())
+
+	assert.Len(t, h.Samples(1), 1)
+}
+
+func TestHistory_Flapping(t *testing.T) {
+	h := NewHistory(20, 6, 3)
+
+	h.Record(sample(true))
+	assert.Equal(t, EventDown, h.Record(sample(false)))
+	assert.Equal(t, EventRecovered, h.Record(sample(true)))
+	assert.Equal(t, EventFlapping, h.Record(sample(false)))
+	assert.True(t, h.Flapping())
+
+	// Во время флаппинга отдельные переходы не сообщаются
+	assert.Equal(t, EventNone, h.Record(sample(true)))
+
+	var last Event
+	for i := 0; i < 6; i++ {
+		last = h.Record(sample(true))
+		if last != EventNone {
+			break
+		}
+	}
+	assert.Equal(t, EventFlapResolved, last)
+	assert.False(t, h.Flapping())
+}
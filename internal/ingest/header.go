@@ -0,0 +1,130 @@
+// internal/ingest/header.go
+package ingest
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+// Columns - колонки файла в порядке по умолчанию (файл без заголовка)
+var Columns = []string{
+	"n", "mqtt", "invid", "unit_guid", "msg_id", "text", "context",
+	"class", "level", "area", "addr", "block", "type", "bit", "invert_bit",
+}
+
+// requiredColumns - колонки, без которых строки файла не разобрать
+var requiredColumns = []string{"n", "unit_guid"}
+
+// layout - позиции колонок Columns в строке файла (-1 - колонки нет).
+// Строится по заголовку файла, поэтому колонки могут идти в любом
+// порядке, а лишние колонки пропускаются.
+type layout struct {
+	index     [fieldCount]int
+	minFields int  // полей в строке, без которых нет обязательных колонок
+	identity  bool // порядок по умолчанию: поля строки не переставляются
+}
+
+// defaultLayout - файл без заголовка или с заголовком в порядке Columns
+var defaultLayout = newLayout(func(i int) int { return i })
+
+func newLayout(pos func(column int) int) layout {
+	var l layout
+	l.identity = true
+	for i := range Columns {
+		l.index[i] = pos(i)
+		if l.index[i] != i {
+			l.identity = false
+		}
+	}
+	for _, name := range requiredColumns {
+		l.minFields = max(l.minFields, l.index[columnIndex(name)]+1)
+	}
+	return l
+}
+
+func columnIndex(name string) int {
+	for i, column := range Columns {
+		if column == name {
+			return i
+		}
+	}
+	return -1
+}
+
+// parseHeader строит позиции колонок по заголовку: имена сравниваются
+// без учёта регистра и пробелов, повторная колонка не учитывается.
+// Возвращает также неизвестные колонки и ошибку со списком отсутствующих
+// обязательных.
+func parseHeader(fields []string) (layout, []string, error) {
+	positions := make(map[string]int, len(fields))
+	var unknown []string
+	for i, field := range fields {
+		name := columnName(field)
+		if _, seen := positions[name]; seen {
+			continue
+		}
+		if columnIndex(name) < 0 {
+			if name != "" {
+				unknown = append(unknown, name)
+			}
+			continue
+		}
+		positions[name] = i
+	}
+
+	var missing []string
+	for _, name := range requiredColumns {
+		if _, ok := positions[name]; !ok {
+			missing = append(missing, name)
+		}
+	}
+	if len(missing) > 0 {
+		return layout{}, unknown, fmt.Errorf("missing required headers: %s", strings.Join(missing, ", "))
+	}
+
+	return newLayout(func(i int) int {
+		if pos, ok := positions[Columns[i]]; ok {
+			return pos
+		}
+		return -1
+	}), unknown, nil
+}
+
+// columnName - имя колонки в заголовке: без BOM, пробелов и регистра
+func columnName(field string) string {
+	return strings.ToLower(strings.TrimSpace(strings.TrimPrefix(field, "\ufeff")))
+}
+
+// looksLikeHeader - строка с именами колонок и без значений unit_guid.
+// Первая строка, не похожая на заголовок, - строка данных с некорректным
+// n (в данных тоже встречаются слова вроде text, поэтому проверяется и UUID).
+func looksLikeHeader(fields []string) bool {
+	named := false
+	for _, field := range fields {
+		if _, err := uuid.Parse(strings.TrimSpace(field)); err == nil {
+			return false
+		}
+		if columnIndex(columnName(field)) >= 0 {
+			named = true
+		}
+	}
+	return named
+}
+
+// reorder раскладывает поля строки в порядке Columns (в dst); колонки,
+// которых нет в файле или в строке, остаются пустыми
+func (l *layout) reorder(dst, fields []string) []string {
+	if l.identity {
+		return fields
+	}
+	dst = dst[:fieldCount]
+	for i, pos := range l.index {
+		dst[i] = ""
+		if pos >= 0 && pos < len(fields) {
+			dst[i] = fields[pos]
+		}
+	}
+	return dst
+}
@@ -0,0 +1,590 @@
+// internal/ingest/ingest.go
+package ingest
+
+import (
+	"TSVProcessingService/db/sqlc"
+	"bufio"
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+// Row представляет строку из TSV файла. Разбор, проверка и хеш файла
+// общие для воркеров (processor) и обработки по HTTP-запросу (handlers),
+// чтобы данные и ошибки не зависели от пути поступления файла.
+type Row struct {
+	Seq        int64 // n - номер строки в экспорте контроллера
+	UnitGuid   uuid.UUID
+	Mqtt       sql.NullString
+	Invid      sql.NullString
+	MsgID      sql.NullString
+	Text       sql.NullString
+	Context    sql.NullString
+	Class      sql.NullString
+	Level      sql.NullInt32
+	Area       sql.NullString
+	Addr       sql.NullString
+	Block      sql.NullString
+	Type       sql.NullString
+	Bit        sql.NullInt32
+	InvertBit  sql.NullBool
+	LineNumber int32
+}
+
+// RowError представляет ошибку обработки строки
+type RowError struct {
+	LineNumber   sql.NullInt32
+	RawLine      sql.NullString
+	ErrorMessage string
+	FieldName    sql.NullString
+	UnitGuid     uuid.NullUUID   // unit_guid строки, если он разобран
+	Partial      json.RawMessage // поля, разобранные до и после ошибки (nil - нет)
+	Seq          sql.NullInt64   // n отклонённой строки данных (не сохраняется)
+}
+
+// emptyPartial - processing_errors.partial ошибки без разобранных полей
+var emptyPartial = json.RawMessage(`{}`)
+
+// DeviceDataParams - параметры вставки строки в device_data
+func (r Row) DeviceDataParams(fileID int64) sqlc.CreateDeviceDataParams {
+	return sqlc.CreateDeviceDataParams{
+		FileID:     fileID,
+		UnitGuid:   r.UnitGuid,
+		Mqtt:       r.Mqtt,
+		Invid:      r.Invid,
+		MsgID:      r.MsgID,
+		Text:       r.Text,
+		Context:    r.Context,
+		Class:      r.Class,
+		Level:      r.Level,
+		Area:       r.Area,
+		Addr:       r.Addr,
+		Block:      r.Block,
+		Type:       r.Type,
+		Bit:        r.Bit,
+		InvertBit:  r.InvertBit,
+		LineNumber: r.LineNumber,
+	}
+}
+
+func (e RowError) partial() json.RawMessage {
+	if len(e.Partial) == 0 {
+		return emptyPartial
+	}
+	return e.Partial
+}
+
+// ProcessingErrorParams - параметры сохранения ошибки строки
+func (e RowError) ProcessingErrorParams(fileID int64) sqlc.CreateProcessingErrorParams {
+	return sqlc.CreateProcessingErrorParams{
+		FileID:       fileID,
+		LineNumber:   e.LineNumber,
+		RawLine:      e.RawLine,
+		ErrorMessage: e.ErrorMessage,
+		FieldName:    e.FieldName,
+		UnitGuid:     e.UnitGuid,
+		Partial:      e.partial(),
+	}
+}
+
+// HashFile вычисляет SHA256 хеш содержимого файла (идентификатор файла
+// для дедупликации во всех путях приёма).
+func HashFile(filePath string) (string, error) {
+	f, err := os.Open(filePath)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	return Hash(f)
+}
+
+// Hash вычисляет SHA256 содержимого (hex)
+func Hash(r io.Reader) (string, error) {
+	hash := sha256.New()
+	if _, err := io.Copy(hash, r); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// firstUnitGuidLines - сколько строк просматривает FirstUnitGuid
+const firstUnitGuidLines = 100
+
+// FirstUnitGuid возвращает unit_guid первой строки данных файла без
+// полного разбора (ключ распределения файлов по воркерам). uuid.Nil -
+// если среди первых строк нет корректного unit_guid.
+func FirstUnitGuid(filePath string) (uuid.UUID, error) {
+	f, err := os.Open(filePath)
+	if err != nil {
+		return uuid.Nil, err
+	}
+	defer f.Close()
+
+	// Позиция unit_guid - по заголовку, если он есть
+	guidIndex := columnIndex("unit_guid")
+	headerSeen := false
+	scanner := bufio.NewScanner(f)
+	for i := 0; i < firstUnitGuidLines && scanner.Scan(); i++ {
+		fields := strings.Split(scanner.Text(), "\t")
+		if _, err := strconv.ParseInt(strings.TrimSpace(fields[0]), 10, 64); err != nil && !headerSeen {
+			if header, _, err := parseHeader(fields); err == nil {
+				headerSeen = true
+				guidIndex = header.index[columnIndex("unit_guid")]
+				continue
+			}
+		}
+		if len(fields) <= guidIndex {
+			continue
+		}
+		if guid, err := uuid.Parse(strings.TrimSpace(fields[guidIndex])); err == nil {
+			return guid, nil
+		}
+	}
+	return uuid.Nil, scanner.Err()
+}
+
+// ParseFile открывает файл и построчно разбирает его.
+// Разделитель – строго символ табуляции ('\t').
+// Разбор прерывается, если контекст отменён (истёк бюджет этапа).
+func ParseFile(ctx context.Context, filePath string) ([]Row, []RowError) {
+	return ParseFileSampled(ctx, filePath, Sampling{})
+}
+
+// ParseFileSampled разбирает только строки данных, попавшие в выборку;
+// остальные строки пропускаются без разбора и не дают ошибок.
+func ParseFileSampled(ctx context.Context, filePath string, sampling Sampling) ([]Row, []RowError) {
+	return ParseFileWithOptions(ctx, filePath, sampling, Options{})
+}
+
+// ParseFileWithOptions разбирает файл с настройками формата, кодировки
+// и строгости проверки полей (см. Options) и выборкой строк.
+func ParseFileWithOptions(ctx context.Context, filePath string, sampling Sampling, opts Options) ([]Row, []RowError) {
+	var rows []Row
+	var errors []RowError
+	StreamFile(ctx, filePath, sampling, opts, 0, func(chunkRows []Row, chunkErrors []RowError) error {
+		rows = append(rows, chunkRows...)
+		errors = append(errors, chunkErrors...)
+		return nil
+	})
+	return rows, errors
+}
+
+// StreamFile разбирает файл частями: fn получает разобранные строки
+// и ошибки очередных chunkSize строк данных (0 - весь файл одной частью),
+// поэтому файл любого размера разбирается в ограниченной памяти. Ошибки
+// открытия и чтения файла и прерывание по контексту передаются в fn как
+// ошибки строк (как в ParseFile). Ошибка fn прерывает разбор и
+// возвращается.
+func StreamFile(ctx context.Context, filePath string, sampling Sampling, opts Options, chunkSize int,
+	fn func(rows []Row, errors []RowError) error) error {
+	if opts.IsZero() {
+		slog.Info("🔍 Parsing TSV (simple split)", "component", "ingest", "sampling", sampling, "file", filePath)
+	} else {
+		slog.Info("🔍 Parsing file", "component", "ingest", "options", opts, "sampling", sampling, "file", filePath)
+	}
+
+	f, err := os.Open(filePath)
+	if err != nil {
+		return fn(nil, []RowError{{
+			ErrorMessage: fmt.Sprintf("failed to open file: %v", err),
+		}})
+	}
+	defer f.Close()
+
+	// Части выделяются сразу нужной ёмкости: fn может хранить строки части
+	var rows []Row
+	if chunkSize > 0 {
+		rows = make([]Row, 0, chunkSize)
+	}
+	var errors []RowError
+	totalRows, totalErrors := 0, 0
+	flush := func() error {
+		totalRows += len(rows)
+		totalErrors += len(errors)
+		err := fn(rows, errors)
+		rows, errors = nil, nil
+		if chunkSize > 0 {
+			rows = make([]Row, 0, chunkSize)
+		}
+		return err
+	}
+
+	lineNumber := int32(0)
+	dataLines := int64(0)
+	lenientRows := 0
+	fields := make([]string, 0, fieldCount)
+	ordered := make([]string, fieldCount)
+	columns := defaultLayout
+	headerSeen := false
+	scanner := bufio.NewScanner(opts.decode(f))
+	release := newLineScanner(scanner)
+	defer release()
+
+	for scanner.Scan() {
+		if chunkSize > 0 && len(rows)+len(errors) >= chunkSize {
+			if err := flush(); err != nil {
+				return err
+			}
+		}
+
+		line := scanner.Text()
+		lineNumber++
+
+		// Проверяем контекст не на каждой строке, а раз в 1000 строк
+		if lineNumber%1000 == 0 && ctx.Err() != nil {
+			errors = append(errors, RowError{
+				LineNumber:   sql.NullInt32{Int32: lineNumber, Valid: true},
+				ErrorMessage: fmt.Sprintf("parsing aborted: %v", ctx.Err()),
+			})
+			break
+		}
+
+		// Пропускаем пустые строки
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" {
+			continue
+		}
+
+		// Пропускаем комментарии (начинаются с #)
+		if strings.HasPrefix(trimmed, "#") {
+			continue
+		}
+
+		// Разбиваем по табуляции (или запятой для csv)
+		fields = opts.split(fields, line)
+
+		// Строка до первой строки данных, первое поле которой не число, -
+		// заголовок: поля ищутся по именам колонок. Без обязательных
+		// колонок строки файла не разобрать - разбор прекращается.
+		// Строка, не похожая на заголовок (looksLikeHeader), - первая строка
+		// файла без заголовка: отклоняется только она.
+		if _, err := strconv.ParseInt(strings.TrimSpace(fields[0]), 10, 64); err != nil && !headerSeen && dataLines == 0 {
+			headerSeen = true
+			header, unknown, err := parseHeader(fields)
+			if err == nil {
+				if len(unknown) > 0 {
+					slog.Info("Ignoring unknown columns", "component", "ingest", "columns", strings.Join(unknown, ", "))
+				}
+				columns = header
+				continue
+			}
+			if looksLikeHeader(fields) {
+				errors = append(errors, RowError{
+					LineNumber:   sql.NullInt32{Int32: lineNumber, Valid: true},
+					RawLine:      sql.NullString{String: line, Valid: true},
+					ErrorMessage: err.Error(),
+					FieldName:    sql.NullString{String: "header", Valid: true},
+				})
+				break
+			}
+			dataLines++
+			if sampling.keep(dataLines) {
+				errors = append(errors, invalidSeqError(fields, line, lineNumber))
+			}
+			continue
+		}
+
+		// Поля в порядке Columns (fields - буфер разбиения строки)
+		values := columns.reorder(ordered, fields)
+
+		// Пропускаем повторные заголовки (первое поле не является числом)
+		seq, err := strconv.ParseInt(strings.TrimSpace(values[0]), 10, 64)
+		if err != nil {
+			slog.Debug("Skipping header line", "component", "ingest", "line", line)
+			continue
+		}
+
+		dataLines++
+		if !sampling.keep(dataLines) {
+			continue
+		}
+
+		// Минимальное количество полей: до последней обязательной колонки
+		// (без заголовка - n, mqtt, invid, unit_guid)
+		if len(fields) < columns.minFields {
+			errors = append(errors, RowError{
+				LineNumber:   sql.NullInt32{Int32: lineNumber, Valid: true},
+				RawLine:      sql.NullString{String: line, Valid: true},
+				ErrorMessage: fmt.Sprintf("insufficient fields: got %d, need at least %d", len(fields), columns.minFields),
+				Seq:          sql.NullInt64{Int64: seq, Valid: true},
+			})
+			continue
+		}
+
+		// Парсинг полей
+		row, parseErr := ParseLine(values, lineNumber)
+		row.Seq = seq
+		if fieldErr, ok := parseErr.(*FieldError); ok && opts.Lenient && fieldErr.Field != "unit_guid" {
+			// Некорректные поля остались NULL
+			lenientRows++
+			parseErr = nil
+		}
+		if parseErr != nil {
+			rowErr := RowError{
+				LineNumber:   sql.NullInt32{Int32: lineNumber, Valid: true},
+				RawLine:      sql.NullString{String: line, Valid: true},
+				ErrorMessage: parseErr.Error(),
+				UnitGuid:     uuid.NullUUID{UUID: row.UnitGuid, Valid: row.UnitGuid != uuid.Nil},
+				Partial:      partialFields(row),
+				Seq:          sql.NullInt64{Int64: seq, Valid: true},
+			}
+			if fieldErr, ok := parseErr.(*FieldError); ok {
+				rowErr.FieldName = sql.NullString{String: fieldErr.Field, Valid: true}
+			}
+			errors = append(errors, rowErr)
+			continue
+		}
+		rows = append(rows, row)
+	}
+
+	if err := scanner.Err(); err != nil {
+		errors = append(errors, RowError{
+			ErrorMessage: fmt.Sprintf("scanner error: %v", err),
+		})
+	}
+
+	if lenientRows > 0 {
+		slog.Warn("⚠️ Lenient mode: rows loaded with invalid fields set to NULL", "component", "ingest", "rows", lenientRows)
+	}
+	err = flush()
+	slog.Info("📊 Parsed file", "component", "ingest", "rows", totalRows, "errors", totalErrors)
+	return err
+}
+
+// invalidSeqError - ошибка строки данных, номер n которой не число.
+// Остальные поля разбираются для Partial, если их достаточно.
+func invalidSeqError(fields []string, line string, lineNumber int32) RowError {
+	rowErr := RowError{
+		LineNumber:   sql.NullInt32{Int32: lineNumber, Valid: true},
+		RawLine:      sql.NullString{String: line, Valid: true},
+		ErrorMessage: fmt.Sprintf("invalid n at column 1: %q is not a number", strings.TrimSpace(fields[0])),
+		FieldName:    sql.NullString{String: "n", Valid: true},
+	}
+	if len(fields) >= defaultLayout.minFields {
+		row, _ := ParseLine(fields, lineNumber)
+		rowErr.UnitGuid = uuid.NullUUID{UUID: row.UnitGuid, Valid: row.UnitGuid != uuid.Nil}
+		rowErr.Partial = partialFields(row)
+	}
+	return rowErr
+}
+
+// ParseLine преобразует массив полей в Row. Поля - в порядке Columns
+// (файл с заголовком переставляется по нему до разбора).
+// Индексы колонок (начиная с 0):
+//
+//	 0: n
+//	 1: mqtt (всегда пусто)
+//	 2: invid
+//	 3: unit_guid
+//	 4: msg_id
+//	 5: text
+//	 6: context
+//	 7: class
+//	 8: level
+//	 9: area
+//	10: addr
+//	11: block
+//	12: type
+//	13: bit
+//	14: invert_bit
+func ParseLine(fields []string, lineNumber int32) (Row, error) {
+	row := Row{LineNumber: lineNumber}
+
+	// Разбор продолжается после ошибки, чтобы вернуть всё, что удалось
+	// разобрать (RowError.Partial); возвращается первая ошибка
+	var firstErr error
+	fail := func(field string, err error) {
+		if firstErr == nil {
+			firstErr = &FieldError{Field: field, Err: err}
+		}
+	}
+
+	// UUID на позиции 3 – строго обязателен
+	guidStr := strings.TrimSpace(fields[3])
+	if guid, err := uuid.Parse(guidStr); err != nil {
+		fail("unit_guid", fmt.Errorf("invalid unit_guid at column 4: %w", err))
+	} else {
+		row.UnitGuid = guid
+	}
+
+	// invid (индекс 2)
+	if val := strings.TrimSpace(fields[2]); val != "" {
+		row.Invid = sql.NullString{String: val, Valid: true}
+	}
+
+	// msg_id (индекс 4)
+	if len(fields) > 4 {
+		if val := strings.TrimSpace(fields[4]); val != "" {
+			row.MsgID = sql.NullString{String: val, Valid: true}
+		}
+	}
+
+	// text (индекс 5)
+	if len(fields) > 5 {
+		if val := strings.TrimSpace(fields[5]); val != "" {
+			row.Text = sql.NullString{String: val, Valid: true}
+		}
+	}
+
+	// context (индекс 6) – игнорируем (всегда NULL)
+
+	// class (индекс 7)
+	if len(fields) > 7 {
+		if val := strings.TrimSpace(fields[7]); val != "" {
+			if isValidClass(val) {
+				row.Class = sql.NullString{String: val, Valid: true}
+			} else {
+				fail("class", fmt.Errorf("invalid class value: %s", val))
+			}
+		}
+	}
+
+	// level (индекс 8)
+	if len(fields) > 8 {
+		val := strings.TrimSpace(fields[8])
+		if val != "" {
+			if level, err := strconv.ParseInt(val, 10, 32); err != nil {
+				fail("level", fmt.Errorf("invalid level (not integer): %s", val))
+			} else {
+				row.Level = sql.NullInt32{Int32: int32(level), Valid: true}
+			}
+		}
+	}
+
+	// area (индекс 9)
+	if len(fields) > 9 {
+		if val := strings.TrimSpace(fields[9]); val != "" {
+			row.Area = sql.NullString{String: val, Valid: true}
+		}
+	}
+
+	// addr (индекс 10)
+	if len(fields) > 10 {
+		if val := strings.TrimSpace(fields[10]); val != "" {
+			row.Addr = sql.NullString{String: val, Valid: true}
+		}
+	}
+
+	// block (индекс 11)
+	if len(fields) > 11 {
+		if val := strings.TrimSpace(fields[11]); val != "" {
+			row.Block = sql.NullString{String: val, Valid: true}
+		}
+	}
+
+	// type (индекс 12)
+	if len(fields) > 12 {
+		if val := strings.TrimSpace(fields[12]); val != "" {
+			row.Type = sql.NullString{String: val, Valid: true}
+		}
+	}
+
+	// bit (индекс 13)
+	if len(fields) > 13 {
+		val := strings.TrimSpace(fields[13])
+		if val != "" {
+			if bit, err := strconv.ParseInt(val, 10, 32); err != nil {
+				fail("bit", fmt.Errorf("invalid bit (not integer): %s", val))
+			} else {
+				row.Bit = sql.NullInt32{Int32: int32(bit), Valid: true}
+			}
+		}
+	}
+
+	// invert_bit (индекс 14)
+	if len(fields) > 14 {
+		val := strings.TrimSpace(fields[14])
+		if val != "" {
+			if invert, err := parseInvertBit(val); err != nil {
+				fail("invert_bit", fmt.Errorf("invalid invert_bit: %w", err))
+			} else {
+				row.InvertBit = sql.NullBool{Bool: invert, Valid: true}
+			}
+		}
+	}
+
+	return row, firstErr
+}
+
+// FieldError - ошибка разбора колонки строки
+type FieldError struct {
+	Field string
+	Err   error
+}
+
+func (e *FieldError) Error() string { return e.Err.Error() }
+
+func (e *FieldError) Unwrap() error { return e.Err }
+
+// partialFields - разобранные поля отклонённой строки (JSON для
+// processing_errors.partial), чтобы при разборе ошибок было видно,
+// что удалось восстановить
+func partialFields(row Row) json.RawMessage {
+	fields := make(map[string]interface{})
+	if row.UnitGuid != uuid.Nil {
+		fields["unit_guid"] = row.UnitGuid
+	}
+	for name, v := range map[string]sql.NullString{
+		"mqtt": row.Mqtt, "invid": row.Invid, "msg_id": row.MsgID, "text": row.Text,
+		"context": row.Context, "class": row.Class, "area": row.Area, "addr": row.Addr,
+		"block": row.Block, "type": row.Type,
+	} {
+		if v.Valid {
+			fields[name] = v.String
+		}
+	}
+	if row.Level.Valid {
+		fields["level"] = row.Level.Int32
+	}
+	if row.Bit.Valid {
+		fields["bit"] = row.Bit.Int32
+	}
+	if row.InvertBit.Valid {
+		fields["invert_bit"] = row.InvertBit.Bool
+	}
+	data, err := json.Marshal(fields)
+	if err != nil {
+		return emptyPartial
+	}
+	return data
+}
+
+// isValidClass проверяет допустимые значения class
+func isValidClass(class string) bool {
+	allowed := map[string]bool{
+		"alarm":   true,
+		"warning": true,
+		"info":    true,
+		"event":   true,
+		"comand":  true,
+		"waiting": true,
+		"working": true,
+	}
+	return allowed[strings.ToLower(class)]
+}
+
+// parseInvertBit преобразует строку в bool
+func parseInvertBit(field string) (bool, error) {
+	field = strings.ToLower(strings.TrimSpace(field))
+	switch field {
+	case "true", "1", "yes":
+		return true, nil
+	case "false", "0", "no", "":
+		return false, nil
+	default:
+		if val, err := strconv.ParseBool(field); err == nil {
+			return val, nil
+		}
+		return false, fmt.Errorf("cannot parse invert_bit: %s", field)
+	}
+}
@@ -0,0 +1,479 @@
+package ingest
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/text/encoding/charmap"
+)
+
+func createTestTSV(t *testing.T, dir, filename string, lines []string) string {
+	path := filepath.Join(dir, filename)
+	f, err := os.Create(path)
+	require.NoError(t, err)
+	defer f.Close()
+	for _, line := range lines {
+		_, err := f.WriteString(line + "\n")
+		require.NoError(t, err)
+	}
+	return path
+}
+
+// ---------- ParseLine ----------
+func TestParseLine_Valid(t *testing.T) {
+	fields := []string{
+		"1", "", "G-044322", "01749246-95f6-57db-b7c3-2ae0e8be671f",
+		"cold7_Defrost_status", "Разморозка", "", "waiting", "100", "LOCAL",
+		"cold7_status.Defrost_status", "", "", "", "",
+	}
+	row, err := ParseLine(fields, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, uuid.MustParse("01749246-95f6-57db-b7c3-2ae0e8be671f"), row.UnitGuid)
+	assert.Equal(t, "G-044322", row.Invid.String)
+}
+
+func TestParseLine_InvalidUUID(t *testing.T) {
+	fields := []string{"1", "", "G-044322", "not-a-uuid"}
+	_, err := ParseLine(fields, 1)
+	assert.ErrorContains(t, err, "invalid unit_guid")
+}
+
+func TestParseLine_InvalidClass(t *testing.T) {
+	fields := []string{
+		"1", "", "G-044322", "01749246-95f6-57db-b7c3-2ae0e8be671f",
+		"msg", "text", "", "INVALID_CLASS", "100",
+	}
+	_, err := ParseLine(fields, 1)
+	assert.ErrorContains(t, err, "invalid class value")
+}
+
+func TestParseLine_InvalidLevel(t *testing.T) {
+	fields := []string{
+		"1", "", "G-044322", "01749246-95f6-57db-b7c3-2ae0e8be671f",
+		"msg", "text", "", "alarm", "abc",
+	}
+	_, err := ParseLine(fields, 1)
+	assert.ErrorContains(t, err, "invalid level (not integer)")
+}
+
+func TestParseLine_InvalidBit(t *testing.T) {
+	fields := []string{
+		"1", "", "G-044322", "01749246-95f6-57db-b7c3-2ae0e8be671f",
+		"msg", "text", "", "alarm", "100", "LOCAL", "addr", "", "coil", "not-a-number",
+	}
+	_, err := ParseLine(fields, 1)
+	assert.ErrorContains(t, err, "invalid bit (not integer)")
+}
+
+func TestParseLine_InvalidInvertBit(t *testing.T) {
+	fields := []string{
+		"1", "", "G-044322", "01749246-95f6-57db-b7c3-2ae0e8be671f",
+		"msg", "text", "", "alarm", "100", "LOCAL", "addr", "", "coil", "1", "maybe",
+	}
+	_, err := ParseLine(fields, 1)
+	assert.ErrorContains(t, err, "invalid invert_bit")
+}
+
+// ---------- ParseFile ----------
+func TestParseFile_ValidFile(t *testing.T) {
+	lines := []string{
+		"n\tmqtt\tinvid\tunit_guid\tmsg_id\ttext\tcontext\tclass\tlevel\tarea\taddr\tblock\ttype\tbit\tinvert_bit",
+		"1\t\tG-044322\t01749246-95f6-57db-b7c3-2ae0e8be671f\tcold7_Defrost_status\tРазморозка\t\twaiting\t100\tLOCAL\tcold7_status.Defrost_status\t\t\t\t",
+		"2\t\tG-044322\t01749246-95f6-57db-b7c3-2ae0e8be671f\tcold7_VentSK_status\tВентилятор\t\tworking\t100\tLOCAL\tcold7_status.VentSK_status\t\t\t\t",
+	}
+	path := createTestTSV(t, t.TempDir(), "valid.tsv", lines)
+
+	rows, errors := ParseFile(context.Background(), path)
+
+	assert.Len(t, rows, 2)
+	assert.Len(t, errors, 0)
+	assert.Equal(t, "cold7_Defrost_status", rows[0].MsgID.String)
+	assert.Equal(t, "cold7_VentSK_status", rows[1].MsgID.String)
+}
+
+func TestParseFile_WithErrors(t *testing.T) {
+	lines := []string{
+		"n\tmqtt\tinvid\tunit_guid\tmsg_id\ttext\tcontext\tclass\tlevel\tarea\taddr\tblock\ttype\tbit\tinvert_bit",
+		"1\t\tG-044322\tinvalid-uuid\tcold7_Defrost_status\tРазморозка\t\twaiting\t100\tLOCAL\taddr\t\t\t\t",
+		"2\t\tG-044322\t01749246-95f6-57db-b7c3-2ae0e8be671f\tbad_level\tтекст\t\talarm\tnot_int\tLOCAL\taddr\t\t\t\t",
+		"3\t\tG-044322\t01749246-95f6-57db-b7c3-2ae0e8be671f\tbad_class\tтекст\t\tinvalid_class\t100\tLOCAL\taddr\t\t\t\t",
+	}
+	path := createTestTSV(t, t.TempDir(), "with_errors.tsv", lines)
+
+	rows, errors := ParseFile(context.Background(), path)
+
+	assert.Len(t, rows, 0)
+	assert.Len(t, errors, 3)
+	assert.Contains(t, errors[0].ErrorMessage, "invalid unit_guid")
+	assert.Contains(t, errors[1].ErrorMessage, "invalid level")
+	assert.Contains(t, errors[2].ErrorMessage, "invalid class value")
+
+	// unit_guid сохраняется для строк, где он корректен
+	assert.False(t, errors[0].UnitGuid.Valid)
+	assert.Equal(t, "01749246-95f6-57db-b7c3-2ae0e8be671f", errors[1].UnitGuid.UUID.String())
+
+	// Разобранные до и после ошибки поля сохраняются
+	assert.Equal(t, "level", errors[1].FieldName.String)
+	var partial map[string]interface{}
+	require.NoError(t, json.Unmarshal(errors[1].Partial, &partial))
+	assert.Equal(t, "bad_level", partial["msg_id"])
+	assert.Equal(t, "alarm", partial["class"])
+	assert.Equal(t, "LOCAL", partial["area"])
+	assert.NotContains(t, partial, "level")
+	assert.Equal(t, "unit_guid", errors[0].FieldName.String)
+	assert.NotContains(t, string(errors[0].Partial), "unit_guid")
+}
+
+func TestParseFile_HeaderColumns(t *testing.T) {
+	// Колонки в другом порядке, без части необязательных, с лишней колонкой
+	lines := []string{
+		"Unit_GUID\tn\tclass\tcomment\tlevel\tmsg_id",
+		"01749246-95f6-57db-b7c3-2ae0e8be671f\t1\talarm\tignored\t100\tcold7_Defrost_status",
+		"01749246-95f6-57db-b7c3-2ae0e8be671f\t2",
+		"only-guid",
+	}
+	path := createTestTSV(t, t.TempDir(), "header.tsv", lines)
+
+	rows, errors := ParseFile(context.Background(), path)
+
+	require.Len(t, rows, 2)
+	assert.Equal(t, int64(1), rows[0].Seq)
+	assert.Equal(t, "01749246-95f6-57db-b7c3-2ae0e8be671f", rows[0].UnitGuid.String())
+	assert.Equal(t, "alarm", rows[0].Class.String)
+	assert.Equal(t, int32(100), rows[0].Level.Int32)
+	assert.Equal(t, "cold7_Defrost_status", rows[0].MsgID.String)
+	assert.False(t, rows[0].Invid.Valid)
+	assert.Equal(t, int64(2), rows[1].Seq)
+	assert.False(t, rows[1].Class.Valid)
+
+	// Строка без колонки n - не строка данных
+	assert.Empty(t, errors)
+}
+
+func TestParseFile_MissingRequiredHeaders(t *testing.T) {
+	lines := []string{
+		"mqtt\tinvid\tguid\tmsg_id",
+		"\tG-044322\t01749246-95f6-57db-b7c3-2ae0e8be671f\tmsg",
+	}
+	path := createTestTSV(t, t.TempDir(), "no_guid.tsv", lines)
+
+	rows, errors := ParseFile(context.Background(), path)
+
+	assert.Empty(t, rows)
+	require.Len(t, errors, 1)
+	assert.Equal(t, "missing required headers: n, unit_guid", errors[0].ErrorMessage)
+	assert.Equal(t, "header", errors[0].FieldName.String)
+	assert.Equal(t, int32(1), errors[0].LineNumber.Int32)
+}
+
+func TestParseFile_MalformedFirstRowWithoutHeader(t *testing.T) {
+	// Файл без заголовка, номер первой строки повреждён
+	lines := []string{
+		"1O\t\tG-044322\t01749246-95f6-57db-b7c3-2ae0e8be671f\tmsg_1\ttext\t\talarm\t100\tLOCAL\taddr\t\t\t\t",
+		"2\t\tG-044322\t01749246-95f6-57db-b7c3-2ae0e8be671f\tmsg_2\ttext\t\talarm\t100\tLOCAL\taddr\t\t\t\t",
+		"3\t\tG-044322\t01749246-95f6-57db-b7c3-2ae0e8be671f\tmsg_3\ttext\t\talarm\t100\tLOCAL\taddr\t\t\t\t",
+	}
+	path := createTestTSV(t, t.TempDir(), "no_header.tsv", lines)
+
+	rows, errors := ParseFile(context.Background(), path)
+
+	require.Len(t, rows, 2)
+	assert.Equal(t, int64(2), rows[0].Seq)
+	assert.Equal(t, int64(3), rows[1].Seq)
+	require.Len(t, errors, 1)
+	assert.Equal(t, int32(1), errors[0].LineNumber.Int32)
+	assert.Equal(t, "n", errors[0].FieldName.String)
+	assert.Equal(t, `invalid n at column 1: "1O" is not a number`, errors[0].ErrorMessage)
+	assert.Equal(t, "01749246-95f6-57db-b7c3-2ae0e8be671f", errors[0].UnitGuid.UUID.String())
+	assert.Contains(t, string(errors[0].Partial), "msg_1")
+
+	// Короткая повреждённая строка тоже отклоняется отдельно
+	path = createTestTSV(t, t.TempDir(), "short.tsv", []string{"garbage", lines[1]})
+	rows, errors = ParseFile(context.Background(), path)
+	require.Len(t, rows, 1)
+	require.Len(t, errors, 1)
+	assert.Equal(t, "n", errors[0].FieldName.String)
+	assert.False(t, errors[0].UnitGuid.Valid)
+}
+
+// ---------- ProcessFile ----------
+func TestParseFileSampled(t *testing.T) {
+	lines := []string{"n\tmqtt\tinvid\tunit_guid\tmsg_id\ttext\tcontext\tclass\tlevel\tarea\taddr\tblock\ttype\tbit\tinvert_bit"}
+	for i := 1; i <= 1000; i++ {
+		lines = append(lines, fmt.Sprintf("%d\t\tG-044322\t01749246-95f6-57db-b7c3-2ae0e8be671f\tmsg_%d\ttext\t\talarm\t100\tLOCAL\taddr\t\t\t\t", i, i))
+	}
+	path := createTestTSV(t, t.TempDir(), "huge.tsv", lines)
+
+	// Каждая 10-я строка данных, начиная с первой
+	rows, errors := ParseFileSampled(context.Background(), path, Sampling{Every: 10})
+	require.Len(t, rows, 100)
+	assert.Len(t, errors, 0)
+	assert.Equal(t, "msg_1", rows[0].MsgID.String)
+	assert.Equal(t, "msg_11", rows[1].MsgID.String)
+
+	// Процент - приблизительно, но детерминированно
+	rows, _ = ParseFileSampled(context.Background(), path, Sampling{Percent: 10})
+	assert.InDelta(t, 100, len(rows), 30)
+	again, _ := ParseFileSampled(context.Background(), path, Sampling{Percent: 10})
+	assert.Equal(t, rows, again)
+
+	// Пустая выборка - все строки
+	rows, _ = ParseFileSampled(context.Background(), path, Sampling{})
+	assert.Len(t, rows, 1000)
+}
+
+func TestParseFileWithOptions(t *testing.T) {
+	guid := "01749246-95f6-57db-b7c3-2ae0e8be671f"
+
+	// CSV в windows-1251 с кавычками
+	text, err := charmap.Windows1251.NewEncoder().String(`"Разморозка, камера 7"`)
+	require.NoError(t, err)
+	path := createTestTSV(t, t.TempDir(), "export.tsv", []string{
+		"n,mqtt,invid,unit_guid,msg_id,text",
+		"1,,G-044322," + guid + ",msg_1," + text,
+	})
+	rows, errors := ParseFileWithOptions(context.Background(), path, Sampling{}, Options{Parser: ParserCSV, Charset: "windows-1251"})
+	require.Empty(t, errors)
+	require.Len(t, rows, 1)
+	assert.Equal(t, "Разморозка, камера 7", rows[0].Text.String)
+
+	// Lenient: некорректные поля - NULL, без unit_guid строка отклоняется
+	path = createTestTSV(t, t.TempDir(), "lenient.tsv", []string{
+		"1\t\tG-044322\t" + guid + "\tmsg_1\ttext\t\tbogus\tnot-a-level\tLOCAL",
+		"2\t\tG-044322\tnot-a-guid\tmsg_2\ttext\t\talarm\t100\tLOCAL",
+	})
+	rows, errors = ParseFileWithOptions(context.Background(), path, Sampling{}, Options{Lenient: true})
+	require.Len(t, rows, 1)
+	assert.False(t, rows[0].Class.Valid)
+	assert.False(t, rows[0].Level.Valid)
+	assert.Equal(t, "LOCAL", rows[0].Area.String)
+	require.Len(t, errors, 1)
+	assert.Equal(t, "unit_guid", errors[0].FieldName.String)
+
+	rows, errors = ParseFileWithOptions(context.Background(), path, Sampling{}, Options{})
+	assert.Empty(t, rows)
+	assert.Len(t, errors, 2)
+
+	assert.Error(t, Options{Parser: "xml"}.Validate())
+	assert.Error(t, Options{Charset: "utf-16"}.Validate())
+	assert.NoError(t, Options{Parser: ParserCSV, Charset: "KOI8-R"}.Validate())
+}
+
+func TestParseSampling(t *testing.T) {
+	s, err := ParseSampling("", "")
+	require.NoError(t, err)
+	assert.False(t, s.Enabled())
+	assert.Equal(t, 1.0, s.Rate())
+
+	s, err = ParseSampling("4", "")
+	require.NoError(t, err)
+	assert.True(t, s.Enabled())
+	assert.Equal(t, 0.25, s.Rate())
+
+	s, err = ParseSampling("", "2.5")
+	require.NoError(t, err)
+	assert.Equal(t, 0.025, s.Rate())
+	assert.Equal(t, "2.5% of lines", s.String())
+
+	for _, tc := range [][2]string{{"0", ""}, {"x", ""}, {"", "0"}, {"", "101"}, {"2", "50"}} {
+		_, err := ParseSampling(tc[0], tc[1])
+		assert.Error(t, err, tc)
+	}
+	assert.Error(t, Sampling{Every: -1}.Validate())
+	assert.Error(t, Sampling{Every: 2, Percent: 5}.Validate())
+	assert.NoError(t, Sampling{Percent: 100}.Validate())
+}
+
+func TestProfileRows(t *testing.T) {
+	guid := uuid.MustParse("01749246-95f6-57db-b7c3-2ae0e8be671f")
+	rows := []Row{
+		{UnitGuid: guid, Class: sql.NullString{String: "alarm", Valid: true}, Level: sql.NullInt32{Int32: 100, Valid: true}},
+		{UnitGuid: guid, Class: sql.NullString{String: "alarm", Valid: true}, Level: sql.NullInt32{Int32: 20, Valid: true}},
+		{UnitGuid: guid, Class: sql.NullString{String: "working", Valid: true}},
+		{UnitGuid: uuid.New()},
+	}
+
+	profile := ProfileRows(rows)
+
+	assert.Equal(t, int64(4), profile.Rows)
+	assert.Equal(t, 2, profile.Columns["unit_guid"].Distinct)
+	assert.Equal(t, int64(1), profile.Columns["class"].Nulls)
+	assert.Equal(t, 0.25, profile.Columns["class"].NullRate)
+	assert.Equal(t, 2, profile.Columns["level"].Distinct)
+	assert.Equal(t, 1.0, profile.Columns["msg_id"].NullRate)
+	require.NotNil(t, profile.Level)
+	assert.Equal(t, LevelProfile{Min: 20, Max: 100, Mean: 60}, *profile.Level)
+	assert.Equal(t, []ValueCount{{Value: "alarm", Count: 2}, {Value: "working", Count: 1}}, profile.TopClasses)
+
+	// Без строк - пустой профиль без диапазона level
+	empty := ProfileRows(nil)
+	assert.Nil(t, empty.Level)
+	assert.Equal(t, 0.0, empty.Columns["class"].NullRate)
+}
+
+// ---------- Validate ----------
+func TestValidate(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name string, content []byte) string {
+		path := filepath.Join(dir, name)
+		require.NoError(t, os.WriteFile(path, content, 0644))
+		return path
+	}
+
+	assert.NoError(t, Validate(write("ok.tsv", []byte("1\tG-044322\tтекст\n"))))
+	assert.ErrorIs(t, Validate(write("empty.tsv", nil)), ErrEmptyFile)
+	assert.ErrorIs(t, Validate(write("photo.tsv", []byte("\xff\xd8\xff\xe0\x00\x10JFIF\x00"))), ErrNotText)
+	assert.ErrorIs(t, Validate(write("latin1.tsv", []byte("1\tcaf\xe9\n"))), ErrInvalidEncoding)
+	assert.ErrorIs(t, Validate(write("commas.tsv", []byte("1,G-044322,text\n"))), ErrNoTabs)
+
+	// Формат и кодировка из sidecar-файла
+	assert.NoError(t, ValidateWithOptions(write("commas.csv", []byte("1,G-044322,text\n")), Options{Parser: ParserCSV}))
+	assert.ErrorIs(t, ValidateWithOptions(write("tabs.csv", []byte("1\tG-044322\n")), Options{Parser: ParserCSV}), ErrNoCommas)
+	assert.NoError(t, ValidateWithOptions(write("latin1.tsv", []byte("1\tcaf\xe9\n")), Options{Charset: "iso-8859-1"}))
+
+	// Многобайтовый символ на границе sniffLen не считается ошибкой кодировки
+	content := append([]byte("\t"), bytes.Repeat([]byte("a"), sniffLen-2)...)
+	content = append(content, []byte("ж\n")...)
+	assert.NoError(t, Validate(write("boundary.tsv", content)))
+}
+
+func TestHashFile(t *testing.T) {
+	path := createTestTSV(t, t.TempDir(), "hash.tsv", []string{"1\tG-044322"})
+
+	hash, err := HashFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "b9c29768fbd7fa0e450480614dd23fd40a723c90589dead019102baa6558dede", hash)
+
+	_, err = HashFile(filepath.Join(t.TempDir(), "missing.tsv"))
+	assert.Error(t, err)
+}
+
+func TestFirstUnitGuid(t *testing.T) {
+	dir := t.TempDir()
+	path := createTestTSV(t, dir, "first.tsv", []string{
+		"n\tmqtt\tinvid\tunit_guid",
+		"1\t\tG-1\tnot-a-guid",
+		"2\t\tG-1\t01749246-95f6-57db-b7c3-2ae0e8be671f",
+	})
+	guid, err := FirstUnitGuid(path)
+	require.NoError(t, err)
+	assert.Equal(t, "01749246-95f6-57db-b7c3-2ae0e8be671f", guid.String())
+
+	guid, err = FirstUnitGuid(createTestTSV(t, dir, "header.tsv", []string{
+		"unit_guid\tn",
+		"01749246-95f6-57db-b7c3-2ae0e8be671f\t1",
+	}))
+	require.NoError(t, err)
+	assert.Equal(t, "01749246-95f6-57db-b7c3-2ae0e8be671f", guid.String())
+
+	guid, err = FirstUnitGuid(createTestTSV(t, dir, "none.tsv", []string{"1\tG-044322"}))
+	require.NoError(t, err)
+	assert.Equal(t, uuid.Nil, guid)
+}
+
+func TestSplitTabs(t *testing.T) {
+	fields := make([]string, 0, fieldCount)
+	fields = splitTabs(fields, "1\t\tG-1\t")
+	assert.Equal(t, []string{"1", "", "G-1", ""}, fields)
+	fields = splitTabs(fields, "no tabs")
+	assert.Equal(t, []string{"no tabs"}, fields)
+	assert.Equal(t, strings.Split("a\tb\t\tc", "\t"), splitTabs(nil, "a\tb\t\tc"))
+}
+
+func TestStreamFile_Chunks(t *testing.T) {
+	lines := []string{"n\tmqtt\tinvid\tunit_guid\tmsg_id\ttext\tcontext\tclass\tlevel\tarea\taddr\tblock\ttype\tbit\tinvert_bit"}
+	for i := 1; i <= 25; i++ {
+		unitGuid := "01749246-95f6-57db-b7c3-2ae0e8be671f"
+		if i == 12 {
+			unitGuid = "not-a-guid"
+		}
+		lines = append(lines, fmt.Sprintf("%d\t\tG-044322\t%s\tmsg_%d\ttext\t\talarm\t100\tLOCAL\taddr\t\t\t\t", i, unitGuid, i))
+	}
+	path := createTestTSV(t, t.TempDir(), "stream.tsv", lines)
+
+	var sizes []int
+	var lineNumbers []int32
+	var errorLines []int32
+	err := StreamFile(context.Background(), path, Sampling{}, Options{}, 10, func(rows []Row, errors []RowError) error {
+		sizes = append(sizes, len(rows)+len(errors))
+		for _, row := range rows {
+			lineNumbers = append(lineNumbers, row.LineNumber)
+		}
+		for _, rerr := range errors {
+			errorLines = append(errorLines, rerr.LineNumber.Int32)
+		}
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []int{10, 10, 5}, sizes)
+	assert.Len(t, lineNumbers, 24)
+	assert.Equal(t, []int32{13}, errorLines)
+
+	// Ошибка обработчика прерывает разбор
+	calls := 0
+	err = StreamFile(context.Background(), path, Sampling{}, Options{}, 10, func([]Row, []RowError) error {
+		calls++
+		return fmt.Errorf("stop")
+	})
+	assert.EqualError(t, err, "stop")
+	assert.Equal(t, 1, calls)
+}
+
+// ---------- Benchmarks ----------
+
+// benchmarkFile - файл из rows строк данных для бенчмарков разбора
+func benchmarkFile(b *testing.B, rows int) string {
+	var buf bytes.Buffer
+	buf.WriteString("n\tmqtt\tinvid\tunit_guid\tmsg_id\ttext\tcontext\tclass\tlevel\tarea\taddr\tblock\ttype\tbit\tinvert_bit\n")
+	for i := 1; i <= rows; i++ {
+		fmt.Fprintf(&buf, "%d\t\tG-044322\t01749246-95f6-57db-b7c3-2ae0e8be671f\tcold7_Defrost_status_%d\tРазморозка\t\twaiting\t100\tLOCAL\tcold7_status.Defrost_status\t\t\t\t\n", i, i)
+	}
+	path := filepath.Join(b.TempDir(), "bench.tsv")
+	require.NoError(b, os.WriteFile(path, buf.Bytes(), 0644))
+	return path
+}
+
+func BenchmarkStreamFile(b *testing.B) {
+	path := benchmarkFile(b, 10000)
+	defer slog.SetDefault(slog.Default())
+	slog.SetDefault(slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+	b.ReportAllocs()
+	for b.Loop() {
+		err := StreamFile(context.Background(), path, Sampling{}, Options{}, 1000, func([]Row, []RowError) error {
+			return nil
+		})
+		require.NoError(b, err)
+	}
+}
+
+// BenchmarkSplit сравнивает разбиение строки с выделением среза на каждую
+// строку (strings.Split) и в переиспользуемый срез (splitTabs)
+func BenchmarkSplit(b *testing.B) {
+	line := "1\t\tG-044322\t01749246-95f6-57db-b7c3-2ae0e8be671f\tcold7_Defrost_status\tРазморозка\t\twaiting\t100\tLOCAL\tcold7_status.Defrost_status\t\t\t\t"
+	b.Run("strings.Split", func(b *testing.B) {
+		b.ReportAllocs()
+		for b.Loop() {
+			_ = strings.Split(line, "\t")
+		}
+	})
+	b.Run("splitTabs", func(b *testing.B) {
+		b.ReportAllocs()
+		fields := make([]string, 0, fieldCount)
+		for b.Loop() {
+			fields = splitTabs(fields, line)
+		}
+	})
+}
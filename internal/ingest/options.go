@@ -0,0 +1,107 @@
+// internal/ingest/options.go
+package ingest
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+
+	"golang.org/x/text/encoding/charmap"
+)
+
+// Форматы файлов (Options.Parser)
+const (
+	ParserTSV = "tsv" // поля разделены табуляцией (по умолчанию)
+	ParserCSV = "csv" // поля разделены запятой, значения могут быть в кавычках
+)
+
+// Parsers - допустимые форматы
+var Parsers = []string{ParserTSV, ParserCSV}
+
+// charsets - кодировки файлов, кроме UTF-8 (Options.Charset)
+var charsets = map[string]*charmap.Charmap{
+	"windows-1251": charmap.Windows1251,
+	"koi8-r":       charmap.KOI8R,
+	"iso-8859-1":   charmap.ISO8859_1,
+}
+
+// Charsets - допустимые кодировки
+var Charsets = []string{"utf-8", "windows-1251", "koi8-r", "iso-8859-1"}
+
+// Options - настройки разбора отдельного файла (по умолчанию - TSV в
+// UTF-8 со строгой проверкой полей). Задаются sidecar-файлом рядом с
+// файлом, чтобы особые выгрузки не требовали изменения общей конфигурации.
+type Options struct {
+	Parser  string `json:"parser,omitempty"`  // tsv или csv
+	Charset string `json:"charset,omitempty"` // utf-8, windows-1251, koi8-r, iso-8859-1
+
+	// Некорректные необязательные поля (class, level, bit, invert_bit)
+	// сохраняются как NULL, строка загружается; без unit_guid строка
+	// по-прежнему отклоняется
+	Lenient bool `json:"lenient,omitempty"`
+}
+
+// Validate проверяет формат и кодировку
+func (o Options) Validate() error {
+	if o.Parser != "" && !slices.Contains(Parsers, o.Parser) {
+		return fmt.Errorf("parser must be one of: %s", strings.Join(Parsers, ", "))
+	}
+	if o.Charset != "" && !slices.Contains(Charsets, strings.ToLower(o.Charset)) {
+		return fmt.Errorf("charset must be one of: %s", strings.Join(Charsets, ", "))
+	}
+	return nil
+}
+
+// IsZero сообщает, что используются настройки по умолчанию
+func (o Options) IsZero() bool {
+	return o == Options{}
+}
+
+func (o Options) String() string {
+	parts := []string{"parser " + o.parser(), "charset " + o.charset()}
+	if o.Lenient {
+		parts = append(parts, "lenient")
+	}
+	return strings.Join(parts, ", ")
+}
+
+func (o Options) parser() string {
+	if o.Parser == "" {
+		return ParserTSV
+	}
+	return o.Parser
+}
+
+func (o Options) charset() string {
+	if o.Charset == "" {
+		return "utf-8"
+	}
+	return strings.ToLower(o.Charset)
+}
+
+// decode перекодирует содержимое файла в UTF-8
+func (o Options) decode(r io.Reader) io.Reader {
+	if cm, ok := charsets[o.charset()]; ok {
+		return cm.NewDecoder().Reader(r)
+	}
+	return r
+}
+
+// split разбивает строку на поля (TSV - в dst, см. splitTabs). Строка
+// CSV с некорректными кавычками разбивается по запятым как есть, чтобы
+// ошибка была в конкретном поле.
+func (o Options) split(dst []string, line string) []string {
+	if o.parser() != ParserCSV {
+		return splitTabs(dst, line)
+	}
+	r := csv.NewReader(strings.NewReader(line))
+	r.FieldsPerRecord = -1
+	r.LazyQuotes = true
+	fields, err := r.Read()
+	if err != nil {
+		return strings.Split(line, ",")
+	}
+	return fields
+}
@@ -0,0 +1,43 @@
+// internal/ingest/pool.go
+package ingest
+
+import (
+	"bufio"
+	"strings"
+	"sync"
+)
+
+// fieldCount - колонок в строке файла (n ... invert_bit)
+const fieldCount = 15
+
+// scanBuffers - буферы сканера строк, переиспользуемые между файлами:
+// сканер сразу получает буфер максимального размера строки и не
+// перевыделяет его по мере роста строк
+var scanBuffers = sync.Pool{
+	New: func() interface{} {
+		buf := make([]byte, bufio.MaxScanTokenSize)
+		return &buf
+	},
+}
+
+// newLineScanner - сканер строк на буфере из пула; release возвращает
+// буфер после окончания чтения
+func newLineScanner(s *bufio.Scanner) (release func()) {
+	buf := scanBuffers.Get().(*[]byte)
+	s.Buffer(*buf, bufio.MaxScanTokenSize)
+	return func() { scanBuffers.Put(buf) }
+}
+
+// splitTabs разбивает строку по табуляции в dst. Поля - подстроки line
+// (без копирования), dst переиспользуется между строками файла.
+func splitTabs(dst []string, line string) []string {
+	dst = dst[:0]
+	for {
+		i := strings.IndexByte(line, '\t')
+		if i < 0 {
+			return append(dst, line)
+		}
+		dst = append(dst, line[:i])
+		line = line[i+1:]
+	}
+}
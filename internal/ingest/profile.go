@@ -0,0 +1,145 @@
+// internal/ingest/profile.go
+package ingest
+
+import (
+	"database/sql"
+	"sort"
+	"strconv"
+)
+
+// profileDistinctLimit - сколько различных значений колонки запоминается;
+// дальше distinct - нижняя оценка (distinct_capped)
+const profileDistinctLimit = 10000
+
+// profileTopClasses - сколько самых частых классов попадает в профиль
+const profileTopClasses = 10
+
+// ColumnProfile - распределение значений одной колонки
+type ColumnProfile struct {
+	Nulls          int64   `json:"nulls"`
+	NullRate       float64 `json:"null_rate"`
+	Distinct       int     `json:"distinct"`
+	DistinctCapped bool    `json:"distinct_capped,omitempty"`
+}
+
+// LevelProfile - диапазон level (null - ни одного значения)
+type LevelProfile struct {
+	Min  int32   `json:"min"`
+	Max  int32   `json:"max"`
+	Mean float64 `json:"mean"`
+}
+
+// ValueCount - значение и число строк с ним
+type ValueCount struct {
+	Value string `json:"value"`
+	Count int64  `json:"count"`
+}
+
+// Profile - профиль значений полей разобранных строк файла. Позволяет
+// быстро заметить, что отправитель сменил формат: например, начал
+// присылать level в других единицах или новые классы.
+type Profile struct {
+	Rows       int64                     `json:"rows"`
+	Columns    map[string]*ColumnProfile `json:"columns"`
+	Level      *LevelProfile             `json:"level"`
+	TopClasses []ValueCount              `json:"top_classes"`
+}
+
+// profileColumns - колонки профиля и их значения в строке ("" и false - NULL)
+var profileColumns = []struct {
+	name  string
+	value func(r Row) (string, bool)
+}{
+	{"unit_guid", func(r Row) (string, bool) { return r.UnitGuid.String(), true }},
+	{"invid", func(r Row) (string, bool) { return nullStringValue(r.Invid) }},
+	{"msg_id", func(r Row) (string, bool) { return nullStringValue(r.MsgID) }},
+	{"text", func(r Row) (string, bool) { return nullStringValue(r.Text) }},
+	{"class", func(r Row) (string, bool) { return nullStringValue(r.Class) }},
+	{"level", func(r Row) (string, bool) { return nullInt32Value(r.Level) }},
+	{"area", func(r Row) (string, bool) { return nullStringValue(r.Area) }},
+	{"addr", func(r Row) (string, bool) { return nullStringValue(r.Addr) }},
+	{"block", func(r Row) (string, bool) { return nullStringValue(r.Block) }},
+	{"type", func(r Row) (string, bool) { return nullStringValue(r.Type) }},
+	{"bit", func(r Row) (string, bool) { return nullInt32Value(r.Bit) }},
+	{"invert_bit", func(r Row) (string, bool) {
+		if !r.InvertBit.Valid {
+			return "", false
+		}
+		return strconv.FormatBool(r.InvertBit.Bool), true
+	}},
+}
+
+// ProfileRows считает профиль строк
+func ProfileRows(rows []Row) Profile {
+	profile := Profile{
+		Rows:       int64(len(rows)),
+		Columns:    make(map[string]*ColumnProfile, len(profileColumns)),
+		TopClasses: []ValueCount{},
+	}
+
+	for _, col := range profileColumns {
+		cp := &ColumnProfile{}
+		seen := make(map[string]struct{})
+		for _, row := range rows {
+			value, ok := col.value(row)
+			if !ok {
+				cp.Nulls++
+				continue
+			}
+			if len(seen) < profileDistinctLimit {
+				seen[value] = struct{}{}
+			} else if _, known := seen[value]; !known {
+				cp.DistinctCapped = true
+			}
+		}
+		cp.Distinct = len(seen)
+		if len(rows) > 0 {
+			cp.NullRate = float64(cp.Nulls) / float64(len(rows))
+		}
+		profile.Columns[col.name] = cp
+	}
+
+	var levelSum float64
+	var levels int64
+	classes := make(map[string]int64)
+	for _, row := range rows {
+		if row.Level.Valid {
+			level := row.Level.Int32
+			if profile.Level == nil {
+				profile.Level = &LevelProfile{Min: level, Max: level}
+			}
+			profile.Level.Min = min(profile.Level.Min, level)
+			profile.Level.Max = max(profile.Level.Max, level)
+			levelSum += float64(level)
+			levels++
+		}
+		if row.Class.Valid {
+			classes[row.Class.String]++
+		}
+	}
+	if profile.Level != nil {
+		profile.Level.Mean = levelSum / float64(levels)
+	}
+
+	for class, count := range classes {
+		profile.TopClasses = append(profile.TopClasses, ValueCount{Value: class, Count: count})
+	}
+	sort.Slice(profile.TopClasses, func(i, j int) bool {
+		if profile.TopClasses[i].Count != profile.TopClasses[j].Count {
+			return profile.TopClasses[i].Count > profile.TopClasses[j].Count
+		}
+		return profile.TopClasses[i].Value < profile.TopClasses[j].Value
+	})
+	if len(profile.TopClasses) > profileTopClasses {
+		profile.TopClasses = profile.TopClasses[:profileTopClasses]
+	}
+	return profile
+}
+
+func nullStringValue(v sql.NullString) (string, bool) {
+	return v.String, v.Valid
+}
+
+func nullInt32Value(v sql.NullInt32) (string, bool) {
+	return strconv.FormatInt(int64(v.Int32), 10), v.Valid
+}
@@ -0,0 +1,95 @@
+// internal/ingest/sampling.go
+package ingest
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// Sampling - выборка строк данных при разборе огромных файлов для
+// предварительной загрузки. Пустая Sampling - все строки. Строки
+// отбираются по порядковому номеру строки данных (без заголовка,
+// комментариев и пустых строк), поэтому повторная загрузка того же
+// файла с той же выборкой даёт те же строки.
+type Sampling struct {
+	Every   int     // каждая N-я строка данных, начиная с первой
+	Percent float64 // доля строк данных в процентах (0 < Percent <= 100)
+}
+
+// ParseSampling разбирает параметры выборки запроса (sample_every,
+// sample_percent). Пустые значения - без выборки; задать можно только один.
+func ParseSampling(every, percent string) (Sampling, error) {
+	var s Sampling
+	if every != "" && percent != "" {
+		return s, fmt.Errorf("sample_every and sample_percent are mutually exclusive")
+	}
+	if every != "" {
+		n, err := strconv.Atoi(every)
+		if err != nil || n < 1 {
+			return s, fmt.Errorf("sample_every must be a positive integer")
+		}
+		s.Every = n
+	}
+	if percent != "" {
+		p, err := strconv.ParseFloat(percent, 64)
+		if err != nil || p <= 0 || p > 100 {
+			return s, fmt.Errorf("sample_percent must be in (0, 100]")
+		}
+		s.Percent = p
+	}
+	return s, nil
+}
+
+// Validate проверяет значения, заданные не через ParseSampling (тело запроса)
+func (s Sampling) Validate() error {
+	switch {
+	case s.Every != 0 && s.Percent != 0:
+		return fmt.Errorf("sample_every and sample_percent are mutually exclusive")
+	case s.Every < 0:
+		return fmt.Errorf("sample_every must be a positive integer")
+	case s.Percent < 0 || s.Percent > 100:
+		return fmt.Errorf("sample_percent must be in (0, 100]")
+	}
+	return nil
+}
+
+// Enabled сообщает, обрабатывается ли только часть строк
+func (s Sampling) Enabled() bool {
+	return s.Rate() < 1
+}
+
+// Rate - доля обрабатываемых строк данных (1 - все строки)
+func (s Sampling) Rate() float64 {
+	switch {
+	case s.Every > 1:
+		return 1 / float64(s.Every)
+	case s.Percent > 0 && s.Percent < 100:
+		return s.Percent / 100
+	}
+	return 1
+}
+
+func (s Sampling) String() string {
+	switch {
+	case s.Every > 1:
+		return fmt.Sprintf("every %d lines", s.Every)
+	case s.Percent > 0 && s.Percent < 100:
+		return fmt.Sprintf("%g%% of lines", s.Percent)
+	}
+	return "all lines"
+}
+
+// keep решает, обрабатывать ли n-ю (с 1) строку данных. Для Percent
+// номер строки перемешивается (fibonacci hashing), чтобы выборка была
+// равномерной по файлу, а не первыми строками каждого блока.
+func (s Sampling) keep(n int64) bool {
+	switch {
+	case s.Every > 1:
+		return (n-1)%int64(s.Every) == 0
+	case s.Percent > 0 && s.Percent < 100:
+		h := uint64(n) * 0x9E3779B97F4A7C15
+		h ^= h >> 29
+		return h%10000 < uint64(s.Percent*100)
+	}
+	return true
+}
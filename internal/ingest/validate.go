@@ -0,0 +1,92 @@
+// internal/ingest/validate.go
+package ingest
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"unicode/utf8"
+)
+
+// sniffLen - объём начала файла, по которому определяется тип содержимого
+const sniffLen = 1024
+
+// Причины отклонения файла до разбора
+var (
+	ErrEmptyFile       = errors.New("file is empty")
+	ErrNotText         = errors.New("file is not a text file")
+	ErrInvalidEncoding = errors.New("file is not valid UTF-8")
+	ErrNoTabs          = errors.New("no tab separators found")
+	ErrNoCommas        = errors.New("no comma separators found")
+)
+
+// Validate проверяет, что файл похож на TSV: не пустой, текстовый,
+// в кодировке UTF-8 и содержит табуляции в первом килобайте.
+func Validate(filePath string) error {
+	return ValidateWithOptions(filePath, Options{})
+}
+
+// ValidateWithOptions проверяет файл с учётом формата и кодировки
+// (Options): для однобайтовых кодировок проверяется только отсутствие
+// нулевых байтов, для csv - наличие запятых вместо табуляций.
+func ValidateWithOptions(filePath string, opts Options) error {
+	f, err := os.Open(filePath)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	head := make([]byte, sniffLen)
+	n, err := io.ReadFull(f, head)
+	if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) && !errors.Is(err, io.EOF) {
+		return err
+	}
+	head = head[:n]
+
+	if n == 0 {
+		return ErrEmptyFile
+	}
+
+	if opts.charset() != "utf-8" {
+		if bytes.IndexByte(head, 0) >= 0 {
+			return ErrNotText
+		}
+		return checkSeparators(head, opts)
+	}
+
+	contentType := http.DetectContentType(head)
+	if !strings.HasPrefix(contentType, "text/") || bytes.IndexByte(head, 0) >= 0 {
+		return fmt.Errorf("%w (detected %s)", ErrNotText, contentType)
+	}
+	if !strings.HasSuffix(contentType, "charset=utf-8") {
+		return fmt.Errorf("%w (detected %s)", ErrInvalidEncoding, contentType)
+	}
+
+	// Последний символ мог быть обрезан границей sniffLen
+	if n == sniffLen {
+		for i := 0; i < utf8.UTFMax-1 && !utf8.Valid(head); i++ {
+			head = head[:len(head)-1]
+		}
+	}
+	if !utf8.Valid(head) {
+		return ErrInvalidEncoding
+	}
+
+	return checkSeparators(head, opts)
+}
+
+// checkSeparators - в начале файла есть разделители полей формата
+func checkSeparators(head []byte, opts Options) error {
+	sep, errNoSep := byte('\t'), ErrNoTabs
+	if opts.parser() == ParserCSV {
+		sep, errNoSep = ',', ErrNoCommas
+	}
+	if bytes.IndexByte(head, sep) < 0 {
+		return fmt.Errorf("%w in the first %d bytes", errNoSep, len(head))
+	}
+	return nil
+}
@@ -0,0 +1,178 @@
+// internal/jobs/manager.go
+package jobs
+
+import (
+	"TSVProcessingService/db/sqlc"
+	"TSVProcessingService/internal/metrics"
+	"context"
+	"database/sql"
+	"errors"
+	"log/slog"
+	"time"
+)
+
+// Виды заданий
+const (
+	KindFile     = "file"     // обработка файла воркером
+	KindReport   = "report"   // генерация PDF-отчётов
+	KindCleanup  = "cleanup"  // ежедневная очистка старых записей
+	KindArchive  = "archive"  // архивация device_data в Parquet
+	KindBackfill = "backfill" // повторная выгрузка данных (ClickHouse replay)
+)
+
+// Состояния задания
+const (
+	StateQueued    = "queued"    // создано или ждёт повторной попытки
+	StateRunning   = "running"   // выполняется
+	StateSucceeded = "succeeded" // завершено успешно
+	StateFailed    = "failed"    // завершено с ошибкой (попытки исчерпаны)
+	StateCancelled = "cancelled" // контекст отменён (оператором или при остановке)
+)
+
+// Kinds и States - допустимые значения фильтров GET /jobs
+var (
+	Kinds  = []string{KindFile, KindReport, KindCleanup, KindArchive, KindBackfill}
+	States = []string{StateQueued, StateRunning, StateSucceeded, StateFailed, StateCancelled}
+)
+
+// bookkeepingTimeout - таймаут записи состояния задания в БД
+const bookkeepingTimeout = 5 * time.Second
+
+var jobsFinished = metrics.Default.NewCounter("tsv_jobs_total",
+	"Finished jobs by kind and final state", "kind", "state")
+
+// Spec описывает задание
+type Spec struct {
+	Kind        string
+	Subject     string        // объект задания: имя файла, unit_guid, период
+	Owner       string        // исполнитель: worker-N, report-worker-N, scheduler, api
+	Priority    int32         // больше - важнее
+	MaxAttempts int           // 0 или 1 - без повторов
+	RetryDelay  time.Duration // пауза между попытками
+}
+
+// Manager выполняет задания и ведёт их журнал в таблице jobs. Ошибки
+// записи журнала не мешают выполнению: задание просто не попадёт в
+// GET /jobs. Нулевой *Manager выполняет задания без журнала.
+type Manager struct {
+	queries *sqlc.Queries
+	shares  *PoolShares
+}
+
+// NewManager создаёт Manager
+func NewManager(queries *sqlc.Queries) *Manager {
+	return &Manager{queries: queries}
+}
+
+// SetPoolShares ограничивает задания долями пула БД по виду задания:
+// попытка ждёт свободного места в состоянии queued
+func (m *Manager) SetPoolShares(shares *PoolShares) {
+	m.shares = shares
+}
+
+// Run выполняет fn как задание spec: создаёт запись, отмечает каждую
+// попытку и итоговое состояние. Ошибка fn повторяется до MaxAttempts
+// раз, если контекст ещё не завершён. Возвращает ошибку последней попытки.
+func (m *Manager) Run(ctx context.Context, spec Spec, fn func(ctx context.Context) error) error {
+	if m == nil {
+		return fn(ctx)
+	}
+
+	attempts := spec.MaxAttempts
+	if attempts < 1 {
+		attempts = 1
+	}
+	id := m.create(spec, attempts)
+
+	for attempt := 1; ; attempt++ {
+		release, err := m.shares.Acquire(ctx, spec.Kind)
+		if err != nil {
+			state := StateFailed
+			if errors.Is(err, context.Canceled) {
+				state = StateCancelled
+			}
+			m.finish(id, spec.Kind, state, err)
+			return err
+		}
+		m.exec(id, "start", func(ctx context.Context) error {
+			return m.queries.StartJob(ctx, sqlc.StartJobParams{ID: id, Owner: spec.Owner})
+		})
+
+		err = fn(ctx)
+		release()
+		switch {
+		case err == nil:
+			m.finish(id, spec.Kind, StateSucceeded, nil)
+			return nil
+		case errors.Is(ctx.Err(), context.Canceled):
+			m.finish(id, spec.Kind, StateCancelled, err)
+			return err
+		case attempt >= attempts || ctx.Err() != nil:
+			m.finish(id, spec.Kind, StateFailed, err)
+			return err
+		}
+
+		slog.Warn("Job failed, retrying", "component", "jobs", "kind", spec.Kind, "subject", spec.Subject, "attempt", attempt, "attempts", attempts, "retry_delay", spec.RetryDelay, "error", err)
+		m.exec(id, "retry", func(ctx context.Context) error {
+			return m.queries.RetryJob(ctx, sqlc.RetryJobParams{ID: id, LastError: nullString(err)})
+		})
+		select {
+		case <-time.After(spec.RetryDelay):
+		case <-ctx.Done():
+			m.finish(id, spec.Kind, StateFailed, err)
+			return err
+		}
+	}
+}
+
+// Recover помечает failed задания, прерванные остановкой сервиса
+// (queued/running на момент запуска). Вызывается до запуска воркеров.
+func (m *Manager) Recover(ctx context.Context) (int64, error) {
+	return m.queries.FailInterruptedJobs(ctx)
+}
+
+// create создаёт запись о задании; 0 - запись не создана
+func (m *Manager) create(spec Spec, attempts int) int64 {
+	ctx, cancel := context.WithTimeout(context.Background(), bookkeepingTimeout)
+	defer cancel()
+
+	job, err := m.queries.CreateJob(ctx, sqlc.CreateJobParams{
+		Kind:        spec.Kind,
+		Subject:     spec.Subject,
+		Priority:    spec.Priority,
+		Owner:       spec.Owner,
+		MaxAttempts: int32(attempts),
+	})
+	if err != nil {
+		slog.Error("Failed to record job", "component", "jobs", "kind", spec.Kind, "subject", spec.Subject, "error", err)
+		return 0
+	}
+	return job.ID
+}
+
+// finish записывает итоговое состояние задания
+func (m *Manager) finish(id int64, kind, state string, err error) {
+	jobsFinished.Inc(kind, state)
+	m.exec(id, "finish", func(ctx context.Context) error {
+		return m.queries.FinishJob(ctx, sqlc.FinishJobParams{ID: id, State: state, LastError: nullString(err)})
+	})
+}
+
+// exec выполняет запись журнала (контекст задания может быть уже отменён)
+func (m *Manager) exec(id int64, action string, fn func(ctx context.Context) error) {
+	if id == 0 {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), bookkeepingTimeout)
+	defer cancel()
+	if err := fn(ctx); err != nil {
+		slog.Error("Failed to update job", "component", "jobs", "action", action, "job", id, "error", err)
+	}
+}
+
+func nullString(err error) sql.NullString {
+	if err == nil {
+		return sql.NullString{}
+	}
+	return sql.NullString{String: err.Error(), Valid: true}
+}
@@ -0,0 +1,199 @@
+package jobs
+
+import (
+	"TSVProcessingService/db/sqlc"
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	_ "modernc.org/sqlite"
+)
+
+func setupTestManager(t *testing.T) (*Manager, *sqlc.Queries) {
+	db, err := sql.Open("sqlite", ":memory:")
+	require.NoError(t, err)
+	db.SetMaxOpenConns(1)
+	t.Cleanup(func() { db.Close() })
+
+	_, err = db.Exec(`
+	CREATE TABLE jobs (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		kind TEXT NOT NULL,
+		subject TEXT NOT NULL DEFAULT '',
+		state TEXT NOT NULL DEFAULT 'queued',
+		priority INTEGER NOT NULL DEFAULT 0,
+		owner TEXT NOT NULL DEFAULT '',
+		attempts INTEGER NOT NULL DEFAULT 0,
+		max_attempts INTEGER NOT NULL DEFAULT 1,
+		last_error TEXT,
+		created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+		started_at DATETIME,
+		finished_at DATETIME,
+		updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		change_seq INTEGER NOT NULL DEFAULT 0
+	);`)
+	require.NoError(t, err)
+
+	queries := sqlc.New(db)
+	return NewManager(queries), queries
+}
+
+func TestRun_Succeeded(t *testing.T) {
+	m, queries := setupTestManager(t)
+	ctx := context.Background()
+
+	err := m.Run(ctx, Spec{Kind: KindCleanup, Owner: "scheduler", Priority: 2}, func(ctx context.Context) error {
+		return nil
+	})
+	require.NoError(t, err)
+
+	job, err := queries.GetJob(ctx, 1)
+	require.NoError(t, err)
+	assert.Equal(t, KindCleanup, job.Kind)
+	assert.Equal(t, StateSucceeded, job.State)
+	assert.Equal(t, "scheduler", job.Owner)
+	assert.Equal(t, int32(2), job.Priority)
+	assert.Equal(t, int32(1), job.Attempts)
+	assert.True(t, job.StartedAt.Valid)
+	assert.True(t, job.FinishedAt.Valid)
+	assert.False(t, job.LastError.Valid)
+}
+
+func TestRun_RetriesUntilSuccess(t *testing.T) {
+	m, queries := setupTestManager(t)
+	ctx := context.Background()
+
+	calls := 0
+	err := m.Run(ctx, Spec{Kind: KindArchive, MaxAttempts: 3}, func(ctx context.Context) error {
+		calls++
+		if calls < 2 {
+			return errors.New("storage unavailable")
+		}
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 2, calls)
+
+	job, err := queries.GetJob(ctx, 1)
+	require.NoError(t, err)
+	assert.Equal(t, StateSucceeded, job.State)
+	assert.Equal(t, int32(2), job.Attempts)
+	assert.Equal(t, int32(3), job.MaxAttempts)
+	assert.Equal(t, "storage unavailable", job.LastError.String, "error of the failed attempt is kept")
+}
+
+func TestRun_FailsAfterLastAttempt(t *testing.T) {
+	m, queries := setupTestManager(t)
+	ctx := context.Background()
+
+	calls := 0
+	err := m.Run(ctx, Spec{Kind: KindFile, Subject: "bad.tsv", MaxAttempts: 2}, func(ctx context.Context) error {
+		calls++
+		return errors.New("broken")
+	})
+	require.EqualError(t, err, "broken")
+	assert.Equal(t, 2, calls)
+
+	job, err := queries.GetJob(ctx, 1)
+	require.NoError(t, err)
+	assert.Equal(t, StateFailed, job.State)
+	assert.Equal(t, "bad.tsv", job.Subject)
+	assert.Equal(t, int32(2), job.Attempts)
+	assert.Equal(t, "broken", job.LastError.String)
+}
+
+func TestRun_Cancelled(t *testing.T) {
+	m, queries := setupTestManager(t)
+	ctx, cancel := context.WithCancel(context.Background())
+
+	err := m.Run(ctx, Spec{Kind: KindFile, MaxAttempts: 3}, func(ctx context.Context) error {
+		cancel()
+		return ctx.Err()
+	})
+	require.ErrorIs(t, err, context.Canceled)
+
+	job, err := queries.GetJob(context.Background(), 1)
+	require.NoError(t, err)
+	assert.Equal(t, StateCancelled, job.State)
+	assert.Equal(t, int32(1), job.Attempts)
+}
+
+func TestRun_WaitsForPoolShare(t *testing.T) {
+	m, queries := setupTestManager(t)
+	m.SetPoolShares(NewPoolShares(4, map[string]float64{KindCleanup: 0.25}))
+	ctx := context.Background()
+
+	started, finish := make(chan struct{}), make(chan struct{})
+	done := make(chan error, 1)
+	go func() {
+		done <- m.Run(ctx, Spec{Kind: KindCleanup}, func(ctx context.Context) error {
+			close(started)
+			<-finish
+			return nil
+		})
+	}()
+	<-started
+
+	// Единственное место очистки занято: второе задание не запускается
+	waitCtx, cancel := context.WithTimeout(ctx, 50*time.Millisecond)
+	defer cancel()
+	called := false
+	err := m.Run(waitCtx, Spec{Kind: KindCleanup}, func(ctx context.Context) error {
+		called = true
+		return nil
+	})
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.False(t, called)
+
+	// Виды без доли не ограничиваются
+	require.NoError(t, m.Run(ctx, Spec{Kind: KindFile}, func(ctx context.Context) error { return nil }))
+
+	close(finish)
+	require.NoError(t, <-done)
+
+	job, err := queries.GetJob(ctx, 2)
+	require.NoError(t, err)
+	assert.Equal(t, StateFailed, job.State)
+	assert.Equal(t, int32(0), job.Attempts)
+}
+
+func TestNewPoolShares(t *testing.T) {
+	s := NewPoolShares(25, map[string]float64{KindReport: 0.25, KindCleanup: 0.01})
+	assert.Equal(t, 6, s.Limit(KindReport))
+	assert.Equal(t, 1, s.Limit(KindCleanup))
+	assert.Equal(t, 0, s.Limit(KindFile))
+
+	assert.Equal(t, 0, NewPoolShares(0, map[string]float64{KindReport: 0.5}).Limit(KindReport))
+}
+
+func TestRun_NilManager(t *testing.T) {
+	var m *Manager
+	called := false
+	require.NoError(t, m.Run(context.Background(), Spec{Kind: KindReport}, func(ctx context.Context) error {
+		called = true
+		return nil
+	}))
+	assert.True(t, called)
+}
+
+func TestRecover(t *testing.T) {
+	m, queries := setupTestManager(t)
+	ctx := context.Background()
+
+	_, err := queries.CreateJob(ctx, sqlc.CreateJobParams{Kind: KindFile, Subject: "a.tsv", MaxAttempts: 1})
+	require.NoError(t, err)
+	require.NoError(t, m.Run(ctx, Spec{Kind: KindCleanup}, func(ctx context.Context) error { return nil }))
+
+	n, err := m.Recover(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), n)
+
+	job, err := queries.GetJob(ctx, 1)
+	require.NoError(t, err)
+	assert.Equal(t, StateFailed, job.State)
+	assert.Equal(t, "interrupted by service restart", job.LastError.String)
+}
@@ -0,0 +1,84 @@
+// internal/jobs/shares.go
+package jobs
+
+import (
+	"context"
+	"log/slog"
+	"math"
+	"sort"
+)
+
+// SubsystemStatistics - подсчёт использования хранилища (размеры таблиц,
+// устройств и источников); остальные подсистемы совпадают с видами заданий
+const SubsystemStatistics = "statistics"
+
+// PoolSubsystems - фоновые подсистемы, доля пула БД которых ограничивается
+// (database.pool_shares). Обработка файлов и API не ограничиваются.
+var PoolSubsystems = []string{KindCleanup, KindReport, KindArchive, KindBackfill, SubsystemStatistics}
+
+// PoolShares ограничивает число одновременных обращений фоновой подсистемы
+// к БД долей пула соединений, чтобы очистка или отчёты не занимали все
+// соединения и не останавливали загрузку файлов. Нулевой *PoolShares
+// ничего не ограничивает.
+type PoolShares struct {
+	slots map[string]chan struct{}
+}
+
+// NewPoolShares создаёт ограничения: подсистеме достаётся floor(maxConns *
+// share) соединений, но не меньше одного. maxConns <= 0 (пул без
+// ограничения) - ограничений нет.
+func NewPoolShares(maxConns int, shares map[string]float64) *PoolShares {
+	s := &PoolShares{slots: make(map[string]chan struct{})}
+	if maxConns <= 0 {
+		return s
+	}
+	for subsystem, share := range shares {
+		if share <= 0 {
+			continue
+		}
+		limit := max(int(math.Floor(float64(maxConns)*share)), 1)
+		s.slots[subsystem] = make(chan struct{}, limit)
+	}
+	return s
+}
+
+// Limit - число соединений подсистемы; 0 - без ограничения
+func (s *PoolShares) Limit(subsystem string) int {
+	if s == nil {
+		return 0
+	}
+	return cap(s.slots[subsystem])
+}
+
+// Acquire ждёт свободного места подсистемы. Возвращённая функция
+// освобождает место; ошибка - контекст завершён раньше.
+func (s *PoolShares) Acquire(ctx context.Context, subsystem string) (func(), error) {
+	if s == nil {
+		return func() {}, nil
+	}
+	slots, ok := s.slots[subsystem]
+	if !ok {
+		return func() {}, nil
+	}
+	select {
+	case slots <- struct{}{}:
+		return func() { <-slots }, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// LogLimits выводит ограничения подсистем
+func (s *PoolShares) LogLimits() {
+	if s == nil || len(s.slots) == 0 {
+		return
+	}
+	subsystems := make([]string, 0, len(s.slots))
+	for subsystem := range s.slots {
+		subsystems = append(subsystems, subsystem)
+	}
+	sort.Strings(subsystems)
+	for _, subsystem := range subsystems {
+		slog.Info("🚦 Database connection limit", "component", "jobs", "subsystem", subsystem, "limit", s.Limit(subsystem))
+	}
+}
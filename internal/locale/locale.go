@@ -0,0 +1,140 @@
+// internal/locale/locale.go
+package locale
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Языки форматирования отчётов
+const (
+	English = "en"
+	Russian = "ru"
+	German  = "de"
+)
+
+// style - формат дат и разделитель разрядов языка
+type style struct {
+	date      string // layout даты
+	dateTime  string // layout даты и времени (с часовым поясом)
+	thousands string // разделитель групп разрядов
+}
+
+// styles - поддерживаемые языки. Подписи отчёта остаются английскими:
+// встроенные шрифты PDF не содержат кириллицы, поэтому для ru разделитель
+// разрядов - обычный пробел, а не неразрывный.
+var styles = map[string]style{
+	English: {date: "Jan 2, 2006", dateTime: "Jan 2, 2006 15:04:05 MST", thousands: ","},
+	Russian: {date: "02.01.2006", dateTime: "02.01.2006 15:04:05 MST", thousands: " "},
+	German:  {date: "02.01.2006", dateTime: "02.01.2006 15:04:05 MST", thousands: "."},
+}
+
+// Supported - поддерживается ли язык
+func Supported(lang string) bool {
+	_, ok := styles[lang]
+	return ok
+}
+
+// Match выбирает поддерживаемый язык по заголовку Accept-Language
+// (с учётом q-весов; "ru-RU" соответствует "ru"). "" - ни один не подходит.
+func Match(acceptLanguage string) string {
+	type candidate struct {
+		lang string
+		q    float64
+	}
+	var candidates []candidate
+	for _, part := range strings.Split(acceptLanguage, ",") {
+		tag, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		q := 1.0
+		if v, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			parsed, err := strconv.ParseFloat(v, 64)
+			if err != nil {
+				continue
+			}
+			q = parsed
+		}
+		base, _, _ := strings.Cut(strings.ToLower(tag), "-")
+		if Supported(base) && q > 0 {
+			candidates = append(candidates, candidate{lang: base, q: q})
+		}
+	}
+	// Порядок заголовка сохраняется для равных весов
+	sort.SliceStable(candidates, func(i, j int) bool { return candidates[i].q > candidates[j].q })
+	if len(candidates) == 0 {
+		return ""
+	}
+	return candidates[0].lang
+}
+
+// Formatter форматирует даты (в часовом поясе отчёта) и числа по языку
+type Formatter struct {
+	style    style
+	location *time.Location
+}
+
+// New создаёт форматтер (неизвестный язык - en, loc nil - UTC)
+func New(lang string, loc *time.Location) Formatter {
+	s, ok := styles[lang]
+	if !ok {
+		s = styles[English]
+	}
+	if loc == nil {
+		loc = time.UTC
+	}
+	return Formatter{style: s, location: loc}
+}
+
+// Location - часовой пояс отчёта
+func (f Formatter) Location() *time.Location {
+	if f.location == nil {
+		return time.UTC
+	}
+	return f.location
+}
+
+// Date - дата без времени
+func (f Formatter) Date(t time.Time) string {
+	return t.In(f.Location()).Format(f.layout(f.style.date, "2006-01-02"))
+}
+
+// DateTime - дата, время и часовой пояс
+func (f Formatter) DateTime(t time.Time) string {
+	return t.In(f.Location()).Format(f.layout(f.style.dateTime, time.RFC3339))
+}
+
+// layout - формат языка (нулевой Formatter - ISO-формат)
+func (f Formatter) layout(layout, fallback string) string {
+	if layout == "" {
+		return fallback
+	}
+	return layout
+}
+
+// Int - целое с разделителями разрядов: 1,234,567 / 1 234 567 / 1.234.567
+func (f Formatter) Int(n int64) string {
+	digits := strconv.FormatInt(n, 10)
+	sign := ""
+	if n < 0 {
+		sign, digits = "-", digits[1:]
+	}
+	sep := f.style.thousands
+	if sep == "" || len(digits) <= 3 {
+		return sign + digits
+	}
+
+	var b strings.Builder
+	b.WriteString(sign)
+	head := len(digits) % 3
+	if head > 0 {
+		b.WriteString(digits[:head])
+	}
+	for i := head; i < len(digits); i += 3 {
+		if i > 0 {
+			b.WriteString(sep)
+		}
+		b.WriteString(digits[i : i+3])
+	}
+	return b.String()
+}
@@ -0,0 +1,47 @@
+package locale
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMatch(t *testing.T) {
+	assert.Equal(t, "ru", Match("ru-RU,ru;q=0.9,en-US;q=0.8"))
+	assert.Equal(t, "de", Match("fr-FR, de;q=0.7, en;q=0.5"))
+	assert.Equal(t, "en", Match("en;q=0.3, de;q=0"))
+	assert.Equal(t, "", Match("fr, ja"))
+	assert.Equal(t, "", Match(""))
+}
+
+func TestFormatter_Int(t *testing.T) {
+	cases := []struct {
+		lang string
+		n    int64
+		want string
+	}{
+		{English, 1234567, "1,234,567"},
+		{Russian, 1234567, "1 234 567"},
+		{German, 1234567, "1.234.567"},
+		{English, 999, "999"},
+		{English, -12345, "-12,345"},
+		{English, 100000, "100,000"},
+	}
+	for _, c := range cases {
+		assert.Equal(t, c.want, New(c.lang, nil).Int(c.n), "%s %d", c.lang, c.n)
+	}
+}
+
+func TestFormatter_DateTimeInLocation(t *testing.T) {
+	msk := time.FixedZone("MSK", 3*3600)
+	ts := time.Date(2026, 3, 1, 22, 30, 0, 0, time.UTC)
+
+	ru := New(Russian, msk)
+	assert.Equal(t, "02.03.2026 01:30:00 MSK", ru.DateTime(ts))
+	assert.Equal(t, "02.03.2026", ru.Date(ts))
+
+	en := New(English, nil)
+	assert.Equal(t, "Mar 1, 2026 22:30:00 UTC", en.DateTime(ts))
+	assert.Equal(t, "Mar 1, 2026", New("xx", nil).Date(ts))
+}
@@ -0,0 +1,86 @@
+// internal/logger/logger.go
+package logger
+
+import (
+	"TSVProcessingService/internal/config"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+)
+
+// Форматы и назначения журнала (logging.format, logging.output)
+const (
+	FormatJSON = "json"
+	FormatText = "text"
+
+	OutputStdout = "stdout"
+	OutputStderr = "stderr"
+	OutputFile   = "file" // logging.file_path с ротацией
+)
+
+// ParseLevel - уровень журнала по logging.level (debug, info, warn, error)
+func ParseLevel(level string) (slog.Level, error) {
+	var l slog.Level
+	if err := l.UnmarshalText([]byte(level)); err != nil {
+		return 0, fmt.Errorf("unknown log level %q", level)
+	}
+	return l, nil
+}
+
+// Setup настраивает журнал приложения по logging: формат (JSON или
+// text), минимальный уровень и назначение (stdout, stderr или файл с
+// ротацией). Компоненты получают журнал с атрибутом component (см.
+// Component) и пишут с явным уровнем. Журнал становится журналом slog по
+// умолчанию: вывод пакетов, пишущих через log, попадает в него с уровнем
+// info. Возвращает журнал и функцию закрытия файла журнала.
+func Setup(cfg config.LoggingConfig) (*slog.Logger, func() error, error) {
+	level, err := ParseLevel(cfg.Level)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var out io.Writer
+	closeFn := func() error { return nil }
+	switch cfg.Output {
+	case "", OutputStdout:
+		out = os.Stdout
+	case OutputStderr:
+		out = os.Stderr
+	case OutputFile:
+		file, err := OpenRotatingFile(cfg.FilePath, cfg.MaxSizeMB, cfg.MaxBackups, cfg.MaxAgeDays)
+		if err != nil {
+			return nil, nil, err
+		}
+		out, closeFn = file, file.Close
+	default:
+		return nil, nil, fmt.Errorf("unknown log output %q", cfg.Output)
+	}
+
+	handler, err := NewHandler(out, cfg.Format, level)
+	if err != nil {
+		closeFn()
+		return nil, nil, err
+	}
+	l := slog.New(handler)
+	slog.SetDefault(l)
+	return l, closeFn, nil
+}
+
+// NewHandler - обработчик slog в формате logging.format
+func NewHandler(out io.Writer, format string, level slog.Level) (slog.Handler, error) {
+	opts := &slog.HandlerOptions{Level: level}
+	switch format {
+	case "", FormatJSON:
+		return slog.NewJSONHandler(out, opts), nil
+	case FormatText:
+		return slog.NewTextHandler(out, opts), nil
+	}
+	return nil, fmt.Errorf("unknown log format %q", format)
+}
+
+// Component - журнал компонента: записи получают атрибут component
+// (processor, watcher, api и т. п.)
+func Component(l *slog.Logger, name string) *slog.Logger {
+	return l.With("component", name)
+}
@@ -0,0 +1,101 @@
+package logger
+
+import (
+	"TSVProcessingService/internal/config"
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestComponent_JSON(t *testing.T) {
+	var buf bytes.Buffer
+	handler, err := NewHandler(&buf, FormatJSON, slog.LevelDebug)
+	require.NoError(t, err)
+	l := Component(slog.New(handler), "processor")
+
+	l.Debug("Created file record", "file_id", 7)
+	l.Warn("No errors found, nothing to reject", "file", "a.tsv")
+	l.Error("Failed to archive file", "file", "a.tsv", "error", "disk full")
+
+	records := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	require.Len(t, records, 3)
+	var got []map[string]interface{}
+	for _, record := range records {
+		var m map[string]interface{}
+		require.NoError(t, json.Unmarshal([]byte(record), &m))
+		got = append(got, m)
+	}
+	// Уровень задаётся вызовом, а не текстом сообщения
+	assert.Equal(t, "DEBUG", got[0]["level"])
+	assert.Equal(t, float64(7), got[0]["file_id"])
+	assert.Equal(t, "WARN", got[1]["level"])
+	assert.Equal(t, "ERROR", got[2]["level"])
+	for _, m := range got {
+		assert.Equal(t, "processor", m["component"])
+	}
+}
+
+func TestSetup_LevelFiltersRecords(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "service.log")
+	l, closeFn, err := Setup(config.LoggingConfig{Level: "warn", Format: FormatText, Output: OutputFile, FilePath: path})
+	require.NoError(t, err)
+	defer slog.SetDefault(slog.New(slog.NewTextHandler(os.Stderr, nil)))
+
+	l.Info("dropped")
+	l.Warn("kept")
+	require.NoError(t, closeFn())
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.NotContains(t, string(data), "dropped")
+	assert.Contains(t, string(data), "level=WARN msg=kept")
+}
+
+func TestNewHandler_UnknownFormat(t *testing.T) {
+	_, err := NewHandler(&bytes.Buffer{}, "xml", slog.LevelInfo)
+	assert.EqualError(t, err, `unknown log format "xml"`)
+	_, err = ParseLevel("verbose")
+	assert.Error(t, err)
+}
+
+func TestRotatingFile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "service.log")
+	f, err := OpenRotatingFile(path, 1, 2, 0)
+	require.NoError(t, err)
+	defer f.Close()
+
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	f.now = func() time.Time {
+		now = now.Add(time.Second)
+		return now
+	}
+
+	// Каждая запись чуть больше половины лимита: ротация перед каждой второй
+	record := bytes.Repeat([]byte("x"), 600<<10)
+	for i := 0; i < 5; i++ {
+		_, err := f.Write(record)
+		require.NoError(t, err)
+	}
+
+	backups := f.backups()
+	assert.Equal(t, []string{"service-2026-03-01T12-00-04.000.log", "service-2026-03-01T12-00-03.000.log"}, backups)
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	assert.Equal(t, int64(len(record)), info.Size())
+
+	// Копии старше max_age удаляются при ротации
+	f.maxAge = time.Hour
+	now = now.Add(2 * time.Hour)
+	_, err = f.Write(record)
+	require.NoError(t, err)
+	assert.Len(t, f.backups(), 1)
+}
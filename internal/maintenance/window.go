@@ -0,0 +1,164 @@
+// internal/maintenance/window.go
+package maintenance
+
+import (
+	"context"
+	"time"
+)
+
+// maxLookahead - горизонт поиска следующего окна (годовые расписания)
+const maxLookahead = 366 * 24 * time.Hour
+
+// Window - окно обслуживания: начинается по расписанию и длится Duration
+type Window struct {
+	Name           string
+	Schedule       Schedule
+	Duration       time.Duration
+	PauseIngestion bool // новые файлы не ставятся в очередь
+	SuppressAlerts bool // оповещения и инциденты не отправляются
+	HeavyTasks     bool // очистка и архивация откладываются до этого окна
+}
+
+// activeSince - начало текущего периода окна (false - окно не активно)
+func (w Window) activeSince(now time.Time) (time.Time, bool) {
+	// Первое начало, после которого окно ещё длится: ищем от now-Duration
+	start := w.Schedule.Next(now.Add(-w.Duration), now)
+	if start.IsZero() {
+		return time.Time{}, false
+	}
+	return start, true
+}
+
+// nextStart - ближайшее начало окна после now (false - не найдено)
+func (w Window) nextStart(now time.Time) (time.Time, bool) {
+	start := w.Schedule.Next(now, now.Add(maxLookahead))
+	return start, !start.IsZero()
+}
+
+// WindowStatus - состояние окна для /health и админ-панели
+type WindowStatus struct {
+	Name           string     `json:"name"`
+	Schedule       string     `json:"schedule"`
+	Duration       string     `json:"duration"`
+	Active         bool       `json:"active"`
+	Until          *time.Time `json:"until,omitempty"` // конец текущего периода
+	NextStart      *time.Time `json:"next_start,omitempty"`
+	PauseIngestion bool       `json:"pause_ingestion"`
+	SuppressAlerts bool       `json:"suppress_alerts"`
+	HeavyTasks     bool       `json:"heavy_tasks"`
+}
+
+// Status - сводное состояние окон обслуживания
+type Status struct {
+	Active           bool           `json:"active"`
+	IngestionPaused  bool           `json:"ingestion_paused"`
+	AlertsSuppressed bool           `json:"alerts_suppressed"`
+	Windows          []WindowStatus `json:"windows"`
+}
+
+// Calendar - набор окон обслуживания в часовом поясе расписаний
+type Calendar struct {
+	windows  []Window
+	location *time.Location
+	now      func() time.Time
+}
+
+// NewCalendar создаёт календарь окон (loc nil - UTC)
+func NewCalendar(windows []Window, loc *time.Location) *Calendar {
+	if loc == nil {
+		loc = time.UTC
+	}
+	return &Calendar{windows: windows, location: loc, now: time.Now}
+}
+
+// active - окна, активные в момент now
+func (c *Calendar) active(now time.Time) []Window {
+	now = now.In(c.location)
+	var active []Window
+	for _, w := range c.windows {
+		if _, ok := w.activeSince(now); ok {
+			active = append(active, w)
+		}
+	}
+	return active
+}
+
+// IngestionPaused - приостановлен ли приём файлов
+func (c *Calendar) IngestionPaused() bool {
+	for _, w := range c.active(c.now()) {
+		if w.PauseIngestion {
+			return true
+		}
+	}
+	return false
+}
+
+// AlertsSuppressed - подавлены ли оповещения
+func (c *Calendar) AlertsSuppressed() bool {
+	for _, w := range c.active(c.now()) {
+		if w.SuppressAlerts {
+			return true
+		}
+	}
+	return false
+}
+
+// Status - состояние всех окон
+func (c *Calendar) Status() Status {
+	now := c.now().In(c.location)
+	status := Status{Windows: make([]WindowStatus, 0, len(c.windows))}
+	for _, w := range c.windows {
+		ws := WindowStatus{
+			Name:           w.Name,
+			Schedule:       w.Schedule.String(),
+			Duration:       w.Duration.String(),
+			PauseIngestion: w.PauseIngestion,
+			SuppressAlerts: w.SuppressAlerts,
+			HeavyTasks:     w.HeavyTasks,
+		}
+		if since, ok := w.activeSince(now); ok {
+			until := since.Add(w.Duration)
+			ws.Active = true
+			ws.Until = &until
+			status.Active = true
+			status.IngestionPaused = status.IngestionPaused || w.PauseIngestion
+			status.AlertsSuppressed = status.AlertsSuppressed || w.SuppressAlerts
+		}
+		if next, ok := w.nextStart(now); ok {
+			ws.NextStart = &next
+		}
+		status.Windows = append(status.Windows, ws)
+	}
+	return status
+}
+
+// WaitForHeavyTasks ждёт окна для тяжёлых задач: возвращается сразу, если
+// такое окно активно, не настроено или начнётся позже maxDelay (задача
+// не откладывается бесконечно). Возвращает ошибку ctx.
+func (c *Calendar) WaitForHeavyTasks(ctx context.Context, maxDelay time.Duration) error {
+	now := c.now().In(c.location)
+	var next time.Time
+	for _, w := range c.windows {
+		if !w.HeavyTasks {
+			continue
+		}
+		if _, ok := w.activeSince(now); ok {
+			return nil
+		}
+		if start, ok := w.nextStart(now); ok && (next.IsZero() || start.Before(next)) {
+			next = start
+		}
+	}
+	if next.IsZero() || next.Sub(now) > maxDelay {
+		return nil
+	}
+
+	timer := time.NewTimer(next.Sub(now))
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,102 @@
+package maintenance
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func mustSchedule(t *testing.T, expr string) Schedule {
+	s, err := ParseSchedule(expr)
+	require.NoError(t, err)
+	return s
+}
+
+func TestParseSchedule(t *testing.T) {
+	s := mustSchedule(t, "*/15 2-4 * * 1-5")
+	// Понедельник 2026-03-02 02:30
+	assert.True(t, s.Matches(time.Date(2026, 3, 2, 2, 30, 0, 0, time.UTC)))
+	assert.False(t, s.Matches(time.Date(2026, 3, 2, 2, 31, 0, 0, time.UTC)))
+	assert.False(t, s.Matches(time.Date(2026, 3, 2, 5, 0, 0, 0, time.UTC)))
+	// Воскресенье
+	assert.False(t, s.Matches(time.Date(2026, 3, 1, 2, 30, 0, 0, time.UTC)))
+
+	sunday := mustSchedule(t, "0 3 * * 7")
+	assert.True(t, sunday.Matches(time.Date(2026, 3, 1, 3, 0, 0, 0, time.UTC)))
+
+	for _, bad := range []string{"", "* * * *", "60 * * * *", "* 24 * * *", "*/0 * * * *", "a * * * *", "5-1 * * * *"} {
+		_, err := ParseSchedule(bad)
+		assert.Error(t, err, bad)
+	}
+}
+
+func TestSchedule_DayOfMonthOrWeekday(t *testing.T) {
+	// Как в cron: 1-е число месяца ИЛИ понедельник
+	s := mustSchedule(t, "0 0 1 * 1")
+	assert.True(t, s.Matches(time.Date(2026, 4, 1, 0, 0, 0, 0, time.UTC)))  // среда, 1-е
+	assert.True(t, s.Matches(time.Date(2026, 4, 6, 0, 0, 0, 0, time.UTC)))  // понедельник
+	assert.False(t, s.Matches(time.Date(2026, 4, 7, 0, 0, 0, 0, time.UTC))) // вторник
+}
+
+func TestSchedule_Next(t *testing.T) {
+	s := mustSchedule(t, "30 2 1 * *")
+	after := time.Date(2026, 1, 15, 10, 0, 0, 0, time.UTC)
+	next := s.Next(after, after.Add(maxLookahead))
+	assert.Equal(t, time.Date(2026, 2, 1, 2, 30, 0, 0, time.UTC), next)
+
+	assert.True(t, s.Next(after, after.Add(time.Hour)).IsZero())
+}
+
+func newTestCalendar(t *testing.T, now time.Time, windows ...Window) *Calendar {
+	c := NewCalendar(windows, time.UTC)
+	c.now = func() time.Time { return now }
+	return c
+}
+
+func TestCalendar_ActiveWindow(t *testing.T) {
+	nightly := Window{Name: "nightly", Schedule: mustSchedule(t, "0 23 * * *"), Duration: 2 * time.Hour,
+		PauseIngestion: true, SuppressAlerts: true}
+
+	// Окно переходит через полночь
+	c := newTestCalendar(t, time.Date(2026, 3, 2, 0, 30, 0, 0, time.UTC), nightly)
+	assert.True(t, c.IngestionPaused())
+	assert.True(t, c.AlertsSuppressed())
+	status := c.Status()
+	require.Len(t, status.Windows, 1)
+	assert.True(t, status.Active)
+	assert.Equal(t, time.Date(2026, 3, 2, 1, 0, 0, 0, time.UTC), *status.Windows[0].Until)
+	assert.Equal(t, time.Date(2026, 3, 2, 23, 0, 0, 0, time.UTC), *status.Windows[0].NextStart)
+
+	c = newTestCalendar(t, time.Date(2026, 3, 2, 1, 0, 0, 0, time.UTC), nightly)
+	assert.False(t, c.IngestionPaused())
+	assert.False(t, c.Status().Active)
+}
+
+func TestCalendar_Location(t *testing.T) {
+	loc := time.FixedZone("MSK", 3*3600)
+	c := NewCalendar([]Window{{Name: "w", Schedule: mustSchedule(t, "0 2 * * *"), Duration: time.Hour,
+		PauseIngestion: true}}, loc)
+	c.now = func() time.Time { return time.Date(2026, 3, 1, 23, 30, 0, 0, time.UTC) } // 02:30 MSK
+	assert.True(t, c.IngestionPaused())
+}
+
+func TestCalendar_WaitForHeavyTasks(t *testing.T) {
+	heavy := Window{Name: "heavy", Schedule: mustSchedule(t, "0 3 * * *"), Duration: time.Hour, HeavyTasks: true}
+
+	// Активное окно - без ожидания
+	c := newTestCalendar(t, time.Date(2026, 3, 2, 3, 10, 0, 0, time.UTC), heavy)
+	assert.NoError(t, c.WaitForHeavyTasks(context.Background(), time.Hour))
+
+	// Окно дальше maxDelay - без ожидания
+	c = newTestCalendar(t, time.Date(2026, 3, 2, 10, 0, 0, 0, time.UTC), heavy)
+	assert.NoError(t, c.WaitForHeavyTasks(context.Background(), time.Hour))
+
+	// Окно скоро - ждём, пока не отменят
+	c = newTestCalendar(t, time.Date(2026, 3, 2, 2, 30, 0, 0, time.UTC), heavy)
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(t, c.WaitForHeavyTasks(ctx, time.Hour), context.DeadlineExceeded)
+}
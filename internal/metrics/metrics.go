@@ -0,0 +1,298 @@
+// internal/metrics/metrics.go
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Типы метрик в формате Prometheus
+const (
+	typeCounter   = "counter"
+	typeGauge     = "gauge"
+	typeHistogram = "histogram"
+)
+
+// Registry хранит метрики и отдаёт их в текстовом формате Prometheus.
+type Registry struct {
+	mu      sync.Mutex
+	metrics []*metric
+	names   map[string]bool
+}
+
+// Default - реестр, используемый сервисом и отдаваемый на /metrics.
+var Default = NewRegistry()
+
+// NewRegistry создаёт пустой реестр.
+func NewRegistry() *Registry {
+	return &Registry{names: make(map[string]bool)}
+}
+
+// metric - семейство рядов с общим именем, типом и набором меток.
+type metric struct {
+	name    string
+	help    string
+	kind    string
+	labels  []string
+	buckets []float64 // только для histogram
+
+	mu      sync.Mutex
+	series  map[string]*series
+	collect func() float64 // для GaugeFunc: значение вычисляется при выгрузке
+}
+
+type series struct {
+	labelValues []string
+	value       float64  // counter / gauge
+	counts      []uint64 // histogram: по бакетам (не накопительно)
+	sum         float64  // histogram
+	count       uint64   // histogram
+}
+
+func (r *Registry) register(m *metric) *metric {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.names[m.name] {
+		panic("metrics: duplicate metric " + m.name)
+	}
+	r.names[m.name] = true
+	m.series = make(map[string]*series)
+	r.metrics = append(r.metrics, m)
+	return m
+}
+
+// seriesFor возвращает ряд для значений меток, создавая его при необходимости.
+func (m *metric) seriesFor(labelValues []string) *series {
+	if len(labelValues) != len(m.labels) {
+		panic(fmt.Sprintf("metrics: %s expects %d label values, got %d", m.name, len(m.labels), len(labelValues)))
+	}
+	key := strings.Join(labelValues, "\xff")
+	s, ok := m.series[key]
+	if !ok {
+		s = &series{labelValues: append([]string(nil), labelValues...)}
+		if m.kind == typeHistogram {
+			s.counts = make([]uint64, len(m.buckets))
+		}
+		m.series[key] = s
+	}
+	return s
+}
+
+// Counter - монотонно растущий счётчик.
+type Counter struct{ m *metric }
+
+// NewCounter регистрирует счётчик с метками labels.
+func (r *Registry) NewCounter(name, help string, labels ...string) *Counter {
+	return &Counter{r.register(&metric{name: name, help: help, kind: typeCounter, labels: labels})}
+}
+
+// Inc увеличивает счётчик на 1.
+func (c *Counter) Inc(labelValues ...string) {
+	c.Add(1, labelValues...)
+}
+
+// Add увеличивает счётчик на v (v >= 0).
+func (c *Counter) Add(v float64, labelValues ...string) {
+	if v < 0 {
+		return
+	}
+	c.m.mu.Lock()
+	c.m.seriesFor(labelValues).value += v
+	c.m.mu.Unlock()
+}
+
+// Value возвращает текущее значение счётчика.
+func (c *Counter) Value(labelValues ...string) float64 {
+	c.m.mu.Lock()
+	defer c.m.mu.Unlock()
+	return c.m.seriesFor(labelValues).value
+}
+
+// Gauge - произвольно меняющееся значение.
+type Gauge struct{ m *metric }
+
+// NewGauge регистрирует gauge с метками labels.
+func (r *Registry) NewGauge(name, help string, labels ...string) *Gauge {
+	return &Gauge{r.register(&metric{name: name, help: help, kind: typeGauge, labels: labels})}
+}
+
+// Set задаёт значение.
+func (g *Gauge) Set(v float64, labelValues ...string) {
+	g.m.mu.Lock()
+	g.m.seriesFor(labelValues).value = v
+	g.m.mu.Unlock()
+}
+
+// Add изменяет значение на v.
+func (g *Gauge) Add(v float64, labelValues ...string) {
+	g.m.mu.Lock()
+	g.m.seriesFor(labelValues).value += v
+	g.m.mu.Unlock()
+}
+
+// NewGaugeFunc регистрирует gauge без меток, значение которого
+// вычисляется fn в момент выгрузки метрик.
+func (r *Registry) NewGaugeFunc(name, help string, fn func() float64) {
+	r.register(&metric{name: name, help: help, kind: typeGauge,
+		collect: fn})
+}
+
+// Histogram - распределение значений по бакетам.
+type Histogram struct{ m *metric }
+
+// NewHistogram регистрирует гистограмму с верхними границами бакетов buckets.
+func (r *Registry) NewHistogram(name, help string, buckets []float64, labels ...string) *Histogram {
+	sorted := append([]float64(nil), buckets...)
+	sort.Float64s(sorted)
+	return &Histogram{r.register(&metric{name: name, help: help, kind: typeHistogram,
+		labels: labels, buckets: sorted})}
+}
+
+// Observe добавляет значение в гистограмму.
+func (h *Histogram) Observe(v float64, labelValues ...string) {
+	h.m.mu.Lock()
+	defer h.m.mu.Unlock()
+	s := h.m.seriesFor(labelValues)
+	for i, upper := range h.m.buckets {
+		if v <= upper {
+			s.counts[i]++
+			break
+		}
+	}
+	s.sum += v
+	s.count++
+}
+
+// HistogramSnapshot - состояние гистограммы для JSON-статистики.
+type HistogramSnapshot struct {
+	Count   uint64            `json:"count"`
+	Sum     float64           `json:"sum"`
+	Buckets map[string]uint64 `json:"buckets"` // верхняя граница -> накопительное количество
+}
+
+// Snapshot возвращает копию состояния гистограммы.
+func (h *Histogram) Snapshot(labelValues ...string) HistogramSnapshot {
+	h.m.mu.Lock()
+	defer h.m.mu.Unlock()
+	s := h.m.seriesFor(labelValues)
+	snapshot := HistogramSnapshot{Count: s.count, Sum: s.sum, Buckets: make(map[string]uint64, len(s.counts)+1)}
+	var cumulative uint64
+	for i, upper := range h.m.buckets {
+		cumulative += s.counts[i]
+		snapshot.Buckets[formatFloat(upper)] = cumulative
+	}
+	snapshot.Buckets["+Inf"] = s.count
+	return snapshot
+}
+
+// ExponentialBuckets возвращает count границ, начиная со start с множителем factor.
+func ExponentialBuckets(start, factor float64, count int) []float64 {
+	buckets := make([]float64, count)
+	for i := range buckets {
+		buckets[i] = start
+		start *= factor
+	}
+	return buckets
+}
+
+// Handler отдаёт метрики реестра в текстовом формате Prometheus.
+func (r *Registry) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		r.WriteText(w)
+	})
+}
+
+// WriteText записывает все метрики в текстовом формате Prometheus.
+func (r *Registry) WriteText(w io.Writer) error {
+	r.mu.Lock()
+	metrics := append([]*metric(nil), r.metrics...)
+	r.mu.Unlock()
+
+	sort.Slice(metrics, func(i, j int) bool { return metrics[i].name < metrics[j].name })
+	for _, m := range metrics {
+		if err := m.write(w); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (m *metric) write(w io.Writer) error {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# HELP %s %s\n", m.name, escapeHelp(m.help))
+	fmt.Fprintf(&b, "# TYPE %s %s\n", m.name, m.kind)
+
+	if m.collect != nil {
+		fmt.Fprintf(&b, "%s %s\n", m.name, formatFloat(m.collect()))
+		_, err := io.WriteString(w, b.String())
+		return err
+	}
+
+	m.mu.Lock()
+	keys := make([]string, 0, len(m.series))
+	for key := range m.series {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		s := m.series[key]
+		if m.kind != typeHistogram {
+			fmt.Fprintf(&b, "%s%s %s\n", m.name, formatLabels(m.labels, s.labelValues, "", ""), formatFloat(s.value))
+			continue
+		}
+		var cumulative uint64
+		for i, upper := range m.buckets {
+			cumulative += s.counts[i]
+			fmt.Fprintf(&b, "%s_bucket%s %d\n", m.name,
+				formatLabels(m.labels, s.labelValues, "le", formatFloat(upper)), cumulative)
+		}
+		fmt.Fprintf(&b, "%s_bucket%s %d\n", m.name, formatLabels(m.labels, s.labelValues, "le", "+Inf"), s.count)
+		fmt.Fprintf(&b, "%s_sum%s %s\n", m.name, formatLabels(m.labels, s.labelValues, "", ""), formatFloat(s.sum))
+		fmt.Fprintf(&b, "%s_count%s %d\n", m.name, formatLabels(m.labels, s.labelValues, "", ""), s.count)
+	}
+	m.mu.Unlock()
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// formatLabels собирает {name="value",...}; extraName добавляется последней (le)
+func formatLabels(names, values []string, extraName, extraValue string) string {
+	if len(names) == 0 && extraName == "" {
+		return ""
+	}
+	pairs := make([]string, 0, len(names)+1)
+	for i, name := range names {
+		pairs = append(pairs, fmt.Sprintf(`%s="%s"`, name, escapeLabel(values[i])))
+	}
+	if extraName != "" {
+		pairs = append(pairs, fmt.Sprintf(`%s="%s"`, extraName, extraValue))
+	}
+	return "{" + strings.Join(pairs, ",") + "}"
+}
+
+func formatFloat(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+var (
+	labelEscaper = strings.NewReplacer(`\`, `\\`, "\n", `\n`, `"`, `\"`)
+	helpEscaper  = strings.NewReplacer(`\`, `\\`, "\n", `\n`)
+)
+
+func escapeLabel(s string) string { return labelEscaper.Replace(s) }
+
+func escapeHelp(s string) string { return helpEscaper.Replace(s) }
@@ -0,0 +1,77 @@
+package metrics
+
+import (
+	"bytes"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteText(t *testing.T) {
+	r := NewRegistry()
+	files := r.NewCounter("tsv_files_total", "Processed files", "status")
+	queue := r.NewGauge("tsv_queue_length", "Files in queue")
+	latency := r.NewHistogram("tsv_latency_seconds", "Latency", []float64{1, 5})
+	r.NewGaugeFunc("tsv_workers", "Workers", func() float64 { return 3 })
+
+	files.Inc("completed")
+	files.Add(2, "failed")
+	files.Inc(`we"ird`)
+	queue.Set(7)
+	latency.Observe(0.5)
+	latency.Observe(3)
+	latency.Observe(10)
+
+	var buf bytes.Buffer
+	require.NoError(t, r.WriteText(&buf))
+	out := buf.String()
+
+	assert.Contains(t, out, "# TYPE tsv_files_total counter\n")
+	assert.Contains(t, out, `tsv_files_total{status="completed"} 1`+"\n")
+	assert.Contains(t, out, `tsv_files_total{status="failed"} 2`+"\n")
+	assert.Contains(t, out, `tsv_files_total{status="we\"ird"} 1`+"\n")
+	assert.Contains(t, out, "tsv_queue_length 7\n")
+	assert.Contains(t, out, "tsv_workers 3\n")
+	assert.Contains(t, out, `tsv_latency_seconds_bucket{le="1"} 1`+"\n")
+	assert.Contains(t, out, `tsv_latency_seconds_bucket{le="5"} 2`+"\n")
+	assert.Contains(t, out, `tsv_latency_seconds_bucket{le="+Inf"} 3`+"\n")
+	assert.Contains(t, out, "tsv_latency_seconds_sum 13.5\n")
+	assert.Contains(t, out, "tsv_latency_seconds_count 3\n")
+
+	// Метрики выводятся в алфавитном порядке
+	assert.Less(t, strings.Index(out, "tsv_files_total"), strings.Index(out, "tsv_workers"))
+}
+
+func TestHistogramSnapshot(t *testing.T) {
+	r := NewRegistry()
+	h := r.NewHistogram("h", "h", ExponentialBuckets(1, 2, 3), "kind")
+	h.Observe(1.5, "a")
+	h.Observe(100, "a")
+
+	snapshot := h.Snapshot("a")
+	assert.Equal(t, uint64(2), snapshot.Count)
+	assert.Equal(t, uint64(0), snapshot.Buckets["1"])
+	assert.Equal(t, uint64(1), snapshot.Buckets["2"])
+	assert.Equal(t, uint64(1), snapshot.Buckets["4"])
+	assert.Equal(t, uint64(2), snapshot.Buckets["+Inf"])
+}
+
+func TestRegistry_DuplicateAndLabelMismatch(t *testing.T) {
+	r := NewRegistry()
+	c := r.NewCounter("c", "c", "status")
+	assert.Panics(t, func() { r.NewGauge("c", "again") })
+	assert.Panics(t, func() { c.Inc() })
+}
+
+func TestHandler(t *testing.T) {
+	r := NewRegistry()
+	r.NewCounter("c_total", "c").Inc()
+
+	rec := httptest.NewRecorder()
+	r.Handler().ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	assert.Equal(t, "text/plain; version=0.0.4; charset=utf-8", rec.Header().Get("Content-Type"))
+	assert.Contains(t, rec.Body.String(), "c_total 1\n")
+}
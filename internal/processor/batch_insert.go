@@ -0,0 +1,166 @@
+// internal/processor/batch_insert.go
+package processor
+
+import (
+	"TSVProcessingService/db/sqlc"
+	"TSVProcessingService/internal/ingest"
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// deviceDataColumns - колонки device_data, заполняемые из строки файла
+const deviceDataColumns = 16
+
+// maxBatchRows - предел строк в одном INSERT (16 параметров на строку,
+// с запасом до лимита PostgreSQL в 65535 параметров)
+const maxBatchRows = 4000
+
+// SetBatchSize задаёт число строк в одном многострочном INSERT при
+// политике конфликтов append (worker.batch_size). 1 - построчная вставка.
+// Для остальных политик строки с msg_id сверяются с существующими
+// и вставляются по одной.
+func (p *Processor) SetBatchSize(size int) {
+	p.batchSize = min(max(size, 1), maxBatchRows)
+}
+
+// rowBatch - строки файла, ожидающие многострочной вставки
+type rowBatch struct {
+	db   sqlc.DBTX // транзакция файла (с обёрткой SetTxWrapper)
+	rows []ingest.Row
+}
+
+// savepointError - ошибка управления точкой сохранения: транзакция файла
+// непригодна, и обработку продолжать нельзя
+type savepointError struct {
+	err error
+}
+
+func (e *savepointError) Error() string { return "savepoint failed: " + e.err.Error() }
+func (e *savepointError) Unwrap() error { return e.err }
+
+func isSavepointError(err error) bool {
+	var spErr *savepointError
+	return errors.As(err, &spErr)
+}
+
+// inSavepoint выполняет fn в точке сохранения name. В PostgreSQL ошибка
+// запроса прерывает всю транзакцию: без отката до точки сохранения все
+// следующие запросы и COMMIT файла тоже завершились бы ошибкой. Ошибки
+// самой точки сохранения возвращаются как *savepointError.
+func inSavepoint(ctx context.Context, db sqlc.DBTX, name string, fn func() error) error {
+	if _, err := db.ExecContext(ctx, "SAVEPOINT "+name); err != nil {
+		return &savepointError{err: err}
+	}
+	if err := fn(); err != nil {
+		if _, rbErr := db.ExecContext(ctx, "ROLLBACK TO SAVEPOINT "+name); rbErr != nil {
+			return &savepointError{err: fmt.Errorf("%v (rollback to savepoint: %w)", err, rbErr)}
+		}
+		return err
+	}
+	if _, err := db.ExecContext(ctx, "RELEASE SAVEPOINT "+name); err != nil {
+		return &savepointError{err: err}
+	}
+	return nil
+}
+
+// insertRowSavepoint - insertRow в точке сохранения: ошибка строки
+// отклоняет только её, транзакция файла остаётся пригодной
+func insertRowSavepoint(ctx context.Context, db sqlc.DBTX, qtx *sqlc.Queries, policy string, params sqlc.CreateDeviceDataParams) (string, error) {
+	var outcome string
+	err := inSavepoint(ctx, db, "device_data_row", func() error {
+		var err error
+		outcome, err = insertRow(ctx, qtx, policy, params)
+		return err
+	})
+	return outcome, err
+}
+
+// insertDeviceDataBatch вставляет строки одним INSERT в точке сохранения:
+// при ошибке транзакция остаётся пригодной, чтобы строки можно было
+// вставить частями и найти некорректные.
+func insertDeviceDataBatch(ctx context.Context, db sqlc.DBTX, fileID int64, rows []ingest.Row) error {
+	var query strings.Builder
+	query.WriteString(`INSERT INTO device_data (file_id, unit_guid, mqtt, invid, msg_id, text, context, class, level,
+		area, addr, block, type, bit, invert_bit, line_number) VALUES `)
+
+	args := make([]interface{}, 0, len(rows)*deviceDataColumns)
+	for i, row := range rows {
+		if i > 0 {
+			query.WriteString(", ")
+		}
+		query.WriteString("(")
+		for c := 1; c <= deviceDataColumns; c++ {
+			if c > 1 {
+				query.WriteString(", ")
+			}
+			fmt.Fprintf(&query, "$%d", i*deviceDataColumns+c)
+		}
+		query.WriteString(")")
+
+		p := row.DeviceDataParams(fileID)
+		args = append(args, p.FileID, p.UnitGuid, p.Mqtt, p.Invid, p.MsgID, p.Text, p.Context, p.Class, p.Level,
+			p.Area, p.Addr, p.Block, p.Type, p.Bit, p.InvertBit, p.LineNumber)
+	}
+
+	return inSavepoint(ctx, db, "device_data_batch", func() error {
+		_, err := db.ExecContext(ctx, query.String(), args...)
+		return err
+	})
+}
+
+// insertFailure - ошибка этапа вставки: истёкший бюджет или непригодная
+// транзакция (ошибка точки сохранения)
+func insertFailure(budget time.Duration, err error) error {
+	if isSavepointError(err) {
+		return stageFailure(StageInsert, err)
+	}
+	return stageError(StageInsert, budget, err)
+}
+
+// rowInserted - обработка итога вставки строки
+type rowInserted func(row ingest.Row, outcome string, err error)
+
+// flush вставляет накопленные строки; при ошибке пачки некорректные
+// строки ищутся делением пачки пополам (insertBisect)
+func (b *rowBatch) flush(ctx context.Context, fileID int64, done rowInserted) error {
+	if len(b.rows) == 0 {
+		return nil
+	}
+	rows := b.rows
+	b.rows = b.rows[:0]
+	return b.insertBisect(ctx, fileID, rows, done)
+}
+
+// insertBisect вставляет rows одним INSERT, а если БД его отклонила -
+// вставляет половины пачки по отдельности, пока отклонённой не окажется
+// одна строка: её ошибка и есть ошибка строки. Пачка из n строк с одной
+// некорректной вставляется не больше чем за 1+2·⌈log2 n⌉ INSERT (вместо
+// n+1 при построчной вставке), в худшем случае - за 2n-1.
+func (b *rowBatch) insertBisect(ctx context.Context, fileID int64, rows []ingest.Row, done rowInserted) error {
+	err := insertDeviceDataBatch(ctx, b.db, fileID, rows)
+	if err == nil {
+		for _, row := range rows {
+			done(row, outcomeInserted, nil)
+		}
+		return nil
+	}
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	if isSavepointError(err) {
+		return err
+	}
+	if len(rows) == 1 {
+		done(rows[0], "", err)
+		return nil
+	}
+
+	mid := len(rows) / 2
+	if err := b.insertBisect(ctx, fileID, rows[:mid], done); err != nil {
+		return err
+	}
+	return b.insertBisect(ctx, fileID, rows[mid:], done)
+}
@@ -0,0 +1,92 @@
+// internal/processor/budget.go
+package processor
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// Этапы обработки файла, для которых выделяется отдельный бюджет времени
+const (
+	StageParse  = "parse"
+	StageInsert = "insert"
+	StageReport = "report"
+)
+
+// StageBudgets - доли общего таймаута ProcessFile, выделяемые этапам.
+// Нулевая доля означает, что этап ограничен только общим дедлайном.
+// Остаток бюджета резервируется под фиксацию транзакции и перемещение файла.
+type StageBudgets struct {
+	Parse  float64
+	Insert float64
+	Report float64
+}
+
+// StageTimeoutError - этап обработки не уложился в свой бюджет времени.
+type StageTimeoutError struct {
+	Stage  string
+	Budget time.Duration
+	Err    error
+}
+
+func (e *StageTimeoutError) Error() string {
+	return fmt.Sprintf("stage %s exceeded its %v budget: %v", e.Stage, e.Budget.Round(time.Millisecond), e.Err)
+}
+
+func (e *StageTimeoutError) Unwrap() error {
+	return e.Err
+}
+
+// SetStageBudgets задаёт доли общего таймаута для этапов обработки.
+func (p *Processor) SetStageBudgets(budgets StageBudgets) {
+	p.budgets = budgets
+}
+
+// stageBudget возвращает длительность этапа исходя из общего бюджета total.
+func (p *Processor) stageBudget(stage string, total time.Duration) time.Duration {
+	var fraction float64
+	switch stage {
+	case StageParse:
+		fraction = p.budgets.Parse
+	case StageInsert:
+		fraction = p.budgets.Insert
+	case StageReport:
+		fraction = p.budgets.Report
+	}
+	if fraction <= 0 || total <= 0 {
+		return 0
+	}
+	return time.Duration(float64(total) * fraction)
+}
+
+// stageContext создаёт контекст этапа с собственным дедлайном, который
+// никогда не превышает дедлайн родительского контекста.
+func (p *Processor) stageContext(ctx context.Context, stage string, total time.Duration) (context.Context, context.CancelFunc, time.Duration) {
+	budget := p.stageBudget(stage, total)
+	if budget <= 0 {
+		if deadline, ok := ctx.Deadline(); ok {
+			budget = time.Until(deadline)
+		}
+		stageCtx, cancel := context.WithCancel(ctx)
+		return stageCtx, cancel, budget
+	}
+	stageCtx, cancel := context.WithTimeout(ctx, budget)
+	return stageCtx, cancel, budget
+}
+
+// stageError оборачивает ошибку контекста этапа в StageTimeoutError.
+func stageError(stage string, budget time.Duration, err error) error {
+	if err == nil {
+		return nil
+	}
+	return &StageTimeoutError{Stage: stage, Budget: budget, Err: err}
+}
+
+// totalBudget - общий бюджет времени обработки по дедлайну контекста.
+func totalBudget(ctx context.Context) time.Duration {
+	if deadline, ok := ctx.Deadline(); ok {
+		return time.Until(deadline)
+	}
+	return 0
+}
@@ -0,0 +1,60 @@
+// internal/processor/cancel.go
+package processor
+
+import (
+	"TSVProcessingService/db/sqlc"
+	"TSVProcessingService/internal/filestatus"
+	"TSVProcessingService/internal/watcher"
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// StageCancel - обработка отменена оператором
+const StageCancel = "cancel"
+
+// ErrCancelled - причина отмены контекста обработки (context.WithCancelCause),
+// по которой ProcessFile отличает отмену оператором от таймаута
+var ErrCancelled = errors.New("processing cancelled by operator")
+
+// cancelled проверяет, что контекст обработки отменён оператором
+func cancelled(ctx context.Context) bool {
+	return errors.Is(context.Cause(ctx), ErrCancelled)
+}
+
+// cancelFile помечает файл как cancelled и перемещает его в папку
+// отложенных файлов (hold_path). Транзакция обработки к этому моменту
+// откачена, поэтому данных файла в БД нет; запись о файле создаётся
+// заново и удаляется при повторной обработке (см. resumeCancelled).
+func (p *Processor) cancelFile(fileInfo watcher.FileInfo) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	p.RecordEvent(fileInfo.Name, EventCancelled, "")
+
+	if _, err := p.markFile(ctx, fileInfo, filestatus.Cancelled, failureMessage(StageCancel, ErrCancelled.Error())); err != nil {
+		return err
+	}
+
+	if p.config.HoldPath == "" {
+		return nil
+	}
+	if _, err := p.fs.Stat(fileInfo.Path); err == nil {
+		if err := p.moveFile(fileInfo.Path, p.config.HoldPath, fileInfo.Name); err != nil {
+			return fmt.Errorf("failed to move file to hold folder: %w", err)
+		}
+		p.logger.Info("⏸️ File moved to hold folder", "file", fileInfo.Name)
+	}
+	return nil
+}
+
+// resumeCancelled удаляет запись об отменённом файле, чтобы файл,
+// возвращённый в watch-директорию, обработался заново
+func (p *Processor) resumeCancelled(ctx context.Context, file sqlc.File) error {
+	if err := p.queries.DeleteFile(ctx, file.ID); err != nil {
+		return fmt.Errorf("failed to reset cancelled file: %w", err)
+	}
+	p.logger.Info("Resuming previously cancelled file", "file", file.Filename)
+	return nil
+}
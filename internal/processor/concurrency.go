@@ -0,0 +1,38 @@
+// internal/processor/concurrency.go
+package processor
+
+import "context"
+
+// SetStageConcurrency ограничивает число файлов, одновременно проходящих
+// разбор (CPU) и вставку строк (соединения БД), независимо от числа
+// воркеров (worker.max_workers - файлов в обработке): воркер ждёт
+// свободного места, ожидание входит в бюджет этапа. При потоковой
+// обработке разбор и вставка чередуются и ограничиваются местом вставки.
+// 0 - без ограничения.
+func (p *Processor) SetStageConcurrency(parse, insert int) {
+	p.parseSlots = newStageSlots(parse)
+	p.insertSlots = newStageSlots(insert)
+}
+
+// stageSlots - места этапа обработки (nil - без ограничения)
+type stageSlots chan struct{}
+
+func newStageSlots(n int) stageSlots {
+	if n <= 0 {
+		return nil
+	}
+	return make(stageSlots, n)
+}
+
+// acquire занимает место этапа; возвращённая функция освобождает его
+func (s stageSlots) acquire(ctx context.Context) (func(), error) {
+	if s == nil {
+		return func() {}, nil
+	}
+	select {
+	case s <- struct{}{}:
+		return func() { <-s }, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
@@ -0,0 +1,142 @@
+// internal/processor/conflict.go
+package processor
+
+import (
+	"TSVProcessingService/db/sqlc"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+)
+
+// Политики конфликтов при вставке строки, запись с тем же ключом
+// (unit_guid, msg_id) которой уже есть в device_data
+const (
+	ConflictAppend    = "append"    // вставлять без проверки (поведение по умолчанию)
+	ConflictSkip      = "skip"      // оставить существующую запись
+	ConflictOverwrite = "overwrite" // заменить последнюю версию записи
+	ConflictVersion   = "version"   // сохранить обе, новая получает следующий номер версии
+)
+
+// Итог вставки строки
+const (
+	outcomeInserted    = "inserted"
+	outcomeSkipped     = "skipped"
+	outcomeOverwritten = "overwritten"
+	outcomeVersioned   = "versioned"
+)
+
+// ValidConflictPolicy проверяет название политики (пустое - политика по умолчанию).
+func ValidConflictPolicy(policy string) bool {
+	switch policy {
+	case "", ConflictAppend, ConflictSkip, ConflictOverwrite, ConflictVersion:
+		return true
+	}
+	return false
+}
+
+// ConflictCounts - итог применения политики конфликтов к файлу
+type ConflictCounts struct {
+	Skipped     int32 `json:"rows_skipped"`
+	Overwritten int32 `json:"rows_overwritten"`
+	Versioned   int32 `json:"rows_versioned"`
+}
+
+// SetConflictPolicy задаёт политику конфликтов для файлов, у которых она
+// не указана явно (FileInfo.ConflictPolicy).
+func (p *Processor) SetConflictPolicy(policy string) {
+	p.conflictPolicy = policy
+}
+
+// resolveConflictPolicy - политика файла, затем политика по умолчанию
+func (p *Processor) resolveConflictPolicy(filePolicy string) string {
+	if filePolicy != "" {
+		return filePolicy
+	}
+	if p.conflictPolicy != "" {
+		return p.conflictPolicy
+	}
+	return ConflictAppend
+}
+
+// insertRow сохраняет строку с учётом политики конфликтов. Строки без
+// msg_id не имеют ключа и всегда вставляются.
+func insertRow(ctx context.Context, qtx *sqlc.Queries, policy string, params sqlc.CreateDeviceDataParams) (string, error) {
+	if policy == ConflictAppend || !params.MsgID.Valid || params.MsgID.String == "" {
+		_, err := qtx.CreateDeviceData(ctx, params)
+		return outcomeInserted, err
+	}
+
+	existing, err := qtx.GetLatestDeviceDataByKey(ctx, sqlc.GetLatestDeviceDataByKeyParams{
+		UnitGuid: params.UnitGuid,
+		MsgID:    params.MsgID,
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		_, err := qtx.CreateDeviceData(ctx, params)
+		return outcomeInserted, err
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to look up existing row: %w", err)
+	}
+
+	switch policy {
+	case ConflictSkip:
+		return outcomeSkipped, nil
+	case ConflictOverwrite:
+		_, err := qtx.OverwriteDeviceData(ctx, sqlc.OverwriteDeviceDataParams{
+			ID:         existing.ID,
+			FileID:     params.FileID,
+			UnitGuid:   params.UnitGuid,
+			Mqtt:       params.Mqtt,
+			Invid:      params.Invid,
+			MsgID:      params.MsgID,
+			Text:       params.Text,
+			Context:    params.Context,
+			Class:      params.Class,
+			Level:      params.Level,
+			Area:       params.Area,
+			Addr:       params.Addr,
+			Block:      params.Block,
+			Type:       params.Type,
+			Bit:        params.Bit,
+			InvertBit:  params.InvertBit,
+			LineNumber: params.LineNumber,
+		})
+		return outcomeOverwritten, err
+	case ConflictVersion:
+		_, err := qtx.CreateDeviceDataVersion(ctx, sqlc.CreateDeviceDataVersionParams{
+			FileID:     params.FileID,
+			UnitGuid:   params.UnitGuid,
+			Mqtt:       params.Mqtt,
+			Invid:      params.Invid,
+			MsgID:      params.MsgID,
+			Text:       params.Text,
+			Context:    params.Context,
+			Class:      params.Class,
+			Level:      params.Level,
+			Area:       params.Area,
+			Addr:       params.Addr,
+			Block:      params.Block,
+			Type:       params.Type,
+			Bit:        params.Bit,
+			InvertBit:  params.InvertBit,
+			LineNumber: params.LineNumber,
+			Version:    existing.Version + 1,
+		})
+		return outcomeVersioned, err
+	default:
+		return "", fmt.Errorf("unknown conflict policy %q", policy)
+	}
+}
+
+// add учитывает итог вставки строки
+func (c *ConflictCounts) add(outcome string) {
+	switch outcome {
+	case outcomeSkipped:
+		c.Skipped++
+	case outcomeOverwritten:
+		c.Overwritten++
+	case outcomeVersioned:
+		c.Versioned++
+	}
+}
@@ -0,0 +1,50 @@
+// internal/processor/content_hash.go
+package processor
+
+import (
+	"TSVProcessingService/internal/features"
+	"TSVProcessingService/internal/filestatus"
+	"TSVProcessingService/internal/watcher"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+)
+
+// SetAllowDuplicateContent разрешает повторную обработку файла, содержимое
+// которого (SHA256) совпадает с уже обработанным файлом под другим
+// именем (worker.allow_duplicate_content). По умолчанию такой файл не
+// обрабатывается: он пропускается и перемещается в архив. С разрешением
+// файл обрабатывается, а совпадение отмечается в хронологии.
+func (p *Processor) SetAllowDuplicateContent(allow bool) {
+	p.allowDuplicateContent = allow
+}
+
+// skipDuplicateContent ищет обработанный файл с тем же хешем содержимого.
+// true - файл пропущен как повтор и перемещён. Флаг content_dedup
+// выключает проверку.
+func (p *Processor) skipDuplicateContent(ctx context.Context, fileInfo watcher.FileInfo) (bool, error) {
+	if fileInfo.Hash == "" || !p.featureEnabled(features.ContentDedup, fileInfo.Source) {
+		return false, nil
+	}
+	original, err := p.queries.GetFileByHash(ctx, fileInfo.Hash)
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to check file content hash: %w", err)
+	}
+
+	detail := fmt.Sprintf("same content as %s (file %d)", original.Filename, original.ID)
+	if p.allowDuplicateContent {
+		p.logger.Warn("File has the same content as a processed file, reprocessing", "file", fileInfo.Name, "original", original.Filename)
+		p.RecordEvent(fileInfo.Name, EventDuplicateContent, detail)
+		return false, nil
+	}
+
+	p.logger.Info("File has the same content as a processed file, skipping", "file", fileInfo.Name, "original", original.Filename)
+	p.RecordEvent(fileInfo.Name, EventSkipped, detail)
+	watcher.Skipped(watcher.SkipDuplicateContent)
+	p.moveExistingFile(fileInfo.Path, filestatus.Of(original.Status))
+	return true, nil
+}
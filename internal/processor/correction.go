@@ -0,0 +1,102 @@
+// internal/processor/correction.go
+package processor
+
+import (
+	"TSVProcessingService/db/sqlc"
+	"TSVProcessingService/internal/filestatus"
+	"TSVProcessingService/internal/watcher"
+	"bufio"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// rejectedMarker - первая строка <name>.rejected.tsv с именем исходного
+// файла. Комментарии пропускаются при разборе, поэтому исправленный файл
+// можно отправить обратно как есть.
+const rejectedMarker = "# rejected_from: "
+
+// correctionMarkerLines - сколько начальных строк просматривается в поисках маркера
+const correctionMarkerLines = 10
+
+// correctionOriginal - имя исходного файла, ошибочные строки которого
+// исправлены в fileInfo: из строки-маркера, иначе по имени
+// (<base>.rejected.tsv, <base>.rejected.fixed.tsv -> <base>.tsv).
+// Пусто - обычный файл.
+func (p *Processor) correctionOriginal(fileInfo watcher.FileInfo) string {
+	if f, err := p.fs.Open(fileInfo.Path); err == nil {
+		defer f.Close()
+		scanner := bufio.NewScanner(f)
+		for i := 0; i < correctionMarkerLines && scanner.Scan(); i++ {
+			line := strings.TrimSpace(scanner.Text())
+			if name, ok := strings.CutPrefix(line, strings.TrimSpace(rejectedMarker)); ok {
+				if name = strings.TrimSpace(name); name != "" {
+					return name
+				}
+			}
+			if line != "" && !strings.HasPrefix(line, "#") {
+				break
+			}
+		}
+	}
+	if base, _, ok := strings.Cut(fileInfo.Name, ".rejected"); ok && base != "" {
+		return base + filepath.Ext(fileInfo.Name)
+	}
+	return ""
+}
+
+// mergeCorrection засчитывает строки файла исправления исходному файлу:
+// rows_processed растёт, rows_failed (ошибки вставки; ошибки разбора в
+// нём не учитываются) уменьшается, статус пересчитывается,
+// correction_note помечает исправление. false - исходный файл не найден,
+// исправление обрабатывается как обычный файл.
+func (p *Processor) mergeCorrection(ctx context.Context, q *sqlc.Queries, file sqlc.File, originalName string, corrected int32) (sqlc.File, bool, error) {
+	original, err := q.GetFileByFilename(ctx, originalName)
+	if errors.Is(err, sql.ErrNoRows) || (err == nil && original.ID == file.ID) {
+		return sqlc.File{}, false, nil
+	}
+	if err != nil {
+		return sqlc.File{}, false, err
+	}
+
+	if _, err := q.SetFileCorrects(ctx, sqlc.SetFileCorrectsParams{
+		ID:             file.ID,
+		CorrectsFileID: sql.NullInt64{Int64: original.ID, Valid: true},
+	}); err != nil {
+		return sqlc.File{}, false, err
+	}
+
+	rowsFailed := original.RowsFailed.Int32 - corrected
+	if rowsFailed < 0 {
+		rowsFailed = 0
+	}
+	rowsProcessed := original.RowsProcessed.Int32 + corrected
+	status := filestatus.Of(original.Status)
+	if rowsProcessed > 0 {
+		status = filestatus.Partial
+		if rowsFailed == 0 {
+			status = filestatus.Completed
+		}
+	}
+	if err := filestatus.Transition(filestatus.Of(original.Status), status); err != nil {
+		return sqlc.File{}, false, err
+	}
+	note := fmt.Sprintf("corrected: %d rows by %s", corrected, file.Filename)
+
+	merged, err := q.MergeFileCorrection(ctx, sqlc.MergeFileCorrectionParams{
+		ID:             original.ID,
+		Status:         status.NullString(),
+		RowsProcessed:  sql.NullInt32{Int32: rowsProcessed, Valid: true},
+		RowsFailed:     sql.NullInt32{Int32: rowsFailed, Valid: true},
+		RowsCorrected:  sql.NullInt32{Int32: original.RowsCorrected.Int32 + corrected, Valid: true},
+		CorrectionNote: sql.NullString{String: note, Valid: true},
+	})
+	if err != nil {
+		return sqlc.File{}, false, err
+	}
+	p.logger.Info("🩹 "+note, "file", original.Filename, "status", status, "failed", rowsFailed)
+	return merged, true, nil
+}
@@ -0,0 +1,44 @@
+// internal/processor/daily_summary.go
+package processor
+
+import (
+	"TSVProcessingService/db/sqlc"
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// dailySummary - вклад файла в суточную сводку устройств (unit_daily_summary).
+// Сводка обновляется в транзакции файла, поэтому графики и дайджесты
+// не сканируют device_data.
+type dailySummary map[uuid.UUID]*sqlc.UpsertUnitDailySummaryParams
+
+// add учитывает сохранённую строку
+func (s dailySummary) add(row TSVRow) {
+	entry, ok := s[row.UnitGuid]
+	if !ok {
+		entry = &sqlc.UpsertUnitDailySummaryParams{UnitGuid: row.UnitGuid}
+		s[row.UnitGuid] = entry
+	}
+	entry.Records++
+	if row.Class.Valid && row.Class.String == "alarm" {
+		entry.Alarms++
+	}
+	if row.Level.Valid && (!entry.MaxLevel.Valid || row.Level.Int32 > entry.MaxLevel.Int32) {
+		entry.MaxLevel = sql.NullInt32{Int32: row.Level.Int32, Valid: true}
+	}
+}
+
+// save добавляет счётчики к сводке за день day (UTC)
+func (s dailySummary) save(ctx context.Context, qtx *sqlc.Queries, day time.Time) error {
+	day = day.UTC().Truncate(24 * time.Hour)
+	for _, entry := range s {
+		entry.Day = day
+		if err := qtx.UpsertUnitDailySummary(ctx, *entry); err != nil {
+			return err
+		}
+	}
+	return nil
+}
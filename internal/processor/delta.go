@@ -0,0 +1,163 @@
+// internal/processor/delta.go
+package processor
+
+import (
+	"TSVProcessingService/db/sqlc"
+	"TSVProcessingService/internal/ingest"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/google/uuid"
+)
+
+// SetDeltaSources включает режим delta для источников (files.source),
+// контроллеры которых выгружают только строки, добавленные после
+// предыдущей выгрузки. Номера n строк должны продолжать seq_last
+// предыдущего файла источника без пропусков: пропуски и повторы
+// сохраняются как ошибки строк (строки всё равно загружаются), диапазон
+// n и число пропущенных номеров - в files.seq_first/seq_last/seq_gaps.
+// Файлы одного источника должны обрабатываться по порядку поступления.
+func (p *Processor) SetDeltaSources(sources []string) {
+	p.deltaSources = make(map[string]bool, len(sources))
+	for _, source := range sources {
+		p.deltaSources[source] = true
+	}
+}
+
+// sequenceCheck - проверка последовательности n файла. Строки файла
+// добавляются частями (add) по порядку, поэтому проверка не держит их
+// в памяти; ошибки части забираются после неё (takeErrors).
+type sequenceCheck struct {
+	first      int64
+	last       int64
+	gaps       int32
+	seen       bool // в файле есть строки с n
+	prev       int64
+	known      bool
+	errorCount int
+	errors     []ingest.RowError // ошибки ещё не сохранённой части
+}
+
+// newSequenceCheck начинает проверку с prevLast+1 (prevLast невалиден -
+// первый файл источника)
+func newSequenceCheck(prevLast sql.NullInt64) *sequenceCheck {
+	return &sequenceCheck{prev: prevLast.Int64, known: prevLast.Valid}
+}
+
+// seqLine - строка данных файла с её номером n
+type seqLine struct {
+	line     int32
+	seq      int64
+	unitGuid uuid.NullUUID
+}
+
+// sequenceLines - строки данных файла с номером n (разобранные и
+// отклонённые при парсинге) по порядку строк файла
+func sequenceLines(rows []ingest.Row, parseErrors []ingest.RowError) []seqLine {
+	lines := make([]seqLine, 0, len(rows)+len(parseErrors))
+	for _, row := range rows {
+		lines = append(lines, seqLine{
+			line:     row.LineNumber,
+			seq:      row.Seq,
+			unitGuid: uuid.NullUUID{UUID: row.UnitGuid, Valid: true},
+		})
+	}
+	for _, perr := range parseErrors {
+		if perr.Seq.Valid && perr.LineNumber.Valid {
+			lines = append(lines, seqLine{line: perr.LineNumber.Int32, seq: perr.Seq.Int64, unitGuid: perr.UnitGuid})
+		}
+	}
+	sort.Slice(lines, func(i, j int) bool { return lines[i].line < lines[j].line })
+	return lines
+}
+
+// add проверяет, что n строк данных (разобранных и отклонённых) растут
+// на 1 по порядку строк файла. lines - очередная часть файла по порядку
+// строк (sequenceLines).
+func (c *sequenceCheck) add(lines []seqLine) {
+	for _, l := range lines {
+		if !c.seen {
+			c.first, c.last, c.seen = l.seq, l.seq, true
+		}
+		c.first = min(c.first, l.seq)
+		c.last = max(c.last, l.seq)
+
+		var message string
+		switch {
+		case !c.known || l.seq == c.prev+1:
+		case l.seq > c.prev+1:
+			missing := l.seq - c.prev - 1
+			c.gaps += int32(missing)
+			message = fmt.Sprintf("sequence gap: n=%d follows n=%d (%d rows missing)", l.seq, c.prev, missing)
+		default:
+			message = fmt.Sprintf("sequence out of order: n=%d after n=%d (row already exported)", l.seq, c.prev)
+		}
+		if message != "" {
+			c.errorCount++
+			c.errors = append(c.errors, ingest.RowError{
+				LineNumber:   sql.NullInt32{Int32: l.line, Valid: true},
+				ErrorMessage: message,
+				FieldName:    sql.NullString{String: "n", Valid: true},
+				UnitGuid:     l.unitGuid,
+			})
+		}
+		if !c.known || l.seq > c.prev {
+			c.prev, c.known = l.seq, true
+		}
+	}
+}
+
+// takeErrors забирает ошибки последовательности, найденные с прошлого вызова
+func (c *sequenceCheck) takeErrors() []ingest.RowError {
+	errs := c.errors
+	c.errors = nil
+	return errs
+}
+
+// startSequence начинает проверку последовательности delta-файла
+// относительно предыдущего файла источника
+func startSequence(ctx context.Context, qtx *sqlc.Queries, source string) (*sequenceCheck, error) {
+	var prevLast sql.NullInt64
+	prev, err := qtx.GetLastSequencedFile(ctx, source)
+	switch {
+	case err == nil:
+		prevLast = prev.SeqLast
+	case !errors.Is(err, sql.ErrNoRows):
+		return nil, fmt.Errorf("failed to get previous file of source %s: %w", source, err)
+	}
+	return newSequenceCheck(prevLast), nil
+}
+
+// saveSequenceErrors сохраняет ошибки пропусков очередной части файла
+func saveSequenceErrors(ctx context.Context, qtx *sqlc.Queries, fileID int64, check *sequenceCheck) error {
+	for _, serr := range check.takeErrors() {
+		if _, err := qtx.CreateProcessingError(ctx, serr.ProcessingErrorParams(fileID)); err != nil {
+			return fmt.Errorf("failed to save sequence error: %w", err)
+		}
+	}
+	return nil
+}
+
+// recordSequence сохраняет диапазон n и число пропусков delta-файла.
+// Возвращает запись о файле с диапазоном.
+func recordSequence(ctx context.Context, qtx *sqlc.Queries, file sqlc.File, check *sequenceCheck) (sqlc.File, error) {
+	if err := saveSequenceErrors(ctx, qtx, file.ID, check); err != nil {
+		return file, err
+	}
+	if !check.seen {
+		return file, nil
+	}
+	updated, err := qtx.UpdateFileSequence(ctx, sqlc.UpdateFileSequenceParams{
+		ID:       file.ID,
+		SeqFirst: sql.NullInt64{Int64: check.first, Valid: true},
+		SeqLast:  sql.NullInt64{Int64: check.last, Valid: true},
+		SeqGaps:  sql.NullInt32{Int32: check.gaps, Valid: true},
+	})
+	if err != nil {
+		return file, fmt.Errorf("failed to save sequence range: %w", err)
+	}
+	return updated, nil
+}
@@ -0,0 +1,173 @@
+// internal/processor/duplicates.go
+package processor
+
+import (
+	"TSVProcessingService/db/sqlc"
+	"TSVProcessingService/internal/ingest"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+)
+
+// Виды дубликатов строк (file_duplicates.kind)
+const (
+	DuplicateExisting = "existing" // такая же строка уже есть в device_data
+	DuplicateInFile   = "in_file"  // повтор строки того же файла
+)
+
+// maxDuplicateRecords - сколько дубликатов одного файла сохраняется в
+// file_duplicates; счётчики в files считаются по всем строкам
+const maxDuplicateRecords = 10000
+
+// ValidDuplicateKind проверяет вид дубликата (пустой - все виды)
+func ValidDuplicateKind(kind string) bool {
+	switch kind {
+	case "", DuplicateExisting, DuplicateInFile:
+		return true
+	}
+	return false
+}
+
+// DuplicateCounts - итог поиска дубликатов строк файла
+type DuplicateCounts struct {
+	Existing int32 `json:"rows_duplicate_existing"`
+	InFile   int32 `json:"rows_duplicate_in_file"`
+}
+
+// SetDuplicateReport включает поиск дубликатов строк при обработке:
+// строки, совпадающие по всем полям со строками других файлов в
+// device_data или с предыдущими строками того же файла. Дубликаты
+// вставляются как обычно (политика конфликтов не меняется), отчёт
+// сохраняется в file_duplicates, счётчики - в files.
+func (p *Processor) SetDuplicateReport(enabled bool) {
+	p.duplicateReport = enabled
+}
+
+// duplicateFinder ищет дубликаты строк одного файла. Содержимое строк
+// держится в памяти только для текущей части файла (nextChunk): повторы
+// строк предыдущих частей, уже вставленных в транзакции файла, ищутся в БД.
+type duplicateFinder struct {
+	fileID  int64
+	seen    map[string]int32 // содержимое строки -> номер её первой строки в файле
+	earlier bool             // предыдущие части файла уже вставлены
+	counts  DuplicateCounts
+	records []sqlc.CreateFileDuplicateParams
+}
+
+func newDuplicateFinder(fileID int64) *duplicateFinder {
+	return &duplicateFinder{fileID: fileID, seen: make(map[string]int32)}
+}
+
+// check проверяет строку до её вставки
+func (d *duplicateFinder) check(ctx context.Context, qtx *sqlc.Queries, row ingest.Row) error {
+	params := identicalRowParams(row, d.fileID)
+
+	key := fmt.Sprintf("%v", params)
+	first, ok := d.seen[key]
+	if !ok && d.earlier {
+		line, err := qtx.GetIdenticalDeviceDataInFile(ctx, inFileRowParams(params, row.LineNumber))
+		switch {
+		case err == nil:
+			first, ok = line, true
+		case !errors.Is(err, sql.ErrNoRows):
+			return err
+		}
+	}
+	if ok {
+		d.counts.InFile++
+		d.add(row, DuplicateInFile, d.fileID, first)
+		d.seen[key] = first
+	} else {
+		d.seen[key] = row.LineNumber
+	}
+
+	existing, err := qtx.GetIdenticalDeviceData(ctx, params)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	d.counts.Existing++
+	d.add(row, DuplicateExisting, existing.FileID, existing.LineNumber)
+	return nil
+}
+
+// nextChunk забывает строки части файла после их вставки
+func (d *duplicateFinder) nextChunk() {
+	clear(d.seen)
+	d.earlier = true
+}
+
+func (d *duplicateFinder) add(row ingest.Row, kind string, ofFileID int64, ofLine int32) {
+	if len(d.records) >= maxDuplicateRecords {
+		return
+	}
+	d.records = append(d.records, sqlc.CreateFileDuplicateParams{
+		FileID:            d.fileID,
+		LineNumber:        row.LineNumber,
+		Kind:              kind,
+		UnitGuid:          row.UnitGuid,
+		MsgID:             row.MsgID,
+		DuplicateOfFileID: ofFileID,
+		DuplicateOfLine:   ofLine,
+	})
+}
+
+// save сохраняет отчёт о дубликатах и счётчики файла
+func (d *duplicateFinder) save(ctx context.Context, qtx *sqlc.Queries) error {
+	for _, record := range d.records {
+		if err := qtx.CreateFileDuplicate(ctx, record); err != nil {
+			return fmt.Errorf("failed to save duplicate of line %d: %w", record.LineNumber, err)
+		}
+	}
+	_, err := qtx.UpdateFileDuplicateStats(ctx, sqlc.UpdateFileDuplicateStatsParams{
+		ID:                    d.fileID,
+		RowsDuplicateExisting: sql.NullInt32{Int32: d.counts.Existing, Valid: true},
+		RowsDuplicateInFile:   sql.NullInt32{Int32: d.counts.InFile, Valid: true},
+	})
+	return err
+}
+
+// identicalRowParams - поиск строки с тем же содержимым в других файлах
+// (context всегда пуст и не сравнивается)
+func identicalRowParams(row ingest.Row, fileID int64) sqlc.GetIdenticalDeviceDataParams {
+	return sqlc.GetIdenticalDeviceDataParams{
+		UnitGuid:  row.UnitGuid,
+		Mqtt:      row.Mqtt,
+		Invid:     row.Invid,
+		MsgID:     row.MsgID,
+		Text:      row.Text,
+		Class:     row.Class,
+		Level:     row.Level,
+		Area:      row.Area,
+		Addr:      row.Addr,
+		Block:     row.Block,
+		Type:      row.Type,
+		Bit:       row.Bit,
+		InvertBit: row.InvertBit,
+		FileID:    fileID,
+	}
+}
+
+// inFileRowParams - поиск строки с тем же содержимым выше по тому же файлу
+func inFileRowParams(p sqlc.GetIdenticalDeviceDataParams, line int32) sqlc.GetIdenticalDeviceDataInFileParams {
+	return sqlc.GetIdenticalDeviceDataInFileParams{
+		UnitGuid:   p.UnitGuid,
+		Mqtt:       p.Mqtt,
+		Invid:      p.Invid,
+		MsgID:      p.MsgID,
+		Text:       p.Text,
+		Class:      p.Class,
+		Level:      p.Level,
+		Area:       p.Area,
+		Addr:       p.Addr,
+		Block:      p.Block,
+		Type:       p.Type,
+		Bit:        p.Bit,
+		InvertBit:  p.InvertBit,
+		FileID:     p.FileID,
+		LineNumber: line,
+	}
+}
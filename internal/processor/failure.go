@@ -0,0 +1,150 @@
+// internal/processor/failure.go
+package processor
+
+import (
+	"TSVProcessingService/db/sqlc"
+	"TSVProcessingService/internal/filestatus"
+	"TSVProcessingService/internal/ingest"
+	"TSVProcessingService/internal/watcher"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Этапы обработки без собственного бюджета времени (см. budget.go)
+const (
+	StageCheck    = "check"    // проверка, не обработан ли файл ранее
+	StageReady    = "ready"    // ожидание окончания записи файла
+	StageValidate = "validate" // предварительная проверка содержимого файла
+	StageCreate   = "create"   // начало транзакции и создание записи о файле
+	StageLock     = "lock"     // ожидание блокировок устройств (SetSerializePerUnit)
+	StageCommit   = "commit"
+	StagePanic    = "panic"
+)
+
+// maxErrorMessageLen - ограничение длины files.error_message
+const maxErrorMessageLen = 4000
+
+// StageError - ошибка этапа обработки файла
+type StageError struct {
+	Stage string
+	Err   error
+}
+
+func (e *StageError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *StageError) Unwrap() error {
+	return e.Err
+}
+
+// stageFailure помечает ошибку этапом обработки
+func stageFailure(stage string, err error) error {
+	return &StageError{Stage: stage, Err: err}
+}
+
+// failureStage определяет этап, на котором произошла ошибка
+func failureStage(err error) string {
+	var stageErr *StageError
+	if errors.As(err, &stageErr) {
+		return stageErr.Stage
+	}
+	var timeoutErr *StageTimeoutError
+	if errors.As(err, &timeoutErr) {
+		return timeoutErr.Stage
+	}
+	return "unknown"
+}
+
+// failureMessage - единый формат files.error_message: "[этап] описание"
+func failureMessage(stage, message string) string {
+	return truncate(fmt.Sprintf("[%s] %s", stage, message), maxErrorMessageLen)
+}
+
+// failFile сохраняет ошибку обработки в записи о файле
+func (p *Processor) failFile(fileInfo watcher.FileInfo, err error) {
+	stage := failureStage(err)
+	if recordErr := p.recordFailure(fileInfo, failureMessage(stage, err.Error()), err.Error()); recordErr != nil {
+		p.logger.Error("Failed to record failure", "stage", stage, "file", fileInfo.Name, "error", recordErr)
+	}
+}
+
+// recordFailure помечает файл как failed с сообщением message и
+// добавляет processing_error с кратким описанием summary. Транзакция
+// обработки к этому моменту уже откачена, поэтому запись о файле
+// может отсутствовать и создаётся заново. Файл перемещается в папку
+// ошибок, чтобы не обрабатываться повторно.
+func (p *Processor) recordFailure(fileInfo watcher.FileInfo, message, summary string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	p.RecordEvent(fileInfo.Name, EventFailed, message)
+
+	file, err := p.markFile(ctx, fileInfo, filestatus.Failed, message)
+	if err != nil {
+		return err
+	}
+
+	fileErr := ingest.RowError{ErrorMessage: truncate(summary, maxErrorMessageLen)}
+	if _, err := p.queries.CreateProcessingError(ctx, fileErr.ProcessingErrorParams(file.ID)); err != nil {
+		return fmt.Errorf("failed to save processing error: %w", err)
+	}
+
+	if _, err := p.fs.Stat(fileInfo.Path); err == nil {
+		if err := p.moveFile(fileInfo.Path, p.config.ErrorPath, fileInfo.Name); err != nil {
+			return fmt.Errorf("failed to move file to error folder: %w", err)
+		}
+		p.RecordEvent(fileInfo.Name, EventMovedToErrors, "")
+	}
+	return nil
+}
+
+// markFile выставляет файлу статус status с сообщением message. Транзакция
+// обработки откачена, поэтому запись о файле создаётся, если её нет.
+// Недопустимый переход статуса (filestatus.Transition) - ошибка.
+func (p *Processor) markFile(ctx context.Context, fileInfo watcher.FileInfo, status filestatus.Status, message string) (sqlc.File, error) {
+	file, err := p.queries.GetFileByFilename(ctx, fileInfo.Name)
+	if err == nil {
+		if err := filestatus.Transition(filestatus.Of(file.Status), status); err != nil {
+			return file, fmt.Errorf("failed to mark file %s: %w", fileInfo.Name, err)
+		}
+	}
+	if errors.Is(err, sql.ErrNoRows) {
+		file, err = p.queries.CreateFile(ctx, sqlc.CreateFileParams{
+			Filename:  fileInfo.Name,
+			FileHash:  fileInfo.Hash,
+			Status:    status.NullString(),
+			Source:    fileSource(fileInfo),
+			FileMtime: nullTime(fileInfo.ModTime),
+			ArrivedAt: nullTime(fileInfo.ArrivedAt),
+			TraceID:   nullString(fileInfo.Trace.TraceID),
+			SpanID:    nullString(fileInfo.Trace.SpanID),
+
+			SampleRate: sampleRate(fileInfo.Sampling),
+		})
+	}
+	if err != nil {
+		return file, fmt.Errorf("failed to get file record: %w", err)
+	}
+
+	if _, err := p.queries.UpdateFileWithError(ctx, sqlc.UpdateFileWithErrorParams{
+		ID:           file.ID,
+		Status:       status.NullString(),
+		ErrorMessage: sql.NullString{String: message, Valid: true},
+	}); err != nil {
+		return file, fmt.Errorf("failed to update file status: %w", err)
+	}
+	return file, nil
+}
+
+// truncate обрезает строку до max байт, не разрывая UTF-8 символы
+func truncate(s string, max int) string {
+	if len(s) <= max {
+		return s
+	}
+	return strings.ToValidUTF8(s[:max], "")
+}
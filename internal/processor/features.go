@@ -0,0 +1,28 @@
+// internal/processor/features.go
+package processor
+
+import (
+	"TSVProcessingService/internal/features"
+	"TSVProcessingService/internal/watcher"
+)
+
+// SetFeatures задаёт флаги рискованных возможностей: lenient-разбор,
+// пропуск файлов с повторным содержимым и режим delta можно выключить
+// для всех или для tenant источника без перезапуска. nil - все
+// возможности работают как настроены.
+func (p *Processor) SetFeatures(flags *features.Flags) {
+	p.features = flags
+}
+
+// featureEnabled - включена ли возможность для источника файла (files.source)
+func (p *Processor) featureEnabled(flag features.Flag, source string) bool {
+	return p.features.Enabled(flag, features.TenantOf(source))
+}
+
+// applyFeatures снимает с файла параметры выключенных возможностей
+func (p *Processor) applyFeatures(fileInfo *watcher.FileInfo) {
+	if fileInfo.Options.Lenient && !p.featureEnabled(features.LenientParsing, fileInfo.Source) {
+		p.logger.Warn("Lenient parsing is disabled by feature flag, parsing strictly", "file", fileInfo.Name)
+		fileInfo.Options.Lenient = false
+	}
+}
@@ -0,0 +1,88 @@
+// internal/processor/filename_meta.go
+package processor
+
+import (
+	"TSVProcessingService/db/sqlc"
+	"TSVProcessingService/internal/filemeta"
+	"context"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+
+	"github.com/google/uuid"
+)
+
+// SetFilenameMetadata задаёт шаблон имени файла: поля из именованных
+// групп сохраняются в files.metadata. archiveDir ("{site}/{date}") -
+// подкаталог архива для файла, reportName ("{site}_{unit_guid}_{timestamp}") -
+// имя PDF-отчёта по строкам файла (без .pdf). Если имя файла не
+// соответствует шаблону или поля шаблона нет, используется архив и
+// имя отчёта по умолчанию. nil - метаданные не извлекаются.
+func (p *Processor) SetFilenameMetadata(pattern *filemeta.Pattern, archiveDir, reportName string) {
+	p.filenamePattern = pattern
+	p.archiveLayout = archiveDir
+	p.reportName = reportName
+}
+
+// fileMetadata - поля имени файла (nil - шаблон не задан или не подошёл)
+func (p *Processor) fileMetadata(filename string) map[string]string {
+	if p.filenamePattern == nil {
+		return nil
+	}
+	meta, ok := p.filenamePattern.Match(filename)
+	if !ok {
+		return nil
+	}
+	return meta
+}
+
+// saveFileMetadata сохраняет поля имени файла в его запись
+func saveFileMetadata(ctx context.Context, qtx *sqlc.Queries, file sqlc.File, meta map[string]string) (sqlc.File, error) {
+	data, err := json.Marshal(meta)
+	if err != nil {
+		return file, err
+	}
+	updated, err := qtx.UpdateFileMetadata(ctx, sqlc.UpdateFileMetadataParams{ID: file.ID, Metadata: data})
+	if err != nil {
+		return file, fmt.Errorf("failed to save filename metadata: %w", err)
+	}
+	return updated, nil
+}
+
+// archiveDir - каталог архива для файла: подкаталог archive_dir по
+// метаданным имени или корень архива
+func (p *Processor) archiveDir(filename string) string {
+	if p.archiveLayout == "" {
+		return p.config.ArchivePath
+	}
+	meta := p.fileMetadata(filename)
+	if meta == nil {
+		return p.config.ArchivePath
+	}
+	subdir, ok := filemeta.Expand(p.archiveLayout, meta)
+	if !ok {
+		p.logger.Warn("Filename metadata does not fill archive_dir, archiving to root", "file", filename, "archive_dir", p.archiveLayout)
+		return p.config.ArchivePath
+	}
+	return filepath.Join(p.config.ArchivePath, filepath.FromSlash(subdir))
+}
+
+// reportBaseName - имя PDF-отчёта без расширения: по шаблону report_name
+// из метаданных файла или <unit_guid>_<timestamp>
+func (p *Processor) reportBaseName(unitGuid uuid.UUID, timestamp string, fileMeta map[string]string) string {
+	fallback := unitGuid.String() + "_" + timestamp
+	if p.reportName == "" || fileMeta == nil {
+		return fallback
+	}
+	values := make(map[string]string, len(fileMeta)+2)
+	for k, v := range fileMeta {
+		values[k] = v
+	}
+	values[filemeta.FieldUnitGuid] = unitGuid.String()
+	values[filemeta.FieldTimestamp] = timestamp
+	name, ok := filemeta.Expand(p.reportName, values)
+	if !ok {
+		return fallback
+	}
+	return name
+}
@@ -0,0 +1,169 @@
+// internal/processor/hooks.go
+package processor
+
+import (
+	"TSVProcessingService/db/sqlc"
+	"TSVProcessingService/internal/filestatus"
+	"TSVProcessingService/internal/watcher"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ProcessResult описывает итог обработки файла для post-processing hooks.
+type ProcessResult struct {
+	File          sqlc.File         // запись о файле после фиксации транзакции
+	FileInfo      watcher.FileInfo  // исходный файл (ещё в watch-директории)
+	Status        filestatus.Status // completed / partial / failed
+	RowsProcessed int32
+	RowsFailed    int32
+	ParseErrors   int32            // строки, отклонённые при парсинге
+	ClassCounts   map[string]int32 // распределение разобранных строк по class
+	Conflicts     ConflictCounts   // итог политики конфликтов вставки
+	ReportPaths   []string         // созданные PDF-отчёты (пусто при асинхронной генерации)
+	UnitGuids     []uuid.UUID      // устройства, данные которых изменились
+	DestPath      string           // путь, куда файл будет перемещён
+	RejectedPath  string           // <name>.rejected.tsv с ошибочными строками (пусто - не создан)
+}
+
+// PostProcessHook - действие, выполняемое после фиксации транзакции
+// и генерации отчётов, но до перемещения файла в архив/папку ошибок.
+// Ошибка hook логируется и не влияет на статус файла.
+type PostProcessHook interface {
+	Name() string
+	AfterProcess(ctx context.Context, result ProcessResult) error
+}
+
+// RegisterHook добавляет post-processing hook. Hooks выполняются
+// в порядке регистрации.
+func (p *Processor) RegisterHook(hook PostProcessHook) {
+	p.hooks = append(p.hooks, hook)
+}
+
+// runHooks последовательно вызывает зарегистрированные hooks.
+func (p *Processor) runHooks(ctx context.Context, result ProcessResult) {
+	for _, hook := range p.hooks {
+		if err := hook.AfterProcess(ctx, result); err != nil {
+			p.logger.Warn("Post-process hook failed", "hook", hook.Name(), "file", result.FileInfo.Name, "error", err)
+		}
+	}
+}
+
+// ReportListener получает каждый сохранённый отчёт (и при обработке файла,
+// и при генерации по запросу). Вызывается синхронно - долгую работу
+// слушатель должен переносить в свою очередь.
+type ReportListener func(ctx context.Context, report sqlc.Report)
+
+// OnReportCreated добавляет слушателя созданных отчётов.
+func (p *Processor) OnReportCreated(listener ReportListener) {
+	p.reported = append(p.reported, listener)
+}
+
+// notifyReport уведомляет слушателей о сохранённом отчёте.
+func (p *Processor) notifyReport(ctx context.Context, report sqlc.Report) {
+	for _, listener := range p.reported {
+		listener(ctx, report)
+	}
+}
+
+// ---------------------------------------------------------------------
+// Встроенные hooks
+// ---------------------------------------------------------------------
+
+// CopyHook копирует успешно обработанный файл в директорию выгрузки
+// (например, смонтированную FTP-директорию для downstream систем).
+type CopyHook struct {
+	dir string
+}
+
+// NewCopyHook создаёт hook копирования в dir.
+func NewCopyHook(dir string) *CopyHook {
+	return &CopyHook{dir: dir}
+}
+
+func (h *CopyHook) Name() string { return "copy" }
+
+func (h *CopyHook) AfterProcess(ctx context.Context, result ProcessResult) error {
+	if result.Status == filestatus.Failed {
+		return nil
+	}
+	if err := os.MkdirAll(h.dir, 0755); err != nil {
+		return err
+	}
+
+	src, err := os.Open(result.FileInfo.Path)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	// Пишем во временный файл и переименовываем, чтобы downstream
+	// не увидел частично скопированный файл
+	dest := filepath.Join(h.dir, result.FileInfo.Name)
+	tmp := dest + ".part"
+	dst, err := os.Create(tmp)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(dst, src); err != nil {
+		dst.Close()
+		os.Remove(tmp)
+		return err
+	}
+	if err := dst.Close(); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	return os.Rename(tmp, dest)
+}
+
+// CommandHook запускает внешний скрипт. Путь к файлу передаётся
+// последним аргументом, итог обработки - через переменные окружения TSV_*.
+type CommandHook struct {
+	command []string
+	timeout time.Duration
+}
+
+// NewCommandHook создаёт hook запуска команды (аргументы разделяются пробелами).
+func NewCommandHook(command string, timeout time.Duration) *CommandHook {
+	return &CommandHook{command: strings.Fields(command), timeout: timeout}
+}
+
+func (h *CommandHook) Name() string { return "command" }
+
+func (h *CommandHook) AfterProcess(ctx context.Context, result ProcessResult) error {
+	if len(h.command) == 0 {
+		return nil
+	}
+	if h.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, h.timeout)
+		defer cancel()
+	}
+
+	args := append(append([]string{}, h.command[1:]...), result.FileInfo.Path)
+	cmd := exec.CommandContext(ctx, h.command[0], args...)
+	cmd.Env = append(os.Environ(),
+		"TSV_FILE_ID="+strconv.FormatInt(result.File.ID, 10),
+		"TSV_FILE_NAME="+result.FileInfo.Name,
+		"TSV_FILE_PATH="+result.FileInfo.Path,
+		"TSV_DEST_PATH="+result.DestPath,
+		"TSV_STATUS="+string(result.Status),
+		"TSV_ROWS_PROCESSED="+strconv.Itoa(int(result.RowsProcessed)),
+		"TSV_ROWS_FAILED="+strconv.Itoa(int(result.RowsFailed)),
+		"TSV_REPORT_PATHS="+strings.Join(result.ReportPaths, string(os.PathListSeparator)),
+	)
+
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("%w: %s", err, strings.TrimSpace(string(output)))
+	}
+	return nil
+}
@@ -3,18 +3,28 @@ package processor
 
 import (
 	"TSVProcessingService/db/sqlc"
+	"TSVProcessingService/internal/branding"
+	"TSVProcessingService/internal/clock"
 	"TSVProcessingService/internal/config"
+	"TSVProcessingService/internal/features"
+	"TSVProcessingService/internal/filemeta"
+	"TSVProcessingService/internal/filestatus"
+	"TSVProcessingService/internal/fsys"
+	"TSVProcessingService/internal/ingest"
+	"TSVProcessingService/internal/jobs"
+	"TSVProcessingService/internal/locale"
+	"TSVProcessingService/internal/tracing"
 	"TSVProcessingService/internal/watcher"
-	"bufio"
+	"bytes"
 	"context"
 	"database/sql"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
-	"log"
+	"log/slog"
 	"os"
 	"path/filepath"
-	"strconv"
 	"strings"
 	"time"
 
@@ -24,496 +34,881 @@ import (
 
 // Processor обрабатывает TSV файлы
 type Processor struct {
-	db      *sql.DB
-	queries *sqlc.Queries
-	config  *config.DirectoryConfig
+	db       *sql.DB
+	queries  *sqlc.Queries
+	config   *config.DirectoryConfig
+	hooks    []PostProcessHook
+	reported []ReportListener // уведомления о созданных отчётах (см. hooks.go)
+	budgets  StageBudgets
+	reports  *reportQueue // nil - отчёты генерируются синхронно
+	progress *progressTracker
+	timeline *timelineTracker
+	report   config.ReportConfig // оформление и шифрование отчётов
+	reportTZ *time.Location      // часовой пояс дат отчёта (report.timezone)
+
+	reportJobs *reportJobTracker
+	unitLocks  *unitLocks // nil - устройства обрабатываются параллельно
+
+	parseSlots  stageSlots // одновременный разбор файлов (см. concurrency.go)
+	insertSlots stageSlots // одновременная вставка строк
+
+	conflictPolicy        string          // политика конфликтов по умолчанию (см. conflict.go)
+	duplicateReport       bool            // поиск дубликатов строк (см. duplicates.go)
+	allowDuplicateContent bool            // повторная обработка файла с уже обработанным содержимым (см. content_hash.go)
+	profiling             bool            // профиль значений полей файла (см. profile.go)
+	deltaSources          map[string]bool // источники выгрузок только новых строк (см. delta.go)
+	features              *features.Flags // выключение рискованных возможностей (см. features.go)
+	sequenceRuns          bool            // отрезки n устройств для диагностики (см. sequence_runs.go)
+	batchSize             int             // строк в одном INSERT при политике append (см. batch_insert.go)
+	reportMemory          int64           // бюджет памяти группировки строк для отчётов (см. report_spill.go)
+	sandbox               bool            // исходные файлы не перемещаются, а копируются (см. sandbox.go)
+	streamMinSize         int64           // файлы от этого размера обрабатываются частями (см. streaming.go)
+	streamChunk           int             // строк данных в части
+
+	filenamePattern *filemeta.Pattern // метаданные из имени файла (см. filename_meta.go)
+	archiveLayout   string            // подкаталог архива по метаданным
+	reportName      string            // имя отчёта по метаданным
+
+	jobs *jobs.Manager // журнал заданий генерации отчётов (nil - не ведётся)
+
+	wrapTx func(sqlc.DBTX) sqlc.DBTX // обёртка запросов транзакции файла (nil - без обёртки)
+
+	logger *slog.Logger // журнал процессора
+
+	retryAttempts int32                        // повторов после временной ошибки (см. retry.go)
+	retryDelay    time.Duration                // пауза перед первым повтором
+	requeue       func(watcher.FileInfo) error // постановка файла в очередь к попытке
+
+	clock clock.Clock // время ожидания записи файла и отметок обработки
+	fs    fsys.FS     // проверка готовности и перемещение входящих файлов
 }
 
 // TSVRow представляет строку из TSV файла
-type TSVRow struct {
-	UnitGuid   uuid.UUID
-	Mqtt       sql.NullString
-	Invid      sql.NullString
-	MsgID      sql.NullString
-	Text       sql.NullString
-	Context    sql.NullString
-	Class      sql.NullString
-	Level      sql.NullInt32
-	Area       sql.NullString
-	Addr       sql.NullString
-	Block      sql.NullString
-	Type       sql.NullString
-	Bit        sql.NullInt32
-	InvertBit  sql.NullBool
-	LineNumber int32
-}
+type TSVRow = ingest.Row
 
 // ProcessingError представляет ошибку обработки строки
-type ProcessingError struct {
-	LineNumber   sql.NullInt32
-	RawLine      sql.NullString
-	ErrorMessage string
-	FieldName    sql.NullString
-}
+type ProcessingError = ingest.RowError
 
 // NewProcessor создает новый процессор
 func NewProcessor(db *sql.DB, queries *sqlc.Queries, config *config.DirectoryConfig) *Processor {
 	return &Processor{
-		db:      db,
-		queries: queries,
-		config:  config,
+		db:       db,
+		queries:  queries,
+		config:   config,
+		progress: newProgressTracker(),
+		timeline: newTimelineTracker(),
+
+		reportJobs: newReportJobTracker(),
+
+		clock:  clock.Real,
+		fs:     fsys.OS,
+		logger: slog.Default().With("component", "processor"),
 	}
 }
 
+// SetLogger задаёт журнал процессора (по умолчанию - журнал slog по
+// умолчанию с component=processor)
+func (p *Processor) SetLogger(l *slog.Logger) {
+	p.logger = l
+}
+
+// SetClock подменяет часы (ожидание стабильного размера файла, прогресс,
+// хронология и отметки завершения). Должна быть вызвана до обработки.
+func (p *Processor) SetClock(c clock.Clock) {
+	p.clock = c
+	p.progress.mu.Lock()
+	p.progress.clock = c
+	p.progress.mu.Unlock()
+}
+
+// SetFS подменяет файловую систему, через которую проверяется готовность
+// файла и выполняется его перемещение в архив, error_path и hold_path.
+func (p *Processor) SetFS(fs fsys.FS) {
+	p.fs = fs
+}
+
+// SetTxWrapper оборачивает запросы sqlc в транзакции обработки файла,
+// например для учёта их длительности (database.InstrumentDB): транзакция
+// создаётся из *sql.DB процессора, а не из переданных ему запросов.
+func (p *Processor) SetTxWrapper(wrap func(sqlc.DBTX) sqlc.DBTX) {
+	p.wrapTx = wrap
+}
+
 // ---------------------------------------------------------------------
 // Основной метод обработки файла
 // ---------------------------------------------------------------------
 
-// ProcessFile – основной метод обработки одного TSV файла
-func (p *Processor) ProcessFile(ctx context.Context, fileInfo watcher.FileInfo) error {
-	log.Printf("[Processor] 🔄 Processing file: %s", fileInfo.Name)
+// ProcessFile – основной метод обработки одного TSV файла.
+// Любая ошибка обработки сохраняется в записи о файле (status=failed,
+// error_message с указанием этапа), см. failure.go. Исключение -
+// ErrNotReady: файл, который ещё дописывается, остаётся в watch-директории.
+func (p *Processor) ProcessFile(ctx context.Context, fileInfo watcher.FileInfo) (err error) {
+	// Обработка - span в трассе поставившего файл запроса (или новая трасса);
+	// trace_id/span_id сохраняются в записи о файле
+	fileInfo.Trace = tracing.NewSpan(fileInfo.Trace)
+	ctx = tracing.ContextWithSpan(ctx, fileInfo.Trace)
+
+	// Хронология сохраняется последней, вместе с событиями ошибки или panic
+	defer p.flushTimeline(fileInfo.Name)
+
+	// panic не должна убивать воркер: файл помечается failed (см. recover.go)
+	defer p.recoverPanic(fileInfo, &err)
+
+	if err := p.processFile(ctx, fileInfo); err != nil {
+		// Отмена оператором - не ошибка файла: он откладывается в hold_path
+		if cancelled(ctx) {
+			if cancelErr := p.cancelFile(fileInfo); cancelErr != nil {
+				p.logger.Error("Failed to record cancellation", "file", fileInfo.Name, "error", cancelErr)
+			}
+			return stageFailure(StageCancel, fmt.Errorf("%w: %v", ErrCancelled, err))
+		}
+		// Файл ещё дописывается: он остаётся в watch-директории и
+		// ставится в очередь следующим сканированием, когда размер перестанет меняться
+		if errors.Is(err, ErrNotReady) {
+			p.RecordEvent(fileInfo.Name, EventNotReady, err.Error())
+			p.logger.Warn("File is still being written, leaving it for the next scan", "file", fileInfo.Name, "error", err)
+			return err
+		}
+		// Временная ошибка: файл остаётся в watch-директории до повтора
+		if p.scheduleRetry(ctx, fileInfo, err) {
+			return err
+		}
+		p.failFile(fileInfo, err)
+		return err
+	}
+	return nil
+}
+
+// nullTime - время или NULL для нулевого значения
+func nullTime(t time.Time) sql.NullTime {
+	return sql.NullTime{Time: t, Valid: !t.IsZero()}
+}
+
+// nullString - строка или NULL для пустого значения
+func nullString(s string) sql.NullString {
+	return sql.NullString{String: s, Valid: s != ""}
+}
+
+// sampleRate - доля загруженных строк или NULL для полной загрузки
+func sampleRate(s ingest.Sampling) sql.NullFloat64 {
+	return sql.NullFloat64{Float64: s.Rate(), Valid: s.Enabled()}
+}
+
+// fileSource - источник файла для files.source (для статистики по источникам)
+func fileSource(fileInfo watcher.FileInfo) string {
+	if fileInfo.Source == "" {
+		return "unknown"
+	}
+	return fileInfo.Source
+}
+
+// queueDetail - описание постановки в очередь для хронологии
+func queueDetail(fileInfo watcher.FileInfo) string {
+	switch {
+	case fileInfo.BatchID != "":
+		return "batch " + fileInfo.BatchID
+	case fileInfo.Priority > 0:
+		return "prioritized"
+	}
+	return ""
+}
+
+// processFile – этапы обработки файла; ошибки помечаются этапом
+func (p *Processor) processFile(ctx context.Context, fileInfo watcher.FileInfo) error {
+	p.logger.Info("🔄 Processing file", "file", fileInfo.Name, "trace_id", fileInfo.Trace.TraceID)
 
 	// 1. СНАЧАЛА проверяем, не был ли этот файл уже обработан
 	existingFile, err := p.queries.GetFileByFilename(ctx, fileInfo.Name)
+	if err == nil && filestatus.Of(existingFile.Status) == filestatus.Cancelled {
+		if err := p.resumeCancelled(ctx, existingFile); err != nil {
+			return stageFailure(StageCheck, err)
+		}
+		err = sql.ErrNoRows
+	}
+	if err == nil && filestatus.Of(existingFile.Status) == filestatus.Retrying {
+		due, dueErr := p.retryDue(ctx, existingFile)
+		if dueErr != nil {
+			return stageFailure(StageCheck, dueErr)
+		}
+		if !due {
+			p.logger.Debug("Retry is not due yet, skipping", "file", fileInfo.Name)
+			watcher.Skipped(watcher.SkipRetryPending)
+			return nil
+		}
+		err = sql.ErrNoRows
+	}
 	if err == nil {
-		log.Printf("[Processor] File %s already processed (status: %s)", fileInfo.Name, existingFile.Status.String)
-		p.moveExistingFile(fileInfo.Path, existingFile.Status.String)
+		p.logger.Info("File already processed", "file", fileInfo.Name, "status", existingFile.Status.String)
+		p.RecordEvent(fileInfo.Name, EventSkipped, "already processed, status "+existingFile.Status.String)
+		watcher.Skipped(watcher.SkipAlreadyProcessed)
+		p.moveExistingFile(fileInfo.Path, filestatus.Of(existingFile.Status))
 		return nil
 	}
 	if !errors.Is(err, sql.ErrNoRows) {
-		return fmt.Errorf("failed to check existing file: %w", err)
+		return stageFailure(StageCheck, fmt.Errorf("failed to check existing file: %w", err))
+	}
+	p.recordEventAt(fileInfo.Name, EventDetected, fileSource(fileInfo), fileInfo.ArrivedAt)
+	p.recordEventAt(fileInfo.Name, EventQueued, queueDetail(fileInfo), fileInfo.QueuedAt)
+
+	// Тот же файл под другим именем (по хешу содержимого)
+	if skipped, err := p.skipDuplicateContent(ctx, fileInfo); err != nil {
+		return stageFailure(StageCheck, err)
+	} else if skipped {
+		return nil
 	}
 
 	// 2. ТОЛЬКО ТЕПЕРЬ проверяем, готов ли файл к чтению
 	if err := p.waitForFileReady(fileInfo.Path, 10*time.Second); err != nil {
-		return fmt.Errorf("file not ready: %w", err)
+		return stageFailure(StageReady, fmt.Errorf("file not ready: %w", err))
+	}
+
+	p.applyFeatures(&fileInfo)
+
+	// Пустые, бинарные и не-TSV файлы отклоняются до разбора
+	if err := ingest.ValidateWithOptions(fileInfo.Path, fileInfo.Options); err != nil {
+		return stageFailure(StageValidate, fmt.Errorf("file rejected: %w", err))
+	}
+
+	conflictPolicy := p.resolveConflictPolicy(fileInfo.ConflictPolicy)
+	if !ValidConflictPolicy(conflictPolicy) {
+		return stageFailure(StageValidate, fmt.Errorf("unknown conflict policy %q", conflictPolicy))
 	}
 
+	// Общий бюджет времени делится между этапами (см. SetStageBudgets)
+	total := totalBudget(ctx)
+
 	// 3. Транзакционная обработка файла
 	tx, err := p.db.BeginTx(ctx, nil)
 	if err != nil {
-		return fmt.Errorf("failed to begin transaction: %w", err)
+		return stageFailure(StageCreate, fmt.Errorf("failed to begin transaction: %w", err))
 	}
 	defer tx.Rollback()
 
-	qtx := p.queries.WithTx(tx)
+	var txdb sqlc.DBTX = tx
+	if p.wrapTx != nil {
+		txdb = p.wrapTx(tx)
+	}
+	qtx := sqlc.New(txdb)
 
 	// 4. Создание записи о файле
 	fileParams := sqlc.CreateFileParams{
-		Filename: fileInfo.Name,
-		FileHash: fileInfo.Hash,
-		Status:   sql.NullString{String: "processing", Valid: true},
+		Filename:  fileInfo.Name,
+		FileHash:  fileInfo.Hash,
+		Status:    filestatus.Processing.NullString(),
+		Source:    fileSource(fileInfo),
+		FileMtime: nullTime(fileInfo.ModTime),
+		ArrivedAt: nullTime(fileInfo.ArrivedAt),
+		TraceID:   nullString(fileInfo.Trace.TraceID),
+		SpanID:    nullString(fileInfo.Trace.SpanID),
+
+		SampleRate: sampleRate(fileInfo.Sampling),
 	}
 	file, err := qtx.CreateFile(ctx, fileParams)
 	if err != nil {
-		return fmt.Errorf("failed to create file record: %w", err)
+		return stageFailure(StageCreate, fmt.Errorf("failed to create file record: %w", err))
+	}
+	p.logger.Debug("Created file record", "file", fileInfo.Name, "file_id", file.ID)
+	if meta := p.fileMetadata(fileInfo.Name); meta != nil {
+		if file, err = saveFileMetadata(ctx, qtx, file, meta); err != nil {
+			return stageFailure(StageCreate, err)
+		}
+	} else if p.filenamePattern != nil {
+		p.logger.Warn("Filename does not match filename_metadata.pattern", "file", fileInfo.Name)
 	}
-	log.Printf("[Processor] Created file record ID: %d", file.ID)
+
+	p.progress.start(file.ID, fileInfo.Name)
+	defer p.progress.finish(fileInfo.Name)
 
 	// 5. Парсинг TSV (новая реализация)
-	rows, parseErrors := p.parseTSVFile(fileInfo.Path, file.ID)
+	// В режиме выборки разбираются только отобранные строки данных;
+	// формат и кодировка могут быть заданы sidecar-файлом
+	var parseDetails []string
+	if fileInfo.Sampling.Enabled() {
+		parseDetails = append(parseDetails, "sample: "+fileInfo.Sampling.String())
+	}
+	if !fileInfo.Options.IsZero() {
+		parseDetails = append(parseDetails, fileInfo.Options.String())
+	}
+	p.RecordEvent(fileInfo.Name, EventParseStarted, strings.Join(parseDetails, "; "))
 
-	// 6. Сохранение ошибок парсинга
-	for _, perr := range parseErrors {
-		errParams := sqlc.CreateProcessingErrorParams{
-			FileID:       file.ID,
-			LineNumber:   perr.LineNumber,
-			RawLine:      perr.RawLine,
-			ErrorMessage: perr.ErrorMessage,
-			FieldName:    perr.FieldName,
+	// Большие файлы разбираются и сохраняются частями (SetStreaming)
+	streaming := p.streams(fileInfo)
+	var rows []TSVRow
+	var parseErrors []ProcessingError
+	var unitGuids []uuid.UUID
+	if streaming {
+		p.logger.Info("🌊 Streaming file in chunks", "file", fileInfo.Name, "size", fileInfo.Size, "chunk_rows", p.streamChunk)
+		scanCtx, cancelScan, scanBudget := p.stageContext(ctx, StageParse, total)
+		release, err := p.parseSlots.acquire(scanCtx)
+		if err != nil {
+			cancelScan()
+			return stageError(StageParse, scanBudget, err)
 		}
-		if _, err := qtx.CreateProcessingError(ctx, errParams); err != nil {
-			log.Printf("[Processor] Failed to save processing error: %v", err)
+		unitGuids, err = p.streamUnitGuids(scanCtx, fileInfo)
+		release()
+		scanErr := scanCtx.Err()
+		cancelScan()
+		if scanErr != nil {
+			return stageError(StageParse, scanBudget, scanErr)
 		}
+		if err != nil {
+			return stageFailure(StageParse, fmt.Errorf("failed to scan units: %w", err))
+		}
+	} else {
+		parseCtx, cancelParse, parseBudget := p.stageContext(ctx, StageParse, total)
+		release, err := p.parseSlots.acquire(parseCtx)
+		if err != nil {
+			cancelParse()
+			return stageError(StageParse, parseBudget, err)
+		}
+		rows, parseErrors = ingest.ParseFileWithOptions(parseCtx, fileInfo.Path, fileInfo.Sampling, fileInfo.Options)
+		release()
+		parseErr := parseCtx.Err()
+		cancelParse()
+		if parseErr != nil {
+			return stageError(StageParse, parseBudget, parseErr)
+		}
+		p.RecordEvent(fileInfo.Name, EventParseFinished,
+			fmt.Sprintf("%d rows, %d parse errors", len(rows), len(parseErrors)))
+		unitGuids = uniqueUnitGuids(rows)
 	}
 
-	// 7. Сохранение валидных строк в device_data
-	successCount := int32(0)
-	failedCount := int32(0)
+	// Данные и отчёты одного устройства не обрабатываются параллельно
+	// (SetSerializePerUnit); блокировки держатся до конца обработки файла
+	unlockUnits, err := p.lockUnits(ctx, unitGuids)
+	if err != nil {
+		return stageFailure(StageLock, fmt.Errorf("failed to lock units: %w", err))
+	}
+	defer unlockUnits()
 
-	for _, row := range rows {
-		params := sqlc.CreateDeviceDataParams{
-			FileID:     file.ID,
-			UnitGuid:   row.UnitGuid,
-			Mqtt:       row.Mqtt,
-			Invid:      row.Invid,
-			MsgID:      row.MsgID,
-			Text:       row.Text,
-			Context:    row.Context,
-			Class:      row.Class,
-			Level:      row.Level,
-			Area:       row.Area,
-			Addr:       row.Addr,
-			Block:      row.Block,
-			Type:       row.Type,
-			Bit:        row.Bit,
-			InvertBit:  row.InvertBit,
-			LineNumber: row.LineNumber,
-		}
-		if _, err := qtx.CreateDeviceData(ctx, params); err != nil {
-			log.Printf("[Processor] ❌ Error inserting device data: %v", err)
-			failedCount++
-		} else {
-			successCount++
+	if p.profiling && streaming {
+		p.logger.Debug("Field profile skipped: file is streamed", "file", fileInfo.Name)
+	} else if p.profiling {
+		if err := saveProfile(ctx, qtx, file.ID, rows); err != nil {
+			p.logger.Error("Failed to save field profile", "file", fileInfo.Name, "error", err)
 		}
 	}
 
-	// 8. Обновление статистики файла
-	updateParams := sqlc.UpdateFileProgressParams{
-		ID:            file.ID,
-		RowsProcessed: sql.NullInt32{Int32: successCount, Valid: true},
-		RowsFailed:    sql.NullInt32{Int32: failedCount, Valid: true},
+	// Delta-файл: n продолжает последовательность предыдущего файла источника.
+	// Выборка и исправления rejected-файлов содержат не все строки - не проверяются.
+	full := !fileInfo.Sampling.Enabled() && p.correctionOriginal(fileInfo) == ""
+	checkDelta := p.deltaSources[file.Source] && full && p.featureEnabled(features.DeltaMode, file.Source)
+	// Отрезки n устройств для поиска пропусков между файлами (по тем же причинам
+	// без выборки и исправлений)
+	checkRuns := p.sequenceRuns && full
+	var sequence *sequenceCheck
+	if checkDelta {
+		if sequence, err = startSequence(ctx, qtx, file.Source); err != nil {
+			return stageFailure(StageInsert, err)
+		}
 	}
-	if _, err := qtx.UpdateFileProgress(ctx, updateParams); err != nil {
-		log.Printf("[Processor] Failed to update file progress: %v", err)
+	var runs *unitRuns
+	if checkRuns {
+		runs = newUnitRuns()
 	}
 
-	// 9. Определение финального статуса
-	status := "completed"
-	if successCount == 0 {
-		status = "failed"
-	} else if failedCount > 0 {
-		status = "partial"
-	}
-	statusParams := sqlc.UpdateFileStatusParams{
-		ID:     file.ID,
-		Status: sql.NullString{String: status, Valid: true},
-	}
-	if _, err := qtx.UpdateFileStatus(ctx, statusParams); err != nil {
-		log.Printf("[Processor] Failed to update file status: %v", err)
-	}
+	successCount := int32(0)
+	failedCount := int32(0)
+	parseErrorCount := int32(0)
+	rowsTotal := int32(0)
+	var conflicts ConflictCounts
+	rejected := p.newRejectedLines()
+	defer rejected.remove()
+	summary := make(dailySummary)
+	stats := newRowStats()
+
+	// Строки для отчётов; после передачи генерации удаляются ею
+	groups := p.newUnitGroups()
+	groupsOwned := true
+	defer func() {
+		if groupsOwned {
+			groups.remove()
+		}
+	}()
 
-	// 10. Фиксация транзакции
-	if err := tx.Commit(); err != nil {
-		return fmt.Errorf("failed to commit transaction: %w", err)
+	var insertCtx context.Context
+	var cancelInsert context.CancelFunc
+	var insertBudget time.Duration
+	if streaming {
+		insertCtx, cancelInsert, insertBudget = p.streamContext(ctx, total)
+	} else {
+		insertCtx, cancelInsert, insertBudget = p.stageContext(ctx, StageInsert, total)
 	}
-	log.Printf("[Processor] ✅ Transaction committed for file %s", fileInfo.Name)
+	defer cancelInsert()
+
+	p.progress.beginInsert(fileInfo.Name, 0)
 
-	// 11. Генерация PDF‑отчётов для каждого unit_guid (вне транзакции)
-	if err := p.generateReports(ctx, file.ID, rows); err != nil {
-		log.Printf("[Processor] Error generating reports: %v", err)
+	var duplicates *duplicateFinder
+	if p.duplicateReport {
+		duplicates = newDuplicateFinder(file.ID)
 	}
 
-	// 12. Перемещение файла в архив или папку ошибок
-	if status == "completed" || status == "partial" {
-		if err := p.moveFile(fileInfo.Path, p.config.ArchivePath, fileInfo.Name); err != nil {
-			log.Printf("[Processor] Failed to archive file %s: %v", fileInfo.Name, err)
-		} else {
-			log.Printf("[Processor] 📦 File moved to archive: %s", fileInfo.Name)
+	// Без сверки с существующими строками вставка идёт пачками (SetBatchSize)
+	var batch *rowBatch
+	if conflictPolicy == ConflictAppend && p.batchSize > 1 {
+		batch = &rowBatch{db: txdb, rows: make([]TSVRow, 0, p.batchSize)}
+	}
+	inserted := func(row TSVRow, outcome string, err error) {
+		if err != nil {
+			p.logger.Warn("Row rejected by database", "file", fileInfo.Name, "line", row.LineNumber, "error", err)
+			failedCount++
+			rejected.add(row.LineNumber, err.Error())
+			return
 		}
-	} else {
-		if err := p.moveFile(fileInfo.Path, p.config.ErrorPath, fileInfo.Name); err != nil {
-			log.Printf("[Processor] Failed to move failed file %s: %v", fileInfo.Name, err)
-		} else {
-			log.Printf("[Processor] ⚠️ File moved to error folder: %s", fileInfo.Name)
+		conflicts.add(outcome)
+		if outcome != outcomeSkipped {
+			successCount++
+			summary.add(row)
 		}
 	}
-
-	log.Printf("[Processor] ✅ Finished processing %s (success: %d, failed: %d)",
-		fileInfo.Name, successCount, failedCount)
-	return nil
-}
-
-// ---------------------------------------------------------------------
-// Новая реализация парсинга TSV (без encoding/csv)
-// ---------------------------------------------------------------------
-
-// parseTSVFile открывает файл и построчно разбирает его.
-// Разделитель – строго символ табуляции ('\t').
-func (p *Processor) parseTSVFile(filePath string, fileID int64) ([]TSVRow, []ProcessingError) {
-	log.Printf("[Processor] 🔍 Parsing TSV (simple split): %s", filePath)
-
-	f, err := os.Open(filePath)
-	if err != nil {
-		return nil, []ProcessingError{{
-			ErrorMessage: fmt.Sprintf("failed to open file: %v", err),
-		}}
+	reportProgress := func() {
+		if p.progress.due(fileInfo.Name, successCount, failedCount) {
+			p.progress.update(fileInfo.Name, successCount, failedCount)
+			p.RecordEvent(fileInfo.Name, EventInsertChunk,
+				fmt.Sprintf("%d/%d rows, %d failed", successCount+failedCount, rowsTotal, failedCount))
+		}
 	}
-	defer f.Close()
-
-	var rows []TSVRow
-	var errors []ProcessingError
-	lineNumber := int32(0)
-	scanner := bufio.NewScanner(f)
 
-	for scanner.Scan() {
-		line := scanner.Text()
-		lineNumber++
-
-		// Пропускаем пустые строки
-		if strings.TrimSpace(line) == "" {
-			continue
+	// 6-7. Сохранение ошибок парсинга и валидных строк в device_data с учётом
+	// политики конфликтов: весь файл одной частью или по частям при потоковой
+	// обработке
+	persist := func(chunkRows []TSVRow, chunkErrors []ProcessingError) error {
+		if err := insertCtx.Err(); err != nil {
+			return stageError(StageInsert, insertBudget, err)
 		}
-
-		// Пропускаем комментарии (начинаются с #)
-		if strings.HasPrefix(strings.TrimSpace(line), "#") {
-			continue
+		for _, perr := range chunkErrors {
+			if _, err := qtx.CreateProcessingError(ctx, perr.ProcessingErrorParams(file.ID)); err != nil {
+				p.logger.Error("Failed to save processing error", "file", fileInfo.Name, "error", err)
+			}
+		}
+		rejected.addParseErrors(chunkErrors)
+		parseErrorCount += int32(len(chunkErrors))
+		if checkDelta || checkRuns {
+			lines := sequenceLines(chunkRows, chunkErrors)
+			if checkDelta {
+				sequence.add(lines)
+				if err := saveSequenceErrors(ctx, qtx, file.ID, sequence); err != nil {
+					return stageFailure(StageInsert, err)
+				}
+			}
+			if checkRuns {
+				runs.add(lines)
+				if err := runs.save(ctx, qtx, file.ID, false); err != nil {
+					return stageFailure(StageInsert, err)
+				}
+			}
 		}
+		stats.add(chunkRows)
+		for _, row := range chunkRows {
+			groups.add(row)
+		}
+		rowsTotal += int32(len(chunkRows))
+		p.progress.addTotal(fileInfo.Name, int32(len(chunkRows)))
 
-		// Разбиваем по табуляции
-		fields := strings.Split(line, "\t")
+		for _, row := range chunkRows {
+			if err := insertCtx.Err(); err != nil {
+				return stageError(StageInsert, insertBudget, err)
+			}
 
-		// Пропускаем строку заголовка (первое поле не является числом)
-		if len(fields) > 0 {
-			if _, err := strconv.Atoi(strings.TrimSpace(fields[0])); err != nil {
-				log.Printf("[Processor] Skipping header line: %s", line)
-				continue
+			// Дубликаты ищутся до вставки, чтобы строка не нашла саму себя
+			if duplicates != nil {
+				if err := duplicates.check(insertCtx, qtx, row); err != nil {
+					if insertCtx.Err() != nil {
+						return stageError(StageInsert, insertBudget, insertCtx.Err())
+					}
+					return stageFailure(StageInsert, fmt.Errorf("failed to look up duplicate rows: %w", err))
+				}
 			}
-		}
 
-		// Минимальное количество полей: n, mqtt, invid, unit_guid
-		if len(fields) < 4 {
-			errors = append(errors, ProcessingError{
-				LineNumber:   sql.NullInt32{Int32: lineNumber, Valid: true},
-				RawLine:      sql.NullString{String: line, Valid: true},
-				ErrorMessage: fmt.Sprintf("insufficient fields: got %d, need at least 4", len(fields)),
-			})
-			continue
+			if batch != nil {
+				batch.rows = append(batch.rows, row)
+				if len(batch.rows) < p.batchSize {
+					continue
+				}
+				if err := batch.flush(insertCtx, file.ID, inserted); err != nil {
+					return insertFailure(insertBudget, err)
+				}
+			} else {
+				outcome, err := insertRowSavepoint(insertCtx, txdb, qtx, conflictPolicy, row.DeviceDataParams(file.ID))
+				if err != nil && insertCtx.Err() != nil {
+					return stageError(StageInsert, insertBudget, insertCtx.Err())
+				}
+				if isSavepointError(err) {
+					return stageFailure(StageInsert, err)
+				}
+				inserted(row, outcome, err)
+			}
+			reportProgress()
+		}
+		if batch != nil {
+			if err := batch.flush(insertCtx, file.ID, inserted); err != nil {
+				return insertFailure(insertBudget, err)
+			}
+			reportProgress()
+		}
+		if !streaming {
+			return nil
 		}
 
-		// Парсинг полей
-		row, parseErr := p.parseLine(fields, lineNumber)
-		if parseErr != nil {
-			errors = append(errors, ProcessingError{
-				LineNumber:   sql.NullInt32{Int32: lineNumber, Valid: true},
-				RawLine:      sql.NullString{String: line, Valid: true},
-				ErrorMessage: parseErr.Error(),
-			})
-			continue
+		// Строки части сохранены: в памяти остаётся только то, что не
+		// растёт с размером файла
+		if err := rejected.flush(); err != nil {
+			p.logger.Warn("Failed to spill rejected lines to disk, keeping them in memory", "file", fileInfo.Name, "error", err)
+		}
+		if duplicates != nil {
+			duplicates.nextChunk()
 		}
-		rows = append(rows, row)
+		p.progress.update(fileInfo.Name, successCount, failedCount)
+		return nil
 	}
 
-	if err := scanner.Err(); err != nil {
-		errors = append(errors, ProcessingError{
-			ErrorMessage: fmt.Sprintf("scanner error: %v", err),
-		})
+	releaseInsert, err := p.insertSlots.acquire(insertCtx)
+	if err != nil {
+		return stageError(StageInsert, insertBudget, err)
 	}
-
-	log.Printf("[Processor] 📊 Parsed %d rows, %d errors", len(rows), len(errors))
-	return rows, errors
-}
-
-// parseLine преобразует массив полей в TSVRow.
-// Индексы колонок (начиная с 0):
-//   0: n
-//   1: mqtt (всегда пусто)
-//   2: invid
-//   3: unit_guid
-//   4: msg_id
-//   5: text
-//   6: context
-//   7: class
-//   8: level
-//   9: area
-//  10: addr
-//  11: block
-//  12: type
-//  13: bit
-//  14: invert_bit
-func (p *Processor) parseLine(fields []string, lineNumber int32) (TSVRow, error) {
-	row := TSVRow{LineNumber: lineNumber}
-
-	// UUID на позиции 3 – строго обязателен
-	guidStr := strings.TrimSpace(fields[3])
-	guid, err := uuid.Parse(guidStr)
+	if streaming {
+		err = ingest.StreamFile(insertCtx, fileInfo.Path, fileInfo.Sampling, fileInfo.Options, p.streamChunk, persist)
+	} else {
+		err = persist(rows, parseErrors)
+	}
+	releaseInsert()
 	if err != nil {
-		return row, fmt.Errorf("invalid unit_guid at column 4: %w", err)
+		return err
 	}
-	row.UnitGuid = guid
-
-	// invid (индекс 2)
-	if val := strings.TrimSpace(fields[2]); val != "" {
-		row.Invid = sql.NullString{String: val, Valid: true}
+	if streaming {
+		p.RecordEvent(fileInfo.Name, EventParseFinished,
+			fmt.Sprintf("%d rows, %d parse errors", rowsTotal, parseErrorCount))
 	}
+	groups.logSpilled()
 
-	// msg_id (индекс 4)
-	if len(fields) > 4 {
-		if val := strings.TrimSpace(fields[4]); val != "" {
-			row.MsgID = sql.NullString{String: val, Valid: true}
+	if checkDelta {
+		updated, err := recordSequence(ctx, qtx, file, sequence)
+		if err != nil {
+			return stageFailure(StageInsert, err)
+		}
+		file = updated
+		if sequence.errorCount > 0 {
+			p.logger.Warn("Sequence check found gaps", "file", fileInfo.Name, "first", sequence.first, "last", sequence.last,
+				"missing", sequence.gaps, "errors", sequence.errorCount)
 		}
 	}
-
-	// text (индекс 5)
-	if len(fields) > 5 {
-		if val := strings.TrimSpace(fields[5]); val != "" {
-			row.Text = sql.NullString{String: val, Valid: true}
+	if checkRuns {
+		if err := runs.save(ctx, qtx, file.ID, true); err != nil {
+			return stageFailure(StageInsert, err)
 		}
 	}
 
-	// context (индекс 6) – игнорируем (всегда NULL)
-
-	// class (индекс 7)
-	if len(fields) > 7 {
-		if val := strings.TrimSpace(fields[7]); val != "" {
-			if isValidClass(val) {
-				row.Class = sql.NullString{String: val, Valid: true}
-			} else {
-				return row, fmt.Errorf("invalid class value: %s", val)
-			}
+	// 8. Обновление статистики файла. Итоги строк пишутся один раз, после
+	// вставки всех частей, в транзакции файла: запись о файле создана этой
+	// транзакцией, и до commit её счётчики больше никто не меняет (прогресс
+	// во время вставки публикуется отдельно, см. progressPublisher).
+	progressParams := sqlc.IncrementFileProgressParams{
+		ProcessedDelta: successCount,
+		FailedDelta:    failedCount,
+		ID:             file.ID,
+	}
+	if err := qtx.IncrementFileProgress(ctx, progressParams); err != nil {
+		p.logger.Error("Failed to update file progress", "file", fileInfo.Name, "error", err)
+	}
+	conflictParams := sqlc.UpdateFileConflictStatsParams{
+		ID:              file.ID,
+		ConflictPolicy:  sql.NullString{String: conflictPolicy, Valid: true},
+		RowsSkipped:     sql.NullInt32{Int32: conflicts.Skipped, Valid: true},
+		RowsOverwritten: sql.NullInt32{Int32: conflicts.Overwritten, Valid: true},
+		RowsVersioned:   sql.NullInt32{Int32: conflicts.Versioned, Valid: true},
+	}
+	if _, err := qtx.UpdateFileConflictStats(ctx, conflictParams); err != nil {
+		p.logger.Error("Failed to update file conflict stats", "file", fileInfo.Name, "error", err)
+	}
+	if duplicates != nil {
+		if err := duplicates.save(ctx, qtx); err != nil {
+			p.logger.Error("Failed to save duplicate report", "file", fileInfo.Name, "error", err)
+		} else if duplicates.counts != (DuplicateCounts{}) {
+			p.logger.Info("🔁 Duplicate rows found", "file", fileInfo.Name,
+				"existing", duplicates.counts.Existing, "in_file", duplicates.counts.InFile)
 		}
 	}
 
-	// level (индекс 8)
-	if len(fields) > 8 {
-		val := strings.TrimSpace(fields[8])
-		if val != "" {
-			level, err := strconv.ParseInt(val, 10, 32)
-			if err != nil {
-				return row, fmt.Errorf("invalid level (not integer): %s", val)
-			}
-			row.Level = sql.NullInt32{Int32: int32(level), Valid: true}
-		}
+	if err := summary.save(ctx, qtx, p.clock.Now()); err != nil {
+		p.logger.Error("Failed to update unit daily summary", "file", fileInfo.Name, "error", err)
 	}
 
-	// area (индекс 9)
-	if len(fields) > 9 {
-		if val := strings.TrimSpace(fields[9]); val != "" {
-			row.Area = sql.NullString{String: val, Valid: true}
+	// Возвращённый исправленный rejected-файл: строки засчитываются исходному файлу
+	var corrected *sqlc.File
+	if originalName := p.correctionOriginal(fileInfo); originalName != "" {
+		original, ok, err := p.mergeCorrection(ctx, qtx, file, originalName, successCount)
+		if err != nil {
+			return stageFailure(StageInsert, fmt.Errorf("failed to merge correction into %s: %w", originalName, err))
+		}
+		if ok {
+			corrected = &original
 		}
 	}
 
-	// addr (индекс 10)
-	if len(fields) > 10 {
-		if val := strings.TrimSpace(fields[10]); val != "" {
-			row.Addr = sql.NullString{String: val, Valid: true}
-		}
+	// 9. Определение финального статуса
+	// Файл, все строки которого пропущены политикой skip, обработан успешно
+	status := filestatus.Completed
+	if successCount == 0 && conflicts.Skipped == 0 {
+		status = filestatus.Failed
+	} else if failedCount > 0 {
+		status = filestatus.Partial
+	}
+	if err := filestatus.Transition(filestatus.Of(file.Status), status); err != nil {
+		return stageFailure(StageCommit, err)
+	}
+	// completed_at - момент, с которого данные доступны (фиксация ниже)
+	statusParams := sqlc.CompleteFileParams{
+		ID:          file.ID,
+		Status:      status.NullString(),
+		CompletedAt: sql.NullTime{Time: p.clock.Now(), Valid: true},
+	}
+	if updated, err := qtx.CompleteFile(ctx, statusParams); err != nil {
+		p.logger.Error("Failed to update file status", "file", fileInfo.Name, "error", err)
+	} else {
+		file = updated
 	}
 
-	// block (индекс 11)
-	if len(fields) > 11 {
-		if val := strings.TrimSpace(fields[11]); val != "" {
-			row.Block = sql.NullString{String: val, Valid: true}
+	// Попытки повторной обработки больше не нужны (см. retry.go)
+	if p.retryAttempts > 0 {
+		if err := qtx.DeleteFileRetry(ctx, fileInfo.Name); err != nil {
+			return stageFailure(StageCommit, fmt.Errorf("failed to clear retry state: %w", err))
 		}
 	}
 
-	// type (индекс 12)
-	if len(fields) > 12 {
-		if val := strings.TrimSpace(fields[12]); val != "" {
-			row.Type = sql.NullString{String: val, Valid: true}
+	// 10. Фиксация транзакции
+	if err := tx.Commit(); err != nil {
+		return stageFailure(StageCommit, fmt.Errorf("failed to commit transaction: %w", err))
+	}
+	p.logger.Debug("Transaction committed", "file", fileInfo.Name)
+	p.RecordEvent(fileInfo.Name, EventCommitted, string(status))
+	if corrected != nil {
+		// Хронология исходного файла сохраняется сразу: он не обрабатывается
+		p.RecordEvent(corrected.Filename, EventCorrectionMerged, corrected.CorrectionNote.String)
+		p.flushTimeline(corrected.Filename)
+	}
+	p.progress.finish(fileInfo.Name)
+
+	// 11. Генерация PDF‑отчётов для каждого unit_guid (вне транзакции).
+	// При асинхронном режиме отчёты уходят в очередь и не задерживают архивирование.
+	var reportPaths []string
+	source := fileSource(fileInfo)
+	groupsOwned = false
+	if err := p.enqueueFileReports(fileInfo.Trace, file.ID, fileInfo.Name, source, groups); err == nil {
+		p.logger.Info("📄 Reports queued", "file", fileInfo.Name)
+		p.RecordEvent(fileInfo.Name, EventReportsQueued, "")
+	} else {
+		if p.reports != nil {
+			p.logger.Warn("Report queue unavailable, generating reports synchronously", "file", fileInfo.Name, "error", err)
+		}
+		reportCtx, cancelReport, reportBudget := p.stageContext(ctx, StageReport, total)
+		reportPaths, err = p.generateReports(reportCtx, file.ID, fileInfo.Name, source, groups)
+		groups.remove()
+		if reportCtx.Err() != nil {
+			err = stageError(StageReport, reportBudget, reportCtx.Err())
 		}
+		cancelReport()
+		if err != nil {
+			p.logger.Error("Failed to generate reports", "file", fileInfo.Name, "error", err)
+		}
+		p.RecordEvent(fileInfo.Name, EventReportsGenerated, fmt.Sprintf("%d reports", len(reportPaths)))
 	}
 
-	// bit (индекс 13)
-	if len(fields) > 13 {
-		val := strings.TrimSpace(fields[13])
-		if val != "" {
-			bit, err := strconv.ParseInt(val, 10, 32)
-			if err != nil {
-				return row, fmt.Errorf("invalid bit (not integer): %s", val)
-			}
-			row.Bit = sql.NullInt32{Int32: int32(bit), Valid: true}
-		}
+	// 12. Post-processing hooks (до перемещения файла)
+	destDir := p.config.ErrorPath
+	if status.Processed() {
+		destDir = p.archiveDir(fileInfo.Name)
+	}
+	result := ProcessResult{
+		File:          file,
+		FileInfo:      fileInfo,
+		Status:        status,
+		RowsProcessed: successCount,
+		RowsFailed:    failedCount,
+		ParseErrors:   parseErrorCount,
+		ClassCounts:   stats.classes,
+		Conflicts:     conflicts,
+		ReportPaths:   reportPaths,
+		UnitGuids:     stats.unitGuids,
+		DestPath:      filepath.Join(destDir, fileInfo.Name),
 	}
+	// Ошибочные строки - отдельным файлом для исправления и повторной отправки
+	if rejectedPath, err := p.writeRejectedFile(fileInfo, rejected); err != nil {
+		p.logger.Error("Failed to write rejected lines", "file", fileInfo.Name, "error", err)
+	} else if rejectedPath != "" {
+		result.RejectedPath = rejectedPath
+		p.RecordEvent(fileInfo.Name, EventRejectedWritten, fmt.Sprintf("%d lines", rejected.count))
+	}
+	p.runHooks(ctx, result)
 
-	// invert_bit (индекс 14)
-	if len(fields) > 14 {
-		val := strings.TrimSpace(fields[14])
-		if val != "" {
-			invert, err := parseInvertBit(val)
-			if err != nil {
-				return row, fmt.Errorf("invalid invert_bit: %w", err)
+	// 13. Перемещение файла в архив или папку ошибок
+	if status.Processed() {
+		if err := p.moveFile(fileInfo.Path, destDir, fileInfo.Name); err != nil {
+			p.logger.Error("Failed to archive file", "file", fileInfo.Name, "error", err)
+		} else {
+			p.logger.Info("📦 File moved to archive", "file", fileInfo.Name)
+			p.RecordEvent(fileInfo.Name, EventArchived, "")
+			if err := p.writeDoneMarker(result); err != nil {
+				p.logger.Error("Failed to write done marker", "file", fileInfo.Name, "error", err)
 			}
-			row.InvertBit = sql.NullBool{Bool: invert, Valid: true}
+		}
+	} else {
+		if err := p.moveFile(fileInfo.Path, p.config.ErrorPath, fileInfo.Name); err != nil {
+			p.logger.Error("Failed to move failed file", "file", fileInfo.Name, "error", err)
+		} else {
+			p.logger.Warn("File moved to error folder", "file", fileInfo.Name)
+			p.RecordEvent(fileInfo.Name, EventMovedToErrors, "")
 		}
 	}
 
-	return row, nil
-}
-
-// isValidClass проверяет допустимые значения class
-func isValidClass(class string) bool {
-	allowed := map[string]bool{
-		"alarm":   true,
-		"warning": true,
-		"info":    true,
-		"event":   true,
-		"comand":  true,
-		"waiting": true,
-		"working": true,
-	}
-	return allowed[strings.ToLower(class)]
+	p.logger.Info("✅ Finished processing", "file", fileInfo.Name, "success", successCount, "failed", failedCount,
+		"skipped", conflicts.Skipped, "overwritten", conflicts.Overwritten, "versioned", conflicts.Versioned)
+	return nil
 }
 
-// parseInvertBit преобразует строку в bool
-func parseInvertBit(field string) (bool, error) {
-	field = strings.ToLower(strings.TrimSpace(field))
-	switch field {
-	case "true", "1", "yes":
-		return true, nil
-	case "false", "0", "no", "":
-		return false, nil
-	default:
-		if val, err := strconv.ParseBool(field); err == nil {
-			return val, nil
+// uniqueUnitGuids возвращает список устройств, встречающихся в строках
+func uniqueUnitGuids(rows []TSVRow) []uuid.UUID {
+	seen := make(map[uuid.UUID]bool)
+	var guids []uuid.UUID
+	for _, row := range rows {
+		if !seen[row.UnitGuid] {
+			seen[row.UnitGuid] = true
+			guids = append(guids, row.UnitGuid)
 		}
-		return false, fmt.Errorf("cannot parse invert_bit: %s", field)
 	}
+	return guids
 }
 
 // ---------------------------------------------------------------------
 // Генерация PDF‑отчётов
 // ---------------------------------------------------------------------
 
-// generateReports группирует данные по unit_guid и создаёт отдельный PDF‑отчёт
-func (p *Processor) generateReports(ctx context.Context, fileID int64, rows []TSVRow) error {
-	byUnit := make(map[uuid.UUID][]TSVRow)
-	for _, row := range rows {
-		byUnit[row.UnitGuid] = append(byUnit[row.UnitGuid], row)
+// SetReportConfig задаёт оформление и пароли PDF‑отчётов (общие и по tenant).
+func (p *Processor) SetReportConfig(cfg config.ReportConfig) {
+	p.report = cfg
+	p.reportTZ = nil
+	if cfg.Timezone != "" {
+		loc, err := time.LoadLocation(cfg.Timezone)
+		if err != nil {
+			p.logger.Warn("Unknown report timezone, using UTC", "timezone", cfg.Timezone, "error", err)
+		}
+		p.reportTZ = loc
+	}
+}
+
+// reportFormat - форматирование дат и чисел отчёта: язык из запроса
+// (Accept-Language) или report.language, часовой пояс - report.timezone
+func (p *Processor) reportFormat(lang string) locale.Formatter {
+	if lang == "" {
+		lang = p.report.Language
 	}
+	return locale.New(lang, p.reportTZ)
+}
+
+// reportPassword - пароль открытия отчёта: заданный в запросе, пароль
+// tenant (source "tenant:<name>") или общий. "" - отчёт не шифруется.
+func (p *Processor) reportPassword(source, requested string) string {
+	if requested != "" {
+		return requested
+	}
+	if name, ok := strings.CutPrefix(source, "tenant:"); ok {
+		if password := p.report.TenantPasswords[name]; password != "" {
+			return password
+		}
+	}
+	return p.report.Password
+}
+
+// generateReports создаёт по сгруппированным строкам файла отдельный PDF‑отчёт
+// для каждого unit_guid в оформлении источника файла (имя - по метаданным
+// имени файла filename). Возвращает пути созданных отчётов.
+func (p *Processor) generateReports(ctx context.Context, fileID int64, filename, source string, groups *unitGroups) ([]string, error) {
+	span := tracing.NewSpan(tracing.FromContext(ctx))
+
+	var reportPaths []string
+	for _, guid := range groups.units {
+		if err := ctx.Err(); err != nil {
+			return reportPaths, err
+		}
+		data, err := groups.rows(guid)
+		if err != nil {
+			p.logger.Error("Failed to load rows for report", "unit_guid", guid, "error", err)
+			continue
+		}
 
-	for guid, data := range byUnit {
-		reportPath, err := p.createPDFReport(guid, data)
+		meta := reportMeta{
+			source:   source,
+			password: p.reportPassword(source, ""),
+			format:   p.reportFormat(""),
+			fileMeta: p.fileMetadata(filename),
+		}
+		reportPath, checksum, err := p.createReport(guid, meta, data)
 		if err != nil {
-			log.Printf("[Processor] ❌ Failed to create PDF for %s: %v", guid, err)
+			p.logger.Error("Failed to create PDF report", "unit_guid", guid, "error", err)
 			continue
 		}
+		reportPaths = append(reportPaths, reportPath)
 
 		params := sqlc.CreateReportParams{
 			UnitGuid:   guid,
-			ReportType: sql.NullString{String: "pdf", Valid: true},
+			ReportType: sql.NullString{String: ReportPDF, Valid: true},
 			FilePath:   reportPath,
+			Checksum:   sql.NullString{String: checksum, Valid: true},
+			TraceID:    nullString(span.TraceID),
+			SpanID:     nullString(span.SpanID),
 		}
-		if _, err := p.queries.CreateReport(ctx, params); err != nil {
-			log.Printf("[Processor] ❌ Failed to save report record: %v", err)
+		if report, err := p.queries.CreateReport(ctx, params); err != nil {
+			p.logger.Error("Failed to save report record", "unit_guid", guid, "error", err)
 		} else {
-			log.Printf("[Processor] ✅ PDF report created: %s", reportPath)
+			p.logger.Info("✅ PDF report created", "unit_guid", guid, "path", reportPath)
+			p.notifyReport(ctx, report)
 		}
 	}
-	return nil
+	return reportPaths, nil
 }
 
-// createPDFReport генерирует PDF‑файл с данными устройства
-func (p *Processor) createPDFReport(unitGuid uuid.UUID, data []TSVRow) (string, error) {
-	if err := os.MkdirAll(p.config.OutputPath, 0755); err != nil {
-		return "", err
-	}
-
-	timestamp := time.Now().Format("20060102_150405")
-	filename := fmt.Sprintf("%s_%s.pdf", unitGuid.String(), timestamp)
-	path := filepath.Join(p.config.OutputPath, filename)
+// reportMeta - формат, оформление, защита и номер части отчёта
+type reportMeta struct {
+	fileFormat string // формат файла (ReportPDF, ReportCSV, ...), "" - PDF
+	source     string // оформление tenant ("" - общее оформление)
+	password   string // непустой - шифрование (стандартная защита PDF, RC4 40 бит)
+	part       int    // номер части многотомного отчёта (0 - отчёт из одной части)
+	period     string // даты записей части
+	format     locale.Formatter
+	fileMeta   map[string]string // метаданные имени файла для имени отчёта (nil - имя по умолчанию)
+}
 
+// renderPDFReport генерирует PDF с данными устройства в оформлении источника
+func (p *Processor) renderPDFReport(unitGuid uuid.UUID, meta reportMeta, data []TSVRow) ([]byte, error) {
+	brand := branding.Resolve(p.report, meta.source)
 	pdf := gofpdf.New("P", "mm", "A4", "")
+	if meta.password != "" {
+		pdf.SetProtection(gofpdf.CnProtectPrint, meta.password, p.report.OwnerPassword)
+	}
+	branding.ApplyPDF(pdf, brand)
 	pdf.AddPage()
 	pdf.SetFont("Arial", "B", 16)
-	pdf.Cell(40, 10, "Device Report")
+	branding.Title(pdf, brand, 10, "Device Report")
 	pdf.Ln(12)
 
 	pdf.SetFont("Arial", "", 12)
 	pdf.Cell(40, 10, "Unit GUID: "+unitGuid.String())
 	pdf.Ln(6)
-	pdf.Cell(40, 10, "Generated: "+time.Now().Format(time.RFC3339))
+	pdf.Cell(40, 10, "Generated: "+meta.format.DateTime(time.Now()))
 	pdf.Ln(6)
-	pdf.Cell(40, 10, fmt.Sprintf("Total records: %d", len(data)))
+	if meta.part > 0 {
+		pdf.Cell(40, 10, fmt.Sprintf("Part: %d (%s)", meta.part, meta.period))
+		pdf.Ln(6)
+	}
+	pdf.Cell(40, 10, "Total records: "+meta.format.Int(int64(len(data))))
 	pdf.Ln(10)
 
 	pdf.SetFont("Arial", "B", 11)
-	pdf.Cell(40, 8, "Device Data:")
+	branding.Title(pdf, brand, 8, "Device Data:")
 	pdf.Ln(8)
 	pdf.SetFont("Arial", "", 10)
 
 	for i, row := range data {
-		pdf.Cell(40, 6, fmt.Sprintf("Record %d:", i+1))
+		pdf.Cell(40, 6, "Record "+meta.format.Int(int64(i+1))+":")
 		pdf.Ln(5)
 		if row.Invid.Valid {
 			pdf.Cell(40, 5, "  Inventory ID: "+row.Invid.String)
@@ -532,7 +927,7 @@ func (p *Processor) createPDFReport(unitGuid uuid.UUID, data []TSVRow) (string,
 			pdf.Ln(5)
 		}
 		if row.Level.Valid {
-			pdf.Cell(40, 5, fmt.Sprintf("  Level: %d", row.Level.Int32))
+			pdf.Cell(40, 5, "  Level: "+meta.format.Int(int64(row.Level.Int32)))
 			pdf.Ln(5)
 		}
 		if row.Area.Valid {
@@ -562,100 +957,67 @@ func (p *Processor) createPDFReport(unitGuid uuid.UUID, data []TSVRow) (string,
 		pdf.Ln(4)
 	}
 
-	if err := pdf.OutputFileAndClose(path); err != nil {
-		return "", fmt.Errorf("failed to save PDF: %w", err)
-	}
-	return path, nil
-}
-
-// GenerateReportForUnit генерирует отчёт для конкретного устройства по всем данным в БД
-func (p *Processor) GenerateReportForUnit(ctx context.Context, unitGuid uuid.UUID) error {
-	log.Printf("[Processor] 📊 Generating PDF report for unit: %s", unitGuid)
-
-	// Получаем все данные устройства (используем пагинацию с большим лимитом)
-	deviceData, err := p.queries.ListDeviceDataByUnit(ctx, sqlc.ListDeviceDataByUnitParams{
-		UnitGuid: unitGuid,
-		Limit:    10000,
-		Offset:   0,
-	})
-	if err != nil {
-		return fmt.Errorf("failed to fetch device data: %w", err)
-	}
-	if len(deviceData) == 0 {
-		return fmt.Errorf("no data found for unit %s", unitGuid)
-	}
-
-	rows := make([]TSVRow, 0, len(deviceData))
-	for _, d := range deviceData {
-		rows = append(rows, TSVRow{
-			UnitGuid:   d.UnitGuid,
-			Mqtt:       d.Mqtt,
-			Invid:      d.Invid,
-			MsgID:      d.MsgID,
-			Text:       d.Text,
-			Context:    d.Context,
-			Class:      d.Class,
-			Level:      d.Level,
-			Area:       d.Area,
-			Addr:       d.Addr,
-			Block:      d.Block,
-			Type:       d.Type,
-			Bit:        d.Bit,
-			InvertBit:  d.InvertBit,
-			LineNumber: d.LineNumber,
-		})
-	}
-
-	reportPath, err := p.createPDFReport(unitGuid, rows)
-	if err != nil {
-		return fmt.Errorf("failed to create PDF report: %w", err)
-	}
-
-	params := sqlc.CreateReportParams{
-		UnitGuid:   unitGuid,
-		ReportType: sql.NullString{String: "pdf", Valid: true},
-		FilePath:   reportPath,
-	}
-	if _, err := p.queries.CreateReport(ctx, params); err != nil {
-		log.Printf("[Processor] ⚠️ Report generated but DB record failed: %v", err)
-	} else {
-		log.Printf("[Processor] ✅ PDF report saved: %s", reportPath)
+	var buf bytes.Buffer
+	if err := pdf.Output(&buf); err != nil {
+		return nil, err
 	}
-	return nil
+	return buf.Bytes(), nil
 }
 
 // ---------------------------------------------------------------------
 // Работа с файловой системой
 // ---------------------------------------------------------------------
 
+// ErrNotReady - размер файла не перестал меняться за время ожидания: файл
+// ещё дописывается, и это не ошибка файла (он не перемещается в error_path)
+var ErrNotReady = errors.New("file is still being written")
+
 // waitForFileReady проверяет, что файл доступен для чтения и его размер стабилен.
+// Пустой файл со стабильным размером считается готовым и отклоняется validateFile.
 func (p *Processor) waitForFileReady(filePath string, timeout time.Duration) error {
-	deadline := time.Now().Add(timeout)
+	deadline := p.clock.Now().Add(timeout)
 	var prevSize int64 = -1
-	for time.Now().Before(deadline) {
-		info, err := os.Stat(filePath)
+	for p.clock.Now().Before(deadline) {
+		info, err := p.fs.Stat(filePath)
 		if err != nil {
 			return err
 		}
-		if info.Size() > 0 && info.Size() == prevSize {
+		if info.Size() == prevSize {
 			return nil
 		}
 		prevSize = info.Size()
-		time.Sleep(500 * time.Millisecond)
+		p.clock.Sleep(500 * time.Millisecond)
 	}
-	return fmt.Errorf("file size not stable within %v", timeout)
+	return fmt.Errorf("%w: file size not stable within %v", ErrNotReady, timeout)
 }
 
-// moveFile перемещает или копирует файл в целевую директорию.
+// moveFile перемещает или копирует файл в целевую директорию вместе
+// с его sidecar-файлом настроек (<name>.meta.json), если он есть.
 // Если rename не работает (cross-device), выполняет copy+remove.
 func (p *Processor) moveFile(src, destDir, filename string) error {
-	if err := os.MkdirAll(destDir, 0755); err != nil {
+	if err := p.fs.MkdirAll(destDir, 0755); err != nil {
+		return err
+	}
+	if err := p.relocate(src, filepath.Join(destDir, filename)); err != nil {
 		return err
 	}
-	dest := filepath.Join(destDir, filename)
 
+	sidecar := watcher.SidecarPath(src)
+	if _, err := p.fs.Stat(sidecar); err == nil {
+		if err := p.relocate(sidecar, watcher.SidecarPath(filepath.Join(destDir, filename))); err != nil {
+			p.logger.Warn("Failed to move sidecar", "sidecar", filepath.Base(sidecar), "error", err)
+		}
+	}
+	return nil
+}
+
+// relocate - rename или copy+remove одного файла
+func (p *Processor) relocate(src, dest string) error {
+	if p.sandbox {
+		return p.copyFile(src, dest)
+	}
 	// Пробуем rename
-	err := os.Rename(src, dest)
+	err := p.fs.Rename(src, dest)
 	if err == nil {
 		return nil
 	}
@@ -664,7 +1026,7 @@ func (p *Processor) moveFile(src, destDir, filename string) error {
 		if err := p.copyFile(src, dest); err != nil {
 			return fmt.Errorf("copy failed: %w", err)
 		}
-		if err := os.Remove(src); err != nil {
+		if err := p.fs.Remove(src); err != nil {
 			return fmt.Errorf("original file remove failed: %w", err)
 		}
 		return nil
@@ -672,15 +1034,65 @@ func (p *Processor) moveFile(src, destDir, filename string) error {
 	return err
 }
 
+// writeDoneMarker создаёт маркер <name>.done рядом с архивным файлом
+// для downstream систем, опрашивающих архив. В режиме "json" маркер
+// содержит итог обработки. Маркер пишется через временный файл, чтобы
+// поллер не прочитал его частично.
+func (p *Processor) writeDoneMarker(result ProcessResult) error {
+	var content []byte
+	switch p.config.DoneMarker {
+	case "", "none":
+		return nil
+	case "empty":
+	case "json":
+		reportPaths := result.ReportPaths
+		if reportPaths == nil {
+			reportPaths = []string{}
+		}
+		data, err := json.MarshalIndent(map[string]interface{}{
+			"file_id":        result.File.ID,
+			"filename":       result.FileInfo.Name,
+			"file_hash":      result.FileInfo.Hash,
+			"status":         result.Status,
+			"rows_processed": result.RowsProcessed,
+			"rows_failed":    result.RowsFailed,
+			"conflicts":      result.Conflicts,
+			"report_paths":   reportPaths,
+			"processed_at":   p.clock.Now().Format(time.RFC3339),
+		}, "", "  ")
+		if err != nil {
+			return err
+		}
+		content = data
+	default:
+		return fmt.Errorf("unknown done marker mode: %s", p.config.DoneMarker)
+	}
+
+	markerPath := result.DestPath + ".done"
+	tmpPath := markerPath + ".tmp"
+	f, err := p.fs.Create(tmpPath)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(content); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return p.fs.Rename(tmpPath, markerPath)
+}
+
 // copyFile копирует содержимое файла.
 func (p *Processor) copyFile(src, dst string) error {
-	source, err := os.Open(src)
+	source, err := p.fs.Open(src)
 	if err != nil {
 		return err
 	}
 	defer source.Close()
 
-	destination, err := os.Create(dst)
+	destination, err := p.fs.Create(dst)
 	if err != nil {
 		return err
 	}
@@ -691,20 +1103,20 @@ func (p *Processor) copyFile(src, dst string) error {
 }
 
 // moveExistingFile перемещает уже обработанный файл в соответствующую папку.
-func (p *Processor) moveExistingFile(filePath, status string) {
-	if _, err := os.Stat(filePath); os.IsNotExist(err) {
-		log.Printf("[Processor] File %s already moved or deleted, skipping", filePath)
+func (p *Processor) moveExistingFile(filePath string, status filestatus.Status) {
+	if _, err := p.fs.Stat(filePath); os.IsNotExist(err) {
+		p.logger.Debug("File already moved or deleted, skipping", "path", filePath)
 		return
 	}
 
 	switch status {
-	case "completed", "partial":
-		if err := p.moveFile(filePath, p.config.ArchivePath, filepath.Base(filePath)); err != nil {
-			log.Printf("[Processor] Failed to archive already processed file: %v", err)
+	case filestatus.Completed, filestatus.Partial:
+		if err := p.moveFile(filePath, p.archiveDir(filepath.Base(filePath)), filepath.Base(filePath)); err != nil {
+			p.logger.Error("Failed to archive already processed file", "path", filePath, "error", err)
 		}
-	case "failed":
+	case filestatus.Failed:
 		if err := p.moveFile(filePath, p.config.ErrorPath, filepath.Base(filePath)); err != nil {
-			log.Printf("[Processor] Failed to move failed file: %v", err)
+			p.logger.Error("Failed to move failed file", "path", filePath, "error", err)
 		}
 	default:
 		// Статус "processing" – ничего не делаем
@@ -2,18 +2,36 @@ package processor
 
 import (
 	"TSVProcessingService/db/sqlc"
+	"TSVProcessingService/internal/clock"
 	"TSVProcessingService/internal/config"
+	"TSVProcessingService/internal/features"
+	"TSVProcessingService/internal/filemeta"
+	"TSVProcessingService/internal/filestatus"
+	"TSVProcessingService/internal/fsys"
+	"TSVProcessingService/internal/ingest"
+	"TSVProcessingService/internal/tracing"
 	"TSVProcessingService/internal/watcher"
+	"archive/zip"
+	"bytes"
 	"context"
 	"crypto/sha256"
 	"database/sql"
 	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
 	"io"
 	"os"
 	"path/filepath"
+	"runtime"
+	"slices"
+	"strings"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	"github.com/google/uuid"
+	"github.com/lib/pq"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	_ "modernc.org/sqlite"
@@ -33,7 +51,32 @@ func setupTestDB(t *testing.T) *sql.DB {
 		rows_failed INTEGER DEFAULT 0,
 		error_message TEXT,
 		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
-		updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+		updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		source TEXT NOT NULL DEFAULT 'unknown',
+		file_mtime DATETIME,
+		arrived_at DATETIME,
+		completed_at DATETIME,
+		conflict_policy TEXT,
+		rows_skipped INTEGER DEFAULT 0,
+		rows_overwritten INTEGER DEFAULT 0,
+		rows_versioned INTEGER DEFAULT 0,
+		superseded_by INTEGER,
+		superseded_at DATETIME,
+		change_seq INTEGER NOT NULL DEFAULT 0,
+		trace_id TEXT,
+		span_id TEXT,
+		sample_rate REAL,
+		corrects_file_id INTEGER,
+		rows_corrected INTEGER DEFAULT 0,
+		correction_note TEXT,
+		rows_duplicate_existing INTEGER,
+		rows_duplicate_in_file INTEGER,
+		profile BLOB NOT NULL DEFAULT X'7B7D',
+		anomalies BLOB NOT NULL DEFAULT X'5B5D',
+		seq_first INTEGER,
+		seq_last INTEGER,
+		seq_gaps INTEGER,
+		metadata BLOB NOT NULL DEFAULT X'7B7D'
 	);
 	CREATE TABLE device_data (
 		id INTEGER PRIMARY KEY AUTOINCREMENT,
@@ -53,7 +96,12 @@ func setupTestDB(t *testing.T) *sql.DB {
 		bit INTEGER,
 		invert_bit INTEGER DEFAULT 0,
 		line_number INTEGER NOT NULL,
-		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		-- в формате, в котором драйвер передаёт time.Time: курсор отчёта
+		-- сравнивает created_at с параметром
+		created_at DATETIME DEFAULT (strftime('%Y-%m-%d %H:%M:%S', 'now') || ' +0000 UTC'),
+		version INTEGER NOT NULL DEFAULT 1,
+		updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		change_seq INTEGER NOT NULL DEFAULT 0,
 		FOREIGN KEY (file_id) REFERENCES files(id) ON DELETE CASCADE
 	);
 	CREATE TABLE processing_errors (
@@ -64,14 +112,92 @@ func setupTestDB(t *testing.T) *sql.DB {
 		error_message TEXT NOT NULL,
 		field_name TEXT,
 		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		unit_guid TEXT,
+		partial BLOB NOT NULL DEFAULT X'7B7D',
+		updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		change_seq INTEGER NOT NULL DEFAULT 0,
 		FOREIGN KEY (file_id) REFERENCES files(id) ON DELETE CASCADE
 	);
+	CREATE TABLE unit_daily_summary (
+		unit_guid TEXT NOT NULL,
+		day DATE NOT NULL,
+		records INTEGER NOT NULL DEFAULT 0,
+		alarms INTEGER NOT NULL DEFAULT 0,
+		max_level INTEGER,
+		updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		change_seq INTEGER NOT NULL DEFAULT 0,
+		PRIMARY KEY (unit_guid, day)
+	);
 	CREATE TABLE reports (
 		id INTEGER PRIMARY KEY AUTOINCREMENT,
 		unit_guid TEXT NOT NULL,
 		report_type TEXT DEFAULT 'pdf',
 		file_path TEXT NOT NULL,
-		generated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+		generated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		checksum TEXT,
+		report_group TEXT,
+		part INTEGER,
+		updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		change_seq INTEGER NOT NULL DEFAULT 0,
+		trace_id TEXT,
+		span_id TEXT
+	);
+	CREATE TABLE file_events (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		filename TEXT NOT NULL,
+		event TEXT NOT NULL,
+		detail TEXT NOT NULL DEFAULT '',
+		occurred_at DATETIME NOT NULL,
+		updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		change_seq INTEGER NOT NULL DEFAULT 0
+	);
+	CREATE TABLE file_duplicates (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		file_id INTEGER NOT NULL,
+		line_number INTEGER NOT NULL,
+		kind TEXT NOT NULL,
+		unit_guid TEXT NOT NULL,
+		msg_id TEXT,
+		duplicate_of_file_id INTEGER NOT NULL,
+		duplicate_of_line INTEGER NOT NULL,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		change_seq INTEGER NOT NULL DEFAULT 0
+	);
+
+	CREATE TABLE unit_sequence_runs (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		file_id INTEGER NOT NULL,
+		unit_guid TEXT NOT NULL,
+		seq_first INTEGER NOT NULL,
+		seq_last INTEGER NOT NULL,
+		rows INTEGER NOT NULL,
+		created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+		updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		change_seq INTEGER NOT NULL DEFAULT 0
+	);
+
+	CREATE TABLE file_retries (
+		filename TEXT PRIMARY KEY,
+		attempts INTEGER NOT NULL DEFAULT 0,
+		next_retry_at DATETIME NOT NULL,
+		last_error TEXT,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		change_seq INTEGER NOT NULL DEFAULT 0
+	);
+
+	CREATE TABLE file_progress (
+		filename TEXT PRIMARY KEY,
+		file_id INTEGER NOT NULL,
+		stage TEXT NOT NULL,
+		rows_total INTEGER NOT NULL DEFAULT 0,
+		rows_processed INTEGER NOT NULL DEFAULT 0,
+		rows_failed INTEGER NOT NULL DEFAULT 0,
+		started_at DATETIME NOT NULL,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		change_seq INTEGER NOT NULL DEFAULT 0
 	);
 	`
 	_, err = db.Exec(schema)
@@ -119,124 +245,6 @@ func createTestTSV(t *testing.T, dir, filename string, lines []string) string {
 	return path
 }
 
-func calculateFileHash(filePath string) (string, error) {
-	f, err := os.Open(filePath)
-	if err != nil {
-		return "", err
-	}
-	defer f.Close()
-	hash := sha256.New()
-	if _, err := io.Copy(hash, f); err != nil {
-		return "", err
-	}
-	return hex.EncodeToString(hash.Sum(nil)), nil
-}
-
-// ---------- parseLine ----------
-func TestParseLine_Valid(t *testing.T) {
-	p := &Processor{}
-	fields := []string{
-		"1", "", "G-044322", "01749246-95f6-57db-b7c3-2ae0e8be671f",
-		"cold7_Defrost_status", "Разморозка", "", "waiting", "100", "LOCAL",
-		"cold7_status.Defrost_status", "", "", "", "",
-	}
-	row, err := p.parseLine(fields, 1)
-	assert.NoError(t, err)
-	assert.Equal(t, uuid.MustParse("01749246-95f6-57db-b7c3-2ae0e8be671f"), row.UnitGuid)
-	assert.Equal(t, "G-044322", row.Invid.String)
-}
-
-func TestParseLine_InvalidUUID(t *testing.T) {
-	p := &Processor{}
-	fields := []string{"1", "", "G-044322", "not-a-uuid"}
-	_, err := p.parseLine(fields, 1)
-	assert.ErrorContains(t, err, "invalid unit_guid")
-}
-
-func TestParseLine_InvalidClass(t *testing.T) {
-	p := &Processor{}
-	fields := []string{
-		"1", "", "G-044322", "01749246-95f6-57db-b7c3-2ae0e8be671f",
-		"msg", "text", "", "INVALID_CLASS", "100",
-	}
-	_, err := p.parseLine(fields, 1)
-	assert.ErrorContains(t, err, "invalid class value")
-}
-
-func TestParseLine_InvalidLevel(t *testing.T) {
-	p := &Processor{}
-	fields := []string{
-		"1", "", "G-044322", "01749246-95f6-57db-b7c3-2ae0e8be671f",
-		"msg", "text", "", "alarm", "abc",
-	}
-	_, err := p.parseLine(fields, 1)
-	assert.ErrorContains(t, err, "invalid level (not integer)")
-}
-
-func TestParseLine_InvalidBit(t *testing.T) {
-	p := &Processor{}
-	fields := []string{
-		"1", "", "G-044322", "01749246-95f6-57db-b7c3-2ae0e8be671f",
-		"msg", "text", "", "alarm", "100", "LOCAL", "addr", "", "coil", "not-a-number",
-	}
-	_, err := p.parseLine(fields, 1)
-	assert.ErrorContains(t, err, "invalid bit (not integer)")
-}
-
-func TestParseLine_InvalidInvertBit(t *testing.T) {
-	p := &Processor{}
-	fields := []string{
-		"1", "", "G-044322", "01749246-95f6-57db-b7c3-2ae0e8be671f",
-		"msg", "text", "", "alarm", "100", "LOCAL", "addr", "", "coil", "1", "maybe",
-	}
-	_, err := p.parseLine(fields, 1)
-	assert.ErrorContains(t, err, "invalid invert_bit")
-}
-
-// ---------- parseTSVFile ----------
-func TestParseTSVFile_ValidFile(t *testing.T) {
-	_, _, cfg, cleanup := setupTestProcessor(t)
-	defer cleanup()
-
-	lines := []string{
-		"n\tmqtt\tinvid\tunit_guid\tmsg_id\ttext\tcontext\tclass\tlevel\tarea\taddr\tblock\ttype\tbit\tinvert_bit",
-		"1\t\tG-044322\t01749246-95f6-57db-b7c3-2ae0e8be671f\tcold7_Defrost_status\tРазморозка\t\twaiting\t100\tLOCAL\tcold7_status.Defrost_status\t\t\t\t",
-		"2\t\tG-044322\t01749246-95f6-57db-b7c3-2ae0e8be671f\tcold7_VentSK_status\tВентилятор\t\tworking\t100\tLOCAL\tcold7_status.VentSK_status\t\t\t\t",
-	}
-	path := createTestTSV(t, cfg.WatchPath, "valid.tsv", lines)
-
-	p, _, _, _ := setupTestProcessor(t)
-	rows, errors := p.parseTSVFile(path, 1)
-
-	assert.Len(t, rows, 2)
-	assert.Len(t, errors, 0)
-	assert.Equal(t, "cold7_Defrost_status", rows[0].MsgID.String)
-	assert.Equal(t, "cold7_VentSK_status", rows[1].MsgID.String)
-}
-
-func TestParseTSVFile_WithErrors(t *testing.T) {
-	_, _, cfg, cleanup := setupTestProcessor(t)
-	defer cleanup()
-
-	lines := []string{
-		"n\tmqtt\tinvid\tunit_guid\tmsg_id\ttext\tcontext\tclass\tlevel\tarea\taddr\tblock\ttype\tbit\tinvert_bit",
-		"1\t\tG-044322\tinvalid-uuid\tcold7_Defrost_status\tРазморозка\t\twaiting\t100\tLOCAL\taddr\t\t\t\t",
-		"2\t\tG-044322\t01749246-95f6-57db-b7c3-2ae0e8be671f\tbad_level\tтекст\t\talarm\tnot_int\tLOCAL\taddr\t\t\t\t",
-		"3\t\tG-044322\t01749246-95f6-57db-b7c3-2ae0e8be671f\tbad_class\tтекст\t\tinvalid_class\t100\tLOCAL\taddr\t\t\t\t",
-	}
-	path := createTestTSV(t, cfg.WatchPath, "with_errors.tsv", lines)
-
-	p, _, _, _ := setupTestProcessor(t)
-	rows, errors := p.parseTSVFile(path, 1)
-
-	assert.Len(t, rows, 0)
-	assert.Len(t, errors, 3)
-	assert.Contains(t, errors[0].ErrorMessage, "invalid unit_guid")
-	assert.Contains(t, errors[1].ErrorMessage, "invalid level")
-	assert.Contains(t, errors[2].ErrorMessage, "invalid class value")
-}
-
-// ---------- ProcessFile ----------
 func TestProcessFile_Success(t *testing.T) {
 	processor, db, cfg, cleanup := setupTestProcessor(t)
 	defer cleanup()
@@ -248,7 +256,7 @@ func TestProcessFile_Success(t *testing.T) {
 	}
 	filePath := createTestTSV(t, cfg.WatchPath, "test_success.tsv", lines)
 
-	hash, err := calculateFileHash(filePath)
+	hash, err := ingest.HashFile(filePath)
 	require.NoError(t, err)
 
 	fileInfo := watcher.FileInfo{
@@ -288,11 +296,13 @@ func TestProcessFile_Success(t *testing.T) {
 	require.NoError(t, err)
 	assert.Equal(t, 1, reportCount)
 
-	var reportPath string
-	err = db.QueryRow(`SELECT file_path FROM reports WHERE unit_guid = ?`, guid.String()).Scan(&reportPath)
+	var reportPath, checksum string
+	err = db.QueryRow(`SELECT file_path, checksum FROM reports WHERE unit_guid = ?`, guid.String()).Scan(&reportPath, &checksum)
 	require.NoError(t, err)
-	_, err = os.Stat(reportPath)
-	assert.NoError(t, err)
+	content, err := os.ReadFile(reportPath)
+	require.NoError(t, err)
+	sum := sha256.Sum256(content)
+	assert.Equal(t, hex.EncodeToString(sum[:]), checksum)
 }
 
 func TestProcessFile_AlreadyProcessed(t *testing.T) {
@@ -304,7 +314,7 @@ func TestProcessFile_AlreadyProcessed(t *testing.T) {
 		"1\t\tG-044322\t01749246-95f6-57db-b7c3-2ae0e8be671f\tmsg\ttext\t\talarm\t100\tLOCAL\taddr\t\t\t\t",
 	}
 	filePath := createTestTSV(t, cfg.WatchPath, "already.tsv", lines)
-	hash, _ := calculateFileHash(filePath)
+	hash, _ := ingest.HashFile(filePath)
 	fileInfo := watcher.FileInfo{Path: filePath, Name: "already.tsv", Hash: hash}
 
 	ctx := context.Background()
@@ -320,6 +330,159 @@ func TestProcessFile_AlreadyProcessed(t *testing.T) {
 	assert.Equal(t, 1, count)
 }
 
+func TestProcessFile_SandboxKeepsSource(t *testing.T) {
+	processor, db, cfg, cleanup := setupTestProcessor(t)
+	defer cleanup()
+	processor.SetSandbox(true)
+
+	lines := []string{
+		"n\tmqtt\tinvid\tunit_guid\tmsg_id\ttext\tcontext\tclass\tlevel\tarea\taddr\tblock\ttype\tbit\tinvert_bit",
+		"1\t\tG-044322\t01749246-95f6-57db-b7c3-2ae0e8be671f\tmsg\ttext\t\talarm\t100\tLOCAL\taddr\t\t\t\t",
+	}
+	filePath := createTestTSV(t, cfg.WatchPath, "mirror.tsv", lines)
+	hash, _ := ingest.HashFile(filePath)
+	fileInfo := watcher.FileInfo{Path: filePath, Name: "mirror.tsv", Hash: hash}
+
+	ctx := context.Background()
+	require.NoError(t, processor.ProcessFile(ctx, fileInfo))
+
+	// В архив попадает копия, исходный файл остаётся на месте
+	_, err := os.Stat(filepath.Join(cfg.ArchivePath, "mirror.tsv"))
+	assert.NoError(t, err)
+	_, err = os.Stat(filePath)
+	assert.NoError(t, err)
+
+	// Повторное обнаружение - пропуск без повторной загрузки
+	require.NoError(t, processor.ProcessFile(ctx, fileInfo))
+	_, err = os.Stat(filePath)
+	assert.NoError(t, err)
+
+	var count int
+	err = db.QueryRow(`SELECT COUNT(*) FROM device_data`).Scan(&count)
+	require.NoError(t, err)
+	assert.Equal(t, 1, count)
+}
+
+func TestProcessFile_SandboxKeepsFailedSource(t *testing.T) {
+	processor, db, cfg, cleanup := setupTestProcessor(t)
+	defer cleanup()
+	processor.SetSandbox(true)
+
+	lines := []string{
+		"n\tmqtt\tinvid\tunit_guid\tmsg_id\ttext\tcontext\tclass\tlevel\tarea\taddr\tblock\ttype\tbit\tinvert_bit",
+		"1\t\tG-044322\tnot-a-uuid\tmsg\ttext\t\talarm\t100\tLOCAL\taddr\t\t\t\t",
+	}
+	filePath := createTestTSV(t, cfg.WatchPath, "mirror_bad.tsv", lines)
+	hash, _ := ingest.HashFile(filePath)
+	fileInfo := watcher.FileInfo{Path: filePath, Name: "mirror_bad.tsv", Hash: hash}
+
+	require.NoError(t, processor.ProcessFile(context.Background(), fileInfo))
+
+	// В error_path - копия, файл production-зеркала не тронут
+	assert.FileExists(t, filepath.Join(cfg.ErrorPath, "mirror_bad.tsv"))
+	data, err := os.ReadFile(filePath)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), "not-a-uuid")
+
+	var status string
+	require.NoError(t, db.QueryRow(`SELECT status FROM files WHERE filename = ?`, "mirror_bad.tsv").Scan(&status))
+	assert.Equal(t, "failed", status)
+}
+
+func TestProcessFile_DuplicateContent(t *testing.T) {
+	processor, db, cfg, cleanup := setupTestProcessor(t)
+	defer cleanup()
+
+	lines := []string{
+		"1\t\tG-044322\t01749246-95f6-57db-b7c3-2ae0e8be671f\tmsg\ttext\t\talarm\t100\tLOCAL\taddr\t\t\t\t",
+	}
+	ctx := context.Background()
+	process := func(name string) {
+		filePath := createTestTSV(t, cfg.WatchPath, name, lines)
+		hash, _ := ingest.HashFile(filePath)
+		require.NoError(t, processor.ProcessFile(ctx, watcher.FileInfo{Path: filePath, Name: name, Hash: hash}))
+	}
+	process("original.tsv")
+
+	// Переименованная копия пропускается и уходит в архив
+	process("renamed.tsv")
+	var count int
+	require.NoError(t, db.QueryRow(`SELECT COUNT(*) FROM files WHERE filename = ?`, "renamed.tsv").Scan(&count))
+	assert.Equal(t, 0, count)
+	assert.FileExists(t, filepath.Join(cfg.ArchivePath, "renamed.tsv"))
+
+	// С разрешением копия обрабатывается, совпадение отмечается в хронологии
+	processor.SetAllowDuplicateContent(true)
+	process("reprocessed.tsv")
+	require.NoError(t, db.QueryRow(`SELECT COUNT(*) FROM device_data`).Scan(&count))
+	assert.Equal(t, 2, count)
+
+	events, err := sqlc.New(db).ListFileEvents(ctx, "reprocessed.tsv")
+	require.NoError(t, err)
+	var duplicate []string
+	for _, event := range events {
+		if event.Event == EventDuplicateContent {
+			duplicate = append(duplicate, event.Detail)
+		}
+	}
+	assert.Equal(t, []string{"same content as original.tsv (file 1)"}, duplicate)
+}
+
+func TestProcessFile_FeatureFlags(t *testing.T) {
+	processor, db, cfg, cleanup := setupTestProcessor(t)
+	defer cleanup()
+
+	// Пропуск повторного содержимого выключен для tenant acme
+	processor.SetFeatures(features.New(nil, map[string]map[string]bool{
+		"acme": {string(features.ContentDedup): false},
+	}))
+	lines := []string{
+		"1\t\tG-044322\t01749246-95f6-57db-b7c3-2ae0e8be671f\tmsg\ttext\t\talarm\t100\tLOCAL\taddr\t\t\t\t",
+	}
+	ctx := context.Background()
+	process := func(name, source string) {
+		filePath := createTestTSV(t, cfg.WatchPath, name, lines)
+		hash, _ := ingest.HashFile(filePath)
+		require.NoError(t, processor.ProcessFile(ctx, watcher.FileInfo{Path: filePath, Name: name, Hash: hash, Source: source}))
+	}
+	process("original.tsv", "directory")
+	process("copy.tsv", "directory")
+	process("acme_copy.tsv", "tenant:acme")
+
+	var count int
+	require.NoError(t, db.QueryRow(`SELECT COUNT(*) FROM files WHERE filename = ?`, "copy.tsv").Scan(&count))
+	assert.Equal(t, 0, count, "duplicate is skipped without tenant")
+	require.NoError(t, db.QueryRow(`SELECT COUNT(*) FROM files WHERE filename = ?`, "acme_copy.tsv").Scan(&count))
+	assert.Equal(t, 1, count, "duplicate is processed for acme")
+
+	// lenient из sidecar игнорируется при выключенном lenient_parsing
+	processor.SetFeatures(features.New(map[string]bool{string(features.LenientParsing): false}, nil))
+	fileInfo := watcher.FileInfo{Name: "lenient.tsv", Options: ingest.Options{Lenient: true}}
+	processor.applyFeatures(&fileInfo)
+	assert.False(t, fileInfo.Options.Lenient)
+}
+
+func TestMarkFile_RejectsInvalidTransition(t *testing.T) {
+	processor, db, cfg, cleanup := setupTestProcessor(t)
+	defer cleanup()
+
+	lines := []string{
+		"1\t\tG-044322\t01749246-95f6-57db-b7c3-2ae0e8be671f\tmsg\ttext\t\talarm\t100\tLOCAL\taddr\t\t\t\t",
+	}
+	filePath := createTestTSV(t, cfg.WatchPath, "done.tsv", lines)
+	hash, _ := ingest.HashFile(filePath)
+	fileInfo := watcher.FileInfo{Path: filePath, Name: "done.tsv", Hash: hash}
+	require.NoError(t, processor.ProcessFile(context.Background(), fileInfo))
+
+	// Обработанный файл не может получить статус повтора
+	_, err := processor.markFile(context.Background(), fileInfo, filestatus.Retrying, "late retry")
+	assert.ErrorIs(t, err, filestatus.ErrInvalidTransition)
+
+	var status string
+	require.NoError(t, db.QueryRow(`SELECT status FROM files WHERE filename = ?`, "done.tsv").Scan(&status))
+	assert.Equal(t, "completed", status)
+}
+
 func TestProcessFile_InvalidFile(t *testing.T) {
 	processor, db, cfg, cleanup := setupTestProcessor(t)
 	defer cleanup()
@@ -329,7 +492,7 @@ func TestProcessFile_InvalidFile(t *testing.T) {
 		"1\t\tG-044322\tnot-a-uuid\tmsg\ttext\t\talarm\t100\tLOCAL\taddr\t\t\t\t",
 	}
 	filePath := createTestTSV(t, cfg.WatchPath, "invalid.tsv", lines)
-	hash, _ := calculateFileHash(filePath)
+	hash, _ := ingest.HashFile(filePath)
 	fileInfo := watcher.FileInfo{Path: filePath, Name: "invalid.tsv", Hash: hash}
 
 	ctx := context.Background()
@@ -349,3 +512,1973 @@ func TestProcessFile_InvalidFile(t *testing.T) {
 	require.NoError(t, err)
 	assert.Greater(t, errorCount, 0)
 }
+
+// ---------- Post-processing hooks ----------
+type recordingHook struct {
+	results []ProcessResult
+}
+
+func (h *recordingHook) Name() string { return "recording" }
+
+func (h *recordingHook) AfterProcess(ctx context.Context, result ProcessResult) error {
+	// Hook вызывается до перемещения: исходный файл ещё на месте
+	if _, err := os.Stat(result.FileInfo.Path); err != nil {
+		return err
+	}
+	h.results = append(h.results, result)
+	return nil
+}
+
+func TestProcessFile_RunsHooksBeforeMove(t *testing.T) {
+	processor, _, cfg, cleanup := setupTestProcessor(t)
+	defer cleanup()
+
+	hook := &recordingHook{}
+	processor.RegisterHook(hook)
+	dropDir := filepath.Join(cfg.TempPath, "drop")
+	processor.RegisterHook(NewCopyHook(dropDir))
+
+	lines := []string{
+		"1\t\tG-044322\t01749246-95f6-57db-b7c3-2ae0e8be671f\tmsg\ttext\t\talarm\t100\tLOCAL\taddr\t\t\t\t",
+	}
+	filePath := createTestTSV(t, cfg.WatchPath, "hooked.tsv", lines)
+	hash, _ := ingest.HashFile(filePath)
+	fileInfo := watcher.FileInfo{Path: filePath, Name: "hooked.tsv", Hash: hash}
+
+	err := processor.ProcessFile(context.Background(), fileInfo)
+	require.NoError(t, err)
+
+	require.Len(t, hook.results, 1)
+	result := hook.results[0]
+	assert.Equal(t, filestatus.Completed, result.Status)
+	assert.Equal(t, "completed", result.File.Status.String)
+	assert.EqualValues(t, 1, result.RowsProcessed)
+	assert.Len(t, result.ReportPaths, 1)
+	assert.Equal(t, filepath.Join(cfg.ArchivePath, "hooked.tsv"), result.DestPath)
+
+	_, err = os.Stat(filepath.Join(dropDir, "hooked.tsv"))
+	assert.NoError(t, err)
+}
+
+// ---------- Done marker ----------
+func TestProcessFile_WritesJSONDoneMarker(t *testing.T) {
+	processor, _, cfg, cleanup := setupTestProcessor(t)
+	defer cleanup()
+	cfg.DoneMarker = "json"
+
+	lines := []string{
+		"1\t\tG-044322\t01749246-95f6-57db-b7c3-2ae0e8be671f\tmsg\ttext\t\talarm\t100\tLOCAL\taddr\t\t\t\t",
+	}
+	filePath := createTestTSV(t, cfg.WatchPath, "marked.tsv", lines)
+	hash, _ := ingest.HashFile(filePath)
+	fileInfo := watcher.FileInfo{Path: filePath, Name: "marked.tsv", Hash: hash}
+
+	err := processor.ProcessFile(context.Background(), fileInfo)
+	require.NoError(t, err)
+
+	data, err := os.ReadFile(filepath.Join(cfg.ArchivePath, "marked.tsv.done"))
+	require.NoError(t, err)
+
+	var marker map[string]interface{}
+	require.NoError(t, json.Unmarshal(data, &marker))
+	assert.Equal(t, "completed", marker["status"])
+	assert.EqualValues(t, 1, marker["rows_processed"])
+	assert.Len(t, marker["report_paths"], 1)
+}
+
+func TestProcessFile_NoDoneMarkerForFailedFile(t *testing.T) {
+	processor, _, cfg, cleanup := setupTestProcessor(t)
+	defer cleanup()
+	cfg.DoneMarker = "empty"
+
+	lines := []string{
+		"1\t\tG-044322\tnot-a-uuid\tmsg\ttext\t\talarm\t100\tLOCAL\taddr\t\t\t\t",
+	}
+	filePath := createTestTSV(t, cfg.WatchPath, "bad.tsv", lines)
+	hash, _ := ingest.HashFile(filePath)
+	fileInfo := watcher.FileInfo{Path: filePath, Name: "bad.tsv", Hash: hash}
+
+	err := processor.ProcessFile(context.Background(), fileInfo)
+	require.NoError(t, err)
+
+	_, err = os.Stat(filepath.Join(cfg.ErrorPath, "bad.tsv.done"))
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestProcessFile_WritesRejectedFile(t *testing.T) {
+	processor, _, cfg, cleanup := setupTestProcessor(t)
+	defer cleanup()
+	cfg.RejectedFile = true
+
+	header := "n\tmqtt\tinvid\tunit_guid\tmsg_id\ttext\tcontext\tclass\tlevel\tarea\taddr\tblock\ttype\tbit\tinvert_bit"
+	badUUID := "2\t\tG-044322\tnot-a-uuid\tmsg\ttext\t\talarm\t100\tLOCAL\taddr\t\t\t\t"
+	badLevel := "4\t\tG-044322\t01749246-95f6-57db-b7c3-2ae0e8be671f\tmsg\ttext\t\talarm\tx\tLOCAL\taddr\t\t\t\t"
+	lines := []string{
+		header,
+		"1\t\tG-044322\t01749246-95f6-57db-b7c3-2ae0e8be671f\tmsg\ttext\t\talarm\t100\tLOCAL\taddr\t\t\t\t",
+		badUUID,
+		"3\t\tG-044322\t01749246-95f6-57db-b7c3-2ae0e8be671f\tmsg\ttext\t\talarm\t100\tLOCAL\taddr\t\t\t\t",
+		badLevel,
+	}
+	filePath := createTestTSV(t, cfg.WatchPath, "partner.tsv", lines)
+	hash, _ := ingest.HashFile(filePath)
+	fileInfo := watcher.FileInfo{Path: filePath, Name: "partner.tsv", Hash: hash}
+
+	require.NoError(t, processor.ProcessFile(context.Background(), fileInfo))
+
+	// Частично обработанный файл - в архиве, ошибочные строки - в error_path
+	data, err := os.ReadFile(filepath.Join(cfg.ErrorPath, "partner.rejected.tsv"))
+	require.NoError(t, err)
+	got := strings.Split(strings.TrimSuffix(string(data), "\n"), "\n")
+	require.Len(t, got, 4)
+	assert.Equal(t, "# rejected_from: partner.tsv", got[0])
+	assert.Equal(t, header+"\terror_reason", got[1])
+	assert.True(t, strings.HasPrefix(got[2], badUUID+"\t"))
+	assert.Contains(t, got[2], "invalid unit_guid")
+	assert.True(t, strings.HasPrefix(got[3], badLevel+"\t"))
+	assert.Contains(t, got[3], "invalid level")
+	assert.FileExists(t, filepath.Join(cfg.ArchivePath, "partner.tsv"))
+
+	// Файл без ошибок не даёт rejected-файла
+	lines = lines[:2]
+	filePath = createTestTSV(t, cfg.WatchPath, "clean.tsv", lines)
+	hash, _ = ingest.HashFile(filePath)
+	require.NoError(t, processor.ProcessFile(context.Background(), watcher.FileInfo{Path: filePath, Name: "clean.tsv", Hash: hash}))
+	assert.NoFileExists(t, filepath.Join(cfg.ErrorPath, "clean.rejected.tsv"))
+}
+
+func TestProcessFile_MergesCorrectedRejectedFile(t *testing.T) {
+	processor, db, cfg, cleanup := setupTestProcessor(t)
+	defer cleanup()
+	cfg.RejectedFile = true
+
+	lines := []string{
+		"n\tmqtt\tinvid\tunit_guid\tmsg_id\ttext\tcontext\tclass\tlevel\tarea\taddr\tblock\ttype\tbit\tinvert_bit",
+		"1\t\tG-044322\t01749246-95f6-57db-b7c3-2ae0e8be671f\tmsg_1\ttext\t\talarm\t100\tLOCAL\taddr\t\t\t\t",
+		"2\t\tG-044322\tnot-a-uuid\tmsg_2\ttext\t\talarm\t100\tLOCAL\taddr\t\t\t\t",
+		"3\t\tG-044322\t01749246-95f6-57db-b7c3-2ae0e8be671f\tmsg_3\ttext\t\talarm\tx\tLOCAL\taddr\t\t\t\t",
+	}
+	filePath := createTestTSV(t, cfg.WatchPath, "partner.tsv", lines)
+	hash, _ := ingest.HashFile(filePath)
+	require.NoError(t, processor.ProcessFile(context.Background(), watcher.FileInfo{Path: filePath, Name: "partner.tsv", Hash: hash}))
+
+	// Отправитель исправляет строки rejected-файла и отправляет его обратно
+	// под другим именем: исходный файл находится по строке-маркеру
+	data, err := os.ReadFile(filepath.Join(cfg.ErrorPath, "partner.rejected.tsv"))
+	require.NoError(t, err)
+	fixed := strings.NewReplacer("not-a-uuid", "01749246-95f6-57db-b7c3-2ae0e8be671f", "\tx\t", "\t100\t").Replace(string(data))
+	fixedPath := filepath.Join(cfg.WatchPath, "partner_fixed.tsv")
+	require.NoError(t, os.WriteFile(fixedPath, []byte(fixed), 0644))
+	hash, _ = ingest.HashFile(fixedPath)
+	require.NoError(t, processor.ProcessFile(context.Background(), watcher.FileInfo{Path: fixedPath, Name: "partner_fixed.tsv", Hash: hash}))
+
+	var status, note string
+	var processed, failed, corrected int
+	require.NoError(t, db.QueryRow(`SELECT status, rows_processed, rows_failed, rows_corrected, correction_note FROM files WHERE filename = ?`, "partner.tsv").
+		Scan(&status, &processed, &failed, &corrected, &note))
+	assert.Equal(t, "completed", status)
+	assert.Equal(t, 3, processed)
+	assert.Equal(t, 0, failed)
+	assert.Equal(t, 2, corrected)
+	assert.Equal(t, "corrected: 2 rows by partner_fixed.tsv", note)
+
+	var originalID, correctsID int64
+	require.NoError(t, db.QueryRow(`SELECT id FROM files WHERE filename = ?`, "partner.tsv").Scan(&originalID))
+	require.NoError(t, db.QueryRow(`SELECT corrects_file_id FROM files WHERE filename = ?`, "partner_fixed.tsv").Scan(&correctsID))
+	assert.Equal(t, originalID, correctsID)
+
+	var events int
+	require.NoError(t, db.QueryRow(`SELECT COUNT(*) FROM file_events WHERE filename = ? AND event = ?`, "partner.tsv", EventCorrectionMerged).Scan(&events))
+	assert.Equal(t, 1, events)
+}
+
+func TestCorrectionOriginal_FilenameConvention(t *testing.T) {
+	processor, _, cfg, cleanup := setupTestProcessor(t)
+	defer cleanup()
+
+	path := createTestTSV(t, cfg.WatchPath, "a.rejected.tsv", []string{"1\t\tG\t01749246-95f6-57db-b7c3-2ae0e8be671f"})
+	assert.Equal(t, "a.tsv", processor.correctionOriginal(watcher.FileInfo{Path: path, Name: "a.rejected.tsv"}))
+
+	path = createTestTSV(t, cfg.WatchPath, "b.tsv", []string{"1\t\tG\t01749246-95f6-57db-b7c3-2ae0e8be671f"})
+	assert.Equal(t, "", processor.correctionOriginal(watcher.FileInfo{Path: path, Name: "b.tsv"}))
+}
+
+// ---------- Stage budgets ----------
+func TestStageBudget(t *testing.T) {
+	p := &Processor{}
+	p.SetStageBudgets(StageBudgets{Parse: 0.25, Insert: 0.5})
+
+	assert.Equal(t, 25*time.Second, p.stageBudget(StageParse, 100*time.Second))
+	assert.Equal(t, 50*time.Second, p.stageBudget(StageInsert, 100*time.Second))
+	assert.Equal(t, time.Duration(0), p.stageBudget(StageReport, 100*time.Second))
+	assert.Equal(t, time.Duration(0), p.stageBudget(StageParse, 0))
+}
+
+func TestProcessFile_ParseStageTimeout(t *testing.T) {
+	processor, db, cfg, cleanup := setupTestProcessor(t)
+	defer cleanup()
+	processor.SetStageBudgets(StageBudgets{Parse: 1e-9})
+
+	lines := []string{
+		"1\t\tG-044322\t01749246-95f6-57db-b7c3-2ae0e8be671f\tmsg\ttext\t\talarm\t100\tLOCAL\taddr\t\t\t\t",
+	}
+	filePath := createTestTSV(t, cfg.WatchPath, "slow.tsv", lines)
+	hash, _ := ingest.HashFile(filePath)
+	fileInfo := watcher.FileInfo{Path: filePath, Name: "slow.tsv", Hash: hash}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	err := processor.ProcessFile(ctx, fileInfo)
+
+	var stageErr *StageTimeoutError
+	require.ErrorAs(t, err, &stageErr)
+	assert.Equal(t, StageParse, stageErr.Stage)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+
+	// Транзакция откатилась, но ошибка сохранена в записи о файле
+	var status, message string
+	require.NoError(t, db.QueryRow(`SELECT status, error_message FROM files WHERE filename = ?`, "slow.tsv").
+		Scan(&status, &message))
+	assert.Equal(t, "failed", status)
+	assert.True(t, strings.HasPrefix(message, "[parse] "), message)
+
+	_, err = os.Stat(filePath)
+	assert.True(t, os.IsNotExist(err))
+	_, err = os.Stat(filepath.Join(cfg.ErrorPath, "slow.tsv"))
+	assert.NoError(t, err)
+}
+
+func TestProcessFile_WaitsForParseSlot(t *testing.T) {
+	processor, _, cfg, cleanup := setupTestProcessor(t)
+	defer cleanup()
+	processor.SetStageBudgets(StageBudgets{Parse: 0.01})
+	processor.SetStageConcurrency(1, 0)
+
+	// Единственное место разбора занято другим файлом
+	release, err := processor.parseSlots.acquire(context.Background())
+	require.NoError(t, err)
+	defer release()
+
+	lines := []string{
+		"1\t\tG-044322\t01749246-95f6-57db-b7c3-2ae0e8be671f\tmsg\ttext\t\talarm\t100\tLOCAL\taddr\t\t\t\t",
+	}
+	filePath := createTestTSV(t, cfg.WatchPath, "waiting.tsv", lines)
+	hash, _ := ingest.HashFile(filePath)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	err = processor.ProcessFile(ctx, watcher.FileInfo{Path: filePath, Name: "waiting.tsv", Hash: hash})
+
+	var stageErr *StageTimeoutError
+	require.ErrorAs(t, err, &stageErr)
+	assert.Equal(t, StageParse, stageErr.Stage)
+}
+func TestProcessFile_CancelledByOperator(t *testing.T) {
+	processor, db, cfg, cleanup := setupTestProcessor(t)
+	defer cleanup()
+	cfg.HoldPath = filepath.Join(filepath.Dir(cfg.WatchPath), "hold")
+	require.NoError(t, os.MkdirAll(cfg.HoldPath, 0755))
+
+	lines := []string{
+		"n\tmqtt\tinvid\tunit_guid\tmsg_id\ttext\tcontext\tclass\tlevel\tarea\taddr\tblock\ttype\tbit\tinvert_bit",
+		"1\t\tG-044322\t01749246-95f6-57db-b7c3-2ae0e8be671f\tmsg\ttext\t\talarm\t100\tLOCAL\taddr\t\t\t\t",
+	}
+	filePath := createTestTSV(t, cfg.WatchPath, "cancel.tsv", lines)
+	hash, _ := ingest.HashFile(filePath)
+	fileInfo := watcher.FileInfo{Path: filePath, Name: "cancel.tsv", Hash: hash}
+
+	ctx, cancel := context.WithCancelCause(context.Background())
+	cancel(ErrCancelled)
+	err := processor.ProcessFile(ctx, fileInfo)
+	require.ErrorIs(t, err, ErrCancelled)
+
+	// Данных нет, файл помечен cancelled и отложен в hold_path
+	var status, message string
+	require.NoError(t, db.QueryRow(`SELECT status, error_message FROM files WHERE filename = ?`, "cancel.tsv").
+		Scan(&status, &message))
+	assert.Equal(t, string(filestatus.Cancelled), status)
+	assert.True(t, strings.HasPrefix(message, "[cancel] "), message)
+	var rows int
+	require.NoError(t, db.QueryRow(`SELECT COUNT(*) FROM device_data`).Scan(&rows))
+	assert.Zero(t, rows)
+	require.NoError(t, db.QueryRow(`SELECT COUNT(*) FROM processing_errors`).Scan(&rows))
+	assert.Zero(t, rows)
+	_, err = os.Stat(filePath)
+	assert.True(t, os.IsNotExist(err))
+
+	// Возвращённый в watch-директорию файл обрабатывается заново
+	require.NoError(t, os.Rename(filepath.Join(cfg.HoldPath, "cancel.tsv"), filePath))
+	require.NoError(t, processor.ProcessFile(context.Background(), fileInfo))
+	require.NoError(t, db.QueryRow(`SELECT status FROM files WHERE filename = ?`, "cancel.tsv").Scan(&status))
+	assert.Equal(t, "completed", status)
+	require.NoError(t, db.QueryRow(`SELECT COUNT(*) FROM device_data`).Scan(&rows))
+	assert.Equal(t, 1, rows)
+}
+
+func TestProcessFile_StoresTraceIDs(t *testing.T) {
+	processor, db, cfg, cleanup := setupTestProcessor(t)
+	defer cleanup()
+
+	lines := []string{
+		"n\tmqtt\tinvid\tunit_guid\tmsg_id\ttext\tcontext\tclass\tlevel\tarea\taddr\tblock\ttype\tbit\tinvert_bit",
+		"1\t\tG-044322\t01749246-95f6-57db-b7c3-2ae0e8be671f\tmsg\ttext\t\talarm\t100\tLOCAL\taddr\t\t\t\t",
+	}
+	filePath := createTestTSV(t, cfg.WatchPath, "traced.tsv", lines)
+	hash, _ := ingest.HashFile(filePath)
+	parent := tracing.SpanContext{TraceID: "4bf92f3577b34da6a3ce929d0e0e4736", SpanID: "00f067aa0ba902b7"}
+	fileInfo := watcher.FileInfo{Path: filePath, Name: "traced.tsv", Hash: hash, Trace: parent}
+
+	require.NoError(t, processor.ProcessFile(context.Background(), fileInfo))
+
+	// Файл обработан в трассе запроса, в собственном span
+	var traceID, spanID string
+	require.NoError(t, db.QueryRow(`SELECT trace_id, span_id FROM files WHERE filename = ?`, "traced.tsv").
+		Scan(&traceID, &spanID))
+	assert.Equal(t, parent.TraceID, traceID)
+	assert.Len(t, spanID, 16)
+	assert.NotEqual(t, parent.SpanID, spanID)
+
+	// Отчёт сгенерирован в той же трассе, в дочернем span
+	var reportTrace, reportSpan string
+	require.NoError(t, db.QueryRow(`SELECT trace_id, span_id FROM reports`).Scan(&reportTrace, &reportSpan))
+	assert.Equal(t, parent.TraceID, reportTrace)
+	assert.NotEqual(t, spanID, reportSpan)
+
+	// Файл из watch-директории получает новую трассу
+	filePath = createTestTSV(t, cfg.WatchPath, "untraced.tsv", append(lines,
+		"2\t\tG-044322\t01749246-95f6-57db-b7c3-2ae0e8be671f\tmsg\ttext\t\talarm\t100\tLOCAL\taddr\t\t\t\t"))
+	hash, _ = ingest.HashFile(filePath)
+	require.NoError(t, processor.ProcessFile(context.Background(), watcher.FileInfo{Path: filePath, Name: "untraced.tsv", Hash: hash}))
+	require.NoError(t, db.QueryRow(`SELECT trace_id FROM files WHERE filename = ?`, "untraced.tsv").Scan(&traceID))
+	assert.Len(t, traceID, 32)
+	assert.NotEqual(t, parent.TraceID, traceID)
+}
+
+func TestProcessFile_StoresSampleRate(t *testing.T) {
+	processor, db, cfg, cleanup := setupTestProcessor(t)
+	defer cleanup()
+
+	lines := []string{"n\tmqtt\tinvid\tunit_guid\tmsg_id\ttext\tcontext\tclass\tlevel\tarea\taddr\tblock\ttype\tbit\tinvert_bit"}
+	for i := 1; i <= 9; i++ {
+		lines = append(lines, fmt.Sprintf("%d\t\tG-044322\t01749246-95f6-57db-b7c3-2ae0e8be671f\tmsg_%d\ttext\t\talarm\t100\tLOCAL\taddr\t\t\t\t", i, i))
+	}
+	filePath := createTestTSV(t, cfg.WatchPath, "sampled.tsv", lines)
+	hash, _ := ingest.HashFile(filePath)
+	fileInfo := watcher.FileInfo{Path: filePath, Name: "sampled.tsv", Hash: hash, Sampling: ingest.Sampling{Every: 3}}
+
+	require.NoError(t, processor.ProcessFile(context.Background(), fileInfo))
+
+	var rate sql.NullFloat64
+	var rows int
+	require.NoError(t, db.QueryRow(`SELECT sample_rate, rows_processed FROM files WHERE filename = ?`, "sampled.tsv").Scan(&rate, &rows))
+	assert.True(t, rate.Valid)
+	assert.InDelta(t, 1.0/3, rate.Float64, 1e-9)
+	assert.Equal(t, 3, rows)
+
+	var count int
+	require.NoError(t, db.QueryRow(`SELECT COUNT(*) FROM device_data`).Scan(&count))
+	assert.Equal(t, 3, count)
+}
+
+func TestProcessFile_RecordsTimeline(t *testing.T) {
+	processor, db, cfg, cleanup := setupTestProcessor(t)
+	defer cleanup()
+	processor.SetProgressInterval(1, 0)
+
+	lines := []string{
+		"n\tmqtt\tinvid\tunit_guid\tmsg_id\ttext\tcontext\tclass\tlevel\tarea\taddr\tblock\ttype\tbit\tinvert_bit",
+		"1\t\tG-044322\t01749246-95f6-57db-b7c3-2ae0e8be671f\tmsg1\ttext\t\talarm\t100\tLOCAL\taddr\t\t\t\t",
+		"2\t\tG-044322\t01749246-95f6-57db-b7c3-2ae0e8be671f\tmsg2\ttext\t\talarm\t100\tLOCAL\taddr\t\t\t\t",
+	}
+	filePath := createTestTSV(t, cfg.WatchPath, "timeline.tsv", lines)
+	hash, _ := ingest.HashFile(filePath)
+	now := time.Now()
+	fileInfo := watcher.FileInfo{
+		Path:      filePath,
+		Name:      "timeline.tsv",
+		Hash:      hash,
+		ArrivedAt: now.Add(-2 * time.Second),
+		QueuedAt:  now.Add(-time.Second),
+	}
+
+	processor.RecordEvent(fileInfo.Name, EventClaimed, "worker-1")
+	assert.Len(t, processor.LiveTimeline(fileInfo.Name), 1, "events are visible before they are saved")
+	require.NoError(t, processor.ProcessFile(context.Background(), fileInfo))
+	assert.Empty(t, processor.LiveTimeline(fileInfo.Name))
+
+	events, err := sqlc.New(db).ListFileEvents(context.Background(), fileInfo.Name)
+	require.NoError(t, err)
+	var names []string
+	for _, e := range events {
+		names = append(names, e.Event)
+	}
+	assert.Equal(t, []string{
+		EventDetected, EventQueued, EventClaimed, EventParseStarted, EventParseFinished,
+		EventInsertChunk, EventInsertChunk, EventCommitted, EventReportsGenerated, EventArchived,
+	}, names)
+	assert.Equal(t, "worker-1", events[2].Detail)
+	assert.Equal(t, "2 rows, 0 parse errors", events[4].Detail)
+	assert.Equal(t, "2/2 rows, 0 failed", events[6].Detail)
+	assert.Equal(t, "completed", events[7].Detail)
+}
+
+func TestProcessFile_TimelineOfFailedFile(t *testing.T) {
+	processor, db, cfg, cleanup := setupTestProcessor(t)
+	defer cleanup()
+
+	filePath := createTestTSV(t, cfg.WatchPath, "broken.tsv", []string{"\x00\x01\x02"})
+	fileInfo := watcher.FileInfo{Path: filePath, Name: "broken.tsv", Hash: "h"}
+	require.Error(t, processor.ProcessFile(context.Background(), fileInfo))
+
+	// События сохраняются, хотя транзакция откачена
+	events, err := sqlc.New(db).ListFileEvents(context.Background(), fileInfo.Name)
+	require.NoError(t, err)
+	require.NotEmpty(t, events)
+	last := events[len(events)-2]
+	assert.Equal(t, EventFailed, last.Event)
+	assert.True(t, strings.HasPrefix(last.Detail, "[validate] "), last.Detail)
+	assert.Equal(t, EventMovedToErrors, events[len(events)-1].Event)
+}
+
+func TestProcessFile_PersistsStageFailure(t *testing.T) {
+	processor, db, cfg, cleanup := setupTestProcessor(t)
+	defer cleanup()
+
+	lines := []string{
+		"1\t\tG-044322\t01749246-95f6-57db-b7c3-2ae0e8be671f\tmsg\ttext\t\talarm\t100\tLOCAL\taddr\t\t\t\t",
+	}
+	filePath := createTestTSV(t, cfg.WatchPath, "gone.tsv", lines)
+	hash, _ := ingest.HashFile(filePath)
+	fileInfo := watcher.FileInfo{Path: filePath, Name: "gone.tsv", Hash: hash}
+
+	// Файл удалён до начала обработки: ошибка на этапе ожидания файла
+	require.NoError(t, os.Remove(filePath))
+	err := processor.ProcessFile(context.Background(), fileInfo)
+
+	var stageErr *StageError
+	require.ErrorAs(t, err, &stageErr)
+	assert.Equal(t, StageReady, stageErr.Stage)
+
+	var status, message string
+	require.NoError(t, db.QueryRow(`SELECT status, error_message FROM files WHERE filename = ?`, "gone.tsv").
+		Scan(&status, &message))
+	assert.Equal(t, "failed", status)
+	assert.True(t, strings.HasPrefix(message, "[ready] file not ready"), message)
+
+	var errorsCount int
+	require.NoError(t, db.QueryRow(`SELECT COUNT(*) FROM processing_errors`).Scan(&errorsCount))
+	assert.Equal(t, 1, errorsCount)
+}
+
+func TestProcessFile_GrowingFileStaysInWatchDir(t *testing.T) {
+	processor, db, _, cleanup := setupTestProcessor(t)
+	defer cleanup()
+	fake := clock.NewFake(time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC))
+	mem := fsys.NewMem(fake.Now)
+	processor.SetClock(fake)
+	processor.SetFS(mem)
+	mem.WriteFile("/watch/growing.tsv", []byte("n\tmqtt\n"))
+	fileInfo := watcher.FileInfo{Path: "/watch/growing.tsv", Name: "growing.tsv", Hash: "h"}
+
+	done := make(chan error, 1)
+	go func() { done <- processor.ProcessFile(context.Background(), fileInfo) }()
+
+	// Файл дописывается, пока не истечёт ожидание готовности
+	stop := make(chan struct{})
+	go func() {
+		for {
+			fake.BlockUntil(1)
+			select {
+			case <-stop:
+				return
+			default:
+			}
+			mem.Append("/watch/growing.tsv", []byte("1\tmqtt\n"))
+			fake.Advance(500 * time.Millisecond)
+		}
+	}()
+	err := <-done
+	close(stop)
+	fake.After(time.Hour) // таймер для BlockUntil: дописывающая горутина завершается
+	require.ErrorIs(t, err, ErrNotReady)
+
+	// Не ошибка файла: записи о файле нет, файл остаётся в watch-директории
+	var files int
+	require.NoError(t, db.QueryRow(`SELECT COUNT(*) FROM files`).Scan(&files))
+	assert.Zero(t, files)
+	_, statErr := mem.Stat("/watch/growing.tsv")
+	assert.NoError(t, statErr)
+	_, statErr = mem.Stat("/errors/growing.tsv")
+	assert.True(t, os.IsNotExist(statErr))
+
+	events, err := sqlc.New(db).ListFileEvents(context.Background(), fileInfo.Name)
+	require.NoError(t, err)
+	require.NotEmpty(t, events)
+	assert.Equal(t, EventNotReady, events[len(events)-1].Event)
+}
+
+// ---------- Retry ----------
+
+// deadlockDB - обёртка транзакции файла, в которой завершение файла
+// (очистка попыток повтора) падает взаимоблокировкой, пока fail взведён
+type deadlockDB struct {
+	sqlc.DBTX
+	fail *atomic.Bool
+}
+
+func (d deadlockDB) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	if d.fail.Load() && strings.Contains(query, "-- name: DeleteFileRetry ") {
+		return nil, &pq.Error{Code: "40P01", Message: "deadlock detected"}
+	}
+	return d.DBTX.ExecContext(ctx, query, args...)
+}
+
+func setupRetryProcessor(t *testing.T, attempts int) (*Processor, *sql.DB, *config.DirectoryConfig, *atomic.Bool, chan watcher.FileInfo) {
+	processor, db, cfg, cleanup := setupTestProcessor(t)
+	t.Cleanup(cleanup)
+
+	fail := &atomic.Bool{}
+	fail.Store(true)
+	processor.SetTxWrapper(func(tx sqlc.DBTX) sqlc.DBTX { return deadlockDB{DBTX: tx, fail: fail} })
+
+	requeued := make(chan watcher.FileInfo, 1)
+	processor.SetRetry(attempts, 50*time.Millisecond, func(fileInfo watcher.FileInfo) error {
+		requeued <- fileInfo
+		return nil
+	})
+	return processor, db, cfg, fail, requeued
+}
+
+func TestProcessFile_RetriesTransientFailure(t *testing.T) {
+	processor, db, cfg, fail, requeued := setupRetryProcessor(t, 3)
+
+	lines := []string{
+		"1\t\tG-044322\t01749246-95f6-57db-b7c3-2ae0e8be671f\tmsg\ttext\t\talarm\t100\tLOCAL\taddr\t\t\t\t",
+	}
+	filePath := createTestTSV(t, cfg.WatchPath, "retry.tsv", lines)
+	hash, _ := ingest.HashFile(filePath)
+	fileInfo := watcher.FileInfo{Path: filePath, Name: "retry.tsv", Hash: hash}
+
+	// Взаимоблокировка: файл остаётся в watch-директории со статусом retrying
+	require.Error(t, processor.ProcessFile(context.Background(), fileInfo))
+	assert.FileExists(t, filePath)
+
+	var status string
+	require.NoError(t, db.QueryRow(`SELECT status FROM files WHERE filename = ?`, "retry.tsv").Scan(&status))
+	assert.Equal(t, string(filestatus.Retrying), status)
+
+	retry, err := sqlc.New(db).GetFileRetry(context.Background(), "retry.tsv")
+	require.NoError(t, err)
+	assert.Equal(t, int32(1), retry.Attempts)
+	assert.Contains(t, retry.LastError.String, "deadlock detected")
+
+	// До срока повтора файл пропускается
+	require.NoError(t, processor.ProcessFile(context.Background(), fileInfo))
+	assert.FileExists(t, filePath)
+
+	select {
+	case got := <-requeued:
+		assert.Equal(t, fileInfo.Name, got.Name)
+	case <-time.After(5 * time.Second):
+		t.Fatal("file was not requeued")
+	}
+
+	// Повтор после устранения ошибки проходит, счётчик попыток удаляется
+	fail.Store(false)
+	require.NoError(t, processor.ProcessFile(context.Background(), fileInfo))
+	assert.FileExists(t, filepath.Join(cfg.ArchivePath, "retry.tsv"))
+
+	require.NoError(t, db.QueryRow(`SELECT status FROM files WHERE filename = ?`, "retry.tsv").Scan(&status))
+	assert.Equal(t, "completed", status)
+	_, err = sqlc.New(db).GetFileRetry(context.Background(), "retry.tsv")
+	assert.ErrorIs(t, err, sql.ErrNoRows)
+}
+
+func TestProcessFile_MovesToErrorsAfterRetries(t *testing.T) {
+	processor, db, cfg, _, requeued := setupRetryProcessor(t, 1)
+
+	lines := []string{
+		"1\t\tG-044322\t01749246-95f6-57db-b7c3-2ae0e8be671f\tmsg\ttext\t\talarm\t100\tLOCAL\taddr\t\t\t\t",
+	}
+	filePath := createTestTSV(t, cfg.WatchPath, "exhausted.tsv", lines)
+	hash, _ := ingest.HashFile(filePath)
+	fileInfo := watcher.FileInfo{Path: filePath, Name: "exhausted.tsv", Hash: hash}
+
+	require.Error(t, processor.ProcessFile(context.Background(), fileInfo))
+	assert.FileExists(t, filePath)
+	<-requeued
+
+	// Попытки исчерпаны: файл уходит в error_path
+	require.Error(t, processor.ProcessFile(context.Background(), fileInfo))
+	assert.FileExists(t, filepath.Join(cfg.ErrorPath, "exhausted.tsv"))
+
+	var status string
+	require.NoError(t, db.QueryRow(`SELECT status FROM files WHERE filename = ?`, "exhausted.tsv").Scan(&status))
+	assert.Equal(t, "failed", status)
+	_, err := sqlc.New(db).GetFileRetry(context.Background(), "exhausted.tsv")
+	assert.ErrorIs(t, err, sql.ErrNoRows)
+}
+
+// ---------- Async reports ----------
+func TestProcessFile_AsyncReports(t *testing.T) {
+	processor, db, cfg, cleanup := setupTestProcessor(t)
+	defer cleanup()
+	db.SetMaxOpenConns(1)
+
+	processor.StartReportWorkers(1, 10, time.Minute)
+
+	lines := []string{
+		"1\t\tG-044322\t01749246-95f6-57db-b7c3-2ae0e8be671f\tmsg\ttext\t\talarm\t100\tLOCAL\taddr\t\t\t\t",
+	}
+	filePath := createTestTSV(t, cfg.WatchPath, "async.tsv", lines)
+	hash, _ := ingest.HashFile(filePath)
+	fileInfo := watcher.FileInfo{Path: filePath, Name: "async.tsv", Hash: hash}
+
+	err := processor.ProcessFile(context.Background(), fileInfo)
+	require.NoError(t, err)
+
+	// Файл архивируется, не дожидаясь отчётов
+	_, err = os.Stat(filepath.Join(cfg.ArchivePath, "async.tsv"))
+	assert.NoError(t, err)
+
+	processor.StopReportWorkers()
+
+	var reportCount int
+	err = db.QueryRow(`SELECT COUNT(*) FROM reports`).Scan(&reportCount)
+	require.NoError(t, err)
+	assert.Equal(t, 1, reportCount)
+
+	// После остановки очередь не принимает задания
+	_, err = processor.EnqueueUnitReport(uuid.New(), ReportOptions{})
+	assert.ErrorIs(t, err, ErrReportQueueFull)
+}
+
+// ---------- Panic recovery ----------
+type panickingHook struct{}
+
+func (panickingHook) Name() string { return "panicking" }
+
+func (panickingHook) AfterProcess(ctx context.Context, result ProcessResult) error {
+	panic("unexpected nil map")
+}
+
+func TestProcessFile_RecoversPanic(t *testing.T) {
+	processor, db, cfg, cleanup := setupTestProcessor(t)
+	defer cleanup()
+	processor.RegisterHook(panickingHook{})
+
+	lines := []string{
+		"1\t\tG-044322\t01749246-95f6-57db-b7c3-2ae0e8be671f\tmsg\ttext\t\talarm\t100\tLOCAL\taddr\t\t\t\t",
+	}
+	filePath := createTestTSV(t, cfg.WatchPath, "panic.tsv", lines)
+	hash, _ := ingest.HashFile(filePath)
+	fileInfo := watcher.FileInfo{Path: filePath, Name: "panic.tsv", Hash: hash}
+
+	err := processor.ProcessFile(context.Background(), fileInfo)
+	var panicErr *PanicError
+	require.ErrorAs(t, err, &panicErr)
+	assert.Equal(t, "unexpected nil map", panicErr.Value)
+
+	var status, message string
+	err = db.QueryRow(`SELECT status, error_message FROM files WHERE filename = 'panic.tsv'`).Scan(&status, &message)
+	require.NoError(t, err)
+	assert.Equal(t, "failed", status)
+	assert.Contains(t, message, "panic: unexpected nil map")
+	assert.LessOrEqual(t, len(message), maxErrorMessageLen)
+
+	var errorCount int
+	err = db.QueryRow(`SELECT COUNT(*) FROM processing_errors WHERE error_message = 'panic: unexpected nil map'`).Scan(&errorCount)
+	require.NoError(t, err)
+	assert.Equal(t, 1, errorCount)
+
+	_, err = os.Stat(filepath.Join(cfg.ErrorPath, "panic.tsv"))
+	assert.NoError(t, err)
+}
+
+// ---------- Progress ----------
+func TestProgress_ETA(t *testing.T) {
+	now := time.Now()
+	progress := Progress{
+		Stage:         StageInsert,
+		RowsTotal:     1000,
+		RowsProcessed: 190,
+		RowsFailed:    10,
+		StartedAt:     now.Add(-2 * time.Second),
+		UpdatedAt:     now,
+	}
+	assert.InDelta(t, 100, progress.Rate(), 0.001)
+
+	eta, ok := progress.ETA()
+	require.True(t, ok)
+	assert.Equal(t, 8*time.Second, eta)
+
+	progress.Stage = StageParse
+	_, ok = progress.ETA()
+	assert.False(t, ok)
+}
+
+func TestProcessFile_TracksProgress(t *testing.T) {
+	processor, _, cfg, cleanup := setupTestProcessor(t)
+	defer cleanup()
+	processor.SetProgressInterval(1, time.Hour)
+
+	lines := []string{
+		"1\t\tG-044322\t01749246-95f6-57db-b7c3-2ae0e8be671f\tmsg\ttext\t\talarm\t100\tLOCAL\taddr\t\t\t\t",
+		"2\t\tG-044322\t01749246-95f6-57db-b7c3-2ae0e8be671f\tmsg\ttext\t\talarm\t100\tLOCAL\taddr\t\t\t\t",
+	}
+	filePath := createTestTSV(t, cfg.WatchPath, "progress.tsv", lines)
+	hash, _ := ingest.HashFile(filePath)
+	fileInfo := watcher.FileInfo{Path: filePath, Name: "progress.tsv", Hash: hash}
+
+	// Счётчики публикуются во время вставки
+	processor.progress.start(1, fileInfo.Name)
+	processor.progress.beginInsert(fileInfo.Name, 2)
+	require.True(t, processor.progress.due(fileInfo.Name, 1, 0))
+	processor.progress.update(fileInfo.Name, 1, 0)
+	progress, ok := processor.Progress(fileInfo.Name)
+	require.True(t, ok)
+	assert.Equal(t, int32(1), progress.RowsProcessed)
+	assert.Equal(t, int32(2), progress.RowsTotal)
+	processor.progress.finish(fileInfo.Name)
+
+	// После обработки живой прогресс не хранится
+	require.NoError(t, processor.ProcessFile(context.Background(), fileInfo))
+	_, ok = processor.Progress(fileInfo.Name)
+	assert.False(t, ok)
+}
+
+func TestProgressPublisher_SavesProgressRecord(t *testing.T) {
+	processor, db, _, cleanup := setupTestProcessor(t)
+	defer cleanup()
+	db.SetMaxOpenConns(1) // :memory: - отдельная база на соединение
+	queries := sqlc.New(db)
+
+	processor.StartProgressPublisher()
+	processor.progress.start(7, "live.tsv")
+	processor.progress.beginInsert("live.tsv", 10)
+	processor.progress.update("live.tsv", 4, 1)
+	processor.StopProgressPublisher()
+
+	record, err := queries.GetFileProgress(context.Background(), "live.tsv")
+	require.NoError(t, err)
+	progress := ProgressFromRecord(record)
+	assert.Equal(t, int64(7), progress.FileID)
+	assert.Equal(t, StageInsert, progress.Stage)
+	assert.Equal(t, int32(10), progress.RowsTotal)
+	assert.Equal(t, int32(4), progress.RowsProcessed)
+	assert.Equal(t, int32(1), progress.RowsFailed)
+
+	// Законченная обработка удаляет запись
+	processor.StartProgressPublisher()
+	processor.progress.finish("live.tsv")
+	processor.StopProgressPublisher()
+	_, err = queries.GetFileProgress(context.Background(), "live.tsv")
+	assert.ErrorIs(t, err, sql.ErrNoRows)
+}
+
+func TestProcessFile_RejectsBinaryFile(t *testing.T) {
+	processor, db, cfg, cleanup := setupTestProcessor(t)
+	defer cleanup()
+
+	filePath := filepath.Join(cfg.WatchPath, "photo.tsv")
+	require.NoError(t, os.WriteFile(filePath, []byte("\xff\xd8\xff\xe0\x00\x10JFIF\x00"), 0644))
+	fileInfo := watcher.FileInfo{Path: filePath, Name: "photo.tsv", Hash: "hash"}
+
+	err := processor.ProcessFile(context.Background(), fileInfo)
+	require.ErrorIs(t, err, ingest.ErrNotText)
+
+	var status, message string
+	require.NoError(t, db.QueryRow(`SELECT status, error_message FROM files WHERE filename = ?`, "photo.tsv").
+		Scan(&status, &message))
+	assert.Equal(t, "failed", status)
+	assert.True(t, strings.HasPrefix(message, "[validate] file rejected"), message)
+
+	_, err = os.Stat(filepath.Join(cfg.ErrorPath, "photo.tsv"))
+	assert.NoError(t, err)
+}
+
+func TestProcessFile_RecordsTimings(t *testing.T) {
+	processor, db, cfg, cleanup := setupTestProcessor(t)
+	defer cleanup()
+
+	lines := []string{
+		"1\t\tG-044322\t01749246-95f6-57db-b7c3-2ae0e8be671f\tmsg\ttext\t\talarm\t100\tLOCAL\taddr\t\t\t\t",
+	}
+	filePath := createTestTSV(t, cfg.WatchPath, "timed.tsv", lines)
+	hash, _ := ingest.HashFile(filePath)
+	modTime := time.Now().Add(-5 * time.Minute)
+	arrivedAt := time.Now().Add(-time.Minute)
+	fileInfo := watcher.FileInfo{Path: filePath, Name: "timed.tsv", Hash: hash,
+		ModTime: modTime, ArrivedAt: arrivedAt, Source: "tenant:a"}
+
+	require.NoError(t, processor.ProcessFile(context.Background(), fileInfo))
+
+	file, err := sqlc.New(db).GetFileByFilename(context.Background(), "timed.tsv")
+	require.NoError(t, err)
+	assert.Equal(t, "tenant:a", file.Source)
+	require.True(t, file.FileMtime.Valid)
+	require.True(t, file.ArrivedAt.Valid)
+	require.True(t, file.CompletedAt.Valid)
+	assert.WithinDuration(t, modTime, file.FileMtime.Time, time.Second)
+	assert.WithinDuration(t, arrivedAt, file.ArrivedAt.Time, time.Second)
+	assert.True(t, file.CompletedAt.Time.After(file.ArrivedAt.Time))
+}
+
+func TestProcessFile_EncryptsTenantReports(t *testing.T) {
+	processor, db, cfg, cleanup := setupTestProcessor(t)
+	defer cleanup()
+	processor.SetReportConfig(config.ReportConfig{
+		TenantPasswords: map[string]string{"partner": "secret"},
+	})
+
+	// Разные устройства - разные файлы отчётов
+	guids := map[string]string{
+		"tenant:partner": "01749246-95f6-57db-b7c3-2ae0e8be671f",
+		"directory":      "02749246-95f6-57db-b7c3-2ae0e8be671f",
+	}
+	for _, source := range []string{"tenant:partner", "directory"} {
+		lines := []string{
+			"n\tmqtt\tinvid\tunit_guid\tmsg_id\ttext\tcontext\tclass\tlevel\tarea\taddr\tblock\ttype\tbit\tinvert_bit",
+			"1\t\tG-044322\t" + guids[source] + "\tcold7_Defrost_status\tРазморозка\t\twaiting\t100\tLOCAL\tcold7_status.Defrost_status\t\t\t\t",
+		}
+		name := strings.ReplaceAll(source, ":", "_") + ".tsv"
+		filePath := createTestTSV(t, cfg.WatchPath, name, lines)
+		hash, err := ingest.HashFile(filePath)
+		require.NoError(t, err)
+		require.NoError(t, processor.ProcessFile(context.Background(),
+			watcher.FileInfo{Path: filePath, Name: name, Hash: hash, Source: source}))
+	}
+
+	rows, err := db.Query(`SELECT file_path FROM reports ORDER BY id`)
+	require.NoError(t, err)
+	defer rows.Close()
+	var encrypted []bool
+	for rows.Next() {
+		var path string
+		require.NoError(t, rows.Scan(&path))
+		content, err := os.ReadFile(path)
+		require.NoError(t, err)
+		encrypted = append(encrypted, bytes.Contains(content, []byte("/Encrypt")))
+	}
+	assert.Equal(t, []bool{true, false}, encrypted)
+}
+
+func TestDatumPeriod_ReportLanguageAndTimezone(t *testing.T) {
+	at := func(s string) sqlc.DeviceDatum {
+		ts, err := time.Parse(time.RFC3339, s)
+		require.NoError(t, err)
+		return sqlc.DeviceDatum{CreatedAt: sql.NullTime{Time: ts, Valid: true}}
+	}
+	// Записи от новых к старым; 22:30 UTC - уже следующие сутки по Москве
+	part := []sqlc.DeviceDatum{at("2026-03-02T10:00:00Z"), at("2026-03-01T22:30:00Z")}
+
+	p := &Processor{}
+	p.SetReportConfig(config.ReportConfig{Language: "ru", Timezone: "Europe/Moscow"})
+	assert.Equal(t, "02.03.2026", datumPeriod(part, p.reportFormat("")))
+
+	p.SetReportConfig(config.ReportConfig{Language: "ru"})
+	assert.Equal(t, "01.03.2026 - 02.03.2026", datumPeriod(part, p.reportFormat("")))
+	assert.Equal(t, "Mar 1, 2026 - Mar 2, 2026", datumPeriod(part, p.reportFormat("en")))
+}
+
+func TestGenerateReportForUnit_SplitsIntoParts(t *testing.T) {
+	processor, db, cfg, cleanup := setupTestProcessor(t)
+	defer cleanup()
+
+	guid := "01749246-95f6-57db-b7c3-2ae0e8be671f"
+	lines := []string{"n\tmqtt\tinvid\tunit_guid\tmsg_id\ttext\tcontext\tclass\tlevel\tarea\taddr\tblock\ttype\tbit\tinvert_bit"}
+	for i := 1; i <= 5; i++ {
+		lines = append(lines, fmt.Sprintf("%d\t\tG-044322\t%s\tmsg_%d\tРазморозка\t\twaiting\t100\tLOCAL\tcold7_status.Defrost_status\t\t\t\t", i, guid, i))
+	}
+	filePath := createTestTSV(t, cfg.WatchPath, "parts.tsv", lines)
+	hash, err := ingest.HashFile(filePath)
+	require.NoError(t, err)
+	require.NoError(t, processor.ProcessFile(context.Background(),
+		watcher.FileInfo{Path: filePath, Name: "parts.tsv", Hash: hash}))
+
+	group := uuid.New()
+	err = processor.GenerateReportForUnit(context.Background(), uuid.MustParse(guid),
+		ReportOptions{PartSize: 2, Group: group})
+	require.NoError(t, err)
+
+	rows, err := db.Query(`SELECT part, file_path FROM reports WHERE report_group = ? ORDER BY part`, group.String())
+	require.NoError(t, err)
+	defer rows.Close()
+	var parts []int
+	for rows.Next() {
+		var part int
+		var path string
+		require.NoError(t, rows.Scan(&part, &path))
+		assert.Contains(t, path, fmt.Sprintf("_part%03d.pdf", part))
+		parts = append(parts, part)
+	}
+	assert.Equal(t, []int{1, 2, 3}, parts)
+
+	// Без данных отчёт не создаётся
+	err = processor.GenerateReportForUnit(context.Background(), uuid.New(), ReportOptions{})
+	assert.Error(t, err)
+}
+
+func TestGenerateReportForUnit_ReadsAllPages(t *testing.T) {
+	processor, _, cfg, cleanup := setupTestProcessor(t)
+	defer cleanup()
+
+	// Больше двух страниц чтения: все записи попадают в отчёт по одному разу
+	guid := "01749246-95f6-57db-b7c3-2ae0e8be671f"
+	total := 2*reportPageSize + 500
+	lines := []string{"n\tmqtt\tinvid\tunit_guid\tmsg_id\ttext\tcontext\tclass\tlevel\tarea\taddr\tblock\ttype\tbit\tinvert_bit"}
+	for i := 1; i <= total; i++ {
+		lines = append(lines, fmt.Sprintf("%d\t\tG-044322\t%s\tmsg_%d\ttext\t\twaiting\t100\tLOCAL\taddr\t\t\t\t", i, guid, i))
+	}
+	filePath := createTestTSV(t, cfg.WatchPath, "pages.tsv", lines)
+	hash, err := ingest.HashFile(filePath)
+	require.NoError(t, err)
+	require.NoError(t, processor.ProcessFile(context.Background(),
+		watcher.FileInfo{Path: filePath, Name: "pages.tsv", Hash: hash}))
+
+	group := uuid.New()
+	processor.reportJobs.queue(group, uuid.MustParse(guid))
+	require.NoError(t, processor.GenerateReportForUnit(context.Background(), uuid.MustParse(guid),
+		ReportOptions{PartSize: 1000, Group: group}))
+
+	job, ok := processor.ReportJobStatus(group)
+	require.True(t, ok)
+	assert.Equal(t, int64(total), job.Records)
+	assert.Equal(t, 3, job.Parts)
+}
+
+func TestUnitDataPage_CursorOverNullAndEqualTimes(t *testing.T) {
+	processor, db, cfg, cleanup := setupTestProcessor(t)
+	defer cleanup()
+	queries := sqlc.New(db)
+
+	guid := "01749246-95f6-57db-b7c3-2ae0e8be671f"
+	var lines []string
+	for i := 1; i <= 7; i++ {
+		lines = append(lines, fmt.Sprintf("%d\t\tG-044322\t%s\tmsg_%d\ttext\t\twaiting\t100\tLOCAL\taddr\t\t\t\t", i, guid, i))
+	}
+	filePath := createTestTSV(t, cfg.WatchPath, "cursor.tsv", lines)
+	hash, err := ingest.HashFile(filePath)
+	require.NoError(t, err)
+	require.NoError(t, processor.ProcessFile(context.Background(),
+		watcher.FileInfo{Path: filePath, Name: "cursor.tsv", Hash: hash}))
+
+	// Строки 1 и 4 без created_at, у 2, 3 и 5 одинаковое время
+	base := time.Date(2025, 3, 14, 10, 0, 0, 0, time.UTC)
+	times := map[int]sql.NullTime{
+		1: {}, 2: {Time: base, Valid: true}, 3: {Time: base, Valid: true}, 4: {},
+		5: {Time: base, Valid: true}, 6: {Time: base.Add(time.Hour), Valid: true}, 7: {Time: base.Add(-time.Hour), Valid: true},
+	}
+	for line, createdAt := range times {
+		_, err := db.Exec(`UPDATE device_data SET created_at = ? WHERE line_number = ?`, createdAt, line)
+		require.NoError(t, err)
+	}
+	idOf := func(line int) int64 {
+		var id int64
+		require.NoError(t, db.QueryRow(`SELECT id FROM device_data WHERE line_number = ?`, line).Scan(&id))
+		return id
+	}
+	// От новых к старым, записи без времени - первыми
+	var expected []int64
+	for _, line := range []int{4, 1, 6, 5, 3, 2, 7} {
+		expected = append(expected, idOf(line))
+	}
+
+	// По две записи на страницу; запись курсора удаляется до запроса
+	// следующей страницы - значения курсора не читаются из БД
+	page, err := queries.ListDeviceDataByUnit(context.Background(), sqlc.ListDeviceDataByUnitParams{
+		UnitGuid: uuid.MustParse(guid), Limit: 2,
+	})
+	require.NoError(t, err)
+	var got []int64
+	for len(page) > 0 {
+		for _, d := range page {
+			got = append(got, d.ID)
+		}
+		cursor := page[len(page)-1]
+		_, err := db.Exec(`DELETE FROM device_data WHERE id = ?`, cursor.ID)
+		require.NoError(t, err)
+		page, err = queries.ListDeviceDataByUnitBefore(context.Background(), sqlc.ListDeviceDataByUnitBeforeParams{
+			UnitGuid: uuid.MustParse(guid), CreatedAt: cursor.CreatedAt, ID: cursor.ID, Limit: 2,
+		})
+		require.NoError(t, err)
+	}
+	assert.Equal(t, expected, got)
+}
+
+func TestGenerateReportForUnit_Formats(t *testing.T) {
+	processor, db, cfg, cleanup := setupTestProcessor(t)
+	defer cleanup()
+
+	guid := "01749246-95f6-57db-b7c3-2ae0e8be671f"
+	lines := []string{
+		"n\tmqtt\tinvid\tunit_guid\tmsg_id\ttext\tcontext\tclass\tlevel\tarea\taddr\tblock\ttype\tbit\tinvert_bit",
+		"1\t\tG-044322\t" + guid + "\tmsg_1\tРазморозка, \"камера\"\t\twaiting\t100\tLOCAL\taddr\t\t\t\t",
+	}
+	filePath := createTestTSV(t, cfg.WatchPath, "formats.tsv", lines)
+	hash, err := ingest.HashFile(filePath)
+	require.NoError(t, err)
+	require.NoError(t, processor.ProcessFile(context.Background(),
+		watcher.FileInfo{Path: filePath, Name: "formats.tsv", Hash: hash}))
+
+	for _, format := range []string{ReportCSV, ReportXLSX, ReportJSON} {
+		group := uuid.New()
+		require.NoError(t, processor.GenerateReportForUnit(context.Background(), uuid.MustParse(guid),
+			ReportOptions{Format: format, Group: group}), format)
+
+		var reportType, path string
+		require.NoError(t, db.QueryRow(`SELECT report_type, file_path FROM reports WHERE report_group = ?`,
+			group.String()).Scan(&reportType, &path))
+		assert.Equal(t, format, reportType)
+		assert.True(t, strings.HasSuffix(path, "."+format), path)
+		content, err := os.ReadFile(path)
+		require.NoError(t, err)
+
+		switch format {
+		case ReportCSV:
+			assert.True(t, strings.HasPrefix(string(content), "line_number,unit_guid,mqtt,invid,msg_id,"))
+			assert.Contains(t, string(content), `"Разморозка, ""камера""",,waiting,100,LOCAL,addr,,,,`)
+		case ReportXLSX:
+			zr, err := zip.NewReader(bytes.NewReader(content), int64(len(content)))
+			require.NoError(t, err)
+			var sheet string
+			for _, f := range zr.File {
+				if f.Name == "xl/worksheets/sheet1.xml" {
+					rc, err := f.Open()
+					require.NoError(t, err)
+					data, _ := io.ReadAll(rc)
+					rc.Close()
+					sheet = string(data)
+				}
+			}
+			assert.Contains(t, sheet, `<c r="E2" t="inlineStr"><is><t xml:space="preserve">msg_1</t></is></c>`)
+			assert.Contains(t, sheet, `<c r="I2"><v>100</v></c>`)
+		case ReportJSON:
+			var report struct {
+				UnitGuid     string                   `json:"unit_guid"`
+				TotalRecords int                      `json:"total_records"`
+				Records      []map[string]interface{} `json:"records"`
+			}
+			require.NoError(t, json.Unmarshal(content, &report))
+			assert.Equal(t, guid, report.UnitGuid)
+			require.Len(t, report.Records, 1)
+			assert.Equal(t, "msg_1", report.Records[0]["msg_id"])
+			assert.Equal(t, float64(100), report.Records[0]["level"])
+			assert.Nil(t, report.Records[0]["block"])
+		}
+	}
+
+	// Пароль поддерживает только PDF, неизвестный формат - ошибка
+	err = processor.GenerateReportForUnit(context.Background(), uuid.MustParse(guid),
+		ReportOptions{Format: ReportCSV, Password: "secret"})
+	assert.ErrorContains(t, err, "only supported for PDF")
+	err = processor.GenerateReportForUnit(context.Background(), uuid.MustParse(guid), ReportOptions{Format: "docx"})
+	assert.ErrorContains(t, err, `unknown report format "docx"`)
+}
+
+func TestXLSXColumn(t *testing.T) {
+	assert.Equal(t, "A", xlsxColumn(0))
+	assert.Equal(t, "Z", xlsxColumn(25))
+	assert.Equal(t, "AA", xlsxColumn(26))
+	assert.Equal(t, "AZ", xlsxColumn(51))
+	assert.Equal(t, "BA", xlsxColumn(52))
+}
+
+func TestEnqueueUnitReport_TracksJob(t *testing.T) {
+	processor, db, cfg, cleanup := setupTestProcessor(t)
+	defer cleanup()
+	processor.StartReportWorkers(1, 4, time.Minute)
+
+	guid := "01749246-95f6-57db-b7c3-2ae0e8be671f"
+	lines := []string{"n\tmqtt\tinvid\tunit_guid\tmsg_id\ttext\tcontext\tclass\tlevel\tarea\taddr\tblock\ttype\tbit\tinvert_bit"}
+	for i, class := range []string{"alarm", "info", "alarm"} {
+		lines = append(lines, fmt.Sprintf("%d\t\tG-044322\t%s\tmsg_%d\tтекст\t\t%s\t100\tLOCAL\taddr\t\t\t\t", i+1, guid, i+1, class))
+	}
+	filePath := createTestTSV(t, cfg.WatchPath, "jobs.tsv", lines)
+	hash, err := ingest.HashFile(filePath)
+	require.NoError(t, err)
+	require.NoError(t, processor.ProcessFile(context.Background(),
+		watcher.FileInfo{Path: filePath, Name: "jobs.tsv", Hash: hash}))
+	processor.StopReportWorkers() // дожидаемся отчётов по файлу
+	processor.StartReportWorkers(1, 4, time.Minute)
+
+	jobID, err := processor.EnqueueUnitReport(uuid.MustParse(guid), ReportOptions{Class: "alarm"})
+	require.NoError(t, err)
+	processor.StopReportWorkers()
+
+	job, ok := processor.ReportJobStatus(jobID)
+	require.True(t, ok)
+	assert.Equal(t, ReportJobCompleted, job.Status)
+	assert.Equal(t, 1, job.Parts)
+	assert.Equal(t, int64(2), job.Records)
+
+	var count int
+	require.NoError(t, db.QueryRow(`SELECT COUNT(*) FROM reports WHERE report_group = ?`, jobID.String()).Scan(&count))
+	assert.Equal(t, 1, count)
+
+	// Фильтр, под который не попадает ни одна запись
+	processor.StartReportWorkers(1, 4, time.Minute)
+	jobID, err = processor.EnqueueUnitReport(uuid.MustParse(guid), ReportOptions{From: time.Now().Add(time.Hour)})
+	require.NoError(t, err)
+	processor.StopReportWorkers()
+	job, _ = processor.ReportJobStatus(jobID)
+	assert.Equal(t, ReportJobFailed, job.Status)
+	assert.Contains(t, job.Error, "no data found")
+}
+
+func TestUnitLocks_SerializesSameUnit(t *testing.T) {
+	locks := newUnitLocks()
+	unitA, unitB := uuid.New(), uuid.New()
+
+	release, err := locks.acquire(context.Background(), []uuid.UUID{unitA})
+	require.NoError(t, err)
+
+	// Другое устройство не ждёт
+	releaseB, err := locks.acquire(context.Background(), []uuid.UUID{unitB})
+	require.NoError(t, err)
+	releaseB()
+
+	// То же устройство ждёт освобождения
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	_, err = locks.acquire(ctx, []uuid.UUID{unitB, unitA})
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+
+	acquired := make(chan func())
+	go func() {
+		r, err := locks.acquire(context.Background(), []uuid.UUID{unitA, unitB})
+		if err == nil {
+			acquired <- r
+		}
+	}()
+	release()
+	select {
+	case r := <-acquired:
+		r()
+	case <-time.After(time.Second):
+		t.Fatal("lock was not handed over after release")
+	}
+	assert.Empty(t, locks.locks)
+}
+
+func TestProcessFile_ConflictPolicies(t *testing.T) {
+	processor, db, cfg, cleanup := setupTestProcessor(t)
+	defer cleanup()
+
+	guid := "01749246-95f6-57db-b7c3-2ae0e8be671f"
+	ingestFile := func(name, text, policy string) sqlc.File {
+		lines := []string{
+			"1\t\tG-044322\t" + guid + "\tmsg_a\t" + text + "\t\talarm\t100\tLOCAL\taddr\t\t\t\t",
+			"2\t\tG-044322\t" + guid + "\t\tno key\t\talarm\t100\tLOCAL\taddr\t\t\t\t",
+		}
+		filePath := createTestTSV(t, cfg.WatchPath, name, lines)
+		hash, _ := ingest.HashFile(filePath)
+		fileInfo := watcher.FileInfo{Path: filePath, Name: name, Hash: hash, ConflictPolicy: policy}
+		require.NoError(t, processor.ProcessFile(context.Background(), fileInfo))
+		file, err := sqlc.New(db).GetFileByFilename(context.Background(), name)
+		require.NoError(t, err)
+		return file
+	}
+	texts := func() []string {
+		rows, err := db.Query(`SELECT text || ':' || version FROM device_data WHERE msg_id = 'msg_a' ORDER BY id`)
+		require.NoError(t, err)
+		defer rows.Close()
+		var result []string
+		for rows.Next() {
+			var s string
+			require.NoError(t, rows.Scan(&s))
+			result = append(result, s)
+		}
+		return result
+	}
+
+	ingestFile("v1.tsv", "first", "")
+	assert.Equal(t, []string{"first:1"}, texts())
+
+	file := ingestFile("v2.tsv", "second", ConflictSkip)
+	assert.Equal(t, "completed", file.Status.String)
+	assert.Equal(t, int32(1), file.RowsSkipped.Int32)
+	assert.Equal(t, int32(1), file.RowsProcessed.Int32) // строка без msg_id
+	assert.Equal(t, []string{"first:1"}, texts())
+
+	file = ingestFile("v3.tsv", "third", ConflictVersion)
+	assert.Equal(t, int32(1), file.RowsVersioned.Int32)
+	assert.Equal(t, []string{"first:1", "third:2"}, texts())
+
+	processor.SetConflictPolicy(ConflictOverwrite)
+	file = ingestFile("v4.tsv", "fourth", "")
+	assert.Equal(t, "overwrite", file.ConflictPolicy.String)
+	assert.Equal(t, int32(1), file.RowsOverwritten.Int32)
+	assert.Equal(t, []string{"first:1", "fourth:2"}, texts())
+}
+
+func TestProcessFile_StoresFieldProfile(t *testing.T) {
+	processor, db, cfg, cleanup := setupTestProcessor(t)
+	defer cleanup()
+	processor.SetProfiling(true)
+
+	lines := []string{
+		"1\t\tG-044322\t01749246-95f6-57db-b7c3-2ae0e8be671f\tmsg_a\ttext\t\talarm\t100\tLOCAL\taddr\t\t\t\t",
+		"2\t\tG-044322\t01749246-95f6-57db-b7c3-2ae0e8be671f\tmsg_b\ttext\t\twarning\t5000\tLOCAL\taddr\t\t\t\t",
+	}
+	filePath := createTestTSV(t, cfg.WatchPath, "profiled.tsv", lines)
+	hash, _ := ingest.HashFile(filePath)
+	require.NoError(t, processor.ProcessFile(context.Background(), watcher.FileInfo{Path: filePath, Name: "profiled.tsv", Hash: hash}))
+
+	file, err := sqlc.New(db).GetFileByFilename(context.Background(), "profiled.tsv")
+	require.NoError(t, err)
+	var profile ingest.Profile
+	require.NoError(t, json.Unmarshal(file.Profile, &profile))
+	assert.Equal(t, int64(2), profile.Rows)
+	assert.Equal(t, int32(5000), profile.Level.Max)
+	assert.Equal(t, 2, profile.Columns["msg_id"].Distinct)
+	assert.Len(t, profile.TopClasses, 2)
+}
+
+func TestProcessFile_DuplicateReport(t *testing.T) {
+	processor, db, cfg, cleanup := setupTestProcessor(t)
+	defer cleanup()
+	queries := sqlc.New(db)
+
+	line := func(n int, msg string) string {
+		return fmt.Sprintf("%d\t\tG-044322\t01749246-95f6-57db-b7c3-2ae0e8be671f\t%s\ttext\t\talarm\t100\tLOCAL\taddr\t\t\t\t", n, msg)
+	}
+	ingestFile := func(name string, lines ...string) sqlc.File {
+		filePath := createTestTSV(t, cfg.WatchPath, name, lines)
+		hash, _ := ingest.HashFile(filePath)
+		require.NoError(t, processor.ProcessFile(context.Background(), watcher.FileInfo{Path: filePath, Name: name, Hash: hash}))
+		file, err := queries.GetFileByFilename(context.Background(), name)
+		require.NoError(t, err)
+		return file
+	}
+
+	// Без duplicate_report счётчики не заполняются
+	first := ingestFile("first.tsv", line(1, "msg_a"), line(2, "msg_b"))
+	assert.False(t, first.RowsDuplicateExisting.Valid)
+
+	processor.SetDuplicateReport(true)
+	// Строка 1 уже загружена first.tsv, строка 3 повторяет строку 2,
+	// строка 4 отличается от загруженной текстом
+	second := ingestFile("second.tsv", line(1, "msg_a"), line(2, "msg_c"), line(3, "msg_c"),
+		strings.Replace(line(4, "msg_b"), "\ttext\t", "\tother\t", 1))
+	assert.Equal(t, "completed", second.Status.String)
+	assert.Equal(t, int32(4), second.RowsProcessed.Int32) // дубликаты вставляются как обычно
+	assert.Equal(t, int32(1), second.RowsDuplicateExisting.Int32)
+	assert.Equal(t, int32(1), second.RowsDuplicateInFile.Int32)
+
+	duplicates, err := queries.ListFileDuplicatesByFilePaged(context.Background(), sqlc.ListFileDuplicatesByFilePagedParams{
+		FileID: second.ID, Limit: 10,
+	})
+	require.NoError(t, err)
+	require.Len(t, duplicates, 2)
+	assert.Equal(t, DuplicateExisting, duplicates[0].Kind)
+	assert.Equal(t, int32(1), duplicates[0].LineNumber)
+	assert.Equal(t, first.ID, duplicates[0].DuplicateOfFileID)
+	assert.Equal(t, int32(1), duplicates[0].DuplicateOfLine)
+	assert.Equal(t, DuplicateInFile, duplicates[1].Kind)
+	assert.Equal(t, int32(3), duplicates[1].LineNumber)
+	assert.Equal(t, second.ID, duplicates[1].DuplicateOfFileID)
+	assert.Equal(t, int32(2), duplicates[1].DuplicateOfLine)
+
+	count, err := queries.CountFileDuplicatesByFile(context.Background(), sqlc.CountFileDuplicatesByFileParams{
+		FileID: second.ID, Kind: DuplicateInFile,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), count)
+}
+
+func TestProcessFile_DuplicateReportAcrossChunks(t *testing.T) {
+	processor, db, cfg, cleanup := setupTestProcessor(t)
+	defer cleanup()
+	processor.SetDuplicateReport(true)
+	processor.SetStreaming(1, 2)
+	queries := sqlc.New(db)
+
+	line := func(n int, msg string) string {
+		return fmt.Sprintf("%d\t\tG-044322\t01749246-95f6-57db-b7c3-2ae0e8be671f\t%s\ttext\t\talarm\t100\tLOCAL\taddr\t\t\t\t", n, msg)
+	}
+	// Строка 5 повторяет строку 1 из первой части файла, строка 4 - строку 3 из той же части
+	filePath := createTestTSV(t, cfg.WatchPath, "chunks.tsv", []string{
+		line(1, "msg_a"), line(2, "msg_b"), line(3, "msg_c"), line(4, "msg_c"), line(1, "msg_a"),
+	})
+	hash, _ := ingest.HashFile(filePath)
+	require.NoError(t, processor.ProcessFile(context.Background(), watcher.FileInfo{Path: filePath, Name: "chunks.tsv", Hash: hash}))
+
+	file, err := queries.GetFileByFilename(context.Background(), "chunks.tsv")
+	require.NoError(t, err)
+	assert.Equal(t, int32(2), file.RowsDuplicateInFile.Int32)
+
+	duplicates, err := queries.ListFileDuplicatesByFilePaged(context.Background(), sqlc.ListFileDuplicatesByFilePagedParams{
+		FileID: file.ID, Limit: 10,
+	})
+	require.NoError(t, err)
+	require.Len(t, duplicates, 2)
+	assert.Equal(t, int32(4), duplicates[0].LineNumber)
+	assert.Equal(t, int32(3), duplicates[0].DuplicateOfLine)
+	assert.Equal(t, int32(5), duplicates[1].LineNumber)
+	assert.Equal(t, int32(1), duplicates[1].DuplicateOfLine)
+}
+
+func TestProcessFile_DeltaSequence(t *testing.T) {
+	processor, db, cfg, cleanup := setupTestProcessor(t)
+	defer cleanup()
+	processor.SetDeltaSources([]string{"tenant:plc"})
+	queries := sqlc.New(db)
+
+	line := func(n int) string {
+		return fmt.Sprintf("%d\t\tG-044322\t01749246-95f6-57db-b7c3-2ae0e8be671f\tmsg_%d\ttext\t\talarm\t100\tLOCAL\taddr\t\t\t\t", n, n)
+	}
+	ingestFile := func(name, source string, lines ...string) sqlc.File {
+		filePath := createTestTSV(t, cfg.WatchPath, name, lines)
+		hash, _ := ingest.HashFile(filePath)
+		require.NoError(t, processor.ProcessFile(context.Background(), watcher.FileInfo{Path: filePath, Name: name, Hash: hash, Source: source}))
+		file, err := queries.GetFileByFilename(context.Background(), name)
+		require.NoError(t, err)
+		return file
+	}
+
+	first := ingestFile("plc_1.tsv", "tenant:plc", line(1), line(2), line(3))
+	assert.Equal(t, int64(1), first.SeqFirst.Int64)
+	assert.Equal(t, int64(3), first.SeqLast.Int64)
+	assert.Equal(t, int32(0), first.SeqGaps.Int32)
+
+	// Строки 4-5 не выгружены, строка 7 потеряна внутри файла;
+	// строка с ошибкой разбора тоже занимает свой номер
+	second := ingestFile("plc_2.tsv", "tenant:plc", line(6), "8\t\tG-044322\tnot-a-guid", line(9))
+	assert.Equal(t, "completed", second.Status.String)
+	assert.Equal(t, int32(2), second.RowsProcessed.Int32) // строки загружаются, пропуски - ошибки
+	assert.Equal(t, int64(6), second.SeqFirst.Int64)
+	assert.Equal(t, int64(9), second.SeqLast.Int64)
+	assert.Equal(t, int32(3), second.SeqGaps.Int32)
+
+	errs, err := queries.ListProcessingErrorsByFile(context.Background(), second.ID)
+	require.NoError(t, err)
+	var gaps []string
+	for _, e := range errs {
+		if e.FieldName.String == "n" {
+			gaps = append(gaps, e.ErrorMessage)
+		}
+	}
+	assert.Equal(t, []string{
+		"sequence gap: n=6 follows n=3 (2 rows missing)",
+		"sequence gap: n=8 follows n=6 (1 rows missing)",
+	}, gaps)
+
+	// Источник не в режиме delta - диапазон не записывается
+	other := ingestFile("other.tsv", "directory", line(1), line(5))
+	assert.False(t, other.SeqLast.Valid)
+}
+
+func TestProcessFile_RecordsUnitSequenceRuns(t *testing.T) {
+	processor, db, cfg, cleanup := setupTestProcessor(t)
+	defer cleanup()
+	processor.SetSequenceRuns(true)
+	queries := sqlc.New(db)
+
+	unitA := uuid.MustParse("01749246-95f6-57db-b7c3-2ae0e8be671f")
+	unitB := uuid.MustParse("11749246-95f6-57db-b7c3-2ae0e8be671f")
+	line := func(n int, unit uuid.UUID) string {
+		return fmt.Sprintf("%d\t\tG-044322\t%s\tmsg_%d\ttext\t\talarm\t100\tLOCAL\taddr\t\t\t\t", n, unit, n)
+	}
+	// Устройства чередуются; у A пропущен n=3, n=5 - строка с ошибкой разбора
+	filePath := createTestTSV(t, cfg.WatchPath, "runs.tsv", []string{
+		line(1, unitA), line(1, unitB), line(2, unitA), line(2, unitB),
+		line(4, unitA), "5\t\tG-044322\t" + unitA.String() + "\tmsg_5\ttext\t\talarm\tnot-a-level", line(6, unitA),
+	})
+	hash, _ := ingest.HashFile(filePath)
+	require.NoError(t, processor.ProcessFile(context.Background(), watcher.FileInfo{Path: filePath, Name: "runs.tsv", Hash: hash}))
+
+	since := time.Now().Add(-time.Hour)
+	runsA, err := queries.ListUnitSequenceRuns(context.Background(), sqlc.ListUnitSequenceRunsParams{UnitGuid: unitA, CreatedAt: since})
+	require.NoError(t, err)
+	require.Len(t, runsA, 2)
+	assert.Equal(t, "runs.tsv", runsA[0].Filename)
+	assert.Equal(t, [3]int64{1, 2, 2}, [3]int64{runsA[0].SeqFirst, runsA[0].SeqLast, int64(runsA[0].Rows)})
+	assert.Equal(t, [3]int64{4, 6, 3}, [3]int64{runsA[1].SeqFirst, runsA[1].SeqLast, int64(runsA[1].Rows)})
+
+	runsB, err := queries.ListUnitSequenceRuns(context.Background(), sqlc.ListUnitSequenceRunsParams{UnitGuid: unitB, CreatedAt: since})
+	require.NoError(t, err)
+	require.Len(t, runsB, 1)
+	assert.Equal(t, int64(2), runsB[0].SeqLast)
+}
+
+func TestProcessFile_FilenameMetadata(t *testing.T) {
+	processor, db, cfg, cleanup := setupTestProcessor(t)
+	defer cleanup()
+	pattern, err := filemeta.Compile(`^(?P<site>[A-Z0-9]+)_(?P<date>\d{8})_(?P<type>\w+)\.tsv$`)
+	require.NoError(t, err)
+	processor.SetFilenameMetadata(pattern, "{site}/{date}", "{site}_{type}_{unit_guid}")
+
+	unit := "01749246-95f6-57db-b7c3-2ae0e8be671f"
+	for i, name := range []string{"SITE42_20240615_full.tsv", "other.tsv"} {
+		line := fmt.Sprintf("%d\t\tG-044322\t%s\tmsg_1\ttext\t\talarm\t100\tLOCAL\taddr\t\t\t\t", i+1, unit)
+		filePath := createTestTSV(t, cfg.WatchPath, name, []string{line})
+		hash, _ := ingest.HashFile(filePath)
+		require.NoError(t, processor.ProcessFile(context.Background(), watcher.FileInfo{Path: filePath, Name: name, Hash: hash}))
+	}
+
+	var meta []byte
+	require.NoError(t, db.QueryRow(`SELECT metadata FROM files WHERE filename = ?`, "SITE42_20240615_full.tsv").Scan(&meta))
+	assert.JSONEq(t, `{"site":"SITE42","date":"20240615","type":"full"}`, string(meta))
+	require.NoError(t, db.QueryRow(`SELECT metadata FROM files WHERE filename = ?`, "other.tsv").Scan(&meta))
+	assert.JSONEq(t, `{}`, string(meta))
+
+	// Архив по полям имени; файл без совпадения - в корень архива
+	_, err = os.Stat(filepath.Join(cfg.ArchivePath, "SITE42", "20240615", "SITE42_20240615_full.tsv"))
+	assert.NoError(t, err)
+	_, err = os.Stat(filepath.Join(cfg.ArchivePath, "other.tsv"))
+	assert.NoError(t, err)
+
+	rows, err := db.Query(`SELECT file_path FROM reports ORDER BY id`)
+	require.NoError(t, err)
+	defer rows.Close()
+	var names []string
+	for rows.Next() {
+		var path string
+		require.NoError(t, rows.Scan(&path))
+		names = append(names, filepath.Base(path))
+	}
+	require.Len(t, names, 2)
+	assert.Equal(t, "SITE42_full_"+unit+".pdf", names[0])
+	assert.True(t, strings.HasPrefix(names[1], unit+"_"), names[1])
+}
+
+func TestProcessFile_SidecarOptions(t *testing.T) {
+	processor, db, cfg, cleanup := setupTestProcessor(t)
+	defer cleanup()
+
+	filePath := createTestTSV(t, cfg.WatchPath, "special.tsv", []string{
+		"1,,G-044322,01749246-95f6-57db-b7c3-2ae0e8be671f,msg_1,text,,bogus,100,LOCAL",
+	})
+	createTestTSV(t, cfg.WatchPath, "special.meta.json", []string{`{"parser":"csv","lenient":true}`})
+	hash, _ := ingest.HashFile(filePath)
+	require.NoError(t, processor.ProcessFile(context.Background(), watcher.FileInfo{
+		Path:    filePath,
+		Name:    "special.tsv",
+		Hash:    hash,
+		Options: ingest.Options{Parser: ingest.ParserCSV, Lenient: true},
+	}))
+
+	var processed, failed int
+	require.NoError(t, db.QueryRow(`SELECT rows_processed, rows_failed FROM files WHERE filename = ?`, "special.tsv").Scan(&processed, &failed))
+	assert.Equal(t, 1, processed)
+	assert.Equal(t, 0, failed)
+
+	// Sidecar перемещается в архив вместе с файлом
+	_, err := os.Stat(filepath.Join(cfg.ArchivePath, "special.meta.json"))
+	assert.NoError(t, err)
+	_, err = os.Stat(filepath.Join(cfg.WatchPath, "special.meta.json"))
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestProcessFile_BatchInsert(t *testing.T) {
+	processor, db, cfg, cleanup := setupTestProcessor(t)
+	defer cleanup()
+	processor.SetBatchSize(100)
+
+	// Строка msg_bad отклоняется БД: пачка с ней вставляется по одной
+	_, err := db.Exec(`CREATE TRIGGER reject_bad BEFORE INSERT ON device_data
+		WHEN NEW.msg_id = 'msg_bad' BEGIN SELECT RAISE(ABORT, 'rejected by trigger'); END`)
+	require.NoError(t, err)
+
+	lines := []string{"n\tmqtt\tinvid\tunit_guid\tmsg_id\ttext\tcontext\tclass\tlevel\tarea\taddr\tblock\ttype\tbit\tinvert_bit"}
+	for i := 1; i <= 250; i++ {
+		msgID := fmt.Sprintf("msg_%d", i)
+		if i == 150 {
+			msgID = "msg_bad"
+		}
+		lines = append(lines, fmt.Sprintf("%d\t\tG-044322\t01749246-95f6-57db-b7c3-2ae0e8be671f\t%s\ttext\t\talarm\t100\tLOCAL\taddr\t\t\t\t", i, msgID))
+	}
+	filePath := createTestTSV(t, cfg.WatchPath, "batched.tsv", lines)
+	hash, _ := ingest.HashFile(filePath)
+	require.NoError(t, processor.ProcessFile(context.Background(), watcher.FileInfo{Path: filePath, Name: "batched.tsv", Hash: hash}))
+
+	var fileID int64
+	var status string
+	var processed, failed int
+	require.NoError(t, db.QueryRow(`SELECT id, status, rows_processed, rows_failed FROM files WHERE filename = ?`, "batched.tsv").
+		Scan(&fileID, &status, &processed, &failed))
+	assert.Equal(t, "partial", status)
+	assert.Equal(t, 249, processed)
+	assert.Equal(t, 1, failed)
+
+	var count, lineSum int
+	require.NoError(t, db.QueryRow(`SELECT COUNT(*), SUM(line_number) FROM device_data WHERE file_id = ?`, fileID).Scan(&count, &lineSum))
+	assert.Equal(t, 249, count)
+	assert.Equal(t, 251*252/2-1-151, lineSum) // строки 2..251 без заголовка и отклонённой
+}
+
+// pgAbortDB - транзакция файла с поведением PostgreSQL поверх SQLite:
+// вставка строки msg_bad отклоняется, после чего все запросы завершаются
+// ошибкой до ROLLBACK TO SAVEPOINT
+type pgAbortDB struct {
+	sqlc.DBTX
+	aborted *bool
+}
+
+var errTxAborted = errors.New("current transaction is aborted, commands ignored until end of transaction block")
+
+func (d pgAbortDB) check(query string, args []interface{}) error {
+	if strings.HasPrefix(query, "ROLLBACK TO SAVEPOINT") {
+		return nil
+	}
+	if *d.aborted {
+		return errTxAborted
+	}
+	if strings.Contains(query, "INSERT INTO device_data") &&
+		slices.Contains(args, interface{}(sql.NullString{String: "msg_bad", Valid: true})) {
+		*d.aborted = true
+		return errors.New("rejected row")
+	}
+	return nil
+}
+
+func (d pgAbortDB) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	if err := d.check(query, args); err != nil {
+		return nil, err
+	}
+	res, err := d.DBTX.ExecContext(ctx, query, args...)
+	if err == nil && strings.HasPrefix(query, "ROLLBACK TO SAVEPOINT") {
+		*d.aborted = false
+	}
+	return res, err
+}
+
+func (d pgAbortDB) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	if err := d.check(query, args); err != nil {
+		return nil, err
+	}
+	return d.DBTX.QueryContext(ctx, query, args...)
+}
+
+func (d pgAbortDB) QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row {
+	if err := d.check(query, args); err != nil {
+		// *sql.Row с ошибкой: запрос к несуществующей таблице
+		return d.DBTX.QueryRowContext(ctx, `SELECT 1 FROM "transaction aborted"`)
+	}
+	return d.DBTX.QueryRowContext(ctx, query, args...)
+}
+
+func TestProcessFile_RejectedRowKeepsTransaction(t *testing.T) {
+	for _, batchSize := range []int{1, 100} {
+		t.Run(fmt.Sprintf("batch_%d", batchSize), func(t *testing.T) {
+			processor, db, cfg, cleanup := setupTestProcessor(t)
+			defer cleanup()
+			processor.SetBatchSize(batchSize)
+			aborted := false
+			processor.SetTxWrapper(func(tx sqlc.DBTX) sqlc.DBTX { return pgAbortDB{DBTX: tx, aborted: &aborted} })
+
+			lines := []string{"n\tmqtt\tinvid\tunit_guid\tmsg_id\ttext\tcontext\tclass\tlevel\tarea\taddr\tblock\ttype\tbit\tinvert_bit"}
+			for i := 1; i <= 250; i++ {
+				msgID := fmt.Sprintf("msg_%d", i)
+				if i == 150 {
+					msgID = "msg_bad"
+				}
+				lines = append(lines, fmt.Sprintf("%d\t\tG-044322\t01749246-95f6-57db-b7c3-2ae0e8be671f\t%s\ttext\t\talarm\t100\tLOCAL\taddr\t\t\t\t", i, msgID))
+			}
+			filePath := createTestTSV(t, cfg.WatchPath, "aborted.tsv", lines)
+			hash, _ := ingest.HashFile(filePath)
+			require.NoError(t, processor.ProcessFile(context.Background(), watcher.FileInfo{Path: filePath, Name: "aborted.tsv", Hash: hash}))
+			assert.False(t, aborted, "transaction must be usable after a rejected row")
+
+			var status string
+			var processed, failed int
+			require.NoError(t, db.QueryRow(`SELECT status, rows_processed, rows_failed FROM files WHERE filename = ?`, "aborted.tsv").
+				Scan(&status, &processed, &failed))
+			assert.Equal(t, "partial", status)
+			assert.Equal(t, 249, processed)
+			assert.Equal(t, 1, failed)
+
+			var count int
+			require.NoError(t, db.QueryRow(`SELECT COUNT(*) FROM device_data`).Scan(&count))
+			assert.Equal(t, 249, count)
+		})
+	}
+}
+
+// statementCountDB - транзакция файла, считающая INSERT в device_data
+// и точки сохранения
+type statementCountDB struct {
+	sqlc.DBTX
+	inserts, savepoints *int
+}
+
+func (d statementCountDB) count(query string) {
+	switch {
+	case strings.HasPrefix(query, "SAVEPOINT "):
+		*d.savepoints++
+	case strings.Contains(query, "INSERT INTO device_data"):
+		*d.inserts++
+	}
+}
+
+func (d statementCountDB) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	d.count(query)
+	return d.DBTX.ExecContext(ctx, query, args...)
+}
+
+func (d statementCountDB) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	d.count(query)
+	return d.DBTX.QueryContext(ctx, query, args...)
+}
+
+func (d statementCountDB) QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row {
+	d.count(query)
+	return d.DBTX.QueryRowContext(ctx, query, args...)
+}
+
+func TestProcessFile_RejectedRowBisectsBatch(t *testing.T) {
+	processor, db, cfg, cleanup := setupTestProcessor(t)
+	defer cleanup()
+	processor.SetBatchSize(100)
+	aborted := false
+	var inserts, savepoints int
+	processor.SetTxWrapper(func(tx sqlc.DBTX) sqlc.DBTX {
+		return statementCountDB{DBTX: pgAbortDB{DBTX: tx, aborted: &aborted}, inserts: &inserts, savepoints: &savepoints}
+	})
+
+	lines := []string{"n\tmqtt\tinvid\tunit_guid\tmsg_id\ttext\tcontext\tclass\tlevel\tarea\taddr\tblock\ttype\tbit\tinvert_bit"}
+	for i := 1; i <= 250; i++ {
+		msgID := fmt.Sprintf("msg_%d", i)
+		if i == 150 {
+			msgID = "msg_bad"
+		}
+		lines = append(lines, fmt.Sprintf("%d\t\tG-044322\t01749246-95f6-57db-b7c3-2ae0e8be671f\t%s\ttext\t\talarm\t100\tLOCAL\taddr\t\t\t\t", i, msgID))
+	}
+	filePath := createTestTSV(t, cfg.WatchPath, "bisect.tsv", lines)
+	hash, _ := ingest.HashFile(filePath)
+	require.NoError(t, processor.ProcessFile(context.Background(), watcher.FileInfo{Path: filePath, Name: "bisect.tsv", Hash: hash}))
+
+	// Пачки по 100, 100 и 50 строк; вторая с некорректной строкой делится
+	// пополам 7 раз (⌈log2 100⌉) вместо вставки 100 строк по одной
+	assert.LessOrEqual(t, inserts, 3+2*7)
+	assert.Equal(t, inserts, savepoints, "every INSERT runs in its own savepoint")
+
+	var processed, failed int
+	require.NoError(t, db.QueryRow(`SELECT rows_processed, rows_failed FROM files WHERE filename = ?`, "bisect.tsv").
+		Scan(&processed, &failed))
+	assert.Equal(t, 249, processed)
+	assert.Equal(t, 1, failed)
+
+	var count, lineSum int
+	require.NoError(t, db.QueryRow(`SELECT COUNT(*), SUM(line_number) FROM device_data`).Scan(&count, &lineSum))
+	assert.Equal(t, 249, count)
+	assert.Equal(t, 251*252/2-1-151, lineSum) // строки 2..251 без заголовка и отклонённой
+}
+
+func TestProcessFile_StreamingPartialFailureCounters(t *testing.T) {
+	processor, db, cfg, cleanup := setupTestProcessor(t)
+	defer cleanup()
+	processor.SetStreaming(1, 20)
+	aborted := false
+	processor.SetTxWrapper(func(tx sqlc.DBTX) sqlc.DBTX { return pgAbortDB{DBTX: tx, aborted: &aborted} })
+
+	// Отклонённые базой строки в трёх разных частях файла и строка с
+	// ошибкой разбора: итоги файла - сумма по всем частям, не по последней
+	var lines []string
+	for i := 1; i <= 95; i++ {
+		unitGuid, msgID := "01749246-95f6-57db-b7c3-2ae0e8be671f", fmt.Sprintf("msg_%d", i)
+		switch i {
+		case 5, 45, 85:
+			msgID = "msg_bad"
+		case 60:
+			unitGuid = "not-a-guid"
+		}
+		lines = append(lines, fmt.Sprintf("%d\t\tG-044322\t%s\t%s\ttext\t\talarm\t100\tLOCAL\taddr\t\t\t\t", i, unitGuid, msgID))
+	}
+	filePath := createTestTSV(t, cfg.WatchPath, "partial.tsv", lines)
+	info, err := os.Stat(filePath)
+	require.NoError(t, err)
+	hash, _ := ingest.HashFile(filePath)
+	require.NoError(t, processor.ProcessFile(context.Background(),
+		watcher.FileInfo{Path: filePath, Name: "partial.tsv", Hash: hash, Size: info.Size()}))
+
+	var fileID int64
+	var status string
+	var processed, failed int
+	require.NoError(t, db.QueryRow(`SELECT id, status, rows_processed, rows_failed FROM files WHERE filename = ?`, "partial.tsv").
+		Scan(&fileID, &status, &processed, &failed))
+	assert.Equal(t, "partial", status)
+	assert.Equal(t, 91, processed)
+	assert.Equal(t, 3, failed)
+
+	var count int
+	require.NoError(t, db.QueryRow(`SELECT COUNT(*) FROM device_data WHERE file_id = ?`, fileID).Scan(&count))
+	assert.Equal(t, processed, count)
+}
+
+func TestProcessFile_Streaming(t *testing.T) {
+	processor, db, cfg, cleanup := setupTestProcessor(t)
+	defer cleanup()
+	processor.SetBatchSize(7)
+	processor.SetStreaming(1, 20)
+	processor.SetSerializePerUnit(true)
+
+	lines := []string{"n\tmqtt\tinvid\tunit_guid\tmsg_id\ttext\tcontext\tclass\tlevel\tarea\taddr\tblock\ttype\tbit\tinvert_bit"}
+	for i := 1; i <= 95; i++ {
+		unitGuid := "01749246-95f6-57db-b7c3-2ae0e8be671f"
+		if i%30 == 0 {
+			unitGuid = "not-a-guid"
+		}
+		lines = append(lines, fmt.Sprintf("%d\t\tG-044322\t%s\tmsg_%d\ttext\t\talarm\t100\tLOCAL\taddr\t\t\t\t", i, unitGuid, i))
+	}
+	filePath := createTestTSV(t, cfg.WatchPath, "streamed.tsv", lines)
+	info, err := os.Stat(filePath)
+	require.NoError(t, err)
+	hash, _ := ingest.HashFile(filePath)
+
+	hook := &recordingHook{}
+	processor.RegisterHook(hook)
+	require.NoError(t, processor.ProcessFile(context.Background(),
+		watcher.FileInfo{Path: filePath, Name: "streamed.tsv", Hash: hash, Size: info.Size()}))
+
+	var fileID int64
+	var status string
+	var processed, failed int
+	require.NoError(t, db.QueryRow(`SELECT id, status, rows_processed, rows_failed FROM files WHERE filename = ?`, "streamed.tsv").
+		Scan(&fileID, &status, &processed, &failed))
+	assert.Equal(t, "completed", status)
+	assert.Equal(t, 92, processed)
+	assert.Equal(t, 0, failed)
+
+	var count, lineSum int
+	require.NoError(t, db.QueryRow(`SELECT COUNT(*), SUM(line_number) FROM device_data WHERE file_id = ?`, fileID).Scan(&count, &lineSum))
+	assert.Equal(t, 92, count)
+	assert.Equal(t, 96*97/2-1-31-61-91, lineSum) // строки 2..96 без заголовка и отклонённых
+
+	var parseErrors int
+	require.NoError(t, db.QueryRow(`SELECT COUNT(*) FROM processing_errors WHERE file_id = ?`, fileID).Scan(&parseErrors))
+	assert.Equal(t, 3, parseErrors)
+	require.Len(t, hook.results, 1)
+	assert.Equal(t, int32(3), hook.results[0].ParseErrors)
+	assert.Equal(t, map[string]int32{"alarm": 92}, hook.results[0].ClassCounts)
+	assert.Len(t, hook.results[0].UnitGuids, 1)
+}
+
+// heapSampler - транзакция файла, которая каждые every запросов снимает
+// объём живой кучи
+type heapSampler struct {
+	sqlc.DBTX
+	every   int
+	calls   *int
+	samples *[]uint64
+}
+
+func (h heapSampler) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	if *h.calls++; *h.calls%h.every == 0 {
+		runtime.GC()
+		var stats runtime.MemStats
+		runtime.ReadMemStats(&stats)
+		*h.samples = append(*h.samples, stats.HeapAlloc)
+	}
+	return h.DBTX.ExecContext(ctx, query, args...)
+}
+
+func TestProcessFile_StreamingMemoryStaysFlat(t *testing.T) {
+	processor, db, cfg, cleanup := setupTestProcessor(t)
+	defer cleanup()
+	cfg.RejectedFile = true
+	processor.SetStreaming(1, 500)
+	processor.SetDeltaSources([]string{"tenant:plc"})
+	processor.SetSequenceRuns(true)
+	processor.SetReportMemoryBudget(64 << 10)
+	processor.SetProgressInterval(1<<30, time.Hour)
+
+	var calls int
+	var samples []uint64
+	processor.SetTxWrapper(func(tx sqlc.DBTX) sqlc.DBTX {
+		return heapSampler{DBTX: tx, every: 2000, calls: &calls, samples: &samples}
+	})
+
+	// Каждая пятая строка отклоняется при разборе, каждые 7 номеров n - пропуск:
+	// ошибки строк, ошибки последовательности и отрезки n растут с размером файла
+	const rows = 40000
+	var buf bytes.Buffer
+	for i := 1; i <= rows; i++ {
+		unitGuid := "01749246-95f6-57db-b7c3-2ae0e8be671f"
+		if i%5 == 0 {
+			unitGuid = "not-a-guid"
+		}
+		fmt.Fprintf(&buf, "%d\t\tG-044322\t%s\tmsg_%d\ttext\t\talarm\t100\tLOCAL\taddr\t\t\t\t\n", i+i/7, unitGuid, i)
+	}
+	filePath := filepath.Join(cfg.WatchPath, "large.tsv")
+	require.NoError(t, os.WriteFile(filePath, buf.Bytes(), 0644))
+	buf = bytes.Buffer{}
+	hash, _ := ingest.HashFile(filePath)
+	info, err := os.Stat(filePath)
+	require.NoError(t, err)
+
+	require.NoError(t, processor.ProcessFile(context.Background(), watcher.FileInfo{
+		Path: filePath, Name: "large.tsv", Hash: hash, Size: info.Size(), Source: "tenant:plc",
+	}))
+
+	var status string
+	var processed, failed int
+	require.NoError(t, db.QueryRow(`SELECT status, rows_processed, rows_failed FROM files WHERE filename = ?`, "large.tsv").
+		Scan(&status, &processed, &failed))
+	assert.Equal(t, "completed", status)
+	assert.Equal(t, rows-rows/5, processed)
+	rejected, err := os.ReadFile(filepath.Join(cfg.ErrorPath, "large.rejected.tsv"))
+	require.NoError(t, err)
+	assert.Equal(t, rows/5+1, bytes.Count(rejected, []byte("\n"))) // маркер и ошибочные строки
+
+	// После первых частей куча не растёт вместе с числом обработанных строк
+	require.Greater(t, len(samples), 10)
+	base := samples[2]
+	for i, sample := range samples[2:] {
+		assert.Less(t, int64(sample)-int64(base), int64(2<<20), "sample %d: heap grew from %d to %d bytes", i+2, base, sample)
+	}
+}
+
+func TestGroupByUnit_SpillsOverBudget(t *testing.T) {
+	processor, _, cfg, cleanup := setupTestProcessor(t)
+	defer cleanup()
+	processor.SetReportMemoryBudget(3 * rowOverhead)
+
+	unitA, unitB := uuid.New(), uuid.New()
+	var rows []TSVRow
+	for i := int32(1); i <= 10; i++ {
+		unit := unitA
+		if i%3 == 0 {
+			unit = unitB
+		}
+		rows = append(rows, TSVRow{
+			Seq:        int64(i),
+			UnitGuid:   unit,
+			MsgID:      sql.NullString{String: fmt.Sprintf("msg_%d", i), Valid: true},
+			LineNumber: i,
+		})
+	}
+
+	groups := processor.groupByUnit(rows)
+	require.NotEmpty(t, groups.dir)
+	assert.Equal(t, []uuid.UUID{unitA, unitB}, groups.units)
+	assert.LessOrEqual(t, groups.memSize, int64(3*rowOverhead))
+
+	// Строки устройства - по порядку файла, из файла и из памяти
+	dataA, err := groups.rows(unitA)
+	require.NoError(t, err)
+	require.Len(t, dataA, 7)
+	for i := 1; i < len(dataA); i++ {
+		assert.Less(t, dataA[i-1].LineNumber, dataA[i].LineNumber)
+	}
+	assert.Equal(t, "msg_1", dataA[0].MsgID.String)
+	dataB, err := groups.rows(unitB)
+	require.NoError(t, err)
+	assert.Len(t, dataB, 3)
+
+	groups.remove()
+	entries, err := os.ReadDir(cfg.TempPath)
+	require.NoError(t, err)
+	assert.Empty(t, entries)
+
+	// Без бюджета строки остаются в памяти
+	processor.SetReportMemoryBudget(0)
+	groups = processor.groupByUnit(rows)
+	assert.Empty(t, groups.dir)
+	assert.Len(t, groups.mem[unitA], 7)
+}
+
+func TestProcessFile_UpdatesDailySummary(t *testing.T) {
+	processor, db, cfg, cleanup := setupTestProcessor(t)
+	defer cleanup()
+
+	guid := uuid.MustParse("01749246-95f6-57db-b7c3-2ae0e8be671f")
+	ingestFile := func(name string, lines []string) {
+		filePath := createTestTSV(t, cfg.WatchPath, name, lines)
+		hash, _ := ingest.HashFile(filePath)
+		fileInfo := watcher.FileInfo{Path: filePath, Name: name, Hash: hash}
+		require.NoError(t, processor.ProcessFile(context.Background(), fileInfo))
+	}
+	ingestFile("day1.tsv", []string{
+		"1\t\tG-044322\t" + guid.String() + "\tmsg_a\ttext\t\talarm\t100\tLOCAL\taddr\t\t\t\t",
+		"2\t\tG-044322\t" + guid.String() + "\tmsg_b\ttext\t\tworking\t50\tLOCAL\taddr\t\t\t\t",
+	})
+	ingestFile("day2.tsv", []string{
+		"1\t\tG-044322\t" + guid.String() + "\tmsg_c\ttext\t\talarm\t200\tLOCAL\taddr\t\t\t\t",
+	})
+
+	today := time.Now().UTC().Truncate(24 * time.Hour)
+	days, err := sqlc.New(db).ListUnitDailySummary(context.Background(), sqlc.ListUnitDailySummaryParams{
+		UnitGuid: guid,
+		Day:      today.AddDate(0, 0, -1),
+		Day_2:    today.AddDate(0, 0, 1),
+	})
+	require.NoError(t, err)
+	require.Len(t, days, 1)
+	assert.Equal(t, int32(3), days[0].Records)
+	assert.Equal(t, int32(2), days[0].Alarms)
+	assert.Equal(t, int32(200), days[0].MaxLevel.Int32)
+}
+
+// ---------- Clock and filesystem ----------
+func setupMemProcessor(t *testing.T) (*Processor, *clock.Fake, *fsys.Mem) {
+	fake := clock.NewFake(time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC))
+	mem := fsys.NewMem(fake.Now)
+	p := NewProcessor(nil, nil, &config.DirectoryConfig{ArchivePath: "/archive", ErrorPath: "/errors"})
+	p.SetClock(fake)
+	p.SetFS(mem)
+	return p, fake, mem
+}
+
+func TestWaitForFileReady_WaitsUntilSizeIsStable(t *testing.T) {
+	p, fake, mem := setupMemProcessor(t)
+	mem.WriteFile("/watch/slow.tsv", []byte("n\tmqtt\n"))
+	start := fake.Now()
+
+	done := make(chan error, 1)
+	go func() { done <- p.waitForFileReady("/watch/slow.tsv", 10*time.Second) }()
+
+	// Файл дописывается между двумя проверками размера
+	for i := 0; i < 2; i++ {
+		fake.BlockUntil(1)
+		mem.Append("/watch/slow.tsv", []byte("1\tmqtt\n"))
+		fake.Advance(500 * time.Millisecond)
+	}
+	fake.BlockUntil(1)
+	fake.Advance(500 * time.Millisecond)
+
+	require.NoError(t, <-done)
+	assert.Equal(t, 1500*time.Millisecond, fake.Since(start))
+}
+
+func TestWaitForFileReady_TimesOut(t *testing.T) {
+	p, fake, mem := setupMemProcessor(t)
+	mem.WriteFile("/watch/endless.tsv", []byte("n\tmqtt\n"))
+
+	done := make(chan error, 1)
+	go func() { done <- p.waitForFileReady("/watch/endless.tsv", 2*time.Second) }()
+
+	for i := 0; i < 4; i++ {
+		fake.BlockUntil(1)
+		mem.Append("/watch/endless.tsv", []byte("1\tmqtt\n"))
+		fake.Advance(500 * time.Millisecond)
+	}
+
+	err := <-done
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "not stable")
+}
+
+func TestWaitForFileReady_FileRemoved(t *testing.T) {
+	p, _, _ := setupMemProcessor(t)
+	err := p.waitForFileReady("/watch/missing.tsv", time.Second)
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestMoveExistingFile_MemFS(t *testing.T) {
+	p, _, mem := setupMemProcessor(t)
+	mem.WriteFile("/watch/done.tsv", []byte("data"))
+	mem.WriteFile("/watch/bad.tsv", []byte("data"))
+
+	p.moveExistingFile("/watch/done.tsv", "completed")
+	p.moveExistingFile("/watch/bad.tsv", "failed")
+
+	_, err := mem.Stat("/watch/done.tsv")
+	assert.True(t, os.IsNotExist(err))
+	info, err := mem.Stat("/archive/done.tsv")
+	require.NoError(t, err)
+	assert.Equal(t, int64(4), info.Size())
+	_, err = mem.Stat("/errors/bad.tsv")
+	assert.NoError(t, err)
+}
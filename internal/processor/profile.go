@@ -0,0 +1,26 @@
+// internal/processor/profile.go
+package processor
+
+import (
+	"TSVProcessingService/db/sqlc"
+	"TSVProcessingService/internal/ingest"
+	"context"
+	"encoding/json"
+)
+
+// SetProfiling включает подсчёт профиля значений полей файла (число
+// различных значений, доля NULL, диапазон level, частые классы);
+// профиль сохраняется в files.profile (GET /files/{filename}/profile).
+func (p *Processor) SetProfiling(enabled bool) {
+	p.profiling = enabled
+}
+
+// saveProfile считает профиль разобранных строк и сохраняет его в запись о файле
+func saveProfile(ctx context.Context, qtx *sqlc.Queries, fileID int64, rows []ingest.Row) error {
+	data, err := json.Marshal(ingest.ProfileRows(rows))
+	if err != nil {
+		return err
+	}
+	_, err = qtx.UpdateFileProfile(ctx, sqlc.UpdateFileProfileParams{ID: fileID, Profile: data})
+	return err
+}
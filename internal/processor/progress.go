@@ -0,0 +1,299 @@
+// internal/processor/progress.go
+package processor
+
+import (
+	"TSVProcessingService/db/sqlc"
+	"TSVProcessingService/internal/clock"
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// Значения по умолчанию для частоты обновления прогресса
+const (
+	defaultProgressEvery    = 500
+	defaultProgressInterval = 2 * time.Second
+)
+
+// Progress описывает ход обработки файла. Запись о файле создаётся
+// внутри транзакции и не видна другим соединениям до commit, поэтому
+// текущие счётчики хранятся в памяти процессора и (StartProgressPublisher)
+// сохраняются в file_progress вне транзакции файла.
+type Progress struct {
+	FileID        int64
+	Filename      string
+	Stage         string // parse / insert
+	RowsTotal     int32  // валидных строк к вставке (после разбора; при потоковой обработке - разобранных частей)
+	RowsProcessed int32
+	RowsFailed    int32
+	StartedAt     time.Time // начало вставки строк
+	UpdatedAt     time.Time
+}
+
+// Rate возвращает скорость вставки (строк в секунду).
+func (p Progress) Rate() float64 {
+	elapsed := p.UpdatedAt.Sub(p.StartedAt).Seconds()
+	done := p.RowsProcessed + p.RowsFailed
+	if elapsed <= 0 || done <= 0 {
+		return 0
+	}
+	return float64(done) / elapsed
+}
+
+// ETA оценивает оставшееся время по текущей скорости.
+// false - оценка пока невозможна.
+func (p Progress) ETA() (time.Duration, bool) {
+	rate := p.Rate()
+	if p.Stage != StageInsert || rate <= 0 {
+		return 0, false
+	}
+	remaining := p.RowsTotal - p.RowsProcessed - p.RowsFailed
+	if remaining <= 0 {
+		return 0, true
+	}
+	return time.Duration(float64(remaining) / rate * float64(time.Second)), true
+}
+
+// ProgressFromRecord - прогресс файла, сохранённый в file_progress
+// (в том числе другим экземпляром сервиса)
+func ProgressFromRecord(r sqlc.FileProgress) Progress {
+	progress := Progress{
+		FileID:        r.FileID,
+		Filename:      r.Filename,
+		Stage:         r.Stage,
+		RowsTotal:     r.RowsTotal,
+		RowsProcessed: r.RowsProcessed,
+		RowsFailed:    r.RowsFailed,
+		StartedAt:     r.StartedAt,
+		UpdatedAt:     r.StartedAt,
+	}
+	if r.UpdatedAt.Valid {
+		progress.UpdatedAt = r.UpdatedAt.Time
+	}
+	return progress
+}
+
+// progressTracker хранит прогресс обрабатываемых файлов
+type progressTracker struct {
+	mu        sync.RWMutex
+	files     map[string]*Progress
+	every     int32
+	interval  time.Duration
+	clock     clock.Clock
+	publisher *progressPublisher // nil - прогресс только в памяти
+}
+
+func newProgressTracker() *progressTracker {
+	return &progressTracker{
+		files:    make(map[string]*Progress),
+		every:    defaultProgressEvery,
+		interval: defaultProgressInterval,
+		clock:    clock.Real,
+	}
+}
+
+// SetProgressInterval задаёт частоту обновления прогресса: каждые rows
+// строк или раз в interval (что наступит раньше). 0 - значение по умолчанию.
+func (p *Processor) SetProgressInterval(rows int, interval time.Duration) {
+	p.progress.mu.Lock()
+	defer p.progress.mu.Unlock()
+	if rows > 0 {
+		p.progress.every = int32(rows)
+	}
+	if interval > 0 {
+		p.progress.interval = interval
+	}
+}
+
+// Progress возвращает прогресс файла, если он сейчас обрабатывается.
+func (p *Processor) Progress(filename string) (Progress, bool) {
+	p.progress.mu.RLock()
+	defer p.progress.mu.RUnlock()
+	progress, ok := p.progress.files[filename]
+	if !ok {
+		return Progress{}, false
+	}
+	return *progress, true
+}
+
+func (t *progressTracker) start(fileID int64, filename string) {
+	t.mu.Lock()
+	now := t.clock.Now()
+	t.files[filename] = &Progress{
+		FileID:    fileID,
+		Filename:  filename,
+		Stage:     StageParse,
+		StartedAt: now,
+		UpdatedAt: now,
+	}
+	t.publish(filename)
+	t.mu.Unlock()
+}
+
+// beginInsert отмечает окончание разбора и начало вставки строк
+func (t *progressTracker) beginInsert(filename string, total int32) {
+	t.mu.Lock()
+	now := t.clock.Now()
+	if progress, ok := t.files[filename]; ok {
+		progress.Stage = StageInsert
+		progress.RowsTotal = total
+		progress.StartedAt = now
+		progress.UpdatedAt = now
+	}
+	t.publish(filename)
+	t.mu.Unlock()
+}
+
+// addTotal увеличивает число строк к вставке на разобранную часть файла
+func (t *progressTracker) addTotal(filename string, rows int32) {
+	t.mu.Lock()
+	if progress, ok := t.files[filename]; ok {
+		progress.RowsTotal += rows
+	}
+	t.publish(filename)
+	t.mu.Unlock()
+}
+
+// due сообщает, пора ли публиковать счётчики
+func (t *progressTracker) due(filename string, processed, failed int32) bool {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	progress, ok := t.files[filename]
+	if !ok {
+		return false
+	}
+	done := processed + failed
+	return done%t.every == 0 || t.clock.Since(progress.UpdatedAt) >= t.interval
+}
+
+func (t *progressTracker) update(filename string, processed, failed int32) {
+	t.mu.Lock()
+	if progress, ok := t.files[filename]; ok {
+		progress.RowsProcessed = processed
+		progress.RowsFailed = failed
+		progress.UpdatedAt = t.clock.Now()
+	}
+	t.publish(filename)
+	t.mu.Unlock()
+}
+
+func (t *progressTracker) finish(filename string) {
+	t.mu.Lock()
+	if _, ok := t.files[filename]; ok {
+		delete(t.files, filename)
+		t.publish(filename)
+	}
+	t.mu.Unlock()
+}
+
+// publish передаёт текущий прогресс файла на сохранение (вызывается под t.mu)
+func (t *progressTracker) publish(filename string) {
+	if t.publisher == nil {
+		return
+	}
+	var snapshot *Progress
+	if progress, ok := t.files[filename]; ok {
+		copied := *progress
+		snapshot = &copied
+	}
+	t.publisher.put(filename, snapshot)
+}
+
+// progressPublisher сохраняет прогресс в file_progress отдельной горутиной
+// через пул соединений, а не транзакцию файла: воркер не ждёт записи, а
+// несохранённые обновления одного файла заменяются последним. Ошибки
+// записи на обработку не влияют.
+type progressPublisher struct {
+	queries *sqlc.Queries
+	logger  *slog.Logger
+	mu      sync.Mutex
+	pending map[string]*Progress // nil - обработка закончена, строка удаляется
+	wake    chan struct{}
+	stop    chan struct{}
+	done    chan struct{}
+}
+
+// StartProgressPublisher включает сохранение прогресса обрабатываемых
+// файлов в file_progress: он виден до commit транзакции файла, в том
+// числе через API других экземпляров.
+func (p *Processor) StartProgressPublisher() {
+	pub := &progressPublisher{
+		queries: p.queries,
+		logger:  p.logger,
+		pending: make(map[string]*Progress),
+		wake:    make(chan struct{}, 1),
+		stop:    make(chan struct{}),
+		done:    make(chan struct{}),
+	}
+	go pub.run()
+	p.progress.mu.Lock()
+	p.progress.publisher = pub
+	p.progress.mu.Unlock()
+}
+
+// StopProgressPublisher сохраняет накопленные обновления и останавливает запись
+func (p *Processor) StopProgressPublisher() {
+	p.progress.mu.Lock()
+	pub := p.progress.publisher
+	p.progress.publisher = nil
+	p.progress.mu.Unlock()
+	if pub == nil {
+		return
+	}
+	close(pub.stop)
+	<-pub.done
+}
+
+func (pub *progressPublisher) put(filename string, progress *Progress) {
+	pub.mu.Lock()
+	pub.pending[filename] = progress
+	pub.mu.Unlock()
+	select {
+	case pub.wake <- struct{}{}:
+	default:
+	}
+}
+
+func (pub *progressPublisher) run() {
+	defer close(pub.done)
+	for {
+		select {
+		case <-pub.wake:
+			pub.flush()
+		case <-pub.stop:
+			pub.flush()
+			return
+		}
+	}
+}
+
+// flush сохраняет накопленные обновления
+func (pub *progressPublisher) flush() {
+	pub.mu.Lock()
+	pending := pub.pending
+	pub.pending = make(map[string]*Progress)
+	pub.mu.Unlock()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	for filename, progress := range pending {
+		var err error
+		if progress == nil {
+			err = pub.queries.DeleteFileProgress(ctx, filename)
+		} else {
+			err = pub.queries.UpsertFileProgress(ctx, sqlc.UpsertFileProgressParams{
+				Filename:      filename,
+				FileID:        progress.FileID,
+				Stage:         progress.Stage,
+				RowsTotal:     progress.RowsTotal,
+				RowsProcessed: progress.RowsProcessed,
+				RowsFailed:    progress.RowsFailed,
+				StartedAt:     progress.StartedAt,
+			})
+		}
+		if err != nil {
+			pub.logger.Warn("Failed to save file progress", "file", filename, "error", err)
+		}
+	}
+}
@@ -0,0 +1,37 @@
+// internal/processor/recover.go
+package processor
+
+import (
+	"TSVProcessingService/internal/watcher"
+	"fmt"
+	"runtime/debug"
+)
+
+// PanicError - panic при обработке файла, превращённый в ошибку
+type PanicError struct {
+	Value interface{}
+	Stack []byte
+}
+
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("panic: %v", e.Value)
+}
+
+// recoverPanic перехватывает panic в ProcessFile: файл помечается как
+// failed, стек сохраняется в БД, а воркер продолжает со следующим файлом.
+// Должна вызываться напрямую через defer.
+func (p *Processor) recoverPanic(fileInfo watcher.FileInfo, errp *error) {
+	r := recover()
+	if r == nil {
+		return
+	}
+
+	panicErr := &PanicError{Value: r, Stack: debug.Stack()}
+	p.logger.Error("💥 Panic while processing file", "file", fileInfo.Name, "panic", r, "stack", string(panicErr.Stack))
+
+	message := failureMessage(StagePanic, fmt.Sprintf("%v\n%s", panicErr, panicErr.Stack))
+	if err := p.recordFailure(fileInfo, message, panicErr.Error()); err != nil {
+		p.logger.Error("Failed to record panic", "file", fileInfo.Name, "error", err)
+	}
+	*errp = panicErr
+}
@@ -0,0 +1,240 @@
+// internal/processor/rejected.go
+package processor
+
+import (
+	"TSVProcessingService/internal/ingest"
+	"TSVProcessingService/internal/watcher"
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// rejectedReasonColumn - колонка с причиной ошибки в <name>.rejected.tsv
+const rejectedReasonColumn = "error_reason"
+
+// rejectedLines - причины ошибок по номерам строк файла. Причины текущей
+// части файла держатся в памяти и после неё (flush) дописываются по
+// порядку номеров во временный файл в temp_path, поэтому память не растёт
+// с размером файла. Без directory.rejected_file причины не нужны и
+// только считаются.
+type rejectedLines struct {
+	keep    bool
+	tempDir string
+	chunk   map[int32]string
+	count   int
+	path    string // временный файл ("" - ничего не выгружено)
+}
+
+func (p *Processor) newRejectedLines() *rejectedLines {
+	return &rejectedLines{
+		keep:    p.config.RejectedFile,
+		tempDir: p.config.TempPath,
+		chunk:   make(map[int32]string),
+	}
+}
+
+// add запоминает причину ошибки строки
+func (r *rejectedLines) add(line int32, reason string) {
+	if _, ok := r.chunk[line]; !ok {
+		r.count++
+	}
+	if r.keep {
+		r.chunk[line] = reason
+	} else {
+		r.chunk[line] = ""
+	}
+}
+
+// addParseErrors запоминает ошибки разбора. Ошибки без номера строки
+// (файл не открылся) к строкам не относятся.
+func (r *rejectedLines) addParseErrors(parseErrors []ingest.RowError) {
+	for _, perr := range parseErrors {
+		if perr.LineNumber.Valid {
+			r.add(perr.LineNumber.Int32, perr.ErrorMessage)
+		}
+	}
+}
+
+// sortedChunk - номера строк текущей части по порядку
+func (r *rejectedLines) sortedChunk() []int32 {
+	lines := make([]int32, 0, len(r.chunk))
+	for line := range r.chunk {
+		lines = append(lines, line)
+	}
+	slices.Sort(lines)
+	return lines
+}
+
+// flush выгружает причины текущей части во временный файл. Части идут по
+// порядку строк файла, поэтому файл остаётся упорядоченным по номерам.
+func (r *rejectedLines) flush() error {
+	if !r.keep {
+		clear(r.chunk)
+		return nil
+	}
+	if len(r.chunk) == 0 {
+		return nil
+	}
+	if r.path == "" {
+		if err := os.MkdirAll(r.tempDir, 0755); err != nil {
+			return err
+		}
+		f, err := os.CreateTemp(r.tempDir, "rejected-*.tsv")
+		if err != nil {
+			return err
+		}
+		r.path = f.Name()
+		f.Close()
+	}
+	f, err := os.OpenFile(r.path, os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		return err
+	}
+	w := bufio.NewWriter(f)
+	for _, line := range r.sortedChunk() {
+		fmt.Fprintf(w, "%d\t%s\n", line, rejectedReason(r.chunk[line]))
+	}
+	if err := w.Flush(); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	clear(r.chunk)
+	return nil
+}
+
+// each перебирает ошибочные строки по порядку номеров: выгруженные, затем
+// оставшиеся в памяти
+func (r *rejectedLines) each(fn func(line int32, reason string) error) error {
+	if r.path != "" {
+		f, err := os.Open(r.path)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		scanner := bufio.NewScanner(f)
+		for scanner.Scan() {
+			number, reason, _ := strings.Cut(scanner.Text(), "\t")
+			line, err := strconv.ParseInt(number, 10, 32)
+			if err != nil {
+				return fmt.Errorf("failed to read spilled rejected lines: %w", err)
+			}
+			if err := fn(int32(line), reason); err != nil {
+				return err
+			}
+		}
+		if err := scanner.Err(); err != nil {
+			return err
+		}
+	}
+	for _, line := range r.sortedChunk() {
+		if err := fn(line, r.chunk[line]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// remove удаляет временный файл
+func (r *rejectedLines) remove() {
+	if r.path != "" {
+		os.Remove(r.path)
+		r.path = ""
+	}
+}
+
+// rejectedName - имя файла ошибочных строк: a.tsv -> a.rejected.tsv
+func rejectedName(name string) string {
+	return strings.TrimSuffix(name, filepath.Ext(name)) + ".rejected.tsv"
+}
+
+// writeRejectedFile записывает в error_path файл с маркером исходного
+// файла (rejectedMarker), его заголовком и только ошибочными строками (с
+// дополнительной колонкой error_reason), чтобы отправитель исправил и
+// отправил повторно лишь их.
+// Строки берутся из исходного файла до его перемещения. Пустой путь -
+// файл не нужен (directory.rejected_file выключен или ошибок нет).
+func (p *Processor) writeRejectedFile(fileInfo watcher.FileInfo, rejected *rejectedLines) (string, error) {
+	if !p.config.RejectedFile || rejected.count == 0 {
+		return "", nil
+	}
+
+	src, err := p.fs.Open(fileInfo.Path)
+	if err != nil {
+		return "", err
+	}
+	defer src.Close()
+
+	if err := p.fs.MkdirAll(p.config.ErrorPath, 0755); err != nil {
+		return "", err
+	}
+	path := filepath.Join(p.config.ErrorPath, rejectedName(fileInfo.Name))
+	tmpPath := path + ".tmp"
+	f, err := p.fs.Create(tmpPath)
+	if err != nil {
+		return "", err
+	}
+	out := bufio.NewWriter(f)
+	out.WriteString(rejectedMarker + fileInfo.Name + "\n")
+
+	// Номера строк считаются так же, как при разборе (ingest.ParseFile);
+	// ошибочные строки перебираются по порядку вместе со строками файла
+	scanner := bufio.NewScanner(src)
+	lineNumber := int32(0)
+	headerWritten := false
+	err = rejected.each(func(failedLine int32, reason string) error {
+		for scanner.Scan() {
+			line := scanner.Text()
+			lineNumber++
+			if lineNumber == failedLine {
+				out.WriteString(line + "\t" + rejectedReason(reason) + "\n")
+				return nil
+			}
+			if !headerWritten && isHeaderLine(line) {
+				out.WriteString(line + "\t" + rejectedReasonColumn + "\n")
+				headerWritten = true
+			}
+		}
+		return scanner.Err()
+	})
+	if err != nil {
+		f.Close()
+		p.fs.Remove(tmpPath)
+		return "", err
+	}
+	if err := out.Flush(); err != nil {
+		f.Close()
+		p.fs.Remove(tmpPath)
+		return "", err
+	}
+	if err := f.Close(); err != nil {
+		p.fs.Remove(tmpPath)
+		return "", err
+	}
+	if err := p.fs.Rename(tmpPath, path); err != nil {
+		return "", err
+	}
+	return path, nil
+}
+
+// isHeaderLine - строка заголовка: непустая, не комментарий, первое поле
+// не число (как при разборе)
+func isHeaderLine(line string) bool {
+	trimmed := strings.TrimSpace(line)
+	if trimmed == "" || strings.HasPrefix(trimmed, "#") {
+		return false
+	}
+	_, err := strconv.Atoi(strings.TrimSpace(strings.Split(line, "\t")[0]))
+	return err != nil
+}
+
+// rejectedReason - причина ошибки одной колонкой TSV
+func rejectedReason(reason string) string {
+	return strings.NewReplacer("\t", " ", "\r", " ", "\n", " ").Replace(reason)
+}
@@ -0,0 +1,278 @@
+// internal/processor/report_formats.go
+package processor
+
+import (
+	"archive/zip"
+	"bytes"
+	"crypto/sha256"
+	"database/sql/driver"
+	"encoding/csv"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Форматы отчёта по устройству (reports.report_type)
+const (
+	ReportPDF  = "pdf"
+	ReportCSV  = "csv"
+	ReportXLSX = "xlsx"
+	ReportJSON = "json"
+)
+
+// ReportFormats - поддерживаемые форматы отчёта
+var ReportFormats = []string{ReportPDF, ReportCSV, ReportXLSX, ReportJSON}
+
+// ParseReportFormat проверяет формат отчёта ("" - PDF)
+func ParseReportFormat(s string) (string, error) {
+	if s == "" {
+		return ReportPDF, nil
+	}
+	format := strings.ToLower(s)
+	for _, f := range ReportFormats {
+		if f == format {
+			return format, nil
+		}
+	}
+	return "", fmt.Errorf("unknown report format %q (expected %s)", s, strings.Join(ReportFormats, ", "))
+}
+
+// reportColumns - колонки табличных отчётов (CSV, XLSX) и поля записей JSON
+var reportColumns = []string{
+	"line_number", "unit_guid", "mqtt", "invid", "msg_id", "text", "context",
+	"class", "level", "area", "addr", "block", "type", "bit", "invert_bit",
+}
+
+// reportCells - значения колонок reportColumns строки: string, int64,
+// bool или nil для пустых (NULL) полей
+func reportCells(row TSVRow) []interface{} {
+	value := func(v driver.Valuer) interface{} {
+		cell, _ := v.Value()
+		return cell
+	}
+	return []interface{}{
+		int64(row.LineNumber), row.UnitGuid.String(), value(row.Mqtt), value(row.Invid),
+		value(row.MsgID), value(row.Text), value(row.Context), value(row.Class), value(row.Level),
+		value(row.Area), value(row.Addr), value(row.Block), value(row.Type), value(row.Bit), value(row.InvertBit),
+	}
+}
+
+// createReport генерирует файл отчёта в формате meta.fileFormat и
+// возвращает его путь и SHA256 (для проверки, что отчёт не изменён
+// после генерации). Пароль поддерживает только PDF.
+func (p *Processor) createReport(unitGuid uuid.UUID, meta reportMeta, data []TSVRow) (string, string, error) {
+	format := meta.fileFormat
+	if format == "" {
+		format = ReportPDF
+	}
+	if meta.password != "" && format != ReportPDF {
+		return "", "", fmt.Errorf("password protection is only supported for PDF reports, not %s", format)
+	}
+
+	var content []byte
+	var err error
+	switch format {
+	case ReportPDF:
+		content, err = p.renderPDFReport(unitGuid, meta, data)
+	case ReportCSV:
+		content, err = renderCSVReport(data)
+	case ReportXLSX:
+		content, err = renderXLSXReport(data)
+	case ReportJSON:
+		content, err = renderJSONReport(unitGuid, meta, data)
+	default:
+		err = fmt.Errorf("unknown report format %q", format)
+	}
+	if err != nil {
+		return "", "", fmt.Errorf("failed to render %s report: %w", format, err)
+	}
+
+	if err := os.MkdirAll(p.config.OutputPath, 0755); err != nil {
+		return "", "", err
+	}
+	timestamp := time.Now().Format("20060102_150405")
+	base := p.reportBaseName(unitGuid, timestamp, meta.fileMeta)
+	filename := base + "." + format
+	if meta.part > 0 {
+		filename = fmt.Sprintf("%s_part%03d.%s", base, meta.part, format)
+	}
+	path := filepath.Join(p.config.OutputPath, filename)
+	if err := os.WriteFile(path, content, 0644); err != nil {
+		return "", "", fmt.Errorf("failed to save %s report: %w", format, err)
+	}
+	sum := sha256.Sum256(content)
+	return path, hex.EncodeToString(sum[:]), nil
+}
+
+// renderCSVReport - строка заголовка reportColumns и по строке на запись.
+// Значения не локализуются: CSV предназначен для загрузки в другие системы.
+func renderCSVReport(data []TSVRow) ([]byte, error) {
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	if err := w.Write(reportColumns); err != nil {
+		return nil, err
+	}
+	record := make([]string, len(reportColumns))
+	for _, row := range data {
+		for i, cell := range reportCells(row) {
+			record[i] = cellString(cell)
+		}
+		if err := w.Write(record); err != nil {
+			return nil, err
+		}
+	}
+	w.Flush()
+	return buf.Bytes(), w.Error()
+}
+
+// cellString - значение ячейки CSV ("" для NULL)
+func cellString(cell interface{}) string {
+	switch v := cell.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case int64:
+		return strconv.FormatInt(v, 10)
+	case bool:
+		return strconv.FormatBool(v)
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// jsonReport - содержимое JSON-отчёта
+type jsonReport struct {
+	UnitGuid     string                   `json:"unit_guid"`
+	GeneratedAt  string                   `json:"generated_at"`
+	Part         int                      `json:"part,omitempty"`
+	Period       string                   `json:"period,omitempty"`
+	TotalRecords int                      `json:"total_records"`
+	Records      []map[string]interface{} `json:"records"`
+}
+
+// renderJSONReport - отчёт с метаданными части и записями (NULL - null)
+func renderJSONReport(unitGuid uuid.UUID, meta reportMeta, data []TSVRow) ([]byte, error) {
+	report := jsonReport{
+		UnitGuid:     unitGuid.String(),
+		GeneratedAt:  time.Now().UTC().Format(time.RFC3339),
+		Part:         meta.part,
+		Period:       meta.period,
+		TotalRecords: len(data),
+		Records:      make([]map[string]interface{}, 0, len(data)),
+	}
+	for _, row := range data {
+		record := make(map[string]interface{}, len(reportColumns))
+		for i, cell := range reportCells(row) {
+			record[reportColumns[i]] = cell
+		}
+		report.Records = append(report.Records, record)
+	}
+	return json.MarshalIndent(report, "", "  ")
+}
+
+// xlsxParts - постоянные части книги XLSX (Office Open XML) с одним листом
+var xlsxParts = []struct{ name, content string }{
+	{"[Content_Types].xml", `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">` +
+		`<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>` +
+		`<Default Extension="xml" ContentType="application/xml"/>` +
+		`<Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/>` +
+		`<Override PartName="/xl/worksheets/sheet1.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/>` +
+		`</Types>`},
+	{"_rels/.rels", `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+		`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/>` +
+		`</Relationships>`},
+	{"xl/workbook.xml", `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">` +
+		`<sheets><sheet name="Device Data" sheetId="1" r:id="rId1"/></sheets>` +
+		`</workbook>`},
+	{"xl/_rels/workbook.xml.rels", `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+		`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet1.xml"/>` +
+		`</Relationships>`},
+}
+
+// renderXLSXReport - книга с листом "Device Data": строка заголовка
+// reportColumns и по строке на запись. Строки пишутся inline (без
+// таблицы общих строк), числа и флаги - типизированными ячейками.
+func renderXLSXReport(data []TSVRow) ([]byte, error) {
+	var sheet bytes.Buffer
+	sheet.WriteString(`<?xml version="1.0" encoding="UTF-8" standalone="yes"?>` + "\n")
+	sheet.WriteString(`<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>`)
+	header := make([]interface{}, len(reportColumns))
+	for i, name := range reportColumns {
+		header[i] = name
+	}
+	writeXLSXRow(&sheet, 1, header)
+	for i, row := range data {
+		writeXLSXRow(&sheet, i+2, reportCells(row))
+	}
+	sheet.WriteString(`</sheetData></worksheet>`)
+
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	write := func(name string, content []byte) error {
+		f, err := zw.Create(name)
+		if err != nil {
+			return err
+		}
+		_, err = f.Write(content)
+		return err
+	}
+	for _, part := range xlsxParts {
+		if err := write(part.name, []byte(part.content)); err != nil {
+			return nil, err
+		}
+	}
+	if err := write("xl/worksheets/sheet1.xml", sheet.Bytes()); err != nil {
+		return nil, err
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// writeXLSXRow пишет строку листа; пустые (NULL) ячейки пропускаются
+func writeXLSXRow(buf *bytes.Buffer, n int, cells []interface{}) {
+	fmt.Fprintf(buf, `<row r="%d">`, n)
+	for i, cell := range cells {
+		ref := xlsxColumn(i) + strconv.Itoa(n)
+		switch v := cell.(type) {
+		case nil:
+			continue
+		case int64:
+			fmt.Fprintf(buf, `<c r="%s"><v>%d</v></c>`, ref, v)
+		case bool:
+			b := 0
+			if v {
+				b = 1
+			}
+			fmt.Fprintf(buf, `<c r="%s" t="b"><v>%d</v></c>`, ref, b)
+		default:
+			fmt.Fprintf(buf, `<c r="%s" t="inlineStr"><is><t xml:space="preserve">`, ref)
+			xml.EscapeText(buf, []byte(cellString(v)))
+			buf.WriteString(`</t></is></c>`)
+		}
+	}
+	buf.WriteString(`</row>`)
+}
+
+// xlsxColumn - буквенное имя колонки по индексу с 0 (A, B, ..., Z, AA, ...)
+func xlsxColumn(i int) string {
+	name := ""
+	for i++; i > 0; i = (i - 1) / 26 {
+		name = string(rune('A'+(i-1)%26)) + name
+	}
+	return name
+}
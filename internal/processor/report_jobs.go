@@ -0,0 +1,112 @@
+// internal/processor/report_jobs.go
+package processor
+
+import (
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Статусы заданий на отчёт по устройству
+const (
+	ReportJobQueued    = "queued"
+	ReportJobRunning   = "running"
+	ReportJobCompleted = "completed"
+	ReportJobFailed    = "failed"
+)
+
+// reportJobRetention - сколько хранится статус завершённого задания
+const reportJobRetention = 24 * time.Hour
+
+// ReportJob описывает задание на отчёт по устройству. ID задания
+// совпадает с report_group его частей.
+type ReportJob struct {
+	ID         uuid.UUID
+	UnitGuid   uuid.UUID
+	Status     string
+	Parts      int   // сгенерировано частей
+	Records    int64 // записей в отчёте
+	Error      string
+	CreatedAt  time.Time
+	UpdatedAt  time.Time
+	FinishedAt time.Time
+}
+
+// reportJobTracker хранит статусы заданий в памяти процессора
+type reportJobTracker struct {
+	mu   sync.RWMutex
+	jobs map[uuid.UUID]*ReportJob
+}
+
+func newReportJobTracker() *reportJobTracker {
+	return &reportJobTracker{jobs: make(map[uuid.UUID]*ReportJob)}
+}
+
+// ReportJobStatus возвращает статус задания на отчёт.
+func (p *Processor) ReportJobStatus(id uuid.UUID) (ReportJob, bool) {
+	p.reportJobs.mu.RLock()
+	defer p.reportJobs.mu.RUnlock()
+	job, ok := p.reportJobs.jobs[id]
+	if !ok {
+		return ReportJob{}, false
+	}
+	return *job, true
+}
+
+func (t *reportJobTracker) queue(id, unitGuid uuid.UUID) {
+	now := time.Now()
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for jobID, job := range t.jobs {
+		if !job.FinishedAt.IsZero() && now.Sub(job.FinishedAt) > reportJobRetention {
+			delete(t.jobs, jobID)
+		}
+	}
+	t.jobs[id] = &ReportJob{
+		ID:        id,
+		UnitGuid:  unitGuid,
+		Status:    ReportJobQueued,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+}
+
+// update изменяет задание, если оно отслеживается
+func (t *reportJobTracker) update(id uuid.UUID, fn func(job *ReportJob)) {
+	t.mu.Lock()
+	if job, ok := t.jobs[id]; ok {
+		fn(job)
+		job.UpdatedAt = time.Now()
+	}
+	t.mu.Unlock()
+}
+
+func (t *reportJobTracker) start(id uuid.UUID) {
+	t.update(id, func(job *ReportJob) { job.Status = ReportJobRunning })
+}
+
+func (t *reportJobTracker) part(id uuid.UUID, records int) {
+	t.update(id, func(job *ReportJob) {
+		job.Parts++
+		job.Records += int64(records)
+	})
+}
+
+func (t *reportJobTracker) finish(id uuid.UUID, err error) {
+	t.update(id, func(job *ReportJob) {
+		job.Status = ReportJobCompleted
+		if err != nil {
+			job.Status = ReportJobFailed
+			job.Error = err.Error()
+		}
+		job.FinishedAt = time.Now()
+	})
+}
+
+// forget удаляет задание, которое не удалось поставить в очередь
+func (t *reportJobTracker) forget(id uuid.UUID) {
+	t.mu.Lock()
+	delete(t.jobs, id)
+	t.mu.Unlock()
+}
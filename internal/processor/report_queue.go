@@ -0,0 +1,174 @@
+// internal/processor/report_queue.go
+package processor
+
+import (
+	"TSVProcessingService/internal/jobs"
+	"TSVProcessingService/internal/tracing"
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ErrReportQueueFull возвращается, если очередь отчётов переполнена или остановлена
+var ErrReportQueueFull = errors.New("report queue is full")
+
+// reportJob - задание на генерацию отчётов: либо по строкам
+// обработанного файла, либо по всем данным устройства из БД.
+type reportJob struct {
+	fileID   int64
+	filename string      // имя файла (метаданные для имени отчёта)
+	source   string      // оформление отчётов файла
+	groups   *unitGroups // строки файла по устройствам (nil - отчёт по данным устройства)
+	unitGuid uuid.UUID
+	options  ReportOptions       // пароль из запроса хранится только в памяти
+	trace    tracing.SpanContext // span обработки файла или запроса отчёта
+}
+
+// reportQueue - асинхронная очередь генерации отчётов
+type reportQueue struct {
+	jobs    chan reportJob
+	timeout time.Duration
+	wg      sync.WaitGroup
+	mu      sync.RWMutex
+	closed  bool
+}
+
+// SetJobs включает запись заданий генерации отчётов в журнал заданий.
+func (p *Processor) SetJobs(m *jobs.Manager) {
+	p.jobs = m
+}
+
+// StartReportWorkers включает асинхронную генерацию отчётов: ProcessFile
+// больше не ждёт рендеринга PDF, а ставит задание в очередь.
+func (p *Processor) StartReportWorkers(workers, queueSize int, timeout time.Duration) {
+	if workers < 1 {
+		workers = 1
+	}
+	q := &reportQueue{
+		jobs:    make(chan reportJob, queueSize),
+		timeout: timeout,
+	}
+	for i := 0; i < workers; i++ {
+		q.wg.Add(1)
+		go p.reportWorker(q, i+1)
+	}
+	p.reports = q
+	p.logger.Info("📄 Started report workers", "workers", workers, "queue_size", queueSize)
+}
+
+// StopReportWorkers прекращает приём заданий и дожидается обработки
+// уже поставленных в очередь.
+func (p *Processor) StopReportWorkers() {
+	q := p.reports
+	if q == nil {
+		return
+	}
+	q.mu.Lock()
+	if !q.closed {
+		q.closed = true
+		close(q.jobs)
+	}
+	q.mu.Unlock()
+	q.wg.Wait()
+}
+
+// EnqueueUnitReport ставит в очередь отчёт по всем данным устройства
+// и возвращает ID задания (он же report_group частей отчёта).
+// Непустой opts.Password шифрует отчёт (пароль передаётся получателю отдельно).
+// Если очередь не запущена, отчёт генерируется в отдельной горутине.
+func (p *Processor) EnqueueUnitReport(unitGuid uuid.UUID, opts ReportOptions) (uuid.UUID, error) {
+	if opts.Group == uuid.Nil {
+		opts.Group = uuid.New()
+	}
+	p.reportJobs.queue(opts.Group, unitGuid)
+
+	if p.reports == nil {
+		go func() {
+			ctx, cancel := context.WithTimeout(tracing.ContextWithSpan(context.Background(), opts.Trace), 2*time.Minute)
+			defer cancel()
+			spec := jobs.Spec{Kind: jobs.KindReport, Subject: unitGuid.String(), Owner: "api"}
+			if err := p.jobs.Run(ctx, spec, func(ctx context.Context) error {
+				return p.GenerateReportForUnit(ctx, unitGuid, opts)
+			}); err != nil {
+				p.logger.Error("Failed to generate report", "unit_guid", unitGuid, "error", err)
+			}
+		}()
+		return opts.Group, nil
+	}
+	if err := p.reports.enqueue(reportJob{unitGuid: unitGuid, options: opts, trace: opts.Trace}); err != nil {
+		p.reportJobs.forget(opts.Group)
+		return uuid.Nil, err
+	}
+	return opts.Group, nil
+}
+
+// enqueueFileReports ставит в очередь отчёты по строкам файла
+// (в трассе обработки файла).
+func (p *Processor) enqueueFileReports(trace tracing.SpanContext, fileID int64, filename, source string, groups *unitGroups) error {
+	if p.reports == nil {
+		return ErrReportQueueFull
+	}
+	return p.reports.enqueue(reportJob{fileID: fileID, filename: filename, source: source, groups: groups, trace: trace})
+}
+
+func (q *reportQueue) enqueue(job reportJob) error {
+	q.mu.RLock()
+	defer q.mu.RUnlock()
+	if q.closed {
+		return ErrReportQueueFull
+	}
+	select {
+	case q.jobs <- job:
+		return nil
+	default:
+		return ErrReportQueueFull
+	}
+}
+
+// reportWorker выполняет задания очереди отчётов до её закрытия.
+func (p *Processor) reportWorker(q *reportQueue, id int) {
+	defer q.wg.Done()
+
+	for job := range q.jobs {
+		ctx, cancel := context.WithTimeout(tracing.ContextWithSpan(context.Background(), job.trace), q.timeout)
+		guids := []uuid.UUID{job.unitGuid}
+		if job.groups != nil {
+			guids = job.groups.units
+		}
+		unlock, err := p.lockUnits(ctx, guids)
+		if err != nil {
+			p.logger.Error("Failed to lock units for report", "report_worker", id, "error", err)
+			if job.groups == nil {
+				p.reportJobs.finish(job.options.Group, err)
+			} else {
+				job.groups.remove()
+			}
+			cancel()
+			continue
+		}
+		spec := jobs.Spec{Kind: jobs.KindReport, Owner: fmt.Sprintf("report-worker-%d", id)}
+		if job.groups != nil {
+			spec.Subject = fmt.Sprintf("file:%d", job.fileID)
+			if err := p.jobs.Run(ctx, spec, func(ctx context.Context) error {
+				_, err := p.generateReports(ctx, job.fileID, job.filename, job.source, job.groups)
+				return err
+			}); err != nil {
+				p.logger.Error("Failed to generate reports", "report_worker", id, "file_id", job.fileID, "error", err)
+			}
+			job.groups.remove()
+		} else {
+			spec.Subject = job.unitGuid.String()
+			if err := p.jobs.Run(ctx, spec, func(ctx context.Context) error {
+				return p.GenerateReportForUnit(ctx, job.unitGuid, job.options)
+			}); err != nil {
+				p.logger.Error("Failed to generate report", "report_worker", id, "unit_guid", job.unitGuid, "error", err)
+			}
+		}
+		unlock()
+		cancel()
+	}
+}
@@ -0,0 +1,179 @@
+// internal/processor/report_spill.go
+package processor
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+
+	"github.com/google/uuid"
+)
+
+// rowOverhead - оценка памяти строки без учёта содержимого строковых полей
+const rowOverhead = 256
+
+// SetReportMemoryBudget ограничивает память (байт) под строки файла,
+// сгруппированные по unit_guid для отчётов (worker.report_memory_mb):
+// при превышении накопленные группы выгружаются во временные файлы
+// в temp_path и читаются по одному устройству при рендеринге. Задание
+// очереди отчётов держит только выгруженные группы, а не все строки
+// файла. 0 - без ограничения.
+func (p *Processor) SetReportMemoryBudget(bytes int64) {
+	p.reportMemory = bytes
+}
+
+// unitGroups - строки файла по unit_guid: в памяти и (при превышении
+// бюджета) во временных файлах по устройству
+type unitGroups struct {
+	units   []uuid.UUID // по порядку первой строки устройства в файле
+	mem     map[uuid.UUID][]TSVRow
+	memSize int64
+	budget  int64
+	dir     string // временная директория ("" - ничего не выгружено)
+	tempDir string
+	spilled map[uuid.UUID]bool
+	logger  *slog.Logger
+}
+
+// groupByUnit группирует строки для отчётов
+func (p *Processor) groupByUnit(rows []TSVRow) *unitGroups {
+	g := p.newUnitGroups()
+	for _, row := range rows {
+		g.add(row)
+	}
+	g.logSpilled()
+	return g
+}
+
+func (p *Processor) newUnitGroups() *unitGroups {
+	return &unitGroups{
+		mem:     make(map[uuid.UUID][]TSVRow),
+		budget:  p.reportMemory,
+		tempDir: p.config.TempPath,
+		spilled: make(map[uuid.UUID]bool),
+		logger:  p.logger,
+	}
+}
+
+// add добавляет строку в группу устройства. Ошибка выгрузки не прерывает
+// обработку: оставшиеся строки группируются в памяти.
+func (g *unitGroups) add(row TSVRow) {
+	if _, ok := g.mem[row.UnitGuid]; !ok && !g.spilled[row.UnitGuid] {
+		g.units = append(g.units, row.UnitGuid)
+	}
+	g.mem[row.UnitGuid] = append(g.mem[row.UnitGuid], row)
+	if g.budget <= 0 {
+		return
+	}
+	g.memSize += rowSize(row)
+	if g.memSize > g.budget {
+		if err := g.spill(); err != nil {
+			g.logger.Warn("Failed to spill report rows to disk, grouping in memory", "error", err)
+			g.budget = 0
+		}
+	}
+}
+
+func (g *unitGroups) logSpilled() {
+	if g.dir != "" {
+		g.logger.Info("💾 Report rows spilled to disk", "units", len(g.spilled), "dir", g.dir, "budget_bytes", g.budget)
+	}
+}
+
+// rowSize - оценка памяти строки
+func rowSize(row TSVRow) int64 {
+	return rowOverhead + int64(len(row.Mqtt.String)+len(row.Invid.String)+len(row.MsgID.String)+
+		len(row.Text.String)+len(row.Context.String)+len(row.Class.String)+len(row.Area.String)+
+		len(row.Addr.String)+len(row.Block.String)+len(row.Type.String))
+}
+
+// spill дописывает группы из памяти в файлы устройств (по строке JSON
+// на запись) и освобождает память
+func (g *unitGroups) spill() error {
+	if g.dir == "" {
+		if err := os.MkdirAll(g.tempDir, 0755); err != nil {
+			return err
+		}
+		dir, err := os.MkdirTemp(g.tempDir, "report-rows-*")
+		if err != nil {
+			return err
+		}
+		g.dir = dir
+	}
+	for guid, rows := range g.mem {
+		if err := appendRows(g.path(guid), rows); err != nil {
+			return err
+		}
+		g.spilled[guid] = true
+		delete(g.mem, guid)
+	}
+	g.memSize = 0
+	return nil
+}
+
+func (g *unitGroups) path(guid uuid.UUID) string {
+	return filepath.Join(g.dir, guid.String()+".ndjson")
+}
+
+func appendRows(path string, rows []TSVRow) error {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		return err
+	}
+	w := bufio.NewWriter(f)
+	enc := json.NewEncoder(w)
+	for _, row := range rows {
+		if err := enc.Encode(row); err != nil {
+			f.Close()
+			return err
+		}
+	}
+	if err := w.Flush(); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// rows - строки устройства по порядку файла (выгруженные строки
+// предшествуют оставшимся в памяти)
+func (g *unitGroups) rows(guid uuid.UUID) ([]TSVRow, error) {
+	if !g.spilled[guid] {
+		return g.mem[guid], nil
+	}
+	f, err := os.Open(g.path(guid))
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var rows []TSVRow
+	dec := json.NewDecoder(bufio.NewReader(f))
+	for {
+		var row TSVRow
+		err := dec.Decode(&row)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read spilled rows of %s: %w", guid, err)
+		}
+		rows = append(rows, row)
+	}
+	return append(rows, g.mem[guid]...), nil
+}
+
+// remove удаляет временные файлы
+func (g *unitGroups) remove() {
+	if g.dir == "" {
+		return
+	}
+	if err := os.RemoveAll(g.dir); err != nil {
+		g.logger.Error("Failed to remove spilled report rows", "dir", g.dir, "error", err)
+	}
+	g.dir = ""
+}
@@ -0,0 +1,134 @@
+// internal/processor/retry.go
+package processor
+
+import (
+	"TSVProcessingService/db/sqlc"
+	"TSVProcessingService/internal/database"
+	"TSVProcessingService/internal/filestatus"
+	"TSVProcessingService/internal/watcher"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// maxRetryDelay - предел паузы между попытками
+const maxRetryDelay = time.Hour
+
+// SetRetry включает повторную обработку файлов после временных ошибок
+// (worker.retry_attempts, worker.retry_delay): БД недоступна, конфликт
+// или таймаут блокировки. Файл остаётся в watch-директории со статусом
+// retrying, число попыток хранится в file_retries; пауза перед попыткой
+// удваивается (delay, 2×delay, 4×delay, ... до часа). В error_path файл
+// перемещается, только когда попытки исчерпаны. requeue ставит файл
+// в очередь, когда подходит время попытки (nil - файл поставит
+// сканирование директории). attempts <= 0 - повторов нет.
+func (p *Processor) SetRetry(attempts int, delay time.Duration, requeue func(watcher.FileInfo) error) {
+	p.retryAttempts = int32(attempts)
+	p.retryDelay = delay
+	p.requeue = requeue
+}
+
+// retryable сообщает, что ошибку обработки стоит повторить. Исчерпанный
+// бюджет файла, таймаут этапа и panic временными не считаются.
+func retryable(ctx context.Context, err error) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+	var timeoutErr *StageTimeoutError
+	var panicErr *PanicError
+	if errors.As(err, &timeoutErr) || errors.As(err, &panicErr) {
+		return false
+	}
+	return database.IsTransient(err)
+}
+
+// retryBackoff - пауза перед повторной попыткой attempt (с 1)
+func (p *Processor) retryBackoff(attempt int32) time.Duration {
+	delay := p.retryDelay
+	for i := int32(1); i < attempt && delay < maxRetryDelay; i++ {
+		delay *= 2
+	}
+	return min(delay, maxRetryDelay)
+}
+
+// scheduleRetry назначает повторную обработку файла после временной
+// ошибки err. false - повтора не будет (ошибка не временная или попытки
+// исчерпаны): файл помечается failed как обычно.
+func (p *Processor) scheduleRetry(ctx context.Context, fileInfo watcher.FileInfo, err error) bool {
+	if p.retryAttempts <= 0 || !retryable(ctx, err) {
+		return false
+	}
+
+	dbCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	// Состояние попыток не прочитать - БД недоступна, и отметить файл
+	// failed тоже нельзя: файл остаётся в watch-директории
+	attempt := int32(1)
+	prev, getErr := p.queries.GetFileRetry(dbCtx, fileInfo.Name)
+	switch {
+	case getErr == nil:
+		attempt = prev.Attempts + 1
+	case !errors.Is(getErr, sql.ErrNoRows):
+		p.logger.Warn("Failed to read retry state, leaving file for the next scan", "file", fileInfo.Name, "error", getErr)
+		return true
+	}
+
+	if attempt > p.retryAttempts {
+		if err := p.queries.DeleteFileRetry(dbCtx, fileInfo.Name); err != nil {
+			p.logger.Error("Failed to clear retry state", "file", fileInfo.Name, "error", err)
+		}
+		p.logger.Error("File failed after retries", "file", fileInfo.Name, "retries", p.retryAttempts, "error", err)
+		return false
+	}
+
+	delay := p.retryBackoff(attempt)
+	next := p.clock.Now().Add(delay)
+	message := failureMessage(failureStage(err), err.Error())
+	if err := p.queries.UpsertFileRetry(dbCtx, sqlc.UpsertFileRetryParams{
+		Filename:    fileInfo.Name,
+		Attempts:    attempt,
+		NextRetryAt: next,
+		LastError:   sql.NullString{String: message, Valid: true},
+	}); err != nil {
+		p.logger.Warn("Failed to save retry state, leaving file for the next scan", "file", fileInfo.Name, "error", err)
+		return true
+	}
+	if _, err := p.markFile(dbCtx, fileInfo, filestatus.Retrying, message); err != nil {
+		p.logger.Error("Failed to mark file as retrying", "file", fileInfo.Name, "error", err)
+	}
+
+	p.RecordEvent(fileInfo.Name, EventRetryScheduled,
+		fmt.Sprintf("attempt %d/%d in %v", attempt, p.retryAttempts, delay))
+	p.logger.Warn("🔁 Retry scheduled", "file", fileInfo.Name, "attempt", attempt, "attempts", p.retryAttempts, "delay", delay, "error", err)
+
+	if p.requeue != nil {
+		go func() {
+			<-p.clock.After(delay)
+			if err := p.requeue(fileInfo); err != nil {
+				p.logger.Warn("Failed to requeue file, leaving it for the next scan", "file", fileInfo.Name, "error", err)
+			}
+		}()
+	}
+	return true
+}
+
+// retryDue сообщает, что подошло время повторной обработки файла со
+// статусом retrying. Запись о файле удаляется, чтобы он обработался
+// заново; число попыток остаётся в file_retries.
+func (p *Processor) retryDue(ctx context.Context, file sqlc.File) (bool, error) {
+	retry, err := p.queries.GetFileRetry(ctx, file.Filename)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return false, fmt.Errorf("failed to read retry state: %w", err)
+	}
+	if err == nil && p.clock.Now().Before(retry.NextRetryAt) {
+		return false, nil
+	}
+	if err := p.queries.DeleteFile(ctx, file.ID); err != nil {
+		return false, fmt.Errorf("failed to reset retrying file: %w", err)
+	}
+	p.logger.Info("Retrying file", "file", file.Filename, "attempt", retry.Attempts)
+	return true, nil
+}
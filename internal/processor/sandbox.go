@@ -0,0 +1,10 @@
+// internal/processor/sandbox.go
+package processor
+
+// SetSandbox включает режим sandbox (sandbox.enabled): исходный файл не
+// перемещается и не удаляется - в archive_path, error_path и hold_path
+// попадает его копия, а оригинал остаётся в watch-директории. При
+// следующем обнаружении файл пропускается как уже обработанный.
+func (p *Processor) SetSandbox(enabled bool) {
+	p.sandbox = enabled
+}
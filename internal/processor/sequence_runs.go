@@ -0,0 +1,78 @@
+// internal/processor/sequence_runs.go
+package processor
+
+import (
+	"TSVProcessingService/db/sqlc"
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+)
+
+// SetSequenceRuns включает сохранение непрерывных отрезков номеров n
+// строк каждого устройства файла (unit_sequence_runs). По отрезкам
+// internal/sequence ищет пропуски и нарушения порядка n устройства
+// между файлами.
+func (p *Processor) SetSequenceRuns(enabled bool) {
+	p.sequenceRuns = enabled
+}
+
+// unitRuns разбивает строки файла (по порядку строк, частями) на
+// непрерывные отрезки n каждого устройства: отрезок продолжается строкой
+// устройства с n = last+1. В памяти остаются только открытые отрезки
+// (по одному на устройство), закрытые забираются после каждой части.
+type unitRuns struct {
+	units  []uuid.UUID // по порядку первой строки устройства
+	open   map[uuid.UUID]*sqlc.CreateUnitSequenceRunParams
+	closed []sqlc.CreateUnitSequenceRunParams
+}
+
+func newUnitRuns() *unitRuns {
+	return &unitRuns{open: make(map[uuid.UUID]*sqlc.CreateUnitSequenceRunParams)}
+}
+
+// add добавляет очередную часть строк файла (sequenceLines)
+func (r *unitRuns) add(lines []seqLine) {
+	for _, l := range lines {
+		if !l.unitGuid.Valid {
+			continue
+		}
+		run, ok := r.open[l.unitGuid.UUID]
+		if ok && run.SeqLast+1 == l.seq {
+			run.SeqLast = l.seq
+			run.Rows++
+			continue
+		}
+		if ok {
+			r.closed = append(r.closed, *run)
+		} else {
+			r.units = append(r.units, l.unitGuid.UUID)
+		}
+		r.open[l.unitGuid.UUID] = &sqlc.CreateUnitSequenceRunParams{
+			UnitGuid: l.unitGuid.UUID,
+			SeqFirst: l.seq,
+			SeqLast:  l.seq,
+			Rows:     1,
+		}
+	}
+}
+
+// save сохраняет закрытые отрезки; last - файл закончился, сохраняются
+// и открытые отрезки (в порядке устройств)
+func (r *unitRuns) save(ctx context.Context, qtx *sqlc.Queries, fileID int64, last bool) error {
+	runs := r.closed
+	r.closed = nil
+	if last {
+		for _, guid := range r.units {
+			runs = append(runs, *r.open[guid])
+		}
+		r.units, r.open = nil, make(map[uuid.UUID]*sqlc.CreateUnitSequenceRunParams)
+	}
+	for _, run := range runs {
+		run.FileID = fileID
+		if err := qtx.CreateUnitSequenceRun(ctx, run); err != nil {
+			return fmt.Errorf("failed to save sequence run of unit %s: %w", run.UnitGuid, err)
+		}
+	}
+	return nil
+}
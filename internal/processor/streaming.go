@@ -0,0 +1,89 @@
+// internal/processor/streaming.go
+package processor
+
+import (
+	"TSVProcessingService/internal/ingest"
+	"TSVProcessingService/internal/watcher"
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// defaultStreamChunk - строк данных в части по умолчанию
+const defaultStreamChunk = 10000
+
+// SetStreaming включает потоковую обработку файлов от minSize байт
+// (worker.stream_min_size_mb): файл разбирается и сохраняется частями по
+// chunkRows строк (worker.stream_chunk_rows), поэтому строки файла целиком
+// в памяти не держатся: ошибочные строки после каждой части выгружаются
+// в temp_path, дубликаты строк предыдущих частей ищутся в БД, а для
+// проверки n хранятся только счётчики и открытые отрезки устройств.
+// Все части сохраняются в одной транзакции; после каждой обновляется
+// прогресс обработки (виден до commit через StartProgressPublisher).
+// Профиль значений полей (SetProfiling) для таких файлов не строится.
+// minSize <= 0 - файлы разбираются целиком.
+func (p *Processor) SetStreaming(minSize int64, chunkRows int) {
+	p.streamMinSize = minSize
+	p.streamChunk = chunkRows
+	if p.streamChunk <= 0 {
+		p.streamChunk = defaultStreamChunk
+	}
+}
+
+// streams сообщает, что файл обрабатывается частями
+func (p *Processor) streams(fileInfo watcher.FileInfo) bool {
+	return p.streamMinSize > 0 && fileInfo.Size >= p.streamMinSize
+}
+
+// streamContext - контекст разбора и вставки частями: этапы чередуются,
+// поэтому их бюджеты складываются
+func (p *Processor) streamContext(ctx context.Context, total time.Duration) (context.Context, context.CancelFunc, time.Duration) {
+	parse, insert := p.stageBudget(StageParse, total), p.stageBudget(StageInsert, total)
+	if parse <= 0 || insert <= 0 {
+		return p.stageContext(ctx, StageInsert, 0)
+	}
+	stageCtx, cancel := context.WithTimeout(ctx, parse+insert)
+	return stageCtx, cancel, parse + insert
+}
+
+// streamUnitGuids - устройства файла для блокировок SetSerializePerUnit:
+// файл предварительно разбирается частями без сохранения строк. Выборка
+// не учитывается - блокируются все устройства файла.
+func (p *Processor) streamUnitGuids(ctx context.Context, fileInfo watcher.FileInfo) ([]uuid.UUID, error) {
+	if p.unitLocks == nil {
+		return nil, nil
+	}
+	stats := newRowStats()
+	err := ingest.StreamFile(ctx, fileInfo.Path, ingest.Sampling{}, fileInfo.Options, p.streamChunk,
+		func(rows []TSVRow, _ []ProcessingError) error {
+			stats.add(rows)
+			return ctx.Err()
+		})
+	return stats.unitGuids, err
+}
+
+// rowStats - устройства и число строк по значению class (без class -
+// ключ "") разобранных строк файла
+type rowStats struct {
+	unitGuids []uuid.UUID // по порядку первой строки устройства
+	seen      map[uuid.UUID]bool
+	classes   map[string]int32
+}
+
+func newRowStats() *rowStats {
+	return &rowStats{
+		seen:    make(map[uuid.UUID]bool),
+		classes: make(map[string]int32),
+	}
+}
+
+func (s *rowStats) add(rows []TSVRow) {
+	for _, row := range rows {
+		if !s.seen[row.UnitGuid] {
+			s.seen[row.UnitGuid] = true
+			s.unitGuids = append(s.unitGuids, row.UnitGuid)
+		}
+		s.classes[row.Class.String]++
+	}
+}
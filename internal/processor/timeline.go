@@ -0,0 +1,115 @@
+// internal/processor/timeline.go
+package processor
+
+import (
+	"TSVProcessingService/db/sqlc"
+	"context"
+	"sync"
+	"time"
+)
+
+// События хронологии обработки файла (таблица file_events)
+const (
+	EventDetected         = "detected"          // файл впервые увиден в watch-директории
+	EventQueued           = "queued"            // поставлен в очередь воркеров
+	EventClaimed          = "claimed"           // взят воркером (detail - worker-N)
+	EventSkipped          = "skipped"           // уже обработан ранее
+	EventDuplicateContent = "duplicate_content" // содержимое совпадает с обработанным файлом, обработан повторно
+	EventParseStarted     = "parse_started"     // начало разбора TSV
+	EventParseFinished    = "parse_finished"    // строк к вставке и ошибок разбора
+	EventInsertChunk      = "insert_chunk"      // прогресс вставки строк
+	EventCommitted        = "committed"         // транзакция зафиксирована (detail - статус)
+	EventReportsQueued    = "reports_queued"    // отчёты поставлены в очередь отчётов
+	EventReportsGenerated = "reports_generated" // отчёты сгенерированы синхронно
+	EventArchived         = "archived"          // перемещён в archive_path
+	EventMovedToErrors    = "moved_to_errors"   // перемещён в error_path
+	EventRejectedWritten  = "rejected_written"  // ошибочные строки записаны в <name>.rejected.tsv
+	EventCorrectionMerged = "correction_merged" // засчитаны строки исправленного rejected-файла
+	EventFailed           = "failed"            // ошибка обработки (detail - files.error_message)
+	EventCancelled        = "cancelled"         // отменён оператором и отложен в hold_path
+	EventRetryScheduled   = "retry_scheduled"   // временная ошибка, назначен повтор (detail - попытка и пауза)
+	EventNotReady         = "not_ready"         // файл ещё дописывается, оставлен до следующего сканирования
+
+	// Из журнала заданий (не хранятся в file_events)
+	EventReportJobStarted  = "report_job_started"  // задание отчётов взято воркером отчётов
+	EventReportJobFinished = "report_job_finished" // итог задания отчётов
+)
+
+// TimelineEvent - событие хронологии обработки файла
+type TimelineEvent struct {
+	Event  string    `json:"event"`
+	At     time.Time `json:"at"`
+	Detail string    `json:"detail,omitempty"`
+}
+
+// timelineTracker накапливает события обрабатываемых файлов. Они
+// сохраняются в file_events одним пакетом после завершения обработки:
+// запись не ждёт транзакции файла и не пропадает при её откате, а до
+// сохранения события видны через LiveTimeline.
+type timelineTracker struct {
+	mu    sync.Mutex
+	files map[string][]TimelineEvent
+}
+
+func newTimelineTracker() *timelineTracker {
+	return &timelineTracker{files: make(map[string][]TimelineEvent)}
+}
+
+func (t *timelineTracker) add(filename string, event TimelineEvent) {
+	t.mu.Lock()
+	t.files[filename] = append(t.files[filename], event)
+	t.mu.Unlock()
+}
+
+// take забирает накопленные события файла
+func (t *timelineTracker) take(filename string) []TimelineEvent {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	events := t.files[filename]
+	delete(t.files, filename)
+	return events
+}
+
+// RecordEvent добавляет событие в хронологию файла (сохраняется в
+// file_events по окончании ProcessFile)
+func (p *Processor) RecordEvent(filename, event, detail string) {
+	p.recordEventAt(filename, event, detail, p.clock.Now())
+}
+
+// recordEventAt - событие с заданным временем; нулевое время - события не было
+func (p *Processor) recordEventAt(filename, event, detail string, at time.Time) {
+	if at.IsZero() {
+		return
+	}
+	p.timeline.add(filename, TimelineEvent{Event: event, At: at, Detail: detail})
+}
+
+// LiveTimeline - ещё не сохранённые события файла, который сейчас обрабатывается
+func (p *Processor) LiveTimeline(filename string) []TimelineEvent {
+	p.timeline.mu.Lock()
+	defer p.timeline.mu.Unlock()
+	return append([]TimelineEvent(nil), p.timeline.files[filename]...)
+}
+
+// flushTimeline сохраняет накопленные события файла в file_events.
+// Ошибки записи хронологии не влияют на результат обработки.
+func (p *Processor) flushTimeline(filename string) {
+	events := p.timeline.take(filename)
+	if len(events) == 0 {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	for _, e := range events {
+		if err := p.queries.CreateFileEvent(ctx, sqlc.CreateFileEventParams{
+			Filename:   filename,
+			Event:      e.Event,
+			Detail:     e.Detail,
+			OccurredAt: e.At,
+		}); err != nil {
+			p.logger.Error("Failed to save timeline", "file", filename, "error", err)
+			return
+		}
+	}
+}
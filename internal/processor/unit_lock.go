@@ -0,0 +1,102 @@
+// internal/processor/unit_lock.go
+package processor
+
+import (
+	"context"
+	"sort"
+	"sync"
+
+	"github.com/google/uuid"
+)
+
+// unitLocks - блокировки по unit_guid. Файлы и отчёты одного устройства
+// обрабатываются по очереди, разных устройств - параллельно.
+type unitLocks struct {
+	mu    sync.Mutex
+	locks map[uuid.UUID]*unitLock
+}
+
+type unitLock struct {
+	ch   chan struct{} // занятый слот - блокировка захвачена
+	refs int           // владелец и ожидающие; при 0 запись удаляется
+}
+
+func newUnitLocks() *unitLocks {
+	return &unitLocks{locks: make(map[uuid.UUID]*unitLock)}
+}
+
+// SetSerializePerUnit включает последовательную обработку данных одного
+// устройства: вставка строк и отчёты файла выполняются под блокировками
+// всех его unit_guid. Должна быть вызвана до начала обработки.
+func (p *Processor) SetSerializePerUnit(enabled bool) {
+	if enabled {
+		p.unitLocks = newUnitLocks()
+	} else {
+		p.unitLocks = nil
+	}
+}
+
+// lockUnits захватывает блокировки устройств (в порядке возрастания
+// unit_guid, чтобы исключить взаимоблокировку). Без SetSerializePerUnit
+// ничего не делает.
+func (p *Processor) lockUnits(ctx context.Context, guids []uuid.UUID) (func(), error) {
+	if p.unitLocks == nil {
+		return func() {}, nil
+	}
+	return p.unitLocks.acquire(ctx, guids)
+}
+
+func (l *unitLocks) acquire(ctx context.Context, guids []uuid.UUID) (func(), error) {
+	sorted := append([]uuid.UUID(nil), guids...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].String() < sorted[j].String() })
+
+	var held []uuid.UUID
+	release := func() {
+		for _, guid := range held {
+			l.unlock(guid)
+		}
+	}
+	for _, guid := range sorted {
+		lock := l.ref(guid)
+		select {
+		case lock.ch <- struct{}{}:
+			held = append(held, guid)
+		case <-ctx.Done():
+			l.unref(guid)
+			release()
+			return nil, ctx.Err()
+		}
+	}
+	return release, nil
+}
+
+func (l *unitLocks) ref(guid uuid.UUID) *unitLock {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	lock, ok := l.locks[guid]
+	if !ok {
+		lock = &unitLock{ch: make(chan struct{}, 1)}
+		l.locks[guid] = lock
+	}
+	lock.refs++
+	return lock
+}
+
+func (l *unitLocks) unref(guid uuid.UUID) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if lock, ok := l.locks[guid]; ok {
+		lock.refs--
+		if lock.refs == 0 {
+			delete(l.locks, guid)
+		}
+	}
+}
+
+func (l *unitLocks) unlock(guid uuid.UUID) {
+	l.mu.Lock()
+	lock := l.locks[guid]
+	l.mu.Unlock()
+	<-lock.ch
+	l.unref(guid)
+}
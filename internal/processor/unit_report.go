@@ -0,0 +1,221 @@
+// internal/processor/unit_report.go
+package processor
+
+import (
+	"TSVProcessingService/db/sqlc"
+	"TSVProcessingService/internal/locale"
+	"TSVProcessingService/internal/tracing"
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	defaultReportPartSize = 10000 // записей в части отчёта по умолчанию
+	reportPageSize        = 1000  // записей, читаемых из БД за один запрос
+)
+
+// ReportOptions - параметры отчёта по устройству
+type ReportOptions struct {
+	Format     string    // формат файла (ReportFormats), "" - PDF
+	Password   string    // пароль PDF из запроса ("" - пароль из конфигурации)
+	PartSize   int       // записей в части (0 - report.part_size)
+	SplitByDay bool      // новая часть для каждых суток данных
+	Group      uuid.UUID // report_group частей (uuid.Nil - новая группа)
+	Language   string    // форматирование дат и чисел (Accept-Language), "" - report.language
+
+	Trace tracing.SpanContext // span запроса отчёта (генерация - дочерний span)
+
+	// Необязательный фильтр данных
+	From  time.Time // created_at >= From
+	To    time.Time // created_at < To
+	Class string
+}
+
+// match - проходит ли запись фильтр по классу и верхней границе периода.
+// Записи без created_at в отчёт за период не попадают.
+func (o ReportOptions) match(d sqlc.DeviceDatum) bool {
+	if !d.CreatedAt.Valid && (!o.From.IsZero() || !o.To.IsZero()) {
+		return false
+	}
+	if !o.To.IsZero() && !d.CreatedAt.Time.Before(o.To) {
+		return false
+	}
+	return o.Class == "" || strings.EqualFold(d.Class.String, o.Class)
+}
+
+// GenerateReportForUnit генерирует отчёт по всем данным устройства
+// (с учётом фильтра opts). Данные читаются из БД порциями по курсору
+// (unitDataPage) без ограничения общего числа записей и делятся на
+// части (отдельные PDF) по PartSize записей, а при SplitByDay - ещё и по
+// суткам. Все части связаны общим report_group и нумеруются с 1.
+// Части пишутся в формате opts.Format (PDF, CSV, XLSX или JSON);
+// пароль (из запроса или конфигурации) поддерживает только PDF.
+func (p *Processor) GenerateReportForUnit(ctx context.Context, unitGuid uuid.UUID, opts ReportOptions) (err error) {
+	partSize := opts.PartSize
+	if partSize <= 0 {
+		partSize = p.report.PartSize
+	}
+	if partSize <= 0 {
+		partSize = defaultReportPartSize
+	}
+	group := opts.Group
+	if group == uuid.Nil {
+		group = uuid.New()
+	}
+	p.reportJobs.start(group)
+	defer func() { p.reportJobs.finish(group, err) }()
+
+	format, err := ParseReportFormat(opts.Format)
+	if err != nil {
+		return err
+	}
+	p.logger.Info("📊 Generating unit report", "unit_guid", unitGuid, "format", format)
+	meta := reportMeta{
+		fileFormat: format,
+		password:   p.reportPassword("", opts.Password),
+		format:     p.reportFormat(opts.Language),
+	}
+	span := tracing.NewSpan(tracing.FromContext(ctx))
+
+	var part []sqlc.DeviceDatum
+	flush := func() error {
+		meta.part++
+		meta.period = datumPeriod(part, meta.format)
+		reportPath, checksum, err := p.createReport(unitGuid, meta, deviceRows(part))
+		if err != nil {
+			return fmt.Errorf("failed to create report part %d: %w", meta.part, err)
+		}
+		p.reportJobs.part(group, len(part))
+		part = part[:0]
+
+		params := sqlc.CreateReportParams{
+			UnitGuid:    unitGuid,
+			ReportType:  sql.NullString{String: format, Valid: true},
+			FilePath:    reportPath,
+			Checksum:    sql.NullString{String: checksum, Valid: true},
+			ReportGroup: uuid.NullUUID{UUID: group, Valid: true},
+			Part:        sql.NullInt32{Int32: int32(meta.part), Valid: true},
+			TraceID:     nullString(span.TraceID),
+			SpanID:      nullString(span.SpanID),
+		}
+		if report, err := p.queries.CreateReport(ctx, params); err != nil {
+			p.logger.Warn("Report generated but DB record failed", "unit_guid", unitGuid, "path", reportPath, "error", err)
+		} else {
+			p.logger.Info("✅ Report part saved", "unit_guid", unitGuid, "format", format, "part", meta.part, "path", reportPath)
+			p.notifyReport(ctx, report)
+		}
+		return nil
+	}
+
+	var cursor *sqlc.DeviceDatum
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		page, err := p.unitDataPage(ctx, unitGuid, cursor)
+		if err != nil {
+			return fmt.Errorf("failed to fetch device data: %w", err)
+		}
+
+		done := false
+		for _, d := range page {
+			// Записи идут от новых к старым: всё дальше - раньше From
+			if !opts.From.IsZero() && d.CreatedAt.Valid && d.CreatedAt.Time.Before(opts.From) {
+				done = true
+				break
+			}
+			if !opts.match(d) {
+				continue
+			}
+			if len(part) > 0 && (len(part) >= partSize ||
+				opts.SplitByDay && datumDay(d, meta.format) != datumDay(part[0], meta.format)) {
+				if err := flush(); err != nil {
+					return err
+				}
+			}
+			part = append(part, d)
+		}
+		if done || len(page) < reportPageSize {
+			break
+		}
+		cursor = &page[len(page)-1]
+	}
+
+	if len(part) > 0 {
+		if err := flush(); err != nil {
+			return err
+		}
+	}
+	if meta.part == 0 {
+		return fmt.Errorf("no data found for unit %s", unitGuid)
+	}
+	p.logger.Info("📚 Unit report generated", "unit_guid", unitGuid, "parts", meta.part, "group", group)
+	return nil
+}
+
+// unitDataPage - очередные reportPageSize записей устройства от новых
+// к старым после записи cursor (nil - с самой новой). Курсор по
+// (created_at, id) вместо OFFSET: запрос не пересчитывает пропущенные
+// записи, а новые записи, загруженные во время генерации, не сдвигают
+// страницы (не дают повторов и пропусков). Значения курсора передаются
+// в запрос, а не читаются по id: запись могла быть удалена или
+// перенесена в архив между страницами. Записи без created_at идут
+// первыми, как в ORDER BY created_at DESC в PostgreSQL.
+func (p *Processor) unitDataPage(ctx context.Context, unitGuid uuid.UUID, cursor *sqlc.DeviceDatum) ([]sqlc.DeviceDatum, error) {
+	if cursor == nil {
+		return p.queries.ListDeviceDataByUnit(ctx, sqlc.ListDeviceDataByUnitParams{
+			UnitGuid: unitGuid,
+			Limit:    reportPageSize,
+		})
+	}
+	return p.queries.ListDeviceDataByUnitBefore(ctx, sqlc.ListDeviceDataByUnitBeforeParams{
+		UnitGuid:  unitGuid,
+		CreatedAt: cursor.CreatedAt,
+		ID:        cursor.ID,
+		Limit:     reportPageSize,
+	})
+}
+
+// datumDay - дата записи в часовом поясе отчёта (сутки для разбиения отчёта)
+func datumDay(d sqlc.DeviceDatum, f locale.Formatter) string {
+	return f.Date(d.CreatedAt.Time)
+}
+
+// datumPeriod - диапазон дат записей части (записи идут от новых к старым)
+func datumPeriod(data []sqlc.DeviceDatum, f locale.Formatter) string {
+	newest, oldest := datumDay(data[0], f), datumDay(data[len(data)-1], f)
+	if newest == oldest {
+		return newest
+	}
+	return oldest + " - " + newest
+}
+
+// deviceRows преобразует записи БД в строки отчёта
+func deviceRows(data []sqlc.DeviceDatum) []TSVRow {
+	rows := make([]TSVRow, 0, len(data))
+	for _, d := range data {
+		rows = append(rows, TSVRow{
+			UnitGuid:   d.UnitGuid,
+			Mqtt:       d.Mqtt,
+			Invid:      d.Invid,
+			MsgID:      d.MsgID,
+			Text:       d.Text,
+			Context:    d.Context,
+			Class:      d.Class,
+			Level:      d.Level,
+			Area:       d.Area,
+			Addr:       d.Addr,
+			Block:      d.Block,
+			Type:       d.Type,
+			Bit:        d.Bit,
+			InvertBit:  d.InvertBit,
+			LineNumber: d.LineNumber,
+		})
+	}
+	return rows
+}
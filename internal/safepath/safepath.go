@@ -0,0 +1,78 @@
+// internal/safepath/safepath.go
+package safepath
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+var (
+	// ErrInvalidName - имя файла содержит разделители пути, ".." и т.п.
+	ErrInvalidName = errors.New("invalid filename")
+	// ErrOutsideRoot - путь выходит за пределы разрешённой директории
+	ErrOutsideRoot = errors.New("path is outside the allowed directory")
+)
+
+// Filename проверяет, что name - простое имя файла без каталогов.
+// Обратный слэш запрещён на всех платформах: имя может прийти
+// из запроса и затем использоваться на Windows.
+func Filename(name string) error {
+	if name == "" || name == "." || name == ".." ||
+		strings.ContainsAny(name, `/\`+"\x00") || name != filepath.Base(name) {
+		return ErrInvalidName
+	}
+	return nil
+}
+
+// Join соединяет корень и имя файла из пользовательского ввода.
+// Имя должно пройти Filename, результат обязан остаться внутри root.
+func Join(root, name string) (string, error) {
+	if err := Filename(name); err != nil {
+		return "", err
+	}
+	return Within(filepath.Join(root, name), root)
+}
+
+// Within возвращает абсолютный путь, если path лежит внутри одной из
+// разрешённых директорий roots. Символические ссылки раскрываются,
+// поэтому ссылка из root наружу тоже отклоняется. Несуществующий файл
+// проверяется по очищенному пути (ошибку "не найден" вернёт вызывающий).
+func Within(path string, roots ...string) (string, error) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return "", ErrOutsideRoot
+	}
+	resolved, err := filepath.EvalSymlinks(abs)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			return "", ErrOutsideRoot
+		}
+		resolved = ""
+	}
+
+	for _, root := range roots {
+		rootAbs, err := filepath.Abs(root)
+		if err != nil || !inside(abs, rootAbs) {
+			continue
+		}
+		if resolved == "" {
+			return abs, nil
+		}
+		// Корень тоже может быть ссылкой (например, /tmp на macOS)
+		if rootReal, err := filepath.EvalSymlinks(rootAbs); err == nil && inside(resolved, rootReal) {
+			return abs, nil
+		}
+		if inside(resolved, rootAbs) {
+			return abs, nil
+		}
+	}
+	return "", ErrOutsideRoot
+}
+
+// inside - лежит ли path внутри dir (оба пути абсолютные, сам dir не подходит)
+func inside(path, dir string) bool {
+	rel, err := filepath.Rel(dir, path)
+	return err == nil && rel != "." && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
@@ -0,0 +1,58 @@
+package safepath
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFilename(t *testing.T) {
+	assert.NoError(t, Filename("device_test.tsv"))
+	assert.NoError(t, Filename("..data.tsv"))
+
+	for _, bad := range []string{"", ".", "..", "../etc/passwd", "a/b.tsv", `..\..\win.ini`, "/etc/passwd", "a\x00.tsv"} {
+		assert.ErrorIs(t, Filename(bad), ErrInvalidName, bad)
+	}
+}
+
+func TestJoin(t *testing.T) {
+	root := t.TempDir()
+
+	path, err := Join(root, "device.tsv")
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(root, "device.tsv"), path)
+
+	_, err = Join(root, "../device.tsv")
+	assert.ErrorIs(t, err, ErrInvalidName)
+}
+
+func TestWithin(t *testing.T) {
+	root := t.TempDir()
+	outside := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(root, "report.pdf"), []byte("%PDF"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(outside, "secret.txt"), []byte("secret"), 0644))
+
+	_, err := Within(filepath.Join(root, "report.pdf"), root)
+	assert.NoError(t, err)
+	_, err = Within(filepath.Join(root, "missing.pdf"), root) // проверяется путь
+	assert.NoError(t, err)
+	_, err = Within(filepath.Join(root, "..", filepath.Base(outside), "secret.txt"), root)
+	assert.ErrorIs(t, err, ErrOutsideRoot)
+	_, err = Within(root, root)
+	assert.ErrorIs(t, err, ErrOutsideRoot)
+
+	// Вторая разрешённая директория
+	_, err = Within(filepath.Join(outside, "secret.txt"), root, outside)
+	assert.NoError(t, err)
+
+	// Ссылка внутри root, ведущая наружу
+	link := filepath.Join(root, "link.pdf")
+	if err := os.Symlink(filepath.Join(outside, "secret.txt"), link); err != nil {
+		t.Skipf("symlinks not supported: %v", err)
+	}
+	_, err = Within(link, root)
+	assert.ErrorIs(t, err, ErrOutsideRoot)
+}
@@ -0,0 +1,274 @@
+// internal/sequence/checker.go
+package sequence
+
+import (
+	"TSVProcessingService/db/sqlc"
+	"TSVProcessingService/internal/alert"
+	"TSVProcessingService/internal/clock"
+	"context"
+	"fmt"
+	"log/slog"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Options - параметры проверки
+type Options struct {
+	Interval time.Duration
+	Lookback time.Duration // проверяются отрезки файлов за этот период
+	Timeout  time.Duration // на одну проверку; 0 - 5 минут
+	Clock    clock.Clock   // nil - системные часы
+}
+
+// Report - итог фоновой проверки всех устройств
+type Report struct {
+	ComputedAt      time.Time      `json:"computed_at"`
+	DurationMs      int64          `json:"duration_ms"`
+	Since           time.Time      `json:"since"`
+	Units           int            `json:"units"`
+	UnitsWithGaps   int            `json:"units_with_gaps"`
+	Missing         int64          `json:"missing"`
+	OutOfOrderUnits int            `json:"out_of_order_units"`
+	Problems        []UnitReport   `json:"problems"`           // устройства с нарушениями, больше пропущенных - раньше
+	Reexport        *ReexportCycle `json:"reexport,omitempty"` // nil - webhook повторной выгрузки не настроен
+}
+
+// ReexportCycle - запросы повторной выгрузки за одну проверку
+type ReexportCycle struct {
+	Created   int      `json:"created"`   // новые запросы на пропуски
+	Sent      int      `json:"sent"`      // приняты партнёром
+	Fulfilled int      `json:"fulfilled"` // пропуск закрыт пришедшими файлами
+	Expired   int64    `json:"expired"`
+	Errors    []string `json:"errors,omitempty"`
+}
+
+// gapKey - пропуск устройства, о котором уже отправлено оповещение
+type gapKey struct {
+	unit     uuid.UUID
+	from, to int64
+}
+
+// Checker периодически проверяет последовательности n всех устройств
+// за Lookback и оповещает о новых пропусках, пока недостающие выгрузки
+// ещё можно запросить у контроллеров.
+type Checker struct {
+	queries  *sqlc.Queries
+	alerts   *alert.Dispatcher // nil - без оповещений
+	reexport *Reexporter       // nil - повторная выгрузка не запрашивается
+	opts     Options
+	ctx      context.Context
+	cancel   context.CancelFunc
+
+	mu       sync.RWMutex
+	report   *Report
+	reported map[gapKey]bool // пропуски последней проверки
+}
+
+// NewChecker создаёт Checker; проверка начинается в Run
+func NewChecker(queries *sqlc.Queries, alerts *alert.Dispatcher, opts Options) *Checker {
+	if opts.Timeout <= 0 {
+		opts.Timeout = 5 * time.Minute
+	}
+	if opts.Clock == nil {
+		opts.Clock = clock.Real
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Checker{queries: queries, alerts: alerts, opts: opts, ctx: ctx, cancel: cancel}
+}
+
+// SetReexporter включает запрос повторной выгрузки новых пропусков у
+// партнёра и закрытие запросов, когда пропуск покрыт пришедшими файлами
+func (c *Checker) SetReexporter(r *Reexporter) {
+	c.reexport = r
+}
+
+// Run проверяет последовательности сразу и затем каждые Interval до вызова Stop.
+func (c *Checker) Run() {
+	clock.Every(c.ctx, c.opts.Clock, c.opts.Interval, func() {
+		if _, err := c.Check(c.ctx); err != nil {
+			slog.Error("❌ Sequence check failed", "component", "sequence", "error", err)
+		}
+	})
+	slog.Info("Sequence checker stopped", "component", "sequence")
+}
+
+// Stop останавливает Run
+func (c *Checker) Stop() {
+	c.cancel()
+}
+
+// Lookback - период проверки
+func (c *Checker) Lookback() time.Duration {
+	return c.opts.Lookback
+}
+
+// Report - итог последней проверки; false - ещё ни разу не выполнена
+func (c *Checker) Report() (Report, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if c.report == nil {
+		return Report{}, false
+	}
+	return *c.report, true
+}
+
+// Unit проверяет последовательность n одного устройства с момента since
+func (c *Checker) Unit(ctx context.Context, unit uuid.UUID, since time.Time) (UnitReport, error) {
+	rows, err := c.queries.ListUnitSequenceRuns(ctx, sqlc.ListUnitSequenceRunsParams{UnitGuid: unit, CreatedAt: since})
+	if err != nil {
+		return UnitReport{}, fmt.Errorf("failed to list sequence runs of unit %s: %w", unit, err)
+	}
+	runs := make([]Run, 0, len(rows))
+	for _, row := range rows {
+		runs = append(runs, newRun(sqlc.ListSequenceRunsSinceRow(row)))
+	}
+	return Analyze(unit, runs), nil
+}
+
+// newRun - отрезок из строки unit_sequence_runs
+func newRun(row sqlc.ListSequenceRunsSinceRow) Run {
+	return Run{
+		ID:        row.ID,
+		FileID:    row.FileID,
+		Filename:  row.Filename,
+		First:     row.SeqFirst,
+		Last:      row.SeqLast,
+		Rows:      row.Rows,
+		CreatedAt: row.CreatedAt,
+	}
+}
+
+// Check проверяет последовательности всех устройств, сохраняет итог и
+// оповещает о пропусках, которых не было в предыдущей проверке.
+func (c *Checker) Check(ctx context.Context) (Report, error) {
+	ctx, cancel := context.WithTimeout(ctx, c.opts.Timeout)
+	defer cancel()
+
+	start := c.opts.Clock.Now()
+	since := start.Add(-c.opts.Lookback)
+	rows, err := c.queries.ListSequenceRunsSince(ctx, since)
+	if err != nil {
+		return Report{}, fmt.Errorf("failed to list sequence runs: %w", err)
+	}
+
+	report := Report{ComputedAt: start, Since: since, Problems: []UnitReport{}}
+	var open map[uuid.UUID][]sqlc.ReexportRequest
+	if c.reexport != nil {
+		report.Reexport = &ReexportCycle{}
+		if open, err = c.reexport.Open(ctx); err != nil {
+			report.Reexport.Errors = append(report.Reexport.Errors, err.Error())
+		}
+	}
+
+	var runs []Run
+	flush := func(unit uuid.UUID) {
+		if len(runs) == 0 {
+			return
+		}
+		if requests := open[unit]; len(requests) > 0 {
+			fulfilled, err := c.reexport.Reconcile(ctx, requests, runs)
+			report.Reexport.Fulfilled += fulfilled
+			if err != nil {
+				report.Reexport.Errors = append(report.Reexport.Errors, err.Error())
+			}
+		}
+		report.Units++
+		if unitReport := Analyze(unit, runs); !unitReport.Healthy() {
+			if unitReport.GapsTotal > 0 {
+				report.UnitsWithGaps++
+			}
+			if unitReport.OutOfOrderTotal > 0 {
+				report.OutOfOrderUnits++
+			}
+			report.Missing += unitReport.Missing
+			report.Problems = append(report.Problems, unitReport)
+		}
+		runs = runs[:0]
+	}
+	// Строки упорядочены по устройству, внутри - по порядку поступления
+	for i, row := range rows {
+		if i > 0 && row.UnitGuid != rows[i-1].UnitGuid {
+			flush(rows[i-1].UnitGuid)
+		}
+		runs = append(runs, newRun(row))
+	}
+	if len(rows) > 0 {
+		flush(rows[len(rows)-1].UnitGuid)
+	}
+	sort.SliceStable(report.Problems, func(i, j int) bool {
+		return report.Problems[i].Missing > report.Problems[j].Missing
+	})
+	if c.reexport != nil {
+		c.requestReexport(ctx, &report)
+	}
+	report.DurationMs = c.opts.Clock.Since(start).Milliseconds()
+
+	c.mu.Lock()
+	previous := c.reported
+	c.reported = make(map[gapKey]bool)
+	var fresh []string
+	for _, unitReport := range report.Problems {
+		for _, gap := range unitReport.Gaps {
+			key := gapKey{unit: unitReport.UnitGuid, from: gap.From, to: gap.To}
+			c.reported[key] = true
+			if !previous[key] {
+				fresh = append(fresh, fmt.Sprintf("unit %s: n %d-%d (%d rows) between %s and %s",
+					unitReport.UnitGuid, gap.From, gap.To, gap.Missing, gap.AfterFile, gap.BeforeFile))
+			}
+		}
+	}
+	c.report = &report
+	c.mu.Unlock()
+
+	if len(fresh) > 0 {
+		slog.Warn("⚠️ New sequence gaps", "component", "sequence", "gaps", len(fresh), "units_with_gaps", report.UnitsWithGaps, "missing", report.Missing)
+		if c.alerts != nil {
+			if len(fresh) > 10 {
+				fresh = append(fresh[:10], fmt.Sprintf("... and %d more", len(fresh)-10))
+			}
+			c.alerts.Send(ctx, alert.Alert{
+				Source:   "sequence",
+				Severity: alert.SeverityWarning,
+				Title:    fmt.Sprintf("%d new gaps in unit row sequences", len(fresh)),
+				Message:  strings.Join(fresh, "\n"),
+				Time:     start,
+			})
+		}
+	}
+	return report, nil
+}
+
+// requestReexport создаёт запросы повторной выгрузки пропусков отчёта,
+// закрывает устаревшие и отправляет запросы, время которых наступило.
+// Ошибки попадают в Report.Reexport.Errors.
+func (c *Checker) requestReexport(ctx context.Context, report *Report) {
+	cycle := report.Reexport
+	for _, unitReport := range report.Problems {
+		created, err := c.reexport.Request(ctx, unitReport.UnitGuid, unitReport.Gaps)
+		cycle.Created += created
+		if err != nil {
+			cycle.Errors = append(cycle.Errors, err.Error())
+		}
+	}
+	expired, err := c.reexport.Expire(ctx, report.Since)
+	cycle.Expired = expired
+	if err != nil {
+		cycle.Errors = append(cycle.Errors, err.Error())
+	}
+	sent, err := c.reexport.SendDue(ctx)
+	cycle.Sent = sent
+	if err != nil {
+		cycle.Errors = append(cycle.Errors, err.Error())
+	}
+
+	if cycle.Created+cycle.Sent+cycle.Fulfilled > 0 || cycle.Expired > 0 {
+		slog.Info("🔁 Re-export cycle", "component", "sequence", "created", cycle.Created, "sent", cycle.Sent, "fulfilled", cycle.Fulfilled, "expired", cycle.Expired)
+	}
+	if len(cycle.Errors) > 0 {
+		slog.Warn("⚠️ Re-export finished with errors", "component", "sequence", "count", len(cycle.Errors), "errors", cycle.Errors)
+	}
+}
@@ -0,0 +1,272 @@
+// internal/sequence/reexport.go
+package sequence
+
+import (
+	"TSVProcessingService/db/sqlc"
+	"TSVProcessingService/internal/subscription"
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Статусы запроса повторной выгрузки
+const (
+	ReexportPending   = "pending"   // ожидает отправки webhook
+	ReexportRequested = "requested" // партнёр принял запрос, ждём файл
+	ReexportFulfilled = "fulfilled" // пропуск закрыт
+	ReexportFailed    = "failed"    // попытки отправки исчерпаны
+	ReexportExpired   = "expired"   // пропуск вышел за период проверки незакрытым
+)
+
+// ReexportStatuses - допустимые статусы
+var ReexportStatuses = []string{ReexportPending, ReexportRequested, ReexportFulfilled, ReexportFailed, ReexportExpired}
+
+// EventReexportRequested - событие запроса повторной выгрузки
+const EventReexportRequested = "sequence.reexport_requested"
+
+// ReexportOptions - настройки webhook повторной выгрузки
+type ReexportOptions struct {
+	URL         string        // webhook партнёра
+	Secret      string        // подпись запросов (как у уведомлений подписок)
+	Timeout     time.Duration // таймаут одного запроса
+	MaxAttempts int           // попыток до статуса failed
+	RetryBase   time.Duration // пауза после первой неудачи (далее удваивается)
+	MaxBackoff  time.Duration // предел паузы между попытками
+	BatchSize   int           // запросов за одну проверку
+}
+
+// ReexportPayload - тело запроса повторной выгрузки
+type ReexportPayload struct {
+	Event      string    `json:"event"`
+	RequestID  int64     `json:"request_id"`
+	UnitGuid   uuid.UUID `json:"unit_guid"`
+	From       int64     `json:"from"`
+	To         int64     `json:"to"`
+	Missing    int64     `json:"missing"`
+	AfterFile  string    `json:"after_file"`
+	BeforeFile string    `json:"before_file"`
+}
+
+// Reexporter запрашивает у партнёра повторную выгрузку пропущенных
+// номеров n. Запросы хранятся в reexport_requests: на каждый новый
+// пропуск - один запрос (пропуск внутри уже запрошенного диапазона
+// повторно не запрашивается), неудачная отправка повторяется с
+// экспоненциальной паузой, запрос закрывается, когда пришедшие файлы
+// покрыли весь диапазон.
+type Reexporter struct {
+	queries *sqlc.Queries
+	client  *http.Client
+	opts    ReexportOptions
+	now     func() time.Time
+}
+
+// NewReexporter создаёт Reexporter
+func NewReexporter(queries *sqlc.Queries, opts ReexportOptions) *Reexporter {
+	if opts.Timeout <= 0 {
+		opts.Timeout = 10 * time.Second
+	}
+	if opts.MaxAttempts <= 0 {
+		opts.MaxAttempts = 5
+	}
+	if opts.RetryBase <= 0 {
+		opts.RetryBase = time.Hour
+	}
+	if opts.MaxBackoff <= 0 {
+		opts.MaxBackoff = 24 * time.Hour
+	}
+	if opts.BatchSize <= 0 {
+		opts.BatchSize = 100
+	}
+	return &Reexporter{
+		queries: queries,
+		client:  &http.Client{Timeout: opts.Timeout},
+		opts:    opts,
+		now:     time.Now,
+	}
+}
+
+// Open - незакрытые запросы по устройствам
+func (r *Reexporter) Open(ctx context.Context) (map[uuid.UUID][]sqlc.ReexportRequest, error) {
+	requests, err := r.queries.ListOpenReexportRequests(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list open re-export requests: %w", err)
+	}
+	open := make(map[uuid.UUID][]sqlc.ReexportRequest)
+	for _, req := range requests {
+		open[req.UnitGuid] = append(open[req.UnitGuid], req)
+	}
+	return open, nil
+}
+
+// Reconcile закрывает запросы устройства, диапазон которых покрыт
+// отрезками runs. Возвращает число закрытых запросов.
+func (r *Reexporter) Reconcile(ctx context.Context, requests []sqlc.ReexportRequest, runs []Run) (int, error) {
+	fulfilled := 0
+	for _, req := range requests {
+		run, ok := Covered(runs, req.SeqFrom, req.SeqTo)
+		if !ok {
+			continue
+		}
+		if err := r.queries.MarkReexportFulfilled(ctx, sqlc.MarkReexportFulfilledParams{
+			ID:              req.ID,
+			FulfilledFileID: sql.NullInt64{Int64: run.FileID, Valid: true},
+		}); err != nil {
+			return fulfilled, fmt.Errorf("failed to mark re-export request %d fulfilled: %w", req.ID, err)
+		}
+		slog.Info("✅ Re-export request fulfilled", "component", "sequence", "request", req.ID, "unit", req.UnitGuid, "seq_from", req.SeqFrom, "seq_to", req.SeqTo, "file", run.Filename)
+		fulfilled++
+	}
+	return fulfilled, nil
+}
+
+// Request создаёт запросы для пропусков устройства, ещё не покрытых
+// открытыми запросами. Возвращает число новых запросов.
+func (r *Reexporter) Request(ctx context.Context, unit uuid.UUID, gaps []Gap) (int, error) {
+	created := 0
+	for _, gap := range gaps {
+		covering, err := r.queries.CountOpenReexportRequestsCovering(ctx, sqlc.CountOpenReexportRequestsCoveringParams{
+			UnitGuid: unit,
+			SeqFrom:  gap.From,
+			SeqTo:    gap.To,
+		})
+		if err != nil {
+			return created, fmt.Errorf("failed to check re-export requests of unit %s: %w", unit, err)
+		}
+		if covering > 0 {
+			continue
+		}
+		n, err := r.queries.CreateReexportRequest(ctx, sqlc.CreateReexportRequestParams{
+			UnitGuid:      unit,
+			SeqFrom:       gap.From,
+			SeqTo:         gap.To,
+			AfterFile:     gap.AfterFile,
+			BeforeFile:    gap.BeforeFile,
+			NextAttemptAt: r.now(),
+		})
+		if err != nil {
+			return created, fmt.Errorf("failed to create re-export request for unit %s: %w", unit, err)
+		}
+		created += int(n)
+	}
+	return created, nil
+}
+
+// Expire закрывает незакрытые запросы, созданные до before (их пропуск
+// вышел за период проверки)
+func (r *Reexporter) Expire(ctx context.Context, before time.Time) (int64, error) {
+	expired, err := r.queries.ExpireReexportRequests(ctx, before)
+	if err != nil {
+		return 0, fmt.Errorf("failed to expire re-export requests: %w", err)
+	}
+	return expired, nil
+}
+
+// SendDue отправляет запросы, время попытки которых наступило.
+// Возвращает число принятых партнёром; неудачная отправка переносится
+// на следующую попытку и ошибкой не считается.
+func (r *Reexporter) SendDue(ctx context.Context) (int, error) {
+	due, err := r.queries.ListDueReexportRequests(ctx, sqlc.ListDueReexportRequestsParams{
+		NextAttemptAt: r.now(),
+		Limit:         int32(r.opts.BatchSize),
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to list due re-export requests: %w", err)
+	}
+	sent := 0
+	for _, req := range due {
+		if err := ctx.Err(); err != nil {
+			return sent, err
+		}
+		status, sendErr := r.send(ctx, req)
+		responseStatus := sql.NullInt32{Int32: int32(status), Valid: status > 0}
+		if sendErr == nil {
+			if err := r.queries.MarkReexportRequested(ctx, sqlc.MarkReexportRequestedParams{
+				ID:             req.ID,
+				ResponseStatus: responseStatus,
+			}); err != nil {
+				return sent, fmt.Errorf("failed to mark re-export request %d requested: %w", req.ID, err)
+			}
+			sent++
+			continue
+		}
+		attempts := int(req.Attempts) + 1
+		next := ReexportPending
+		if attempts >= r.opts.MaxAttempts {
+			next = ReexportFailed
+			slog.Warn("⚠️ Giving up on re-export request", "component", "sequence", "request", req.ID, "attempts", attempts, "error", sendErr)
+		}
+		if err := r.queries.MarkReexportFailed(ctx, sqlc.MarkReexportFailedParams{
+			ID:             req.ID,
+			Status:         next,
+			ResponseStatus: responseStatus,
+			LastError:      sql.NullString{String: sendErr.Error(), Valid: true},
+			NextAttemptAt:  r.now().Add(r.backoff(attempts)),
+		}); err != nil {
+			return sent, fmt.Errorf("failed to record re-export request %d attempt: %w", req.ID, err)
+		}
+	}
+	return sent, nil
+}
+
+// send отправляет подписанный запрос. Возвращает HTTP-статус ответа
+// (0 - ответа нет); успех - только 2xx.
+func (r *Reexporter) send(ctx context.Context, req sqlc.ReexportRequest) (int, error) {
+	body, err := json.Marshal(ReexportPayload{
+		Event:      EventReexportRequested,
+		RequestID:  req.ID,
+		UnitGuid:   req.UnitGuid,
+		From:       req.SeqFrom,
+		To:         req.SeqTo,
+		Missing:    req.SeqTo - req.SeqFrom + 1,
+		AfterFile:  req.AfterFile,
+		BeforeFile: req.BeforeFile,
+	})
+	if err != nil {
+		return 0, err
+	}
+	timestamp := r.now().Unix()
+
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, r.opts.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	httpReq.Header.Set(subscription.HeaderEvent, EventReexportRequested)
+	httpReq.Header.Set(subscription.HeaderDelivery, strconv.FormatInt(req.ID, 10))
+	httpReq.Header.Set(subscription.HeaderTimestamp, strconv.FormatInt(timestamp, 10))
+	httpReq.Header.Set(subscription.HeaderSignature, subscription.Sign(r.opts.Secret, timestamp, body))
+
+	resp, err := r.client.Do(httpReq)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		snippet, _ := io.ReadAll(io.LimitReader(resp.Body, 256))
+		return resp.StatusCode, fmt.Errorf("webhook returned %d: %s", resp.StatusCode, strings.TrimSpace(string(snippet)))
+	}
+	io.Copy(io.Discard, resp.Body)
+	return resp.StatusCode, nil
+}
+
+// backoff - пауза перед попыткой после attempts неудач
+func (r *Reexporter) backoff(attempts int) time.Duration {
+	wait := r.opts.RetryBase
+	for i := 1; i < attempts; i++ {
+		wait *= 2
+		if wait >= r.opts.MaxBackoff {
+			return r.opts.MaxBackoff
+		}
+	}
+	return wait
+}
@@ -0,0 +1,155 @@
+// internal/sequence/sequence.go
+package sequence
+
+import (
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Run - непрерывный отрезок номеров n строк устройства в одном файле
+type Run struct {
+	ID        int64     `json:"id"` // порядок поступления
+	FileID    int64     `json:"file_id"`
+	Filename  string    `json:"filename"`
+	First     int64     `json:"first"`
+	Last      int64     `json:"last"`
+	Rows      int32     `json:"rows"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Gap - номера n устройства, не пришедшие ни в одном файле
+type Gap struct {
+	From       int64  `json:"from"`
+	To         int64  `json:"to"`
+	Missing    int64  `json:"missing"`
+	AfterFile  string `json:"after_file"`  // файл с n=From-1
+	BeforeFile string `json:"before_file"` // файл с n=To+1
+}
+
+// OutOfOrder - отрезок, пришедший после файла с большими номерами n
+// (запоздавшая или повторная выгрузка)
+type OutOfOrder struct {
+	Filename  string `json:"filename"`
+	First     int64  `json:"first"`
+	Last      int64  `json:"last"`
+	After     int64  `json:"after"`      // наибольший n, полученный раньше
+	AfterFile string `json:"after_file"` // файл с этим n
+}
+
+// UnitReport - итог проверки последовательности n устройства
+type UnitReport struct {
+	UnitGuid        uuid.UUID    `json:"unit_guid"`
+	First           int64        `json:"first"`
+	Last            int64        `json:"last"`
+	Files           int          `json:"files"`
+	Rows            int64        `json:"rows"`
+	Missing         int64        `json:"missing"`
+	GapsTotal       int          `json:"gaps_total"`
+	Gaps            []Gap        `json:"gaps"` // первые MaxItems
+	OutOfOrderTotal int          `json:"out_of_order_total"`
+	OutOfOrder      []OutOfOrder `json:"out_of_order"` // первые MaxItems
+}
+
+// MaxItems - пропусков и нарушений порядка в отчёте по устройству
+const MaxItems = 100
+
+// Healthy - пропусков и нарушений порядка нет
+func (r UnitReport) Healthy() bool {
+	return r.GapsTotal == 0 && r.OutOfOrderTotal == 0
+}
+
+// Analyze проверяет последовательность n устройства по его отрезкам
+// (в порядке поступления). Пропуск - номера между отрезками, не
+// покрытые ни одним файлом; отрезок нарушает порядок, если начинается
+// не выше наибольшего n, полученного раньше. Номера до первого и после
+// последнего полученного n не проверяются.
+func Analyze(unit uuid.UUID, runs []Run) UnitReport {
+	report := UnitReport{UnitGuid: unit, Gaps: []Gap{}, OutOfOrder: []OutOfOrder{}}
+	if len(runs) == 0 {
+		return report
+	}
+
+	files := make(map[int64]bool)
+	var maxLast int64
+	var maxFile string
+	for i, run := range runs {
+		files[run.FileID] = true
+		report.Rows += int64(run.Rows)
+		if i > 0 && run.First <= maxLast {
+			report.OutOfOrderTotal++
+			if len(report.OutOfOrder) < MaxItems {
+				report.OutOfOrder = append(report.OutOfOrder, OutOfOrder{
+					Filename:  run.Filename,
+					First:     run.First,
+					Last:      run.Last,
+					After:     maxLast,
+					AfterFile: maxFile,
+				})
+			}
+		}
+		if i == 0 || run.Last > maxLast {
+			maxLast, maxFile = run.Last, run.Filename
+		}
+	}
+	report.Files = len(files)
+
+	sorted := make([]Run, len(runs))
+	copy(sorted, runs)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].First < sorted[j].First })
+
+	report.First = sorted[0].First
+	covered, coveredFile := sorted[0].Last, sorted[0].Filename
+	for _, run := range sorted[1:] {
+		if run.First > covered+1 {
+			gap := Gap{
+				From:       covered + 1,
+				To:         run.First - 1,
+				AfterFile:  coveredFile,
+				BeforeFile: run.Filename,
+			}
+			gap.Missing = gap.To - gap.From + 1
+			report.Missing += gap.Missing
+			report.GapsTotal++
+			if len(report.Gaps) < MaxItems {
+				report.Gaps = append(report.Gaps, gap)
+			}
+		}
+		if run.Last > covered {
+			covered, coveredFile = run.Last, run.Filename
+		}
+	}
+	report.Last = covered
+	return report
+}
+
+// Covered проверяет, что номера from..to покрыты отрезками runs, и
+// возвращает последний поступивший отрезок, пересекающий диапазон
+// (файл, закрывший пропуск).
+func Covered(runs []Run, from, to int64) (Run, bool) {
+	sorted := make([]Run, 0, len(runs))
+	var latest Run
+	for _, run := range runs {
+		if run.Last < from || run.First > to {
+			continue
+		}
+		sorted = append(sorted, run)
+		if run.ID > latest.ID {
+			latest = run
+		}
+	}
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].First < sorted[j].First })
+
+	next := from
+	for _, run := range sorted {
+		if run.First > next {
+			return Run{}, false
+		}
+		next = max(next, run.Last+1)
+		if next > to {
+			return latest, true
+		}
+	}
+	return Run{}, false
+}
@@ -0,0 +1,254 @@
+package sequence
+
+import (
+	"TSVProcessingService/db/sqlc"
+	"TSVProcessingService/internal/alert"
+	"TSVProcessingService/internal/clock"
+	"TSVProcessingService/internal/subscription"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	_ "modernc.org/sqlite"
+)
+
+func TestAnalyze(t *testing.T) {
+	unit := uuid.New()
+	report := Analyze(unit, []Run{
+		{ID: 1, FileID: 1, Filename: "a.tsv", First: 1, Last: 100, Rows: 100},
+		{ID: 2, FileID: 2, Filename: "b.tsv", First: 151, Last: 200, Rows: 50},
+		// Запоздавшая выгрузка закрывает часть пропуска
+		{ID: 3, FileID: 3, Filename: "late.tsv", First: 101, Last: 120, Rows: 20},
+		{ID: 4, FileID: 4, Filename: "c.tsv", First: 201, Last: 210, Rows: 10},
+		{ID: 5, FileID: 4, Filename: "c.tsv", First: 215, Last: 215, Rows: 1},
+	})
+
+	assert.Equal(t, int64(1), report.First)
+	assert.Equal(t, int64(215), report.Last)
+	assert.Equal(t, 4, report.Files)
+	assert.Equal(t, int64(181), report.Rows)
+	assert.Equal(t, []Gap{
+		{From: 121, To: 150, Missing: 30, AfterFile: "late.tsv", BeforeFile: "b.tsv"},
+		{From: 211, To: 214, Missing: 4, AfterFile: "c.tsv", BeforeFile: "c.tsv"},
+	}, report.Gaps)
+	assert.Equal(t, int64(34), report.Missing)
+	assert.Equal(t, []OutOfOrder{
+		{Filename: "late.tsv", First: 101, Last: 120, After: 200, AfterFile: "b.tsv"},
+	}, report.OutOfOrder)
+	assert.False(t, report.Healthy())
+
+	assert.True(t, Analyze(unit, nil).Healthy())
+	assert.True(t, Analyze(unit, []Run{
+		{ID: 1, FileID: 1, First: 1, Last: 5, Rows: 5},
+		{ID: 2, FileID: 2, First: 6, Last: 9, Rows: 4},
+	}).Healthy())
+}
+
+func TestCovered(t *testing.T) {
+	runs := []Run{
+		{ID: 1, FileID: 1, First: 1, Last: 10},
+		{ID: 2, FileID: 2, First: 21, Last: 30},
+		{ID: 3, FileID: 3, First: 11, Last: 15},
+	}
+	_, ok := Covered(runs, 11, 20)
+	assert.False(t, ok)
+
+	run, ok := Covered(append(runs, Run{ID: 4, FileID: 4, First: 14, Last: 22}), 11, 20)
+	assert.True(t, ok)
+	assert.Equal(t, int64(4), run.FileID)
+}
+
+type recordingNotifier struct {
+	alerts []alert.Alert
+}
+
+func (n *recordingNotifier) Name() string { return "test" }
+
+func (n *recordingNotifier) Notify(ctx context.Context, a alert.Alert) error {
+	n.alerts = append(n.alerts, a)
+	return nil
+}
+
+func setupSequenceDB(t *testing.T) *sql.DB {
+	db, err := sql.Open("sqlite", ":memory:")
+	require.NoError(t, err)
+	db.SetMaxOpenConns(1)
+	t.Cleanup(func() { db.Close() })
+
+	_, err = db.Exec(`
+	CREATE TABLE files (
+		id INTEGER PRIMARY KEY,
+		filename TEXT NOT NULL,
+		superseded_by INTEGER
+	);
+	CREATE TABLE unit_sequence_runs (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		file_id INTEGER NOT NULL,
+		unit_guid TEXT NOT NULL,
+		seq_first INTEGER NOT NULL,
+		seq_last INTEGER NOT NULL,
+		rows INTEGER NOT NULL,
+		created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+		updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		change_seq INTEGER NOT NULL DEFAULT 0
+	);
+	CREATE TABLE reexport_requests (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		unit_guid TEXT NOT NULL,
+		seq_from INTEGER NOT NULL,
+		seq_to INTEGER NOT NULL,
+		after_file TEXT NOT NULL,
+		before_file TEXT NOT NULL,
+		status TEXT NOT NULL DEFAULT 'pending',
+		attempts INTEGER NOT NULL DEFAULT 0,
+		last_error TEXT,
+		response_status INTEGER,
+		next_attempt_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+		requested_at DATETIME,
+		fulfilled_at DATETIME,
+		fulfilled_file_id INTEGER,
+		created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+		updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		change_seq INTEGER NOT NULL DEFAULT 0,
+		UNIQUE (unit_guid, seq_from, seq_to)
+	);`)
+	require.NoError(t, err)
+	return db
+}
+
+func TestChecker_AlertsOnNewGaps(t *testing.T) {
+	db := setupSequenceDB(t)
+	ctx := context.Background()
+	queries := sqlc.New(db)
+
+	now := time.Now().UTC()
+	unitA, unitB := uuid.New(), uuid.New()
+	_, err := db.Exec(`INSERT INTO files (id, filename, superseded_by) VALUES (1, 'a.tsv', NULL), (2, 'b.tsv', NULL), (3, 'old.tsv', 2)`)
+	require.NoError(t, err)
+	addRun := func(fileID int64, unit uuid.UUID, first, last int64) {
+		require.NoError(t, queries.CreateUnitSequenceRun(ctx, sqlc.CreateUnitSequenceRunParams{
+			FileID: fileID, UnitGuid: unit, SeqFirst: first, SeqLast: last, Rows: int32(last - first + 1),
+		}))
+	}
+	addRun(1, unitA, 1, 10)
+	addRun(2, unitA, 21, 30)
+	addRun(1, unitB, 1, 10)
+	addRun(3, unitB, 20, 30) // заменённый файл не учитывается
+
+	notifier := &recordingNotifier{}
+	c := NewChecker(queries, alert.NewDispatcher(notifier), Options{
+		Interval: time.Hour,
+		Lookback: 24 * time.Hour,
+		Clock:    clock.NewFake(now),
+	})
+	_, ok := c.Report()
+	assert.False(t, ok)
+
+	report, err := c.Check(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 2, report.Units)
+	assert.Equal(t, 1, report.UnitsWithGaps)
+	assert.Equal(t, int64(10), report.Missing)
+	require.Len(t, report.Problems, 1)
+	assert.Equal(t, unitA, report.Problems[0].UnitGuid)
+	require.Len(t, notifier.alerts, 1)
+	assert.Contains(t, notifier.alerts[0].Message, "n 11-20 (10 rows) between a.tsv and b.tsv")
+
+	// Тот же пропуск повторно не оповещается
+	_, err = c.Check(ctx)
+	require.NoError(t, err)
+	assert.Len(t, notifier.alerts, 1)
+
+	cached, ok := c.Report()
+	assert.True(t, ok)
+	assert.Equal(t, int64(10), cached.Missing)
+
+	unitReport, err := c.Unit(ctx, unitB, now.Add(-time.Hour))
+	require.NoError(t, err)
+	assert.True(t, unitReport.Healthy())
+	assert.Equal(t, int64(10), unitReport.Last)
+}
+
+func TestChecker_RequestsReexportAndReconciles(t *testing.T) {
+	db := setupSequenceDB(t)
+	ctx := context.Background()
+	queries := sqlc.New(db)
+
+	var payloads []ReexportPayload
+	failNext := true
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		timestamp, _ := strconv.ParseInt(r.Header.Get(subscription.HeaderTimestamp), 10, 64)
+		assert.Equal(t, subscription.Sign("secret", timestamp, body), r.Header.Get(subscription.HeaderSignature))
+		if failNext {
+			failNext = false
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		var p ReexportPayload
+		require.NoError(t, json.Unmarshal(body, &p))
+		payloads = append(payloads, p)
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer server.Close()
+
+	unit := uuid.New()
+	_, err := db.Exec(`INSERT INTO files (id, filename) VALUES (1, 'a.tsv'), (2, 'b.tsv'), (3, 'backfill.tsv')`)
+	require.NoError(t, err)
+	addRun := func(fileID int64, first, last int64) {
+		require.NoError(t, queries.CreateUnitSequenceRun(ctx, sqlc.CreateUnitSequenceRunParams{
+			FileID: fileID, UnitGuid: unit, SeqFirst: first, SeqLast: last, Rows: int32(last - first + 1),
+		}))
+	}
+	addRun(1, 1, 10)
+	addRun(2, 21, 30)
+
+	c := NewChecker(queries, nil, Options{Interval: time.Hour, Lookback: 24 * time.Hour})
+	reexporter := NewReexporter(queries, ReexportOptions{URL: server.URL, Secret: "secret", RetryBase: time.Nanosecond})
+	c.SetReexporter(reexporter)
+
+	// Первая отправка неудачна - запрос остаётся pending
+	report, err := c.Check(ctx)
+	require.NoError(t, err)
+	require.NotNil(t, report.Reexport)
+	assert.Equal(t, 1, report.Reexport.Created)
+	assert.Equal(t, 0, report.Reexport.Sent)
+
+	report, err = c.Check(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 0, report.Reexport.Created) // пропуск уже запрошен
+	assert.Equal(t, 1, report.Reexport.Sent)
+	require.Len(t, payloads, 1)
+	assert.Equal(t, ReexportPayload{
+		Event: EventReexportRequested, RequestID: payloads[0].RequestID, UnitGuid: unit,
+		From: 11, To: 20, Missing: 10, AfterFile: "a.tsv", BeforeFile: "b.tsv",
+	}, payloads[0])
+
+	requests, err := queries.ListReexportRequests(ctx, sqlc.ListReexportRequestsParams{Limit: 10})
+	require.NoError(t, err)
+	require.Len(t, requests, 1)
+	assert.Equal(t, ReexportRequested, requests[0].Status)
+	assert.Equal(t, int32(2), requests[0].Attempts)
+	assert.Equal(t, int32(http.StatusAccepted), requests[0].ResponseStatus.Int32)
+
+	// Пришёл файл с недостающими строками
+	addRun(3, 11, 20)
+	report, err = c.Check(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, report.Reexport.Fulfilled)
+	assert.Equal(t, 0, report.UnitsWithGaps)
+
+	requests, err = queries.ListReexportRequests(ctx, sqlc.ListReexportRequestsParams{Status: ReexportFulfilled, Limit: 10})
+	require.NoError(t, err)
+	require.Len(t, requests, 1)
+	assert.Equal(t, int64(3), requests[0].FulfilledFileID.Int64)
+}
@@ -0,0 +1,90 @@
+// internal/signedurl/signer.go
+package signedurl
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// Параметры подписи в строке запроса
+const (
+	ParamExpires   = "expires"   // срок действия, Unix-время в секундах
+	ParamSignature = "signature" // base64url(HMAC-SHA256(key, "<path>\n<expires>"))
+)
+
+var (
+	ErrMissing = errors.New("download signature is missing")
+	ErrInvalid = errors.New("download signature is invalid")
+	ErrExpired = errors.New("download link has expired")
+)
+
+// Signer - подпись ссылок на скачивание: ссылка действует до expires
+// и только для пути, для которого выдана, ключ API в ней не передаётся.
+type Signer struct {
+	key []byte
+	ttl time.Duration
+	now func() time.Time
+}
+
+// NewSigner создаёт подпись ссылок с ключом key и сроком действия ttl.
+func NewSigner(key []byte, ttl time.Duration) *Signer {
+	return &Signer{key: key, ttl: ttl, now: time.Now}
+}
+
+// GenerateKey - случайный ключ подписи (ссылки действуют до перезапуска
+// и только на этом экземпляре сервиса)
+func GenerateKey() ([]byte, error) {
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		return nil, err
+	}
+	return key, nil
+}
+
+// TTL - срок действия выдаваемых ссылок
+func (s *Signer) TTL() time.Duration {
+	return s.ttl
+}
+
+// Sign возвращает path с параметрами подписи и момент окончания действия.
+// path - путь без строки запроса, в том виде, в каком он придёт в запросе.
+func (s *Signer) Sign(path string) (string, time.Time) {
+	expires := s.now().Add(s.ttl).Truncate(time.Second)
+	query := url.Values{}
+	query.Set(ParamExpires, strconv.FormatInt(expires.Unix(), 10))
+	query.Set(ParamSignature, s.signature(path, expires.Unix()))
+	return path + "?" + query.Encode(), expires
+}
+
+// Verify проверяет подпись запроса к path.
+func (s *Signer) Verify(path string, query url.Values) error {
+	expiresParam, signature := query.Get(ParamExpires), query.Get(ParamSignature)
+	if expiresParam == "" && signature == "" {
+		return ErrMissing
+	}
+	expires, err := strconv.ParseInt(expiresParam, 10, 64)
+	if err != nil {
+		return ErrInvalid
+	}
+	// Подпись проверяется до срока: просроченной считается только подлинная ссылка
+	if !hmac.Equal([]byte(signature), []byte(s.signature(path, expires))) {
+		return ErrInvalid
+	}
+	if s.now().Unix() > expires {
+		return ErrExpired
+	}
+	return nil
+}
+
+// signature - подпись пути и срока действия
+func (s *Signer) signature(path string, expires int64) string {
+	mac := hmac.New(sha256.New, s.key)
+	mac.Write([]byte(path + "\n" + strconv.FormatInt(expires, 10)))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,40 @@
+package signedurl
+
+import (
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSigner_SignAndVerify(t *testing.T) {
+	s := NewSigner([]byte("secret"), 10*time.Minute)
+	now := time.Date(2026, 3, 2, 10, 0, 0, 0, time.UTC)
+	s.now = func() time.Time { return now }
+
+	path := "/api/v1/reports/0b7f1c2e-8d6a-4a63-9d41-3c5f0a7e2b11/42/download"
+	signed, expires := s.Sign(path)
+	assert.Equal(t, now.Add(10*time.Minute), expires)
+
+	u, err := url.Parse(signed)
+	require.NoError(t, err)
+	assert.Equal(t, path, u.Path)
+	assert.NoError(t, s.Verify(u.Path, u.Query()))
+
+	// Подпись не переносится на другой путь и другой срок
+	assert.ErrorIs(t, s.Verify(strings.Replace(path, "/42/", "/43/", 1), u.Query()), ErrInvalid)
+	extended := u.Query()
+	extended.Set(ParamExpires, "9999999999")
+	assert.ErrorIs(t, s.Verify(path, extended), ErrInvalid)
+	assert.ErrorIs(t, s.Verify(path, url.Values{}), ErrMissing)
+
+	// Другой ключ - недействительная подпись
+	other := NewSigner([]byte("other"), 10*time.Minute)
+	assert.ErrorIs(t, other.Verify(path, u.Query()), ErrInvalid)
+
+	now = now.Add(11 * time.Minute)
+	assert.ErrorIs(t, s.Verify(path, u.Query()), ErrExpired)
+}
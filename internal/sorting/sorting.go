@@ -0,0 +1,101 @@
+// internal/sorting/sorting.go
+package sorting
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// MaxFields - максимальное количество полей в параметре sort
+const MaxFields = 3
+
+// AllowList - разрешённые для сортировки поля endpoint'а:
+// имя в параметре sort -> колонка в SQL.
+type AllowList map[string]string
+
+// Field - поле сортировки
+type Field struct {
+	Name string
+	Desc bool
+}
+
+// Parse разбирает параметр вида "field1,-field2". Префикс "-" задаёт
+// сортировку по убыванию, "+" или его отсутствие - по возрастанию.
+func Parse(param string, allowed AllowList) ([]Field, error) {
+	param = strings.TrimSpace(param)
+	if param == "" {
+		return nil, nil
+	}
+
+	parts := strings.Split(param, ",")
+	if len(parts) > MaxFields {
+		return nil, fmt.Errorf("too many sort fields: %d (max %d)", len(parts), MaxFields)
+	}
+
+	fields := make([]Field, 0, len(parts))
+	seen := make(map[string]bool)
+	for _, part := range parts {
+		part = strings.TrimSpace(part)
+		field := Field{Name: part}
+		switch {
+		case strings.HasPrefix(part, "-"):
+			field = Field{Name: part[1:], Desc: true}
+		case strings.HasPrefix(part, "+"):
+			field = Field{Name: part[1:]}
+		}
+
+		if _, ok := allowed[field.Name]; !ok {
+			return nil, fmt.Errorf("unsupported sort field %q (allowed: %s)", field.Name, allowed.names())
+		}
+		if seen[field.Name] {
+			return nil, fmt.Errorf("duplicate sort field %q", field.Name)
+		}
+		seen[field.Name] = true
+		fields = append(fields, field)
+	}
+	return fields, nil
+}
+
+// OrderBy собирает ORDER BY из разобранных полей. Колонки берутся только
+// из allow-list, поэтому пользовательский ввод в SQL не попадает.
+// Если поля не заданы, используется defaultOrder. Последним всегда
+// добавляется id, чтобы порядок страниц был стабильным.
+func OrderBy(fields []Field, allowed AllowList, defaultOrder string) string {
+	if len(fields) == 0 {
+		return "ORDER BY " + defaultOrder + ", id DESC"
+	}
+
+	clauses := make([]string, 0, len(fields)+1)
+	for _, field := range fields {
+		direction := "ASC"
+		if field.Desc {
+			direction = "DESC"
+		}
+		clauses = append(clauses, allowed[field.Name]+" "+direction)
+	}
+	clauses = append(clauses, "id DESC")
+	return "ORDER BY " + strings.Join(clauses, ", ")
+}
+
+// Key - нормализованное представление сортировки (для ключей кэша)
+func Key(fields []Field) string {
+	parts := make([]string, 0, len(fields))
+	for _, field := range fields {
+		if field.Desc {
+			parts = append(parts, "-"+field.Name)
+		} else {
+			parts = append(parts, field.Name)
+		}
+	}
+	return strings.Join(parts, ",")
+}
+
+func (a AllowList) names() string {
+	names := make([]string, 0, len(a))
+	for name := range a {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return strings.Join(names, ", ")
+}
@@ -0,0 +1,44 @@
+package sorting
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var testAllowList = AllowList{
+	"created_at": "created_at",
+	"level":      "level",
+	"class":      "class",
+}
+
+func TestParse(t *testing.T) {
+	fields, err := Parse("level, -created_at", testAllowList)
+	require.NoError(t, err)
+	assert.Equal(t, []Field{{Name: "level"}, {Name: "created_at", Desc: true}}, fields)
+
+	fields, err = Parse("", testAllowList)
+	require.NoError(t, err)
+	assert.Empty(t, fields)
+}
+
+func TestParse_Rejects(t *testing.T) {
+	_, err := Parse("id; DROP TABLE files", testAllowList)
+	assert.Error(t, err)
+
+	_, err = Parse("level,-level", testAllowList)
+	assert.Error(t, err)
+
+	_, err = Parse("level,class,created_at,level", testAllowList)
+	assert.Error(t, err)
+}
+
+func TestOrderBy(t *testing.T) {
+	fields, err := Parse("-level,class", testAllowList)
+	require.NoError(t, err)
+
+	assert.Equal(t, "ORDER BY level DESC, class ASC, id DESC", OrderBy(fields, testAllowList, "created_at DESC"))
+	assert.Equal(t, "ORDER BY created_at DESC, id DESC", OrderBy(nil, testAllowList, "created_at DESC"))
+	assert.Equal(t, "-level,class", Key(fields))
+}
@@ -0,0 +1,316 @@
+// internal/subscription/delivery.go
+package subscription
+
+import (
+	"TSVProcessingService/db/sqlc"
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Статусы доставки уведомления
+const (
+	StatusPending   = "pending"
+	StatusDelivered = "delivered"
+	StatusFailed    = "failed" // попытки исчерпаны
+)
+
+// EventReportCreated - событие о новом отчёте
+const EventReportCreated = "report.created"
+
+// Заголовки подписанного уведомления
+const (
+	HeaderEvent     = "X-TSV-Event"
+	HeaderDelivery  = "X-TSV-Delivery"
+	HeaderTimestamp = "X-TSV-Timestamp"
+	HeaderSignature = "X-TSV-Signature"
+)
+
+// Options - настройки доставки уведомлений
+type Options struct {
+	PublicURL   string        // внешний адрес API для ссылок на скачивание
+	Interval    time.Duration // период проверки очереди
+	Timeout     time.Duration // таймаут одного запроса к callback URL
+	MaxAttempts int           // попыток до статуса failed
+	RetryBase   time.Duration // пауза после первой неудачи (далее удваивается)
+	MaxBackoff  time.Duration // предел паузы между попытками
+	BatchSize   int           // доставок за один запрос к БД
+}
+
+// Notification - тело уведомления о новом отчёте
+type Notification struct {
+	Event          string     `json:"event"`
+	DeliveryID     int64      `json:"delivery_id"`
+	SubscriptionID int64      `json:"subscription_id"`
+	ReportID       int64      `json:"report_id"`
+	UnitGuid       uuid.UUID  `json:"unit_guid"`
+	ReportType     string     `json:"report_type,omitempty"`
+	ReportGroup    *uuid.UUID `json:"report_group,omitempty"`
+	Part           int32      `json:"part,omitempty"`
+	GeneratedAt    *time.Time `json:"generated_at,omitempty"`
+	DownloadURL    string     `json:"download_url"`
+}
+
+// Deliverer рассылает подписчикам уведомления о новых отчётах.
+// Очередь хранится в таблице report_deliveries: недоступный получатель
+// не теряет уведомления - они повторяются с экспоненциальной паузой
+// до MaxAttempts, статус каждой доставки виден через API.
+type Deliverer struct {
+	queries *sqlc.Queries
+	client  *http.Client
+	opts    Options
+	ctx     context.Context // отменяется Stop
+	cancel  context.CancelFunc
+	wake    chan struct{}
+	now     func() time.Time
+}
+
+// NewDeliverer создаёт рассылку уведомлений.
+func NewDeliverer(queries *sqlc.Queries, opts Options) *Deliverer {
+	if opts.Interval <= 0 {
+		opts.Interval = 10 * time.Second
+	}
+	if opts.Timeout <= 0 {
+		opts.Timeout = 10 * time.Second
+	}
+	if opts.MaxAttempts <= 0 {
+		opts.MaxAttempts = 8
+	}
+	if opts.RetryBase <= 0 {
+		opts.RetryBase = 30 * time.Second
+	}
+	if opts.MaxBackoff <= 0 {
+		opts.MaxBackoff = time.Hour
+	}
+	if opts.BatchSize <= 0 {
+		opts.BatchSize = 50
+	}
+	opts.PublicURL = strings.TrimRight(opts.PublicURL, "/")
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Deliverer{
+		queries: queries,
+		client:  &http.Client{Timeout: opts.Timeout},
+		opts:    opts,
+		ctx:     ctx,
+		cancel:  cancel,
+		wake:    make(chan struct{}, 1),
+		now:     time.Now,
+	}
+}
+
+// ReportCreated ставит в очередь уведомления для подписок устройства
+// отчёта и подписок на все устройства (processor.ReportListener).
+func (d *Deliverer) ReportCreated(ctx context.Context, report sqlc.Report) {
+	queued, err := d.queries.EnqueueReportDeliveries(ctx, sqlc.EnqueueReportDeliveriesParams{
+		ReportID:      report.ID,
+		NextAttemptAt: d.now(),
+		UnitGuid:      uuid.NullUUID{UUID: report.UnitGuid, Valid: true},
+	})
+	if err != nil {
+		slog.Error("❌ Failed to queue notifications", "component", "subscriptions", "report", report.ID, "error", err)
+		return
+	}
+	if queued > 0 {
+		d.Notify()
+	}
+}
+
+// Notify запускает доставку, не дожидаясь Interval
+func (d *Deliverer) Notify() {
+	select {
+	case d.wake <- struct{}{}:
+	default:
+	}
+}
+
+// Run доставляет уведомления каждые Interval (и по Notify) до вызова Stop.
+func (d *Deliverer) Run() {
+	ticker := time.NewTicker(d.opts.Interval)
+	defer ticker.Stop()
+	for {
+		if delivered, err := d.DeliverDue(d.ctx); err != nil {
+			if d.ctx.Err() == nil {
+				slog.Error("❌ Delivery failed", "component", "subscriptions", "error", err)
+			}
+		} else if delivered > 0 {
+			slog.Info("📨 Delivered report notifications", "component", "subscriptions", "delivered", delivered)
+		}
+
+		select {
+		case <-ticker.C:
+		case <-d.wake:
+		case <-d.ctx.Done():
+			slog.Info("Deliverer stopped", "component", "subscriptions")
+			return
+		}
+	}
+}
+
+// Stop останавливает Run
+func (d *Deliverer) Stop() {
+	d.cancel()
+}
+
+// DeliverDue отправляет уведомления, время попытки которых наступило.
+// Возвращает число успешных доставок; неудачная доставка переносится
+// на следующую попытку и ошибкой не считается.
+func (d *Deliverer) DeliverDue(ctx context.Context) (int, error) {
+	delivered := 0
+	for {
+		due, err := d.queries.ListDueReportDeliveries(ctx, sqlc.ListDueReportDeliveriesParams{
+			NextAttemptAt: d.now(),
+			Limit:         int32(d.opts.BatchSize),
+		})
+		if err != nil {
+			return delivered, fmt.Errorf("failed to list due deliveries: %w", err)
+		}
+		for _, item := range due {
+			if err := ctx.Err(); err != nil {
+				return delivered, err
+			}
+			ok, err := d.deliver(ctx, item)
+			if err != nil {
+				return delivered, err
+			}
+			if ok {
+				delivered++
+			}
+		}
+		if len(due) < d.opts.BatchSize {
+			return delivered, nil
+		}
+	}
+}
+
+// deliver отправляет одно уведомление и фиксирует результат попытки
+func (d *Deliverer) deliver(ctx context.Context, item sqlc.ListDueReportDeliveriesRow) (bool, error) {
+	status, sendErr := d.send(ctx, item)
+	responseStatus := sql.NullInt32{Int32: int32(status), Valid: status > 0}
+
+	if sendErr == nil {
+		if err := d.queries.MarkReportDeliveryDelivered(ctx, sqlc.MarkReportDeliveryDeliveredParams{
+			ID:             item.ID,
+			ResponseStatus: responseStatus,
+		}); err != nil {
+			return false, fmt.Errorf("failed to mark delivery %d delivered: %w", item.ID, err)
+		}
+		return true, nil
+	}
+
+	attempts := int(item.Attempts) + 1
+	next := StatusPending
+	if attempts >= d.opts.MaxAttempts {
+		next = StatusFailed
+		slog.Warn("⚠️ Giving up on delivery", "component", "subscriptions", "delivery", item.ID, "callback_url", item.CallbackUrl, "attempts", attempts, "error", sendErr)
+	}
+	if err := d.queries.MarkReportDeliveryFailed(ctx, sqlc.MarkReportDeliveryFailedParams{
+		ID:             item.ID,
+		Status:         next,
+		ResponseStatus: responseStatus,
+		LastError:      sql.NullString{String: sendErr.Error(), Valid: true},
+		NextAttemptAt:  d.now().Add(d.backoff(attempts)),
+	}); err != nil {
+		return false, fmt.Errorf("failed to record delivery %d attempt: %w", item.ID, err)
+	}
+	return false, nil
+}
+
+// send отправляет подписанное уведомление. Возвращает HTTP-статус
+// ответа (0 - ответа нет); успех - только 2xx.
+func (d *Deliverer) send(ctx context.Context, item sqlc.ListDueReportDeliveriesRow) (int, error) {
+	body, err := json.Marshal(d.notification(item))
+	if err != nil {
+		return 0, err
+	}
+	timestamp := d.now().Unix()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, item.CallbackUrl, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(HeaderEvent, EventReportCreated)
+	req.Header.Set(HeaderDelivery, strconv.FormatInt(item.ID, 10))
+	req.Header.Set(HeaderTimestamp, strconv.FormatInt(timestamp, 10))
+	req.Header.Set(HeaderSignature, Sign(item.Secret, timestamp, body))
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		snippet, _ := io.ReadAll(io.LimitReader(resp.Body, 256))
+		return resp.StatusCode, fmt.Errorf("callback returned %d: %s", resp.StatusCode, strings.TrimSpace(string(snippet)))
+	}
+	io.Copy(io.Discard, resp.Body)
+	return resp.StatusCode, nil
+}
+
+// notification - тело уведомления со ссылкой на скачивание отчёта
+func (d *Deliverer) notification(item sqlc.ListDueReportDeliveriesRow) Notification {
+	n := Notification{
+		Event:          EventReportCreated,
+		DeliveryID:     item.ID,
+		SubscriptionID: item.SubscriptionID,
+		ReportID:       item.ReportID,
+		UnitGuid:       item.UnitGuid,
+		ReportType:     item.ReportType.String,
+		Part:           item.Part.Int32,
+		DownloadURL: fmt.Sprintf("%s/api/v1/reports/%s/%d/download",
+			d.opts.PublicURL, item.UnitGuid, item.ReportID),
+	}
+	if item.ReportGroup.Valid {
+		n.ReportGroup = &item.ReportGroup.UUID
+	}
+	if item.GeneratedAt.Valid {
+		n.GeneratedAt = &item.GeneratedAt.Time
+	}
+	return n
+}
+
+// backoff - пауза перед попыткой после attempts неудач
+func (d *Deliverer) backoff(attempts int) time.Duration {
+	wait := d.opts.RetryBase
+	for i := 1; i < attempts; i++ {
+		wait *= 2
+		if wait >= d.opts.MaxBackoff {
+			return d.opts.MaxBackoff
+		}
+	}
+	return wait
+}
+
+// Sign - подпись уведомления: "sha256=" + hex(HMAC-SHA256(secret, "<timestamp>.<body>")).
+// Получатель проверяет её по заголовкам X-TSV-Timestamp и X-TSV-Signature
+// и отклоняет уведомления со старой отметкой времени (защита от повтора).
+func Sign(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// GenerateSecret - случайный секрет подписи для новой подписки
+func GenerateSecret() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
+}
@@ -0,0 +1,238 @@
+package subscription
+
+import (
+	"TSVProcessingService/db/sqlc"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	_ "modernc.org/sqlite"
+)
+
+func setupDeliveryDB(t *testing.T) *sql.DB {
+	db, err := sql.Open("sqlite", ":memory:")
+	require.NoError(t, err)
+	db.SetMaxOpenConns(1)
+	t.Cleanup(func() { db.Close() })
+
+	_, err = db.Exec(`
+	CREATE TABLE reports (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		unit_guid TEXT NOT NULL,
+		report_type TEXT,
+		file_path TEXT NOT NULL,
+		generated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		checksum TEXT,
+		report_group TEXT,
+		part INTEGER,
+		updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		change_seq INTEGER NOT NULL DEFAULT 0,
+		trace_id TEXT,
+		span_id TEXT
+	);
+	CREATE TABLE report_subscriptions (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		unit_guid TEXT,
+		callback_url TEXT NOT NULL,
+		secret TEXT NOT NULL,
+		active BOOLEAN NOT NULL DEFAULT 1,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		change_seq INTEGER NOT NULL DEFAULT 0
+	);
+	CREATE TABLE report_deliveries (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		subscription_id INTEGER NOT NULL,
+		report_id INTEGER NOT NULL,
+		status TEXT NOT NULL DEFAULT 'pending',
+		attempts INTEGER NOT NULL DEFAULT 0,
+		last_error TEXT,
+		response_status INTEGER,
+		next_attempt_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+		delivered_at DATETIME,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		change_seq INTEGER NOT NULL DEFAULT 0,
+		UNIQUE (subscription_id, report_id)
+	);`)
+	require.NoError(t, err)
+	return db
+}
+
+// fakeReceiver - получатель уведомлений, проверяющий подпись
+type fakeReceiver struct {
+	secret string
+	mu     sync.Mutex
+	fail   bool
+	got    []Notification
+}
+
+func (f *fakeReceiver) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.fail {
+		http.Error(w, "maintenance", http.StatusServiceUnavailable)
+		return
+	}
+	body, _ := io.ReadAll(r.Body)
+	timestamp, _ := strconv.ParseInt(r.Header.Get(HeaderTimestamp), 10, 64)
+	if r.Header.Get(HeaderSignature) != Sign(f.secret, timestamp, body) {
+		http.Error(w, "bad signature", http.StatusUnauthorized)
+		return
+	}
+	var n Notification
+	if err := json.Unmarshal(body, &n); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	f.got = append(f.got, n)
+}
+
+func newTestDeliverer(db *sql.DB, now *time.Time) *Deliverer {
+	d := NewDeliverer(sqlc.New(db), Options{
+		PublicURL:   "https://tsv.example.com/",
+		MaxAttempts: 3,
+		RetryBase:   time.Minute,
+	})
+	d.now = func() time.Time { return *now }
+	return d
+}
+
+func TestDeliverer_DeliversSignedNotificationsForMatchingSubscriptions(t *testing.T) {
+	db := setupDeliveryDB(t)
+	ctx := context.Background()
+	unit := uuid.MustParse("01749246-95f6-57db-b7c3-2ae0e8be671f")
+	other := uuid.MustParse("01749246-95f6-57db-b7c3-2ae0e8be6720")
+
+	receiver := &fakeReceiver{secret: "s3cret"}
+	server := httptest.NewServer(receiver)
+	defer server.Close()
+
+	queries := sqlc.New(db)
+	for _, guid := range []uuid.NullUUID{{UUID: unit, Valid: true}, {}, {UUID: other, Valid: true}} {
+		_, err := queries.CreateReportSubscription(ctx, sqlc.CreateReportSubscriptionParams{
+			UnitGuid: guid, CallbackUrl: server.URL, Secret: "s3cret",
+		})
+		require.NoError(t, err)
+	}
+	report, err := queries.CreateReport(ctx, sqlc.CreateReportParams{
+		UnitGuid: unit, FilePath: "/reports/r.pdf", Part: sql.NullInt32{Int32: 1, Valid: true},
+	})
+	require.NoError(t, err)
+
+	now := time.Date(2026, 3, 2, 10, 0, 0, 0, time.UTC)
+	d := newTestDeliverer(db, &now)
+	d.ReportCreated(ctx, report)
+	d.ReportCreated(ctx, report) // повтор не создаёт вторую доставку
+
+	delivered, err := d.DeliverDue(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 2, delivered) // подписка устройства и подписка на все устройства
+
+	require.Len(t, receiver.got, 2)
+	n := receiver.got[0]
+	assert.Equal(t, EventReportCreated, n.Event)
+	assert.Equal(t, report.ID, n.ReportID)
+	assert.Equal(t, unit, n.UnitGuid)
+	assert.Equal(t, int32(1), n.Part)
+	assert.Equal(t, "https://tsv.example.com/api/v1/reports/"+unit.String()+"/"+strconv.FormatInt(report.ID, 10)+"/download",
+		n.DownloadURL)
+
+	deliveries, err := queries.ListReportDeliveriesBySubscription(ctx, sqlc.ListReportDeliveriesBySubscriptionParams{
+		SubscriptionID: 1, Limit: 10,
+	})
+	require.NoError(t, err)
+	require.Len(t, deliveries, 1)
+	assert.Equal(t, StatusDelivered, deliveries[0].Status)
+	assert.Equal(t, int32(http.StatusOK), deliveries[0].ResponseStatus.Int32)
+
+	// Доставленные уведомления не отправляются повторно
+	delivered, err = d.DeliverDue(ctx)
+	require.NoError(t, err)
+	assert.Zero(t, delivered)
+}
+
+func TestDeliverer_RetriesWithBackoffThenFails(t *testing.T) {
+	db := setupDeliveryDB(t)
+	ctx := context.Background()
+	unit := uuid.MustParse("01749246-95f6-57db-b7c3-2ae0e8be671f")
+
+	receiver := &fakeReceiver{secret: "s3cret", fail: true}
+	server := httptest.NewServer(receiver)
+	defer server.Close()
+
+	queries := sqlc.New(db)
+	_, err := queries.CreateReportSubscription(ctx, sqlc.CreateReportSubscriptionParams{
+		UnitGuid: uuid.NullUUID{UUID: unit, Valid: true}, CallbackUrl: server.URL, Secret: "s3cret",
+	})
+	require.NoError(t, err)
+	report, err := queries.CreateReport(ctx, sqlc.CreateReportParams{UnitGuid: unit, FilePath: "/reports/r.pdf"})
+	require.NoError(t, err)
+
+	now := time.Date(2026, 3, 2, 10, 0, 0, 0, time.UTC)
+	d := newTestDeliverer(db, &now)
+	d.ReportCreated(ctx, report)
+
+	list := func() sqlc.ReportDelivery {
+		deliveries, err := queries.ListReportDeliveriesBySubscription(ctx, sqlc.ListReportDeliveriesBySubscriptionParams{
+			SubscriptionID: 1, Limit: 10,
+		})
+		require.NoError(t, err)
+		require.Len(t, deliveries, 1)
+		return deliveries[0]
+	}
+
+	// Первая неудача - повтор через RetryBase
+	_, err = d.DeliverDue(ctx)
+	require.NoError(t, err)
+	delivery := list()
+	assert.Equal(t, StatusPending, delivery.Status)
+	assert.Equal(t, int32(1), delivery.Attempts)
+	assert.Equal(t, int32(http.StatusServiceUnavailable), delivery.ResponseStatus.Int32)
+	assert.Contains(t, delivery.LastError.String, "503")
+	assert.True(t, delivery.NextAttemptAt.Equal(now.Add(time.Minute)))
+
+	// До времени повтора доставка не выполняется
+	_, err = d.DeliverDue(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, int32(1), list().Attempts)
+
+	// Вторая неудача - пауза удваивается
+	now = now.Add(time.Minute)
+	_, err = d.DeliverDue(ctx)
+	require.NoError(t, err)
+	delivery = list()
+	assert.Equal(t, int32(2), delivery.Attempts)
+	assert.True(t, delivery.NextAttemptAt.Equal(now.Add(2*time.Minute)))
+
+	// Попытки исчерпаны
+	now = now.Add(2 * time.Minute)
+	_, err = d.DeliverDue(ctx)
+	require.NoError(t, err)
+	delivery = list()
+	assert.Equal(t, StatusFailed, delivery.Status)
+	assert.Equal(t, int32(3), delivery.Attempts)
+
+	now = now.Add(time.Hour)
+	receiver.fail = false
+	delivered, err := d.DeliverDue(ctx)
+	require.NoError(t, err)
+	assert.Zero(t, delivered)
+}
+
+func TestSign(t *testing.T) {
+	sig := Sign("secret", 1700000000, []byte(`{"event":"report.created"}`))
+	assert.Regexp(t, `^sha256=[0-9a-f]{64}$`, sig)
+	assert.NotEqual(t, sig, Sign("other", 1700000000, []byte(`{"event":"report.created"}`)))
+	assert.NotEqual(t, sig, Sign("secret", 1700000001, []byte(`{"event":"report.created"}`)))
+}
@@ -0,0 +1,220 @@
+// internal/supervisor/supervisor.go
+package supervisor
+
+import (
+	"fmt"
+	"log/slog"
+	"runtime/debug"
+	"sort"
+	"sync"
+	"time"
+)
+
+// RunFunc - тело компонента. beat вызывается компонентом как heartbeat.
+// Возврат nil означает штатное завершение (перезапуск не нужен),
+// ошибка или panic - аварийное, компонент перезапускается с backoff.
+type RunFunc func(beat func()) error
+
+// ComponentStats - состояние компонента для метрик
+type ComponentStats struct {
+	Name          string    `json:"name"`
+	Running       bool      `json:"running"`
+	Restarts      int       `json:"restarts"`
+	LastHeartbeat time.Time `json:"last_heartbeat"`
+	Stalled       bool      `json:"stalled"`
+	LastError     string    `json:"last_error,omitempty"`
+}
+
+// component - наблюдаемая горутина
+type component struct {
+	name             string
+	run              RunFunc
+	heartbeatTimeout time.Duration // 0 - без контроля зависаний
+
+	running   bool
+	restarts  int
+	lastBeat  time.Time
+	stalled   bool
+	lastError string
+}
+
+// Supervisor следит за внутренними горутинами (watcher, воркеры):
+// перезапускает упавшие с экспоненциальным backoff и отмечает
+// зависшие - те, что дольше heartbeatTimeout не присылали heartbeat.
+// Зависшую горутину в Go нельзя прервать, поэтому она только
+// попадает в метрики и оповещения.
+type Supervisor struct {
+	mu         sync.Mutex
+	components map[string]*component
+	minBackoff time.Duration
+	maxBackoff time.Duration
+	stopping   chan struct{}
+	stopOnce   sync.Once
+	now        func() time.Time
+}
+
+// New создаёт супервизор с границами задержки перезапуска
+func New(minBackoff, maxBackoff time.Duration) *Supervisor {
+	if minBackoff <= 0 {
+		minBackoff = time.Second
+	}
+	if maxBackoff < minBackoff {
+		maxBackoff = minBackoff
+	}
+	return &Supervisor{
+		components: make(map[string]*component),
+		minBackoff: minBackoff,
+		maxBackoff: maxBackoff,
+		stopping:   make(chan struct{}),
+		now:        time.Now,
+	}
+}
+
+// Go запускает компонент под наблюдением. Возвращаемый канал
+// закрывается, когда компонент завершился штатно или супервизор
+// остановлен.
+func (s *Supervisor) Go(name string, heartbeatTimeout time.Duration, run RunFunc) <-chan struct{} {
+	c := &component{name: name, run: run, heartbeatTimeout: heartbeatTimeout}
+
+	s.mu.Lock()
+	s.components[name] = c
+	s.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		s.supervise(c)
+	}()
+	return done
+}
+
+// Stop прекращает перезапуски. Работающие компоненты завершаются
+// своими штатными механизмами (закрытие очереди, Watcher.Stop).
+func (s *Supervisor) Stop() {
+	s.stopOnce.Do(func() { close(s.stopping) })
+}
+
+// Stats возвращает состояние всех компонентов (по имени)
+func (s *Supervisor) Stats() []ComponentStats {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stats := make([]ComponentStats, 0, len(s.components))
+	for _, c := range s.components {
+		stats = append(stats, ComponentStats{
+			Name:          c.name,
+			Running:       c.running,
+			Restarts:      c.restarts,
+			LastHeartbeat: c.lastBeat,
+			Stalled:       c.stalled,
+			LastError:     c.lastError,
+		})
+	}
+	sort.Slice(stats, func(i, j int) bool { return stats[i].Name < stats[j].Name })
+	return stats
+}
+
+// TotalRestarts - суммарное количество перезапусков
+func (s *Supervisor) TotalRestarts() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	total := 0
+	for _, c := range s.components {
+		total += c.restarts
+	}
+	return total
+}
+
+// CheckStalls отмечает зависшие компоненты и возвращает имена тех,
+// что зависли с момента предыдущей проверки.
+func (s *Supervisor) CheckStalls() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	var newlyStalled []string
+	for _, c := range s.components {
+		stalled := c.running && c.heartbeatTimeout > 0 && now.Sub(c.lastBeat) > c.heartbeatTimeout
+		if stalled && !c.stalled {
+			newlyStalled = append(newlyStalled, c.name)
+		}
+		c.stalled = stalled
+	}
+	sort.Strings(newlyStalled)
+	return newlyStalled
+}
+
+// supervise выполняет компонент и перезапускает его после аварий
+func (s *Supervisor) supervise(c *component) {
+	backoff := s.minBackoff
+	for {
+		started := s.now()
+		s.setRunning(c, true)
+		err := s.runOnce(c)
+		s.setRunning(c, false)
+
+		if err == nil {
+			return
+		}
+
+		s.mu.Lock()
+		c.lastError = err.Error()
+		s.mu.Unlock()
+
+		select {
+		case <-s.stopping:
+			return
+		default:
+		}
+
+		// Долго проработавший компонент начинает backoff заново
+		if s.now().Sub(started) > s.maxBackoff {
+			backoff = s.minBackoff
+		}
+		slog.Error("❌ Component died", "component", "supervisor", "name", c.name, "error", err, "backoff", backoff)
+
+		select {
+		case <-time.After(backoff):
+		case <-s.stopping:
+			return
+		}
+
+		s.mu.Lock()
+		c.restarts++
+		s.mu.Unlock()
+		slog.Info("🔄 Restarting component", "component", "supervisor", "name", c.name, "restarts", c.restarts)
+
+		backoff *= 2
+		if backoff > s.maxBackoff {
+			backoff = s.maxBackoff
+		}
+	}
+}
+
+// runOnce запускает компонент, превращая panic в ошибку
+func (s *Supervisor) runOnce(c *component) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v\n%s", r, debug.Stack())
+		}
+	}()
+	return c.run(func() { s.beat(c) })
+}
+
+func (s *Supervisor) beat(c *component) {
+	s.mu.Lock()
+	c.lastBeat = s.now()
+	c.stalled = false
+	s.mu.Unlock()
+}
+
+func (s *Supervisor) setRunning(c *component, running bool) {
+	s.mu.Lock()
+	c.running = running
+	if running {
+		c.lastBeat = s.now()
+		c.stalled = false
+	}
+	s.mu.Unlock()
+}
@@ -0,0 +1,94 @@
+package supervisor
+
+import (
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSupervisor_RestartsAfterPanic(t *testing.T) {
+	s := New(time.Millisecond, 5*time.Millisecond)
+	var runs int32
+
+	done := s.Go("worker-1", 0, func(beat func()) error {
+		if atomic.AddInt32(&runs, 1) < 3 {
+			panic("boom")
+		}
+		return nil
+	})
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("component was not restarted")
+	}
+
+	stats := s.Stats()
+	require.Len(t, stats, 1)
+	assert.Equal(t, 2, stats[0].Restarts)
+	assert.False(t, stats[0].Running)
+	assert.Contains(t, stats[0].LastError, "panic: boom")
+	assert.Equal(t, 2, s.TotalRestarts())
+}
+
+func TestSupervisor_CleanExitNotRestarted(t *testing.T) {
+	s := New(time.Millisecond, time.Millisecond)
+	var runs int32
+
+	<-s.Go("watcher", 0, func(beat func()) error {
+		atomic.AddInt32(&runs, 1)
+		return nil
+	})
+
+	assert.EqualValues(t, 1, runs)
+	assert.Equal(t, 0, s.TotalRestarts())
+}
+
+func TestSupervisor_StopPreventsRestart(t *testing.T) {
+	s := New(time.Hour, time.Hour)
+	s.Stop()
+
+	done := s.Go("worker-1", 0, func(beat func()) error {
+		return errors.New("failed")
+	})
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("supervisor restarted component after Stop")
+	}
+	assert.Equal(t, 0, s.TotalRestarts())
+}
+
+func TestSupervisor_CheckStalls(t *testing.T) {
+	s := New(time.Millisecond, time.Millisecond)
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	s.now = func() time.Time { return now }
+
+	release := make(chan struct{})
+	beats := make(chan func(), 1)
+	done := s.Go("watcher", time.Minute, func(beat func()) error {
+		beats <- beat
+		<-release
+		return nil
+	})
+	beat := <-beats
+
+	assert.Empty(t, s.CheckStalls())
+
+	now = now.Add(2 * time.Minute)
+	assert.Equal(t, []string{"watcher"}, s.CheckStalls())
+	// Повторно о том же зависании не сообщается
+	assert.Empty(t, s.CheckStalls())
+	assert.True(t, s.Stats()[0].Stalled)
+
+	beat()
+	assert.False(t, s.Stats()[0].Stalled)
+
+	close(release)
+	<-done
+}
@@ -0,0 +1,213 @@
+// internal/throttle/limiter.go
+package throttle
+
+import (
+	"TSVProcessingService/internal/config"
+	"TSVProcessingService/internal/watcher"
+	"log/slog"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Источник по умолчанию для файлов из watch-директории
+const DirectorySource = "directory"
+
+// bucket - token bucket для одного источника
+type bucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// Limiter ограничивает скорость передачи файлов воркерам отдельно для
+// каждого источника. Файлы, превысившие лимит, попадают в spillover-очередь
+// своего источника и догружаются по мере появления токенов, не блокируя
+// файлы других источников.
+type Limiter struct {
+	cfg     config.ThrottleConfig
+	out     chan watcher.FileInfo
+	mu      sync.Mutex
+	buckets map[string]*bucket
+	spill   map[string][]watcher.FileInfo
+	pending map[string]bool // пути файлов, уже лежащих в spillover
+	now     func() time.Time
+}
+
+// NewLimiter создаёт Limiter с выходной очередью заданного размера.
+func NewLimiter(cfg config.ThrottleConfig, queueSize int) *Limiter {
+	return &Limiter{
+		cfg:     cfg,
+		out:     make(chan watcher.FileInfo, queueSize),
+		buckets: make(map[string]*bucket),
+		spill:   make(map[string][]watcher.FileInfo),
+		pending: make(map[string]bool),
+		now:     time.Now,
+	}
+}
+
+// Output возвращает очередь файлов, прошедших ограничение скорости.
+func (l *Limiter) Output() <-chan watcher.FileInfo {
+	return l.out
+}
+
+// Run читает входную очередь до её закрытия и раздаёт файлы в Output.
+// После закрытия входной очереди (остановка или дренаж) отложенные файлы
+// передаются в Output без ограничения скорости: дренаж обрабатывает всю
+// принятую очередь, и Output закрывается только после них.
+func (l *Limiter) Run(in <-chan watcher.FileInfo) {
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case fileInfo, ok := <-in:
+			if !ok {
+				flushed := l.flush()
+				slog.Info("Input queue closed, limiter stopped", "component", "throttle", "flushed", flushed)
+				close(l.out)
+				return
+			}
+			l.admit(fileInfo)
+		case <-ticker.C:
+			l.drain()
+		}
+	}
+}
+
+// SourceOf определяет источник файла: явно заданный (API), tenant по
+// префиксу имени файла либо watch-директорию.
+func (l *Limiter) SourceOf(fileInfo watcher.FileInfo) string {
+	return ResolveSource(fileInfo, l.cfg.TenantSeparator)
+}
+
+// ResolveSource определяет источник файла по тем же правилам, что и
+// Limiter (используется и при выключенном ограничении скорости).
+func ResolveSource(fileInfo watcher.FileInfo, tenantSeparator string) string {
+	if fileInfo.Source != "" {
+		return fileInfo.Source
+	}
+	if tenantSeparator != "" {
+		if idx := strings.Index(fileInfo.Name, tenantSeparator); idx > 0 {
+			return "tenant:" + strings.ToLower(fileInfo.Name[:idx])
+		}
+	}
+	return DirectorySource
+}
+
+// SpilloverSizes возвращает количество отложенных файлов по источникам.
+func (l *Limiter) SpilloverSizes() map[string]int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	sizes := make(map[string]int, len(l.spill))
+	for source, queue := range l.spill {
+		if len(queue) > 0 {
+			sizes[source] = len(queue)
+		}
+	}
+	return sizes
+}
+
+// admit пропускает файл сразу либо откладывает его в spillover источника.
+func (l *Limiter) admit(fileInfo watcher.FileInfo) {
+	source := l.SourceOf(fileInfo)
+
+	l.mu.Lock()
+	if l.pending[fileInfo.Path] {
+		// Повторное сканирование нашло файл, который уже ждёт своей очереди
+		l.mu.Unlock()
+		return
+	}
+	if len(l.spill[source]) == 0 && l.take(source) {
+		l.mu.Unlock()
+		l.out <- fileInfo
+		return
+	}
+	if len(l.spill[source]) >= l.cfg.MaxSpillover {
+		l.mu.Unlock()
+		slog.Warn("Spillover is full, dropping file", "component", "throttle", "source", source, "file", fileInfo.Name)
+		return
+	}
+	l.spill[source] = append(l.spill[source], fileInfo)
+	l.pending[fileInfo.Path] = true
+	l.mu.Unlock()
+}
+
+// drain передаёт воркерам отложенные файлы, для которых появились токены.
+func (l *Limiter) drain() {
+	var ready []watcher.FileInfo
+
+	l.mu.Lock()
+	for source, queue := range l.spill {
+		for len(queue) > 0 && l.take(source) {
+			ready = append(ready, queue[0])
+			delete(l.pending, queue[0].Path)
+			queue = queue[1:]
+		}
+		l.spill[source] = queue
+	}
+	l.mu.Unlock()
+
+	for _, fileInfo := range ready {
+		l.out <- fileInfo
+	}
+}
+
+// flush передаёт в Output все отложенные файлы, не расходуя токены.
+// Возвращает их количество.
+func (l *Limiter) flush() int {
+	var rest []watcher.FileInfo
+
+	l.mu.Lock()
+	for source, queue := range l.spill {
+		rest = append(rest, queue...)
+		delete(l.spill, source)
+	}
+	clear(l.pending)
+	l.mu.Unlock()
+
+	for _, fileInfo := range rest {
+		l.out <- fileInfo
+	}
+	return len(rest)
+}
+
+// take забирает токен источника. Вызывается под мьютексом.
+func (l *Limiter) take(source string) bool {
+	rate := l.rateFor(source)
+	if rate <= 0 {
+		return true
+	}
+
+	capacity := float64(l.cfg.Burst)
+	if capacity < 1 {
+		capacity = 1
+	}
+
+	now := l.now()
+	b, ok := l.buckets[source]
+	if !ok {
+		b = &bucket{tokens: capacity, last: now}
+		l.buckets[source] = b
+	}
+
+	b.tokens += now.Sub(b.last).Minutes() * float64(rate)
+	if b.tokens > capacity {
+		b.tokens = capacity
+	}
+	b.last = now
+
+	if b.tokens < 1 {
+		return false
+	}
+	b.tokens--
+	return true
+}
+
+// rateFor возвращает лимит (файлов в минуту) для источника.
+func (l *Limiter) rateFor(source string) int {
+	if rate, ok := l.cfg.SourceLimits[strings.ToLower(source)]; ok {
+		return rate
+	}
+	return l.cfg.DefaultFilesPerMinute
+}
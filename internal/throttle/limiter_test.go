@@ -0,0 +1,146 @@
+package throttle
+
+import (
+	"TSVProcessingService/internal/config"
+	"TSVProcessingService/internal/watcher"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func setupTestLimiter(cfg config.ThrottleConfig) (*Limiter, *time.Time) {
+	if cfg.MaxSpillover == 0 {
+		cfg.MaxSpillover = 100
+	}
+	l := NewLimiter(cfg, 100)
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	l.now = func() time.Time { return now }
+	return l, &now
+}
+
+// ---------------------------------------------------------------------
+// Тесты SourceOf
+// ---------------------------------------------------------------------
+
+func TestSourceOf(t *testing.T) {
+	l, _ := setupTestLimiter(config.ThrottleConfig{TenantSeparator: "_"})
+
+	assert.Equal(t, "api:key1", l.SourceOf(watcher.FileInfo{Name: "a_b.tsv", Source: "api:key1"}))
+	assert.Equal(t, "tenant:partner", l.SourceOf(watcher.FileInfo{Name: "Partner_001.tsv"}))
+	assert.Equal(t, DirectorySource, l.SourceOf(watcher.FileInfo{Name: "plain.tsv"}))
+}
+
+func TestResolveSource_NoSeparator(t *testing.T) {
+	assert.Equal(t, DirectorySource, ResolveSource(watcher.FileInfo{Name: "partner_001.tsv"}, ""))
+	assert.Equal(t, "api", ResolveSource(watcher.FileInfo{Name: "partner_001.tsv", Source: "api"}, ""))
+}
+
+// ---------------------------------------------------------------------
+// Тесты admit/drain
+// ---------------------------------------------------------------------
+
+func TestAdmit_UnlimitedPassesThrough(t *testing.T) {
+	l, _ := setupTestLimiter(config.ThrottleConfig{})
+
+	for i := 0; i < 10; i++ {
+		l.admit(watcher.FileInfo{Name: "f.tsv", Path: string(rune('a' + i))})
+	}
+	assert.Len(t, l.out, 10)
+	assert.Empty(t, l.SpilloverSizes())
+}
+
+func TestAdmit_SpillsOverAndDrains(t *testing.T) {
+	l, now := setupTestLimiter(config.ThrottleConfig{
+		TenantSeparator: "_",
+		SourceLimits:    map[string]int{"tenant:bulk": 60},
+		Burst:           1,
+	})
+
+	l.admit(watcher.FileInfo{Name: "bulk_1.tsv", Path: "/in/bulk_1.tsv"})
+	l.admit(watcher.FileInfo{Name: "bulk_2.tsv", Path: "/in/bulk_2.tsv"})
+	l.admit(watcher.FileInfo{Name: "bulk_3.tsv", Path: "/in/bulk_3.tsv"})
+	// Повторное сканирование не должно дублировать отложенный файл
+	l.admit(watcher.FileInfo{Name: "bulk_2.tsv", Path: "/in/bulk_2.tsv"})
+	// Другой источник не ограничен и не ждёт bulk
+	l.admit(watcher.FileInfo{Name: "normal.tsv", Path: "/in/normal.tsv"})
+
+	assert.Len(t, l.out, 2)
+	assert.Equal(t, map[string]int{"tenant:bulk": 2}, l.SpilloverSizes())
+
+	*now = now.Add(time.Second)
+	l.drain()
+	assert.Len(t, l.out, 3)
+	assert.Equal(t, map[string]int{"tenant:bulk": 1}, l.SpilloverSizes())
+
+	<-l.out
+	<-l.out
+	received := <-l.out
+	assert.Equal(t, "bulk_2.tsv", received.Name)
+}
+
+func TestAdmit_SpilloverLimit(t *testing.T) {
+	l, _ := setupTestLimiter(config.ThrottleConfig{
+		DefaultFilesPerMinute: 1,
+		Burst:                 1,
+		MaxSpillover:          1,
+	})
+
+	l.admit(watcher.FileInfo{Name: "1.tsv", Path: "/1"})
+	l.admit(watcher.FileInfo{Name: "2.tsv", Path: "/2"})
+	l.admit(watcher.FileInfo{Name: "3.tsv", Path: "/3"})
+
+	assert.Len(t, l.out, 1)
+	assert.Equal(t, map[string]int{DirectorySource: 1}, l.SpilloverSizes())
+}
+
+// ---------------------------------------------------------------------
+// Тест Run
+// ---------------------------------------------------------------------
+
+func TestRun_ClosesOutputWhenInputClosed(t *testing.T) {
+	l, _ := setupTestLimiter(config.ThrottleConfig{})
+	in := make(chan watcher.FileInfo, 1)
+	in <- watcher.FileInfo{Name: "a.tsv", Path: "/a"}
+	close(in)
+
+	go l.Run(in)
+
+	received, ok := <-l.Output()
+	assert.True(t, ok)
+	assert.Equal(t, "a.tsv", received.Name)
+
+	select {
+	case _, ok := <-l.Output():
+		assert.False(t, ok)
+	case <-time.After(time.Second):
+		t.Fatal("output not closed")
+	}
+}
+
+func TestRun_FlushesSpilloverWhenInputClosed(t *testing.T) {
+	l, _ := setupTestLimiter(config.ThrottleConfig{
+		TenantSeparator:       "_",
+		DefaultFilesPerMinute: 1,
+		Burst:                 1,
+	})
+	in := make(chan watcher.FileInfo)
+	go l.Run(in)
+
+	// Дренаж: отложенные файлы уже приняты и должны быть обработаны
+	names := []string{"a_1.tsv", "a_2.tsv", "a_3.tsv", "b_1.tsv", "b_2.tsv"}
+	for _, name := range names {
+		in <- watcher.FileInfo{Name: name, Path: "/in/" + name}
+	}
+	assert.Eventually(t, func() bool {
+		return assert.ObjectsAreEqual(map[string]int{"tenant:a": 2, "tenant:b": 1}, l.SpilloverSizes())
+	}, time.Second, time.Millisecond)
+	close(in)
+
+	var received []string
+	for fileInfo := range l.Output() {
+		received = append(received, fileInfo.Name)
+	}
+	assert.ElementsMatch(t, names, received)
+	assert.Empty(t, l.SpilloverSizes())
+}
@@ -0,0 +1,104 @@
+// internal/tracing/tracing.go
+package tracing
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"strings"
+)
+
+// Header - заголовок W3C Trace Context, в котором передаётся трасса
+const Header = "traceparent"
+
+// SpanContext - идентификаторы трассы и span (W3C Trace Context):
+// trace_id - 32, span_id - 16 шестнадцатеричных символов в нижнем регистре.
+// По ним запись о файле или отчёте находится в Jaeger/Tempo.
+type SpanContext struct {
+	TraceID string
+	SpanID  string
+}
+
+// IsValid - заданы ли оба идентификатора
+func (s SpanContext) IsValid() bool {
+	return validID(s.TraceID, 32) && validID(s.SpanID, 16)
+}
+
+// Traceparent - значение заголовка traceparent ("" для пустого span)
+func (s SpanContext) Traceparent() string {
+	if !s.IsValid() {
+		return ""
+	}
+	return fmt.Sprintf("00-%s-%s-01", s.TraceID, s.SpanID)
+}
+
+// Parse разбирает заголовок traceparent (version-trace_id-span_id-flags)
+func Parse(traceparent string) (SpanContext, bool) {
+	parts := strings.Split(strings.TrimSpace(traceparent), "-")
+	if len(parts) < 4 || !validID(parts[0], 2) || parts[0] == "ff" || !validID(parts[3], 2) {
+		return SpanContext{}, false
+	}
+	// Версия 00 не допускает дополнительных полей
+	if parts[0] == "00" && len(parts) != 4 {
+		return SpanContext{}, false
+	}
+	s := SpanContext{TraceID: parts[1], SpanID: parts[2]}
+	if !s.IsValid() {
+		return SpanContext{}, false
+	}
+	return s, true
+}
+
+// NewSpan создаёт span в трассе parent или, если parent пуст, в новой трассе
+func NewSpan(parent SpanContext) SpanContext {
+	traceID := parent.TraceID
+	if !parent.IsValid() {
+		traceID = randomID(16)
+	}
+	return SpanContext{TraceID: traceID, SpanID: randomID(8)}
+}
+
+type contextKey struct{}
+
+// ContextWithSpan сохраняет span в контексте
+func ContextWithSpan(ctx context.Context, s SpanContext) context.Context {
+	return context.WithValue(ctx, contextKey{}, s)
+}
+
+// FromContext - span из контекста (пустой, если не задан)
+func FromContext(ctx context.Context) SpanContext {
+	s, _ := ctx.Value(contextKey{}).(SpanContext)
+	return s
+}
+
+// Start создаёт дочерний span span'а из ctx и возвращает контекст с ним
+func Start(ctx context.Context) (context.Context, SpanContext) {
+	s := NewSpan(FromContext(ctx))
+	return ContextWithSpan(ctx, s), s
+}
+
+// validID - n шестнадцатеричных символов в нижнем регистре; идентификаторы
+// трассы и span не могут состоять из одних нулей (версия и флаги - могут)
+func validID(id string, n int) bool {
+	if len(id) != n {
+		return false
+	}
+	for _, c := range id {
+		if !('0' <= c && c <= '9' || 'a' <= c && c <= 'f') {
+			return false
+		}
+	}
+	return n <= 2 || strings.Trim(id, "0") != ""
+}
+
+// randomID - n случайных байт в hex (ненулевой идентификатор)
+func randomID(n int) string {
+	b := make([]byte, n)
+	for {
+		rand.Read(b)
+		if id := hex.EncodeToString(b); strings.Trim(id, "0") != "" {
+			return id
+		}
+	}
+}
@@ -0,0 +1,56 @@
+package tracing
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParse(t *testing.T) {
+	s, ok := Parse("00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	require.True(t, ok)
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", s.TraceID)
+	assert.Equal(t, "00f067aa0ba902b7", s.SpanID)
+	assert.Equal(t, "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", s.Traceparent())
+
+	for _, header := range []string{
+		"",
+		"garbage",
+		"00-00000000000000000000000000000000-00f067aa0ba902b7-01", // нулевой trace_id
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-0000000000000000-01", // нулевой span_id
+		"00-4BF92F3577B34DA6A3CE929D0E0E4736-00f067aa0ba902b7-01", // верхний регистр
+		"ff-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", // недопустимая версия
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-extra",
+	} {
+		_, ok := Parse(header)
+		assert.False(t, ok, header)
+	}
+
+	// Будущие версии могут добавлять поля
+	_, ok = Parse("01-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-extra")
+	assert.True(t, ok)
+}
+
+func TestNewSpan(t *testing.T) {
+	root := NewSpan(SpanContext{})
+	require.True(t, root.IsValid())
+
+	child := NewSpan(root)
+	assert.Equal(t, root.TraceID, child.TraceID)
+	assert.NotEqual(t, root.SpanID, child.SpanID)
+
+	assert.Empty(t, SpanContext{}.Traceparent())
+}
+
+func TestContext(t *testing.T) {
+	assert.False(t, FromContext(context.Background()).IsValid())
+
+	ctx, parent := Start(context.Background())
+	assert.Equal(t, parent, FromContext(ctx))
+
+	_, child := Start(ctx)
+	assert.Equal(t, parent.TraceID, child.TraceID)
+	assert.NotEqual(t, parent.SpanID, child.SpanID)
+}
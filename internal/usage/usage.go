@@ -0,0 +1,198 @@
+// internal/usage/usage.go
+package usage
+
+import (
+	"TSVProcessingService/internal/clock"
+	"TSVProcessingService/internal/database"
+	"context"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// Source - запросы объёма данных в БД (реализуется database.Store)
+type Source interface {
+	GetTableSizes(ctx context.Context) ([]database.TableSize, error)
+	GetUnitStorage(ctx context.Context) ([]database.UnitStorage, error)
+	GetSourceStorage(ctx context.Context) ([]database.SourceStorage, error)
+}
+
+// Dir - директория, занимаемое место которой учитывается
+type Dir struct {
+	Name string // reports, archive, ...
+	Path string
+}
+
+// DirUsage - занимаемое директорией место
+type DirUsage struct {
+	Name  string `json:"name"`
+	Path  string `json:"path"`
+	Files int64  `json:"files"`
+	Bytes int64  `json:"bytes"`
+	Error string `json:"error,omitempty"`
+}
+
+// DatabaseUsage - размер таблиц БД
+type DatabaseUsage struct {
+	TotalBytes int64                `json:"total_bytes"`
+	Tables     []database.TableSize `json:"tables"`
+}
+
+// Report - снимок использования хранилища
+type Report struct {
+	ComputedAt  time.Time                `json:"computed_at"`
+	DurationMs  int64                    `json:"duration_ms"`
+	Database    DatabaseUsage            `json:"database"`
+	Sources     []database.SourceStorage `json:"sources"`
+	Units       []database.UnitStorage   `json:"units"` // TopUnits самых больших
+	UnitsTotal  int                      `json:"units_total"`
+	Directories []DirUsage               `json:"directories"`
+	Errors      []string                 `json:"errors,omitempty"` // части снимка, которые не удалось посчитать
+}
+
+// Options - параметры сбора
+type Options struct {
+	Interval time.Duration
+	Timeout  time.Duration // на один сбор; 0 - 5 минут
+	TopUnits int           // устройств в отчёте; 0 - 100
+	Dirs     []Dir
+	Clock    clock.Clock // nil - системные часы
+}
+
+// Collector периодически считает использование хранилища: размеры
+// таблиц, объём данных по источникам (tenant) и устройствам, место
+// директорий отчётов и архивов. Подсчёт тяжёлый (полный проход по
+// device_data и директориям), поэтому API отдаёт последний снимок.
+type Collector struct {
+	source Source
+	opts   Options
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	mu     sync.RWMutex
+	report *Report
+}
+
+// NewCollector создаёт Collector; сбор начинается в Run
+func NewCollector(source Source, opts Options) *Collector {
+	if opts.Timeout <= 0 {
+		opts.Timeout = 5 * time.Minute
+	}
+	if opts.TopUnits <= 0 {
+		opts.TopUnits = 100
+	}
+	if opts.Clock == nil {
+		opts.Clock = clock.Real
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Collector{source: source, opts: opts, ctx: ctx, cancel: cancel}
+}
+
+// Run считает использование сразу и затем каждые Interval до вызова Stop.
+func (c *Collector) Run() {
+	clock.Every(c.ctx, c.opts.Clock, c.opts.Interval, func() {
+		report := c.Collect(c.ctx)
+		if len(report.Errors) > 0 {
+			slog.Warn("⚠️ Storage usage computed with errors", "component", "usage", "count", len(report.Errors), "errors", report.Errors)
+		}
+	})
+	slog.Info("Storage usage collector stopped", "component", "usage")
+}
+
+// Stop останавливает Run
+func (c *Collector) Stop() {
+	c.cancel()
+}
+
+// Report - последний снимок; false - ещё ни разу не посчитан
+func (c *Collector) Report() (Report, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if c.report == nil {
+		return Report{}, false
+	}
+	return *c.report, true
+}
+
+// Collect считает использование и сохраняет снимок. Ошибки отдельных
+// частей попадают в Report.Errors, остальные части считаются.
+func (c *Collector) Collect(ctx context.Context) Report {
+	ctx, cancel := context.WithTimeout(ctx, c.opts.Timeout)
+	defer cancel()
+
+	start := c.opts.Clock.Now()
+	report := Report{
+		ComputedAt:  start,
+		Database:    DatabaseUsage{Tables: []database.TableSize{}},
+		Sources:     []database.SourceStorage{},
+		Units:       []database.UnitStorage{},
+		Directories: []DirUsage{},
+	}
+
+	if tables, err := c.source.GetTableSizes(ctx); err != nil {
+		report.Errors = append(report.Errors, err.Error())
+	} else {
+		report.Database.Tables = tables
+		for _, t := range tables {
+			report.Database.TotalBytes += t.TotalBytes
+		}
+	}
+	if sources, err := c.source.GetSourceStorage(ctx); err != nil {
+		report.Errors = append(report.Errors, err.Error())
+	} else {
+		report.Sources = sources
+	}
+	if units, err := c.source.GetUnitStorage(ctx); err != nil {
+		report.Errors = append(report.Errors, err.Error())
+	} else {
+		report.UnitsTotal = len(units)
+		if len(units) > c.opts.TopUnits {
+			units = units[:c.opts.TopUnits]
+		}
+		report.Units = units
+	}
+	for _, dir := range c.opts.Dirs {
+		report.Directories = append(report.Directories, dirUsage(dir))
+	}
+
+	report.DurationMs = c.opts.Clock.Since(start).Milliseconds()
+
+	c.mu.Lock()
+	c.report = &report
+	c.mu.Unlock()
+	return report
+}
+
+// dirUsage - число и суммарный размер файлов директории (рекурсивно)
+func dirUsage(dir Dir) DirUsage {
+	usage := DirUsage{Name: dir.Name, Path: dir.Path}
+	err := filepath.WalkDir(dir.Path, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			// Файл удалён во время обхода
+			if os.IsNotExist(err) && path != dir.Path {
+				return nil
+			}
+			return err
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		info, err := d.Info()
+		if os.IsNotExist(err) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		usage.Files++
+		usage.Bytes += info.Size()
+		return nil
+	})
+	if err != nil {
+		usage.Error = err.Error()
+	}
+	return usage
+}
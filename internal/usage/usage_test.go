@@ -0,0 +1,126 @@
+package usage
+
+import (
+	"TSVProcessingService/internal/clock"
+	"TSVProcessingService/internal/database"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeSource struct {
+	tables  []database.TableSize
+	units   []database.UnitStorage
+	sources []database.SourceStorage
+	err     error // ошибка GetSourceStorage
+}
+
+func (f *fakeSource) GetTableSizes(ctx context.Context) ([]database.TableSize, error) {
+	return f.tables, nil
+}
+
+func (f *fakeSource) GetUnitStorage(ctx context.Context) ([]database.UnitStorage, error) {
+	return f.units, nil
+}
+
+func (f *fakeSource) GetSourceStorage(ctx context.Context) ([]database.SourceStorage, error) {
+	return f.sources, f.err
+}
+
+func TestCollect(t *testing.T) {
+	reports := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(reports, "unit"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(reports, "a.pdf"), make([]byte, 100), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(reports, "unit", "b.pdf"), make([]byte, 50), 0644))
+
+	source := &fakeSource{
+		tables: []database.TableSize{
+			{Table: "device_data", TotalBytes: 1000},
+			{Table: "files", TotalBytes: 200},
+		},
+		units: []database.UnitStorage{
+			{UnitGuid: uuid.New(), Rows: 3, Bytes: 300},
+			{UnitGuid: uuid.New(), Rows: 2, Bytes: 200},
+			{UnitGuid: uuid.New(), Rows: 1, Bytes: 100},
+		},
+		sources: []database.SourceStorage{{Source: "tenant:acme", Tenant: "acme", Rows: 6, Bytes: 600}},
+	}
+	fake := clock.NewFake(time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC))
+	c := NewCollector(source, Options{
+		Interval: time.Hour,
+		TopUnits: 2,
+		Clock:    fake,
+		Dirs: []Dir{
+			{Name: "reports", Path: reports},
+			{Name: "missing", Path: filepath.Join(reports, "missing")},
+		},
+	})
+
+	_, ok := c.Report()
+	assert.False(t, ok)
+
+	c.Collect(context.Background())
+	report, ok := c.Report()
+	require.True(t, ok)
+
+	assert.Equal(t, fake.Now(), report.ComputedAt)
+	assert.Equal(t, int64(1200), report.Database.TotalBytes)
+	assert.Equal(t, 3, report.UnitsTotal)
+	require.Len(t, report.Units, 2)
+	assert.Equal(t, int64(300), report.Units[0].Bytes)
+	assert.Equal(t, "acme", report.Sources[0].Tenant)
+	assert.Empty(t, report.Errors)
+
+	require.Len(t, report.Directories, 2)
+	assert.Equal(t, int64(2), report.Directories[0].Files)
+	assert.Equal(t, int64(150), report.Directories[0].Bytes)
+	assert.Empty(t, report.Directories[0].Error)
+	assert.NotEmpty(t, report.Directories[1].Error)
+}
+
+func TestCollect_PartialFailure(t *testing.T) {
+	source := &fakeSource{
+		tables: []database.TableSize{{Table: "files", TotalBytes: 10}},
+		err:    errors.New("failed to get source storage: timeout"),
+	}
+	c := NewCollector(source, Options{Interval: time.Hour})
+
+	report := c.Collect(context.Background())
+	assert.Equal(t, []string{"failed to get source storage: timeout"}, report.Errors)
+	assert.Equal(t, int64(10), report.Database.TotalBytes)
+	assert.NotNil(t, report.Sources)
+}
+
+func TestRun_RecomputesEveryInterval(t *testing.T) {
+	fake := clock.NewFake(time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC))
+	c := NewCollector(&fakeSource{}, Options{Interval: time.Hour, Clock: fake})
+
+	stopped := make(chan struct{})
+	go func() {
+		c.Run()
+		close(stopped)
+	}()
+
+	fake.BlockUntil(1)
+	require.Eventually(t, func() bool {
+		_, ok := c.Report()
+		return ok
+	}, time.Second, 5*time.Millisecond)
+	first, _ := c.Report()
+
+	fake.Advance(time.Hour)
+	require.Eventually(t, func() bool {
+		report, _ := c.Report()
+		return report.ComputedAt.After(first.ComputedAt)
+	}, time.Second, 5*time.Millisecond)
+
+	c.Stop()
+	<-stopped
+}
@@ -2,36 +2,110 @@
 package watcher
 
 import (
-	"crypto/sha256"
-	"encoding/hex"
+	"TSVProcessingService/internal/clock"
+	"TSVProcessingService/internal/fsys"
+	"TSVProcessingService/internal/ingest"
+	"TSVProcessingService/internal/tracing"
+	"errors"
 	"fmt"
-	"io"
-	"log"
+	"log/slog"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
 	"sync"
 	"time"
+
+	"github.com/fsnotify/fsnotify"
 )
 
 // FileInfo представляет информацию о файле, который будет обработан.
 type FileInfo struct {
-	Path    string    // полный путь к файлу
-	Name    string    // имя файла
-	Size    int64     // размер в байтах
-	ModTime time.Time // время последней модификации
-	Hash    string    // SHA256 хеш содержимого файла
+	Path      string    // полный путь к файлу
+	Name      string    // имя файла
+	Size      int64     // размер в байтах
+	ModTime   time.Time // время последней модификации
+	ArrivedAt time.Time // когда сервис впервые увидел файл
+	Hash      string    // SHA256 хеш содержимого файла
+	Source    string    // источник поступления (пусто для файлов из watch-директории)
+	BatchID   string    // пакет обработки (пусто, если файл поставлен не через process-batch)
+	QueuedAt  time.Time // постановка в очередь (время ожидания воркера)
+	Priority  int32     // приоритет задания обработки (PriorityHigh - Prioritize)
+
+	// Трасса обработки: до ProcessFile - span API-запроса, поставившего
+	// файл (пусто для watch-директории), в ProcessFile - span обработки
+	Trace tracing.SpanContext
+
+	ConflictPolicy string          // политика конфликтов вставки (пусто - по умолчанию)
+	Sampling       ingest.Sampling // выборка строк для предварительной загрузки (пусто - все строки)
+	Options        ingest.Options  // формат, кодировка, строгость разбора (sidecar.go; пусто - по умолчанию)
+}
+
+// Причины, по которым файл остаётся в watch-директории
+const (
+	ReasonQueued    = "queued"     // поставлен в очередь, ждёт воркера
+	ReasonQueueFull = "queue_full" // очередь переполнена
+	ReasonIgnored   = "ignored"    // не .tsv, скрытый или подходит под ignore_patterns
+	ReasonNotReady  = "not_ready"  // размер меняется между сканированиями
+	ReasonError     = "error"      // ошибка stat/чтения файла
+
+	ReasonMaintenance = "maintenance" // приём приостановлен окном обслуживания
+)
+
+// BacklogEntry описывает необработанный файл в watch-директории.
+type BacklogEntry struct {
+	Name      string    `json:"name"`
+	Size      int64     `json:"size"`
+	ModTime   time.Time `json:"mod_time"`
+	FirstSeen time.Time `json:"first_seen"`
+	Reason    string    `json:"reason"`
+	Hash      string    `json:"hash,omitempty"` // хеш на момент постановки в очередь
+
+	restored bool // восстановлен из файла состояния и ещё не поставлен в очередь
+}
+
+// BacklogStats - агрегированные показатели backlog.
+type BacklogStats struct {
+	Files      int            `json:"files"`
+	TotalBytes int64          `json:"total_bytes"`
+	OldestAge  time.Duration  `json:"oldest_age_ns"`
+	ByReason   map[string]int `json:"by_reason"`
+	Restored   int            `json:"restored"` // восстановлено из файла состояния при запуске
 }
 
 // Watcher отвечает за периодическое сканирование директории,
 // обнаружение новых .tsv файлов и передачу их в очередь на обработку.
 type Watcher struct {
-	watchDir  string        // директория для наблюдения
-	interval  time.Duration // интервал сканирования
-	fileQueue chan FileInfo // буферизированный канал с файлами для обработки
-	stopChan  chan struct{} // сигнал остановки
-	closed    bool          // флаг для защиты от повторного закрытия каналов
-	mu        sync.Mutex    // мьютекс для атомарного закрытия
+	watchDir      string        // директория для наблюдения
+	interval      time.Duration // интервал сканирования
+	fileQueue     chan FileInfo // буферизированный канал с файлами для обработки
+	priorityQueue chan FileInfo // файлы, которые обрабатываются раньше очереди (priority.go)
+	stopChan      chan struct{} // сигнал остановки
+	closed        bool          // флаг для защиты от повторного закрытия каналов
+	mu            sync.RWMutex  // закрытие очереди (Lock) против постановки в неё (RLock)
+
+	backlog   map[string]*BacklogEntry // файлы, найденные при последнем сканировании
+	backlogMu sync.Mutex               // защищает backlog
+
+	heartbeat      func()      // вызывается после каждого сканирования (контроль зависаний)
+	ignorePatterns []string    // шаблоны имён (filepath.Match), которые не обрабатываются
+	paused         func() bool // приём приостановлен (окно обслуживания), nil - никогда
+	wasPaused      bool        // состояние на прошлом сканировании (лог переключений)
+
+	minFileAge  time.Duration // файл моложе (по mtime) ещё не ставится в очередь
+	hashWorkers int           // файлов, хешируемых одновременно при сканировании
+	sandbox     bool          // обработанные файлы остаются в директории (scan_hooks.go)
+	mode        string        // poll или fsnotify (notify.go)
+	debounce    time.Duration // ожидание после события файловой системы до сканирования
+	clock       clock.Clock   // время и таймеры (подменяются в тестах)
+	fs          fsys.FS       // файловая система watch-директории (подменяется в тестах)
+	hooks       ScanHooks     // точки вмешательства для тестов (scan_hooks.go)
+	logger      *slog.Logger  // журнал watcher
+
+	stateFile string // файл состояния backlog (state.go), пусто - не сохраняется
+	lastState []byte // последнее записанное состояние
+	restored  int    // файлов восстановлено из состояния при запуске
+	requeued  int    // восстановленных файлов поставлено в очередь за сканирование
 }
 
 // NewWatcher создаёт новый экземпляр Watcher.
@@ -40,36 +114,114 @@ type Watcher struct {
 // queueSize  – размер буфера очереди файлов.
 func NewWatcher(watchDir string, interval time.Duration, queueSize int) *Watcher {
 	return &Watcher{
-		watchDir:  watchDir,
-		interval:  interval,
-		fileQueue: make(chan FileInfo, queueSize),
-		stopChan:  make(chan struct{}),
+		watchDir:      watchDir,
+		interval:      interval,
+		fileQueue:     make(chan FileInfo, queueSize),
+		priorityQueue: make(chan FileInfo, priorityQueueSize),
+		stopChan:      make(chan struct{}),
+		backlog:       make(map[string]*BacklogEntry),
+		clock:         clock.Real,
+		fs:            fsys.OS,
+		logger:        slog.Default().With("component", "watcher"),
 	}
 }
 
 // Start запускает цикл сканирования директории.
 // Запускается в отдельной горутине; работает до вызова Stop().
 func (w *Watcher) Start() {
-	log.Printf("[Watcher] Starting directory watcher for: %s (interval: %v)", w.watchDir, w.interval)
+	w.logger.Info("Starting directory watcher", "dir", w.watchDir, "interval", w.interval)
+
+	// События файловой системы (режим fsnotify) ускоряют сканирование;
+	// подписка до первого сканирования, чтобы не пропустить файлы между ними
+	var events <-chan fsnotify.Event
+	var notifyErrors <-chan error
+	if notifier := w.startNotify(); notifier != nil {
+		defer notifier.Close()
+		events, notifyErrors = notifier.Events, notifier.Errors
+	}
 
 	// Первоначальное сканирование
 	w.scanDirectory()
+	w.beat()
 
-	ticker := time.NewTicker(w.interval)
+	ticker := w.clock.NewTicker(w.interval)
 	defer ticker.Stop()
+	var pending <-chan time.Time // сканирование после серии событий
 
 	for {
 		select {
-		case <-ticker.C:
+		case <-ticker.Chan():
+			w.scanDirectory()
+			w.beat()
+		case event, ok := <-events:
+			if !ok {
+				events = nil
+				continue
+			}
+			if pending == nil && triggersScan(event) {
+				pending = w.clock.After(w.debounce)
+			}
+		case err, ok := <-notifyErrors:
+			if !ok {
+				notifyErrors = nil
+				continue
+			}
+			// Переполнение очереди событий: часть файлов могла быть пропущена
+			w.logger.Warn("fsnotify error, rescanning", "error", err)
+			if pending == nil {
+				pending = w.clock.After(w.debounce)
+			}
+		case <-pending:
+			pending = nil
 			w.scanDirectory()
+			w.beat()
 		case <-w.stopChan:
-			log.Println("[Watcher] Directory watcher stopped")
+			w.logger.Info("Directory watcher stopped")
 			return
 		}
 	}
 }
 
-// Stop останавливает Watcher и закрывает канал fileQueue.
+// SetHeartbeat задаёт функцию, вызываемую после каждого сканирования.
+// Должна быть вызвана до Start.
+func (w *Watcher) SetHeartbeat(beat func()) {
+	w.heartbeat = beat
+}
+
+// SetIgnorePatterns задаёт шаблоны имён файлов (filepath.Match), которые
+// остаются в директории без обработки. Должна быть вызвана до Start.
+func (w *Watcher) SetIgnorePatterns(patterns []string) {
+	w.ignorePatterns = patterns
+}
+
+// SetHashWorkers задаёт число файлов, которые проверяются и хешируются
+// параллельно при сканировании (хеширование упирается в диск, а не в CPU).
+// Файлы по-прежнему берутся по порядку обнаружения, но при n > 1 могут
+// попасть в очередь в другом порядке. n <= 1 - по одному. Должна быть
+// вызвана до Start.
+func (w *Watcher) SetHashWorkers(n int) {
+	w.hashWorkers = n
+}
+
+// SetPaused задаёт проверку приостановки приёма: пока она возвращает true,
+// новые файлы не ставятся в очередь и остаются в backlog с причиной
+// maintenance. Ручная постановка через SendToQueue не блокируется.
+// Должна быть вызвана до Start.
+func (w *Watcher) SetPaused(paused func() bool) {
+	w.paused = paused
+}
+
+func (w *Watcher) beat() {
+	if w.heartbeat != nil {
+		w.heartbeat()
+	}
+}
+
+// ErrStopped - очередь закрыта (Stop): файлы в неё больше не ставятся
+var ErrStopped = errors.New("watcher is stopped")
+
+// Stop останавливает Watcher и закрывает канал fileQueue. Уже
+// поставленные файлы остаются в канале и дочитываются воркерами.
 // Может быть вызвана многократно безопасно.
 func (w *Watcher) Stop() {
 	w.mu.Lock()
@@ -79,8 +231,9 @@ func (w *Watcher) Stop() {
 	}
 	close(w.stopChan)
 	close(w.fileQueue)
+	close(w.priorityQueue)
 	w.closed = true
-	log.Println("[Watcher] File queue closed")
+	w.logger.Info("File queue closed")
 }
 
 // GetFileQueue возвращает канал для чтения FileInfo.
@@ -93,85 +246,401 @@ func (w *Watcher) GetFileQueue() <-chan FileInfo {
 // поставить файл в очередь обработки. Блокируется до освобождения места
 // в канале, но не дольше timeout (5 секунд).
 func (w *Watcher) SendToQueue(fileInfo FileInfo) error {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	if w.closed {
+		queueRejected.Inc(QueueSourceAPI)
+		return ErrStopped
+	}
+
+	w.markQueued(&fileInfo)
 	select {
 	case w.fileQueue <- fileInfo:
-		log.Printf("[Watcher] Manually queued file: %s", fileInfo.Name)
+		w.logger.Info("Manually queued file", "file", fileInfo.Name)
+		queueEnqueued.Inc(QueueSourceAPI)
 		return nil
-	case <-time.After(5 * time.Second):
+	case <-w.clock.After(5 * time.Second):
+		queueRejected.Inc(QueueSourceAPI)
 		return fmt.Errorf("queue is full, timeout after 5s")
 	}
 }
 
+// Requeue ставит в очередь файл, повторная обработка которого назначена
+// после временной ошибки. Не ждёт места в очереди: файл, оставшийся
+// в watch-директории, поставит следующее сканирование.
+func (w *Watcher) Requeue(fileInfo FileInfo) error {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	if w.closed {
+		return ErrStopped
+	}
+
+	w.markQueued(&fileInfo)
+	select {
+	case w.fileQueue <- fileInfo:
+		w.logger.Info("Re-queued file for retry", "file", fileInfo.Name)
+		queueEnqueued.Inc(QueueSourceRetry)
+		return nil
+	default:
+		queueRejected.Inc(QueueSourceRetry)
+		return fmt.Errorf("queue is full")
+	}
+}
+
 // scanDirectory читает содержимое watchDir, отбирает .tsv файлы
-// и для каждого вызывает processFile.
+// и для каждого вызывает processFile. Попутно обновляет backlog:
+// все файлы директории с причиной, по которой они ещё не обработаны.
 func (w *Watcher) scanDirectory() {
-	entries, err := os.ReadDir(w.watchDir)
+	entries, err := w.fs.ReadDir(w.watchDir)
 	if err != nil {
-		log.Printf("[Watcher] Error reading directory %s: %v", w.watchDir, err)
+		w.logger.Error("Failed to read directory", "dir", w.watchDir, "error", err)
 		return
 	}
 
+	if w.hooks.AfterList != nil {
+		names := make([]string, 0, len(entries))
+		for _, entry := range entries {
+			names = append(names, entry.Name())
+		}
+		w.hooks.AfterList(names)
+	}
+
+	// Файлы, известные по прошлым сканированиям (в том числе до перезапуска),
+	// обрабатываются в порядке первого обнаружения, новые - после них
+	w.backlogMu.Lock()
+	firstSeen := make(map[string]time.Time, len(w.backlog))
+	for name, entry := range w.backlog {
+		firstSeen[name] = entry.FirstSeen
+	}
+	w.backlogMu.Unlock()
+	sort.SliceStable(entries, func(i, j int) bool {
+		ti, iKnown := firstSeen[entries[i].Name()]
+		tj, jKnown := firstSeen[entries[j].Name()]
+		if iKnown != jKnown {
+			return iKnown
+		}
+		return iKnown && ti.Before(tj)
+	})
+
+	paused := w.paused != nil && w.paused()
+	if paused != w.wasPaused {
+		if paused {
+			w.logger.Info("⏸️ Ingestion paused by maintenance window")
+		} else {
+			w.logger.Info("▶️ Ingestion resumed after maintenance window")
+		}
+		w.wasPaused = paused
+	}
+
+	w.requeued = 0
+	seen := make(map[string]bool, len(entries))
+	var hashing chan struct{} // nil - файлы обрабатываются по одному
+	var wg sync.WaitGroup
+	if w.hashWorkers > 1 {
+		hashing = make(chan struct{}, w.hashWorkers)
+	}
 	for _, entry := range entries {
-		// Пропускаем поддиректории и скрытые файлы
-		if entry.IsDir() || strings.HasPrefix(entry.Name(), ".") {
+		if entry.IsDir() {
 			continue
 		}
-		// Интересуют только файлы с расширением .tsv
-		if !strings.HasSuffix(strings.ToLower(entry.Name()), ".tsv") {
+		seen[entry.Name()] = true
+
+		// Пропускаем скрытые файлы, файлы не .tsv и подходящие под ignore_patterns
+		if skip := w.skipReason(entry.Name()); skip != "" {
+			if info, err := entry.Info(); err == nil {
+				if w.trackBacklog(entry.Name(), info.Size(), info.ModTime(), ReasonIgnored) {
+					Skipped(skip)
+				}
+			}
+			continue
+		}
+
+		if paused {
+			if info, err := entry.Info(); err == nil {
+				w.trackBacklog(entry.Name(), info.Size(), info.ModTime(), ReasonMaintenance)
+			}
 			continue
 		}
 
 		filePath := filepath.Join(w.watchDir, entry.Name())
-		w.processFile(filePath)
+		if hashing == nil {
+			w.processFile(filePath)
+			continue
+		}
+		hashing <- struct{}{}
+		wg.Add(1)
+		go func() {
+			defer func() {
+				<-hashing
+				wg.Done()
+			}()
+			w.processFile(filePath)
+		}()
 	}
+	wg.Wait()
+
+	// Файлы, исчезнувшие из директории, обработаны (перемещены)
+	w.backlogMu.Lock()
+	for name := range w.backlog {
+		if !seen[name] {
+			delete(w.backlog, name)
+		}
+	}
+	w.backlogMu.Unlock()
+
+	if w.requeued > 0 {
+		w.logger.Info("Re-queued files restored from state", "files", w.requeued)
+	}
+	w.saveState()
+}
+
+// skipReason - причина пропуска файла по имени ("" - файл обрабатывается)
+func (w *Watcher) skipReason(name string) string {
+	if strings.HasPrefix(name, ".") {
+		return SkipHidden
+	}
+	if IsSidecar(name) {
+		return SkipSidecar
+	}
+	for _, pattern := range w.ignorePatterns {
+		if ok, _ := filepath.Match(pattern, name); ok {
+			return SkipIgnored
+		}
+	}
+	if !strings.HasSuffix(strings.ToLower(name), ".tsv") {
+		return SkipWrongExtension
+	}
+	return ""
 }
 
 // processFile собирает информацию о файле, вычисляет хеш и
-// отправляет его в очередь (с таймаутом).
-func (w *Watcher) processFile(filePath string) {
-	info, err := os.Stat(filePath)
+// отправляет его в очередь (с таймаутом). Возвращает причину,
+// с которой файл учтён в backlog ("" - файл исчез во время сканирования).
+func (w *Watcher) processFile(filePath string) string {
+	name := filepath.Base(filePath)
+
+	info, err := w.fs.Stat(filePath)
+	if os.IsNotExist(err) {
+		// Переименован или удалён после чтения директории
+		w.forget(name)
+		return ""
+	}
 	if err != nil {
-		log.Printf("[Watcher] Error stating file %s: %v", filePath, err)
-		return
+		w.logger.Error("Failed to stat file", "path", filePath, "error", err)
+		return ReasonError
+	}
+
+	// Размер изменился с прошлого сканирования - файл ещё дописывается
+	w.backlogMu.Lock()
+	prev, known := w.backlog[name]
+	var prevEntry BacklogEntry
+	if known {
+		prevEntry = *prev
+	}
+	w.backlogMu.Unlock()
+	if known && prevEntry.Size != info.Size() {
+		return w.notReady(name, info)
+	}
+	// В режиме sandbox обработанный файл остаётся в директории: без
+	// изменений он повторно не хешируется и не ставится в очередь
+	if w.sandbox && known && prevEntry.Reason == ReasonQueued && !prevEntry.restored &&
+		prevEntry.ModTime.Equal(info.ModTime()) {
+		return ReasonQueued
+	}
+	if w.minFileAge > 0 && w.clock.Since(info.ModTime()) < w.minFileAge {
+		return w.notReady(name, info)
+	}
+
+	if w.hooks.AfterStat != nil {
+		w.hooks.AfterStat(name)
 	}
 
 	// Вычисляем SHA256 хеш содержимого файла
-	hash, err := w.calculateFileHash(filePath)
+	hash, err := w.hashFile(filePath)
+	if os.IsNotExist(err) {
+		w.forget(name)
+		return ""
+	}
 	if err != nil {
-		log.Printf("[Watcher] Error calculating hash for %s: %v", filePath, err)
-		return
+		w.logger.Error("Failed to calculate file hash", "path", filePath, "error", err)
+		w.trackBacklog(name, info.Size(), info.ModTime(), ReasonError)
+		return ReasonError
+	}
+
+	if w.hooks.AfterHash != nil {
+		w.hooks.AfterHash(name)
+	}
+
+	// Файл изменился, пока считался хеш: хеш не соответствует содержимому
+	after, err := w.fs.Stat(filePath)
+	if os.IsNotExist(err) {
+		w.forget(name)
+		return ""
+	}
+	if err != nil {
+		w.logger.Error("Failed to stat file", "path", filePath, "error", err)
+		return ReasonError
+	}
+	if after.Size() != info.Size() || !after.ModTime().Equal(info.ModTime()) {
+		return w.notReady(name, after)
+	}
+
+	// Время поступления - первое обнаружение файла, а не постановка в очередь
+	arrivedAt := w.clock.Now()
+	if known {
+		arrivedAt = prevEntry.FirstSeen
 	}
 
 	fileInfo := FileInfo{
-		Path:    filePath,
-		Name:    info.Name(),
-		Size:    info.Size(),
-		ModTime: info.ModTime(),
-		Hash:    hash,
+		Path:      filePath,
+		Name:      info.Name(),
+		Size:      info.Size(),
+		ModTime:   info.ModTime(),
+		ArrivedAt: arrivedAt,
+		Hash:      hash,
+	}
+
+	// Настройки обработки из <name>.meta.json
+	sidecar, ok, err := w.readSidecar(filePath)
+	if err != nil {
+		if !known || prevEntry.Reason != ReasonError {
+			w.logger.Error("Not queuing file: invalid sidecar", "file", name, "error", err)
+		}
+		w.trackBacklog(name, info.Size(), info.ModTime(), ReasonError)
+		return ReasonError
+	}
+	if ok {
+		sidecar.apply(&fileInfo)
+		w.logger.Info("Sidecar overrides", "file", name, "options", fileInfo.Options.String(),
+			"source", fileInfo.Source, "priority", fileInfo.Priority)
+	}
+
+	// Очередь закрыта во время сканирования (Stop): файл остаётся
+	// в watch-директории до следующего запуска
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	if w.closed {
+		return ReasonQueueFull
+	}
+
+	// Приоритетные файлы - в приоритетную очередь (если в ней есть место)
+	w.markQueued(&fileInfo)
+	if fileInfo.Priority > 0 && w.queuePriority(fileInfo) {
+		queueEnqueued.Inc(QueueSourceWatcher)
+		w.trackBacklog(name, info.Size(), info.ModTime(), ReasonQueued)
+		w.setBacklogHash(name, hash)
+		return ReasonQueued
 	}
 
 	// Отправляем в очередь с таймаутом 5 секунд.
 	// Если очередь заполнена, ждём; если таймаут истёк – логируем ошибку.
 	select {
 	case w.fileQueue <- fileInfo:
-		log.Printf("[Watcher] Queued file: %s (size: %d bytes, hash: %s)",
-			fileInfo.Name, fileInfo.Size, fileInfo.Hash[:8])
-	case <-time.After(5 * time.Second):
-		log.Printf("[Watcher] Queue is full, cannot queue file: %s", fileInfo.Name)
+		// Восстановленные после перезапуска файлы не логируются по одному
+		if prevEntry.restored && prevEntry.Hash == hash {
+			w.backlogMu.Lock()
+			w.requeued++
+			w.backlogMu.Unlock()
+		} else {
+			w.logger.Info("Queued file", "file", fileInfo.Name, "size", fileInfo.Size, "hash", fileInfo.Hash[:8])
+		}
+		queueEnqueued.Inc(QueueSourceWatcher)
+		w.trackBacklog(name, info.Size(), info.ModTime(), ReasonQueued)
+		w.setBacklogHash(name, hash)
+		return ReasonQueued
+	case <-w.clock.After(5 * time.Second):
+		w.logger.Warn("Queue is full, cannot queue file", "file", fileInfo.Name)
+		queueRejected.Inc(QueueSourceWatcher)
+		w.trackBacklog(name, info.Size(), info.ModTime(), ReasonQueueFull)
+		return ReasonQueueFull
 	}
 }
 
-// calculateFileHash вычисляет SHA256 хеш содержимого файла.
-func (w *Watcher) calculateFileHash(filePath string) (string, error) {
-	f, err := os.Open(filePath)
+// hashFile вычисляет SHA256 содержимого файла
+func (w *Watcher) hashFile(filePath string) (string, error) {
+	f, err := w.fs.Open(filePath)
 	if err != nil {
 		return "", err
 	}
 	defer f.Close()
+	return ingest.Hash(f)
+}
 
-	hash := sha256.New()
-	if _, err := io.Copy(hash, f); err != nil {
-		return "", err
+// notReady учитывает файл, который ещё дописывается
+func (w *Watcher) notReady(name string, info os.FileInfo) string {
+	if w.trackBacklog(name, info.Size(), info.ModTime(), ReasonNotReady) {
+		Skipped(SkipTooNew)
+	}
+	return ReasonNotReady
+}
+
+// forget удаляет файл из backlog (файл исчез из директории)
+func (w *Watcher) forget(name string) {
+	w.backlogMu.Lock()
+	defer w.backlogMu.Unlock()
+	delete(w.backlog, name)
+}
+
+// trackBacklog добавляет или обновляет запись backlog для файла.
+// Возвращает true, если файл новый или причина изменилась (пропуски
+// учитываются один раз, а не на каждом сканировании).
+func (w *Watcher) trackBacklog(name string, size int64, modTime time.Time, reason string) bool {
+	w.backlogMu.Lock()
+	defer w.backlogMu.Unlock()
+
+	entry, ok := w.backlog[name]
+	if !ok {
+		entry = &BacklogEntry{Name: name, FirstSeen: w.clock.Now()}
+		w.backlog[name] = entry
+	}
+	changed := !ok || entry.Reason != reason
+	entry.Size = size
+	entry.ModTime = modTime
+	entry.Reason = reason
+	return changed
+}
+
+// setBacklogHash запоминает хеш поставленного в очередь файла
+func (w *Watcher) setBacklogHash(name, hash string) {
+	w.backlogMu.Lock()
+	defer w.backlogMu.Unlock()
+	if entry, ok := w.backlog[name]; ok {
+		entry.Hash = hash
+		entry.restored = false
+	}
+}
+
+// Backlog возвращает необработанные файлы, начиная с самых старых.
+func (w *Watcher) Backlog() []BacklogEntry {
+	w.backlogMu.Lock()
+	entries := make([]BacklogEntry, 0, len(w.backlog))
+	for _, entry := range w.backlog {
+		entries = append(entries, *entry)
+	}
+	w.backlogMu.Unlock()
+
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].ModTime.Before(entries[j].ModTime)
+	})
+	return entries
+}
+
+// GetBacklogStats возвращает количество, объём и возраст необработанных файлов.
+// Возраст считается от времени модификации файла.
+func (w *Watcher) GetBacklogStats() BacklogStats {
+	stats := BacklogStats{ByReason: make(map[string]int), Restored: w.restored}
+	now := w.clock.Now()
+
+	w.backlogMu.Lock()
+	defer w.backlogMu.Unlock()
+
+	for _, entry := range w.backlog {
+		stats.Files++
+		stats.TotalBytes += entry.Size
+		stats.ByReason[entry.Reason]++
+		if age := now.Sub(entry.ModTime); age > stats.OldestAge {
+			stats.OldestAge = age
+		}
 	}
-	return hex.EncodeToString(hash.Sum(nil)), nil
+	return stats
 }
@@ -1,8 +1,10 @@
 package watcher
 
 import (
+	"TSVProcessingService/internal/ingest"
 	"crypto/sha256"
 	"encoding/hex"
+	"fmt"
 	"io"
 	"os"
 	"path/filepath"
@@ -65,6 +67,31 @@ func TestScanDirectory_FindsTSVFiles(t *testing.T) {
 	}
 }
 
+func TestScanDirectory_HashWorkers(t *testing.T) {
+	w, watchDir, cleanup := setupTestWatcher(t)
+	defer cleanup()
+	w.SetHashWorkers(4)
+
+	want := make(map[string]string)
+	for i := 0; i < 10; i++ {
+		name := fmt.Sprintf("file%d.tsv", i)
+		content := fmt.Sprintf("%d\tcontent", i)
+		createTestFile(t, watchDir, name, content)
+		sum := sha256.Sum256([]byte(content))
+		want[name] = hex.EncodeToString(sum[:])
+	}
+
+	w.scanDirectory()
+
+	got := make(map[string]string)
+	for len(w.fileQueue) > 0 {
+		fileInfo := <-w.fileQueue
+		got[fileInfo.Name] = fileInfo.Hash
+	}
+	assert.Equal(t, want, got)
+	assert.Equal(t, 10, w.GetBacklogStats().ByReason[ReasonQueued])
+}
+
 func TestProcessFile_QueueWithTimeout(t *testing.T) {
 	w, watchDir, cleanup := setupTestWatcher(t)
 	defer cleanup()
@@ -95,17 +122,17 @@ func TestProcessFile_FileNotFound(t *testing.T) {
 }
 
 // ---------------------------------------------------------------------
-// Тест calculateFileHash
+// Тест хеша файла (ingest.HashFile)
 // ---------------------------------------------------------------------
 
 func TestCalculateFileHash(t *testing.T) {
-	w, watchDir, cleanup := setupTestWatcher(t)
+	_, watchDir, cleanup := setupTestWatcher(t)
 	defer cleanup()
 
 	content := "test content"
 	path := createTestFile(t, watchDir, "hash.tsv", content)
 
-	hash, err := w.calculateFileHash(path)
+	hash, err := ingest.HashFile(path)
 	require.NoError(t, err)
 	assert.NotEmpty(t, hash)
 
@@ -190,6 +217,15 @@ func TestStop_ClosesQueue(t *testing.T) {
 	assert.False(t, ok, "channel should be closed")
 }
 
+func TestSendToQueue_AfterStop(t *testing.T) {
+	w, _, cleanup := setupTestWatcher(t)
+	defer cleanup()
+
+	w.Stop()
+	assert.ErrorIs(t, w.SendToQueue(FileInfo{Name: "a.tsv"}), ErrStopped)
+	assert.ErrorIs(t, w.Requeue(FileInfo{Name: "a.tsv"}), ErrStopped)
+}
+
 // ---------------------------------------------------------------------
 // Интеграционный тест Start/Stop (исправлен – ожидание 600 мс)
 // ---------------------------------------------------------------------
@@ -226,3 +262,263 @@ func TestWatcher_StartStop(t *testing.T) {
 	_, ok := <-w.fileQueue
 	assert.False(t, ok)
 }
+
+func TestWatcher_FSNotifyMode(t *testing.T) {
+	w, watchDir, cleanup := setupTestWatcher(t)
+	w.interval = time.Hour // опрос не успеет сработать
+	w.SetMode(ModeFSNotify, 50*time.Millisecond)
+	defer cleanup()
+
+	go w.Start()
+	time.Sleep(100 * time.Millisecond) // даём время на подписку и первый scan
+
+	createTestFile(t, watchDir, "event.tsv", "data")
+
+	select {
+	case fileInfo := <-w.fileQueue:
+		assert.Equal(t, "event.tsv", fileInfo.Name)
+	case <-time.After(5 * time.Second):
+		t.Fatal("File not queued on fsnotify event")
+	}
+	w.Stop()
+}
+
+// ---------------------------------------------------------------------
+// Тесты backlog
+// ---------------------------------------------------------------------
+
+func TestScanDirectory_TracksBacklog(t *testing.T) {
+	w, watchDir, cleanup := setupTestWatcher(t)
+	defer cleanup()
+
+	createTestFile(t, watchDir, "data.tsv", "a\tb")
+	createTestFile(t, watchDir, "notes.txt", "hello")
+
+	w.scanDirectory()
+
+	entries := w.Backlog()
+	require.Len(t, entries, 2)
+	reasons := map[string]string{}
+	for _, e := range entries {
+		reasons[e.Name] = e.Reason
+	}
+	assert.Equal(t, ReasonQueued, reasons["data.tsv"])
+	assert.Equal(t, ReasonIgnored, reasons["notes.txt"])
+
+	stats := w.GetBacklogStats()
+	assert.Equal(t, 2, stats.Files)
+	assert.Equal(t, int64(len("a\tb")+len("hello")), stats.TotalBytes)
+	assert.Equal(t, 1, stats.ByReason[ReasonQueued])
+
+	// Файл обработан и перемещён - исчезает из backlog
+	<-w.fileQueue
+	require.NoError(t, os.Remove(filepath.Join(watchDir, "data.tsv")))
+	w.scanDirectory()
+	entries = w.Backlog()
+	require.Len(t, entries, 1)
+	assert.Equal(t, "notes.txt", entries[0].Name)
+}
+
+func TestProcessFile_NotReadyWhenSizeChanges(t *testing.T) {
+	w, watchDir, cleanup := setupTestWatcher(t)
+	defer cleanup()
+
+	path := createTestFile(t, watchDir, "growing.tsv", "a")
+	assert.Equal(t, ReasonQueued, w.processFile(path))
+	<-w.fileQueue
+
+	createTestFile(t, watchDir, "growing.tsv", "abc")
+	assert.Equal(t, ReasonNotReady, w.processFile(path))
+
+	select {
+	case <-w.fileQueue:
+		t.Fatal("Growing file should not be queued")
+	default:
+	}
+}
+
+func TestProcessFile_QueueFullReason(t *testing.T) {
+	tmpDir := t.TempDir()
+	w := NewWatcher(tmpDir, time.Second, 1)
+	defer w.Stop()
+
+	w.fileQueue <- FileInfo{Name: "occupied.tsv"}
+	path := createTestFile(t, tmpDir, "late.tsv", "data")
+
+	assert.Equal(t, ReasonQueueFull, w.processFile(path))
+	assert.Equal(t, ReasonQueueFull, w.Backlog()[0].Reason)
+}
+
+// ---------------------------------------------------------------------
+// Тест метрик очереди
+// ---------------------------------------------------------------------
+
+func TestQueueStats(t *testing.T) {
+	w := NewWatcher("/tmp", time.Second, 1)
+	defer w.Stop()
+
+	before := w.QueueStats()
+
+	require.NoError(t, w.SendToQueue(FileInfo{Name: "file1.tsv"}))
+	assert.Error(t, w.SendToQueue(FileInfo{Name: "file2.tsv"}))
+
+	stats := w.QueueStats()
+	assert.Equal(t, 1, stats.Depth)
+	assert.Equal(t, 1, stats.Capacity)
+	assert.Equal(t, before.Enqueued[QueueSourceAPI]+1, stats.Enqueued[QueueSourceAPI])
+	assert.Equal(t, before.Rejected[QueueSourceAPI]+1, stats.Rejected[QueueSourceAPI])
+
+	received := <-w.GetFileQueue()
+	assert.False(t, received.QueuedAt.IsZero())
+	Dequeued(received)
+
+	stats = w.QueueStats()
+	assert.Equal(t, 0, stats.Depth)
+	assert.Equal(t, before.Dequeued+1, stats.Dequeued)
+	assert.Equal(t, before.Wait.Count+1, stats.Wait.Count)
+}
+
+func TestScanDirectory_CountsSkippedFilesOnce(t *testing.T) {
+	w, watchDir, cleanup := setupTestWatcher(t)
+	defer cleanup()
+	w.SetIgnorePatterns([]string{"*.part.tsv"})
+
+	createTestFile(t, watchDir, "export.csv", "a,b")
+	createTestFile(t, watchDir, ".hidden.tsv", "a\tb")
+	createTestFile(t, watchDir, "upload.part.tsv", "a\tb")
+	createTestFile(t, watchDir, "growing.tsv", "a")
+
+	before := w.QueueStats().Skipped
+	w.scanDirectory()
+	<-w.fileQueue
+	createTestFile(t, watchDir, "growing.tsv", "abc")
+	w.scanDirectory()
+	w.scanDirectory() // повторное сканирование не увеличивает счётчики
+
+	skipped := w.QueueStats().Skipped
+	assert.Equal(t, before[SkipWrongExtension]+1, skipped[SkipWrongExtension])
+	assert.Equal(t, before[SkipHidden]+1, skipped[SkipHidden])
+	assert.Equal(t, before[SkipIgnored]+1, skipped[SkipIgnored])
+	assert.Equal(t, before[SkipTooNew]+1, skipped[SkipTooNew])
+	assert.Equal(t, 3, w.GetBacklogStats().ByReason[ReasonIgnored])
+}
+
+func TestScanDirectory_PausedKeepsFilesInBacklog(t *testing.T) {
+	w, watchDir, cleanup := setupTestWatcher(t)
+	defer cleanup()
+	paused := true
+	w.SetPaused(func() bool { return paused })
+
+	createTestFile(t, watchDir, "night.tsv", "a\tb")
+	w.scanDirectory()
+	assert.Empty(t, drainQueue(w))
+	reason, ok := backlogReason(w, "night.tsv")
+	require.True(t, ok)
+	assert.Equal(t, ReasonMaintenance, reason)
+
+	paused = false
+	w.scanDirectory()
+	queued := drainQueue(w)
+	require.Len(t, queued, 1)
+	assert.Equal(t, "night.tsv", queued[0].Name)
+}
+
+func TestScanDirectory_SandboxQueuesFileOnce(t *testing.T) {
+	w, watchDir, cleanup := setupTestWatcher(t)
+	defer cleanup()
+	w.SetSandbox(true)
+
+	path := createTestFile(t, watchDir, "mirror.tsv", "a\tb")
+	w.scanDirectory()
+	require.Len(t, drainQueue(w), 1)
+
+	// Файл остаётся в директории после обработки, но повторно не ставится
+	w.scanDirectory()
+	assert.Empty(t, drainQueue(w))
+
+	// Изменённый файл ставится снова
+	require.NoError(t, os.WriteFile(path, []byte("a\tb\nc\td"), 0644))
+	w.scanDirectory()
+	w.scanDirectory()
+	assert.Len(t, drainQueue(w), 1)
+}
+
+// ---------------------------------------------------------------------
+// Тест приоритетной очереди
+// ---------------------------------------------------------------------
+
+func TestPrioritize(t *testing.T) {
+	w, watchDir, cleanup := setupTestWatcher(t)
+	defer cleanup()
+
+	createTestFile(t, watchDir, "urgent.tsv", "data")
+	w.scanDirectory()
+	entry, ok := w.LookupBacklog("urgent.tsv")
+	require.True(t, ok)
+
+	require.NoError(t, w.Prioritize(FileInfo{Name: "urgent.tsv", Hash: "abc"}))
+	assert.Equal(t, 1, w.QueueStats().Prioritized)
+
+	fi := <-w.GetPriorityQueue()
+	assert.Equal(t, "urgent.tsv", fi.Name)
+	assert.True(t, fi.ArrivedAt.Equal(entry.FirstSeen))
+	assert.False(t, fi.QueuedAt.IsZero())
+
+	for i := 0; i < priorityQueueSize; i++ {
+		require.NoError(t, w.Prioritize(FileInfo{Name: "urgent.tsv"}))
+	}
+	assert.ErrorIs(t, w.Prioritize(FileInfo{Name: "urgent.tsv"}), ErrPriorityQueueFull)
+
+	w.Stop()
+	assert.Error(t, w.Prioritize(FileInfo{Name: "urgent.tsv"}))
+}
+
+func TestProcessFile_AppliesSidecar(t *testing.T) {
+	w, watchDir, cleanup := setupTestWatcher(t)
+	defer cleanup()
+
+	createTestFile(t, watchDir, "special.tsv", "data")
+	createTestFile(t, watchDir, "special.meta.json", `{"parser":"csv","charset":"windows-1251","lenient":true,"tenant":"ACME","priority":5}`)
+	createTestFile(t, watchDir, "plain.tsv", "data")
+	w.scanDirectory()
+
+	fi := <-w.GetPriorityQueue()
+	assert.Equal(t, "special.tsv", fi.Name)
+	assert.Equal(t, ingest.Options{Parser: ingest.ParserCSV, Charset: "windows-1251", Lenient: true}, fi.Options)
+	assert.Equal(t, "tenant:acme", fi.Source)
+	assert.Equal(t, int32(5), fi.Priority)
+
+	queued := drainQueue(w)
+	require.Len(t, queued, 1)
+	assert.Equal(t, "plain.tsv", queued[0].Name)
+	assert.True(t, queued[0].Options.IsZero())
+
+	// Сам sidecar не ставится в очередь
+	reason, ok := backlogReason(w, "special.meta.json")
+	require.True(t, ok)
+	assert.Equal(t, ReasonIgnored, reason)
+}
+
+func TestProcessFile_InvalidSidecarKeepsFile(t *testing.T) {
+	w, watchDir, cleanup := setupTestWatcher(t)
+	defer cleanup()
+
+	createTestFile(t, watchDir, "special.tsv", "data")
+	sidecar := createTestFile(t, watchDir, "special.meta.json", `{"parser":"xml"}`)
+	w.scanDirectory()
+	assert.Empty(t, drainQueue(w))
+	reason, ok := backlogReason(w, "special.tsv")
+	require.True(t, ok)
+	assert.Equal(t, ReasonError, reason)
+
+	// Неизвестные поля - тоже ошибка (опечатка в имени настройки)
+	require.NoError(t, os.WriteFile(sidecar, []byte(`{"lenent":true}`), 0644))
+	w.scanDirectory()
+	assert.Empty(t, drainQueue(w))
+
+	require.NoError(t, os.WriteFile(sidecar, []byte(`{"lenient":true}`), 0644))
+	w.scanDirectory()
+	queued := drainQueue(w)
+	require.Len(t, queued, 1)
+	assert.True(t, queued[0].Options.Lenient)
+}
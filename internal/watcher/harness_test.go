@@ -0,0 +1,220 @@
+package watcher
+
+import (
+	"TSVProcessingService/internal/clock"
+	"TSVProcessingService/internal/fsys"
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// ---------------------------------------------------------------------
+// Harness: медленная запись, переименование, перезапись и удаление
+// файла во время сканирования через ScanHooks
+// ---------------------------------------------------------------------
+
+// drainQueue забирает из очереди всё, что успел поставить watcher
+func drainQueue(w *Watcher) []FileInfo {
+	var queued []FileInfo
+	for {
+		select {
+		case fi := <-w.fileQueue:
+			queued = append(queued, fi)
+		default:
+			return queued
+		}
+	}
+}
+
+func backlogReason(w *Watcher, name string) (string, bool) {
+	for _, e := range w.Backlog() {
+		if e.Name == name {
+			return e.Reason, true
+		}
+	}
+	return "", false
+}
+
+func sha256Hex(content string) string {
+	sum := sha256.Sum256([]byte(content))
+	return hex.EncodeToString(sum[:])
+}
+
+func TestHarness_SlowWriterIsNotQueuedUntilStable(t *testing.T) {
+	w, watchDir, cleanup := setupTestWatcher(t)
+	defer cleanup()
+
+	path := createTestFile(t, watchDir, "slow.tsv", "n\tmqtt\n")
+	appended := false
+	w.SetScanHooks(ScanHooks{
+		AfterHash: func(name string) {
+			if appended {
+				return
+			}
+			appended = true
+			f, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0644)
+			require.NoError(t, err)
+			_, err = f.WriteString("1\tdev\n")
+			require.NoError(t, err)
+			require.NoError(t, f.Close())
+		},
+	})
+
+	w.ScanOnce()
+	assert.Empty(t, drainQueue(w), "file written during hashing must not be queued")
+	reason, ok := backlogReason(w, "slow.tsv")
+	require.True(t, ok)
+	assert.Equal(t, ReasonNotReady, reason)
+
+	w.ScanOnce()
+	queued := drainQueue(w)
+	require.Len(t, queued, 1)
+	assert.Equal(t, sha256Hex("n\tmqtt\n1\tdev\n"), queued[0].Hash)
+	assert.Equal(t, int64(len("n\tmqtt\n1\tdev\n")), queued[0].Size)
+}
+
+func TestHarness_RenameDuringScan(t *testing.T) {
+	w, watchDir, cleanup := setupTestWatcher(t)
+	defer cleanup()
+
+	createTestFile(t, watchDir, "upload.tsv", "data")
+	renamed := false
+	w.SetScanHooks(ScanHooks{
+		AfterList: func(names []string) {
+			if renamed {
+				return
+			}
+			renamed = true
+			require.NoError(t, os.Rename(
+				filepath.Join(watchDir, "upload.tsv"),
+				filepath.Join(watchDir, "final.tsv")))
+		},
+	})
+
+	w.ScanOnce()
+	assert.Empty(t, drainQueue(w))
+	_, ok := backlogReason(w, "upload.tsv")
+	assert.False(t, ok, "renamed file must not stay in backlog")
+	assert.Zero(t, w.GetBacklogStats().ByReason[ReasonError])
+
+	w.ScanOnce()
+	queued := drainQueue(w)
+	require.Len(t, queued, 1)
+	assert.Equal(t, "final.tsv", queued[0].Name)
+}
+
+func TestHarness_DeleteDuringScan(t *testing.T) {
+	w, watchDir, cleanup := setupTestWatcher(t)
+	defer cleanup()
+
+	path := createTestFile(t, watchDir, "gone.tsv", "data")
+	w.SetScanHooks(ScanHooks{
+		AfterStat: func(name string) {
+			require.NoError(t, os.Remove(path))
+		},
+	})
+
+	w.ScanOnce()
+	assert.Empty(t, drainQueue(w))
+	assert.Empty(t, w.Backlog())
+}
+
+func TestHarness_OverwriteDuringScan(t *testing.T) {
+	w, watchDir, cleanup := setupTestWatcher(t)
+	defer cleanup()
+
+	path := createTestFile(t, watchDir, "over.tsv", "old1")
+	w.SetScanHooks(ScanHooks{
+		AfterHash: func(name string) {
+			// Тот же размер, другое содержимое и время модификации
+			require.NoError(t, os.WriteFile(path, []byte("new2"), 0644))
+			later := time.Now().Add(time.Minute)
+			require.NoError(t, os.Chtimes(path, later, later))
+		},
+	})
+
+	w.ScanOnce()
+	assert.Empty(t, drainQueue(w), "hash of overwritten file must not be queued")
+	reason, _ := backlogReason(w, "over.tsv")
+	assert.Equal(t, ReasonNotReady, reason)
+
+	w.SetScanHooks(ScanHooks{})
+	w.ScanOnce()
+	queued := drainQueue(w)
+	require.Len(t, queued, 1)
+	assert.Equal(t, sha256Hex("new2"), queued[0].Hash)
+}
+
+func TestHarness_MinFileAgeWithFakeClock(t *testing.T) {
+	w, watchDir, cleanup := setupTestWatcher(t)
+	defer cleanup()
+
+	path := createTestFile(t, watchDir, "fresh.tsv", "data")
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+
+	fake := clock.NewFake(info.ModTime().Add(time.Second))
+	w.SetClock(fake)
+	w.SetMinFileAge(10 * time.Second)
+
+	w.ScanOnce()
+	assert.Empty(t, drainQueue(w))
+	reason, _ := backlogReason(w, "fresh.tsv")
+	assert.Equal(t, ReasonNotReady, reason)
+
+	fake.Advance(10 * time.Second)
+	w.ScanOnce()
+	queued := drainQueue(w)
+	require.Len(t, queued, 1)
+	assert.Equal(t, "fresh.tsv", queued[0].Name)
+}
+
+func TestHarness_TickerWithFakeClockAndMemFS(t *testing.T) {
+	fake := clock.NewFake(time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC))
+	mem := fsys.NewMem(fake.Now)
+	require.NoError(t, mem.MkdirAll("/watch", 0755))
+
+	w := NewWatcher("/watch", 10*time.Second, 10)
+	w.SetClock(fake)
+	w.SetFS(mem)
+	w.SetMinFileAge(25 * time.Second)
+
+	scanned := make(chan struct{}, 10)
+	w.SetHeartbeat(func() { scanned <- struct{}{} })
+	go w.Start()
+	defer w.Stop()
+
+	tick := func() {
+		fake.Advance(10 * time.Second)
+		select {
+		case <-scanned:
+		case <-time.After(2 * time.Second):
+			t.Fatal("scan did not happen")
+		}
+	}
+
+	<-scanned
+	fake.BlockUntil(1) // тикер заведён
+	mem.WriteFile("/watch/mem.tsv", []byte("n\tmqtt\n"))
+
+	// Два срабатывания тикера файл моложе min_file_age
+	for i := 0; i < 2; i++ {
+		tick()
+		assert.Empty(t, drainQueue(w))
+		reason, _ := backlogReason(w, "mem.tsv")
+		assert.Equal(t, ReasonNotReady, reason)
+	}
+
+	tick()
+	queued := drainQueue(w)
+	require.Len(t, queued, 1)
+	assert.Equal(t, "mem.tsv", queued[0].Name)
+	assert.Equal(t, sha256Hex("n\tmqtt\n"), queued[0].Hash)
+	assert.Equal(t, fake.Now(), queued[0].QueuedAt)
+}
@@ -0,0 +1,66 @@
+// internal/watcher/notify.go
+package watcher
+
+import (
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// Режимы обнаружения новых файлов (watcher.mode)
+const (
+	ModePoll     = "poll"     // сканирование раз в scan_interval
+	ModeFSNotify = "fsnotify" // сканирование по событиям файловой системы
+)
+
+// Modes - допустимые режимы
+var Modes = []string{ModePoll, ModeFSNotify}
+
+// defaultDebounce - ожидание после события до сканирования
+const defaultDebounce = 500 * time.Millisecond
+
+// SetMode задаёт режим обнаружения файлов. В режиме fsnotify директория
+// сканируется через debounce после создания или записи файла (серия
+// событий - одно сканирование), а не только раз в scan_interval.
+// Периодическое сканирование сохраняется: оно находит файлы, которые
+// дописывались во время сканирования, и файлы на файловых системах без
+// событий (NFS, SMB). debounce <= 0 - значение по умолчанию. Должна быть
+// вызвана до Start.
+func (w *Watcher) SetMode(mode string, debounce time.Duration) {
+	w.mode = mode
+	w.debounce = debounce
+	if w.debounce <= 0 {
+		w.debounce = defaultDebounce
+	}
+}
+
+// startNotify подписывается на события watch-директории. nil - режим
+// poll или события недоступны (остаётся опрос).
+func (w *Watcher) startNotify() *fsnotify.Watcher {
+	if w.mode != ModeFSNotify {
+		return nil
+	}
+	notifier, err := fsnotify.NewWatcher()
+	if err != nil {
+		w.logger.Warn("fsnotify unavailable, falling back to polling", "interval", w.interval, "error", err)
+		return nil
+	}
+	if err := notifier.Add(w.watchDir); err != nil {
+		notifier.Close()
+		w.logger.Warn("Cannot watch directory for events, falling back to polling", "dir", w.watchDir,
+			"interval", w.interval, "error", err)
+		return nil
+	}
+	w.logger.Info("🔔 Watching directory for file events", "dir", w.watchDir, "debounce", w.debounce)
+	return notifier
+}
+
+// triggersScan сообщает, что событие может означать новый файл
+func triggersScan(event fsnotify.Event) bool {
+	if strings.HasPrefix(filepath.Base(event.Name), ".") {
+		return false
+	}
+	return event.Has(fsnotify.Create) || event.Has(fsnotify.Write)
+}
@@ -0,0 +1,77 @@
+// internal/watcher/priority.go
+package watcher
+
+import (
+	"errors"
+)
+
+// priorityQueueSize - ёмкость приоритетной очереди: это ручное действие
+// оператора для отдельных файлов, а не обходной путь для всего backlog
+const priorityQueueSize = 16
+
+// PriorityHigh - приоритет файлов, поставленных через Prioritize
+const PriorityHigh = 1
+
+// ErrPriorityQueueFull - приоритетная очередь заполнена
+var ErrPriorityQueueFull = errors.New("priority queue is full")
+
+// Prioritize ставит файл в начало порядка обработки: в приоритетную
+// очередь, которую воркеры читают раньше основной. Копия файла, уже
+// стоящая в основной очереди, будет пропущена процессором как
+// обработанная. Время поступления берётся из backlog.
+func (w *Watcher) Prioritize(fileInfo FileInfo) error {
+	w.backlogMu.Lock()
+	if entry, ok := w.backlog[fileInfo.Name]; ok {
+		fileInfo.ArrivedAt = entry.FirstSeen
+		entry.Reason = ReasonQueued
+		entry.Hash = fileInfo.Hash
+	}
+	w.backlogMu.Unlock()
+
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	if w.closed {
+		return ErrStopped
+	}
+
+	fileInfo.Priority = PriorityHigh
+	w.markQueued(&fileInfo)
+	select {
+	case w.priorityQueue <- fileInfo:
+		w.logger.Info("Prioritized file", "file", fileInfo.Name)
+		queueEnqueued.Inc(QueueSourceAPI)
+		return nil
+	default:
+		queueRejected.Inc(QueueSourceAPI)
+		return ErrPriorityQueueFull
+	}
+}
+
+// queuePriority ставит файл с приоритетом из sidecar-файла в приоритетную
+// очередь без ожидания; false - очередь заполнена (файл идёт в основную)
+func (w *Watcher) queuePriority(fileInfo FileInfo) bool {
+	select {
+	case w.priorityQueue <- fileInfo:
+		w.logger.Info("Queued file with priority", "file", fileInfo.Name, "priority", fileInfo.Priority)
+		return true
+	default:
+		return false
+	}
+}
+
+// GetPriorityQueue возвращает приоритетную очередь (см. Prioritize).
+// Закрывается вместе с основной очередью.
+func (w *Watcher) GetPriorityQueue() <-chan FileInfo {
+	return w.priorityQueue
+}
+
+// LookupBacklog возвращает запись backlog файла
+func (w *Watcher) LookupBacklog(name string) (BacklogEntry, bool) {
+	w.backlogMu.Lock()
+	defer w.backlogMu.Unlock()
+	entry, ok := w.backlog[name]
+	if !ok {
+		return BacklogEntry{}, false
+	}
+	return *entry, true
+}
@@ -0,0 +1,102 @@
+// internal/watcher/queue_metrics.go
+package watcher
+
+import (
+	"TSVProcessingService/internal/metrics"
+	"time"
+)
+
+// Кто поставил файл в очередь
+const (
+	QueueSourceWatcher = "watcher" // сканирование watch-директории
+	QueueSourceAPI     = "api"     // SendToQueue (process, process-batch)
+	QueueSourceRetry   = "retry"   // Requeue: повтор после временной ошибки
+)
+
+// Причины, по которым файл не поставлен в очередь (tsv_watcher_skipped_total)
+const (
+	SkipWrongExtension   = "wrong_extension"   // не .tsv
+	SkipHidden           = "hidden"            // имя начинается с "."
+	SkipTooNew           = "too_new"           // файл ещё дописывается
+	SkipIgnored          = "ignored"           // подходит под directory.ignore_patterns
+	SkipAlreadyProcessed = "already_processed" // файл с таким именем уже обработан
+	SkipSidecar          = "sidecar"           // настройки обработки файла (<name>.meta.json)
+	SkipRetryPending     = "retry_pending"     // повторная обработка назначена на более позднее время
+	SkipDuplicateContent = "duplicate_content" // файл с таким содержимым уже обработан под другим именем
+)
+
+var skipReasons = []string{SkipWrongExtension, SkipHidden, SkipTooNew, SkipIgnored, SkipAlreadyProcessed, SkipSidecar, SkipRetryPending, SkipDuplicateContent}
+
+// Бакеты ожидания в очереди: от 10 мс до ~5.5 минут
+var queueWaitBuckets = metrics.ExponentialBuckets(0.01, 2, 16)
+
+var (
+	queueEnqueued = metrics.Default.NewCounter("tsv_queue_enqueued_total",
+		"Files put into the processing queue", "source")
+	queueRejected = metrics.Default.NewCounter("tsv_queue_rejected_total",
+		"Files not queued because the processing queue was full", "source")
+	queueDequeued = metrics.Default.NewCounter("tsv_queue_dequeued_total",
+		"Files picked up from the processing queue by workers")
+	queueWait = metrics.Default.NewHistogram("tsv_queue_wait_seconds",
+		"Time between enqueue and worker pickup", queueWaitBuckets)
+	filesSkipped = metrics.Default.NewCounter("tsv_watcher_skipped_total",
+		"Files found in the watch directory but not ingested", "reason")
+)
+
+// QueueStats - счётчики очереди обработки для /admin/queue
+type QueueStats struct {
+	Depth          int                       `json:"depth"`
+	Prioritized    int                       `json:"prioritized"` // в приоритетной очереди
+	Capacity       int                       `json:"capacity"`
+	Enqueued       map[string]float64        `json:"enqueued"`
+	Rejected       map[string]float64        `json:"rejected"`
+	Dequeued       float64                   `json:"dequeued"`
+	AvgWaitSeconds float64                   `json:"avg_wait_seconds"`
+	Wait           metrics.HistogramSnapshot `json:"wait_seconds"`
+	Skipped        map[string]float64        `json:"skipped"` // файлы, не поставленные в очередь, по причинам
+}
+
+// markQueued отмечает постановку файла в очередь
+func (w *Watcher) markQueued(fileInfo *FileInfo) {
+	fileInfo.QueuedAt = w.clock.Now()
+}
+
+// Dequeued учитывает, что воркер взял файл из очереди: счётчик
+// и время ожидания с момента постановки.
+func Dequeued(fileInfo FileInfo) {
+	queueDequeued.Inc()
+	if !fileInfo.QueuedAt.IsZero() {
+		queueWait.Observe(time.Since(fileInfo.QueuedAt).Seconds())
+	}
+}
+
+// Skipped учитывает файл, пропущенный по причине reason (Skip*).
+// Каждый файл учитывается один раз.
+func Skipped(reason string) {
+	filesSkipped.Inc(reason)
+}
+
+// QueueStats возвращает текущую глубину очереди и накопленные счётчики.
+func (w *Watcher) QueueStats() QueueStats {
+	stats := QueueStats{
+		Depth:       len(w.fileQueue),
+		Prioritized: len(w.priorityQueue),
+		Capacity:    cap(w.fileQueue),
+		Enqueued:    make(map[string]float64),
+		Rejected:    make(map[string]float64),
+		Dequeued:    queueDequeued.Value(),
+		Wait:        queueWait.Snapshot(),
+		Skipped:     make(map[string]float64, len(skipReasons)),
+	}
+	for _, source := range []string{QueueSourceWatcher, QueueSourceAPI, QueueSourceRetry} {
+		stats.Enqueued[source] = queueEnqueued.Value(source)
+		stats.Rejected[source] = queueRejected.Value(source)
+	}
+	for _, reason := range skipReasons {
+		stats.Skipped[reason] = filesSkipped.Value(reason)
+	}
+	if stats.Wait.Count > 0 {
+		stats.AvgWaitSeconds = stats.Wait.Sum / float64(stats.Wait.Count)
+	}
+	return stats
+}
@@ -0,0 +1,61 @@
+// internal/watcher/scan_hooks.go
+package watcher
+
+import (
+	"TSVProcessingService/internal/clock"
+	"TSVProcessingService/internal/fsys"
+	"log/slog"
+	"time"
+)
+
+// ScanHooks - точки вмешательства в сканирование для интеграционных
+// тестов: позволяют детерминированно эмулировать медленную запись,
+// переименование, перезапись и удаление файла во время сканирования.
+// Все поля необязательны.
+type ScanHooks struct {
+	AfterList func(names []string) // после чтения директории, до обработки файлов
+	AfterStat func(name string)    // между stat и вычислением хеша
+	AfterHash func(name string)    // после вычисления хеша, до повторной проверки файла
+}
+
+// SetScanHooks задаёт hooks сканирования. Должна быть вызвана до Start.
+func (w *Watcher) SetScanHooks(hooks ScanHooks) {
+	w.hooks = hooks
+}
+
+// SetClock подменяет часы: проверка min_file_age, время обнаружения
+// файлов, тикер сканирования. Должна быть вызвана до Start.
+func (w *Watcher) SetClock(c clock.Clock) {
+	w.clock = c
+}
+
+// SetFS подменяет файловую систему watch-директории (чтение директории,
+// stat и хеширование файлов). Должна быть вызвана до Start.
+func (w *Watcher) SetFS(fs fsys.FS) {
+	w.fs = fs
+}
+
+// SetMinFileAge задаёт минимальный возраст файла (от времени модификации),
+// после которого он ставится в очередь: защита от файлов, которые ещё
+// дописываются, но не меняли размер между сканированиями.
+func (w *Watcher) SetMinFileAge(age time.Duration) {
+	w.minFileAge = age
+}
+
+// SetSandbox включает режим sandbox: процессор не перемещает исходные
+// файлы, поэтому поставленный в очередь файл не ставится повторно, пока
+// не изменится. Должна быть вызвана до Start.
+func (w *Watcher) SetSandbox(enabled bool) {
+	w.sandbox = enabled
+}
+
+// SetLogger задаёт журнал watcher (по умолчанию - журнал slog по
+// умолчанию с component=watcher). Должна быть вызвана до Start.
+func (w *Watcher) SetLogger(l *slog.Logger) {
+	w.logger = l
+}
+
+// ScanOnce выполняет одно сканирование синхронно (без цикла Start).
+func (w *Watcher) ScanOnce() {
+	w.scanDirectory()
+}
@@ -0,0 +1,98 @@
+// internal/watcher/sidecar.go
+package watcher
+
+import (
+	"TSVProcessingService/internal/ingest"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// SidecarSuffix - суффикс файла настроек обработки: для data.tsv -
+// data.meta.json в той же директории
+const SidecarSuffix = ".meta.json"
+
+// maxSidecarSize - предел размера файла настроек
+const maxSidecarSize = 64 << 10
+
+// Sidecar - настройки обработки отдельного файла вместо общей
+// конфигурации. Файл настроек должен появиться в директории раньше
+// файла данных (или одновременно с ним): читается при постановке файла
+// в очередь и перемещается процессором вместе с файлом.
+type Sidecar struct {
+	ingest.Options
+
+	Tenant   string `json:"tenant,omitempty"`   // источник "tenant:<tenant>" вместо определённого по имени
+	Priority int32  `json:"priority,omitempty"` // > 0 - файл ставится в приоритетную очередь
+}
+
+// SidecarPath - путь к файлу настроек файла данных
+func SidecarPath(filePath string) string {
+	return strings.TrimSuffix(filePath, filepath.Ext(filePath)) + SidecarSuffix
+}
+
+// IsSidecar сообщает, что имя - файл настроек
+func IsSidecar(name string) bool {
+	return strings.HasSuffix(strings.ToLower(name), SidecarSuffix)
+}
+
+// Validate проверяет настройки
+func (s Sidecar) Validate() error {
+	if err := s.Options.Validate(); err != nil {
+		return err
+	}
+	if strings.ContainsAny(s.Tenant, ":/\\ \t") {
+		return fmt.Errorf("tenant must not contain ':', '/', '\\' or spaces")
+	}
+	if s.Priority < 0 {
+		return fmt.Errorf("priority must not be negative")
+	}
+	return nil
+}
+
+// apply переносит настройки в задание обработки
+func (s Sidecar) apply(fileInfo *FileInfo) {
+	fileInfo.Options = s.Options
+	if s.Tenant != "" {
+		fileInfo.Source = "tenant:" + strings.ToLower(s.Tenant)
+	}
+	if s.Priority > 0 {
+		fileInfo.Priority = s.Priority
+	}
+}
+
+// readSidecar читает настройки файла данных. ok=false - файла настроек нет;
+// ошибка - файл есть, но некорректен (файл данных не ставится в очередь,
+// чтобы не обработать его с общими настройками).
+func (w *Watcher) readSidecar(filePath string) (Sidecar, bool, error) {
+	var s Sidecar
+	f, err := w.fs.Open(SidecarPath(filePath))
+	if os.IsNotExist(err) {
+		return s, false, nil
+	}
+	if err != nil {
+		return s, false, err
+	}
+	defer f.Close()
+
+	data, err := io.ReadAll(io.LimitReader(f, maxSidecarSize+1))
+	if err != nil {
+		return s, false, err
+	}
+	if len(data) > maxSidecarSize {
+		return s, false, fmt.Errorf("%s is larger than %d bytes", filepath.Base(SidecarPath(filePath)), maxSidecarSize)
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&s); err != nil {
+		return s, false, fmt.Errorf("invalid %s: %w", filepath.Base(SidecarPath(filePath)), err)
+	}
+	if err := s.Validate(); err != nil {
+		return s, false, fmt.Errorf("invalid %s: %w", filepath.Base(SidecarPath(filePath)), err)
+	}
+	return s, true, nil
+}
@@ -0,0 +1,133 @@
+// internal/watcher/state.go
+package watcher
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// stateEntry - запись файла состояния: файл, найденный, но ещё не обработанный
+type stateEntry struct {
+	Path      string    `json:"path"`
+	Hash      string    `json:"hash,omitempty"`
+	FirstSeen time.Time `json:"first_seen"`
+	Size      int64     `json:"size"`
+	ModTime   time.Time `json:"mod_time"`
+	Reason    string    `json:"reason"`
+}
+
+// SetStateFile задаёт файл, в котором backlog сохраняется после каждого
+// сканирования. Должна быть вызвана до LoadState и Start.
+func (w *Watcher) SetStateFile(path string) {
+	w.stateFile = path
+}
+
+// LoadState восстанавливает backlog из файла состояния: время первого
+// обнаружения (порядок обработки) и хеш файлов, которые ещё лежат в
+// watch-директории. Возвращает количество восстановленных файлов;
+// отсутствие файла состояния - не ошибка.
+func (w *Watcher) LoadState() (int, error) {
+	if w.stateFile == "" {
+		return 0, nil
+	}
+	data, err := os.ReadFile(w.stateFile)
+	if errors.Is(err, fs.ErrNotExist) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to read watcher state: %w", err)
+	}
+
+	var entries []stateEntry
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return 0, fmt.Errorf("failed to parse watcher state %s: %w", w.stateFile, err)
+	}
+
+	w.backlogMu.Lock()
+	defer w.backlogMu.Unlock()
+
+	for _, e := range entries {
+		name := filepath.Base(e.Path)
+		// Файл обработан (перемещён), пока сервис был остановлен
+		if _, err := w.fs.Stat(filepath.Join(w.watchDir, name)); err != nil {
+			continue
+		}
+		w.backlog[name] = &BacklogEntry{
+			Name:      name,
+			Size:      e.Size,
+			ModTime:   e.ModTime,
+			FirstSeen: e.FirstSeen,
+			Reason:    e.Reason,
+			Hash:      e.Hash,
+			restored:  true,
+		}
+	}
+	w.restored = len(w.backlog)
+	w.lastState = data
+	return w.restored, nil
+}
+
+// saveState записывает backlog в файл состояния (атомарно, через
+// временный файл). Файл перезаписывается, только если backlog изменился.
+func (w *Watcher) saveState() {
+	if w.stateFile == "" {
+		return
+	}
+
+	w.backlogMu.Lock()
+	entries := make([]stateEntry, 0, len(w.backlog))
+	for _, e := range w.backlog {
+		entries = append(entries, stateEntry{
+			Path:      filepath.Join(w.watchDir, e.Name),
+			Hash:      e.Hash,
+			FirstSeen: e.FirstSeen,
+			Size:      e.Size,
+			ModTime:   e.ModTime,
+			Reason:    e.Reason,
+		})
+	}
+	w.backlogMu.Unlock()
+
+	sort.Slice(entries, func(i, j int) bool {
+		if !entries[i].FirstSeen.Equal(entries[j].FirstSeen) {
+			return entries[i].FirstSeen.Before(entries[j].FirstSeen)
+		}
+		return entries[i].Path < entries[j].Path
+	})
+
+	data, err := json.MarshalIndent(entries, "", "  ")
+	if err != nil {
+		w.logger.Error("Failed to encode state", "error", err)
+		return
+	}
+	if bytes.Equal(data, w.lastState) {
+		return
+	}
+
+	if err := os.MkdirAll(filepath.Dir(w.stateFile), 0755); err != nil {
+		w.logger.Error("Failed to save state", "path", w.stateFile, "error", err)
+		return
+	}
+	tmp := w.stateFile + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		w.logger.Error("Failed to save state", "path", w.stateFile, "error", err)
+		return
+	}
+	if err := os.Rename(tmp, w.stateFile); err != nil {
+		w.logger.Error("Failed to save state", "path", w.stateFile, "error", err)
+		return
+	}
+	w.lastState = data
+}
+
+// RestoredBacklog - количество файлов backlog, восстановленных при запуске
+func (w *Watcher) RestoredBacklog() int {
+	return w.restored
+}
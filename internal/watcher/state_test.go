@@ -0,0 +1,100 @@
+package watcher
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// ---------------------------------------------------------------------
+// Тесты сохранения backlog между перезапусками
+// ---------------------------------------------------------------------
+
+func TestState_RestoresBacklogAfterRestart(t *testing.T) {
+	w, watchDir, cleanup := setupTestWatcher(t)
+	defer cleanup()
+	stateFile := filepath.Join(t.TempDir(), "state", "watcher.json")
+	w.SetStateFile(stateFile)
+
+	createTestFile(t, watchDir, "b.tsv", "second")
+	w.ScanOnce()
+	time.Sleep(10 * time.Millisecond)
+	createTestFile(t, watchDir, "a.tsv", "first-later")
+	w.ScanOnce()
+	drainQueue(w)
+
+	before := make(map[string]BacklogEntry)
+	for _, e := range w.Backlog() {
+		before[e.Name] = e
+	}
+	require.Len(t, before, 2)
+	require.FileExists(t, stateFile)
+
+	// Перезапуск: новый watcher на той же директории
+	restarted := NewWatcher(watchDir, time.Second, 10)
+	defer restarted.Stop()
+	restarted.SetStateFile(stateFile)
+	n, err := restarted.LoadState()
+	require.NoError(t, err)
+	assert.Equal(t, 2, n)
+	assert.Equal(t, 2, restarted.GetBacklogStats().Restored)
+
+	restarted.ScanOnce()
+	queued := drainQueue(restarted)
+	require.Len(t, queued, 2)
+	// Порядок - по первому обнаружению, а не по имени
+	assert.Equal(t, "b.tsv", queued[0].Name)
+	assert.Equal(t, "a.tsv", queued[1].Name)
+	for _, fi := range queued {
+		assert.True(t, fi.ArrivedAt.Equal(before[fi.Name].FirstSeen), "first_seen must survive restart")
+		assert.Equal(t, before[fi.Name].Hash, fi.Hash)
+	}
+	assert.Equal(t, 2, restarted.requeued)
+}
+
+func TestState_SkipsFilesProcessedWhileStopped(t *testing.T) {
+	w, watchDir, cleanup := setupTestWatcher(t)
+	defer cleanup()
+	stateFile := filepath.Join(t.TempDir(), "watcher.json")
+	w.SetStateFile(stateFile)
+
+	path := createTestFile(t, watchDir, "done.tsv", "data")
+	createTestFile(t, watchDir, "pending.tsv", "data")
+	w.ScanOnce()
+	require.NoError(t, os.Remove(path))
+
+	restarted := NewWatcher(watchDir, time.Second, 10)
+	defer restarted.Stop()
+	restarted.SetStateFile(stateFile)
+	n, err := restarted.LoadState()
+	require.NoError(t, err)
+	assert.Equal(t, 1, n)
+	require.Len(t, restarted.Backlog(), 1)
+	assert.Equal(t, "pending.tsv", restarted.Backlog()[0].Name)
+}
+
+func TestState_MissingFileIsNotAnError(t *testing.T) {
+	w, _, cleanup := setupTestWatcher(t)
+	defer cleanup()
+	w.SetStateFile(filepath.Join(t.TempDir(), "absent.json"))
+
+	n, err := w.LoadState()
+	require.NoError(t, err)
+	assert.Zero(t, n)
+}
+
+func TestState_CorruptFile(t *testing.T) {
+	w, _, cleanup := setupTestWatcher(t)
+	defer cleanup()
+	stateFile := filepath.Join(t.TempDir(), "watcher.json")
+	require.NoError(t, os.WriteFile(stateFile, []byte("{not json"), 0644))
+	w.SetStateFile(stateFile)
+
+	_, err := w.LoadState()
+	assert.Error(t, err)
+	assert.Empty(t, w.Backlog())
+}